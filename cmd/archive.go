@@ -0,0 +1,238 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// archiveOptions encapsulates the possible options which can be used to change the behavior of the 'archive'
+// sub-commands.
+var archiveOptions = struct {
+	configPath string
+	jsonOut    bool
+	runs       []string
+	all        bool
+}{}
+
+// archiveCommand is the archive sub-command, used to manage the benchmark repositories created in the archive.
+var archiveCommand = &cobra.Command{
+	Short: "manage the benchmark repositories created by cbtools-autobench in the archive on the backup client",
+	Use:   "archive",
+}
+
+// archiveListCommand is the archive list sub-command, used to list the benchmark repositories in the archive.
+var archiveListCommand = &cobra.Command{
+	RunE:  archiveList,
+	Short: "list the benchmark repositories created by cbtools-autobench in the archive, and the runs which created them",
+	Use:   "list",
+}
+
+// archiveDeleteCommand is the archive delete sub-command, used to delete benchmark repositories from the archive.
+var archiveDeleteCommand = &cobra.Command{
+	RunE:  archiveDelete,
+	Short: "delete the benchmark repositories created by the given runs from the archive",
+	Use:   "delete",
+}
+
+// init the flags/arguments for the archive sub-commands.
+func init() {
+	for _, command := range []*cobra.Command{archiveListCommand, archiveDeleteCommand} {
+		command.Flags().StringVarP(
+			&archiveOptions.configPath,
+			"config",
+			"c",
+			"",
+			"path to a cbtools-autobench config file",
+		)
+
+		markFlagRequired(command, "config")
+	}
+
+	archiveListCommand.Flags().BoolVarP(
+		&archiveOptions.jsonOut,
+		"json",
+		"j",
+		false,
+		"JSON format list of repositories",
+	)
+
+	archiveDeleteCommand.Flags().StringSliceVar(
+		&archiveOptions.runs,
+		"run",
+		nil,
+		"the id of a run whose repository will be deleted, may be provided multiple times",
+	)
+
+	archiveDeleteCommand.Flags().BoolVar(
+		&archiveOptions.all,
+		"all",
+		false,
+		"delete every repository created by cbtools-autobench",
+	)
+
+	archiveCommand.AddCommand(archiveListCommand, archiveDeleteCommand)
+}
+
+// archiveList sub-command, this will list the benchmark repositories created by 'cbtools-autobench' in the archive of
+// each environment.
+func archiveList(_ *cobra.Command, _ []string) error {
+	config, err := readArchiveConfig(archiveOptions.configPath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		lock         sync.Mutex
+		repositories = make(map[string]value.ArchiveRepositories)
+	)
+
+	err = forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return withBackupClient(config, func(client *nodes.BackupClient) error {
+			repos, err := client.Repositories(config.BenchmarkConfig.CBMConfig)
+			if err != nil {
+				return errors.Wrap(err, "failed to list repositories")
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			repositories[config.Blueprint.Name] = repos
+
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if archiveOptions.jsonOut {
+		data, err := json.Marshal(repositories)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal repositories")
+		}
+
+		fmt.Printf("%s\n", data)
+
+		return nil
+	}
+
+	environments := make([]string, 0, len(repositories))
+	for environment := range repositories {
+		environments = append(environments, environment)
+	}
+
+	sort.Strings(environments)
+
+	for _, environment := range environments {
+		if environment != "" {
+			fmt.Printf("| Environment\n| -----------\n| %s\n\n", environment)
+		}
+
+		fmt.Printf("%s\n\n", repositories[environment])
+	}
+
+	return nil
+}
+
+// archiveDelete sub-command, this will delete the benchmark repositories created by the given runs from the archive of
+// each environment.
+func archiveDelete(_ *cobra.Command, _ []string) error {
+	if len(archiveOptions.runs) == 0 && !archiveOptions.all {
+		return errors.New("either '--run' or '--all' must be provided")
+	}
+
+	config, err := readArchiveConfig(archiveOptions.configPath)
+	if err != nil {
+		return err
+	}
+
+	var hosts []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		hosts = append(hosts, blueprint.BackupClient.Host)
+	}
+
+	err = confirm(destructiveAction{
+		description: fmt.Sprintf("delete the repositories created by the selected runs from the archive '%s'",
+			config.BenchmarkConfig.CBMConfig.Archive),
+		hosts: hosts,
+	})
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[value.RunID]bool, len(archiveOptions.runs))
+	for _, id := range archiveOptions.runs {
+		selected[value.RunID(id)] = true
+	}
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return withBackupClient(config, func(client *nodes.BackupClient) error {
+			repos, err := client.Repositories(config.BenchmarkConfig.CBMConfig)
+			if err != nil {
+				return errors.Wrap(err, "failed to list repositories")
+			}
+
+			for _, repo := range repos {
+				if !archiveOptions.all && !selected[repo.RunID] {
+					continue
+				}
+
+				err = client.RemoveRepository(config.BenchmarkConfig.CBMConfig, repo.Name)
+				if err != nil {
+					return errors.Wrapf(err, "failed to delete repository '%s'", repo.Name)
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+// readArchiveConfig reads the autobench config at the given path, validating that it describes the archive.
+func readArchiveConfig(path string) (*value.AutobenchConfig, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read autobench config")
+	}
+
+	if config.BenchmarkConfig == nil || config.BenchmarkConfig.CBMConfig == nil ||
+		config.BenchmarkConfig.CBMConfig.Archive == "" {
+		return nil, errors.New("the config must contain the 'cbbackupmgr_config' archive")
+	}
+
+	return config, nil
+}
+
+// withBackupClient connects to the backup client in the given config, then runs the given function.
+func withBackupClient(config *value.AutobenchConfig, fn func(client *nodes.BackupClient) error) error {
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	return fn(client)
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesl33/cbtools-autobench/inventory"
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// bakeOptions encapsulates the possible options which can be used to change the behavior of the 'bake' sub-command.
+var bakeOptions = struct {
+	configPath string
+
+	// target is the machine which will be baked, either 'cluster' (the first cluster node) or 'backup_client'.
+	target string
+
+	name     string
+	region   string
+	profile  string
+	noReboot bool
+}{}
+
+// bakeCommand is the bake sub-command, used to create an image with the dependencies/package pre-installed.
+var bakeCommand = &cobra.Command{
+	RunE:  bake,
+	Short: "install the dependencies/package on a machine and create an image from it, cutting provisioning time",
+	Use:   "bake",
+}
+
+// init the flags/arguments for the bake sub-command.
+func init() {
+	bakeCommand.Flags().StringVarP(
+		&bakeOptions.configPath,
+		"config",
+		"c",
+		"",
+		"path to a cbtools-autobench config file",
+	)
+
+	bakeCommand.Flags().StringVar(
+		&bakeOptions.target,
+		"target",
+		"cluster",
+		"the machine to bake, either 'cluster' (the first cluster node) or 'backup_client'",
+	)
+
+	bakeCommand.Flags().StringVar(
+		&bakeOptions.name,
+		"name",
+		"",
+		"the name of the created image (defaults to 'cbtools-autobench-<run id>')",
+	)
+
+	bakeCommand.Flags().StringVar(
+		&bakeOptions.region,
+		"region",
+		"",
+		"the region passed to the 'aws' CLI",
+	)
+
+	bakeCommand.Flags().StringVar(
+		&bakeOptions.profile,
+		"profile",
+		"",
+		"the profile passed to the 'aws' CLI",
+	)
+
+	bakeCommand.Flags().BoolVar(
+		&bakeOptions.noReboot,
+		"no-reboot",
+		false,
+		"create the image without rebooting the instance, the file system may be inconsistent",
+	)
+
+	markFlagRequired(bakeCommand, "config")
+}
+
+// bake sub-command, this will install the dependencies/package on the target machine then create an image from it;
+// machines launched from the image skip installing them when provisioned with the same package.
+func bake(_ *cobra.Command, _ []string) error {
+	if bakeOptions.target != "cluster" && bakeOptions.target != "backup_client" {
+		return fmt.Errorf("unsupported target '%s', expected 'cluster' or 'backup_client'", bakeOptions.target)
+	}
+
+	config, err := readConfig(bakeOptions.configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	actions := []destructiveAction{{
+		description: "uninstall Couchbase Server, removing all of its data",
+		hosts:       clientHosts(config),
+	}}
+
+	if bakeOptions.target == "cluster" {
+		actions = connectActions(config)
+
+		for _, blueprint := range config.Blueprint.Split() {
+			actions = append(actions, destructiveAction{
+				description: "uninstall Couchbase Server, removing all of its data",
+				hosts:       []string{blueprint.Cluster.Nodes[0].Host},
+			})
+		}
+	}
+
+	err = confirm(actions...)
+	if err != nil {
+		return err
+	}
+
+	ctx := signalHandler()
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return bakeEnvironment(ctx, config)
+	})
+}
+
+// bakeEnvironment bakes the target machine in the given config, then creates an image from it. Cancelling the context
+// kills any running remote commands, the image isn't created.
+func bakeEnvironment(ctx context.Context, config *value.AutobenchConfig) error {
+	var (
+		instanceID string
+		err        error
+	)
+
+	if bakeOptions.target == "cluster" {
+		instanceID, err = bakeCluster(ctx, config)
+	} else {
+		instanceID, err = bakeBackupClient(ctx, config)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to bake machine")
+	}
+
+	image, err := inventory.CreateImage(bakeConfig(config.Blueprint), instanceID, dryRun)
+	if err != nil {
+		return errors.Wrap(err, "failed to create image")
+	}
+
+	log.WithFields(log.Fields{"target": bakeOptions.target, "image": image}).
+		Info("Created image, set 'image' in the blueprint for machines launched from it")
+
+	fmt.Println(image)
+
+	return nil
+}
+
+// bakeCluster bakes the first node of the cluster in the given config, returning its instance id.
+func bakeCluster(ctx context.Context, config *value.AutobenchConfig) (string, error) {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	return cluster.Bake(ctx)
+}
+
+// bakeBackupClient bakes the backup client in the given config, returning its instance id.
+func bakeBackupClient(ctx context.Context, config *value.AutobenchConfig) (string, error) {
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	return client.Bake(ctx)
+}
+
+// bakeConfig returns the config for the image created for the given environment, the environment name is appended to
+// the image name (when there are multiple environments) since image names must be unique.
+func bakeConfig(blueprint *value.Blueprint) *value.BakeConfig {
+	name := bakeOptions.name
+	if name == "" {
+		name = run.Namespace("cbtools-autobench")
+	}
+
+	if blueprint.Name != "" {
+		name = fmt.Sprintf("%s-%s", name, blueprint.Name)
+	}
+
+	return &value.BakeConfig{
+		Name:      name,
+		AWSConfig: value.AWSConfig{Region: bakeOptions.region, Profile: bakeOptions.profile},
+		NoReboot:  bakeOptions.noReboot,
+	}
+}
@@ -15,13 +15,20 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	fsutil "github.com/couchbase/tools-common/fs/util"
 	"github.com/jamesl33/cbtools-autobench/nodes"
 	"github.com/jamesl33/cbtools-autobench/report"
 	"github.com/jamesl33/cbtools-autobench/value"
 
+	"github.com/apex/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -32,16 +39,32 @@ var benchmarkOptions = struct {
 	configPath string
 	logsPath   string
 	jsonOut    bool
+
+	// output is the format the results are output in, either the human readable report or the stable machine readable
+	// report (see 'value.BenchmarkReport').
+	output string
 }{}
 
+const (
+	// outputText outputs the human readable report.
+	outputText = "text"
+
+	// outputJSON outputs the machine readable report, which has stable field names so that it may be parsed by CI.
+	outputJSON = "json"
+)
+
 // benchmarkCommand is the benchmark sub-command, used to benchmark the 'cbbackupmgr' tool by running multiple
 // backups/restores against an already provisioned cluster.
 var benchmarkCommand = &cobra.Command{
-	RunE:      benchmark,
-	Short:     "benchmark the cbbackupmgr tool performing either a backup or restore",
-	Use:       "benchmark {backup|restore}",
-	Args:      cobra.ExactValidArgs(1),
-	ValidArgs: []string{"backup", "restore"},
+	RunE:  benchmark,
+	Short: "benchmark cbbackupmgr e.g. performing a backup, restore, upgrade, compatibility, sweep or soak benchmark",
+	Use: "benchmark {backup|restore|multi-restore|upgrade|compatibility|sweep|threads|matrix|storage|bisect|mtls|soak|" +
+		"cloud}",
+	Args: configArgs(cobra.ExactValidArgs(1)),
+	ValidArgs: []string{
+		"backup", "restore", "multi-restore", "upgrade", "compatibility", "sweep", "threads", "matrix", "storage",
+		"bisect", "mtls", "soak", "cloud",
+	},
 }
 
 // init the flags/arguments for the benchmark sub-command.
@@ -70,78 +93,574 @@ func init() {
 		"JSON format benchmarking report",
 	)
 
+	benchmarkCommand.Flags().StringVarP(
+		&benchmarkOptions.output,
+		"output",
+		"o",
+		outputText,
+		"output the human readable report (text) or the machine readable results (json)",
+	)
+
+	addLockFlags(benchmarkCommand)
+
 	markFlagRequired(benchmarkCommand, "config")
 }
 
 // benchmark sub-command, this will use the provided configuration to run one or more benchmarks against an already
-// provisioned cluster and then print a report to stdout.
+// provisioned cluster and then print a report to stdout. When multiple environments are defined, the benchmarks are run
+// against each environment concurrently and a comparative report is printed.
 //
 // NOTE: The report prints information about the cluster/dataset, therefore, it's up to the user to the dataset hasn't
 // changed since it was provisioned.
 func benchmark(_ *cobra.Command, args []string) error {
-	config, err := readConfig(benchmarkOptions.configPath)
+	if benchmarkOptions.output != outputText && benchmarkOptions.output != outputJSON {
+		return value.Categorize(value.ExitCodeConfig,
+			fmt.Errorf("unsupported output format '%s', expected 'text' or 'json'", benchmarkOptions.output))
+	}
+
+	// Keep stdout clean so that the results may be piped straight into another tool
+	loggingOptions.stderr = benchmarkOptions.output == outputJSON
+
+	config, err := readBenchmarkConfig(benchmarkOptions.configPath)
+	if err != nil {
+		return err
+	}
+
+	err = confirm(benchmarkActions(config, args[0])...)
+	if err != nil {
+		return err
+	}
+
+	ctx := signalHandler()
+
+	if config.Blueprint.MultipleEnvironments() {
+		err = benchmarkEnvironments(ctx, config, args[0])
+	} else {
+		err = benchmarkSingleEnvironment(ctx, config, args[0])
+	}
+
+	// The benchmarks complete gracefully when interrupted, however, the results are incomplete
+	switch {
+	case ctx.Err() == nil:
+	case err == nil:
+		err = value.Categorize(value.ExitCodeAborted, errors.New("benchmark(s) were interrupted"))
+	default:
+		err = value.Categorize(value.ExitCodeAborted, errors.Wrap(err, "benchmark(s) were interrupted"))
+	}
+
+	return err
+}
+
+// benchmarkSingleEnvironment runs the benchmark against the only environment in the config then prints the report.
+func benchmarkSingleEnvironment(ctx context.Context, config *value.AutobenchConfig, kind string) error {
+	benchmarkReport, err := benchmarkEnvironment(ctx, config, kind)
+
+	var printErr error
+
+	switch {
+	case benchmarkOptions.output == outputJSON:
+		environment := &report.EnvironmentReport{Name: config.Blueprint.Name, Report: benchmarkReport, Err: err}
+		printErr = printJSON(environment.BenchmarkReport(run, kind))
+	case benchmarkReport == nil:
+		return err
+	default:
+		printErr = benchmarkReport.Print(benchmarkOptions.jsonOut)
+	}
+
+	if err != nil {
+		if printErr != nil {
+			log.WithError(printErr).Error("Failed to display report")
+		}
+
+		return err
+	}
+
+	return errors.Wrap(printErr, "failed to display report")
+}
+
+// readBenchmarkConfig reads the autobench config at the given path and validates that each environment can be
+// benchmarked.
+func readBenchmarkConfig(path string) (*value.AutobenchConfig, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read autobench config")
+	}
+
+	for _, blueprint := range config.Blueprint.Split() {
+		err = validateEnvironment(config.WithBlueprint(blueprint))
+		if err != nil {
+			return nil, value.Categorize(value.ExitCodeConfig, err)
+		}
+	}
+
+	return config, nil
+}
+
+// benchmarkEnvironments runs the benchmark against each of the environments concurrently then prints a comparative
+// report, an error is returned if the benchmark failed for any of the environments.
+func benchmarkEnvironments(ctx context.Context, config *value.AutobenchConfig, kind string) error {
+	var (
+		environments = config.Blueprint.Split()
+		reports      = make([]*report.EnvironmentReport, len(environments))
+		wg           sync.WaitGroup
+	)
+
+	for idx, blueprint := range environments {
+		wg.Add(1)
+
+		go func(idx int, blueprint *value.Blueprint) {
+			defer wg.Done()
+
+			benchmarkReport, err := benchmarkEnvironment(ctx, config.WithBlueprint(blueprint), kind)
+			if err != nil {
+				log.WithError(err).WithField("environment", blueprint.Name).Error("Failed to benchmark environment")
+			}
+
+			reports[idx] = &report.EnvironmentReport{Name: blueprint.Name, Report: benchmarkReport, Err: err}
+		}(idx, blueprint)
+	}
+
+	wg.Wait()
+
+	var err error
+
+	if benchmarkOptions.output == outputJSON {
+		machine := make([]*value.BenchmarkReport, 0, len(reports))
+		for _, environment := range reports {
+			machine = append(machine, environment.BenchmarkReport(run, kind))
+		}
+
+		err = printJSON(machine)
+	} else {
+		err = report.NewComparison(run, reports).Print(benchmarkOptions.jsonOut)
+	}
+
 	if err != nil {
-		return errors.Wrap(err, "failed to read autobench config")
+		return errors.Wrap(err, "failed to display report")
 	}
 
-	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster)
+	var (
+		failed int
+		code   value.ExitCode
+	)
+
+	// When the environments failed for differing reasons, fallback to a generic failure
+	for _, environment := range reports {
+		if environment.Err == nil {
+			continue
+		}
+
+		failed++
+
+		switch envCode := value.ExitCodeOf(environment.Err); {
+		case failed == 1:
+			code = envCode
+		case envCode != code:
+			code = value.ExitCodeFailure
+		}
+	}
+
+	if failed != 0 {
+		return value.Categorize(code, fmt.Errorf("benchmark(s) failed for %d of %d environment(s)", failed,
+			len(reports)))
+	}
+
+	return nil
+}
+
+// benchmarkEnvironment runs the given kind of benchmark against the cluster/backup client in the provided config and
+// returns the report. When the benchmark fails, a report flagging the run as failed is returned (alongside the error)
+// if the failure was caused by a crash or degraded cluster health.
+//
+// The benchmark is timed in the run manifest and the report is written into the run directory, so that the run may be
+// described later.
+func benchmarkEnvironment(ctx context.Context, config *value.AutobenchConfig, kind string) (*report.Report, error) {
+	var benchmarkReport *report.Report
+
+	config = namespaceRepository(config)
+
+	err := timePhase(config.Blueprint.Name, value.Phase(kind), func() error {
+		var err error
+
+		benchmarkReport, err = runBenchmark(ctx, config, kind)
+
+		return err
+	})
+
+	if benchmarkReport != nil {
+		recordReport(config.Blueprint, benchmarkReport)
+	}
+
+	return benchmarkReport, err
+}
+
+// namespaceRepository returns a copy of the config whose repository is namespaced using the run id and environment, so
+// that concurrent runs (or environments benchmarked concurrently) using a shared archive never collide.
+func namespaceRepository(config *value.AutobenchConfig) *value.AutobenchConfig {
+	var (
+		benchmark  = *config.BenchmarkConfig
+		cbm        = *benchmark.CBMConfig
+		repository = cbm.Repository
+	)
+
+	if config.Blueprint.Name != "" {
+		repository += "-" + config.Blueprint.Name
+	}
+
+	cbm.Repository = run.Namespace(repository)
+	benchmark.CBMConfig = &cbm
+
+	copied := *config
+	copied.BenchmarkConfig = &benchmark
+
+	return &copied
+}
+
+// runBenchmark runs the given kind of benchmark against the cluster/backup client in the provided config, see
+// 'benchmarkEnvironment'.
+func runBenchmark(ctx context.Context, config *value.AutobenchConfig, kind string) (*report.Report, error) {
+	// The sweep benchmark launches its own backup clients
+	if kind == "sweep" {
+		return benchmarkSweep(ctx, config)
+	}
+
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
 	if err != nil {
-		return errors.Wrap(err, "failed to connect to cluster")
+		return nil, errors.Wrap(err, "failed to connect to cluster")
 	}
 	defer cluster.Close()
 
-	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient)
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
 	if err != nil {
-		return errors.Wrap(err, "failed to connect to backup client")
+		return nil, errors.Wrap(err, "failed to connect to backup client")
 	}
 	defer client.Close()
 
-	ctx := signalHandler()
+	client.SetLocalDirectory(environmentDirectory(run.LocalDirectory(), config.Blueprint))
 
-	var results value.BenchmarkResults
+	err = client.Lock(ctx, clientLockWait)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock backup client")
+	}
+	defer unlockClient(client)
 
-	switch args[0] {
+	settings, err := cluster.ApplyServerSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to apply server settings")
+	}
+
+	var (
+		results       value.BenchmarkResults
+		upgrade       *value.UpgradeResult
+		compatibility value.CompatibilityMatrix
+		multiRestore  value.MultiRestoreResults
+		threads       *value.ThreadsSweepResults
+		matrix        value.MatrixResults
+		storage       value.StorageSweepResults
+		bisect        *value.BisectResult
+		mtls          *value.MTLSResults
+		soak          *value.SoakResult
+		cloud         *value.CloudResult
+		loads         = hostLoads(cluster, client)
+		start         = time.Now()
+	)
+
+	switch kind {
 	case "backup":
 		results, err = client.BenchmarkBackup(ctx, config.BenchmarkConfig, cluster)
 	case "restore":
 		results, err = client.BenchmarkRestore(ctx, config.BenchmarkConfig, cluster)
+	case "multi-restore":
+		multiRestore, err = client.BenchmarkMultiRestore(ctx, config.BenchmarkConfig, cluster)
+	case "upgrade":
+		upgrade, err = client.BenchmarkUpgrade(ctx, config.BenchmarkConfig, cluster)
+	case "compatibility":
+		compatibility, err = client.BenchmarkCompatibility(ctx, config.BenchmarkConfig, cluster)
+	case "threads":
+		threads, err = client.BenchmarkThreads(ctx, config.BenchmarkConfig, cluster)
+	case "matrix":
+		matrix, err = client.BenchmarkMatrix(ctx, config.BenchmarkConfig, cluster)
+	case "storage":
+		storage, err = client.BenchmarkStorage(ctx, config.BenchmarkConfig, cluster)
+	case "bisect":
+		bisect, err = client.BenchmarkBisect(ctx, config.BenchmarkConfig, cluster)
+	case "mtls":
+		mtls, err = client.BenchmarkMTLS(ctx, config.BenchmarkConfig, cluster)
+	case "soak":
+		soak, err = client.BenchmarkSoak(ctx, config.BenchmarkConfig, cluster)
+	case "cloud":
+		cloud, err = client.BenchmarkCloud(ctx, config.BenchmarkConfig, cluster)
 	}
 
+	// Always archive the 'cbbackupmgr' logs into the run directory, they're most useful when the benchmark has failed
+	archiveBackupLogs(client, config.BenchmarkConfig, environmentDirectory(run.LocalDirectory(), config.Blueprint))
+
 	if err != nil {
-		return errors.Wrap(err, "failed to run benchmark(s)")
+		return failureReport(config, kind, err), value.Categorize(value.ExitCodeBenchmark,
+			errors.Wrap(err, "failed to run benchmark(s)"))
 	}
 
 	stats, err := cluster.Stats()
 	if err != nil {
-		return errors.Wrap(err, "failed to get cluster stats")
+		return nil, errors.Wrap(err, "failed to get cluster stats")
 	}
 
-	clusterLogs, backupLogs, err := collectLogs(cluster, client, config.BenchmarkConfig, benchmarkOptions.logsPath)
+	hardware, err := cluster.Hardware()
 	if err != nil {
-		return errors.Wrap(err, "failed to collect logs")
+		return nil, errors.Wrap(err, "failed to get cluster hardware")
 	}
 
-	report := report.NewReport(report.Options{
-		Blueprint:   config.Blueprint,
-		Stats:       stats,
-		CBMConfig:   config.BenchmarkConfig.CBMConfig,
-		Results:     results,
-		ClusterLogs: clusterLogs,
-		BackupLogs:  backupLogs,
-	})
+	// The archive may not exist e.g. when backing up to blackhole, the report is still useful without this
+	clientHardware, err := client.Hardware(config.BenchmarkConfig.CBMConfig)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get backup client hardware")
+	}
+
+	credits := creditBalances(config.BenchmarkConfig.Credits, cluster, client, start)
+	metrics := cloudWatchMetrics(config.BenchmarkConfig.CloudWatch, cluster, client, start)
+
+	var logsPath string
+	if benchmarkOptions.logsPath != "" {
+		logsPath = environmentDirectory(benchmarkOptions.logsPath, config.Blueprint)
+	}
 
-	err = report.Print(benchmarkOptions.jsonOut)
+	clusterLogs, backupLogs, err := collectLogs(ctx, cluster, client, config.BenchmarkConfig, logsPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to display report")
+		return nil, errors.Wrap(err, "failed to collect logs")
+	}
+
+	// The report is still returned when the thresholds are breached, it's needed to investigate the regression
+	return report.NewReport(report.Options{
+		RunID:          run,
+		Benchmark:      kind,
+		Status:         value.RunStatusSuccess,
+		CI:             value.DetectCIMetadata(),
+		Blueprint:      config.Blueprint,
+		Stats:          stats,
+		Hardware:       hardware,
+		HostLoads:      loads,
+		ClientHardware: clientHardware,
+		CBMConfig:      config.BenchmarkConfig.CBMConfig,
+		Workload:       config.BenchmarkConfig.LiveWorkload,
+		Settle:         cluster.Settle(),
+		ServerSettings: settings,
+		Results:        results,
+		Extrapolation:  config.BenchmarkConfig.Extrapolation,
+		Upgrade:        upgrade,
+		Compatibility:  compatibility,
+		MultiRestore:   multiRestore,
+		Threads:        threads,
+		Matrix:         matrix,
+		Storage:        storage,
+		Bisect:         bisect,
+		MTLS:           mtls,
+		Soak:           soak,
+		Cloud:          cloud,
+		ClusterLogs:    clusterLogs,
+		BackupLogs:     backupLogs,
+		Credits:        credits,
+		CloudWatch:     metrics,
+		Profiles:       append(cluster.Profiles(), client.Profile()),
+		Notes:          runNotes(),
+	}), config.BenchmarkConfig.Thresholds.Check(results)
+}
+
+// printJSON outputs the given value as JSON to stdout.
+func printJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
 	}
 
+	fmt.Printf("%s\n", data)
+
 	return nil
 }
 
+// unlockClient releases the backup client lock, this is best effort since the lock may be removed using 'gc'.
+func unlockClient(client *nodes.BackupClient) {
+	err := client.Unlock()
+	if err != nil {
+		log.WithError(err).Warn("Failed to release backup client lock")
+	}
+}
+
+// environmentDirectory returns the directory within the given directory which artifacts for the environment should be
+// stored in, this is the directory itself when there's only a single (unnamed) environment.
+func environmentDirectory(directory string, blueprint *value.Blueprint) string {
+	return filepath.Join(directory, blueprint.Name)
+}
+
+// validateEnvironment returns an error if the benchmark can't be run against the cluster/backup client in the given
+// config.
+func validateEnvironment(config *value.AutobenchConfig) error {
+	err := validateEdition(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate edition")
+	}
+
+	err = validateVersions(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate versions")
+	}
+
+	err = config.Blueprint.Cluster.ValidateSnapshot()
+	if err != nil {
+		return errors.Wrap(err, "failed to validate snapshot config")
+	}
+
+	if config.BenchmarkConfig.Chaos != nil {
+		err = config.BenchmarkConfig.Chaos.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid chaos config")
+		}
+	}
+
+	if config.BenchmarkConfig.Thresholds != nil {
+		err = config.BenchmarkConfig.Thresholds.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid thresholds")
+		}
+	}
+
+	err = config.BenchmarkConfig.CBMConfig.ValidateCloud()
+	if err != nil {
+		return errors.Wrap(err, "invalid cloud config")
+	}
+
+	if config.BenchmarkConfig.CBMConfig.ClientCert != nil {
+		err = config.BenchmarkConfig.CBMConfig.ClientCert.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid client certificate config")
+		}
+	}
+
+	if config.BenchmarkConfig.Churn != nil {
+		err = config.BenchmarkConfig.Churn.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid churn config")
+		}
+
+		if !config.BenchmarkConfig.Incremental {
+			return errors.New("churn requires incremental backups to be enabled")
+		}
+	}
+
+	if config.BenchmarkConfig.Tombstones != nil {
+		err = config.BenchmarkConfig.Tombstones.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid tombstone config")
+		}
+
+		if !config.BenchmarkConfig.Incremental {
+			return errors.New("tombstones require incremental backups to be enabled")
+		}
+	}
+
+	// The snapshots are of the data path of the bucket, there's nothing to roll back to once it's been deleted
+	if config.Blueprint.Cluster.Snapshot != nil && config.BenchmarkConfig.CBMConfig.AutoCreateBuckets {
+		return errors.New("auto-creating buckets is not supported when using data path snapshots")
+	}
+
+	return nil
+}
+
+// validateEdition returns an error if the benchmark uses Enterprise Edition only features with a Community Edition
+// backup client, a warning is logged if the cluster/backup client editions differ.
+func validateEdition(config *value.AutobenchConfig) error {
+	var (
+		cluster = config.Blueprint.Cluster.Package().Edition()
+		client  = config.Blueprint.BackupClient.Package().Edition()
+	)
+
+	if cluster != client {
+		log.WithFields(log.Fields{"cluster": cluster, "backup_client": client}).
+			Warn("The cluster and backup client editions differ, results may not be comparable")
+	}
+
+	return config.BenchmarkConfig.CBMConfig.ValidateEdition(client)
+}
+
+// validateVersions returns an error if the benchmark uses features which aren't available in the versions of Couchbase
+// Server/'cbbackupmgr' being benchmarked, including each of the versions used by the compatibility benchmark.
+func validateVersions(config *value.AutobenchConfig) error {
+	err := config.Blueprint.Cluster.ValidateVersion(config.Blueprint.Cluster.Package().ReleaseVersion())
+	if err != nil {
+		return err
+	}
+
+	cbm := config.BenchmarkConfig.CBMConfig
+
+	err = cbm.ValidateVersion(config.Blueprint.BackupClient.Package().ReleaseVersion(), "backup client")
+	if err != nil {
+		return err
+	}
+
+	if config.BenchmarkConfig.Compatibility == nil {
+		return nil
+	}
+
+	for _, path := range config.BenchmarkConfig.Compatibility.PackagePaths {
+		pkg := value.NewPackage(path, "", "")
+
+		err = cbm.ValidateVersion(pkg.ReleaseVersion(), fmt.Sprintf("'%s' compatibility", pkg.Version()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// failureReport returns a report flagging the run as failed when the given error indicates that a benchmarked tool
+// crashed or that the health of the cluster degraded, this ensures that failed runs are never mistaken for slow ones.
+// Nil is returned for any other error.
+func failureReport(config *value.AutobenchConfig, kind string, err error) *report.Report {
+	options := report.Options{
+		RunID:     run,
+		Benchmark: kind,
+		CI:        value.DetectCIMetadata(),
+		Blueprint: config.Blueprint,
+		CBMConfig: config.BenchmarkConfig.CBMConfig,
+		Notes:     runNotes(),
+	}
+
+	var (
+		crash       *nodes.CrashError
+		environment *nodes.EnvironmentError
+	)
+
+	switch {
+	case errors.As(err, &crash):
+		options.Status = value.RunStatusCrashed
+		options.CoreDumps = crash.CoreDumps
+	case errors.As(err, &environment):
+		options.Status = value.RunStatusEnvironmentFailure
+		options.HealthEvents = environment.Events
+	default:
+		return nil
+	}
+
+	return report.NewReport(options)
+}
+
+// archiveBackupLogs will download the 'cbbackupmgr' logs directory from the backup client into the given directory, any
+// failure is logged but otherwise ignored since it shouldn't cause the benchmark to fail.
+func archiveBackupLogs(client *nodes.BackupClient, config *value.BenchmarkConfig, path string) {
+	err := fsutil.Mkdir(path, 0, true, true)
+	if err == nil {
+		_, err = client.ArchiveLogs(config, path)
+	}
+
+	if err != nil {
+		log.WithError(err).Warn("Failed to archive 'cbbackupmgr' logs")
+	}
+}
+
 // collectLogs will collect the logs from the cluster/backup archive, note if an empty path is provided the logs will
 // not be collected.
-func collectLogs(cluster *nodes.Cluster, client *nodes.BackupClient, config *value.BenchmarkConfig,
-	path string,
+func collectLogs(ctx context.Context, cluster *nodes.Cluster, client *nodes.BackupClient,
+	config *value.BenchmarkConfig, path string,
 ) ([]string, string, error) {
 	// We haven't been provided a path by the user, this indicates that they don't want to collect the logs
 	if path == "" {
@@ -162,7 +681,7 @@ func collectLogs(cluster *nodes.Cluster, client *nodes.BackupClient, config *val
 		return nil, "", errors.Wrap(err, "failed to create logs output directory")
 	}
 
-	clusterLogs, err := cluster.CollectLogs(path)
+	clusterLogs, err := cluster.CollectLogs(ctx, path)
 	if err != nil {
 		return nil, "", errors.Wrap(err, "failed to collect cluster logs")
 	}
@@ -0,0 +1,60 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// cloudWatchMetrics returns the CloudWatch metrics for the cluster/backup client since the given start time (if
+// enabled). Failures are only logged, since the report is still useful without the metrics.
+func cloudWatchMetrics(config *value.CloudWatchConfig, cluster *nodes.Cluster, client *nodes.BackupClient,
+	start time.Time,
+) value.CloudWatchMetrics {
+	if config == nil {
+		return nil
+	}
+
+	metrics, err := fetchCloudWatchMetrics(config, cluster, client, start, time.Now())
+	if err != nil {
+		log.WithError(err).Warn("Failed to get CloudWatch metrics")
+		return nil
+	}
+
+	return metrics
+}
+
+// fetchCloudWatchMetrics fetches the CloudWatch metrics for the cluster/backup client between the start/end.
+func fetchCloudWatchMetrics(config *value.CloudWatchConfig, cluster *nodes.Cluster, client *nodes.BackupClient,
+	start, end time.Time,
+) (value.CloudWatchMetrics, error) {
+	metrics, err := cluster.CloudWatchMetrics(config, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster metrics")
+	}
+
+	found, err := client.CloudWatchMetrics(config, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get backup client metrics")
+	}
+
+	return append(metrics, found...), nil
+}
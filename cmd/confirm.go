@@ -0,0 +1,326 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+)
+
+// assumeYes skips the confirmation prompt displayed before running destructive actions, this is required when running
+// non-interactively e.g. in CI.
+var assumeYes bool
+
+// errNotConfirmable is returned when destructive actions need to be confirmed, but there's no user to confirm them.
+var errNotConfirmable = value.Categorize(value.ExitCodeConfig,
+	errors.New("destructive actions must be confirmed, use '--yes' when running non-interactively"))
+
+// destructiveAction is an action which destroys data on the listed hosts, it must be confirmed before it's run.
+type destructiveAction struct {
+	description string
+	hosts       []string
+}
+
+// confirm lists the given destructive actions (and the hosts they affect) then prompts the user to confirm them, an
+// error is returned if they're not confirmed. The prompt is skipped when '--yes' or '--dry-run' are provided.
+func confirm(actions ...destructiveAction) error {
+	filtered := make([]destructiveAction, 0, len(actions))
+
+	for _, action := range actions {
+		if len(action.hosts) != 0 {
+			filtered = append(filtered, action)
+		}
+	}
+
+	if len(filtered) == 0 || assumeYes || dryRun {
+		return nil
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return errNotConfirmable
+	}
+
+	fmt.Fprintln(os.Stderr, "The following destructive actions will be run:")
+
+	for _, action := range filtered {
+		fmt.Fprintf(os.Stderr, "- %s on:\n", action.description)
+
+		for _, host := range action.hosts {
+			fmt.Fprintf(os.Stderr, "    %s\n", host)
+		}
+	}
+
+	fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	// NOTE: Character devices such as '/dev/null' pass the check above but will never provide an answer
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintln(os.Stderr)
+		return errNotConfirmable
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to read confirmation")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+
+	return value.Categorize(value.ExitCodeAborted, errors.New("destructive actions were not confirmed"))
+}
+
+// connectActions returns the destructive actions run when connecting to the cluster nodes/backup client in the given
+// config; nodes with an index path have their last block device partitioned/formatted (unless it's already
+// partitioned).
+func connectActions(config *value.AutobenchConfig) []destructiveAction {
+	var hosts []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		for _, node := range blueprint.Cluster.Nodes {
+			if node.IndexPath != "" {
+				hosts = append(hosts, node.Host)
+			}
+		}
+	}
+
+	return []destructiveAction{{
+		description: "partition/format the last block device (for the index path) unless it's already partitioned",
+		hosts:       hosts,
+	}}
+}
+
+// retentionActions returns the destructive actions run when applying the given retention policy; the local run
+// directories are pruned and, when enabled, the repositories created by the pruned runs are removed from the archive.
+func retentionActions(config *value.AutobenchConfig, policy *value.RetentionConfig) []destructiveAction {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	actions := []destructiveAction{{
+		description: fmt.Sprintf("remove the run directories in '%s' exceeding the retention policy", value.RunsDirectory),
+		hosts:       []string{"localhost"},
+	}}
+
+	if !policy.Archives || config.BenchmarkConfig == nil || config.BenchmarkConfig.CBMConfig == nil {
+		return actions
+	}
+
+	var hosts []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		hosts = append(hosts, blueprint.BackupClient.Host)
+	}
+
+	return append(actions, destructiveAction{
+		description: fmt.Sprintf("remove the repositories created by the pruned runs from the archive '%s'",
+			config.BenchmarkConfig.CBMConfig.Archive),
+		hosts: hosts,
+	})
+}
+
+// provisionActions returns the destructive actions run when provisioning the cluster nodes/backup client in the given
+// config.
+func provisionActions(config *value.AutobenchConfig) []destructiveAction {
+	var reinstall, reuse, stores, tiered []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		for _, node := range blueprint.Cluster.Nodes {
+			if provisionOptions.reuseCluster || blueprint.Cluster.ReuseInstall {
+				reuse = append(reuse, node.Host)
+			} else {
+				reinstall = append(reinstall, node.Host)
+			}
+
+			if node.InstanceStore != nil {
+				stores = append(stores, node.Host)
+			}
+
+			if node.TieredStorage != nil {
+				tiered = append(tiered, node.Host)
+			}
+		}
+
+		if blueprint.BackupClient.InstanceStore != nil {
+			stores = append(stores, blueprint.BackupClient.Host)
+		}
+
+		if blueprint.BackupClient.TieredStorage != nil {
+			tiered = append(tiered, blueprint.BackupClient.Host)
+		}
+	}
+
+	return append(connectActions(config),
+		destructiveAction{
+			description: "uninstall Couchbase Server, removing all of its data",
+			hosts:       append(reinstall, clientHosts(config)...),
+		},
+		destructiveAction{
+			description: "wipe the state of Couchbase Server and everything within the data/index paths when the " +
+				"version being provisioned is already installed (otherwise uninstall it), removing all of its data",
+			hosts: reuse,
+		},
+		destructiveAction{
+			description: "format the instance store device (unless it's already mounted)",
+			hosts:       stores,
+		},
+		destructiveAction{
+			description: "wipe the cache/backing devices to build a tiered device (unless it's already mounted)",
+			hosts:       tiered,
+		},
+	)
+}
+
+// teardownActions returns the destructive actions run when tearing down the cluster nodes/backup client in the given
+// config.
+func teardownActions(config *value.AutobenchConfig, teardown *value.TeardownConfig) []destructiveAction {
+	var paths, devices []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		for _, node := range blueprint.Cluster.Nodes {
+			if node.DataPath != "" || node.IndexPath != "" {
+				paths = append(paths, node.Host)
+			}
+
+			if node.InstanceStore != nil || node.TieredStorage != nil {
+				devices = append(devices, node.Host)
+			}
+		}
+
+		if blueprint.BackupClient.InstanceStore != nil || blueprint.BackupClient.TieredStorage != nil {
+			devices = append(devices, blueprint.BackupClient.Host)
+		}
+	}
+
+	hosts := append(clusterHosts(config), clientHosts(config)...)
+
+	actions := append(connectActions(config),
+		destructiveAction{
+			description: "uninstall Couchbase Server, removing all of its data",
+			hosts:       hosts,
+		},
+		destructiveAction{
+			description: "remove everything within the data/index paths",
+			hosts:       paths,
+		},
+		destructiveAction{
+			description: "unmount the instance store device and dismantle the tiered device",
+			hosts:       devices,
+		},
+		destructiveAction{
+			description: fmt.Sprintf("remove the temporary directories in '%s' (or 'remote_tmp_dir') for every run, "+
+				"including active runs", value.TempDirectoryParent),
+			hosts: hosts,
+		},
+	)
+
+	if !teardown.Terminate {
+		return actions
+	}
+
+	return append(actions, destructiveAction{
+		description: "terminate the EC2 instances",
+		hosts:       hosts,
+	})
+}
+
+// flushAction returns the destructive action run when the benchmark requires the bucket to be flushed, for example
+// when loading the dataset or before each restore.
+func flushAction(config *value.AutobenchConfig) destructiveAction {
+	return destructiveAction{
+		description: "flush the benchmark bucket, removing all of its data",
+		hosts:       clusterHosts(config),
+	}
+}
+
+// loadActions returns the destructive actions run when loading the test dataset, the bucket is flushed unless resuming
+// or topping up the load.
+func loadActions(config *value.AutobenchConfig) []destructiveAction {
+	if !loadMode.Flush() {
+		return nil
+	}
+
+	return []destructiveAction{flushAction(config)}
+}
+
+// benchmarkActions returns the destructive actions run by the given kind of benchmark, the bucket is flushed (or
+// deleted when auto-creating buckets) before each restore (unless backing up to blackhole).
+func benchmarkActions(config *value.AutobenchConfig, kind string) []destructiveAction {
+	actions := connectActions(config)
+
+	// The dataset is reloaded by the matrix when the document sizes/counts are varied, even when backing up to blackhole
+	if kind == "matrix" && config.BenchmarkConfig.Matrix.ReloadsDataset() {
+		actions = append(actions, flushAction(config))
+	}
+
+	if config.BenchmarkConfig.CBMConfig.Blackhole {
+		return actions
+	}
+
+	// Each build is benchmarked using the benchmark from the bisect config
+	if kind == "bisect" && config.BenchmarkConfig.Bisect != nil {
+		kind = config.BenchmarkConfig.Bisect.BenchmarkOrDefault()
+	}
+
+	switch kind {
+	case "restore":
+		if !config.BenchmarkConfig.CBMConfig.AutoCreateBuckets {
+			return append(actions, flushAction(config))
+		}
+
+		actions = append(actions, destructiveAction{
+			description: "delete the benchmark bucket, it's recreated by each restore using '--auto-create-buckets'",
+			hosts:       clusterHosts(config),
+		})
+	case "upgrade", "compatibility":
+		actions = append(actions, flushAction(config))
+	}
+
+	return actions
+}
+
+// clusterHosts returns the hosts of the cluster nodes in every environment of the given config.
+func clusterHosts(config *value.AutobenchConfig) []string {
+	var hosts []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		for _, node := range blueprint.Cluster.Nodes {
+			hosts = append(hosts, node.Host)
+		}
+	}
+
+	return hosts
+}
+
+// clientHosts returns the hosts of the backup clients in every environment of the given config.
+func clientHosts(config *value.AutobenchConfig) []string {
+	var hosts []string
+
+	for _, blueprint := range config.Blueprint.Split() {
+		hosts = append(hosts, blueprint.BackupClient.Host)
+	}
+
+	return hosts
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/inventory"
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// creditBalances returns the credit balances of the burstable resources used by the cluster/backup client since the
+// given start time (if enabled). Failures are only logged, since the report is still useful without the balances.
+func creditBalances(config *value.CreditsConfig, cluster *nodes.Cluster, client *nodes.BackupClient,
+	start time.Time,
+) value.CreditBalances {
+	if config == nil {
+		return nil
+	}
+
+	balances, err := fetchCreditBalances(config, cluster, client, start)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get burst credit balances")
+		return nil
+	}
+
+	for _, balance := range balances {
+		if balance.ExhaustedDuring(start, time.Since(start)) {
+			log.WithFields(log.Fields{"host": balance.Resource.Host, "id": balance.Resource.ID}).
+				Warn("Credits were exhausted during the benchmark, results may not be representative")
+		}
+	}
+
+	return balances
+}
+
+// fetchCreditBalances determines which of the resources used by the cluster/backup client are burstable then fetches
+// their credit balances from CloudWatch.
+func fetchCreditBalances(config *value.CreditsConfig, cluster *nodes.Cluster, client *nodes.BackupClient,
+	start time.Time,
+) (value.CreditBalances, error) {
+	resources, err := cluster.BurstableResources(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster burstable resources")
+	}
+
+	found, err := client.BurstableResources(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get backup client burstable resources")
+	}
+
+	resources = append(resources, found...)
+
+	if len(resources) == 0 {
+		return nil, nil
+	}
+
+	// Include the period before the benchmark started, allowing credits exhausted during setup to be spotted
+	return inventory.CreditBalances(config, resources, start.Add(-value.CreditsPeriod), time.Now())
+}
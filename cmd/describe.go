@@ -0,0 +1,114 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// describeCommand is the describe sub-command, used to display everything recorded in the run directory of a past run.
+var describeCommand = &cobra.Command{
+	RunE:  describe,
+	Short: "describe a past run using its run directory, including the config, phase timings and reports",
+	Use:   "describe <run-id>",
+	Args:  configArgs(cobra.ExactArgs(1)),
+}
+
+// describe sub-command, this will display the manifest, config, reports and notes recorded in the run directory of the
+// given run on a single page.
+func describe(_ *cobra.Command, args []string) error {
+	id := value.RunID(args[0])
+
+	_, err := os.Stat(id.LocalDirectory())
+	if err != nil {
+		return errors.Wrapf(err, "failed to find run directory for run '%s'", id)
+	}
+
+	manifest := &value.RunManifest{RunID: id}
+
+	data, err := os.ReadFile(id.ManifestPath())
+	if err == nil {
+		err = json.Unmarshal(data, manifest)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read run manifest")
+	}
+
+	fmt.Printf("%s\n\n", manifest)
+
+	config, err := os.ReadFile(filepath.Join(id.LocalDirectory(), value.ConfigFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read config")
+	}
+
+	if len(config) != 0 {
+		fmt.Printf("| Config\n| ------\n")
+
+		scanner := bufio.NewScanner(bytes.NewReader(value.RedactConfig(config)))
+		for scanner.Scan() {
+			fmt.Printf("| %s\n", scanner.Text())
+		}
+
+		fmt.Println()
+	}
+
+	// Reports are written into the run directory, or a directory for each environment when there are multiple
+	reports, err := filepath.Glob(filepath.Join(id.LocalDirectory(), value.ReportFile))
+	if err != nil {
+		return errors.Wrap(err, "failed to find reports")
+	}
+
+	environments, err := filepath.Glob(filepath.Join(id.LocalDirectory(), "*", value.ReportFile))
+	if err != nil {
+		return errors.Wrap(err, "failed to find reports")
+	}
+
+	for _, path := range append(reports, environments...) {
+		report, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read report")
+		}
+
+		fmt.Printf("%s\n", bytes.TrimSpace(report))
+		fmt.Println()
+	}
+
+	// The notes are included in the reports, they're only displayed separately when the run didn't record any
+	notes, err := readNotes(id)
+	if err != nil {
+		return err
+	}
+
+	if len(notes) != 0 && len(reports)+len(environments) == 0 {
+		fmt.Printf("%s\n\n", notes)
+	}
+
+	if len(manifest.Phases) == 0 && len(config) == 0 && len(reports)+len(environments) == 0 && len(notes) == 0 {
+		return fmt.Errorf("nothing has been recorded in the run directory for run '%s'", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// exportOptions encapsulates the possible options which can be used to change the behavior of the 'export'
+// sub-commands.
+var exportOptions = struct {
+	output string
+}{}
+
+// exportCommand is the export sub-command, used to export past runs so that they may be shared.
+var exportCommand = &cobra.Command{
+	Short: "export past runs using their run directory, for example to hand off regressions between teams",
+	Use:   "export",
+}
+
+// exportBundleCommand is the export bundle sub-command, used to create a reproducible bundle of a past run.
+var exportBundleCommand = &cobra.Command{
+	RunE:  exportBundle,
+	Short: "create a tarball with the resolved config, versions, seeds and scripts needed to reproduce the given run",
+	Use:   "bundle <run-id>",
+	Args:  configArgs(cobra.ExactArgs(1)),
+}
+
+// init the flags/arguments for the export sub-commands.
+func init() {
+	exportBundleCommand.Flags().StringVarP(
+		&exportOptions.output,
+		"output",
+		"o",
+		"",
+		"the path the bundle will be written to (defaults to '<run-id>.tar.gz' in the current directory)",
+	)
+
+	exportCommand.AddCommand(exportBundleCommand)
+}
+
+// exportBundle sub-command, this will create a gzipped tarball containing everything needed to reproduce the given run
+// elsewhere, using what's recorded in its run directory.
+func exportBundle(_ *cobra.Command, args []string) error {
+	id := value.RunID(args[0])
+
+	_, err := os.Stat(id.LocalDirectory())
+	if err != nil {
+		return errors.Wrapf(err, "failed to find run directory for run '%s'", id)
+	}
+
+	files, err := bundleFiles(id)
+	if err != nil {
+		return err
+	}
+
+	output := exportOptions.output
+	if output == "" {
+		output = string(id) + ".tar.gz"
+	}
+
+	err = writeBundle(output, string(id), files)
+	if err != nil {
+		return errors.Wrap(err, "failed to write bundle")
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	log.WithFields(log.Fields{"run": id, "path": output, "files": names}).Info("Exported run bundle")
+
+	return nil
+}
+
+// bundleFiles returns the contents of each file in the bundle of the given run, keyed by their path in the bundle.
+func bundleFiles(id value.RunID) (map[string][]byte, error) {
+	config, err := resolvedConfig(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest value.RunManifest
+
+	data, err := os.ReadFile(id.ManifestPath())
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "failed to read run manifest")
+	}
+
+	files := map[string][]byte{
+		value.BundleConfigFile: value.RedactConfig(config),
+		"reproduce.sh":         []byte(value.ReproduceScript(id, manifest.Command)),
+	}
+
+	versions, err := bundleVersions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	files["versions.json"], err = json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode versions")
+	}
+
+	// The manifest, notes and reports are copied as-is, they provide the context needed to compare the reproduction
+	for _, pattern := range []string{
+		value.ManifestFile,
+		value.NotesFile,
+		value.ReportFile,
+		value.ReportJSONFile,
+		filepath.Join("*", value.ReportFile),
+		filepath.Join("*", value.ReportJSONFile),
+	} {
+		err = addBundleFiles(files, id.LocalDirectory(), pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seeds, err := bundleSeeds(files)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seeds) != 0 {
+		files["seeds.json"], err = json.MarshalIndent(seeds, "", "  ")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode seeds")
+		}
+	}
+
+	return files, nil
+}
+
+// resolvedConfig returns the resolved config recorded in the run directory of the given run, falling back to the
+// config as provided for runs which didn't record one.
+func resolvedConfig(id value.RunID) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(id.LocalDirectory(), value.ResolvedConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		log.WithField("run", id).Warn("The run didn't record a resolved config, bundling the config as provided")
+
+		data, err = os.ReadFile(filepath.Join(id.LocalDirectory(), value.ConfigFile))
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config")
+	}
+
+	return data, nil
+}
+
+// bundleVersions returns the versions of Couchbase Server/'cbbackupmgr' used by each environment in the given config.
+func bundleVersions(data []byte) ([]*value.BundleVersions, error) {
+	var config *value.AutobenchConfig
+
+	err := yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode config")
+	}
+
+	versions := make([]*value.BundleVersions, 0)
+
+	if config == nil || config.Blueprint == nil {
+		return versions, nil
+	}
+
+	for _, blueprint := range config.Blueprint.Split() {
+		if blueprint.Cluster != nil && blueprint.BackupClient != nil {
+			versions = append(versions, &value.BundleVersions{
+				Environment:       blueprint.Name,
+				BenchmarkVersions: value.NewBenchmarkVersions(blueprint.Cluster, blueprint.BackupClient),
+			})
+		}
+	}
+
+	return versions, nil
+}
+
+// bundleSeeds returns the seeds used to inject faults during each iteration, extracted from the JSON reports in the
+// given bundle files.
+func bundleSeeds(files map[string][]byte) ([]*value.BundleSeed, error) {
+	names := make([]string, 0)
+
+	for name := range files {
+		if filepath.Base(name) == value.ReportJSONFile {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	seeds := make([]*value.BundleSeed, 0)
+
+	for _, name := range names {
+		var report struct {
+			Environment string                `json:"environment"`
+			Faults      value.FaultInjections `json:"fault_injection"`
+		}
+
+		err := json.Unmarshal(files[name], &report)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode report '%s'", name)
+		}
+
+		for _, injection := range report.Faults {
+			if injection.ChaosResult == nil {
+				continue
+			}
+
+			seeds = append(seeds, &value.BundleSeed{
+				Environment: report.Environment,
+				Iteration:   injection.Iteration,
+				Seed:        injection.Seed,
+			})
+		}
+	}
+
+	return seeds, nil
+}
+
+// addBundleFiles adds the files in the given directory which match the given pattern to the bundle files, keyed by
+// their path relative to the directory.
+func addBundleFiles(files map[string][]byte, directory, pattern string) error {
+	paths, err := filepath.Glob(filepath.Join(directory, pattern))
+	if err != nil {
+		return errors.Wrap(err, "failed to find files")
+	}
+
+	for _, path := range paths {
+		name, err := filepath.Rel(directory, path)
+		if err != nil {
+			return errors.Wrap(err, "failed to get relative path")
+		}
+
+		files[name], err = os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// writeBundle writes the given files into a gzipped tarball at the given path, beneath a directory with the given name.
+func writeBundle(path, directory string, files map[string][]byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bundle")
+	}
+	defer file.Close()
+
+	var (
+		compressed = gzip.NewWriter(file)
+		archive    = tar.NewWriter(compressed)
+		now        = time.Now()
+	)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		mode := int64(0o644)
+		if filepath.Ext(name) == ".sh" {
+			mode = 0o755
+		}
+
+		err = archive.WriteHeader(&tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(directory, name)),
+			Mode:    mode,
+			Size:    int64(len(files[name])),
+			ModTime: now,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to write header for '%s'", name)
+		}
+
+		_, err = archive.Write(files[name])
+		if err != nil {
+			return errors.Wrapf(err, "failed to write '%s'", name)
+		}
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close archive")
+	}
+
+	err = compressed.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close compressed writer")
+	}
+
+	return file.Close()
+}
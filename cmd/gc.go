@@ -0,0 +1,166 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// gcOptions encapsulates the possible options which can be used to change the behavior of the 'gc' sub-command.
+var gcOptions = struct {
+	configPath string
+	olderThan  time.Duration
+	retention  value.RetentionConfig
+}{}
+
+// gcCommand is the gc sub-command, used to remove temporary directories left behind by runs which crashed and to apply
+// the retention policy to old runs.
+var gcCommand = &cobra.Command{
+	RunE:  gc,
+	Short: "remove temporary directories left behind by previous runs, and old runs exceeding the retention policy",
+	Use:   "gc",
+}
+
+// init the flags/arguments for the gc sub-command.
+func init() {
+	gcCommand.Flags().StringVarP(
+		&gcOptions.configPath,
+		"config",
+		"c",
+		"",
+		"path to a cbtools-autobench config file",
+	)
+
+	gcCommand.Flags().DurationVar(
+		&gcOptions.olderThan,
+		"older-than",
+		24*time.Hour,
+		"only remove temporary directories which haven't been modified for at least this long",
+	)
+
+	gcCommand.Flags().IntVar(
+		&gcOptions.retention.KeepRuns,
+		"keep-runs",
+		0,
+		"only keep this many of the most recent run directories (overrides the config file)",
+	)
+
+	gcCommand.Flags().IntVar(
+		&gcOptions.retention.MaxSizeGiB,
+		"max-size",
+		0,
+		"the maximum total size (in GiB) of the run directories which are kept (overrides the config file)",
+	)
+
+	gcCommand.Flags().BoolVar(
+		&gcOptions.retention.Archives,
+		"archives",
+		false,
+		"remove the benchmark repositories created by the pruned runs from the archive (overrides the config file)",
+	)
+
+	markFlagRequired(gcCommand, "config")
+}
+
+// gc sub-command, this will remove any per-run temporary directories which are older than the given duration, the
+// duration ensures we don't remove the directories in use by concurrent runs. Any local run directories (and optionally
+// their repositories) which don't satisfy the retention policy are also removed.
+func gc(cmd *cobra.Command, _ []string) error {
+	config, err := readConfig(gcOptions.configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	policy := retentionPolicy(cmd, config.Retention)
+
+	err = confirm(append(connectActions(config), retentionActions(config, policy)...)...)
+	if err != nil {
+		return err
+	}
+
+	pruned, err := pruneRuns(policy)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply retention policy")
+	}
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return gcEnvironment(config, policy, pruned)
+	})
+}
+
+// retentionPolicy returns the retention policy from the config file, overridden by any flags provided by the user.
+func retentionPolicy(cmd *cobra.Command, config *value.RetentionConfig) *value.RetentionConfig {
+	merged := value.RetentionConfig{}
+	if config != nil {
+		merged = *config
+	}
+
+	if cmd.Flags().Changed("keep-runs") {
+		merged.KeepRuns = gcOptions.retention.KeepRuns
+	}
+
+	if cmd.Flags().Changed("max-size") {
+		merged.MaxSizeGiB = gcOptions.retention.MaxSizeGiB
+	}
+
+	if cmd.Flags().Changed("archives") {
+		merged.Archives = gcOptions.retention.Archives
+	}
+
+	return &merged
+}
+
+// gcEnvironment removes the leftover temporary directories from the cluster/backup client in the given config, and the
+// repositories created by the pruned runs when enabled by the retention policy.
+func gcEnvironment(config *value.AutobenchConfig, policy *value.RetentionConfig, pruned []value.RunID) error {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	err = cluster.GarbageCollect(gcOptions.olderThan)
+	if err != nil {
+		return errors.Wrap(err, "failed to garbage collect cluster nodes")
+	}
+
+	err = client.GarbageCollect(gcOptions.olderThan)
+	if err != nil {
+		return errors.Wrap(err, "failed to garbage collect backup client")
+	}
+
+	if !policy.Archives || len(pruned) == 0 || config.BenchmarkConfig == nil || config.BenchmarkConfig.CBMConfig == nil {
+		return nil
+	}
+
+	err = client.RemoveRepositories(config.BenchmarkConfig.CBMConfig, pruned)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove repositories of pruned runs")
+	}
+
+	return nil
+}
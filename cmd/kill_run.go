@@ -0,0 +1,137 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// killRunOptions encapsulates the possible options which can be used to change the behavior of the 'kill-run'
+// sub-command.
+var killRunOptions = struct {
+	configPath string
+}{}
+
+// killRunCommand is the kill-run sub-command, used to clean up the remote processes left running by a run which
+// crashed (or was killed) on the operator side.
+var killRunCommand = &cobra.Command{
+	RunE:  killRun,
+	Short: "kill the long-running processes (e.g. loaders and cbbackupmgr) left running on every host by a past run",
+	Use:   "kill-run <run-id>",
+	Args:  configArgs(cobra.ExactArgs(1)),
+}
+
+// init the flags/arguments for the kill-run sub-command.
+func init() {
+	killRunCommand.Flags().StringVarP(
+		&killRunOptions.configPath,
+		"config",
+		"c",
+		"",
+		"path to a cbtools-autobench config file (defaults to the config recorded in the run directory)",
+	)
+}
+
+// killRun sub-command, this will connect to every host in the config and kill each process (and its descendants)
+// started by the given run which is still running, the backup client lock is also released if it's held by the run.
+func killRun(_ *cobra.Command, args []string) error {
+	target := value.RunID(args[0])
+
+	path := killRunOptions.configPath
+	if path == "" {
+		path = filepath.Join(target.LocalDirectory(), value.ConfigFile)
+	}
+
+	config, err := readConfig(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	running, err := nodes.ReadProcesses(target)
+	if err != nil {
+		return errors.Wrap(err, "failed to read running processes")
+	}
+
+	if len(running) != 0 {
+		fmt.Printf("%s\n\n", running)
+	}
+
+	hosts := append(clusterHosts(config), clientHosts(config)...)
+
+	warnUnknownHosts(running, hosts)
+
+	err = confirm(append(connectActions(config), destructiveAction{
+		description: fmt.Sprintf("kill the processes started by run '%s'", target),
+		hosts:       hosts,
+	})...)
+	if err != nil {
+		return err
+	}
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return killEnvironment(config, target)
+	})
+}
+
+// warnUnknownHosts logs a warning for each host which the processes were running on that isn't in the config, since
+// they won't be cleaned up.
+func warnUnknownHosts(running value.TrackedProcesses, hosts []string) {
+	known := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		known[host] = struct{}{}
+	}
+
+	for _, host := range running.Hosts() {
+		if _, ok := known[host]; !ok {
+			log.WithField("host", host).Warn("Processes were running on a host which isn't in the config, they must be " +
+				"killed manually")
+		}
+	}
+}
+
+// killEnvironment kills the processes started by the given run on the cluster/backup client in the given config.
+func killEnvironment(config *value.AutobenchConfig, target value.RunID) error {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	err = cluster.KillRun(target)
+	if err != nil {
+		return errors.Wrap(err, "failed to kill cluster processes")
+	}
+
+	err = client.KillRun(target)
+	if err != nil {
+		return errors.Wrap(err, "failed to kill backup client processes")
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// clientLockWait is how long to wait for the backup client to be free when it's in use by another benchmark, by default
+// we refuse to start a concurrent benchmark.
+var clientLockWait time.Duration
+
+// addLockFlags adds the flags which control how we handle the backup client being in use to the given command.
+func addLockFlags(command *cobra.Command) {
+	command.Flags().DurationVar(
+		&clientLockWait,
+		"wait-for-client",
+		0,
+		"wait for up to this long for the backup client to be free when it's in use by another benchmark",
+	)
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	fsutil "github.com/couchbase/tools-common/fs/util"
+	"github.com/jamesl33/cbtools-autobench/utilities"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// loggingOptions encapsulates the global flags which can be used to override the logging config.
+var loggingOptions = struct {
+	handler string
+	level   string
+	file    string
+
+	// stderr writes the logs to stderr rather than stdout, used when stdout is reserved for machine readable output.
+	stderr bool
+}{}
+
+// setupLogging configures the logging handler using the given config, any flags provided by the user take precedence.
+func setupLogging(config *value.LoggingConfig) error {
+	merged := value.LoggingConfig{}
+	if config != nil {
+		merged = *config
+	}
+
+	override := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	override(&merged.Handler, loggingOptions.handler)
+	override(&merged.Level, loggingOptions.level)
+	override(&merged.File, loggingOptions.file)
+
+	format := utilities.LoggingFormat(merged.Handler)
+
+	switch format {
+	case "":
+		format = utilities.LoggingFormatCLI
+	case utilities.LoggingFormatCLI, utilities.LoggingFormatJSON:
+	default:
+		return fmt.Errorf("unsupported log handler '%s'", merged.Handler)
+	}
+
+	if merged.Level != "" {
+		level, err := log.ParseLevel(merged.Level)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse log level")
+		}
+
+		log.SetLevel(level)
+	}
+
+	var writer io.Writer = os.Stdout
+	if loggingOptions.stderr {
+		writer = os.Stderr
+	}
+
+	if path := merged.LogFile(run); path != "" {
+		err := fsutil.Mkdir(filepath.Dir(path), 0, true, true)
+		if err != nil {
+			return errors.Wrap(err, "failed to create log file directory")
+		}
+
+		// NOTE: The file is intentionally left open, it will be closed when the process exits
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return errors.Wrap(err, "failed to open log file")
+		}
+
+		writer = io.MultiWriter(writer, file)
+	}
+
+	log.SetHandler(utilities.NewLoggingHandlerWithOptions(writer, format, log.Fields{"run_id": run}))
+
+	return nil
+}
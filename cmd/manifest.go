@@ -0,0 +1,135 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	fsutil "github.com/couchbase/tools-common/fs/util"
+	"github.com/jamesl33/cbtools-autobench/report"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// manifest describes this run, it's written to the run directory each time a phase completes so that the run may be
+// described later using 'describe'.
+var manifest = struct {
+	lock     sync.Mutex
+	manifest *value.RunManifest
+}{}
+
+// timePhase runs the given function recording how long the phase took for the environment in the run manifest.
+//
+// NOTE: Failing to write the manifest isn't fatal, it only affects describing the run.
+func timePhase(environment string, phase value.Phase, fn func() error) error {
+	start := time.Now()
+
+	err := fn()
+
+	timing := &value.PhaseTiming{
+		Environment: environment,
+		Phase:       phase,
+		Started:     start,
+		Duration:    time.Since(start),
+	}
+
+	if err != nil {
+		timing.Err = errors.Cause(err).Error()
+	}
+
+	recordWebhookPhase(timing)
+
+	manifest.lock.Lock()
+	defer manifest.lock.Unlock()
+
+	if manifest.manifest == nil {
+		manifest.manifest = &value.RunManifest{RunID: run, Command: strings.Join(os.Args[1:], " "), Started: start}
+	}
+
+	manifest.manifest.Phases = append(manifest.manifest.Phases, timing)
+
+	writeErr := writeArtifact(run.ManifestPath(), func() ([]byte, error) {
+		return json.MarshalIndent(manifest.manifest, "", "  ")
+	})
+	if writeErr != nil {
+		log.WithError(writeErr).Warn("Failed to write run manifest")
+	}
+
+	return err
+}
+
+// recordConfig copies the config at the given path into the run directory.
+func recordConfig(path string) {
+	err := writeArtifact(filepath.Join(run.LocalDirectory(), value.ConfigFile), func() ([]byte, error) {
+		return os.ReadFile(path)
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to copy config into run directory")
+	}
+}
+
+// recordResolvedConfig writes the given (resolved) config into the run directory, it's used to reproduce the run
+// elsewhere using 'export bundle'.
+func recordResolvedConfig(config *value.AutobenchConfig) {
+	err := writeArtifact(filepath.Join(run.LocalDirectory(), value.ResolvedConfigFile), func() ([]byte, error) {
+		return yaml.Marshal(config)
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to write resolved config into run directory")
+	}
+}
+
+// recordReport writes the given report (in both formats) into the run directory of the environment.
+func recordReport(blueprint *value.Blueprint, benchmarkReport *report.Report) {
+	recordWebhookReport(benchmarkReport)
+
+	directory := environmentDirectory(run.LocalDirectory(), blueprint)
+
+	err := writeArtifact(filepath.Join(directory, value.ReportFile), func() ([]byte, error) {
+		return []byte(benchmarkReport.String() + "\n"), nil
+	})
+	if err == nil {
+		err = writeArtifact(filepath.Join(directory, value.ReportJSONFile), func() ([]byte, error) {
+			return json.Marshal(benchmarkReport)
+		})
+	}
+
+	if err != nil {
+		log.WithError(err).Warn("Failed to write report into run directory")
+	}
+}
+
+// writeArtifact writes the data returned by the given function to the given path, creating any missing directories.
+func writeArtifact(path string, data func() ([]byte, error)) error {
+	contents, err := data()
+	if err != nil {
+		return errors.Wrap(err, "failed to get contents")
+	}
+
+	err = fsutil.Mkdir(filepath.Dir(path), 0, true, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	return errors.Wrap(os.WriteFile(path, contents, 0o644), "failed to write file")
+}
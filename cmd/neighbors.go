@@ -0,0 +1,55 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// hostLoads returns the load on the cluster nodes/backup client at the start of the benchmark, logging a warning for
+// any hosts which are busy or have noisy neighbors. Failures are only logged, since the benchmark may still be run.
+func hostLoads(cluster *nodes.Cluster, client *nodes.BackupClient) value.HostLoads {
+	loads, err := fetchHostLoads(cluster, client)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get host loads")
+		return nil
+	}
+
+	for _, load := range loads.Contaminated() {
+		fields := log.Fields{"host": load.Host, "load_average": load.Load, "cpus": load.CPUs, "neighbors": load.Neighbors}
+		log.WithFields(fields).Warn("Host is busy or has noisy neighbors, results may not be representative")
+	}
+
+	return loads
+}
+
+// fetchHostLoads returns the load on each of the cluster nodes and the backup client.
+func fetchHostLoads(cluster *nodes.Cluster, client *nodes.BackupClient) (value.HostLoads, error) {
+	loads, err := cluster.HostLoads()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster host loads")
+	}
+
+	load, err := client.HostLoad()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get backup client host load")
+	}
+
+	return append(loads, load), nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// noteCommand is the note sub-command, used to manage the notes attached to runs.
+var noteCommand = &cobra.Command{
+	Short: "manage the notes attached to runs, which provide context to later readers of their reports",
+	Use:   "note",
+}
+
+// noteAddCommand is the note add sub-command, used to attach a manual observation to a (possibly running) run.
+var noteAddCommand = &cobra.Command{
+	RunE:  noteAdd,
+	Short: "attach a note (e.g. \"switch firmware updated mid-run\") to the given run, it's shown in the run's reports",
+	Use:   "add <run-id> <text>",
+	Args:  configArgs(cobra.ExactArgs(2)),
+}
+
+// init the flags/arguments for the note sub-commands.
+func init() {
+	noteCommand.AddCommand(noteAddCommand)
+}
+
+// noteAdd sub-command, this will attach the given note to the run and add it to any reports already recorded in the
+// run directory. Reports created once the note has been added (i.e. when the run is still in progress) include it.
+func noteAdd(_ *cobra.Command, args []string) error {
+	var (
+		id   = value.RunID(args[0])
+		text = strings.TrimSpace(args[1])
+	)
+
+	if text == "" {
+		return value.Categorize(value.ExitCodeConfig, errors.New("the note must not be empty"))
+	}
+
+	_, err := os.Stat(id.LocalDirectory())
+	if err != nil {
+		return errors.Wrapf(err, "failed to find run directory for run '%s'", id)
+	}
+
+	notes, err := readNotes(id)
+	if err != nil {
+		return err
+	}
+
+	notes = append(notes, &value.Note{Text: text, Author: os.Getenv("USER"), Added: time.Now()})
+
+	err = writeArtifact(id.NotesPath(), func() ([]byte, error) { return json.MarshalIndent(notes, "", "  ") })
+	if err != nil {
+		return errors.Wrap(err, "failed to write notes")
+	}
+
+	err = annotateReports(id, notes)
+	if err != nil {
+		return errors.Wrap(err, "failed to add note to reports")
+	}
+
+	log.WithFields(log.Fields{"run": id, "notes": len(notes)}).Info("Added note to run")
+
+	return nil
+}
+
+// readNotes returns the notes attached to the given run, an empty list is returned if none have been added.
+func readNotes(id value.RunID) (value.Notes, error) {
+	data, err := os.ReadFile(id.NotesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read notes")
+	}
+
+	var notes value.Notes
+
+	err = json.Unmarshal(data, &notes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode notes")
+	}
+
+	return notes, nil
+}
+
+// runNotes returns the notes attached to this run, which are included in its reports.
+//
+// NOTE: Failing to read the notes isn't fatal, they may be added to the recorded reports later using 'note add'.
+func runNotes() value.Notes {
+	notes, err := readNotes(run)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read run notes")
+	}
+
+	return notes
+}
+
+// annotateReports replaces the notes in each of the reports (in both formats) recorded in the run directory of the
+// given run, or a directory for each environment when there are multiple.
+func annotateReports(id value.RunID, notes value.Notes) error {
+	for _, pattern := range []string{
+		filepath.Join(id.LocalDirectory(), value.ReportFile),
+		filepath.Join(id.LocalDirectory(), "*", value.ReportFile),
+		filepath.Join(id.LocalDirectory(), value.ReportJSONFile),
+		filepath.Join(id.LocalDirectory(), "*", value.ReportJSONFile),
+	} {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return errors.Wrap(err, "failed to find reports")
+		}
+
+		for _, path := range paths {
+			annotate := annotateReport
+			if filepath.Base(path) == value.ReportJSONFile {
+				annotate = annotateReportJSON
+			}
+
+			err = annotateFile(path, notes, annotate)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add notes to '%s'", path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// annotateFile replaces the notes in the report at the given path using the given function.
+func annotateFile(path string, notes value.Notes, annotate func([]byte, value.Notes) ([]byte, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to read report")
+	}
+
+	return writeArtifact(path, func() ([]byte, error) { return annotate(data, notes) })
+}
+
+// annotateReport replaces the notes section of the given human readable report, the notes are always the last section
+// of the report.
+func annotateReport(report []byte, notes value.Notes) ([]byte, error) {
+	report = bytes.TrimSpace(report)
+
+	if idx := bytes.LastIndex(report, []byte("| Notes\n| -----\n")); idx == 0 || (idx > 0 && report[idx-1] == '\n') {
+		report = bytes.TrimSpace(report[:idx])
+	}
+
+	return []byte(fmt.Sprintf("%s\n\n%s\n", report, notes)), nil
+}
+
+// annotateReportJSON replaces the notes in the given JSON report.
+func annotateReportJSON(report []byte, notes value.Notes) ([]byte, error) {
+	var fields map[string]json.RawMessage
+
+	err := json.Unmarshal(report, &fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode report")
+	}
+
+	fields["notes"], err = json.Marshal(notes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode notes")
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// phaseOptions encapsulates the possible options which can be used to change the behavior of the phase sub-commands.
+var phaseOptions = struct {
+	configPath string
+	jsonOut    bool
+}{}
+
+// loadCommand is the load sub-command, used to (re)load the test dataset into an already provisioned cluster.
+var loadCommand = &cobra.Command{
+	RunE:  load,
+	Short: "load the benchmark dataset into an already provisioned cluster",
+	Use:   "load",
+}
+
+// benchBackupCommand is the bench-backup sub-command, used to run only the backup benchmark phase.
+var benchBackupCommand = &cobra.Command{
+	RunE:  benchPhase(value.PhaseBackup),
+	Short: "run the backup benchmark, recording the report in the state file",
+	Use:   "bench-backup",
+}
+
+// benchRestoreCommand is the bench-restore sub-command, used to run only the restore benchmark phase.
+var benchRestoreCommand = &cobra.Command{
+	RunE:  benchPhase(value.PhaseRestore),
+	Short: "run the restore benchmark, recording the report in the state file",
+	Use:   "bench-restore",
+}
+
+// reportCommand is the report sub-command, used to display the reports recorded by the benchmark phases.
+var reportCommand = &cobra.Command{
+	RunE:  displayReports,
+	Short: "display the reports recorded in the state file by the benchmark phases",
+	Use:   "report",
+}
+
+// init the flags/arguments for the phase sub-commands.
+func init() {
+	for _, command := range []*cobra.Command{loadCommand, benchBackupCommand, benchRestoreCommand} {
+		command.Flags().StringVarP(
+			&phaseOptions.configPath,
+			"config",
+			"c",
+			"",
+			"path to a cbtools-autobench config file",
+		)
+
+		markFlagRequired(command, "config")
+	}
+
+	addLoadFlags(loadCommand)
+	addLockFlags(benchBackupCommand)
+	addLockFlags(benchRestoreCommand)
+
+	for _, command := range []*cobra.Command{benchBackupCommand, benchRestoreCommand, reportCommand} {
+		command.Flags().BoolVarP(
+			&phaseOptions.jsonOut,
+			"json",
+			"j",
+			false,
+			"JSON format benchmarking report",
+		)
+	}
+}
+
+// load sub-command, this will load the test dataset into the cluster without provisioning it.
+func load(_ *cobra.Command, _ []string) error {
+	config, err := readConfig(phaseOptions.configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	err = confirm(append(connectActions(config), loadActions(config)...)...)
+	if err != nil {
+		return err
+	}
+
+	state, err := readPhaseState(statePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read state")
+	}
+
+	ctx := signalHandler()
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return provisionEnvironment(ctx, config, state, false, true)
+	})
+}
+
+// benchPhase returns a sub-command which runs the given benchmark phase against each environment, the report for each
+// environment is displayed and recorded in the state file (replacing any previous report for the phase).
+func benchPhase(phase value.Phase) func(_ *cobra.Command, _ []string) error {
+	return func(_ *cobra.Command, _ []string) error {
+		config, err := readBenchmarkConfig(phaseOptions.configPath)
+		if err != nil {
+			return err
+		}
+
+		err = confirm(benchmarkActions(config, string(phase))...)
+		if err != nil {
+			return err
+		}
+
+		state, err := readPhaseState(statePath)
+		if err != nil {
+			return errors.Wrap(err, "failed to read state")
+		}
+
+		var (
+			ctx  = signalHandler()
+			lock sync.Mutex
+		)
+
+		return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+			state.require(config.Blueprint.Name, value.PhaseProvision, value.PhaseLoad)
+
+			benchmarkReport, err := benchmarkEnvironment(ctx, config, string(phase))
+			if benchmarkReport == nil {
+				return err
+			}
+
+			recordErr := state.recordReport(config.Blueprint.Name, phase, benchmarkReport)
+			if recordErr != nil {
+				log.WithError(recordErr).Error("Failed to record report")
+			}
+
+			// Avoid interleaving the reports for concurrently benchmarked environments
+			lock.Lock()
+			defer lock.Unlock()
+
+			printErr := benchmarkReport.Print(phaseOptions.jsonOut)
+			if printErr != nil {
+				log.WithError(printErr).Error("Failed to display report")
+			}
+
+			return err
+		})
+	}
+}
+
+// displayReports sub-command, this will display the latest report recorded by each benchmark phase for every
+// environment in the state file.
+func displayReports(_ *cobra.Command, _ []string) error {
+	state, err := readPhaseState(statePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read state")
+	}
+
+	environments := make([]string, 0, len(state.state.Environments))
+	for environment := range state.state.Environments {
+		environments = append(environments, environment)
+	}
+
+	sort.Strings(environments)
+
+	var (
+		reports = make(map[string]map[value.Phase]json.RawMessage)
+		found   bool
+	)
+
+	for _, environment := range environments {
+		for _, phase := range value.BenchmarkPhases {
+			record := state.state.Get(environment, phase)
+			if record == nil {
+				continue
+			}
+
+			found = true
+
+			if phaseOptions.jsonOut {
+				if reports[environment] == nil {
+					reports[environment] = make(map[value.Phase]json.RawMessage)
+				}
+
+				reports[environment][phase] = record.ReportJSON
+
+				continue
+			}
+
+			fmt.Printf("| Phase\n| -----\n| %s (run %s, completed %s)\n\n%s\n\n",
+				phase, record.RunID, record.Completed.Format("2006-01-02 15:04:05"), record.Report)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("no benchmark phases have been recorded in '%s'", statePath)
+	}
+
+	if !phaseOptions.jsonOut {
+		return nil
+	}
+
+	data, err := json.Marshal(reports)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal reports")
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
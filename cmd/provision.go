@@ -16,8 +16,10 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
 
 	"github.com/couchbase/tools-common/sync/hofp"
 	"github.com/pkg/errors"
@@ -32,8 +34,20 @@ var provisionOptions = struct {
 	// loadOnly skips actual provisioning i.e. just flush and load the test dataset; this is useful when benchmarking
 	// multiple datasets whilst using the same cluster.
 	loadOnly bool
+
+	// skipLoad skips loading the test dataset, it may be loaded later using the 'load' sub-command.
+	skipLoad bool
+
+	// concurrency is the maximum number of cluster nodes which are provisioned concurrently.
+	concurrency int
+
+	// reuseCluster skips reinstalling Couchbase Server on nodes which already have the version being provisioned.
+	reuseCluster bool
 }{}
 
+// loadMode controls how the test dataset is loaded by the 'provision'/'load' sub-commands.
+var loadMode value.LoadMode
+
 // provisionCommand is the provision sub-command, used to provision a cluster and load a test dataset.
 var provisionCommand = &cobra.Command{
 	RunE:  provision,
@@ -59,58 +73,206 @@ func init() {
 		"skip provisioning and only load benchmark dataset",
 	)
 
+	provisionCommand.Flags().BoolVar(
+		&provisionOptions.skipLoad,
+		"skip-load",
+		false,
+		"skip loading the benchmark dataset, it may be loaded later using the 'load' sub-command",
+	)
+
+	provisionCommand.Flags().IntVar(
+		&provisionOptions.concurrency,
+		"concurrency",
+		0,
+		"the maximum number of cluster nodes to provision concurrently (defaults to the number of CPUs)",
+	)
+
+	provisionCommand.Flags().BoolVar(
+		&provisionOptions.reuseCluster,
+		"reuse-cluster",
+		false,
+		"skip reinstalling Couchbase Server on nodes which already have the version being provisioned installed",
+	)
+
+	addLoadFlags(provisionCommand)
+
 	markFlagRequired(provisionCommand, "config")
 }
 
+// addLoadFlags adds the flags which control how the test dataset is loaded to the given command.
+func addLoadFlags(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&loadMode.Resume,
+		"resume",
+		false,
+		"resume an interrupted load, only loading the items which are missing from the bucket",
+	)
+
+	command.Flags().IntVar(
+		&loadMode.TopUp,
+		"top-up",
+		0,
+		"load this many additional items into the existing dataset, rather than flushing the bucket and reloading it",
+	)
+}
+
 // provision sub-command, this will use the provided configuration to provision a cluster/backup client and load a test
 // dataset.
 func provision(_ *cobra.Command, _ []string) error {
+	if provisionOptions.loadOnly && provisionOptions.skipLoad {
+		return value.Categorize(value.ExitCodeConfig,
+			errors.New("'--load-only' and '--skip-load' are mutually exclusive"))
+	}
+
+	if provisionOptions.concurrency < 0 {
+		return value.Categorize(value.ExitCodeConfig, errors.New("'--concurrency' must not be negative"))
+	}
+
 	config, err := readConfig(provisionOptions.configPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to read autobench config")
 	}
 
-	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster)
+	// Fail early, rather than once the cluster has been (partially) provisioned
+	for _, blueprint := range config.Blueprint.Split() {
+		err = blueprint.Cluster.ValidateVersion(blueprint.Cluster.Package().ReleaseVersion())
+		if err != nil {
+			return value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "failed to validate versions"))
+		}
+	}
+
+	actions := connectActions(config)
+	if !provisionOptions.loadOnly {
+		actions = provisionActions(config)
+	}
+
+	if !provisionOptions.skipLoad {
+		actions = append(actions, loadActions(config)...)
+	}
+
+	err = confirm(actions...)
+	if err != nil {
+		return err
+	}
+
+	state, err := readPhaseState(statePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read state")
+	}
+
+	ctx := signalHandler()
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return provisionEnvironment(ctx, config, state, !provisionOptions.loadOnly, !provisionOptions.skipLoad)
+	})
+}
+
+// provisionEnvironment provisions the cluster/backup client and/or loads the test dataset for the given config,
+// recording each phase in the state once it completes. Cancelling the context kills any running remote commands, the
+// phase isn't recorded in the state since it didn't complete.
+func provisionEnvironment(ctx context.Context, config *value.AutobenchConfig, state *phaseState, provision,
+	load bool,
+) error {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
 	if err != nil {
 		return errors.Wrap(err, "failed to connect to cluster")
 	}
 	defer cluster.Close()
 
-	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient)
+	cluster.SetConcurrency(provisionOptions.concurrency)
+
+	if provisionOptions.reuseCluster {
+		cluster.ReuseInstalls()
+	}
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
 	if err != nil {
 		return errors.Wrap(err, "failed to connect to backup client")
 	}
 	defer client.Close()
 
-	type provisioner interface {
-		Provision() error
+	if provision {
+		err = timePhase(config.Blueprint.Name, value.PhaseProvision, func() error {
+			return provisionMachines(ctx, config, cluster, client)
+		})
+		if err != nil {
+			return value.Categorize(interruptedOr(ctx, value.ExitCodeProvision), err)
+		}
+
+		err = state.record(config.Blueprint.Name, value.PhaseProvision, &value.PhaseRecord{})
+		if err != nil {
+			return errors.Wrap(err, "failed to record provisioning")
+		}
 	}
 
-	var provisioners []provisioner
-	if !provisionOptions.loadOnly {
-		provisioners = []provisioner{cluster, client}
+	if load {
+		state.require(config.Blueprint.Name, value.PhaseProvision)
+
+		err = timePhase(config.Blueprint.Name, value.PhaseLoad, func() error {
+			return cluster.LoadData(ctx, config.Blueprint.Cluster.Bucket.Compact, loadMode)
+		})
+		if err != nil {
+			return value.Categorize(interruptedOr(ctx, value.ExitCodeProvision),
+				errors.Wrap(err, "failed to load test dataset"))
+		}
+
+		err = state.record(config.Blueprint.Name, value.PhaseLoad, &value.PhaseRecord{})
+		if err != nil {
+			return errors.Wrap(err, "failed to record load")
+		}
+	}
+
+	// Display how long each of the remote steps took, this makes it easier to spot slow infrastructure
+	fmt.Printf("%s\n", append(cluster.Profiles(), client.Profile()))
+
+	return nil
+}
+
+// provisionMachines provisions the cluster and backup client concurrently.
+func provisionMachines(ctx context.Context, config *value.AutobenchConfig, cluster *nodes.Cluster,
+	client *nodes.BackupClient,
+) error {
+	if config.Blueprint.Cluster.ManageHosts {
+		err := updateHosts(cluster, client)
+		if err != nil {
+			return errors.Wrap(err, "failed to update '/etc/hosts'")
+		}
+	}
+
+	type provisioner interface {
+		Provision(ctx context.Context) error
 	}
 
 	pool := hofp.NewPool(hofp.Options{Size: 2})
 
 	queue := func(p provisioner) error {
-		return pool.Queue(func(_ context.Context) error { return p.Provision() })
+		return pool.Queue(func(_ context.Context) error { return p.Provision(ctx) })
 	}
 
-	for _, p := range provisioners {
+	for _, p := range []provisioner{cluster, client} {
 		if queue(p) != nil {
 			break
 		}
 	}
 
-	err = pool.Stop()
+	return errors.Wrap(pool.Stop(), "unexpected error whilst provisioning")
+}
+
+// updateHosts pushes consistent '/etc/hosts' entries for the cluster nodes to all the nodes and the backup client.
+func updateHosts(cluster *nodes.Cluster, client *nodes.BackupClient) error {
+	entries, err := cluster.HostsEntries()
+	if err != nil {
+		return errors.Wrap(err, "failed to determine entries")
+	}
+
+	err = cluster.UpdateHosts(entries)
 	if err != nil {
-		return errors.Wrap(err, "unexpected error whilst provisioning")
+		return errors.Wrap(err, "failed to update cluster nodes")
 	}
 
-	err = cluster.LoadData(config.Blueprint.Cluster.Bucket.Compact)
+	err = client.UpdateHosts(entries)
 	if err != nil {
-		return errors.Wrap(err, "failed to load test dataset")
+		return errors.Wrap(err, "failed to update backup client")
 	}
 
 	return nil
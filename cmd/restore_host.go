@@ -0,0 +1,95 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// restoreHostOptions encapsulates the possible options which can be used to change the behavior of the 'restore-host'
+// sub-command.
+var restoreHostOptions = struct {
+	configPath string
+}{}
+
+// restoreHostCommand is the restore-host sub-command, used to revert the changes made to shared hosts.
+var restoreHostCommand = &cobra.Command{
+	RunE:  restoreHost,
+	Short: "restore the cluster nodes and backup client to the state they were in before being provisioned",
+	Use:   "restore-host",
+}
+
+// init the flags/arguments for the restore-host sub-command.
+func init() {
+	restoreHostCommand.Flags().StringVarP(
+		&restoreHostOptions.configPath,
+		"config",
+		"c",
+		"",
+		"path to a cbtools-autobench config file",
+	)
+
+	markFlagRequired(restoreHostCommand, "config")
+}
+
+// restoreHost sub-command, this will use the state snapshotted when the hosts were first connected to, to revert any
+// changes made by 'cbtools-autobench' e.g. installed packages and modified configuration files.
+func restoreHost(_ *cobra.Command, _ []string) error {
+	config, err := readConfig(restoreHostOptions.configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	err = confirm(append(connectActions(config), destructiveAction{
+		description: "uninstall the packages installed since the state was snapshotted and restore the snapshotted files",
+		hosts:       append(clusterHosts(config), clientHosts(config)...),
+	})...)
+	if err != nil {
+		return err
+	}
+
+	return forEachEnvironment(config, restoreEnvironment)
+}
+
+// restoreEnvironment restores the cluster/backup client in the given config to their original state.
+func restoreEnvironment(config *value.AutobenchConfig) error {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	err = cluster.RestoreState()
+	if err != nil {
+		return errors.Wrap(err, "failed to restore cluster nodes")
+	}
+
+	err = client.RestoreState()
+	if err != nil {
+		return errors.Wrap(err, "failed to restore backup client")
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// pruneRuns removes the local run directories which don't satisfy the given retention policy (the directory for this
+// run is always kept), returning the ids of the runs which were pruned.
+func pruneRuns(policy *value.RetentionConfig) ([]value.RunID, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	runs, err := runDirectories()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list run directories")
+	}
+
+	pruned := make([]value.RunID, 0)
+
+	for _, dir := range policy.Prune(runs) {
+		fields := log.Fields{"run_id": dir.RunID, "size": dir.Size, "modified": dir.Modified, "dry_run": dryRun}
+		log.WithFields(fields).Info("Removing run directory")
+
+		pruned = append(pruned, dir.RunID)
+
+		if dryRun {
+			continue
+		}
+
+		err = os.RemoveAll(dir.RunID.LocalDirectory())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to remove run directory for run '%s'", dir.RunID)
+		}
+	}
+
+	log.WithFields(log.Fields{"runs": len(runs), "pruned": len(pruned)}).Info("Applied retention policy")
+
+	return pruned, nil
+}
+
+// runDirectories returns the local run directories (excluding the directory for this run), their size and when they
+// were last modified.
+func runDirectories() ([]*value.RunDirectory, error) {
+	entries, err := os.ReadDir(value.RunsDirectory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read runs directory")
+	}
+
+	runs := make([]*value.RunDirectory, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() || value.RunID(entry.Name()) == run {
+			continue
+		}
+
+		dir := &value.RunDirectory{RunID: value.RunID(entry.Name())}
+
+		err = filepath.WalkDir(dir.RunID.LocalDirectory(), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				dir.Size += uint64(info.Size())
+			}
+
+			if info.ModTime().After(dir.Modified) {
+				dir.Modified = info.ModTime()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to walk run directory for run '%s'", dir.RunID)
+		}
+
+		runs = append(runs, dir)
+	}
+
+	return runs, nil
+}
@@ -15,7 +15,25 @@
 package cmd
 
 import (
+	"os"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// run is the unique identifier for this invocation of cbtools-autobench.
+	run value.RunID
+
+	// dryRun indicates that no commands should be run on the cluster nodes/backup client, instead the orchestration is
+	// run against scripted responses; this is useful for validating configs.
+	dryRun bool
+
+	// timeout is the maximum duration of the sub-command, once reached it's cancelled in the same way as an interrupt.
+	timeout time.Duration
 )
 
 // rootCommand represents the root cbtools-autobench command and encapsulates all the supported sub-commands.
@@ -27,10 +45,113 @@ var rootCommand = &cobra.Command{
 
 // init the root command by adding all the supported sub-commands.
 func init() {
-	rootCommand.AddCommand(provisionCommand, benchmarkCommand)
+	rootCommand.PersistentFlags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"validate the config and run the orchestration without connecting to/running commands on any machines",
+	)
+
+	rootCommand.PersistentFlags().DurationVar(
+		&timeout,
+		"timeout",
+		0,
+		"cancel any remote commands and clean up if the sub-command runs for longer than this duration (e.g. '6h')",
+	)
+
+	rootCommand.PersistentFlags().BoolVarP(
+		&assumeYes,
+		"yes",
+		"y",
+		false,
+		"run destructive actions (e.g. uninstalling Couchbase Server or flushing the bucket) without confirmation",
+	)
+
+	rootCommand.PersistentFlags().BoolVar(
+		&assumeYes,
+		"non-interactive",
+		false,
+		"alias for '--yes', required when running destructive sub-commands in automation",
+	)
+
+	rootCommand.PersistentFlags().StringVar(
+		&statePath,
+		"state",
+		value.DefaultStateFile,
+		"path to the state file shared by the phase sub-commands, used to record which phases have completed",
+	)
+
+	rootCommand.PersistentFlags().StringVar(
+		&loggingOptions.handler,
+		"log-handler",
+		"",
+		"the format in which logs are written, either 'cli' or 'json' (overrides the config file)",
+	)
+
+	rootCommand.PersistentFlags().StringVar(
+		&loggingOptions.level,
+		"log-level",
+		"",
+		"the minimum level of the logs which will be written (overrides the config file)",
+	)
+
+	rootCommand.PersistentFlags().StringVar(
+		&loggingOptions.file,
+		"log-file",
+		"",
+		"a file which logs will be tee'd to, 'none' disables writing logs to disk (overrides the config file)",
+	)
+
+	rootCommand.PersistentFlags().StringVar(
+		&webhookOptions.url,
+		"webhook-url",
+		"",
+		"a URL which lifecycle events (e.g. 'run.finished') are posted to as JSON (overrides the config file)",
+	)
+
+	// Misusing the sub-commands/flags is a config error, the flag error func is inherited by all the sub-commands
+	rootCommand.SetFlagErrorFunc(configFlagError)
+
+	rootCommand.AddCommand(provisionCommand, benchmarkCommand, restoreHostCommand, gcCommand, bakeCommand, loadCommand,
+		benchBackupCommand, benchRestoreCommand, reportCommand, describeCommand, archiveCommand, teardownCommand,
+		killRunCommand, noteCommand, exportCommand)
 }
 
-// Execute cbtools-autobench, returning any errors raised during the operation of the chosen sub-command.
-func Execute() error {
-	return rootCommand.Execute()
+// Execute cbtools-autobench using the given run id, returning any errors raised during the operation of the chosen
+// sub-command.
+func Execute(id value.RunID) error {
+	run = id
+
+	err := categorizeUsage(rootCommand.Execute())
+
+	finishWebhook(err)
+
+	return err
+}
+
+// categorizeUsage categorizes the errors returned by cobra before the sub-command is run as config errors i.e. unknown
+// sub-commands and missing required flags, the positional arguments/flags are categorized by their validators.
+func categorizeUsage(err error) error {
+	if err == nil || value.ExitCodeOf(err) != value.ExitCodeFailure {
+		return err
+	}
+
+	command, _, findErr := rootCommand.Find(os.Args[1:])
+	if findErr != nil {
+		return value.Categorize(value.ExitCodeConfig, err)
+	}
+
+	missing := false
+
+	command.Flags().VisitAll(func(flag *pflag.Flag) {
+		required := flag.Annotations[cobra.BashCompOneRequiredFlag]
+		missing = missing || (len(required) != 0 && required[0] == "true" && !flag.Changed)
+	})
+
+	// NOTE: Required flags are validated before the sub-command is run, so it can't have failed for any other reason
+	if missing {
+		return value.Categorize(value.ExitCodeConfig, err)
+	}
+
+	return err
 }
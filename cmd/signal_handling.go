@@ -18,29 +18,52 @@ import (
 	"context"
 	"os"
 	"os/signal"
-	"syscall"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
 
 	"github.com/apex/log"
 )
 
-// signalHandler spawns a goroutine which gracefully handles SIGINT by cancelling the returned context, this can be used
-// to determine if we need to gracefully terminate.
+// signalHandler spawns a goroutine which gracefully handles an interrupt (SIGINT/Ctrl+C) by cancelling the returned
+// context, this can be used to determine if we need to gracefully terminate. The context is also cancelled once the
+// global '--timeout' is reached (if one was given).
+//
+// NOTE: The handler is removed once the context is cancelled, so a second interrupt terminates immediately (skipping
+// any cleanup).
 func signalHandler() context.Context {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+
 	signalStream := make(chan os.Signal, 1)
-	signal.Notify(signalStream, syscall.SIGINT)
+	signal.Notify(signalStream, os.Interrupt)
 
 	go func() {
-		<-signalStream
+		select {
+		case <-signalStream:
+			log.Warn("Received interrupt signal, gracefully terminating")
+		case <-expired:
+			log.WithField("timeout", timeout.String()).Warn("Timeout reached, gracefully terminating")
+		}
 
 		signal.Stop(signalStream)
-		close(signalStream)
-
-		log.Warn("Received interrupt signal, gracefully terminating")
 
 		cancelFunc()
 	}()
 
 	return ctx
 }
+
+// interruptedOr returns the aborted exit code if the given context was cancelled (i.e. a failure was caused by an
+// interrupt or the timeout) otherwise the given exit code.
+func interruptedOr(ctx context.Context, code value.ExitCode) value.ExitCode {
+	if ctx.Err() != nil {
+		return value.ExitCodeAborted
+	}
+
+	return code
+}
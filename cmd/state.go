@@ -0,0 +1,125 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/report"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// statePath is the path to the state file shared by the phase sub-commands.
+var statePath string
+
+// phaseState wraps the state shared by the phase sub-commands, allowing the phases for multiple environments to be
+// recorded concurrently; the state file is rewritten each time a phase is recorded.
+type phaseState struct {
+	lock  sync.Mutex
+	path  string
+	state *value.PhaseState
+}
+
+// readPhaseState reads the state file at the given path, an empty state is returned if the file doesn't exist yet.
+func readPhaseState(path string) (*phaseState, error) {
+	state := &phaseState{path: path, state: value.NewPhaseState()}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read state file")
+	}
+
+	err = json.Unmarshal(data, state.state)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode state file")
+	}
+
+	return state, nil
+}
+
+// record records the completion of the given phase for the given environment, then writes the state file.
+func (p *phaseState) record(environment string, phase value.Phase, record *value.PhaseRecord) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	record.RunID, record.Completed = run, time.Now()
+
+	p.state.Record(environment, phase, record)
+
+	return p.write()
+}
+
+// recordReport records the completion of the given benchmark phase along with the report it produced.
+func (p *phaseState) recordReport(environment string, phase value.Phase, benchmarkReport *report.Report) error {
+	data, err := json.Marshal(benchmarkReport)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal report")
+	}
+
+	return p.record(environment, phase, &value.PhaseRecord{
+		Status:     benchmarkReport.Status,
+		Report:     benchmarkReport.String(),
+		ReportJSON: data,
+	})
+}
+
+// require logs a warning for each of the given phases which haven't been recorded for the environment, they may have
+// been completed without using the phase sub-commands so this isn't an error.
+func (p *phaseState) require(environment string, phases ...value.Phase) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, phase := range phases {
+		if p.state.Get(environment, phase) != nil {
+			continue
+		}
+
+		log.WithFields(log.Fields{"environment": environment, "phase": phase, "state": p.path}).
+			Warn("Phase hasn't been recorded in the state file, results may be invalid if it wasn't completed")
+	}
+}
+
+// write atomically writes the state file, the caller must hold the lock.
+func (p *phaseState) write() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state")
+	}
+
+	err = os.MkdirAll(filepath.Dir(p.path), 0o755)
+	if err != nil {
+		return errors.Wrap(err, "failed to create state directory")
+	}
+
+	temp := p.path + ".tmp"
+
+	err = os.WriteFile(temp, data, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "failed to write state file")
+	}
+
+	return errors.Wrap(os.Rename(temp, p.path), "failed to replace state file")
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/jamesl33/cbtools-autobench/inventory"
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/report"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// benchmarkSweep runs the backup benchmark using a backup client launched for each of the instance types in the sweep
+// config, the same cluster is used throughout so that only the backup client hardware differs between the results.
+func benchmarkSweep(ctx context.Context, config *value.AutobenchConfig) (*report.Report, error) {
+	sweep := config.BenchmarkConfig.ClientSweep
+
+	err := sweep.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid client sweep config")
+	}
+
+	if config.Blueprint.BackupClient == nil {
+		return nil, errors.New("a backup client must be provided, it's used as the template for each instance")
+	}
+
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	var (
+		results  = &value.SweepResults{Config: sweep}
+		profiles value.Profiles
+	)
+
+	for _, instanceType := range sweep.InstanceTypes {
+		benchmarkResults, profile, err := sweepInstanceType(ctx, config, cluster, instanceType)
+
+		result := value.NewSweepResult(sweep, instanceType, benchmarkResults)
+
+		if profile != nil {
+			profiles = append(profiles, profile)
+		}
+
+		if err != nil {
+			// The cluster is shared by every instance type, the remaining results would be misleading
+			var environment *nodes.EnvironmentError
+			if errors.As(err, &environment) {
+				return failureReport(config, "sweep", err), errors.Wrapf(err, "failed to benchmark '%s'", instanceType)
+			}
+
+			log.WithError(err).WithField("instance_type", instanceType).Error("Failed to benchmark instance type")
+
+			result.Error = err.Error()
+		}
+
+		results.Results = append(results.Results, result)
+
+		// If the context has been cancelled, don't benchmark any more instance types
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	stats, err := cluster.Stats()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster stats")
+	}
+
+	hardware, err := cluster.Hardware()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster hardware")
+	}
+
+	return report.NewReport(report.Options{
+		RunID:     run,
+		Status:    value.RunStatusSuccess,
+		CI:        value.DetectCIMetadata(),
+		Blueprint: config.Blueprint,
+		Stats:     stats,
+		Hardware:  hardware,
+		CBMConfig: config.BenchmarkConfig.CBMConfig,
+		Sweep:     results,
+		Profiles:  append(cluster.Profiles(), profiles...),
+		Notes:     runNotes(),
+	}), nil
+}
+
+// sweepInstanceType launches a backup client of the given instance type, provisions it then runs the backup benchmark
+// against the cluster; the instance is always terminated afterwards.
+func sweepInstanceType(ctx context.Context, config *value.AutobenchConfig, cluster *nodes.Cluster,
+	instanceType string,
+) (value.BenchmarkResults, *value.HostProfile, error) {
+	launch := config.BenchmarkConfig.ClientSweep.Launch
+
+	instance, err := inventory.Launch(launch, instanceType, run, dryRun)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to launch instance")
+	}
+
+	defer func() {
+		err := inventory.Terminate(launch, instance, dryRun)
+		if err != nil {
+			log.WithError(err).WithField("id", instance.ID).Error("Failed to terminate EC2 instance, it must be " +
+				"terminated manually")
+		}
+	}()
+
+	blueprint := *config.Blueprint.BackupClient
+	blueprint.Host, blueprint.AWS = instance.Host, nil
+
+	// The instances may have been launched from a baked image, this is checked when provisioning
+	if blueprint.Image == "" {
+		blueprint.Image = launch.ImageID
+	}
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, &blueprint, run)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	directory := filepath.Join(environmentDirectory(run.LocalDirectory(), config.Blueprint), instanceType)
+
+	client.SetLocalDirectory(directory)
+
+	if config.Blueprint.Cluster.ManageHosts {
+		err = updateHosts(cluster, client)
+		if err != nil {
+			return nil, client.Profile(), errors.Wrap(err, "failed to update '/etc/hosts'")
+		}
+	}
+
+	err = client.Provision(ctx)
+	if err != nil {
+		return nil, client.Profile(), errors.Wrap(err, "failed to provision backup client")
+	}
+
+	results, err := client.BenchmarkBackup(ctx, config.BenchmarkConfig, cluster)
+
+	archiveBackupLogs(client, config.BenchmarkConfig, directory)
+
+	if err != nil {
+		return nil, client.Profile(), errors.Wrap(err, "failed to run benchmark(s)")
+	}
+
+	return results, client.Profile(), nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/jamesl33/cbtools-autobench/inventory"
+	"github.com/jamesl33/cbtools-autobench/nodes"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// teardownOptions encapsulates the possible options which can be used to change the behavior of the 'teardown'
+// sub-command.
+var teardownOptions = struct {
+	configPath string
+	teardown   value.TeardownConfig
+}{}
+
+// teardownCommand is the teardown sub-command, used to remove everything created when provisioning the cluster nodes
+// and backup client.
+var teardownCommand = &cobra.Command{
+	RunE:  teardown,
+	Short: "uninstall Couchbase Server and remove everything created by provisioning, optionally terminating instances",
+	Use:   "teardown",
+}
+
+// init the flags/arguments for the teardown sub-command.
+func init() {
+	teardownCommand.Flags().StringVarP(
+		&teardownOptions.configPath,
+		"config",
+		"c",
+		"",
+		"path to a cbtools-autobench config file",
+	)
+
+	teardownCommand.Flags().BoolVar(
+		&teardownOptions.teardown.Terminate,
+		"terminate",
+		false,
+		"terminate the EC2 instances used for the cluster nodes/backup client using the 'aws' CLI",
+	)
+
+	teardownCommand.Flags().StringVar(
+		&teardownOptions.teardown.Region,
+		"region",
+		"",
+		"the region passed to the 'aws' CLI when terminating instances (defaults to the CLI default)",
+	)
+
+	teardownCommand.Flags().StringVar(
+		&teardownOptions.teardown.Profile,
+		"profile",
+		"",
+		"the profile passed to the 'aws' CLI when terminating instances (defaults to the CLI default)",
+	)
+
+	markFlagRequired(teardownCommand, "config")
+}
+
+// teardown sub-command, this will uninstall Couchbase Server from every node described in the blueprint (and the
+// backup client), clean the data/index paths, unmount any devices prepared during provisioning and remove the files
+// uploaded/created by 'cbtools-autobench'.
+func teardown(_ *cobra.Command, _ []string) error {
+	config, err := readConfig(teardownOptions.configPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read autobench config")
+	}
+
+	err = confirm(teardownActions(config, &teardownOptions.teardown)...)
+	if err != nil {
+		return err
+	}
+
+	return forEachEnvironment(config, func(config *value.AutobenchConfig) error {
+		return teardownEnvironment(config, &teardownOptions.teardown)
+	})
+}
+
+// teardownEnvironment tears down the cluster/backup client in the given config, the instances are only terminated once
+// all the hosts have been cleaned up and disconnected from.
+func teardownEnvironment(config *value.AutobenchConfig, teardown *value.TeardownConfig) error {
+	ids, err := teardownHosts(config, teardown)
+	if err != nil {
+		return err
+	}
+
+	err = inventory.TerminateInstances(teardown, ids, dryRun)
+	if err != nil {
+		return errors.Wrap(err, "failed to terminate instances")
+	}
+
+	return nil
+}
+
+// teardownHosts tears down the cluster/backup client in the given config, returning the ids of their EC2 instances when
+// they should be terminated.
+func teardownHosts(config *value.AutobenchConfig, teardown *value.TeardownConfig) ([]string, error) {
+	cluster, err := nodes.NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to cluster")
+	}
+	defer cluster.Close()
+
+	client, err := nodes.NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to backup client")
+	}
+	defer client.Close()
+
+	// Determine the instance ids up front, so that we don't tear down hosts which we're then unable to terminate
+	var ids []string
+
+	if teardown.Terminate {
+		ids, err = cluster.InstanceIDs()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to determine cluster instance ids")
+		}
+
+		var id string
+
+		id, err = client.InstanceID()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to determine backup client instance id")
+		}
+
+		ids = append(ids, id)
+	}
+
+	err = cluster.Teardown()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to teardown cluster")
+	}
+
+	err = client.Teardown()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to teardown backup client")
+	}
+
+	return ids, nil
+}
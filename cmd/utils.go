@@ -15,10 +15,13 @@
 package cmd
 
 import (
+	"context"
 	"os"
 
+	"github.com/jamesl33/cbtools-autobench/inventory"
 	"github.com/jamesl33/cbtools-autobench/value"
 
+	"github.com/couchbase/tools-common/sync/hofp"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -32,11 +35,24 @@ func markFlagRequired(command *cobra.Command, flag string) {
 	}
 }
 
+// configArgs wraps the given positional argument validator so that any errors are categorized as config errors, rather
+// than being returned with the generic exit code.
+func configArgs(validator cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(command *cobra.Command, args []string) error {
+		return value.Categorize(value.ExitCodeConfig, validator(command, args))
+	}
+}
+
+// configFlagError categorizes errors caused by unknown flags or invalid flag values as config errors.
+func configFlagError(_ *cobra.Command, err error) error {
+	return value.Categorize(value.ExitCodeConfig, err)
+}
+
 // readConfig is a utility function to read and decode the autobench config file at the given path.
 func readConfig(path string) (*value.AutobenchConfig, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to open config file")
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "failed to open config file"))
 	}
 	defer file.Close()
 
@@ -44,8 +60,76 @@ func readConfig(path string) (*value.AutobenchConfig, error) {
 
 	err = yaml.NewDecoder(file).Decode(&config)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode config file")
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "failed to decode config file"))
+	}
+
+	err = setupLogging(config.Logging)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to setup logging")
+	}
+
+	recordConfig(path)
+
+	err = setupWebhook(config.Webhook)
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid webhook config"))
+	}
+
+	if config.Blueprint == nil {
+		return config, nil
+	}
+
+	err = config.Blueprint.ValidateEnvironments()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid environments"))
+	}
+
+	for _, blueprint := range config.Blueprint.Split() {
+		err = inventory.Resolve(blueprint, dryRun)
+		if err != nil {
+			return nil, value.Categorize(value.ExitCodeProvision, errors.Wrap(err, "failed to resolve inventory"))
+		}
+
+		err = blueprint.Expand()
+		if err != nil {
+			return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "failed to expand blueprint"))
+		}
+	}
+
+	recordResolvedConfig(config)
+
+	if dryRun {
+		for _, blueprint := range config.Blueprint.Split() {
+			blueprint.DryRun()
+		}
 	}
 
 	return config, nil
 }
+
+// forEachEnvironment runs the given function concurrently for each of the environments in the config, the config
+// passed to the function only contains the blueprint for a single environment.
+func forEachEnvironment(config *value.AutobenchConfig, fn func(config *value.AutobenchConfig) error) error {
+	environments := config.Blueprint.Split()
+
+	pool := hofp.NewPool(hofp.Options{Size: len(environments)})
+
+	queue := func(blueprint *value.Blueprint) error {
+		return pool.Queue(func(_ context.Context) error {
+			err := fn(config.WithBlueprint(blueprint))
+			if err != nil && blueprint.Name != "" {
+				return errors.Wrapf(err, "environment '%s'", blueprint.Name)
+			}
+
+			return err
+		})
+	}
+
+	for _, blueprint := range environments {
+		if queue(blueprint) != nil {
+			break
+		}
+	}
+
+	return pool.Stop()
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/report"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// webhookAttempts is the number of times posting each event is attempted before giving up.
+const webhookAttempts = 3
+
+// webhookOptions encapsulates the global flags which can be used to override the webhook config.
+var webhookOptions = struct {
+	url string
+}{}
+
+// webhook is the webhook which lifecycle events are posted to (if configured), along with the state required to
+// summarize the run once it's finished.
+var webhook = struct {
+	lock    sync.Mutex
+	config  *value.WebhookConfig
+	started time.Time
+	reports []*value.BenchmarkReport
+}{}
+
+// setupWebhook configures the webhook using the given config (the '--webhook-url' flag takes precedence), then posts
+// the 'run.started' event. Nothing is posted when no URL is configured.
+func setupWebhook(config *value.WebhookConfig) error {
+	merged := value.WebhookConfig{}
+	if config != nil {
+		merged = *config
+	}
+
+	if webhookOptions.url != "" {
+		merged.URL = webhookOptions.url
+	}
+
+	if merged.URL == "" {
+		return nil
+	}
+
+	err := merged.Validate()
+	if err != nil {
+		return err
+	}
+
+	webhook.lock.Lock()
+	webhook.config, webhook.started = &merged, time.Now()
+	webhook.lock.Unlock()
+
+	postWebhook(&value.WebhookPayload{Event: value.WebhookEventRunStarted})
+
+	return nil
+}
+
+// recordWebhookPhase posts the 'phase.completed' event for the given phase.
+func recordWebhookPhase(timing *value.PhaseTiming) {
+	postWebhook(&value.WebhookPayload{Event: value.WebhookEventPhaseCompleted, Phase: timing})
+}
+
+// recordWebhookReport records the machine readable form of the given report so that it's included in the summary of
+// the run.
+func recordWebhookReport(benchmarkReport *report.Report) {
+	webhook.lock.Lock()
+	defer webhook.lock.Unlock()
+
+	if webhook.config != nil {
+		webhook.reports = append(webhook.reports, benchmarkReport.BenchmarkReport())
+	}
+}
+
+// finishWebhook posts the 'run.finished' event, summarizing the run which completed with the given error (or nil).
+func finishWebhook(err error) {
+	webhook.lock.Lock()
+	if webhook.config == nil {
+		webhook.lock.Unlock()
+		return
+	}
+
+	var (
+		duration = time.Since(webhook.started)
+		reports  = webhook.reports
+	)
+
+	webhook.lock.Unlock()
+
+	manifest.lock.Lock()
+
+	var phases value.PhaseTimings
+	if manifest.manifest != nil {
+		phases = append(phases, manifest.manifest.Phases...)
+	}
+
+	manifest.lock.Unlock()
+
+	postWebhook(&value.WebhookPayload{
+		Event:   value.WebhookEventRunFinished,
+		Summary: value.NewRunSummary(err, duration, phases, reports),
+	})
+}
+
+// postWebhook posts the given event to the webhook (if configured and enabled for the event), the common fields are
+// populated before it's sent. Events aren't posted in dry-run mode, since they may trigger downstream jobs.
+//
+// NOTE: Failing to post an event isn't fatal, it doesn't affect the outcome of the run.
+func postWebhook(payload *value.WebhookPayload) {
+	webhook.lock.Lock()
+	config := webhook.config
+	webhook.lock.Unlock()
+
+	if config == nil || !config.Enabled(payload.Event) {
+		return
+	}
+
+	payload.RunID, payload.Command, payload.Time = run, webhookCommand(), time.Now()
+	payload.DryRun, payload.CI = dryRun, value.DetectCIMetadata()
+
+	fields := log.Fields{"event": payload.Event, "url": config.URL}
+
+	if dryRun {
+		log.WithFields(fields).Info("Skipping posting webhook event in dry-run mode")
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.WithError(err).WithFields(fields).Warn("Failed to marshal webhook event")
+		return
+	}
+
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = sendWebhook(config, body)
+		if err == nil {
+			log.WithFields(fields).Debug("Posted webhook event")
+			return
+		}
+
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+
+	log.WithError(err).WithFields(fields).Warn("Failed to post webhook event")
+}
+
+// webhookCommand returns the name of the sub-command being run e.g. 'benchmark backup', positional arguments are only
+// included when they're restricted to a set of valid values.
+//
+// NOTE: The flags aren't included, they may contain secrets (e.g. the webhook URL itself) which shouldn't be sent to an
+// external service.
+func webhookCommand() string {
+	command, _, err := rootCommand.Find(os.Args[1:])
+	if err != nil {
+		return ""
+	}
+
+	name := strings.TrimSpace(command.CommandPath())
+
+	if len(command.ValidArgs) != 0 && len(command.Flags().Args()) != 0 {
+		name += " " + strings.Join(command.Flags().Args(), " ")
+	}
+
+	return name
+}
+
+// sendWebhook posts the given body to the webhook, returning an error if it doesn't respond with a 2xx status code.
+func sendWebhook(config *value.WebhookConfig, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.TimeoutOrDefault())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "cbtools-autobench")
+
+	if config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
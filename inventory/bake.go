@@ -0,0 +1,54 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// dryRunImage is the id of every image created during a dry run.
+const dryRunImage = "ami-dry-run"
+
+// CreateImage uses the 'aws' CLI to create an image from the given instance, waiting until it's available so that it
+// may be used to launch instances.
+func CreateImage(config *value.BakeConfig, instanceID string, dryRun bool) (string, error) {
+	if dryRun {
+		return dryRunImage, nil
+	}
+
+	fields := log.Fields{"instance_id": instanceID, "name": config.Name}
+	log.WithFields(fields).Info("Creating image")
+
+	output, err := runAWS(config.ArgsCreateImage(instanceID))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create image")
+	}
+
+	id := strings.TrimSpace(string(output))
+
+	log.WithFields(fields).WithField("id", id).Info("Waiting for image to become available")
+
+	_, err = runAWS(config.ArgsWait(id))
+	if err != nil {
+		return "", errors.Wrapf(err, "image '%s' did not become available", id)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+)
+
+// CloudWatchMetrics uses the 'aws' CLI to fetch the EC2/EBS metrics for the given instance (and the volumes attached to
+// it) between the start/end from CloudWatch.
+func CloudWatchMetrics(config *value.CloudWatchConfig, host, instanceID string, start, end time.Time,
+) (value.CloudWatchMetrics, error) {
+	volumes, err := describeVolumes(config.ArgsVolumes(instanceID))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(value.CloudWatchMetrics, 0)
+
+	fetch := func(resource string, metric *value.CloudWatchMetric) error {
+		samples, err := getStatistics(config.ArgsStatistics(metric, resource, start, end), metric.Statistic)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get '%s' for '%s'", metric.Metric, resource)
+		}
+
+		metrics = append(metrics, value.NewCloudWatchSeries(host, resource, metric, samples))
+
+		return nil
+	}
+
+	for _, metric := range value.EC2Metrics {
+		if err := fetch(instanceID, metric); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, volume := range volumes {
+		for _, metric := range value.EBSMetrics {
+			if err := fetch(volume.VolumeID, metric); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// describeVolumes runs 'aws ec2 describe-volumes' with the given arguments, returning the decoded volumes.
+func describeVolumes(args []string) ([]volume, error) {
+	output, err := runAWS(args)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe volumes")
+	}
+
+	var decoded []volume
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode volumes")
+	}
+
+	return decoded, nil
+}
+
+// getStatistics runs 'aws cloudwatch get-metric-statistics' with the given arguments, returning the value of the given
+// statistic for each datapoint ordered by timestamp.
+func getStatistics(args []string, statistic string) ([]value.CloudWatchSample, error) {
+	type overlay struct {
+		Datapoints []map[string]interface{} `json:"Datapoints"`
+	}
+
+	output, err := runAWS(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded overlay
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode statistics")
+	}
+
+	samples := make([]value.CloudWatchSample, 0, len(decoded.Datapoints))
+
+	for _, datapoint := range decoded.Datapoints {
+		raw, _ := datapoint["Timestamp"].(string)
+
+		timestamp, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse timestamp")
+		}
+
+		statistic, _ := datapoint[statistic].(float64)
+
+		samples = append(samples, value.CloudWatchSample{Timestamp: timestamp, Value: statistic})
+	}
+
+	// CloudWatch doesn't return datapoints in any particular order
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+
+	return samples, nil
+}
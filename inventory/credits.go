@@ -0,0 +1,91 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+)
+
+// BurstableResources uses the 'aws' CLI to determine whether the given instance (and the volumes attached to it) are
+// burstable, returning those whose credit balances should be monitored.
+func BurstableResources(config *value.CreditsConfig, host, instanceID string) ([]*value.CreditResource, error) {
+	output, err := runAWS(config.ArgsInstanceType(instanceID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe instance")
+	}
+
+	resources := make([]*value.CreditResource, 0)
+
+	if instanceType := strings.TrimSpace(string(output)); value.IsBurstableInstance(instanceType) {
+		resources = append(resources, &value.CreditResource{
+			Host:  host,
+			Type:  value.CreditResourceCPU,
+			ID:    instanceID,
+			Class: instanceType,
+		})
+	}
+
+	volumes, err := describeVolumes(config.ArgsVolumes(instanceID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		if !value.IsBurstableVolume(volume.VolumeType) {
+			continue
+		}
+
+		resources = append(resources, &value.CreditResource{
+			Host:  host,
+			Type:  value.CreditResourceEBS,
+			ID:    volume.VolumeID,
+			Class: volume.VolumeType,
+		})
+	}
+
+	return resources, nil
+}
+
+// CreditBalances uses the 'aws' CLI to fetch the credit balances of the given resources between the start/end from
+// CloudWatch.
+func CreditBalances(config *value.CreditsConfig, resources []*value.CreditResource, start, end time.Time,
+) (value.CreditBalances, error) {
+	balances := make(value.CreditBalances, 0, len(resources))
+
+	for _, resource := range resources {
+		samples, err := getStatistics(config.ArgsStatistics(resource, start, end), "Minimum")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get credit balance for '%s'", resource.ID)
+		}
+
+		balance := &value.CreditBalance{Resource: resource, Samples: make([]value.CreditSample, 0, len(samples))}
+
+		for _, sample := range samples {
+			balance.Samples = append(balance.Samples, value.CreditSample{
+				Timestamp: sample.Timestamp,
+				Minimum:   sample.Value,
+			})
+		}
+
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
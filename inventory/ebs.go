@@ -0,0 +1,127 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// modificationTimeout is the maximum amount of time we'll wait for a volume modification to take effect.
+const modificationTimeout = 10 * time.Minute
+
+// volume is the subset of the description returned by 'aws ec2 describe-volumes' required to modify a volume.
+type volume struct {
+	VolumeID   string `json:"VolumeId"`
+	VolumeType string `json:"VolumeType"`
+	Size       int    `json:"Size"`
+	Iops       int    `json:"Iops"`
+	Throughput int    `json:"Throughput"`
+}
+
+// ModifyVolumes uses the 'aws' CLI to modify the EBS volumes attached to the given instance so that they match the
+// blueprint, volumes which already match are skipped since a volume may only be modified once every six hours.
+func ModifyVolumes(config *value.EBSBlueprint, instanceID string, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	for _, blueprint := range config.Volumes {
+		err := modifyVolume(config, blueprint, instanceID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to modify volume '%s'", blueprint.Device)
+		}
+	}
+
+	return nil
+}
+
+// modifyVolume modifies the volume attached to the given instance as the device in the blueprint, waiting until the
+// modification has taken effect.
+func modifyVolume(config *value.EBSBlueprint, blueprint *value.EBSVolumeBlueprint, instanceID string) error {
+	output, err := runAWS(config.ArgsDescribe(instanceID, blueprint.Device))
+	if err != nil {
+		return errors.Wrap(err, "failed to describe volume")
+	}
+
+	var decoded []volume
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode volume")
+	}
+
+	if len(decoded) != 1 {
+		return fmt.Errorf("expected to find one volume attached to '%s', but found %d", instanceID, len(decoded))
+	}
+
+	current := decoded[0]
+
+	fields := log.Fields{
+		"instance_id": instanceID,
+		"volume_id":   current.VolumeID,
+		"device":      blueprint.Device,
+		"type":        blueprint.TypeOrDefault(),
+	}
+
+	if blueprint.Matches(current.VolumeType, current.Size, current.Iops, current.Throughput) {
+		log.WithFields(fields).Info("EBS volume already matches blueprint, skipping modification")
+		return nil
+	}
+
+	log.WithFields(fields).Info("Modifying EBS volume")
+
+	_, err = runAWS(config.ArgsModify(blueprint, current.VolumeID))
+	if err != nil {
+		return errors.Wrap(err, "failed to modify volume")
+	}
+
+	return waitForModification(config, current.VolumeID)
+}
+
+// waitForModification waits until the modification of the volume with the given id has taken effect i.e. it's
+// 'optimizing' or 'completed'.
+//
+// NOTE: Performance whilst the volume is 'optimizing' may be somewhere between the old/new settings.
+func waitForModification(config *value.EBSBlueprint, id string) error {
+	args := append([]string{
+		"ec2", "describe-volumes-modifications", "--volume-ids", id, "--output", "text",
+		"--query", "VolumesModifications[0].ModificationState",
+	}, config.ArgsGlobal()...)
+
+	for start := time.Now(); time.Since(start) < modificationTimeout; time.Sleep(15 * time.Second) {
+		output, err := runAWS(args)
+		if err != nil {
+			return errors.Wrap(err, "failed to describe volume modification")
+		}
+
+		switch state := strings.TrimSpace(string(output)); state {
+		case "optimizing", "completed":
+			log.WithFields(log.Fields{"volume_id": id, "state": state}).Info("EBS volume modification took effect")
+			return nil
+		case "failed":
+			return errors.New("volume modification failed")
+		}
+	}
+
+	return fmt.Errorf("timed out after %s waiting for volume modification", modificationTimeout)
+}
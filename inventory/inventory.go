@@ -0,0 +1,174 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory provides providers which discover the hosts used in the blueprint e.g. by querying AWS.
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// dryRunHost is the host used for every inventory during a dry run, since we shouldn't be querying any real
+// infrastructure.
+const dryRunHost = "dry-run.inventory"
+
+// Resolve replaces each node (and the backup client) in the blueprint which uses an inventory with the hosts it
+// discovers; nodes are copied for each host, the backup client inventory must match exactly one host.
+func Resolve(blueprint *value.Blueprint, dryRun bool) error {
+	if blueprint.Cluster != nil {
+		nodes := make([]*value.NodeBlueprint, 0, len(blueprint.Cluster.Nodes))
+
+		for _, node := range blueprint.Cluster.Nodes {
+			if node.AWS == nil {
+				nodes = append(nodes, node)
+				continue
+			}
+
+			hosts, err := resolveAWS(node.AWS, dryRun)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve cluster nodes")
+			}
+
+			for _, host := range hosts {
+				copied := *node
+				copied.Host, copied.AWS = host, nil
+
+				nodes = append(nodes, &copied)
+			}
+		}
+
+		blueprint.Cluster.Nodes = nodes
+	}
+
+	if blueprint.BackupClient == nil || blueprint.BackupClient.AWS == nil {
+		return nil
+	}
+
+	hosts, err := resolveAWS(blueprint.BackupClient.AWS, dryRun)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve backup client")
+	}
+
+	if len(hosts) != 1 {
+		return fmt.Errorf("backup client inventory must match exactly one instance, but matched %d", len(hosts))
+	}
+
+	blueprint.BackupClient.Host, blueprint.BackupClient.AWS = hosts[0], nil
+
+	return nil
+}
+
+// resolveAWS uses the 'aws' CLI to find the running instances matching the given inventory, the hosts are sorted by
+// instance id so that the order is stable across runs.
+func resolveAWS(inventory *value.AWSInventory, dryRun bool) ([]string, error) {
+	if dryRun {
+		return []string{dryRunHost}, nil
+	}
+
+	log.WithField("tags", inventory.Tags).Info("Discovering EC2 instances")
+
+	output, err := runAWS(inventory.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded []instance
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode instances")
+	}
+
+	if len(decoded) == 0 {
+		return nil, fmt.Errorf("no running instances match the tags %v", inventory.Tags)
+	}
+
+	sort.Slice(decoded, func(i, j int) bool { return decoded[i].InstanceID < decoded[j].InstanceID })
+
+	hosts := make([]string, 0, len(decoded))
+
+	for _, found := range decoded {
+		host, err := found.address(inventory.AddressOrDefault())
+		if err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	log.WithFields(log.Fields{"tags": inventory.Tags, "hosts": hosts}).Info("Discovered EC2 instances")
+
+	return hosts, nil
+}
+
+// instance is the subset of the output of 'aws ec2 describe-instances' used to determine the host for an instance.
+type instance struct {
+	InstanceID       string `json:"InstanceId"`
+	PrivateIPAddress string `json:"PrivateIpAddress"`
+	PublicIPAddress  string `json:"PublicIpAddress"`
+	PrivateDNSName   string `json:"PrivateDnsName"`
+	PublicDNSName    string `json:"PublicDnsName"`
+}
+
+// address returns the address of the instance of the given type, an error is returned if the instance doesn't have one.
+func (i instance) address(address value.AWSAddressType) (string, error) {
+	var host string
+
+	switch address {
+	case value.AWSAddressPrivateIP:
+		host = i.PrivateIPAddress
+	case value.AWSAddressPublicIP:
+		host = i.PublicIPAddress
+	case value.AWSAddressPrivateDNS:
+		host = i.PrivateDNSName
+	case value.AWSAddressPublicDNS:
+		host = i.PublicDNSName
+	default:
+		return "", fmt.Errorf("unsupported address type '%s'", address)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("instance '%s' does not have a '%s' address", i.InstanceID, address)
+	}
+
+	return host, nil
+}
+
+// runAWS runs the 'aws' CLI with the given arguments returning its output.
+func runAWS(args []string) ([]byte, error) {
+	var (
+		stdout  = &bytes.Buffer{}
+		stderr  = &bytes.Buffer{}
+		command = exec.Command("aws", args...)
+	)
+
+	command.Stdout, command.Stderr = stdout, stderr
+
+	err := command.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run 'aws': %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// dryRunInstance is the id of every instance launched during a dry run.
+const dryRunInstance = "i-dry-run"
+
+// Instance is an EC2 instance launched by 'cbtools-autobench', it must be terminated once it's no longer required.
+type Instance struct {
+	ID   string
+	Host string
+}
+
+// Launch uses the 'aws' CLI to launch an instance of the given type, waiting until it has passed its status checks so
+// that it's ready to be connected to.
+func Launch(config *value.AWSLaunchConfig, instanceType string, run value.RunID, dryRun bool) (*Instance, error) {
+	if dryRun {
+		return &Instance{ID: dryRunInstance, Host: dryRunHost}, nil
+	}
+
+	log.WithField("instance_type", instanceType).Info("Launching EC2 instance")
+
+	output, err := runAWS(config.ArgsRun(instanceType, run))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to launch instance")
+	}
+
+	launched := &Instance{ID: strings.TrimSpace(string(output))}
+
+	log.WithFields(log.Fields{"instance_type": instanceType, "id": launched.ID}).
+		Info("Waiting for EC2 instance to become ready")
+
+	_, err = runAWS(config.ArgsWait(launched.ID))
+	if err == nil {
+		launched.Host, err = describe(config, launched.ID)
+	}
+
+	// The instance has been launched so it must be terminated, even though it's not usable
+	if err != nil {
+		return nil, errors.Wrapf(err, "instance '%s' did not become ready (terminated: %t)", launched.ID,
+			Terminate(config, launched, false) == nil)
+	}
+
+	log.WithFields(log.Fields{"id": launched.ID, "host": launched.Host}).Info("Launched EC2 instance")
+
+	return launched, nil
+}
+
+// Terminate uses the 'aws' CLI to terminate the given instance.
+func Terminate(config *value.AWSLaunchConfig, instance *Instance, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	log.WithField("id", instance.ID).Info("Terminating EC2 instance")
+
+	_, err := runAWS(config.ArgsTerminate(instance.ID))
+
+	return err
+}
+
+// TerminateInstances uses the 'aws' CLI to terminate the instances with the given ids, for example those used by the
+// cluster nodes/backup client when tearing them down.
+func TerminateInstances(config *value.TeardownConfig, ids []string, dryRun bool) error {
+	if dryRun || len(ids) == 0 {
+		return nil
+	}
+
+	log.WithField("ids", ids).Info("Terminating EC2 instances")
+
+	_, err := runAWS(config.ArgsTerminate(ids))
+
+	return err
+}
+
+// describe returns the host which should be used to connect to the instance with the given id.
+func describe(config *value.AWSLaunchConfig, id string) (string, error) {
+	output, err := runAWS(config.ArgsDescribe(id))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to describe instance")
+	}
+
+	var decoded []instance
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode instance")
+	}
+
+	if len(decoded) != 1 {
+		return "", fmt.Errorf("expected to find one instance, but found %d", len(decoded))
+	}
+
+	return decoded[0].address(config.AddressOrDefault())
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/jamesl33/cbtools-autobench/cmd"
 	"github.com/jamesl33/cbtools-autobench/utilities"
+	"github.com/jamesl33/cbtools-autobench/value"
 
 	"github.com/apex/log"
 	"github.com/pkg/errors"
@@ -28,7 +29,13 @@ import (
 
 // main will setup logging, then execute cbtools-autobench.
 func main() {
-	log.SetHandler(utilities.NewLoggingHandler())
+	run, err := value.NewRunID()
+	if err != nil {
+		fmt.Printf("Error: %s\n", errors.Cause(err))
+		os.Exit(1)
+	}
+
+	log.SetHandler(utilities.NewLoggingHandler(log.Fields{"run_id": run}))
 
 	level, err := log.ParseLevel(os.Getenv("CBM_AUTOBENCH_LOG_LEVEL"))
 	if err != nil {
@@ -37,13 +44,13 @@ func main() {
 
 	log.SetLevel(level)
 
-	err = cmd.Execute()
+	err = cmd.Execute(run)
 	if err == nil {
 		return
 	}
 
-	// The sub-command failed for some reason, ensure that we exit with a non-zero exit code
-	defer os.Exit(1)
+	// The sub-command failed for some reason, ensure that we exit with the non-zero exit code for the category of error
+	defer os.Exit(int(value.ExitCodeOf(err)))
 
 	stacktrace := os.Getenv("CBM_AUTOBENCH_DISPLAY_STACKTRACE")
 	if display, _ := strconv.ParseBool(stacktrace); display {
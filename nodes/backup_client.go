@@ -33,14 +33,36 @@ import (
 type BackupClient struct {
 	blueprint *value.BackupClientBlueprint
 	node      *Node
+
+	// tools is the package containing the 'cbbackupmgr' binaries in use when they're not those from the installed
+	// package e.g. during the compatibility benchmark, the commands are built for its version.
+	tools *value.Package
+
+	// directory is the local directory which artifacts (e.g. core dumps) are downloaded into, by default this is the
+	// run directory.
+	directory string
 }
 
 // NewBackupClient will connect to a backup client using the provided config.
-func NewBackupClient(config *value.SSHConfig, blueprint *value.BackupClientBlueprint) (*BackupClient, error) {
-	node, err := NewNode(config, &value.NodeBlueprint{Host: blueprint.Host})
+func NewBackupClient(config *value.SSHConfig, blueprint *value.BackupClientBlueprint, run value.RunID,
+) (*BackupClient, error) {
+	nb := &value.NodeBlueprint{
+		Host:          blueprint.Host,
+		Transport:     blueprint.Transport,
+		Platform:      blueprint.Platform,
+		InstanceStore: blueprint.InstanceStore,
+		TieredStorage: blueprint.TieredStorage,
+		EBS:           blueprint.EBS,
+		Readiness:     blueprint.Readiness,
+		RemoteTmpDir:  blueprint.RemoteTmpDir,
+	}
+
+	node, err := NewNode(config, nb, blueprint.Package(), run)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to node")
 	}
+
+	node.image = blueprint.Image
 	//
 	// err = node.checkAndPartitionEBS()
 	// if err != nil {
@@ -53,12 +75,35 @@ func NewBackupClient(config *value.SSHConfig, blueprint *value.BackupClientBluep
 	}, nil
 }
 
+// SetLocalDirectory sets the local directory which artifacts are downloaded into, this is used to separate the
+// artifacts of each environment when they're benchmarked concurrently.
+func (b *BackupClient) SetLocalDirectory(directory string) {
+	b.directory = directory
+}
+
+// localDirectory returns the local directory which artifacts should be downloaded into.
+func (b *BackupClient) localDirectory() string {
+	if b.directory == "" {
+		return b.node.run.LocalDirectory()
+	}
+
+	return b.directory
+}
+
+// Bake installs the dependencies/package on the backup client without configuring Couchbase Server, returning the id
+// of the instance which an image should be created from.
+func (b *BackupClient) Bake(ctx context.Context) (string, error) {
+	log.WithField("host", b.blueprint.Host).Info("Baking backup client")
+
+	return b.node.bake(ctx)
+}
+
 // Provision will use the client blueprint to provision the backup client, note that if the client is already
 // provisioned it will be re-provisioned i.e. we will remove then install Couchbase.
-func (b *BackupClient) Provision() error {
+func (b *BackupClient) Provision(ctx context.Context) error {
 	log.WithField("host", b.blueprint.Host).Info("Provisioning backup client")
 
-	err := b.node.provision(b.blueprint.PackagePath)
+	err := b.node.provision(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to provision node")
 	}
@@ -82,26 +127,21 @@ func (b *BackupClient) CollectLogs(config *value.BenchmarkConfig, path string) (
 		return "", errors.Wrap(err, "failed to run 'collect-logs'")
 	}
 
-	local := config.CBMConfig.Archive
-	if config.CBMConfig.ObjStagingDirectory != "" {
-		local = config.CBMConfig.ObjStagingDirectory
-	}
-
 	output, err := b.node.client.ExecuteCommand(
-		value.NewCommand(`ls -t %s | head -1`, filepath.Join(local, "logs", "*.zip")))
+		value.NewCommand(`ls -t %s | head -1`, value.RemoteJoin(config.CBMConfig.LogsDirectory(), "*.zip")))
 	if err != nil {
 		return "", errors.Wrap(err, "failed to determine which zip file to cp/download")
 	}
 
 	var (
 		source = strings.TrimSpace(string(output))
-		sink   = filepath.Join(path, filepath.Base(source))
+		sink   = filepath.Join(path, value.RemoteBase(source))
 	)
 
 	fields := log.Fields{"source": source, "sink": sink}
 	log.WithFields(fields).Info("Downloading 'cbbackupmgr' logs")
 
-	err = b.node.client.SecureDownload(source, sink)
+	err = b.node.client.SecureDownload(context.Background(), source, sink)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to cp/download logs")
 	}
@@ -109,14 +149,62 @@ func (b *BackupClient) CollectLogs(config *value.BenchmarkConfig, path string) (
 	return sink, nil
 }
 
+// ArchiveLogs will archive the 'cbbackupmgr' logs directory on the backup client then download the archive into the
+// provided directory, returning the path to the downloaded archive.
+func (b *BackupClient) ArchiveLogs(config *value.BenchmarkConfig, path string) (string, error) {
+	log.WithField("path", path).Info("Archiving 'cbbackupmgr' logs directory")
+
+	directory := b.node.tempDirectory()
+
+	err := b.node.client.CreateDirectory(directory)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	var (
+		source = value.RemoteJoin(directory, "cbbackupmgr-logs.tar.gz")
+		sink   = filepath.Join(path, value.RemoteBase(source))
+	)
+
+	_, err = b.node.client.ExecuteCommand(config.CBMConfig.CommandArchiveLogs(source))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to archive logs directory")
+	}
+
+	err = b.node.client.SecureDownload(context.Background(), source, sink)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to download logs archive")
+	}
+
+	err = b.node.client.RemoveFile(source)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to remove logs archive")
+	}
+
+	return sink, nil
+}
+
 // BenchmarkBackup will run one or more backup benchmarks on the client using the provided benchmark config. If the
-// provided context is cancelled, we will gracefully complete the current backup then return early.
+// provided context is cancelled, the current backup is killed and the results of the completed backups are returned.
 func (b *BackupClient) BenchmarkBackup(ctx context.Context, config *value.BenchmarkConfig,
 	cluster *Cluster,
 ) (value.BenchmarkResults, error) {
 	log.WithField("iterations", config.Iterations).Info("Beginning 'cbbackupmgr' backup benchmark(s)")
 
-	err := b.purgeArchive(config)
+	defer b.enableCoreDumps()()
+	defer cluster.destroySnapshot()
+
+	err := cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	err = cluster.waitUntilSettled(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for cluster to settle")
+	}
+
+	err = b.purgeArchive(config)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to purge archive")
 	}
@@ -131,11 +219,46 @@ func (b *BackupClient) BenchmarkBackup(ctx context.Context, config *value.Benchm
 	for iteration := 0; iteration < maths.Max(1, config.Iterations); iteration++ {
 		log.WithField("iteration", iteration+1).Info("Beginning 'cbbackupmgr' backup benchmark")
 
-		result, err := b.benchmarkBackup(config, cluster)
+		// The live workload mutates the dataset, reset it so that every iteration backs up the same data
+		if config.LiveWorkload != nil {
+			err = cluster.resetData(ctx, iteration)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to reset dataset")
+			}
+		}
+
+		var before *value.PhaseSummary
+		if config.LiveWorkload != nil {
+			before, err = cluster.capturePhase(config.LiveWorkload)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to capture latency before benchmark")
+			}
+		}
+
+		result, err := b.benchmarkBackup(ctx, config, cluster)
+		if err != nil && ctx.Err() != nil && len(results) != 0 {
+			break
+		}
+
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to run benchmark")
 		}
 
+		if config.LiveWorkload != nil {
+			result.Workload.Before = before
+
+			result.Workload.After, err = cluster.capturePhase(config.LiveWorkload)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to capture latency after benchmark")
+			}
+		}
+
+		// Abort if the cluster health degraded during the benchmark, the result would be misleading
+		err = cluster.checkHealth()
+		if err != nil {
+			return nil, errors.Wrap(err, "cluster health check failed")
+		}
+
 		results = append(results, result)
 
 		// If the context has been cancelled, don't run any more benchmarks; the user wants to gracefully terminate
@@ -148,13 +271,26 @@ func (b *BackupClient) BenchmarkBackup(ctx context.Context, config *value.Benchm
 }
 
 // BenchmarkRestore will run one or more restore benchmarks on the client using the providing benchmark config. If the
-// provided context is cancelled, we will gracefully complete the current restore then return early.
+// provided context is cancelled, the current restore is killed and the results of the completed restores are returned.
 func (b *BackupClient) BenchmarkRestore(ctx context.Context, config *value.BenchmarkConfig,
 	cluster *Cluster,
 ) (value.BenchmarkResults, error) {
 	log.WithField("iterations", config.Iterations).Info("Beginning 'cbbackupmgr' restore benchmark(s)")
 
-	err := b.purgeArchive(config)
+	defer b.enableCoreDumps()()
+	defer cluster.destroySnapshot()
+
+	err := cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	err = cluster.waitUntilSettled(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for cluster to settle")
+	}
+
+	err = b.purgeArchive(config)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to purge archive")
 	}
@@ -164,7 +300,33 @@ func (b *BackupClient) BenchmarkRestore(ctx context.Context, config *value.Bench
 		return nil, errors.Wrap(err, "failed to create repository")
 	}
 
-	backupInfo, err := b.createBackup(config, cluster, true)
+	// Capture the expected query results before the backup, so restores may be validated against the original data
+	var expected map[string]uint64
+	if len(config.Queries) != 0 && !config.CBMConfig.Blackhole {
+		expected, err = cluster.runQueries(config.Queries)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to run queries before backup")
+		}
+	}
+
+	// Sample the document expiries before the backup, so restores may be validated against the original expiries
+	var expiries map[string]uint64
+	if config.ExpiryValidation != nil && !config.CBMConfig.Blackhole {
+		expiries, err = cluster.sampleExpiries(config.ExpiryValidation)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to sample document expiries before backup")
+		}
+	}
+
+	services := &value.RecoverableServices{}
+	if !config.CBMConfig.Blackhole {
+		services, err = cluster.recoverableServices()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get services which will be recovered")
+		}
+	}
+
+	source, err := b.benchmarkSourceBackup(ctx, config, cluster)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create backup")
 	}
@@ -174,16 +336,61 @@ func (b *BackupClient) BenchmarkRestore(ctx context.Context, config *value.Bench
 	for iteration := 0; iteration < maths.Max(1, config.Iterations); iteration++ {
 		log.WithField("iteration", iteration+1).Info("Beginning 'cbbackupmgr' restore benchmark")
 
+		switch {
+		case config.CBMConfig.Blackhole:
+		case config.CBMConfig.AutoCreateBuckets:
+			for _, name := range cluster.blueprint.BucketNames() {
+				if err == nil {
+					err = cluster.deleteBucket(name)
+				}
+			}
+		default:
+			err = cluster.emptyBucket(ctx, iteration)
+			if err == nil {
+				err = cluster.dropIndexes()
+			}
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to empty bucket")
+		}
+
+		result, err := b.benchmarkRestore(ctx, config, cluster, source)
+		if err != nil && ctx.Err() != nil && len(results) != 0 {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to run benchmark")
+		}
+
+		// The indexes/eventing functions become operational once the restore completes, they're timed separately since
+		// the restore isn't complete from the users perspective until they're able to service requests
 		if !config.CBMConfig.Blackhole {
-			err = cluster.flushBucket()
+			err = cluster.timeRecovery(ctx, result, services, time.Now())
 			if err != nil {
-				return nil, errors.Wrap(err, "failed to flush bucket")
+				return nil, errors.Wrap(err, "failed to time service recovery")
 			}
 		}
 
-		result, err := b.benchmarkRestore(config, cluster, backupInfo.BackupSize)
+		if expected != nil {
+			result.Queries, err = cluster.validateQueries(config.Queries, expected)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to validate queries")
+			}
+		}
+
+		if expiries != nil {
+			result.Expiry, err = cluster.validateExpiries(expiries)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to validate document expiries")
+			}
+		}
+
+		// Abort if the cluster health degraded during the benchmark, the result would be misleading
+		err = cluster.checkHealth()
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to run benchmark")
+			return nil, errors.Wrap(err, "cluster health check failed")
 		}
 
 		results = append(results, result)
@@ -197,18 +404,200 @@ func (b *BackupClient) BenchmarkRestore(ctx context.Context, config *value.Bench
 	return results, nil
 }
 
+// BenchmarkUpgrade will create a backup using the versions from the blueprint, upgrade the cluster and/or backup client
+// to the versions in the upgrade config then measure the performance/compatibility of an incremental backup and a
+// restore across the upgrade.
+//
+// NOTE: The cluster/backup client are upgraded in-place, they must be re-provisioned to run further benchmarks against
+// the original versions.
+func (b *BackupClient) BenchmarkUpgrade(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.UpgradeResult, error) {
+	log.Info("Beginning 'cbbackupmgr' upgrade benchmark")
+
+	err := config.Upgrade.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid upgrade config")
+	}
+
+	defer b.enableCoreDumps()()
+
+	err = cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	err = b.purgeArchive(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to purge archive")
+	}
+
+	err = b.createRepository(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create repository")
+	}
+
+	result := value.NewUpgradeResult(cluster.blueprint, b.blueprint, config.Upgrade)
+
+	backup := func() (uint64, error) {
+		info, err := b.createBackup(ctx, config, cluster, true)
+		if err != nil {
+			return 0, err
+		}
+
+		return info.BackupSize, nil
+	}
+
+	before, err := b.upgradeStep("backup (before upgrade)", cluster, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Steps = append(result.Steps, before)
+
+	// We can't measure anything across the upgrade without a backup created using the original version
+	if !before.Compatible() {
+		return nil, fmt.Errorf("failed to create backup before upgrade: %s", before.Error)
+	}
+
+	err = b.upgrade(ctx, config.Upgrade, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to upgrade")
+	}
+
+	// If the context has been cancelled, don't run the remaining steps; the user wants to gracefully terminate
+	if ctx.Err() != nil {
+		return result, nil
+	}
+
+	incremental, err := b.upgradeStep("incremental backup (after upgrade)", cluster, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Steps = append(result.Steps, incremental)
+
+	if ctx.Err() != nil {
+		return result, nil
+	}
+
+	if !config.CBMConfig.Blackhole {
+		err = cluster.flushBucket()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to flush bucket")
+		}
+	}
+
+	restore, err := b.upgradeStep("restore (after upgrade)", cluster, func() (uint64, error) {
+		ads := before.Result.ADS
+		if incremental.Compatible() {
+			ads += incremental.Result.ADS
+		}
+
+		return ads, b.restoreBackup(ctx, config, cluster)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Steps = append(result.Steps, restore)
+
+	return result, nil
+}
+
+// upgrade upgrades the cluster and/or backup client in-place using the provided upgrade config.
+func (b *BackupClient) upgrade(ctx context.Context, config *value.UpgradeConfig, cluster *Cluster) error {
+	if config.ClusterPackagePath != "" {
+		err := cluster.Upgrade(ctx, config.ClusterPackagePath)
+		if err != nil {
+			return errors.Wrap(err, "failed to upgrade cluster")
+		}
+	}
+
+	if config.BackupClientPackagePath != "" {
+		log.WithField("host", b.blueprint.Host).Info("Upgrading backup client")
+
+		err := b.node.upgradeCB(ctx, value.NewPackage(config.BackupClientPackagePath, "", ""))
+		if err != nil {
+			return errors.Wrap(err, "failed to upgrade backup client")
+		}
+
+		// Installing the package will have started Couchbase Server again, it should remain disabled
+		err = b.node.disableCB()
+		if err != nil {
+			return errors.Wrap(err, "failed to disable Couchbase Server")
+		}
+	}
+
+	// Upgrading restarts the services on the cluster, which would otherwise be detected as degraded health
+	return cluster.startHealthMonitor()
+}
+
+// upgradeStep runs a single step of the upgrade benchmark timing the provided function, which returns the amount of
+// data transferred. Failures of the step are recorded rather than returned since they indicate an incompatibility,
+// however, an error is returned if the benchmarked tool crashes or the cluster health degrades.
+func (b *BackupClient) upgradeStep(name string, cluster *Cluster, fn func() (uint64, error),
+) (*value.UpgradeStep, error) {
+	err := cluster.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run cluster pre-benchmark tasks")
+	}
+
+	err = b.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run client pre-benchmark tasks")
+	}
+
+	start := time.Now()
+
+	ads, err := fn()
+
+	step := &value.UpgradeStep{Name: name, Result: &value.BenchmarkResult{Duration: time.Since(start), ADS: ads}}
+
+	var crash *CrashError
+	if errors.As(err, &crash) {
+		return nil, err
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("step", name).Error("Upgrade benchmark step failed")
+
+		step.Result, step.Error = nil, err.Error()
+	}
+
+	err = cluster.checkHealth()
+	if err != nil {
+		return nil, errors.Wrap(err, "cluster health check failed")
+	}
+
+	return step, nil
+}
+
 // benchmarkBackup will run an individual backup benchmark and fetch any data needed to produce a useful report.
-func (b *BackupClient) benchmarkBackup(config *value.BenchmarkConfig,
+func (b *BackupClient) benchmarkBackup(ctx context.Context, config *value.BenchmarkConfig,
 	cluster *Cluster,
 ) (*value.BenchmarkResult, error) {
+	// Ensure all the mutations have been persisted before the timer starts, otherwise we'd be racing with persistence
+	err := cluster.persistBarrier(ctx, config.CompactBeforeBackup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for mutations to be persisted")
+	}
+
 	result := &value.BenchmarkResult{}
 
-	start := time.Now()
+	var (
+		start       = time.Now()
+		incremental time.Duration
+		churn       time.Duration
+		tombstones  time.Duration
+	)
+
+	// The churn/tombstones/incremental backup are timed separately, they're not part of the benchmarked backup
 	defer func() {
-		result.Duration = time.Since(start)
+		result.Start, result.Duration = start, time.Since(start)-incremental-churn-tombstones
 	}()
 
-	err := cluster.runPreBenchmarkTasks()
+	err = cluster.runPreBenchmarkTasks()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to run cluster pre-benchmark tasks")
 	}
@@ -218,13 +607,103 @@ func (b *BackupClient) benchmarkBackup(config *value.BenchmarkConfig,
 		return nil, errors.Wrap(err, "failed to run client pre-benchmark tasks")
 	}
 
-	backupInfo, err := b.createBackup(config, cluster, false)
+	if config.LiveWorkload != nil {
+		err = cluster.startLiveWorkload(config.LiveWorkload)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start live workload")
+		}
+	}
+
+	throughput := startSampler(config.Sampling, value.SampleUnitBytes, func() (uint64, error) {
+		return b.archiveSize(config.CBMConfig)
+	})
+
+	disks := startDiskMonitor(config, cluster, b)
+
+	faults := startChaos(config.Chaos, cluster)
+
+	tool := startToolMonitor(config.Sampling, b)
+
+	watchCtx, health := watchHealth(ctx, config, cluster)
+
+	backupInfo, err := b.createBackup(watchCtx, config, cluster, false)
+
+	healthErr := health.stop()
+
+	result.Throughput, result.DiskIO = throughput.stop(), disks.stop()
+	result.Memory, result.Resources = tool.stop()
+
+	// Always heal the faults, even when the backup fails so that we don't leave the cluster degraded
+	var chaosErr error
+	result.Chaos, chaosErr = faults.stop(ctx)
+	if chaosErr != nil {
+		return nil, errors.Wrap(chaosErr, "failed to stop fault injection")
+	}
+
+	// Always stop the live workload, even when the backup fails so that we don't leave it running in the background
+	if config.LiveWorkload != nil {
+		during, stopErr := cluster.stopLiveWorkload()
+		if stopErr != nil {
+			return nil, errors.Wrap(stopErr, "failed to stop live workload")
+		}
+
+		result.Workload = &value.WorkloadResult{During: during}
+	}
+
+	// The backup was killed because the cluster health degraded, report that rather than the cancellation
+	if healthErr != nil {
+		return nil, healthErr
+	}
+
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create backup")
 	}
 
 	result.ADS = backupInfo.BackupSize
 	result.AIN = backupInfo.ItemsNum
+	result.Buckets = backupInfo.Buckets
+	result.ArchiveSize = b.archiveSizeOrZero(config.CBMConfig)
+
+	// There's no backup to create an incremental backup on top of when backing up to blackhole
+	if config.Incremental && !config.CBMConfig.Blackhole {
+		if config.Churn != nil {
+			churnStart := time.Now()
+
+			result.Churn, err = cluster.churn(ctx, config.Churn)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to mutate dataset")
+			}
+
+			churn = time.Since(churnStart)
+		}
+
+		if config.Tombstones != nil {
+			tombstonesStart := time.Now()
+
+			result.Tombstones, err = cluster.tombstone(ctx, config.Tombstones)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to leave tombstones")
+			}
+
+			tombstones = time.Since(tombstonesStart)
+		}
+
+		result.Incremental, err = b.benchmarkIncrementalBackup(ctx, config, cluster)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create incremental backup")
+		}
+
+		incremental = result.Incremental.Duration
+
+		if result.Churn != nil {
+			result.Churn.Items, result.Churn.Size = result.Incremental.AIN, result.Incremental.ADS
+		}
+
+		if result.Tombstones != nil {
+			result.Tombstones.IncrementalItems = result.Incremental.AIN
+			result.Tombstones.IncrementalSize = result.Incremental.ADS
+		}
+	}
 
 	err = b.purgeBackups(config)
 	if err != nil {
@@ -234,17 +713,80 @@ func (b *BackupClient) benchmarkBackup(config *value.BenchmarkConfig,
 	return result, nil
 }
 
-// benchmarkRestore will run an individual restore benchmark and fetch any data needed to produce a useful report.
-func (b *BackupClient) benchmarkRestore(config *value.BenchmarkConfig,
-	cluster *Cluster, ads uint64,
+// benchmarkIncrementalBackup creates an incremental backup on top of the benchmarked backup, it contains any mutations
+// made since the benchmarked backup started (e.g. by the live workload).
+func (b *BackupClient) benchmarkIncrementalBackup(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.BenchmarkResult, error) {
+	log.Info("Creating incremental backup")
+
+	start := time.Now()
+
+	backupInfo, err := b.createBackup(ctx, config, cluster, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value.BenchmarkResult{
+		Start:       start,
+		Duration:    time.Since(start),
+		ADS:         backupInfo.BackupSize,
+		AIN:         backupInfo.ItemsNum,
+		ArchiveSize: b.archiveSizeOrZero(config.CBMConfig),
+	}, nil
+}
+
+// benchmarkSourceBackup creates the backup which will be restored by the restore benchmarks, it's timed under the same
+// conditions as the restores so that backup/restore performance may be compared.
+func (b *BackupClient) benchmarkSourceBackup(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.BenchmarkResult, error) {
+	err := cluster.persistBarrier(ctx, config.CompactBeforeBackup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for mutations to be persisted")
+	}
+
+	err = cluster.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run cluster pre-benchmark tasks")
+	}
+
+	err = b.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run client pre-benchmark tasks")
+	}
+
+	start := time.Now()
+
+	backupInfo, err := b.createBackup(ctx, config, cluster, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value.BenchmarkResult{
+		Start:    start,
+		Duration: time.Since(start),
+		ADS:      backupInfo.BackupSize,
+		AIN:      backupInfo.ItemsNum,
+		Buckets:  backupInfo.Buckets,
+	}, nil
+}
+
+// benchmarkRestore will run an individual restore benchmark of the given source backup and fetch any data needed to
+// produce a useful report.
+func (b *BackupClient) benchmarkRestore(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster, source *value.BenchmarkResult,
 ) (*value.BenchmarkResult, error) {
 	result := &value.BenchmarkResult{
-		ADS: ads,
+		ADS:     source.ADS,
+		AIN:     source.AIN,
+		Buckets: source.Buckets,
+		Source:  source,
 	}
 
 	start := time.Now()
 	defer func() {
-		result.Duration = time.Since(start)
+		result.Start, result.Duration = start, time.Since(start)
 	}()
 
 	err := cluster.runPreBenchmarkTasks()
@@ -257,7 +799,35 @@ func (b *BackupClient) benchmarkRestore(config *value.BenchmarkConfig,
 		return nil, errors.Wrap(err, "failed to run client pre-benchmark tasks")
 	}
 
-	err = b.restoreBackup(config, cluster)
+	throughput := startSampler(config.Sampling, value.SampleUnitItems, cluster.itemCount)
+
+	disks := startDiskMonitor(config, cluster, b)
+
+	faults := startChaos(config.Chaos, cluster)
+
+	tool := startToolMonitor(config.Sampling, b)
+
+	watchCtx, health := watchHealth(ctx, config, cluster)
+
+	err = b.restoreBackup(watchCtx, config, cluster)
+
+	healthErr := health.stop()
+
+	result.Throughput, result.DiskIO = throughput.stop(), disks.stop()
+	result.Memory, result.Resources = tool.stop()
+
+	// Always heal the faults, even when the restore fails so that we don't leave the cluster degraded
+	var chaosErr error
+	result.Chaos, chaosErr = faults.stop(ctx)
+	if chaosErr != nil {
+		return nil, errors.Wrap(chaosErr, "failed to stop fault injection")
+	}
+
+	// The restore was killed because the cluster health degraded, report that rather than the cancellation
+	if healthErr != nil {
+		return nil, healthErr
+	}
+
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to restore backup")
 	}
@@ -265,11 +835,33 @@ func (b *BackupClient) benchmarkRestore(config *value.BenchmarkConfig,
 	return result, nil
 }
 
+// useTools runs 'cbbackupmgr' using the binaries from the given package, which has been extracted into the given
+// directory. A nil package reverts to using the installed binaries.
+func (b *BackupClient) useTools(pkg *value.Package, directory string) {
+	if pkg == nil {
+		directory = b.node.pkg.BinDirectory()
+	}
+
+	b.tools = pkg
+	b.node.client.SetBinDirectory(directory)
+}
+
+// cbm returns the 'cbbackupmgr' config from the given benchmark config with its commands built for the version of
+// 'cbbackupmgr' in use.
+func (b *BackupClient) cbm(config *value.BenchmarkConfig) *value.CBMConfig {
+	pkg := b.tools
+	if pkg == nil {
+		pkg = b.node.pkg
+	}
+
+	return config.CBMConfig.ForVersion(pkg.ReleaseVersion())
+}
+
 // configureRepository will run the config sub-command to create a new backup repository.
 func (b *BackupClient) createRepository(config *value.BenchmarkConfig) error {
 	log.Info("Creating repository")
 
-	_, err := b.node.client.ExecuteCommand(config.CBMConfig.CommandConfig())
+	_, err := b.node.client.ExecuteCommand(b.cbm(config).CommandConfig())
 
 	return err
 }
@@ -284,7 +876,7 @@ func (b *BackupClient) runPreBenchmarkTasks() error {
 
 // createBackup creates a backup of the provided cluster, note that the 'ignoreBlackhole' argument is required to allow
 // benchmarking restore to blackhole i.e. we must create a backup to restore.
-func (b *BackupClient) createBackup(config *value.BenchmarkConfig, cluster *Cluster,
+func (b *BackupClient) createBackup(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
 	ignoreBlackhole bool,
 ) (*value.BackupInfo, error) {
 	fields := log.Fields{
@@ -294,8 +886,8 @@ func (b *BackupClient) createBackup(config *value.BenchmarkConfig, cluster *Clus
 
 	log.WithFields(fields).Info("Creating backup")
 
-	fmt.Printf("cluster.ConnectionString(): %s\n", cluster.ConnectionString())
-	_, err := b.node.client.ExecuteCommand(config.CBMConfig.CommandBackup(cluster.ConnectionString(), ignoreBlackhole))
+	_, err := b.runTool(ctx, b.cbm(config).CommandBackup(cluster.ConnectionString(), cluster.ToolCredentials(),
+		ignoreBlackhole))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to run backup")
 	}
@@ -312,6 +904,8 @@ func (b *BackupClient) createBackup(config *value.BenchmarkConfig, cluster *Clus
 	}
 
 	type overlayBucket struct {
+		Name  string `json:"name"`
+		Size  uint64 `json:"size"`
 		Items uint64 `json:"total_mutations"`
 	}
 
@@ -331,14 +925,23 @@ func (b *BackupClient) createBackup(config *value.BenchmarkConfig, cluster *Clus
 		return nil, errors.Wrap(err, "failed to decode info output")
 	}
 
+	latest := decoded.Backups[len(decoded.Backups)-1]
+
 	backupInfo := &value.BackupInfo{
-		// On each iteration we only do one backup so we only care about the size of the first and only backup in the
-		// list
-		BackupSize: decoded.Backups[0].Size,
-		// We are only backing up one bucket so we can get the number of items from the first and only bucket
-		// NOTE: This is subject to change, the number of items will need to be collected across all buckets if we add
-		// support for testing backups/restores with multiple buckets
-		ItemsNum: decoded.Backups[0].Buckets[0].Items,
+		// On each iteration we only do one backup so we only care about the size of the latest backup in the list, this
+		// is the only backup unless we're running the upgrade benchmark (which creates an incremental backup)
+		BackupSize: latest.Size,
+	}
+
+	// The number of items is collected across all the buckets, the per-bucket results are only kept when there are
+	// additional buckets
+	for _, bucket := range latest.Buckets {
+		backupInfo.ItemsNum += bucket.Items
+
+		if len(cluster.blueprint.Buckets) != 0 {
+			backupInfo.Buckets = append(backupInfo.Buckets,
+				&value.BucketResult{Name: bucket.Name, ADS: bucket.Size, AIN: bucket.Items})
+		}
 	}
 
 	return backupInfo, nil
@@ -346,7 +949,7 @@ func (b *BackupClient) createBackup(config *value.BenchmarkConfig, cluster *Clus
 
 // restoreBackup will run a restore of the backups in the repository, realistically there should only be a single
 // backup.
-func (b *BackupClient) restoreBackup(config *value.BenchmarkConfig, cluster *Cluster) error {
+func (b *BackupClient) restoreBackup(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster) error {
 	fields := log.Fields{
 		"blackhole": config.CBMConfig.Blackhole,
 		"hosts":     cluster.hosts(),
@@ -354,47 +957,27 @@ func (b *BackupClient) restoreBackup(config *value.BenchmarkConfig, cluster *Clu
 
 	log.WithFields(fields).Info("Restoring backup")
 
-	_, err := b.node.client.ExecuteCommand(config.CBMConfig.CommandRestore(cluster.ConnectionString()))
+	_, err := b.runTool(ctx, b.cbm(config).CommandRestore(cluster.ConnectionString(), cluster.ToolCredentials()))
 
 	return err
 }
 
 // purgeArchive ensures our workspace is clean, we don't want any existing files to get in the way.
 func (b *BackupClient) purgeArchive(config *value.BenchmarkConfig) error {
-	if !strings.HasPrefix(config.CBMConfig.Archive, "s3://") {
+	if !config.CBMConfig.Cloud() {
 		log.WithField("archive", config.CBMConfig.Archive).Info("Purging local archive")
 		return b.node.client.RemoveDirectory(config.CBMConfig.Archive)
 	}
 
 	log.WithField("archive", config.CBMConfig.Archive).Info("Purging remote archive")
 
-	var command string
-
-	if config.CBMConfig.ObjAccessKeyID != "" {
-		command += fmt.Sprintf("export AWS_ACCESS_KEY_ID=%s; ", config.CBMConfig.ObjAccessKeyID)
-	}
-
-	if config.CBMConfig.ObjSecretAccessKey != "" {
-		command += fmt.Sprintf("export AWS_SECRET_ACCESS_KEY=%s; ", config.CBMConfig.ObjSecretAccessKey)
-	}
-
-	if config.CBMConfig.ObjRegion != "" {
-		command += fmt.Sprintf("export AWS_REGION=%s; ", config.CBMConfig.ObjRegion)
-	}
-
-	command += fmt.Sprintf("aws s3 rm %s --recursive", config.CBMConfig.Archive)
-
-	if config.CBMConfig.ObjEndpoint != "" {
-		command += fmt.Sprintf(" --endpoint=%s", config.CBMConfig.ObjEndpoint)
-	}
-
 	// We're using S3 backup, use the AWS cli to ensure the remote archive has been removed
-	_, err := b.node.client.ExecuteCommand(value.NewCommand(command))
+	_, err := b.node.client.ExecuteCommand(config.CBMConfig.CommandPurgeObjArchive())
 	if err != nil {
 		return errors.Wrap(err, "failed to purge remote archive")
 	}
 
-	log.WithField("staging_directory", config.CBMConfig.Archive).Info("Purging local staging directory")
+	log.WithField("staging_directory", config.CBMConfig.ObjStagingDirectory).Info("Purging local staging directory")
 
 	return b.node.client.RemoveDirectory(config.CBMConfig.ObjStagingDirectory)
 }
@@ -438,6 +1021,161 @@ func (b *BackupClient) purgeBackups(config *value.BenchmarkConfig) error {
 	return err
 }
 
+// Hardware returns a description of the hardware of the backup client, the disk/storage type is that of the archive
+// (or the staging directory when using cloud storage).
+func (b *BackupClient) Hardware(config *value.CBMConfig) (*value.Hardware, error) {
+	log.WithField("host", b.blueprint.Host).Info("Getting backup client hardware info")
+
+	output, err := b.node.client.ExecuteCommand(value.CommandHardware(config.LocalArchive()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get hardware info")
+	}
+
+	hardware, err := value.ParseHardware(b.blueprint.Host, output)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tiered device is a device mapper/bcache device, so the model/rotational flag are those of the virtual device
+	if tiered := b.blueprint.TieredStorage; tiered != nil && tiered.Contains(config.LocalArchive()) {
+		hardware.Storage = value.StorageTypeTiered
+	}
+
+	return hardware, nil
+}
+
+// Lock acquires the backup client lock for this run, preventing other runs from starting a concurrent benchmark. When
+// the lock is held by another run (or an unmanaged instance of 'cbbackupmgr' is running), we'll wait for up to the
+// given duration for it to be released before returning an error.
+func (b *BackupClient) Lock(ctx context.Context, wait time.Duration) error {
+	fields := log.Fields{"host": b.blueprint.Host, "wait": wait}
+	log.WithFields(fields).Info("Acquiring backup client lock")
+
+	var owner string
+
+	acquire := func() (bool, error) {
+		output, err := b.node.client.ExecuteCommand(value.CommandAcquireClientLock(b.node.run))
+		if err != nil {
+			return false, errors.Wrap(err, "failed to acquire lock")
+		}
+
+		owner = value.ParseClientLockOwner(output)
+		if owner != "" {
+			log.WithFields(fields).WithField("owner", owner).Info("Backup client is in use, waiting for it to be free")
+		}
+
+		return owner == "", nil
+	}
+
+	acquired, err := acquire()
+	if err != nil || acquired {
+		return err
+	}
+
+	if wait > 0 {
+		timeout, err := poll(ctx, acquire, wait)
+		if err != nil || !timeout {
+			return err
+		}
+	}
+
+	return fmt.Errorf("backup client is in use by '%s', refusing to start a concurrent benchmark (see "+
+		"'--wait-for-client', stale locks may be removed using 'gc')", owner)
+}
+
+// Unlock releases the backup client lock acquired by 'Lock'.
+func (b *BackupClient) Unlock() error {
+	log.WithField("host", b.blueprint.Host).Info("Releasing backup client lock")
+
+	_, err := b.node.client.ExecuteCommand(value.CommandReleaseClientLock(b.node.run))
+
+	return err
+}
+
+// HostLoad returns the load on the backup client along with any noisy neighbors, Couchbase Server is disabled on the
+// backup client so isn't expected to be running.
+func (b *BackupClient) HostLoad() (*value.HostLoad, error) {
+	return b.node.hostLoad(nil)
+}
+
+// BurstableResources returns the burstable instance/volumes used by the backup client, whose credit balances should be
+// monitored.
+func (b *BackupClient) BurstableResources(config *value.CreditsConfig) ([]*value.CreditResource, error) {
+	return b.node.burstableResources(config)
+}
+
+// CloudWatchMetrics returns the CloudWatch metrics for the backup client between the start/end.
+func (b *BackupClient) CloudWatchMetrics(config *value.CloudWatchConfig, start, end time.Time,
+) (value.CloudWatchMetrics, error) {
+	return b.node.cloudWatchMetrics(config, start, end)
+}
+
+// RestoreState reverts the changes made to the backup client, restoring its original state.
+func (b *BackupClient) RestoreState() error {
+	return b.node.restoreState()
+}
+
+// GarbageCollect removes any temporary directories older than the given duration from the backup client.
+func (b *BackupClient) GarbageCollect(olderThan time.Duration) error {
+	return b.node.garbageCollect(olderThan)
+}
+
+// Repositories returns the benchmark repositories created by 'cbtools-autobench' in the archive.
+func (b *BackupClient) Repositories(config *value.CBMConfig) (value.ArchiveRepositories, error) {
+	output, err := b.node.client.ExecuteCommand(config.CommandArchiveInfo())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get archive info")
+	}
+
+	return value.ParseArchiveRepositories(config.Repository, output)
+}
+
+// RemoveRepository removes the given repository from the archive.
+func (b *BackupClient) RemoveRepository(config *value.CBMConfig, repository string) error {
+	log.WithFields(log.Fields{"archive": config.Archive, "repository": repository}).Info("Removing repository")
+
+	_, err := b.node.client.ExecuteCommand(config.CommandRemoveRepository(repository))
+
+	return err
+}
+
+// RemoveRepositories removes the benchmark repositories created by the given runs from the archive, repositories which
+// weren't created by 'cbtools-autobench' are never removed.
+func (b *BackupClient) RemoveRepositories(config *value.CBMConfig, runs []value.RunID) error {
+	repositories, err := b.Repositories(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to list repositories")
+	}
+
+	pruned := make(map[value.RunID]bool, len(runs))
+	for _, run := range runs {
+		pruned[run] = true
+	}
+
+	for _, repository := range repositories {
+		if !pruned[repository.RunID] {
+			continue
+		}
+
+		err = b.RemoveRepository(config, repository.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove repository '%s'", repository.Name)
+		}
+	}
+
+	return nil
+}
+
+// UpdateHosts adds the given entries to '/etc/hosts' on the backup client.
+func (b *BackupClient) UpdateHosts(entries value.HostsEntries) error {
+	return b.node.updateHosts(entries)
+}
+
+// Profile returns the remote operation timings for the backup client.
+func (b *BackupClient) Profile() *value.HostProfile {
+	return b.node.client.Profile()
+}
+
 // Close the connection to the backup client.
 func (b *BackupClient) Close() error {
 	return b.node.Close()
@@ -0,0 +1,179 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkBisect finds the build of 'cbbackupmgr' which introduced a performance regression, by repeatedly
+// downloading/extracting the build in the middle of the range of builds which may have introduced it and running the
+// (fast) benchmark from the bisect config using it.
+func (b *BackupClient) BenchmarkBisect(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.BisectResult, error) {
+	bisect := config.Bisect
+
+	err := bisect.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid bisect config")
+	}
+
+	log.WithFields(log.Fields{"good": bisect.Good, "bad": bisect.Bad}).Info("Beginning 'cbbackupmgr' bisect")
+
+	// Ensure we always go back to using the installed version of 'cbbackupmgr'
+	defer b.useTools(nil, "")
+
+	result := value.NewBisectResult(bisect)
+
+	// The good/bad builds are benchmarked first, they determine the threshold at which a build is considered bad
+	good, err := b.benchmarkBuild(ctx, config, cluster, bisect.Good)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to benchmark good build %d", bisect.Good)
+	}
+
+	bad, err := b.benchmarkBuild(ctx, config, cluster, bisect.Bad)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to benchmark bad build %d", bisect.Bad)
+	}
+
+	result.Threshold = bisect.Regressed(good, bad)
+
+	result.Record(&value.BisectStep{Build: bisect.Good, Verdict: value.BisectVerdictGood, AvgDuration: good})
+	result.Record(&value.BisectStep{Build: bisect.Bad, Verdict: value.BisectVerdictBad, AvgDuration: bad})
+
+	if bad <= result.Threshold {
+		return nil, fmt.Errorf("the bad build (%s) isn't slower than the good build (%s), there's no regression "+
+			"to bisect", bad, good)
+	}
+
+	for {
+		// If the context has been cancelled, don't benchmark any more builds; the user wants to gracefully terminate
+		if ctx.Err() != nil {
+			return result, nil
+		}
+
+		build, ok := result.Next()
+		if !ok {
+			break
+		}
+
+		step := &value.BisectStep{Build: build}
+
+		step.AvgDuration, err = b.benchmarkBuild(ctx, config, cluster, build)
+
+		var environment *EnvironmentError
+
+		switch {
+		case errors.As(err, &environment):
+			return nil, errors.Wrapf(err, "failed to benchmark build %d", build)
+		case err != nil:
+			// Builds which are missing from the archive (or crash) can't be compared with the others, they're skipped
+			log.WithError(err).WithField("build", build).Warn("Failed to benchmark build, skipping")
+
+			step.Verdict, step.Error, step.AvgDuration = value.BisectVerdictSkipped, err.Error(), 0
+		case step.AvgDuration > result.Threshold:
+			step.Verdict = value.BisectVerdictBad
+		default:
+			step.Verdict = value.BisectVerdictGood
+		}
+
+		log.WithFields(log.Fields{"build": build, "verdict": step.Verdict}).Info("Benchmarked build")
+
+		result.Record(step)
+	}
+
+	result.Complete = true
+
+	return result, nil
+}
+
+// benchmarkBuild downloads/extracts the given build on the backup client, then runs the benchmark from the bisect
+// config using it, returning the average duration. The build is removed afterwards.
+func (b *BackupClient) benchmarkBuild(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+	build int,
+) (time.Duration, error) {
+	var (
+		bisect     = config.Bisect
+		pkg        = bisect.Package(build)
+		directory  = value.RemoteJoin(b.node.tempDirectory(), "bisect", strconv.Itoa(build))
+		remotePath = value.RemoteJoin(b.node.tempDirectory(), value.LocalBase(pkg.Path))
+	)
+
+	log.WithFields(log.Fields{"build": build, "url": pkg.Path}).Info("Downloading build")
+
+	err := b.node.client.CreateDirectory(directory)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create build directory")
+	}
+
+	defer func() {
+		err := b.node.client.RemoveDirectory(directory)
+		if err != nil {
+			log.WithError(err).WithField("build", build).Warn("Failed to remove build directory")
+		}
+	}()
+
+	_, err = b.node.client.ExecuteCommand(bisect.CommandDownload(build, remotePath))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to download build")
+	}
+
+	_, err = b.node.client.ExecuteCommand(pkg.CommandExtract(remotePath, directory))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to extract build")
+	}
+
+	err = b.node.client.RemoveFile(remotePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to remove build archive")
+	}
+
+	b.useTools(pkg, value.RemoteJoin(directory, "opt", "couchbase", "bin"))
+
+	fast := *config
+	fast.Iterations = bisect.IterationsOrDefault()
+
+	var results value.BenchmarkResults
+
+	if bisect.BenchmarkOrDefault() == "restore" {
+		results, err = b.BenchmarkRestore(ctx, &fast, cluster)
+	} else {
+		results, err = b.BenchmarkBackup(ctx, &fast, cluster)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, errors.New("no iterations completed")
+	}
+
+	var total time.Duration
+	for _, result := range results {
+		total += result.Duration
+	}
+
+	return total / time.Duration(len(results)), nil
+}
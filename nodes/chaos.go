@@ -0,0 +1,208 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// ChaosRecoveryTimeout is how long we'll wait for the cluster to become healthy once the injected faults have been
+// healed, before giving up.
+const ChaosRecoveryTimeout = 10 * time.Minute
+
+// chaos randomly injects faults into the cluster in the background.
+type chaos struct {
+	config  *value.ChaosConfig
+	cluster *Cluster
+	rng     *rand.Rand
+	result  *value.ChaosResult
+	start   time.Time
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// startChaos begins injecting faults into the cluster at the configured interval, nil is returned if chaos isn't
+// enabled; it's valid to call 'stop' on a nil chaos.
+func startChaos(config *value.ChaosConfig, cluster *Cluster) *chaos {
+	if config == nil {
+		return nil
+	}
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	c := &chaos{
+		config:  config,
+		cluster: cluster,
+		rng:     rand.New(rand.NewSource(seed)), //nolint:gosec
+		result:  &value.ChaosResult{Seed: seed, Events: make([]*value.FaultEvent, 0)},
+		start:   time.Now(),
+		done:    make(chan struct{}),
+	}
+
+	log.WithFields(log.Fields{"seed": seed, "faults": config.Faults()}).Info("Starting fault injection")
+
+	c.wg.Add(1)
+
+	go c.run()
+
+	return c
+}
+
+// run rolls the dice for each fault every interval until stopped.
+func (c *chaos) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.IntervalOrDefault())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, fault := range c.config.Faults() {
+			// The node is always chosen so that the same seed results in the same sequence of faults
+			node := c.cluster.nodes[c.rng.Intn(len(c.cluster.nodes))]
+
+			if c.rng.Float64() < c.config.Fault(fault).Probability {
+				c.inject(fault, node)
+			}
+		}
+	}
+}
+
+// inject injects the given fault into the given node, failing to inject a fault is recorded rather than failing the
+// benchmark.
+func (c *chaos) inject(fault value.FaultType, node *Node) {
+	event := &value.FaultEvent{Type: fault, Node: node.blueprint.Host, Elapsed: time.Since(c.start)}
+
+	fields := log.Fields{"fault": fault, "host": node.blueprint.Host}
+	log.WithFields(fields).Warn("Injecting fault")
+
+	var err error
+
+	switch fault {
+	case value.FaultTypeNodeRestart:
+		err = node.startCB()
+	case value.FaultTypeNetworkPartition:
+		event.Duration = c.config.NetworkPartition.DurationOrDefault()
+		err = c.partition(node, event.Duration)
+	case value.FaultTypeDiskLatency:
+		event.Duration = c.config.DiskLatency.DurationOrDefault()
+		_, err = node.client.ExecuteCommand(c.config.DiskLatency.CommandDelayDisk(event.Duration))
+	}
+
+	if err != nil {
+		log.WithError(err).WithFields(fields).Warn("Failed to inject fault")
+		event.Err = errors.Cause(err).Error()
+	}
+
+	c.result.Events = append(c.result.Events, event)
+}
+
+// partition drops all the traffic between the given node and the other nodes in the cluster for the given duration.
+func (c *chaos) partition(node *Node, duration time.Duration) error {
+	if len(c.cluster.nodes) == 1 {
+		return errors.New("a single node cluster can't be partitioned")
+	}
+
+	peers := make([]string, 0, len(c.cluster.nodes)-1)
+
+	for _, peer := range c.cluster.nodes {
+		if peer == node {
+			continue
+		}
+
+		address, err := peer.address()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get address for '%s'", peer.blueprint.Host)
+		}
+
+		peers = append(peers, address)
+	}
+
+	_, err := node.client.ExecuteCommand(value.CommandPartition(peers, duration))
+
+	return err
+}
+
+// stop stops injecting faults, heals any which are ongoing then waits for the cluster to become healthy; the health
+// events caused by the faults are ignored. Returns the faults which were injected, nil is returned if chaos wasn't
+// enabled.
+func (c *chaos) stop(ctx context.Context) (*value.ChaosResult, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	close(c.done)
+	c.wg.Wait()
+
+	log.WithField("faults", len(c.result.Events)).Info("Stopped fault injection, healing faults")
+
+	err := c.cluster.forEachNode(func(node *Node) error {
+		if c.config.NetworkPartition != nil {
+			_, err := node.client.ExecuteCommand(value.CommandHealPartition())
+			if err != nil {
+				return errors.Wrap(err, "failed to heal network partition")
+			}
+		}
+
+		if c.config.DiskLatency != nil {
+			_, err := node.client.ExecuteCommand(c.config.DiskLatency.CommandHealDisk())
+			if err != nil {
+				return errors.Wrap(err, "failed to heal disk latency")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to heal faults")
+	}
+
+	// The REST API may be unreachable whilst the cluster recovers, so errors mean that it's not healthy yet
+	healthy := func() (bool, error) { return c.cluster.checkNodeHealth() == nil, nil }
+
+	timeout, err := pollEvery(ctx, healthy, 5*time.Second, ChaosRecoveryTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to poll until the cluster was healthy")
+	}
+
+	if timeout {
+		return nil, fmt.Errorf("cluster didn't become healthy within %s of healing the faults", ChaosRecoveryTimeout)
+	}
+
+	// The faults will have been logged by the cluster, they're expected so move the health checkpoint past them
+	_, err = c.cluster.healthEvents()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to checkpoint cluster logs")
+	}
+
+	return c.result, nil
+}
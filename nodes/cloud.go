@@ -0,0 +1,60 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkCloud runs the backup then restore benchmarks against an archive stored in an object store, exercising the
+// cloud path end-to-end. The bucket is checked up front so that misconfigured credentials/regions fail fast, rather
+// than after the cluster has settled.
+func (b *BackupClient) BenchmarkCloud(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.CloudResult, error) {
+	if !config.CBMConfig.Cloud() {
+		return nil, errors.New("the cloud benchmark requires an 's3://' archive")
+	}
+
+	fields := log.Fields{"archive": config.CBMConfig.Archive, "staging_directory": config.CBMConfig.ObjStagingDirectory}
+	log.WithFields(fields).Info("Beginning 'cbbackupmgr' cloud benchmark")
+
+	_, err := b.node.client.ExecuteCommand(config.CBMConfig.CommandCheckObjBucket())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to access bucket '%s'", config.CBMConfig.ObjBucket())
+	}
+
+	backup, err := b.BenchmarkBackup(ctx, config, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to benchmark backup")
+	}
+
+	// If the context has been cancelled, don't benchmark the restore; the user wants to gracefully terminate
+	if ctx.Err() != nil {
+		return value.NewCloudResult(config.CBMConfig, backup, nil), nil
+	}
+
+	restore, err := b.BenchmarkRestore(ctx, config, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to benchmark restore")
+	}
+
+	return value.NewCloudResult(config.CBMConfig, backup, restore), nil
+}
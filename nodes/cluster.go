@@ -18,9 +18,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jamesl33/cbtools-autobench/value"
@@ -33,10 +35,11 @@ import (
 )
 
 // memInfo is a prefix which will be added to commands which require memory/quota based information. For example, when
-// provisioning a bucket we will use 80% of the available memory by default.
+// provisioning a bucket we will use 80% of the available memory by default (less the memory reserved for any services
+// run alongside the data service, see 'Cluster.memInfo').
 const memInfo = `
 	FREE=$(free | awk '{ print $2 }' | sed '1d;3d' | awk '{ print int($0 / 1024) }');
-	QUOTA=$(echo $FREE | awk '{ print int($0 * 0.8) }');
+	QUOTA=$(echo $FREE | awk '{ print int($0 * 0.8) - %d }');
 `
 
 // Cluster represents a connection to a number of nodes in a Couchbase Cluster (note that the cluster may not be setup
@@ -44,10 +47,47 @@ const memInfo = `
 type Cluster struct {
 	blueprint *value.ClusterBlueprint
 	nodes     []*Node
+
+	// credentials are the credentials of the cluster administrator, resolved when connecting to the cluster.
+	credentials *value.Credentials
+
+	// cpuTimes is the CPU times for each node, snapshotted when the live workload was started.
+	cpuTimes []value.CPUTimes
+
+	// settle is how long we waited for the cluster to settle before the benchmark was timed.
+	settle *value.SettleResult
+
+	// healthCheckpoint is the timestamp (according to the cluster) of the latest log entry seen by the health monitor.
+	healthCheckpoint int64
+
+	// snapshotted indicates that the data path of each node has been snapshotted and should be cleaned up.
+	snapshotted bool
+
+	// concurrency is the maximum number of nodes which are operated on concurrently, by default this is the number of
+	// local CPUs.
+	concurrency int
+
+	// bootstrap is the flow used to initialize the cluster, it's determined once the first node has been provisioned.
+	bootstrap value.BootstrapFlow
 }
 
 // NewCluster creates a connection to each of the remote cluster nodes using the provided ssh config.
-func NewCluster(config *value.SSHConfig, blueprint *value.ClusterBlueprint) (*Cluster, error) {
+func NewCluster(config *value.SSHConfig, blueprint *value.ClusterBlueprint, run value.RunID) (*Cluster, error) {
+	err := blueprint.Credentials.Validate()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid credentials"))
+	}
+
+	err = blueprint.LDAP.Validate()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid LDAP config"))
+	}
+
+	err = blueprint.Bootstrap.Validate()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid bootstrap flow"))
+	}
+
 	var (
 		pool  = hofp.NewPool(hofp.Options{Size: maths.Min(system.NumCPU(), len(blueprint.Nodes))})
 		nodes = make([]*Node, len(blueprint.Nodes))
@@ -56,10 +96,16 @@ func NewCluster(config *value.SSHConfig, blueprint *value.ClusterBlueprint) (*Cl
 	connect := func(idx int, nb *value.NodeBlueprint) error {
 		var err error
 
-		nodes[idx], err = NewNode(config, nb)
+		if nb.Readiness == nil {
+			nb.Readiness = blueprint.Readiness
+		}
+
+		nodes[idx], err = NewNode(config, nb, blueprint.Package(), run)
 		if err != nil {
 			return err
 		}
+
+		nodes[idx].image, nodes[idx].reuse = blueprint.Image, blueprint.ReuseInstall
 		//
 		// if nodes[idx].blueprint.DataPath != "" {
 		// 	err = nodes[idx].checkAndPartitionEBS()
@@ -88,33 +134,69 @@ func NewCluster(config *value.SSHConfig, blueprint *value.ClusterBlueprint) (*Cl
 		}
 	}
 
-	err := pool.Stop()
+	err = pool.Stop()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to stop pool")
 	}
 
-	return &Cluster{blueprint: blueprint, nodes: nodes}, nil
+	cluster := &Cluster{blueprint: blueprint, nodes: nodes}
+
+	err = cluster.resolveCredentials()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve credentials")
+	}
+
+	return cluster, nil
+}
+
+// Bake installs the dependencies/package on the first cluster node without configuring Couchbase Server, returning
+// the id of the instance which an image should be created from.
+func (c *Cluster) Bake(ctx context.Context) (string, error) {
+	log.WithField("host", c.nodes[0].blueprint.Host).Info("Baking cluster node")
+
+	return c.nodes[0].bake(ctx)
 }
 
 // Provision will provision the cluster installing Couchbase and any required dependencies.
-func (c *Cluster) Provision() error {
+func (c *Cluster) Provision(ctx context.Context) error {
 	log.WithField("hosts", c.hosts()).Info("Provision cluster")
 
-	err := c.provisionNodes()
+	err := c.provisionNodes(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to provision nodes")
 	}
 
-	err = c.initializeCB()
+	// Stop before initializing the cluster, the nodes are torn down by the next provision regardless
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// Catch any network misconfiguration now, rather than ending up with a half initialized cluster
+	err = c.checkReachability()
+	if err != nil {
+		return errors.Wrap(err, "failed to check port reachability")
+	}
+
+	err = c.initializeCB(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize Couchbase")
 	}
 
+	err = c.configureSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to configure cluster settings")
+	}
+
 	err = c.enableDeveloperPreviewMode()
 	if err != nil {
 		return errors.Wrap(err, "failed to enable developer preview mode")
 	}
 
+	err = c.configureLDAP(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure LDAP")
+	}
+
 	// Sometimes it's useful to limit the number of vBuckets in the remote cluster when performing testing which is
 	// scaled to simulate a dataset of a certain size.
 	err = c.limitVBuckets()
@@ -122,25 +204,49 @@ func (c *Cluster) Provision() error {
 		return errors.Wrap(err, "failed to limit vBuckets")
 	}
 
-	err = c.createBucket()
+	err = c.createBuckets()
 	if err != nil {
-		return errors.Wrap(err, "failed to create bucket")
+		return errors.Wrap(err, "failed to create buckets")
 	}
 
 	// If we request to flush the bucket to close to the creation, we may hit a 500 internal error
-	time.Sleep(30 * time.Second)
+	if !sleepContext(ctx, 30*time.Second) {
+		return ctx.Err()
+	}
 
 	return nil
 }
 
 // LoadData will load the benchmark dataset using the data loader specified in the config. The load phase is sped up by
 // modifying the eviction pager settings to speed up eviction.
-func (c *Cluster) LoadData(compact bool) error {
-	log.WithField("compact", compact).Info("Loading test data")
+//
+// By default, the bucket is flushed before loading the dataset. When resuming, only the items which are missing from
+// the bucket are loaded, and when topping up the given number of items are added to the existing dataset.
+func (c *Cluster) LoadData(ctx context.Context, compact bool, mode value.LoadMode) error {
+	fields := log.Fields{"compact": compact, "resume": mode.Resume, "top_up": mode.TopUp}
+	log.WithFields(fields).Info("Loading test data")
 
-	err := c.flushBucket()
+	err := mode.Validate(c.blueprint.Bucket.Data)
 	if err != nil {
-		return errors.Wrap(err, "failed to flush bucket")
+		return errors.Wrap(err, "invalid load mode")
+	}
+
+	items, offset, err := c.itemsToLoad(mode)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine the number of items to load")
+	}
+
+	// The number of items in an imported dataset isn't known up front
+	if items <= 0 && c.blueprint.Bucket.Data.DataLoader != value.CBImport {
+		log.WithField("items", offset).Info("Dataset is already fully loaded, nothing to resume")
+		return nil
+	}
+
+	if mode.Flush() {
+		err = c.flushBucket()
+		if err != nil {
+			return errors.Wrap(err, "failed to flush bucket")
+		}
 	}
 
 	err = c.modifyEvictionPercentages(0)
@@ -148,30 +254,63 @@ func (c *Cluster) LoadData(compact bool) error {
 		return errors.Wrap(err, "failed to set eviction percentages to zero")
 	}
 
-	err = c.loadData()
+	err = c.loadData(ctx, items, offset)
 	if err != nil {
 		return errors.Wrap(err, "failed to load data")
 	}
 
+	// The additional buckets are only (re)loaded from scratch, they're not resumed/topped up
+	if mode.Flush() {
+		err = c.loadAdditionalBuckets(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to load additional buckets")
+		}
+	}
+
 	err = c.modifyEvictionPercentages(30)
 	if err != nil {
 		return errors.Wrap(err, "failed to reset eviction percentages")
 	}
 
-	if !compact {
-		return nil
+	if compact {
+		err = c.compactBucket(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to compact bucket")
+		}
 	}
 
-	err = c.compactBucket()
+	err = c.createIndexes(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to compact bucket")
+		return errors.Wrap(err, "failed to create indexes")
 	}
 
 	return nil
 }
 
+// itemsToLoad returns the number of items which should be loaded using the given mode, and the number of items which
+// are already in the bucket (zero when the bucket will be flushed).
+func (c *Cluster) itemsToLoad(mode value.LoadMode) (int, int, error) {
+	if mode.Flush() {
+		return c.blueprint.Bucket.Data.Items, 0, nil
+	}
+
+	existing, err := c.itemCount()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get item count")
+	}
+
+	if mode.TopUp != 0 {
+		return mode.TopUp, int(existing), nil
+	}
+
+	log.WithFields(log.Fields{"items": c.blueprint.Bucket.Data.Items, "existing": existing}).
+		Info("Resuming load from the items already in the bucket")
+
+	return c.blueprint.Bucket.Data.Items - int(existing), int(existing), nil
+}
+
 // CollectLogs will collect the logs from the remote cluster then copy the logs into the provided directory.
-func (c *Cluster) CollectLogs(path string) ([]string, error) {
+func (c *Cluster) CollectLogs(ctx context.Context, path string) ([]string, error) {
 	log.WithField("path", path).Info("Collecting cluster logs")
 
 	err := c.startCollection()
@@ -179,8 +318,12 @@ func (c *Cluster) CollectLogs(path string) ([]string, error) {
 		return nil, errors.Wrap(err, "failed to start collection")
 	}
 
-	// We are safe to ignore the error here since 'logCollectionComplete' does not return an error
-	timeout, _ := poll(c.logCollectionComplete, 5*time.Minute)
+	// NOTE: 'logCollectionComplete' does not return an error, so any error is from the context being cancelled
+	timeout, err := poll(ctx, c.logCollectionComplete, 5*time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to poll until log collection completed")
+	}
+
 	if timeout {
 		return nil, errors.New("timeout whilst waiting for log collection to complete")
 	}
@@ -190,26 +333,66 @@ func (c *Cluster) CollectLogs(path string) ([]string, error) {
 		return nil, errors.Wrap(err, "failed to determine the paths to logs")
 	}
 
-	err = c.downloadLogs(paths, path)
+	err = c.downloadLogs(ctx, paths, path)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to download logs")
 	}
 
 	converted := make([]string, 0, len(paths))
 	for _, logPath := range paths {
-		converted = append(converted, filepath.Join(path, filepath.Base(logPath)))
+		converted = append(converted, filepath.Join(path, value.RemoteBase(logPath)))
 	}
 
 	return converted, nil
 }
 
-// Stats returns the basic stats from the cluster as reported by ns_server.
+// Upgrade upgrades Couchbase Server in-place on all the nodes in the cluster to the package at the given path, then
+// waits for the cluster to become healthy.
+func (c *Cluster) Upgrade(ctx context.Context, path string) error {
+	log.WithFields(log.Fields{"hosts": c.hosts(), "package": path}).Info("Upgrading cluster")
+
+	pkg := value.NewPackage(path, "", "")
+
+	err := c.forEachNode(func(node *Node) error { return node.upgradeCB(ctx, pkg) })
+	if err != nil {
+		return errors.Wrap(err, "failed to upgrade nodes")
+	}
+
+	err = c.waitUntilHealthy(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for the cluster to become healthy after upgrading")
+	}
+
+	return nil
+}
+
+// waitUntilHealthy waits for all the nodes in the cluster to become healthy after they've been restarted.
+func (c *Cluster) waitUntilHealthy(ctx context.Context) error {
+	// The nodes have been restarted so we'll see errors until they're back up, these are ignored
+	timeout, err := poll(ctx, func() (bool, error) { return c.checkNodeHealth() == nil, nil }, 5*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to poll until the cluster was healthy")
+	}
+
+	if timeout {
+		return errors.New("timeout whilst waiting for the cluster to become healthy")
+	}
+
+	return nil
+}
+
+// Stats returns the basic stats for the benchmarking bucket from the cluster as reported by ns_server.
 func (c *Cluster) Stats() (*value.Stats, error) {
-	log.WithField("host", c.blueprint.Nodes[0].Host).Info("Getting bucket stats")
+	return c.bucketStats(value.DefaultBucketName)
+}
+
+// bucketStats returns the basic stats for the bucket with the given name as reported by ns_server.
+func (c *Cluster) bucketStats(name string) (*value.Stats, error) {
+	log.WithFields(log.Fields{"host": c.blueprint.Nodes[0].Host, "bucket": name}).Info("Getting bucket stats")
 
 	// This should probably be done with 'cbrest' or by using an actual HTTP client but for now using curl will suffice
-	output, err := exec.Command("curl", "-s", "-u", "Administrator:asdasd",
-		fmt.Sprintf("%s:8091/pools/default/buckets/default", c.blueprint.Nodes[0].Host)).CombinedOutput()
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/pools/default/buckets/%s`, c.credentials.UserInfo(), c.nodes[0].localREST(), name))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to execute curl command")
 	}
@@ -228,13 +411,82 @@ func (c *Cluster) Stats() (*value.Stats, error) {
 	return decoded.BasicStats, nil
 }
 
+// Hardware returns a description of the hardware of each of the nodes in the cluster.
+func (c *Cluster) Hardware() (value.HardwareSummary, error) {
+	log.WithField("hosts", c.hosts()).Info("Getting hardware info")
+
+	hardware := make(value.HardwareSummary, len(c.nodes))
+
+	for idx, node := range c.nodes {
+		hw, err := node.hardware()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get hardware info for node '%s'", node.blueprint.Host)
+		}
+
+		hardware[idx] = hw
+	}
+
+	return hardware, nil
+}
+
+// HostLoads returns the load on each node in the cluster along with any noisy neighbors, Couchbase Server is expected
+// to be running on the nodes.
+func (c *Cluster) HostLoads() (value.HostLoads, error) {
+	loads := make(value.HostLoads, len(c.nodes))
+
+	for idx, node := range c.nodes {
+		load, err := node.hostLoad(value.CouchbaseProcesses)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get host load for node '%s'", node.blueprint.Host)
+		}
+
+		loads[idx] = load
+	}
+
+	return loads, nil
+}
+
+// BurstableResources returns the burstable instances/volumes used by the cluster nodes, whose credit balances should be
+// monitored.
+func (c *Cluster) BurstableResources(config *value.CreditsConfig) ([]*value.CreditResource, error) {
+	resources := make([]*value.CreditResource, 0)
+
+	for _, node := range c.nodes {
+		found, err := node.burstableResources(config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get burstable resources for node '%s'", node.blueprint.Host)
+		}
+
+		resources = append(resources, found...)
+	}
+
+	return resources, nil
+}
+
+// CloudWatchMetrics returns the CloudWatch metrics for the cluster nodes between the start/end.
+func (c *Cluster) CloudWatchMetrics(config *value.CloudWatchConfig, start, end time.Time,
+) (value.CloudWatchMetrics, error) {
+	metrics := make(value.CloudWatchMetrics, 0)
+
+	for _, node := range c.nodes {
+		found, err := node.cloudWatchMetrics(config, start, end)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get CloudWatch metrics for node '%s'", node.blueprint.Host)
+		}
+
+		metrics = append(metrics, found...)
+	}
+
+	return metrics, nil
+}
+
 // startCollection uses the CLI to begin a log collection on all the nodes in the cluster.
 func (c *Cluster) startCollection() error {
 	log.Info("Starting log collection")
 
 	_, err := c.nodes[0].client.ExecuteCommand(
-		value.NewCommand(`couchbase-cli collect-logs-start -c %s -u Administrator -p asdasd --all-nodes`,
-			c.nodes[0].blueprint.Host))
+		value.NewCommand(`couchbase-cli collect-logs-start -c %s %s --all-nodes`,
+			c.nodes[0].blueprint.RESTAddress(), c.credentials.Args()))
 
 	return err
 }
@@ -244,10 +496,10 @@ func (c *Cluster) compactionComplete() (bool, error) {
 	log.Info("Checking compaction status")
 
 	// This should probably be done with 'cbrest' or by using an actual HTTP client but for now using curl will suffice
-	output, err := exec.Command("curl", "-s", "-u", "Administrator:asdasd",
-		fmt.Sprintf("%s:8091/pools/default/tasks", c.blueprint.Nodes[0].Host)).CombinedOutput()
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/pools/default/tasks`, c.credentials.UserInfo(), c.nodes[0].localREST()))
 	if err != nil {
-		return false, errors.Wrap(err, "")
+		return false, errors.Wrap(err, "failed to execute curl command")
 	}
 
 	type overlay struct {
@@ -276,7 +528,7 @@ func (c *Cluster) logCollectionComplete() (bool, error) {
 	log.Info("Checking log collection status")
 
 	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`couchbase-cli collect-logs-status -c %s \
-		-u Administrator -p asdasd | grep -q '^Status: completed'`, c.nodes[0].blueprint.Host))
+		%s | grep -q '^Status: completed'`, c.nodes[0].blueprint.RESTAddress(), c.credentials.Args()))
 
 	return err == nil, nil
 }
@@ -286,14 +538,14 @@ func (c *Cluster) collectionPaths() ([]string, error) {
 	log.Info("Determining which logs to download from cluster")
 
 	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
-		`couchbase-cli collect-logs-status -c %s -u Administrator -p asdasd | grep 'path :' | \
-			awk '{ print $3 }' | paste -sd ","`, c.nodes[0].blueprint.Host,
+		`couchbase-cli collect-logs-status -c %s %s | grep 'path :' | \
+			awk '{ print $3 }' | paste -sd ","`, c.nodes[0].blueprint.RESTAddress(), c.credentials.Args(),
 	))
 
 	return strings.Split(strings.TrimSpace(string(output)), ","), err
 }
 
-func (c *Cluster) downloadLogs(logPaths []string, output string) error {
+func (c *Cluster) downloadLogs(ctx context.Context, logPaths []string, output string) error {
 	log.Info("Downloading cluster logs")
 
 	for _, source := range logPaths {
@@ -302,12 +554,12 @@ func (c *Cluster) downloadLogs(logPaths []string, output string) error {
 				return nil
 			}
 
-			sink := filepath.Join(output, filepath.Base(source))
+			sink := filepath.Join(output, value.RemoteBase(source))
 
 			fields := log.Fields{"host": node.blueprint.Host, "source": source, "sink": sink}
 			log.WithFields(fields).Info("Downloading cluster logs from node")
 
-			return node.client.SecureDownload(source, sink)
+			return node.client.SecureDownload(ctx, source, sink)
 		})
 		if err != nil {
 			return errors.Wrapf(err, "failed to download logs at '%s'", source)
@@ -318,15 +570,27 @@ func (c *Cluster) downloadLogs(logPaths []string, output string) error {
 }
 
 // provisionNodes provisions and initializes Couchbase Server on all the node in the cluster.
-func (c *Cluster) provisionNodes() error {
-	return c.forEachNode(func(node *Node) error { return c.provisionNode(node) })
+func (c *Cluster) provisionNodes(ctx context.Context) error {
+	return c.forEveryNode(func(node *Node) error { return c.provisionNode(ctx, node) })
 }
 
 // provisionNode provision and initialize Couchbase Server on the provided node.
-func (c *Cluster) provisionNode(node *Node) error {
+func (c *Cluster) provisionNode(ctx context.Context, node *Node) error {
+	// Don't start provisioning any queued nodes once we've been interrupted
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	log.WithField("host", node.blueprint.Host).Info("Provisioning node")
 
-	err := node.provision(c.blueprint.PackagePath)
+	if c.blueprint.PermissiveSecurity {
+		err := node.relaxSecurity()
+		if err != nil {
+			return errors.Wrap(err, "failed to set SELinux/AppArmor to permissive mode")
+		}
+	}
+
+	err := node.provision(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to provision node")
 	}
@@ -341,7 +605,19 @@ func (c *Cluster) provisionNode(node *Node) error {
 		return errors.Wrap(err, "failed to create index path")
 	}
 
-	err = node.initializeCB()
+	// The first node is initialized along with the cluster when using the '/clusterInit' endpoint
+	if node == c.nodes[0] {
+		c.bootstrap, err = c.bootstrapFlow()
+		if err != nil {
+			return errors.Wrap(err, "failed to determine bootstrap flow")
+		}
+
+		if c.bootstrap == value.BootstrapFlowClusterInit {
+			return nil
+		}
+	}
+
+	err = node.initializeCB(c.blueprint.UseIPv6())
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize Couchbase Server")
 	}
@@ -349,171 +625,609 @@ func (c *Cluster) provisionNode(node *Node) error {
 	return nil
 }
 
-// initializeCB will initialize Couchbase Server
-func (c *Cluster) initializeCB() error {
-	err := c.clusterInit()
-	if err != nil {
-		return errors.Wrap(err, "failed to initialize cluster")
+// bootstrapFlow returns the flow used to initialize the cluster, unless one is configured the flow supported by the
+// installed version of Couchbase Server is detected using the (uninitialized) first node.
+func (c *Cluster) bootstrapFlow() (value.BootstrapFlow, error) {
+	flow := c.blueprint.Bootstrap.OrDefault()
+	if flow != value.BootstrapFlowAuto {
+		return flow, nil
 	}
 
-	err = c.forEachNode(func(node *Node) error { return c.serverAdd(node) })
+	node := c.nodes[0]
+
+	output, err := node.client.ExecuteCommand(value.CommandProbeClusterInit(node.localREST()))
 	if err != nil {
-		return errors.Wrap(err, "failed to add cluster nodes")
+		return "", errors.Wrap(err, "failed to probe '/clusterInit' endpoint")
 	}
 
-	err = c.rebalance()
+	flow, err = value.ParseBootstrapFlow(output)
 	if err != nil {
-		return errors.Wrap(err, "failed to rebalance nodes into cluster")
+		return "", err
 	}
 
-	return nil
+	log.WithFields(log.Fields{"host": node.blueprint.Host, "flow": flow}).Info("Detected bootstrap flow")
+
+	return flow, nil
 }
 
-// limitVBuckets uses /diag/eval to limit the number of vBuckets in the cluster.
-func (c *Cluster) limitVBuckets() error {
-	// We're using a default number of vBuckets don't bother changing anything
-	if c.blueprint.Bucket.VBuckets == 0 || c.blueprint.Bucket.VBuckets == 1024 {
-		return nil
-	}
+// checkReachability verifies that the Couchbase Server ports of each node are reachable from all the other nodes in the
+// cluster, displaying the resulting matrix.
+func (c *Cluster) checkReachability() error {
+	log.WithField("hosts", c.hosts()).Info("Checking port reachability")
 
-	log.WithField("vbuckets", c.blueprint.Bucket.VBuckets).Info("Limiting number of vBuckets")
+	var (
+		mu     sync.Mutex
+		matrix value.PortMatrix
+	)
 
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
-		`curl -X POST -u Administrator:asdasd localhost:8091/diag/eval -d \
-			"ns_config:set(couchbase_num_vbuckets_default, %d)."`, c.blueprint.Bucket.VBuckets))
+	err := c.forEachNode(func(source *Node) error {
+		for _, target := range c.nodes {
+			if source == target {
+				continue
+			}
 
-	return err
-}
+			for _, port := range value.PeerPorts(target.blueprint) {
+				check := value.PortCheck{
+					Source:    source.blueprint.Host,
+					Target:    target.blueprint.Name(),
+					Port:      port,
+					Reachable: source.reachable(target.blueprint.Name(), port),
+				}
+
+				mu.Lock()
+				matrix = append(matrix, check)
+				mu.Unlock()
+			}
+		}
 
-// enableDeveloperPreviewMode enables the developer preview mode for the cluster.
-func (c *Cluster) enableDeveloperPreviewMode() error {
-	if !c.blueprint.DeveloperPreview {
 		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	log.WithField("hosts", c.hosts()).Info("Enabling developer preview mode")
-
-	// Using POST request instead of the related CLI command since it prompts for user input confirmation
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`curl -X POST -u Administrator:asdasd \
-		localhost:8091/settings/developerPreview -d "enabled=true"`))
-
-	return err
-}
-
-// createBucket creates the benchmarking on the remote cluster which by default uses a quota of 80% of the total memory
-// on the cluster nodes.
-func (c *Cluster) createBucket() error {
-	fields := log.Fields{
-		"name":                 "default",
-		"type":                 c.blueprint.Bucket.Type,
-		"eviction_policy":      c.blueprint.Bucket.EvictionPolicy,
-		"pitr_enabled":         c.blueprint.Bucket.PiTREnabled,
-		"pitr_granularity":     c.blueprint.Bucket.PiTRGranularity,
-		"pitr_max_history_age": c.blueprint.Bucket.PiTRMaxHistoryAge,
+	if len(matrix) == 0 {
+		return nil
 	}
 
-	log.WithFields(fields).Info("Creating bucket")
-
-	command := fmt.Sprintf(
-		`%s couchbase-cli bucket-create --bucket default --bucket-type %s -c localhost:8091 \
-			-u Administrator -p asdasd --bucket-ramsize $QUOTA --bucket-eviction-policy %s \
-			--bucket-replica 0 --enable-flush 1 --wait`,
-		memInfo,
-		c.blueprint.Bucket.Type,
-		c.blueprint.Bucket.EvictionPolicy,
-	)
-
-	command = c.addPiTRArgs(command)
+	sort.Slice(matrix, func(i, j int) bool {
+		if matrix[i].Source != matrix[j].Source {
+			return matrix[i].Source < matrix[j].Source
+		}
 
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(command))
+		if matrix[i].Target != matrix[j].Target {
+			return matrix[i].Target < matrix[j].Target
+		}
 
-	return err
-}
+		return matrix[i].Port < matrix[j].Port
+	})
 
-// flushBucket flushes the benchmarking bucket on the remote cluster.
-//
-// TODO (jamesl33) This looks to be a synchronous operation so for large buckets this operation may timeout and fail.
-func (c *Cluster) flushBucket() error {
-	log.WithField("name", "default").Info("Flushing bucket")
+	fmt.Printf("%s\n", matrix)
 
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`couchbase-cli bucket-flush -c localhost:8091 \
-		-u Administrator -p asdasd --bucket default --force`))
-	if err != nil {
-		return err
+	if unreachable := matrix.Unreachable(); len(unreachable) != 0 {
+		return fmt.Errorf("%d port(s) are unreachable between cluster nodes", len(unreachable))
 	}
 
-	// We've got to wait for things to complete, this isn't ideal but will have to do for now
-	time.Sleep(30 * time.Second)
-
 	return nil
 }
 
-// compactBucket compacts the benchmarking bucket on the remote cluster.
-func (c *Cluster) compactBucket() error {
-	log.WithField("name", "default").Info("Compacting bucket")
-
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`couchbase-cli bucket-compact -c localhost:8091 \
-		-u Administrator -p asdasd --bucket default`))
+// initializeCB will initialize Couchbase Server
+func (c *Cluster) initializeCB(ctx context.Context) error {
+	err := c.clusterInit()
 	if err != nil {
-		return errors.Wrap(err, "")
+		return errors.Wrap(err, "failed to initialize cluster")
 	}
 
-	// We've got to wait for things to start, for example we need to wait for the compaction entry to be added to the
-	// running tasks.
-	time.Sleep(30 * time.Second)
+	// The server groups must exist before any nodes can be added to them
+	err = c.configureServerGroups()
+	if err != nil {
+		return errors.Wrap(err, "failed to configure server groups")
+	}
 
-	timeout, err := poll(c.compactionComplete, 24*time.Hour)
+	err = c.forEachNode(func(node *Node) error { return c.serverAdd(node) })
 	if err != nil {
-		return errors.Wrap(err, "failed to poll until compaction completed")
+		return errors.Wrap(err, "failed to add cluster nodes")
 	}
 
-	if timeout {
-		return errors.New("timeout whilst waiting for bucket compaction to complete")
+	err = c.rebalance(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to rebalance nodes into cluster")
 	}
 
 	return nil
 }
 
-// runPreBenchmarkTasks performs any tasks which should be completed prior to running any benchmarks. For example, we
-// should flush the caches to avoid skewed results.
-func (c *Cluster) runPreBenchmarkTasks() error {
-	log.Info("Running cluster pre-benchmark tasks")
+// configureSettings applies the cluster wide settings from the blueprint.
+func (c *Cluster) configureSettings() error {
+	err := c.configureAutoFailover()
+	if err != nil {
+		return errors.Wrap(err, "failed to configure auto-failover")
+	}
 
-	err := c.flushCaches()
+	err = c.configureCompaction()
 	if err != nil {
-		return errors.Wrap(err, "failed to flush caches")
+		return errors.Wrap(err, "failed to configure auto-compaction")
+	}
+
+	err = c.forEachNode(func(node *Node) error { return c.configureAlternateAddress(node) })
+	if err != nil {
+		return errors.Wrap(err, "failed to configure alternate addresses")
 	}
 
 	return nil
 }
 
-// flushCaches flushes the caches on all the nodes in the cluster.
-func (c *Cluster) flushCaches() error {
-	log.WithField("hosts", c.hosts()).Info("Flushing caches")
-
-	return c.forEachNode(func(node *Node) error { return node.client.FlushCaches() })
-}
+// configureAutoFailover uses the CLI to apply the auto-failover settings from the blueprint.
+func (c *Cluster) configureAutoFailover() error {
+	if c.blueprint.AutoFailover == nil {
+		return nil
+	}
 
-// forEachNode is a utility function which concurrently runs the provided function on each node in the cluster.
-func (c *Cluster) forEachNode(fn func(node *Node) error) error {
-	pool := hofp.NewPool(hofp.Options{
-		Size: maths.Min(system.NumCPU(), len(c.nodes)),
-	})
+	fields := log.Fields{"enabled": c.blueprint.AutoFailover.Enabled, "timeout": c.blueprint.AutoFailover.Timeout}
+	log.WithFields(fields).Info("Configuring auto-failover")
 
-	queue := func(node *Node) error { return pool.Queue(func(_ context.Context) error { return fn(node) }) }
+	command := fmt.Sprintf(`couchbase-cli setting-autofailover -c %s %s \
+		--enable-auto-failover %d`, c.nodes[0].localREST(), c.credentials.Args(), boolToInt(c.blueprint.AutoFailover.Enabled))
 
-	for _, node := range c.nodes {
-		if queue(node) != nil {
-			break
-		}
+	if c.blueprint.AutoFailover.Timeout != 0 {
+		command += fmt.Sprintf(" --auto-failover-timeout %d", c.blueprint.AutoFailover.Timeout)
 	}
 
-	return pool.Stop()
-}
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(command))
 
-// modifyEvictionPercentages updates the eviction percentages on each node in the cluster to the given value.
-func (c *Cluster) modifyEvictionPercentages(percentage int) error {
-	log.WithField("hosts", c.hosts()).Info("Modifying eviction percentages")
+	return err
+}
+
+// configureCompaction uses the CLI to apply the auto-compaction settings from the blueprint.
+func (c *Cluster) configureCompaction() error {
+	if c.blueprint.Compaction == nil {
+		return nil
+	}
+
+	fields := log.Fields{
+		"database_percentage": c.blueprint.Compaction.DatabasePercentage,
+		"view_percentage":     c.blueprint.Compaction.ViewPercentage,
+		"parallel":            c.blueprint.Compaction.Parallel,
+		"purge_interval":      c.blueprint.Compaction.MetadataPurgeInterval,
+	}
+
+	err := c.blueprint.Compaction.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid auto-compaction settings")
+	}
+
+	log.WithFields(fields).Info("Configuring auto-compaction")
+
+	command := fmt.Sprintf(`couchbase-cli setting-compaction -c %s %s \
+		--compaction-period-from 00:00 --compaction-period-to 00:00 --enable-compaction-abort 0 \
+		--enable-compaction-parallel %d`, c.nodes[0].localREST(), c.credentials.Args(),
+		boolToInt(c.blueprint.Compaction.Parallel))
+
+	if c.blueprint.Compaction.DatabasePercentage != 0 {
+		command += fmt.Sprintf(" --compaction-db-percentage %d", c.blueprint.Compaction.DatabasePercentage)
+	}
+
+	if c.blueprint.Compaction.ViewPercentage != 0 {
+		command += fmt.Sprintf(" --compaction-view-percentage %d", c.blueprint.Compaction.ViewPercentage)
+	}
+
+	if c.blueprint.Compaction.MetadataPurgeInterval != 0 {
+		command += fmt.Sprintf(" --metadata-purge-interval %g", c.blueprint.Compaction.MetadataPurgeInterval)
+	}
+
+	_, err = c.nodes[0].client.ExecuteCommand(value.NewCommand(command))
+
+	return err
+}
+
+// configureAlternateAddress uses the CLI to set the alternate address for the given node (if it has one).
+func (c *Cluster) configureAlternateAddress(node *Node) error {
+	if node.blueprint.AlternateAddress == "" {
+		return nil
+	}
+
+	fields := log.Fields{"host": node.blueprint.Host, "alternate_address": node.blueprint.AlternateAddress}
+	log.WithFields(fields).Info("Configuring alternate address")
+
+	_, err := node.client.ExecuteCommand(value.NewCommand(`couchbase-cli setting-alternate-address -c %s \
+		%s --set --node %s --hostname %s`,
+		node.localREST(), c.credentials.Args(), node.blueprint.Name(), node.blueprint.AlternateAddress))
+
+	return err
+}
+
+// limitVBuckets uses /diag/eval to limit the number of vBuckets in the cluster.
+func (c *Cluster) limitVBuckets() error {
+	// We're using a default number of vBuckets don't bother changing anything
+	if c.blueprint.Bucket.VBuckets == 0 || c.blueprint.Bucket.VBuckets == 1024 {
+		return nil
+	}
+
+	log.WithField("vbuckets", c.blueprint.Bucket.VBuckets).Info("Limiting number of vBuckets")
+
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -X POST -u %s %s/diag/eval -d "ns_config:set(couchbase_num_vbuckets_default, %d)."`,
+		c.credentials.UserInfo(), c.nodes[0].localREST(), c.blueprint.Bucket.VBuckets))
+
+	return err
+}
+
+// enableDeveloperPreviewMode enables the developer preview mode for the cluster.
+func (c *Cluster) enableDeveloperPreviewMode() error {
+	if !c.blueprint.DeveloperPreview {
+		return nil
+	}
+
+	log.WithField("hosts", c.hosts()).Info("Enabling developer preview mode")
+
+	// Using POST request instead of the related CLI command since it prompts for user input confirmation
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`curl -X POST -u %s \
+		%s/settings/developerPreview -d "enabled=true"`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+
+	return err
+}
+
+// createBuckets creates the benchmarking bucket and any additional buckets on the remote cluster, by default the
+// benchmarking bucket uses a quota of 80% of the total memory on the cluster nodes (less the additional bucket quotas).
+func (c *Cluster) createBuckets() error {
+	err := c.blueprint.ValidateBuckets()
+	if err != nil {
+		return errors.Wrap(err, "invalid buckets")
+	}
+
+	err = c.createNamedBucket(value.DefaultBucketName, fmt.Sprintf("$((%s))", c.blueprint.QuotaExpression()))
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range c.blueprint.Buckets {
+		err = c.createBucketFrom(bucket, bucket.Name, strconv.Itoa(bucket.Quota))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create bucket '%s'", bucket.Name)
+		}
+	}
+
+	return nil
+}
+
+// createNamedBucket creates a bucket with the given name and memory quota (in MiB, which may reference the '$QUOTA'
+// variable set by 'memInfo') using the settings from the bucket blueprint.
+func (c *Cluster) createNamedBucket(name, quota string) error {
+	return c.createBucketFrom(c.blueprint.Bucket, name, quota)
+}
+
+// createBucketFrom creates a bucket with the given name and memory quota using the settings from the given bucket
+// blueprint, the type/eviction policy default to those of the benchmarking bucket.
+func (c *Cluster) createBucketFrom(blueprint *value.BucketBlueprint, name, quota string) error {
+	bucketType := blueprint.Type
+	if bucketType == "" {
+		bucketType = c.blueprint.Bucket.Type
+	}
+
+	evictionPolicy := blueprint.EvictionPolicy
+	if evictionPolicy == "" {
+		evictionPolicy = c.blueprint.Bucket.EvictionPolicy
+	}
+
+	fields := log.Fields{
+		"name":                 name,
+		"type":                 bucketType,
+		"eviction_policy":      evictionPolicy,
+		"replicas":             blueprint.Replicas,
+		"storage_backend":      blueprint.StorageBackend,
+		"pitr_enabled":         c.blueprint.Bucket.PiTREnabled,
+		"pitr_granularity":     c.blueprint.Bucket.PiTRGranularity,
+		"pitr_max_history_age": c.blueprint.Bucket.PiTRMaxHistoryAge,
+	}
+
+	log.WithFields(fields).Info("Creating bucket")
+
+	command := fmt.Sprintf(
+		`%s couchbase-cli bucket-create --bucket %s --bucket-type %s -c %s \
+			%s --bucket-ramsize %s --bucket-eviction-policy %s --bucket-replica %d --enable-flush 1 --wait`,
+		c.memInfo(),
+		name,
+		bucketType,
+		c.nodes[0].localREST(),
+		c.credentials.Args(),
+		quota,
+		evictionPolicy,
+		blueprint.Replicas,
+	)
+
+	if blueprint.StorageBackend != "" {
+		command += " --storage-backend " + blueprint.StorageBackend
+	}
+
+	command = c.addPiTRArgs(command)
+
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(command))
+
+	return err
+}
+
+// flushBucket flushes the benchmarking bucket (and any additional buckets) on the remote cluster.
+//
+// TODO (jamesl33) This looks to be a synchronous operation so for large buckets this operation may timeout and fail.
+func (c *Cluster) flushBucket() error {
+	return c.flushBuckets(c.blueprint.BucketNames()...)
+}
+
+// flushBuckets flushes the buckets with the given names on the remote cluster.
+func (c *Cluster) flushBuckets(names ...string) error {
+	for _, name := range names {
+		log.WithField("name", name).Info("Flushing bucket")
+
+		_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`couchbase-cli bucket-flush -c %s \
+			%s --bucket %s --force`, c.nodes[0].localREST(), c.credentials.Args(), name))
+		if err != nil {
+			return err
+		}
+	}
+
+	// We've got to wait for things to complete, this isn't ideal but will have to do for now
+	time.Sleep(30 * time.Second)
+
+	return nil
+}
+
+// resizeBucket modifies the memory quota of the bucket with the given name, the quota is in MiB and may reference the
+// '$QUOTA' variable set by 'memInfo'.
+func (c *Cluster) resizeBucket(name, quota string) error {
+	log.WithFields(log.Fields{"name": name, "quota": quota}).Info("Resizing bucket")
+
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`%s couchbase-cli bucket-edit -c %s \
+		%s --bucket %s --bucket-ramsize %s`, c.memInfo(), c.nodes[0].localREST(), c.credentials.Args(), name, quota))
+
+	return err
+}
+
+// deleteBucket deletes the bucket with the given name from the remote cluster.
+func (c *Cluster) deleteBucket(name string) error {
+	log.WithField("name", name).Info("Deleting bucket")
+
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`couchbase-cli bucket-delete -c %s \
+		%s --bucket %s`, c.nodes[0].localREST(), c.credentials.Args(), name))
+
+	return err
+}
+
+// compactBucket compacts the benchmarking bucket on the remote cluster.
+func (c *Cluster) compactBucket(ctx context.Context) error {
+	log.WithField("name", "default").Info("Compacting bucket")
+
+	_, err := c.nodes[0].client.ExecuteCommandContext(ctx, value.NewCommand(`couchbase-cli bucket-compact -c %s \
+		%s --bucket default`, c.nodes[0].localREST(), c.credentials.Args()))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	// We've got to wait for things to start, for example we need to wait for the compaction entry to be added to the
+	// running tasks.
+	if !sleepContext(ctx, 30*time.Second) {
+		return ctx.Err()
+	}
+
+	timeout, err := poll(ctx, c.compactionComplete, 24*time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "failed to poll until compaction completed")
+	}
+
+	if timeout {
+		return errors.New("timeout whilst waiting for bucket compaction to complete")
+	}
+
+	return nil
+}
+
+// runPreBenchmarkTasks performs any tasks which should be completed prior to running any benchmarks. For example, we
+// should flush the caches to avoid skewed results.
+func (c *Cluster) runPreBenchmarkTasks() error {
+	log.Info("Running cluster pre-benchmark tasks")
+
+	err := c.flushCaches()
+	if err != nil {
+		return errors.Wrap(err, "failed to flush caches")
+	}
+
+	return nil
+}
+
+// startLiveWorkload starts the given live workload in the background on the first node in the cluster.
+func (c *Cluster) startLiveWorkload(config *value.LiveWorkloadConfig) error {
+	log.WithField("host", c.nodes[0].blueprint.Host).Info("Starting live workload")
+
+	var (
+		node      = c.nodes[0]
+		directory = node.tempDirectory()
+	)
+
+	err := node.client.CreateDirectory(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	c.cpuTimes, err = c.snapshotCPUTimes()
+	if err != nil {
+		return errors.Wrap(err, "failed to snapshot cpu times")
+	}
+
+	// The PID file is kept in the process directory, so that the workload is killed by 'kill-run'
+	err = node.client.CreateDirectory(node.processDirectory())
+	if err != nil {
+		return errors.Wrap(err, "failed to create process directory")
+	}
+
+	pidFile := value.RemoteJoin(node.processDirectory(), "workload.pid")
+
+	_, err = node.client.ExecuteCommand(config.CommandStart(node.localKV(), c.credentials,
+		value.RemoteJoin(directory, "workload.log"), pidFile))
+	if err != nil {
+		return err
+	}
+
+	node.track("cbc-pillowfight", pidFile)
+
+	return nil
+}
+
+// stopLiveWorkload stops the live workload started by 'startLiveWorkload' and returns a summary of the latencies and
+// CPU usage observed whilst it was running.
+func (c *Cluster) stopLiveWorkload() (*value.PhaseSummary, error) {
+	log.WithField("host", c.nodes[0].blueprint.Host).Info("Stopping live workload")
+
+	var (
+		node      = c.nodes[0]
+		directory = node.tempDirectory()
+		output    = value.RemoteJoin(directory, "workload.log")
+	)
+
+	cpuTimes, err := c.snapshotCPUTimes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to snapshot cpu times")
+	}
+
+	pidFile := value.RemoteJoin(node.processDirectory(), "workload.pid")
+
+	_, err = node.client.ExecuteCommand(value.CommandStopWorkload(pidFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stop workload")
+	}
+
+	node.untrack(pidFile)
+
+	histogram, err := node.client.ExecuteCommand(value.NewCommand("cat %s", output))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read workload output")
+	}
+
+	err = node.client.RemoveFile(output)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove workload output")
+	}
+
+	var cpu float64
+	for idx := range cpuTimes {
+		cpu += value.CPUUsage(c.cpuTimes[idx], cpuTimes[idx]) / float64(len(cpuTimes))
+	}
+
+	return &value.PhaseSummary{Latency: value.ParseLatencyHistogram(histogram).Summary(), CPU: cpu}, nil
+}
+
+// snapshotCPUTimes returns the current CPU times for each node in the cluster.
+func (c *Cluster) snapshotCPUTimes() ([]value.CPUTimes, error) {
+	outputs := c.RunOnAll(value.CommandCPUTimes())
+
+	err := outputs.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cpu times")
+	}
+
+	times := make([]value.CPUTimes, len(outputs))
+
+	for idx, output := range outputs {
+		times[idx], err = value.ParseCPUTimes(output.Output)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse cpu times for node '%s'", output.Host)
+		}
+	}
+
+	return times, nil
+}
+
+// capturePhase runs the given live workload for its configured phase duration and returns a summary of the observed
+// latencies/CPU usage, this is used to capture the baseline before/after a benchmark.
+func (c *Cluster) capturePhase(config *value.LiveWorkloadConfig) (*value.PhaseSummary, error) {
+	err := c.startLiveWorkload(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start live workload")
+	}
+
+	time.Sleep(config.PhaseDuration())
+
+	return c.stopLiveWorkload()
+}
+
+// flushCaches flushes the caches on all the nodes in the cluster.
+func (c *Cluster) flushCaches() error {
+	log.WithField("hosts", c.hosts()).Info("Flushing caches")
+
+	return c.RunOnAll(value.CommandFlushCaches()).Err()
+}
+
+// SetConcurrency sets the maximum number of nodes which are operated on concurrently e.g. when provisioning, a zero
+// value restores the default (the number of local CPUs).
+func (c *Cluster) SetConcurrency(concurrency int) {
+	c.concurrency = concurrency
+}
+
+// ReuseInstalls allows reusing existing installs of the version of Couchbase Server being provisioned on each node,
+// regardless of the blueprint.
+func (c *Cluster) ReuseInstalls() {
+	for _, node := range c.nodes {
+		node.reuse = true
+	}
+}
+
+// forEachNode is a utility function which concurrently runs the provided function on each node in the cluster, stopping
+// at the first error.
+func (c *Cluster) forEachNode(fn func(node *Node) error) error {
+	concurrency := c.concurrency
+	if concurrency == 0 {
+		concurrency = system.NumCPU()
+	}
+
+	pool := hofp.NewPool(hofp.Options{Size: maths.Min(concurrency, len(c.nodes))})
+
+	queue := func(node *Node) error { return pool.Queue(func(_ context.Context) error { return fn(node) }) }
+
+	for _, node := range c.nodes {
+		if queue(node) != nil {
+			break
+		}
+	}
+
+	return pool.Stop()
+}
+
+// forEveryNode concurrently runs the provided function on every node in the cluster, unlike 'forEachNode' a failure
+// doesn't stop the function being run on the remaining nodes. The errors for each of the failed nodes are aggregated.
+func (c *Cluster) forEveryNode(fn func(node *Node) error) error {
+	var (
+		mu       sync.Mutex
+		failures []string
+	)
+
+	err := c.forEachNode(func(node *Node) error {
+		err := fn(node)
+		if err == nil {
+			return nil
+		}
+
+		log.WithError(err).WithField("host", node.blueprint.Host).Error("Failed on node")
+
+		mu.Lock()
+		failures = append(failures, fmt.Sprintf("%s: %s", node.blueprint.Host, err))
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	sort.Strings(failures)
+
+	return fmt.Errorf("failed on %d of %d node(s): %s", len(failures), len(c.nodes), strings.Join(failures, "; "))
+}
+
+// modifyEvictionPercentages updates the eviction percentages on each node in the cluster to the given value.
+func (c *Cluster) modifyEvictionPercentages(percentage int) error {
+	log.WithField("hosts", c.hosts()).Info("Modifying eviction percentages")
 
 	return c.forEachNode(func(node *Node) error { return c.modifyEvictionPercentage(node, percentage) })
 }
@@ -523,31 +1237,52 @@ func (c *Cluster) modifyEvictionPercentage(node *Node, percentage int) error {
 	fields := log.Fields{"node": node.blueprint.Host, "percentage": percentage}
 	log.WithFields(fields).Info("Modifying eviction percentage on node")
 
-	_, err := c.nodes[0].client.ExecuteCommand(
-		value.NewCommand(`cbepctl localhost:11210 -b default -u Administrator -p asdasd \
-			set flush_param item_eviction_age_percentage %d`, percentage))
+	_, err := node.client.ExecuteCommand(
+		value.NewCommand(`cbepctl %s -b default %s \
+			set flush_param item_eviction_age_percentage %d`, node.localKV(), c.credentials.Args(), percentage))
 
 	return err
 }
 
-// loadData runs the data loader specified in the config on each node in the cluster to generate the benchmarking
-// dataset.
-func (c *Cluster) loadData() error {
+// loadData runs the data loader specified in the config on each node in the cluster to load the given number of items
+// into the benchmarking bucket, which already contains the given number of items (the offset).
+func (c *Cluster) loadData(ctx context.Context, total, offset int) error {
+	// The dataset is imported from a single node, 'cbimport' parallelizes the import itself
+	if c.blueprint.Bucket.Data.DataLoader == value.CBImport {
+		return c.importData(ctx)
+	}
+
 	items := make(chan int, len(c.nodes))
 
 	for i := 0; i < len(c.nodes)-1; i++ {
-		items <- c.blueprint.Bucket.Data.Items / len(c.nodes)
+		items <- total / len(c.nodes)
+	}
+
+	items <- (total / len(c.nodes)) + (total % len(c.nodes))
+
+	err := c.blueprint.Bucket.Data.ValidateKeys()
+	if err != nil {
+		return errors.Wrap(err, "invalid key pattern")
 	}
 
-	items <- (c.blueprint.Bucket.Data.Items / len(c.nodes)) + (c.blueprint.Bucket.Data.Items % len(c.nodes))
+	err = c.blueprint.Bucket.Data.ValidateSimilarity()
+	if err != nil {
+		return errors.Wrap(err, "invalid similarity")
+	}
+
+	batches := make(chan []value.KeyBatch, len(c.nodes))
+
+	for _, batch := range c.blueprint.Bucket.Data.KeyBatches(total, len(c.nodes), offset) {
+		batches <- batch
+	}
 
 	var nodeDataLoadingFunc func(node *Node) error
 
 	switch c.blueprint.Bucket.Data.DataLoader {
 	case "", value.CBM:
-		nodeDataLoadingFunc = func(node *Node) error { return c.loadDataFromNodeUsingBackupMgr(node, <-items) }
+		nodeDataLoadingFunc = func(node *Node) error { return c.loadDataFromNodeUsingBackupMgr(ctx, node, <-batches) }
 	case value.Pillowfight:
-		nodeDataLoadingFunc = func(node *Node) error { return c.loadDataFromNodeUsingPillowfight(node, <-items) }
+		nodeDataLoadingFunc = func(node *Node) error { return c.loadDataFromNodeUsingPillowfight(ctx, node, <-items) }
 	default:
 		return fmt.Errorf("unknown/unsupported data loader '%s'", c.blueprint.Bucket.Data.DataLoader)
 	}
@@ -555,44 +1290,256 @@ func (c *Cluster) loadData() error {
 	return c.forEachNode(nodeDataLoadingFunc)
 }
 
-// loadDataFromNodeUsingBackupMgr runs 'cbbackupmgr' on the provided node to load the given number of items into the
-// benchmarking bucket.
-func (c *Cluster) loadDataFromNodeUsingBackupMgr(node *Node, items int) error {
+// importData runs 'cbimport' on the first node to import the dataset from the blueprint into the benchmarking bucket,
+// local JSON/CSV files are uploaded to the node first.
+func (c *Cluster) importData(ctx context.Context) error {
+	var (
+		dataset = c.blueprint.Bucket.Data.Import
+		node    = c.nodes[0]
+	)
+
+	err := dataset.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid import dataset")
+	}
+
+	path := dataset.SamplePath(node.pkg.InstallDirectory())
+
+	if dataset.Source != value.ImportSourceSample {
+		path = value.RemoteJoin(node.tempDirectory(), value.LocalBase(dataset.Path))
+
+		err = node.client.CreateDirectory(node.tempDirectory())
+		if err != nil {
+			return errors.Wrap(err, "failed to create upload directory")
+		}
+
+		err = node.client.SecureUpload(ctx, dataset.Path, path)
+		if err != nil {
+			return errors.Wrap(err, "failed to upload dataset")
+		}
+
+		defer func() {
+			err := node.client.RemoveFile(path)
+			if err != nil {
+				log.WithError(err).WithField("path", path).Warn("Failed to remove uploaded dataset")
+			}
+		}()
+	}
+
 	fields := log.Fields{
 		"host":    node.blueprint.Host,
 		"bucket":  "default",
-		"items":   items,
-		"size":    c.blueprint.Bucket.Data.Size,
+		"dataset": dataset.String(),
 		"threads": c.blueprint.Bucket.Data.LoadThreads,
 	}
 
+	log.WithFields(fields).Info("Running 'cbimport' to import data into bucket")
+
+	_, err = node.executeTracked(ctx, dataset.CommandImport(node.localREST(), c.credentials, path,
+		c.blueprint.Bucket.Data.LoadThreads))
+
+	return err
+}
+
+// loadDataFromNodeUsingBackupMgr runs 'cbbackupmgr' on the provided node to load the given batches of keys into the
+// benchmarking bucket.
+func (c *Cluster) loadDataFromNodeUsingBackupMgr(ctx context.Context, node *Node, batches []value.KeyBatch) error {
+	generate := c.generateKeys
+	if c.blueprint.Bucket.Data.Similarity != 0 {
+		generate = c.generateSimilarKeys
+	}
+
+	for _, batch := range batches {
+		err := generate(ctx, node, batch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate keys with prefix '%s'", batch.Prefix)
+		}
+	}
+
+	return nil
+}
+
+// generateKeys runs 'cbbackupmgr' on the provided node to load the given batch of keys into the benchmarking bucket.
+func (c *Cluster) generateKeys(ctx context.Context, node *Node, batch value.KeyBatch) error {
+	return c.generateBucketKeys(ctx, node, value.DefaultBucketName, c.blueprint.Bucket.Data, batch)
+}
+
+// generateBucketKeys runs 'cbbackupmgr' on the provided node to load the given batch of keys into the bucket with the
+// given name, using the settings from the given data blueprint.
+func (c *Cluster) generateBucketKeys(ctx context.Context, node *Node, bucket string, data *value.DataBlueprint,
+	batch value.KeyBatch,
+) error {
+	fields := log.Fields{
+		"host":        node.blueprint.Host,
+		"bucket":      bucket,
+		"items":       batch.Items,
+		"size":        data.Size,
+		"threads":     data.LoadThreads,
+		"key_pattern": data.KeyPatternOrDefault(),
+	}
+
 	log.WithFields(fields).Info("Running 'cbbackupmgr' to load data into bucket")
 
-	command := fmt.Sprintf(`cbbackupmgr generate --cluster localhost:8091 -u Administrator --password asdasd \
-		--bucket default --num-documents %d --prefix $(cat /dev/urandom | tr -dc 'a-z0-9' | fold -w 5 | head -n 1):: \
-		--size %d --no-progress-bar`,
-		items,
-		c.blueprint.Bucket.Data.Size,
+	command := fmt.Sprintf(`cbbackupmgr generate --cluster %s -u %s --password %s \
+		--bucket %s --num-documents %d --prefix %s --size %d --no-progress-bar`,
+		node.localREST(),
+		c.credentials.QuotedUsername(),
+		c.credentials.QuotedPassword(),
+		bucket,
+		batch.Items,
+		batch.Prefix,
+		data.Size,
 	)
 
-	if c.blueprint.Bucket.Data.LoadThreads != 0 {
-		command += fmt.Sprintf(" --threads %d", c.blueprint.Bucket.Data.LoadThreads)
+	if data.LoadThreads != 0 {
+		command += fmt.Sprintf(" --threads %d", data.LoadThreads)
 	} else {
 		command += " --threads $(nproc)"
 	}
 
-	if !c.blueprint.Bucket.Data.Compressible {
+	if !data.Compressible {
 		command += " --low-compression"
 	}
 
-	_, err := node.client.ExecuteCommand(value.NewCommand(command))
+	_, err := node.client.ExecuteCommandContext(ctx, value.NewCommand(command))
 
 	return err
 }
 
+// loadAdditionalBuckets loads the dataset of each of the additional buckets using 'cbbackupmgr', the keys are split
+// between the nodes in the same way as for the benchmarking bucket.
+func (c *Cluster) loadAdditionalBuckets(ctx context.Context) error {
+	for _, bucket := range c.blueprint.Buckets {
+		if bucket.Data == nil || bucket.Data.Items == 0 {
+			continue
+		}
+
+		err := bucket.Data.ValidateKeys()
+		if err != nil {
+			return errors.Wrapf(err, "invalid key pattern for bucket '%s'", bucket.Name)
+		}
+
+		batches := make(chan []value.KeyBatch, len(c.nodes))
+
+		for _, batch := range bucket.Data.KeyBatches(bucket.Data.Items, len(c.nodes), 0) {
+			batches <- batch
+		}
+
+		err = c.forEachNode(func(node *Node) error {
+			for _, batch := range <-batches {
+				err := c.generateBucketKeys(ctx, node, bucket.Name, bucket.Data, batch)
+				if err != nil {
+					return errors.Wrapf(err, "failed to generate keys with prefix '%s'", batch.Prefix)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to load bucket '%s'", bucket.Name)
+		}
+	}
+
+	return nil
+}
+
+// generateSimilarKeys generates the given batch of keys on the provided node with the configured cross-document
+// similarity, then imports them into the benchmarking bucket using 'cbimport'. The generated documents are written to
+// the run temporary directory, and removed once they've been imported.
+func (c *Cluster) generateSimilarKeys(ctx context.Context, node *Node, batch value.KeyBatch) error {
+	fields := log.Fields{
+		"host":       node.blueprint.Host,
+		"bucket":     "default",
+		"items":      batch.Items,
+		"size":       c.blueprint.Bucket.Data.Size,
+		"similarity": c.blueprint.Bucket.Data.Similarity,
+	}
+
+	log.WithFields(fields).Info("Generating similar documents to load into bucket")
+
+	err := node.client.CreateDirectory(node.tempDirectory())
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	path := value.RemoteJoin(node.tempDirectory(), "similar.json")
+
+	defer func() {
+		err := node.client.RemoveFile(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Warn("Failed to remove generated documents")
+		}
+	}()
+
+	_, err = node.executeTracked(ctx, c.blueprint.Bucket.Data.CommandGenerateSimilar(batch, path))
+	if err != nil {
+		return errors.Wrap(err, "failed to generate documents")
+	}
+
+	_, err = node.executeTracked(ctx, c.blueprint.Bucket.Data.CommandImportSimilar(node.localREST(), c.credentials, path))
+
+	return err
+}
+
+// churn generates the mutations described by the given config on the first node, then imports them into the
+// benchmarking bucket using 'cbimport' and waits for them to be persisted so the incremental backup contains them all.
+func (c *Cluster) churn(ctx context.Context, config *value.ChurnConfig) (*value.ChurnResult, error) {
+	node := c.nodes[0]
+
+	fields := log.Fields{
+		"host":         node.blueprint.Host,
+		"mutations":    config.Mutations,
+		"keys":         config.Keys,
+		"distribution": config.DistributionOrDefault(),
+	}
+
+	log.WithFields(fields).Info("Mutating dataset")
+
+	err := node.client.CreateDirectory(node.tempDirectory())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	path := value.RemoteJoin(node.tempDirectory(), "churn.json")
+
+	defer func() {
+		err := node.client.RemoveFile(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Warn("Failed to remove generated mutations")
+		}
+	}()
+
+	output, err := node.executeTracked(ctx, config.CommandGenerate(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate mutations")
+	}
+
+	unique, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse number of mutated keys")
+	}
+
+	_, err = node.executeTracked(ctx, config.CommandImport(node.localREST(), c.credentials, path,
+		c.blueprint.Bucket.Data.LoadThreads))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to import mutations")
+	}
+
+	err = c.persistBarrier(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for mutations to be persisted")
+	}
+
+	return &value.ChurnResult{
+		Distribution: config.DistributionOrDefault(),
+		Mutations:    config.Mutations,
+		Unique:       unique,
+	}, nil
+}
+
 // loadDataFromNodeBackupUsingPillowfight runs 'cbc-pillowfight' on a given node to load and mutate the given number
 // of items for at least one time for each granularity period (used with Point-In-Time backup testing).
-func (c *Cluster) loadDataFromNodeUsingPillowfight(node *Node, items int) error {
+func (c *Cluster) loadDataFromNodeUsingPillowfight(ctx context.Context, node *Node, items int) error {
 	if !c.blueprint.Bucket.PiTREnabled {
 		return fmt.Errorf("loading data with 'cbc-pillowfight' is only supported for PiTR")
 	}
@@ -619,8 +1566,11 @@ func (c *Cluster) loadDataFromNodeUsingPillowfight(node *Node, items int) error
 
 	log.WithFields(fields).Info("Running 'pillowfight' to load data into bucket")
 
-	command := fmt.Sprintf(`cbc-pillowfight -U localhost -u Administrator -P asdasd -B %d -I %d --num-cycles %d \
-		--rate-limit %d -m %d -M %d -r 100 -R --sequential`,
+	command := fmt.Sprintf(`cbc-pillowfight -U couchbase://%s=mcd -u %s -P %s -B %d -I %d \
+		--num-cycles %d --rate-limit %d -m %d -M %d -r 100 -R --sequential`,
+		node.localKV(),
+		c.credentials.QuotedUsername(),
+		c.credentials.QuotedPassword(),
 		c.blueprint.Bucket.Data.ActiveItems,
 		c.blueprint.Bucket.Data.ActiveItems,
 		cyclesNum,
@@ -637,23 +1587,101 @@ func (c *Cluster) loadDataFromNodeUsingPillowfight(node *Node, items int) error
 		command += " --compress"
 	}
 
-	_, err := node.client.ExecuteCommand(value.NewCommand(command))
+	_, err := node.executeTracked(ctx, value.NewCommand(command))
 
 	return err
 }
 
 // clusterInit uses the CLI to initialize the cluster with an 80% ram quota and the standard cluster_run credentials.
 func (c *Cluster) clusterInit() error {
-	fields := log.Fields{"hosts": c.hosts(), "username": "Administrator", "password": "asdasd"}
+	indexStorage, err := value.IndexStorageSetting(c.blueprint.IndexStorageMode)
+	if err != nil {
+		return err
+	}
+
+	services := c.nodes[0].blueprint.ServicesOrDefault(true)
+	if !services.Has(value.ServiceData) {
+		return fmt.Errorf("the first node must run the data service, not '%s'", services)
+	}
+
+	fields := log.Fields{
+		"hosts":         c.hosts(),
+		"username":      c.credentials.Username,
+		"index_storage": indexStorage,
+		"services":      services,
+	}
+
 	log.WithFields(fields).Info("Initializing cluster")
 
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`
-		%s couchbase-cli cluster-init -c localhost:8091 --cluster-username Administrator --cluster-password asdasd \
-			--cluster-ramsize $QUOTA`, memInfo))
+	if c.bootstrap == value.BootstrapFlowClusterInit {
+		return c.clusterInitREST(indexStorage, services)
+	}
+
+	_, err = c.nodes[0].client.ExecuteCommand(value.NewCommand(`
+		%s couchbase-cli cluster-init -c %s --cluster-username %s --cluster-password %s \
+			--cluster-ramsize $QUOTA --index-storage-setting %s --services %s`, c.memInfo(), c.nodes[0].localREST(),
+		c.credentials.QuotedUsername(), c.credentials.QuotedPassword(), indexStorage, services))
 
 	return err
 }
 
+// clusterInitREST initializes the first node and the cluster using a single call to the '/clusterInit' REST endpoint,
+// setting the administrator credentials at the same time as the node settings which would otherwise be set using
+// 'node-init'.
+func (c *Cluster) clusterInitREST(indexStorage string, services value.Services) error {
+	node := c.nodes[0]
+
+	afamily := "ipv4"
+	if c.blueprint.UseIPv6() {
+		afamily = "ipv6"
+	}
+
+	command := fmt.Sprintf(`%s curl -s -f -g -X POST http://%s/clusterInit --data-urlencode username=%s \
+		--data-urlencode password=%s -d port=SAME -d services=%s -d memoryQuota=$QUOTA -d indexerStorageMode=%s \
+		-d afamily=%s`, c.memInfo(), node.localREST(), c.credentials.QuotedUsername(), c.credentials.QuotedPassword(),
+		services.REST(), value.IndexerStorageMode(indexStorage), afamily)
+
+	if node.blueprint.DataPath != "" {
+		command += fmt.Sprintf(" --data-urlencode dataPath=%s", node.blueprint.DataPath)
+	}
+
+	if node.blueprint.IndexPath != "" {
+		command += fmt.Sprintf(" --data-urlencode indexPath=%s", node.blueprint.IndexPath)
+	}
+
+	if node.blueprint.Hostname != "" {
+		command += fmt.Sprintf(" --data-urlencode hostname=%s", node.blueprint.Hostname)
+	}
+
+	_, err := node.client.ExecuteCommand(value.NewCommand("%s", command))
+
+	return err
+}
+
+// serviceNode returns the first node running the given service, nil is returned if no nodes are running it.
+func (c *Cluster) serviceNode(service value.Service) *Node {
+	for i, node := range c.nodes {
+		if node.blueprint.ServicesOrDefault(i == 0).Has(service) {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// memInfo returns the 'memInfo' prefix for this cluster, the data service quota leaves enough memory for the services
+// which are run alongside it on any node (using their default quotas).
+func (c *Cluster) memInfo() string {
+	var reserved int
+	for i, node := range c.nodes {
+		if memory := node.blueprint.ServicesOrDefault(i == 0).ReservedMemory(); memory > reserved {
+			reserved = memory
+		}
+	}
+
+	return fmt.Sprintf(memInfo, reserved)
+}
+
 // serverAdd uses the CLI to add the given node into the cluster.
 func (c *Cluster) serverAdd(node *Node) error {
 	log.WithField("host", node.blueprint.Host).Info("Adding node to cluster")
@@ -663,29 +1691,93 @@ func (c *Cluster) serverAdd(node *Node) error {
 		return nil
 	}
 
-	var service string
-	switch {
-	case node.blueprint.DataPath != "":
-		service = "data"
-	case node.blueprint.IndexPath != "":
-		service = "search"
-	default:
+	services := node.blueprint.ServicesOrDefault(false)
+	if len(services) == 0 {
 		return fmt.Errorf("node %s does not have a data or index path", node.blueprint.Host)
 	}
 
-	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(`
-		couchbase-cli server-add -c localhost:8091 -u Administrator -p asdasd --server-add %s \
-			--server-add-username Administrator --server-add-password asdasd --services %s`, node.blueprint.Host, service))
+	command := fmt.Sprintf(`couchbase-cli server-add -c %s %s --server-add %s \
+		--server-add-username %s --server-add-password %s --services %s`,
+		c.nodes[0].localREST(), c.credentials.Args(), node.blueprint.ClusterAddress(), c.credentials.QuotedUsername(),
+		c.credentials.QuotedPassword(), services)
+
+	if node.blueprint.ServerGroup != "" {
+		command += fmt.Sprintf(" --group-name '%s'", node.blueprint.ServerGroup)
+	}
+
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(command))
 
 	return err
 }
 
+// configureServerGroups creates the server groups used by the nodes in the blueprint and moves the first node (which
+// is placed in the default group when the cluster is initialized) into its group. The default group is removed when
+// it's no longer used.
+func (c *Cluster) configureServerGroups() error {
+	groups := c.blueprint.ServerGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	log.WithField("groups", groups).Info("Configuring server groups")
+
+	var (
+		node        = c.nodes[0]
+		defaultUsed = false
+	)
+
+	for _, group := range groups {
+		if group == value.DefaultServerGroup {
+			defaultUsed = true
+			continue
+		}
+
+		_, err := node.client.ExecuteCommand(value.NewCommand(
+			`couchbase-cli group-manage -c %s %s --create --group-name '%s'`,
+			node.localREST(), c.credentials.Args(), group))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create server group '%s'", group)
+		}
+	}
+
+	group := node.blueprint.ServerGroup
+	if group == "" || group == value.DefaultServerGroup {
+		return nil
+	}
+
+	_, err := node.client.ExecuteCommand(value.NewCommand(
+		`couchbase-cli group-manage -c %s %s --move-servers %s --from-group '%s' \
+			--to-group '%s'`, node.localREST(), c.credentials.Args(), node.blueprint.ClusterAddress(),
+		value.DefaultServerGroup, group))
+	if err != nil {
+		return errors.Wrapf(err, "failed to move node into server group '%s'", group)
+	}
+
+	// Nodes without a server group are added to the default group, so it must only be removed when they are all in one
+	for _, node := range c.blueprint.Nodes {
+		defaultUsed = defaultUsed || node.ServerGroup == ""
+	}
+
+	if defaultUsed {
+		return nil
+	}
+
+	_, err = node.client.ExecuteCommand(value.NewCommand(
+		`couchbase-cli group-manage -c %s %s --delete --group-name '%s'`,
+		node.localREST(), c.credentials.Args(), value.DefaultServerGroup))
+	if err != nil {
+		return errors.Wrap(err, "failed to remove default server group")
+	}
+
+	return nil
+}
+
 // rebalance uses the CLI to rebalance the cluster.
-func (c *Cluster) rebalance() error {
+func (c *Cluster) rebalance(ctx context.Context) error {
 	log.Info("Rebalancing cluster")
 
-	_, err := c.nodes[0].client.ExecuteCommand(
-		value.NewCommand(`couchbase-cli rebalance -c localhost:8091 -u Administrator -p asdasd`))
+	_, err := c.nodes[0].client.ExecuteCommandContext(ctx,
+		value.NewCommand(`couchbase-cli rebalance -c %s %s`, c.nodes[0].localREST(), c.credentials.Args()))
 
 	return err
 }
@@ -711,7 +1803,11 @@ func (c *Cluster) addPiTRArgs(command string) string {
 //
 // NOTE: We don't use a multi-node connection string currently since they're not supported until 7.0.0.
 func (c *Cluster) ConnectionString() string {
-	return fmt.Sprintf("couchbase://%s", c.nodes[0].blueprint.Host)
+	if c.nodes[0].blueprint.RESTPortOrDefault() != value.DefaultRESTPort {
+		return fmt.Sprintf("http://%s", c.nodes[0].blueprint.RESTAddress())
+	}
+
+	return fmt.Sprintf("couchbase://%s", value.FormatHost(c.nodes[0].blueprint.Host))
 }
 
 // hosts returns a slice of all the hostnames for the nodes in the cluster.
@@ -724,22 +1820,89 @@ func (c *Cluster) hosts() []string {
 	return hosts
 }
 
+// RestoreState reverts the changes made to each of the nodes in the cluster, restoring their original state.
+func (c *Cluster) RestoreState() error {
+	return c.forEachNode(func(node *Node) error { return node.restoreState() })
+}
+
+// GarbageCollect removes any temporary directories older than the given duration from each of the nodes in the
+// cluster.
+func (c *Cluster) GarbageCollect(olderThan time.Duration) error {
+	return c.forEachNode(func(node *Node) error { return node.garbageCollect(olderThan) })
+}
+
+// HostsEntries returns the '/etc/hosts' entries required to resolve the names of all the nodes in the cluster, nodes
+// without a 'hostname' are skipped.
+func (c *Cluster) HostsEntries() (value.HostsEntries, error) {
+	entries := make(value.HostsEntries, 0, len(c.nodes))
+
+	for _, node := range c.nodes {
+		if node.blueprint.Hostname == "" {
+			continue
+		}
+
+		address, err := node.address()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get address for '%s'", node.blueprint.Host)
+		}
+
+		entries = append(entries, value.HostsEntry{Address: address, Name: node.blueprint.Hostname})
+	}
+
+	return entries, nil
+}
+
+// UpdateHosts adds the given entries to '/etc/hosts' on all the nodes in the cluster.
+func (c *Cluster) UpdateHosts(entries value.HostsEntries) error {
+	return c.forEachNode(func(node *Node) error { return node.updateHosts(entries) })
+}
+
+// Profiles returns the remote operation timings for each node in the cluster.
+func (c *Cluster) Profiles() value.Profiles {
+	profiles := make(value.Profiles, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		profiles = append(profiles, node.client.Profile())
+	}
+
+	return profiles
+}
+
 // Close releases any resources in use by the connection.
 func (c *Cluster) Close() error {
 	return c.forEachNode(func(node *Node) error { return node.Close() })
 }
 
-// poll runs the given function until it returns true or we reach the provided timeout.
-func poll(pollFunc func() (bool, error), timeout time.Duration) (bool, error) {
-	ctx, cancelFunc := context.WithTimeout(context.Background(), timeout)
+// boolToInt converts the given boolean into the 0/1 representation accepted by the CLI.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// poll runs the given function until it returns true or we reach the provided timeout, the context error is returned
+// if it's cancelled before then.
+func poll(ctx context.Context, pollFunc func() (bool, error), timeout time.Duration) (bool, error) {
+	return pollEvery(ctx, pollFunc, 15*time.Second, timeout)
+}
+
+// pollEvery is similar to 'poll' but runs the given function at the provided interval, this should be used when the
+// time taken to complete is being measured.
+func pollEvery(ctx context.Context, pollFunc func() (bool, error), interval, timeout time.Duration) (bool, error) {
+	timeoutCtx, cancelFunc := context.WithTimeout(ctx, timeout)
 	defer cancelFunc()
 
-	ticker := time.NewTicker(15 * time.Second)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-timeoutCtx.Done():
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+
 			return true, nil
 		case <-ticker.C:
 			ready, err := pollFunc()
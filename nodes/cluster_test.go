@@ -0,0 +1,201 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesl33/cbtools-autobench/ssh"
+	"github.com/jamesl33/cbtools-autobench/transport"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"gopkg.in/yaml.v2"
+)
+
+// testConfig is a minimal config for a two node cluster and a backup client, the package paths are relative to the
+// test directory.
+const testConfig = `
+ssh:
+  username: "root"
+blueprint:
+  cluster:
+    package_path: "couchbase-server-enterprise_7.1.0-2556-ubuntu20.04_amd64.deb"
+    nodes:
+      - host: "node-0"
+        data_path: "/mnt/data"
+      - host: "node-1"
+        data_path: "/mnt/data"
+    bucket:
+      data:
+        data_loader: "cbbackupmgr"
+        items: 100
+        size: 100
+  backup_client:
+    host: "backup-client"
+    package_path: "couchbase-server-enterprise_7.1.0-2556-ubuntu20.04_amd64.deb"
+benchmark:
+  iterations: 2
+  cbbackupmgr_config:
+    archive: "/mnt/archive"
+    repository: "repo"
+`
+
+// newTestConfig returns the test config, with the package created in a temporary directory which is used as the
+// working directory for the duration of the test (so that the run directory isn't created in the source tree).
+func newTestConfig(t *testing.T) *value.AutobenchConfig {
+	directory := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	err = os.Chdir(directory)
+	if err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
+	}
+
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	var config *value.AutobenchConfig
+
+	err = yaml.Unmarshal([]byte(testConfig), &config)
+	if err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(directory, config.Blueprint.Cluster.PackagePath), nil, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to create package: %v", err)
+	}
+
+	err = config.Blueprint.Expand()
+	if err != nil {
+		t.Fatalf("Failed to expand blueprint: %v", err)
+	}
+
+	config.Blueprint.DryRun()
+
+	return config
+}
+
+// useFake replaces the client of the given node with one which runs commands using a fake transport scripted with the
+// dry-run responses, the transport is returned so that the commands may be inspected.
+func useFake(t *testing.T, config *value.SSHConfig, node *Node) *transport.Fake {
+	fake := transport.NewDryRun()
+
+	client, err := ssh.NewClientWithTransport(node.blueprint.Host, fake, config, node.blueprint.Platform)
+	if err != nil {
+		t.Fatalf("Failed to create client for '%s': %v", node.blueprint.Host, err)
+	}
+
+	client.SetBinDirectory(node.pkg.BinDirectory())
+
+	node.client = client
+
+	return fake
+}
+
+// ran returns the number of commands run using the given transport which contain the given string.
+func ran(fake *transport.Fake, substr string) int {
+	var count int
+
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, substr) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestProvisionAndBenchmarkBackup(t *testing.T) {
+	// Creating the bucket waits for 30 seconds, regardless of the transport
+	if testing.Short() {
+		t.Skip("Skipping in short mode")
+	}
+
+	var (
+		ctx    = context.Background()
+		config = newTestConfig(t)
+		run    = value.RunID("test")
+	)
+
+	cluster, err := NewCluster(config.SSHConfig, config.Blueprint.Cluster, run)
+	if err != nil {
+		t.Fatalf("Failed to create cluster: %v", err)
+	}
+	defer cluster.Close()
+
+	client, err := NewBackupClient(config.SSHConfig, config.Blueprint.BackupClient, run)
+	if err != nil {
+		t.Fatalf("Failed to create backup client: %v", err)
+	}
+	defer client.Close()
+
+	fakes := make([]*transport.Fake, 0, len(cluster.nodes))
+	for _, node := range cluster.nodes {
+		fakes = append(fakes, useFake(t, config.SSHConfig, node))
+	}
+
+	clientFake := useFake(t, config.SSHConfig, client.node)
+
+	err = cluster.Provision(ctx)
+	if err != nil {
+		t.Fatalf("Failed to provision cluster: %v", err)
+	}
+
+	err = client.Provision(ctx)
+	if err != nil {
+		t.Fatalf("Failed to provision backup client: %v", err)
+	}
+
+	for idx, fake := range fakes {
+		if ran(fake, "dpkg -i") != 1 {
+			t.Errorf("Expected Couchbase Server to be installed once on node %d", idx)
+		}
+	}
+
+	if ran(fakes[0], "cluster-init") == 0 && ran(fakes[0], "/clusterInit") == 0 {
+		t.Errorf("Expected the cluster to be initialized using the first node")
+	}
+
+	if ran(fakes[0], "server-add") == 0 || ran(fakes[0], "rebalance") == 0 {
+		t.Errorf("Expected the second node to be added to the cluster and rebalanced in")
+	}
+
+	results, err := client.BenchmarkBackup(ctx, config.BenchmarkConfig, cluster)
+	if err != nil {
+		t.Fatalf("Failed to run backup benchmark: %v", err)
+	}
+
+	if len(results) != config.BenchmarkConfig.Iterations {
+		t.Fatalf("Expected %d results, got %d", config.BenchmarkConfig.Iterations, len(results))
+	}
+
+	if backups := ran(clientFake, "cbbackupmgr backup"); backups != config.BenchmarkConfig.Iterations {
+		t.Errorf("Expected %d backups to be run, got %d", config.BenchmarkConfig.Iterations, backups)
+	}
+
+	for idx, fake := range fakes {
+		if ran(fake, "cbbackupmgr") != 0 {
+			t.Errorf("Expected 'cbbackupmgr' not to be run on node %d", idx)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkCompatibility will create a backup using each of the versions of 'cbbackupmgr' from the compatibility
+// config, then restore it using every version (including the one which created it); producing a compatibility matrix
+// which includes the timings for each restore.
+func (b *BackupClient) BenchmarkCompatibility(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (value.CompatibilityMatrix, error) {
+	log.Info("Beginning 'cbbackupmgr' compatibility benchmark")
+
+	err := config.Compatibility.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid compatibility config")
+	}
+
+	// Ensure we always go back to using the installed version of 'cbbackupmgr'
+	defer b.useTools(nil, "")
+
+	defer b.enableCoreDumps()()
+
+	err = cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	var (
+		pkgs        = make([]*value.Package, 0, len(config.Compatibility.PackagePaths))
+		directories = make([]string, 0, len(config.Compatibility.PackagePaths))
+	)
+
+	for idx, path := range config.Compatibility.PackagePaths {
+		pkg := value.NewPackage(path, "", "")
+
+		directory, err := b.node.extractPackage(ctx, pkg,
+			value.RemoteJoin(b.node.tempDirectory(), "compatibility", strconv.Itoa(idx)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract package '%s'", path)
+		}
+
+		pkgs, directories = append(pkgs, pkg), append(directories, directory)
+	}
+
+	matrix := make(value.CompatibilityMatrix, 0, len(pkgs)*len(pkgs))
+
+	for creator := range pkgs {
+		ads, err := b.createCompatibilityBackup(ctx, config, cluster, pkgs[creator], directories[creator])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create backup using '%s'", pkgs[creator].Version())
+		}
+
+		for restorer := range pkgs {
+			// If the context has been cancelled, don't run any more restores; the user wants to gracefully terminate
+			if ctx.Err() != nil {
+				return matrix, nil
+			}
+
+			result, err := b.restoreCompatibilityBackup(ctx, config, cluster, pkgs[restorer], directories[restorer],
+				ads)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to restore backup using '%s'", pkgs[restorer].Version())
+			}
+
+			result.Backup, result.Restore = pkgs[creator].Version(), pkgs[restorer].Version()
+
+			matrix = append(matrix, result)
+		}
+	}
+
+	return matrix, nil
+}
+
+// createCompatibilityBackup creates a new repository containing a single backup using the 'cbbackupmgr' binaries from
+// the given package (extracted into the given directory), returning the size of the backup.
+func (b *BackupClient) createCompatibilityBackup(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+	pkg *value.Package, directory string,
+) (uint64, error) {
+	b.useTools(pkg, directory)
+
+	err := b.purgeArchive(config)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to purge archive")
+	}
+
+	err = b.createRepository(config)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create repository")
+	}
+
+	info, err := b.createBackup(ctx, config, cluster, true)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create backup")
+	}
+
+	return info.BackupSize, nil
+}
+
+// restoreCompatibilityBackup restores the backup in the repository using the 'cbbackupmgr' binaries from the given
+// package (extracted into the given directory). A failed restore is recorded in the result rather than returned since
+// it indicates an incompatibility, however, an error is returned if 'cbbackupmgr' crashes or the cluster health
+// degrades.
+func (b *BackupClient) restoreCompatibilityBackup(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+	pkg *value.Package, directory string, ads uint64,
+) (*value.CompatibilityResult, error) {
+	b.useTools(pkg, directory)
+
+	if !config.CBMConfig.Blackhole {
+		err := cluster.flushBucket()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to flush bucket")
+		}
+	}
+
+	err := cluster.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run cluster pre-benchmark tasks")
+	}
+
+	err = b.runPreBenchmarkTasks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run client pre-benchmark tasks")
+	}
+
+	start := time.Now()
+
+	err = b.restoreBackup(ctx, config, cluster)
+
+	result := &value.CompatibilityResult{Result: &value.BenchmarkResult{Duration: time.Since(start), ADS: ads}}
+
+	var crash *CrashError
+	if errors.As(err, &crash) {
+		return nil, err
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Restore failed, versions are incompatible")
+
+		result.Result, result.Error = nil, err.Error()
+	}
+
+	err = cluster.checkHealth()
+	if err != nil {
+		return nil, errors.Wrap(err, "cluster health check failed")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	fsutil "github.com/couchbase/tools-common/fs/util"
+	"github.com/pkg/errors"
+)
+
+// CrashError is returned when a benchmarked tool crashes, it contains the core dumps which were downloaded for
+// post-mortem debugging.
+type CrashError struct {
+	CoreDumps value.CoreDumps
+	err       error
+}
+
+// Error implements the 'error' interface.
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("benchmarked tool crashed producing %d core dump(s): %s", len(e.CoreDumps), e.err)
+}
+
+// Unwrap returns the error returned by the crashed tool.
+func (e *CrashError) Unwrap() error {
+	return e.err
+}
+
+// enableCoreDumps configures the backup client to write core dumps into the run core directory, the returned function
+// restores the original core pattern.
+//
+// NOTE: This is best effort, some environments (e.g. containers) don't allow modifying the core pattern.
+func (b *BackupClient) enableCoreDumps() func() {
+	output, err := b.node.client.ExecuteCommand(value.NewCommand("cat %s", value.CorePattern))
+	if err == nil {
+		_, err = b.node.client.ExecuteCommand(value.CommandEnableCoreDumps(b.node.coreDirectory()))
+	}
+
+	if err != nil {
+		log.WithError(err).Warn("Failed to enable core dumps, crashes will not be captured")
+		return func() {}
+	}
+
+	pattern := strings.TrimSpace(string(output))
+
+	return func() {
+		_, err := b.node.client.ExecuteCommand(value.CommandSetCorePattern(pattern))
+		if err != nil {
+			log.WithError(err).Warn("Failed to restore core pattern")
+		}
+	}
+}
+
+// runTool runs the given benchmarked command with core dumps enabled, if it fails any core dumps it produced are
+// downloaded into the run directory and a 'CrashError' is returned. The command is killed if the context is cancelled.
+func (b *BackupClient) runTool(ctx context.Context, command value.Command) ([]byte, error) {
+	output, err := b.node.executeTracked(ctx, value.WithCoreDumps(command))
+	if err == nil {
+		return output, nil
+	}
+
+	// We killed the command ourselves, so it didn't crash
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	dumps, dumpErr := b.collectCoreDumps()
+	if dumpErr != nil {
+		log.WithError(dumpErr).Warn("Failed to collect core dumps")
+	}
+
+	if len(dumps) == 0 {
+		return nil, err
+	}
+
+	return nil, &CrashError{CoreDumps: dumps, err: err}
+}
+
+// collectCoreDumps downloads any core dumps (and the binaries which produced them) from the run core directory into
+// the local run directory, they're removed from the backup client once downloaded.
+func (b *BackupClient) collectCoreDumps() (value.CoreDumps, error) {
+	output, err := b.node.client.ExecuteCommand(value.CommandFindCoreDumps(b.node.coreDirectory()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find core dumps")
+	}
+
+	cores := strings.Fields(string(output))
+	if len(cores) == 0 {
+		return nil, nil
+	}
+
+	path := filepath.Join(b.localDirectory(), "cores")
+
+	err = fsutil.Mkdir(path, 0, true, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create core dumps directory")
+	}
+
+	dumps := make(value.CoreDumps, 0, len(cores))
+
+	for _, core := range cores {
+		dump := &value.CoreDump{
+			Host:   b.blueprint.Host,
+			Core:   filepath.Join(path, value.RemoteBase(core)),
+			Binary: filepath.Join(path, value.RemoteBase(value.CoreDumpBinary(core))),
+		}
+
+		log.WithFields(log.Fields{"host": dump.Host, "core": core}).Error("Benchmarked tool crashed, downloading core dump")
+
+		err = b.node.client.SecureDownload(context.Background(), core, dump.Core)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to download core dump")
+		}
+
+		err = b.node.client.SecureDownload(context.Background(), value.CoreDumpBinary(core), dump.Binary)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to download binary")
+		}
+
+		err = b.node.client.RemoveFile(core)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to remove core dump")
+		}
+
+		dumps = append(dumps, dump)
+	}
+
+	return dumps, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// Credentials returns the credentials of the cluster administrator.
+func (c *Cluster) Credentials() *value.Credentials {
+	return c.credentials
+}
+
+// ToolCredentials returns the credentials the benchmarked tools should authenticate with, this is the external (LDAP)
+// user when configured to do so, otherwise the cluster administrator.
+func (c *Cluster) ToolCredentials() *value.Credentials {
+	if c.blueprint.LDAP != nil && c.blueprint.LDAP.AuthenticateTools {
+		return c.blueprint.LDAP.Credentials()
+	}
+
+	return c.credentials
+}
+
+// resolveCredentials determines the credentials of the cluster administrator and shares them with each node. When the
+// password is generated, the password stored on the first node is used; if there isn't one, a new password is
+// generated and stored on every node.
+func (c *Cluster) resolveCredentials() error {
+	credentials := &value.Credentials{
+		Username: c.blueprint.Credentials.UsernameOrDefault(),
+		Password: c.blueprint.Credentials.PasswordOrDefault(),
+	}
+
+	if c.blueprint.Credentials.Generated() {
+		var err error
+
+		credentials, err = c.generatedCredentials(credentials.Username)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.credentials = credentials
+
+	for _, node := range c.nodes {
+		node.credentials = credentials
+	}
+
+	return nil
+}
+
+// generatedCredentials returns the credentials for the given username using the generated password stored on the first
+// node, a password is generated (and stored on every node) if there isn't one.
+func (c *Cluster) generatedCredentials(username string) (*value.Credentials, error) {
+	output, err := c.nodes[0].client.ExecuteCommand(value.CommandReadPassword())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read generated password")
+	}
+
+	if password := value.ParsePassword(output); password != "" {
+		return &value.Credentials{Username: username, Password: password}, nil
+	}
+
+	log.WithField("path", value.CredentialsPath).Info("Generating cluster administrator password")
+
+	credentials, err := value.GenerateCredentials(username)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range c.nodes {
+		_, err = node.client.ExecuteCommand(value.CommandWritePassword(credentials.Password))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to store generated password on '%s'", node.blueprint.Host)
+		}
+	}
+
+	return credentials, nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// diskHost is a host whose IO is being attributed to the devices backing its data paths/archive/root filesystem.
+type diskHost struct {
+	node     *Node
+	devices  map[string]*value.DiskIO
+	previous map[string]value.DiskCounters
+}
+
+// diskMonitor periodically samples the IO counters of the devices backing the data paths of the cluster nodes and the
+// archive of the backup client in the background.
+type diskMonitor struct {
+	config *value.DiskIOConfig
+	hosts  []*diskHost
+	start  time.Time
+	last   time.Time
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// startDiskMonitor begins sampling the IO counters at the configured interval, nil is returned if monitoring isn't
+// enabled (or the devices couldn't be determined); it's valid to call 'stop' on a nil monitor.
+func startDiskMonitor(config *value.BenchmarkConfig, cluster *Cluster, client *BackupClient) *diskMonitor {
+	if config.DiskIO == nil {
+		return nil
+	}
+
+	m := &diskMonitor{config: config.DiskIO, done: make(chan struct{})}
+
+	// The backup client may also be a cluster node, in which case the paths are attributed using a single host
+	paths := make(map[string]map[value.DiskRole]string)
+	nodes := make(map[string]*Node)
+
+	add := func(node *Node, role value.DiskRole, path string) {
+		if path == "" {
+			return
+		}
+
+		if _, ok := paths[node.blueprint.Host]; !ok {
+			paths[node.blueprint.Host], nodes[node.blueprint.Host] = make(map[value.DiskRole]string), node
+		}
+
+		paths[node.blueprint.Host][role] = path
+	}
+
+	for _, node := range cluster.nodes {
+		add(node, value.DiskRoleData, node.blueprint.DataPath)
+		add(node, value.DiskRoleIndex, node.blueprint.IndexPath)
+		add(node, value.DiskRoleRoot, "/")
+	}
+
+	add(client.node, value.DiskRoleArchive, config.CBMConfig.LocalArchive())
+	add(client.node, value.DiskRoleRoot, "/")
+
+	for _, host := range cluster.hosts() {
+		m.addHost(nodes[host], paths[host])
+		delete(nodes, host)
+	}
+
+	for host, node := range nodes {
+		m.addHost(node, paths[host])
+	}
+
+	if len(m.hosts) == 0 {
+		return nil
+	}
+
+	m.start = time.Now()
+	m.last = m.start
+
+	m.wg.Add(1)
+
+	go m.run()
+
+	return m
+}
+
+// addHost determines the devices backing the given paths on the given node, and takes the initial sample of their
+// counters. Monitoring is best effort, hosts whose devices can't be determined are skipped rather than failing the
+// benchmark.
+func (m *diskMonitor) addHost(node *Node, paths map[value.DiskRole]string) {
+	fields := log.Fields{"host": node.blueprint.Host}
+
+	devices, err := node.blockDevices(paths)
+	if err != nil {
+		log.WithFields(fields).WithError(err).Warn("Failed to determine block devices, disk IO won't be monitored")
+		return
+	}
+
+	previous, err := node.diskStats()
+	if err != nil {
+		log.WithFields(fields).WithError(err).Warn("Failed to get disk stats, disk IO won't be monitored")
+		return
+	}
+
+	m.hosts = append(m.hosts, &diskHost{node: node, devices: devices, previous: previous})
+}
+
+// run takes a sample every interval until the monitor is stopped.
+func (m *diskMonitor) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.IntervalOrDefault())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.sample()
+	}
+}
+
+// sample records the IO which took place on each device since the previous sample.
+func (m *diskMonitor) sample() {
+	var (
+		now      = time.Now()
+		elapsed  = now.Sub(m.start)
+		interval = now.Sub(m.last)
+	)
+
+	m.last = now
+
+	for _, host := range m.hosts {
+		counters, err := host.node.diskStats()
+		if err != nil {
+			// Sampling is best effort, a missed sample shouldn't cause the benchmark to fail
+			log.WithField("host", host.node.blueprint.Host).WithError(err).Warn("Failed to take disk IO sample")
+			continue
+		}
+
+		for device, io := range host.devices {
+			current, ok := counters[device]
+			if !ok {
+				continue
+			}
+
+			io.Record(current.Sub(host.previous[device]), elapsed, interval, m.config.SaturationOrDefault())
+		}
+
+		host.previous = counters
+	}
+}
+
+// stop stops monitoring, takes a final sample and returns the IO attributed to each device, nil is returned if
+// monitoring wasn't enabled.
+func (m *diskMonitor) stop() []*value.DiskIO {
+	if m == nil {
+		return nil
+	}
+
+	close(m.done)
+	m.wg.Wait()
+
+	m.sample()
+
+	var devices []*value.DiskIO
+
+	for _, host := range m.hosts {
+		for _, io := range host.devices {
+			devices = append(devices, io)
+		}
+	}
+
+	value.SortDiskIO(devices)
+
+	return devices
+}
+
+// blockDevices returns the IO for the devices backing the given paths, paths backed by the same device are attributed
+// to a single device and paths which aren't backed by a block device (e.g. in a container) are ignored.
+func (n *Node) blockDevices(paths map[value.DiskRole]string) (map[string]*value.DiskIO, error) {
+	roles := make([]value.DiskRole, 0, len(paths))
+	for _, role := range value.DiskRoles {
+		if _, ok := paths[role]; ok {
+			roles = append(roles, role)
+		}
+	}
+
+	ordered := make([]string, 0, len(roles))
+	for _, role := range roles {
+		ordered = append(ordered, paths[role])
+	}
+
+	output, err := n.client.ExecuteCommand(value.CommandBlockDevices(ordered...))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get block devices")
+	}
+
+	names, err := value.ParseBlockDevices(output, len(ordered))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse block devices")
+	}
+
+	devices := make(map[string]*value.DiskIO)
+
+	for idx, name := range names {
+		if name == "" {
+			continue
+		}
+
+		if _, ok := devices[name]; !ok {
+			devices[name] = &value.DiskIO{Host: n.blueprint.Host, Device: name}
+		}
+
+		devices[name].Roles = append(devices[name].Roles, roles[idx])
+	}
+
+	return devices, nil
+}
+
+// diskStats returns the cumulative IO counters for each block device on the remote machine.
+func (n *Node) diskStats() (map[string]value.DiskCounters, error) {
+	output, err := n.client.ExecuteCommand(value.CommandDiskStats())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get disk stats")
+	}
+
+	return value.ParseDiskStats(output)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// sampleExpiries samples random documents from the benchmarking bucket returning their expiry by key, if a TTL is
+// configured it's set on the sampled documents first.
+//
+// NOTE: The keys are sampled using the first node, so only the vBuckets which are active on that node are sampled.
+func (c *Cluster) sampleExpiries(config *value.ExpiryValidationConfig) (map[string]uint64, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid expiry validation config")
+	}
+
+	node := c.nodes[0]
+
+	output, err := node.client.ExecuteCommand(value.CommandRandomKeys(node.localREST(), c.credentials,
+		config.SampleOrDefault()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sample keys")
+	}
+
+	keys, err := value.ParseRandomKeys(output)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse sampled keys")
+	}
+
+	if config.TTL != 0 {
+		output, err = c.runStatement(value.StatementSetExpiry(keys, config.TTL))
+		if err == nil {
+			_, err = value.ParseQueryResultCount(output)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set expiry on sampled documents")
+		}
+	}
+
+	expiries, err := c.expiries(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := log.Fields{"sampled": len(expiries), "ttl": config.TTL}
+	log.WithFields(fields).Info("Sampled document expiries before backup")
+
+	return expiries, nil
+}
+
+// validateExpiries compares the expiry of each of the sampled documents against the expiry captured before the backup
+// was created.
+//
+// NOTE: Mismatches don't fail the benchmark, they're logged and included in the report.
+func (c *Cluster) validateExpiries(expected map[string]uint64) (*value.ExpiryCheck, error) {
+	log.WithField("sampled", len(expected)).Info("Validating restored document expiries")
+
+	keys := make([]string, 0, len(expected))
+
+	for key := range expected {
+		keys = append(keys, key)
+	}
+
+	actual, err := c.expiries(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	check := value.NewExpiryCheck(expected, actual)
+
+	for _, mismatch := range check.Mismatches {
+		fields := log.Fields{
+			"key":      mismatch.Key,
+			"expected": mismatch.Expected,
+			"actual":   mismatch.Actual,
+			"missing":  mismatch.Missing,
+		}
+
+		log.WithFields(fields).Warn("Document has an unexpected expiry after restore")
+	}
+
+	return check, nil
+}
+
+// expiries returns the expiry of each of the documents with the given keys, documents which don't exist are omitted.
+func (c *Cluster) expiries(keys []string) (map[string]uint64, error) {
+	output, err := c.runStatement(value.StatementExpiries(keys))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query document expiries")
+	}
+
+	expiries, err := value.ParseExpiries(output)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse document expiries")
+	}
+
+	return expiries, nil
+}
+
+// runStatement runs the given N1QL statement using a node running the query service, returning the raw response.
+func (c *Cluster) runStatement(statement string) ([]byte, error) {
+	node := c.serviceNode(value.ServiceQuery)
+	if node == nil {
+		return nil, errors.New("no node is running the 'query' service")
+	}
+
+	return node.client.ExecuteCommand(value.CommandQuery(fmt.Sprintf("localhost:%d", value.QueryPort), c.credentials,
+		statement))
+}
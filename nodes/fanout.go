@@ -0,0 +1,76 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"sync"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/pkg/errors"
+)
+
+// RunOnAll concurrently executes the given command on all the nodes in the cluster, returning the output/error from
+// each node. Unlike 'forEachNode', a failure on one node doesn't prevent the command from being run on the others.
+func (c *Cluster) RunOnAll(command value.Command) value.HostOutputs {
+	return c.runOnEach(func(_ *Node) value.Command { return command })
+}
+
+// runOnEach is similar to 'RunOnAll' but allows the command to be tailored to each node e.g. to use its local address.
+func (c *Cluster) runOnEach(command func(node *Node) value.Command) value.HostOutputs {
+	var (
+		outputs = make(value.HostOutputs, len(c.nodes))
+		wg      sync.WaitGroup
+	)
+
+	for idx, node := range c.nodes {
+		wg.Add(1)
+
+		go func(idx int, node *Node) {
+			defer wg.Done()
+
+			output, err := node.client.ExecuteCommand(command(node))
+
+			outputs[idx] = &value.HostOutput{Host: node.blueprint.Host, Output: output, Err: err}
+		}(idx, node)
+	}
+
+	wg.Wait()
+
+	return outputs
+}
+
+// queueStats returns the disk/replication queue stats for the benchmarking bucket on each node in the cluster.
+func (c *Cluster) queueStats() ([]map[string]uint64, error) {
+	outputs := c.runOnEach(func(node *Node) value.Command {
+		return value.NewCommand(`cbstats %s %s -b default all`, node.localKV(), c.credentials.Args())
+	})
+
+	err := outputs.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get stats")
+	}
+
+	stats := make([]map[string]uint64, len(outputs))
+
+	for idx, output := range outputs {
+		stats[idx], err = value.ParseQueueStats(output.Output)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse stats for node '%s'", output.Host)
+		}
+	}
+
+	return stats, nil
+}
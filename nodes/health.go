@@ -0,0 +1,191 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// HealthPollInterval is how often the health of the nodes is checked whilst a benchmarked tool is running.
+const HealthPollInterval = 10 * time.Second
+
+// EnvironmentError is returned when the health of the cluster degrades during a run, the benchmarks are aborted since
+// any results would be misleading.
+type EnvironmentError struct {
+	Events value.HealthEvents
+}
+
+// Error implements the 'error' interface.
+func (e *EnvironmentError) Error() string {
+	return fmt.Sprintf("cluster health degraded, %d event(s) detected, the first being '%s' on '%s'",
+		len(e.Events), e.Events[0].Text, e.Events[0].Node)
+}
+
+// startHealthMonitor checkpoints the cluster logs so that only events which occur after this point are considered by
+// 'checkHealth'; any existing health issues will cause an error to be returned.
+func (c *Cluster) startHealthMonitor() error {
+	log.WithField("hosts", c.hosts()).Info("Starting cluster health monitor")
+
+	c.healthCheckpoint = 0
+
+	// Historic events are ignored, they shouldn't cause the current run to fail
+	_, err := c.healthEvents()
+	if err != nil {
+		return errors.Wrap(err, "failed to checkpoint cluster logs")
+	}
+
+	return c.checkNodeHealth()
+}
+
+// checkHealth returns an 'EnvironmentError' if the health of the cluster has degraded since the last check e.g. a node
+// was failed over or memcached crashed/restarted.
+func (c *Cluster) checkHealth() error {
+	log.WithField("hosts", c.hosts()).Info("Checking cluster health")
+
+	events, err := c.healthEvents()
+	if err != nil {
+		return errors.Wrap(err, "failed to get health events")
+	}
+
+	if len(events) != 0 {
+		return &EnvironmentError{Events: events}
+	}
+
+	return c.checkNodeHealth()
+}
+
+// healthEvents returns the events indicating degraded health which have been logged by the cluster since the
+// checkpoint, the checkpoint is moved forward to the latest log entry.
+func (c *Cluster) healthEvents() (value.HealthEvents, error) {
+	// This should probably be done with 'cbrest' or by using an actual HTTP client but for now using curl will suffice
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/logs`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute curl command")
+	}
+
+	events, latest, err := value.ParseHealthEvents(output, c.healthCheckpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse logs")
+	}
+
+	c.healthCheckpoint = latest
+
+	return events, nil
+}
+
+// checkNodeHealth returns an 'EnvironmentError' if any of the nodes in the cluster are unhealthy or have been failed
+// over.
+func (c *Cluster) checkNodeHealth() error {
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/pools/default`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+	if err != nil {
+		return errors.Wrap(err, "failed to execute curl command")
+	}
+
+	events, err := value.ParseNodeHealth(output)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse node health")
+	}
+
+	if len(events) != 0 {
+		return &EnvironmentError{Events: events}
+	}
+
+	return nil
+}
+
+// healthWatch polls the health of the nodes in the background whilst a benchmarked tool is running, the tool is killed
+// (by cancelling its context) as soon as the health degrades rather than letting a misleadingly slow run complete.
+type healthWatch struct {
+	cluster *Cluster
+	cancel  context.CancelFunc
+	err     error
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// watchHealth begins polling the health of the nodes, returning a context which is cancelled if it degrades. Polling
+// is disabled (and nil is returned) when injecting faults, since they degrade the health intentionally; it's valid to
+// call 'stop' on a nil watch.
+func watchHealth(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+) (context.Context, *healthWatch) {
+	if config.Chaos != nil {
+		return ctx, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	w := &healthWatch{cluster: cluster, cancel: cancel, done: make(chan struct{})}
+
+	w.wg.Add(1)
+
+	go w.run()
+
+	return ctx, w
+}
+
+// run checks the health of the nodes every interval until stopped or the health degrades.
+//
+// NOTE: Failing to check the health (e.g. the REST API timing out under load) isn't considered to be degraded health.
+func (w *healthWatch) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(HealthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		var environment *EnvironmentError
+
+		err := w.cluster.checkNodeHealth()
+		if !errors.As(err, &environment) {
+			continue
+		}
+
+		log.WithError(err).WithField("hosts", w.cluster.hosts()).Error("Cluster health degraded, aborting benchmark")
+
+		w.err = err
+		w.cancel()
+
+		return
+	}
+}
+
+// stop stops polling, returning an 'EnvironmentError' if the health degraded whilst the tool was running.
+func (w *healthWatch) stop() error {
+	if w == nil {
+		return nil
+	}
+
+	close(w.done)
+	w.wg.Wait()
+	w.cancel()
+
+	return w.err
+}
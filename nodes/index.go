@@ -0,0 +1,99 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// createIndexes creates any GSI indexes from the data blueprint which don't already exist (e.g. when topping up the
+// dataset), then waits for them to be built.
+func (c *Cluster) createIndexes(ctx context.Context) error {
+	indexes := c.blueprint.Bucket.Data.Indexes
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	statuses, err := c.indexStatuses()
+	if err != nil {
+		return errors.Wrap(err, "failed to get index statuses")
+	}
+
+	for _, index := range indexes {
+		err := index.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid index")
+		}
+
+		if _, ok := statuses[index.Name]; ok {
+			continue
+		}
+
+		log.WithFields(log.Fields{"name": index.Name, "fields": index.Fields}).Info("Creating index")
+
+		_, err = c.nodes[0].client.ExecuteCommand(value.CommandCreateIndex(c.nodes[0].localREST(), c.credentials, index))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create index '%s'", index.Name)
+		}
+	}
+
+	_, err = c.waitForIndexes(ctx, time.Now())
+
+	return err
+}
+
+// dropIndexes drops the GSI indexes from the data blueprint, so that they're recreated/built by the restore.
+func (c *Cluster) dropIndexes() error {
+	for _, index := range c.blueprint.Bucket.Data.Indexes {
+		log.WithField("name", index.Name).Info("Dropping index")
+
+		_, err := c.nodes[0].client.ExecuteCommand(value.CommandDropIndex(c.nodes[0].localREST(), c.credentials, index))
+		if err != nil {
+			return errors.Wrapf(err, "failed to drop index '%s'", index.Name)
+		}
+	}
+
+	return nil
+}
+
+// waitForIndexes waits for all the GSI indexes from the data blueprint to be ready, returning how long it took since
+// the provided time.
+func (c *Cluster) waitForIndexes(ctx context.Context, since time.Time) (time.Duration, error) {
+	return waitUntilOperational(ctx, "GSI indexes", since, func() ([]string, error) {
+		statuses, err := c.indexStatuses()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get index statuses")
+		}
+
+		return value.UnreadyIndexes(c.blueprint.Bucket.Data.Indexes, statuses), nil
+	})
+}
+
+// indexStatuses returns the status of each GSI index on the benchmarking bucket by name.
+func (c *Cluster) indexStatuses() (map[string]string, error) {
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/indexStatus`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute curl command")
+	}
+
+	return value.ParseIndexStatus(output)
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// configureLDAP stands up an LDAP server on the first node, then configures the cluster to authenticate the external
+// user against it; the external user is then authenticated to validate the configuration.
+//
+// NOTE: The LDAP port must be reachable from every node in the cluster, this isn't checked alongside the Couchbase
+// Server ports.
+func (c *Cluster) configureLDAP(ctx context.Context) error {
+	if c.blueprint.LDAP == nil {
+		return nil
+	}
+
+	var (
+		node   = c.nodes[0]
+		ldap   = c.blueprint.LDAP
+		fields = log.Fields{"host": node.blueprint.Host, "user": ldap.Credentials().Username}
+	)
+
+	log.WithFields(fields).Info("Configuring LDAP authentication")
+
+	err := node.client.InstallPackages(ctx, value.DockerPackage(node.client.Capabilities.PackageManager))
+	if err != nil {
+		return errors.Wrap(err, "failed to install docker")
+	}
+
+	_, err = node.client.ExecuteCommand(node.client.Capabilities.CommandEnableService("docker"))
+	if err != nil {
+		return errors.Wrap(err, "failed to start docker")
+	}
+
+	_, err = node.client.ExecuteCommand(ldap.CommandStartServer())
+	if err != nil {
+		return errors.Wrap(err, "failed to start LDAP server")
+	}
+
+	_, err = node.client.ExecuteCommand(ldap.CommandAddUser())
+	if err != nil {
+		return errors.Wrap(err, "failed to add user to LDAP server")
+	}
+
+	_, err = node.client.ExecuteCommand(ldap.CommandConfigureCluster(node.localREST(), c.credentials,
+		node.blueprint.Name()))
+	if err != nil {
+		return errors.Wrap(err, "failed to configure cluster LDAP settings")
+	}
+
+	_, err = node.client.ExecuteCommand(ldap.CommandAddExternalUser(node.localREST(), c.credentials))
+	if err != nil {
+		return errors.Wrap(err, "failed to add external user")
+	}
+
+	return c.validateLDAP()
+}
+
+// validateLDAP returns an error unless the external user authenticates against the cluster as an external user.
+func (c *Cluster) validateLDAP() error {
+	node := c.nodes[0]
+
+	output, err := node.client.ExecuteCommand(c.blueprint.LDAP.CommandWhoAmI(node.localREST()))
+	if err != nil {
+		return errors.Wrap(err, "failed to authenticate as external user")
+	}
+
+	whoAmI, err := value.ParseWhoAmI(output)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse authenticated user")
+	}
+
+	if whoAmI.Domain != "external" {
+		return fmt.Errorf("user '%s' authenticated using the '%s' domain rather than 'external'", whoAmI.ID,
+			whoAmI.Domain)
+	}
+
+	log.WithField("user", whoAmI.ID).Info("Successfully authenticated as external user")
+
+	return nil
+}
+
+// removeLDAP removes the LDAP server from the first node, this is best effort since docker may not be installed.
+func (c *Cluster) removeLDAP() {
+	_, err := c.nodes[0].client.ExecuteCommand(value.NewCommand("docker rm -f %s", value.LDAPContainer))
+	if err != nil {
+		log.WithError(err).Warn("Failed to remove LDAP server")
+	}
+}
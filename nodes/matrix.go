@@ -0,0 +1,105 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkMatrix runs the backup benchmark for each combination of the threads/sizes/items from the matrix config,
+// the provisioned cluster is reused throughout and the dataset is only reloaded when the document size/count changes.
+//
+// NOTE: The cluster is left containing the dataset from the last combination, it may be reloaded using 'load'.
+func (b *BackupClient) BenchmarkMatrix(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (value.MatrixResults, error) {
+	original := cluster.blueprint.Bucket.Data
+
+	err := config.Matrix.Validate(original)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid matrix config")
+	}
+
+	var (
+		datasets = config.Matrix.Datasets(original)
+		threads  = config.Matrix.ThreadsOrDefault(config.CBMConfig.Threads)
+		results  = make(value.MatrixResults, 0, len(datasets)*len(threads))
+		loaded   = original
+	)
+
+	// The remainder of the run (e.g. the report) should reflect the dataset from the blueprint
+	defer func() {
+		cluster.blueprint.Bucket.Data = original
+
+		if loaded.Items != original.Items || loaded.Size != original.Size {
+			log.WithFields(log.Fields{"items": loaded.Items, "size": loaded.Size}).
+				Warn("The cluster contains the dataset from the last matrix combination, use 'load' to reload it")
+		}
+	}()
+
+	fields := log.Fields{"threads": threads, "sizes": config.Matrix.Sizes, "items": config.Matrix.Items}
+	log.WithFields(fields).Info("Beginning 'cbbackupmgr' matrix benchmark")
+
+	for _, dataset := range datasets {
+		fields := log.Fields{"items": dataset.Items, "size": dataset.Size}
+
+		cluster.blueprint.Bucket.Data = dataset
+
+		if dataset.Items != loaded.Items || dataset.Size != loaded.Size {
+			log.WithFields(fields).Info("Loading matrix dataset")
+
+			err = cluster.LoadData(ctx, cluster.blueprint.Bucket.Compact, value.LoadMode{})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to load %d items of %d bytes", dataset.Items, dataset.Size)
+			}
+		}
+
+		loaded = dataset
+
+		for _, threads := range threads {
+			var (
+				cbm   = *config.CBMConfig
+				sweep = *config
+			)
+
+			cbm.Threads, sweep.CBMConfig = threads, &cbm
+
+			benchmarkResults, err := b.BenchmarkBackup(ctx, &sweep, cluster)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to benchmark %d items of %d bytes using %d threads",
+					dataset.Items, dataset.Size, threads)
+			}
+
+			results = append(results, &value.MatrixResult{
+				Items:  dataset.Items,
+				Size:   dataset.Size,
+				Backup: value.NewThreadsResult(threads, benchmarkResults),
+			})
+
+			// If the context has been cancelled, don't benchmark any more combinations; the user wants to gracefully
+			// terminate
+			if ctx.Err() != nil {
+				return results, nil
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// toolMonitor periodically samples the memory usage and number of open file descriptors/threads of 'cbbackupmgr' on
+// the backup client in the background.
+type toolMonitor struct {
+	client    *BackupClient
+	memory    []value.MemorySample
+	resources []value.ResourceSample
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// startToolMonitor begins sampling 'cbbackupmgr' at the sampling interval (or the default if sampling isn't enabled),
+// the tool is always monitored since memory/descriptor leaks matter as much as throughput.
+func startToolMonitor(config *value.SamplingConfig, client *BackupClient) *toolMonitor {
+	m := &toolMonitor{client: client, done: make(chan struct{})}
+
+	m.wg.Add(1)
+
+	go m.run(config.IntervalOrDefault())
+
+	return m
+}
+
+// run takes a sample every interval until the monitor is stopped.
+func (m *toolMonitor) run(interval time.Duration) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		// Monitoring is best effort, a missed sample shouldn't cause the benchmark to fail
+		memory, err := m.client.toolMemory()
+		if err == nil {
+			m.memory = append(m.memory, memory)
+		} else {
+			log.WithError(err).Warn("Failed to take memory sample")
+		}
+
+		resources, err := m.client.toolResources()
+		if err == nil {
+			m.resources = append(m.resources, resources)
+		} else {
+			log.WithError(err).Warn("Failed to take resource sample")
+		}
+	}
+}
+
+// stop stops monitoring and returns the peak/average memory and resource usage, nil is returned if 'cbbackupmgr'
+// wasn't running when any of the samples were taken (e.g. the backup/restore completed within a single interval).
+func (m *toolMonitor) stop() (*value.MemoryUsage, *value.ResourceUsage) {
+	close(m.done)
+	m.wg.Wait()
+
+	return value.NewMemoryUsage(m.memory), value.NewResourceUsage(m.resources)
+}
+
+// toolMemory returns the total memory usage of the running 'cbbackupmgr' processes on the backup client.
+func (b *BackupClient) toolMemory() (value.MemorySample, error) {
+	output, err := b.node.client.ExecuteCommand(value.CommandToolMemory())
+	if err != nil {
+		return value.MemorySample{}, errors.Wrap(err, "failed to get memory usage")
+	}
+
+	sample, err := value.ParseToolMemory(output)
+	if err != nil {
+		return value.MemorySample{}, errors.Wrap(err, "failed to parse memory usage")
+	}
+
+	return sample, nil
+}
+
+// toolResources returns the total number of open file descriptors/threads of the running 'cbbackupmgr' processes on
+// the backup client.
+func (b *BackupClient) toolResources() (value.ResourceSample, error) {
+	output, err := b.node.client.ExecuteCommand(value.CommandToolResources())
+	if err != nil {
+		return value.ResourceSample{}, errors.Wrap(err, "failed to get resource usage")
+	}
+
+	sample, err := value.ParseToolResources(output)
+	if err != nil {
+		return value.ResourceSample{}, errors.Wrap(err, "failed to parse resource usage")
+	}
+
+	return sample, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkMTLS runs the backup/restore benchmarks authenticating using the cluster credentials, then again using a
+// generated client certificate (over TLS), so that the overhead of using mTLS may be measured.
+//
+// NOTE: This enables client certificate authentication on the cluster and replaces the certificates of each node with
+// ones signed by a generated CA, password authentication remains enabled.
+func (b *BackupClient) BenchmarkMTLS(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.MTLSResults, error) {
+	if cluster.nodes[0].blueprint.CustomPorts() {
+		return nil, errors.New("client certificate authentication requires the cluster to use the default ports")
+	}
+
+	log.Info("Beginning 'cbbackupmgr' mTLS benchmark")
+
+	bundle, err := value.NewCertBundle(cluster.Credentials().Username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate certificates")
+	}
+
+	err = cluster.enableCertAuth(bundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to enable client certificate authentication")
+	}
+
+	clientCert, err := b.installClientCert(bundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to install client certificate")
+	}
+
+	identity, err := b.whoAmI(clientCert, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate client certificate authentication")
+	}
+
+	results := &value.MTLSResults{Identity: identity}
+
+	for _, auth := range []string{value.AuthPassword, value.AuthCertificate} {
+		var (
+			cbm  = *config.CBMConfig
+			mtls = *config
+		)
+
+		cbm.ClientCert, mtls.CBMConfig = nil, &cbm
+
+		if auth == value.AuthCertificate {
+			cbm.ClientCert = clientCert
+		}
+
+		backup, err := b.BenchmarkBackup(ctx, &mtls, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to benchmark backup using %s authentication", auth)
+		}
+
+		results.Results = append(results.Results, value.NewMTLSResult("backup", auth, backup))
+
+		// If the context has been cancelled, don't benchmark anything else; the user wants to gracefully terminate
+		if ctx.Err() != nil {
+			break
+		}
+
+		restore, err := b.BenchmarkRestore(ctx, &mtls, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to benchmark restore using %s authentication", auth)
+		}
+
+		results.Results = append(results.Results, value.NewMTLSResult("restore", auth, restore))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// installClientCert uploads the client certificate/key and CA certificate from the given bundle to the backup client,
+// returning the config which 'cbbackupmgr' should use to authenticate with them.
+func (b *BackupClient) installClientCert(bundle *value.CertBundle) (*value.ClientCertConfig, error) {
+	paths, err := b.node.uploadCerts("client", map[string][]byte{
+		"client.pem": bundle.ClientCert,
+		"client.key": bundle.ClientKey,
+		"ca.pem":     bundle.CA,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &value.ClientCertConfig{Cert: paths["client.pem"], Key: paths["client.key"], CACert: paths["ca.pem"]}, nil
+}
+
+// whoAmI connects to the first node in the cluster (over TLS) from the backup client using the given client
+// certificate, returning an error unless it authenticates as the cluster administrator.
+//
+// NOTE: This validates the mTLS path end-to-end, without it a misconfiguration would only surface as a failed backup.
+func (b *BackupClient) whoAmI(config *value.ClientCertConfig, cluster *Cluster) (string, error) {
+	host := net.JoinHostPort(cluster.nodes[0].blueprint.Host, strconv.Itoa(value.DefaultTLSRESTPort))
+
+	output, err := b.node.client.ExecuteCommand(config.CommandWhoAmI(host))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to authenticate using client certificate")
+	}
+
+	whoAmI, err := value.ParseWhoAmI(output)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse authenticated user")
+	}
+
+	if whoAmI.ID != cluster.Credentials().Username {
+		return "", fmt.Errorf("client certificate authenticated as '%s' rather than '%s'", whoAmI.ID,
+			cluster.Credentials().Username)
+	}
+
+	log.WithField("user", whoAmI.ID).Info("Successfully authenticated using client certificate")
+
+	return whoAmI.ID, nil
+}
+
+// enableCertAuth installs a certificate signed by the CA in the given bundle on each node, then enables client
+// certificate authentication on the cluster.
+func (c *Cluster) enableCertAuth(bundle *value.CertBundle) error {
+	err := c.forEachNode(func(node *Node) error { return node.installNodeCert(bundle) })
+	if err != nil {
+		return err
+	}
+
+	_, err = c.nodes[0].client.ExecuteCommand(value.CommandEnableClientCertAuth(c.nodes[0].localREST(),
+		c.credentials))
+
+	return err
+}
+
+// installNodeCert generates a certificate for this node signed by the CA in the given bundle, then uploads and loads
+// it, replacing the existing node certificate.
+func (n *Node) installNodeCert(bundle *value.CertBundle) error {
+	log.WithField("host", n.blueprint.Host).Info("Installing node certificate")
+
+	cert, key, err := bundle.NodeCert(n.blueprint.Host, n.blueprint.Name(), "localhost", "127.0.0.1")
+	if err != nil {
+		return errors.Wrap(err, "failed to generate node certificate")
+	}
+
+	paths, err := n.uploadCerts("node", map[string][]byte{"chain.pem": cert, "pkey.key": key, "ca.pem": bundle.CA})
+	if err != nil {
+		return err
+	}
+
+	_, err = n.client.ExecuteCommand(value.CommandInstallNodeCert(n.pkg.InstallDirectory(), paths["chain.pem"],
+		paths["pkey.key"], paths["ca.pem"]))
+	if err != nil {
+		return errors.Wrap(err, "failed to install node certificate")
+	}
+
+	_, err = n.client.ExecuteCommand(value.CommandLoadNodeCert(n.localREST(), n.credentials))
+	if err != nil {
+		return errors.Wrap(err, "failed to load node certificate")
+	}
+
+	return nil
+}
+
+// uploadCerts writes the given certificates/keys into the run directory then uploads them into the given directory
+// within the temporary directory on the remote machine, returning their remote paths.
+func (n *Node) uploadCerts(name string, files map[string][]byte) (map[string]string, error) {
+	var (
+		local  = filepath.Join(n.run.LocalDirectory(), "certs", n.blueprint.Host, name)
+		remote = value.RemoteJoin(n.tempDirectory(), "certs", name)
+		paths  = make(map[string]string, len(files))
+	)
+
+	err := os.MkdirAll(local, 0o700)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create local certificate directory")
+	}
+
+	err = n.client.CreateDirectory(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create remote certificate directory")
+	}
+
+	for file, data := range files {
+		err = os.WriteFile(filepath.Join(local, file), data, 0o600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to write '%s'", file)
+		}
+
+		paths[file] = value.RemoteJoin(remote, file)
+
+		err = n.client.SecureUpload(context.Background(), filepath.Join(local, file), paths[file])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to upload '%s'", file)
+		}
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/tools-common/sync/hofp"
+	"github.com/couchbase/tools-common/utils/maths"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkMultiRestore creates a backup of the benchmarking bucket, then restores it into multiple buckets
+// concurrently (using a 'cbbackupmgr' process per bucket). Each iteration first restores a single bucket on its own to
+// provide a baseline for the contention between the concurrent restores.
+//
+// NOTE: The memory quota of the benchmarking bucket is shared with the restored buckets for the duration of the
+// benchmark, the restored buckets are deleted (and the quota returned) once complete.
+func (b *BackupClient) BenchmarkMultiRestore(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (value.MultiRestoreResults, error) {
+	log.WithField("iterations", config.Iterations).Info("Beginning 'cbbackupmgr' concurrent restore benchmark(s)")
+
+	err := config.MultiRestore.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid multi-restore config")
+	}
+
+	// The backup is restored into each of the buckets using '--map-data', which only maps the benchmarking bucket
+	if len(cluster.blueprint.Buckets) != 0 {
+		return nil, errors.New("the multi-restore benchmark doesn't support additional buckets")
+	}
+
+	defer b.enableCoreDumps()()
+
+	err = cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	err = cluster.waitUntilSettled(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for cluster to settle")
+	}
+
+	err = b.purgeArchive(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to purge archive")
+	}
+
+	err = b.createRepository(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create repository")
+	}
+
+	backupInfo, err := b.createBackup(ctx, config, cluster, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create backup")
+	}
+
+	names := config.MultiRestore.BucketNames()
+
+	if !config.CBMConfig.Blackhole {
+		cleanup, err := cluster.createRestoreBuckets(names)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create restore buckets")
+		}
+		defer cleanup()
+	}
+
+	results := make(value.MultiRestoreResults, 0, config.Iterations)
+
+	for iteration := 0; iteration < maths.Max(1, config.Iterations); iteration++ {
+		log.WithField("iteration", iteration+1).Info("Beginning 'cbbackupmgr' concurrent restore benchmark")
+
+		result, err := b.benchmarkMultiRestore(ctx, config, cluster, names, backupInfo.BackupSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to run benchmark")
+		}
+
+		// Abort if the cluster health degraded during the benchmark, the result would be misleading
+		err = cluster.checkHealth()
+		if err != nil {
+			return nil, errors.Wrap(err, "cluster health check failed")
+		}
+
+		results = append(results, result)
+
+		// If the context has been cancelled, don't run any more benchmarks; the user wants to gracefully terminate
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// benchmarkMultiRestore runs an individual baseline restore, followed by the concurrent restores into the given
+// buckets.
+func (b *BackupClient) benchmarkMultiRestore(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+	names []string, ads uint64,
+) (*value.MultiRestoreResult, error) {
+	result := &value.MultiRestoreResult{}
+
+	err := b.prepareMultiRestore(config, cluster, names[:1])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare for baseline restore")
+	}
+
+	err = b.measureCPU(&result.BaselineCPU, func() error {
+		result.Baseline, err = b.restoreInto(ctx, config, cluster, names[0], ads)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run baseline restore")
+	}
+
+	err = b.prepareMultiRestore(config, cluster, names)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare for concurrent restores")
+	}
+
+	result.Buckets = make([]*value.BenchmarkResult, len(names))
+
+	start := time.Now()
+
+	err = b.measureCPU(&result.CPU, func() error {
+		pool := hofp.NewPool(hofp.Options{Size: len(names)})
+
+		for idx := range names {
+			idx := idx
+
+			err := pool.Queue(func(_ context.Context) error {
+				var err error
+
+				result.Buckets[idx], err = b.restoreInto(ctx, config, cluster, names[idx], ads)
+
+				return errors.Wrapf(err, "failed to restore into bucket '%s'", names[idx])
+			})
+			if err != nil {
+				break
+			}
+		}
+
+		return pool.Stop()
+	})
+
+	result.Duration = time.Since(start)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run concurrent restores")
+	}
+
+	return result, nil
+}
+
+// prepareMultiRestore empties the given buckets (unless restoring to blackhole) and runs the pre-benchmark tasks.
+func (b *BackupClient) prepareMultiRestore(config *value.BenchmarkConfig, cluster *Cluster, names []string) error {
+	if !config.CBMConfig.Blackhole {
+		err := cluster.flushBuckets(names...)
+		if err != nil {
+			return errors.Wrap(err, "failed to flush buckets")
+		}
+	}
+
+	err := cluster.runPreBenchmarkTasks()
+	if err != nil {
+		return errors.Wrap(err, "failed to run cluster pre-benchmark tasks")
+	}
+
+	err = b.runPreBenchmarkTasks()
+	if err != nil {
+		return errors.Wrap(err, "failed to run client pre-benchmark tasks")
+	}
+
+	return nil
+}
+
+// restoreInto restores the backup in the repository into the bucket with the given name, returning its timings.
+func (b *BackupClient) restoreInto(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster, name string,
+	ads uint64,
+) (*value.BenchmarkResult, error) {
+	fields := log.Fields{
+		"blackhole": config.CBMConfig.Blackhole,
+		"bucket":    name,
+		"hosts":     cluster.hosts(),
+	}
+
+	log.WithFields(fields).Info("Restoring backup")
+
+	result := &value.BenchmarkResult{Start: time.Now(), ADS: ads}
+
+	_, err := b.runTool(ctx,
+		b.cbm(config).CommandRestoreInto(cluster.ConnectionString(), cluster.ToolCredentials(), name))
+	if err != nil {
+		return nil, err
+	}
+
+	result.Duration = time.Since(result.Start)
+
+	return result, nil
+}
+
+// measureCPU runs the given function, storing the CPU usage of the backup client whilst it was running in 'usage'.
+// Failing to measure the CPU usage isn't fatal, it's reported as zero.
+func (b *BackupClient) measureCPU(usage *float64, fn func() error) error {
+	start, cpuErr := b.cpuTimes()
+
+	err := fn()
+	if err != nil {
+		return err
+	}
+
+	var end value.CPUTimes
+	if cpuErr == nil {
+		end, cpuErr = b.cpuTimes()
+	}
+
+	if cpuErr != nil {
+		log.WithError(cpuErr).Warn("Failed to measure backup client CPU usage")
+		return nil
+	}
+
+	*usage = value.CPUUsage(start, end)
+
+	return nil
+}
+
+// cpuTimes returns the current CPU times for the backup client.
+func (b *BackupClient) cpuTimes() (value.CPUTimes, error) {
+	output, err := b.node.client.ExecuteCommand(value.CommandCPUTimes())
+	if err != nil {
+		return value.CPUTimes{}, err
+	}
+
+	return value.ParseCPUTimes(output)
+}
+
+// createRestoreBuckets shares the memory quota of the benchmarking bucket equally between it and the buckets with the
+// given names, which are created. The returned function deletes the created buckets and returns the quota.
+func (c *Cluster) createRestoreBuckets(names []string) (func(), error) {
+	quota := fmt.Sprintf("$(((%s) / %d))", c.blueprint.QuotaExpression(), len(names)+1)
+
+	err := c.resizeBucket("default", quota)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resize benchmarking bucket")
+	}
+
+	created := make([]string, 0, len(names))
+
+	cleanup := func() {
+		for _, name := range created {
+			err := c.deleteBucket(name)
+			if err != nil {
+				log.WithError(err).WithField("name", name).Warn("Failed to delete restore bucket")
+			}
+		}
+
+		err := c.resizeBucket("default", fmt.Sprintf("$((%s))", c.blueprint.QuotaExpression()))
+		if err != nil {
+			log.WithError(err).Warn("Failed to return benchmarking bucket quota")
+		}
+	}
+
+	for _, name := range names {
+		err = c.createNamedBucket(name, quota)
+		if err != nil {
+			cleanup()
+			return nil, errors.Wrapf(err, "failed to create bucket '%s'", name)
+		}
+
+		created = append(created, name)
+	}
+
+	return cleanup, nil
+}
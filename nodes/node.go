@@ -15,11 +15,13 @@
 package nodes
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
+	"net"
 	"strings"
 	"time"
 
+	"github.com/jamesl33/cbtools-autobench/inventory"
 	"github.com/jamesl33/cbtools-autobench/ssh"
 	"github.com/jamesl33/cbtools-autobench/value"
 
@@ -30,38 +32,126 @@ import (
 // Node represents a connection to a remote Couchbase Server node (note that the node may or may not be setup yet).
 type Node struct {
 	blueprint *value.NodeBlueprint
+	pkg       *value.Package
 	client    *ssh.Client
+	run       value.RunID
+
+	// image is the baked image the machine was launched from (if any).
+	image string
+
+	// reuse indicates that an existing install of the version being provisioned may be reused, rather than reinstalled.
+	reuse bool
+
+	// credentials are the credentials of the cluster administrator.
+	credentials *value.Credentials
+
+	// permissive indicates that SELinux/AppArmor have been made permissive, so custom paths don't need to be labelled.
+	permissive bool
 }
 
-// NewNode creates a connection to the remote node using the provided ssh config.
-func NewNode(config *value.SSHConfig, blueprint *value.NodeBlueprint) (*Node, error) {
-	client, err := ssh.NewClient(blueprint.Host, config)
+// NewNode creates a connection to the remote node using the provided ssh config, the given package describes where
+// Couchbase Server is/will be installed and the run id is used to namespace any files created on the remote node.
+func NewNode(config *value.SSHConfig, blueprint *value.NodeBlueprint, pkg *value.Package, run value.RunID,
+) (*Node, error) {
+	err := blueprint.ValidateRemoteTmpDir()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid remote temporary directory"))
+	}
+
+	err = blueprint.Services.Validate()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid services"))
+	}
+
+	client, err := ssh.NewClient(blueprint.Host, config, blueprint.Transport, blueprint.Platform)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create ssh client")
+		return nil, value.Categorize(value.ExitCodeProvision, errors.Wrap(err, "failed to create ssh client"))
 	}
 
-	return &Node{blueprint: blueprint, client: client}, nil
+	client.SetBinDirectory(pkg.BinDirectory())
+
+	return &Node{blueprint: blueprint, pkg: pkg, client: client, run: run}, nil
+}
+
+// tempDirectory returns the per-run temporary directory on the node, which contains all the uploads/scratch files.
+func (n *Node) tempDirectory() string {
+	return n.run.TempDirectory(n.blueprint.RemoteTmpDirOrDefault())
+}
+
+// processDirectory returns the directory on the node which contains the PID files of the long-running processes started
+// by this run.
+func (n *Node) processDirectory() string {
+	return n.run.ProcessDirectory(n.blueprint.RemoteTmpDirOrDefault())
+}
+
+// coreDirectory returns the directory on the node which core dumps are written to during this run.
+func (n *Node) coreDirectory() string {
+	return n.run.CoreDirectory(n.blueprint.RemoteTmpDirOrDefault())
 }
 
 // provision the node by installing the required dependencies (including Couchbase Server).
-func (n *Node) provision(packagePath string) error {
-	err := n.installDeps()
+func (n *Node) provision(ctx context.Context) error {
+	err := n.pkg.Validate()
 	if err != nil {
-		return errors.Wrap(err, "failed to install dependencies")
+		return errors.Wrap(err, "invalid package")
 	}
 
-	err = n.uninstallCB()
+	n.checkCapabilities()
+
+	baked, err := n.baked()
 	if err != nil {
-		return errors.Wrap(err, "failed to uninstall Couchbase Server")
+		return errors.Wrap(err, "failed to check whether the machine was baked")
 	}
 
-	err = n.installCB(packagePath)
+	installed, err := n.installed(baked)
 	if err != nil {
-		return errors.Wrap(err, "failed to install Couchbase Server")
+		return errors.Wrap(err, "failed to check whether Couchbase Server is installed")
 	}
 
-	// We've got to wait for things to complete, for example we need to actually wait for Couchbase Server to start
-	time.Sleep(30 * time.Second)
+	if !baked {
+		err = n.installDeps(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to install dependencies")
+		}
+	}
+
+	err = n.modifyVolumes()
+	if err != nil {
+		return errors.Wrap(err, "failed to modify EBS volumes")
+	}
+
+	err = n.prepareInstanceStore(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare instance store")
+	}
+
+	err = n.prepareTieredStorage(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare tiered storage")
+	}
+
+	switch {
+	case baked:
+		err = n.enableCB()
+	case installed:
+		err = n.wipeCB(ctx)
+	default:
+		err = n.reinstallCB(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = n.configurePorts()
+	if err != nil {
+		return errors.Wrap(err, "failed to configure ports")
+	}
+
+	err = n.waitUntilReady(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for Couchbase Server to become ready")
+	}
 
 	err = n.giveCBPermissions()
 	if err != nil {
@@ -71,27 +161,379 @@ func (n *Node) provision(packagePath string) error {
 	return nil
 }
 
+// bake installs the dependencies/package on the remote machine without configuring Couchbase Server so that an image
+// may be created from it, returning the id of the instance. The state generated when Couchbase Server was started is
+// removed, so that each machine launched from the image is initialized as a new node.
+func (n *Node) bake(ctx context.Context) (string, error) {
+	err := n.pkg.Validate()
+	if err != nil {
+		return "", errors.Wrap(err, "invalid package")
+	}
+
+	err = n.installDeps(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to install dependencies")
+	}
+
+	err = n.reinstallCB(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = n.disableCB()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to disable Couchbase Server")
+	}
+
+	log.WithField("host", n.blueprint.Host).Info("Removing generated Couchbase Server state")
+
+	_, err = n.client.ExecuteCommand(n.pkg.CommandResetState())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to remove generated state")
+	}
+
+	_, err = n.client.ExecuteCommand(n.pkg.CommandMarkBaked())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to mark machine as baked")
+	}
+
+	err = n.client.Sync()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sync")
+	}
+
+	instanceID, err := n.instanceID()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to determine instance id")
+	}
+
+	return instanceID, nil
+}
+
+// baked returns a boolean indicating whether the machine was launched from an image baked with the package being
+// provisioned, in which case the dependencies/package don't need to be installed.
+func (n *Node) baked() (bool, error) {
+	if n.image == "" {
+		return false, nil
+	}
+
+	output, err := n.client.ExecuteCommand(value.CommandBakedPackage())
+	if err != nil {
+		return false, err
+	}
+
+	fields := log.Fields{"host": n.blueprint.Host, "image": n.image, "package": n.pkg.Name()}
+
+	if baked := strings.TrimSpace(string(output)); baked != n.pkg.Name() {
+		log.WithFields(fields).WithField("baked", baked).
+			Warn("Machine wasn't baked with the package being provisioned, it will be installed")
+
+		return false, nil
+	}
+
+	log.WithFields(fields).Info("Machine was baked with the package being provisioned, skipping install")
+
+	return true, nil
+}
+
+// installed returns a boolean indicating whether the version of Couchbase Server being provisioned is already installed
+// on a (non-baked) machine which may be reused, in which case uninstalling/uploading/installing it is skipped.
+//
+// NOTE: Only the build is compared, the edition isn't recorded in the install directory.
+func (n *Node) installed(baked bool) (bool, error) {
+	if !n.reuse || baked {
+		return false, nil
+	}
+
+	output, err := n.client.ExecuteCommand(n.pkg.CommandInstalledVersion())
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		installed = strings.TrimSpace(string(output))
+		fields    = log.Fields{"host": n.blueprint.Host, "package": n.pkg.Name(), "installed": installed}
+	)
+
+	if installed != n.pkg.Version() {
+		log.WithFields(fields).Info("Installed version doesn't match the package being provisioned, it will be " +
+			"reinstalled")
+
+		return false, nil
+	}
+
+	log.WithFields(fields).Info("Package being provisioned is already installed, skipping reinstall")
+
+	return true, nil
+}
+
+// wipeCB stops Couchbase Server then wipes its generated state (and the data/index paths) before starting it again, so
+// that an existing install is initialized as a new node without having to reinstall it.
+func (n *Node) wipeCB(ctx context.Context) error {
+	err := n.disableCB()
+	if err != nil {
+		return errors.Wrap(err, "failed to stop Couchbase Server")
+	}
+
+	log.WithField("host", n.blueprint.Host).Info("Wiping generated Couchbase Server state")
+
+	_, err = n.client.ExecuteCommand(n.pkg.CommandWipeState())
+	if err != nil {
+		return errors.Wrap(err, "failed to wipe generated state")
+	}
+
+	// The data/index paths would otherwise still contain the bucket files from the previous cluster
+	for _, path := range []string{n.blueprint.DataPath, n.blueprint.IndexPath} {
+		if path == "" {
+			continue
+		}
+
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "path": path}).Info("Cleaning path")
+
+		_, err = n.client.ExecuteCommandContext(ctx, value.CommandCleanPath(path))
+		if err != nil {
+			return errors.Wrapf(err, "failed to clean '%s'", path)
+		}
+	}
+
+	return n.enableCB()
+}
+
+// reinstallCB uninstalls then installs Couchbase Server on the remote machine ensuring a clean slate.
+func (n *Node) reinstallCB(ctx context.Context) error {
+	err := n.uninstallCB(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to uninstall Couchbase Server")
+	}
+
+	err = n.installCB(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to install Couchbase Server")
+	}
+
+	return nil
+}
+
+// prepareInstanceStore formats/mounts the instance store device on the remote machine (if configured), this must be
+// done each time the node is provisioned since instance store devices are wiped when the machine is stopped.
+func (n *Node) prepareInstanceStore(ctx context.Context) error {
+	if n.blueprint.InstanceStore == nil {
+		return nil
+	}
+
+	fields := log.Fields{"host": n.blueprint.Host, "mount_point": n.blueprint.InstanceStore.MountPointOrDefault()}
+	log.WithFields(fields).Info("Preparing instance store")
+
+	err := n.client.InstallPackages(ctx, "xfsprogs")
+	if err != nil {
+		return errors.Wrap(err, "failed to install 'xfsprogs'")
+	}
+
+	_, err = n.client.ExecuteCommandContext(ctx, n.blueprint.InstanceStore.CommandPrepare())
+
+	return err
+}
+
+// prepareTieredStorage builds/formats/mounts the tiered device on the remote machine (if configured), this must be done
+// after preparing the instance store, since the cache device is usually an instance store device.
+func (n *Node) prepareTieredStorage(ctx context.Context) error {
+	tiered := n.blueprint.TieredStorage
+	if tiered == nil {
+		return nil
+	}
+
+	err := tiered.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid tiered storage config")
+	}
+
+	fields := log.Fields{
+		"host":        n.blueprint.Host,
+		"type":        tiered.TypeOrDefault(),
+		"cache_mode":  tiered.CacheModeOrDefault(),
+		"mount_point": tiered.MountPointOrDefault(),
+	}
+
+	log.WithFields(fields).Info("Preparing tiered storage")
+
+	err = n.client.InstallPackages(ctx, tiered.Packages()...)
+	if err != nil {
+		return errors.Wrap(err, "failed to install dependencies")
+	}
+
+	_, err = n.client.ExecuteCommandContext(ctx, tiered.CommandPrepare())
+
+	return err
+}
+
+// modifyVolumes modifies the EBS volumes attached to the remote machine (if configured) using the local 'aws' CLI, the
+// id of the instance is determined using the instance metadata service.
+func (n *Node) modifyVolumes() error {
+	if n.blueprint.EBS == nil {
+		return nil
+	}
+
+	err := n.blueprint.EBS.Volumes.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid EBS config")
+	}
+
+	instanceID, err := n.instanceID()
+	if err != nil {
+		return errors.Wrap(err, "failed to determine instance id")
+	}
+
+	return inventory.ModifyVolumes(n.blueprint.EBS, instanceID, n.dryRun())
+}
+
+// instanceID returns the id of the EC2 instance using the instance metadata service.
+func (n *Node) instanceID() (string, error) {
+	output, err := n.client.ExecuteCommand(value.CommandInstanceID())
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// dryRun returns a boolean indicating whether this is a dry run, in which case no changes should be made to any AWS
+// resources.
+func (n *Node) dryRun() bool {
+	return n.blueprint.Transport.TypeOrDefault() == value.TransportTypeDryRun
+}
+
+// burstableResources returns the burstable instance/volumes used by the remote machine, nothing is returned during a
+// dry run.
+func (n *Node) burstableResources(config *value.CreditsConfig) ([]*value.CreditResource, error) {
+	if n.dryRun() {
+		return nil, nil
+	}
+
+	instanceID, err := n.instanceID()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to determine instance id")
+	}
+
+	return inventory.BurstableResources(config, n.blueprint.Host, instanceID)
+}
+
+// cloudWatchMetrics returns the CloudWatch metrics for the remote machine between the start/end, nothing is returned
+// during a dry run.
+func (n *Node) cloudWatchMetrics(config *value.CloudWatchConfig, start, end time.Time,
+) (value.CloudWatchMetrics, error) {
+	if n.dryRun() {
+		return nil, nil
+	}
+
+	instanceID, err := n.instanceID()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to determine instance id")
+	}
+
+	return inventory.CloudWatchMetrics(config, n.blueprint.Host, instanceID, start, end)
+}
+
+// hostLoad returns the load on the remote machine along with any noisy neighbors, the given processes are expected to
+// be running on the machine.
+func (n *Node) hostLoad(expected []string) (*value.HostLoad, error) {
+	output, err := n.client.ExecuteCommand(value.CommandHostLoad())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get host load")
+	}
+
+	return value.ParseHostLoad(n.blueprint.Host, output, expected)
+}
+
+// checkCapabilities logs a warning for any detected capabilities which may prevent Couchbase Server from working once
+// it's provisioned, we don't modify the firewall since the changes wouldn't be reverted by 'restore-host'.
+func (n *Node) checkCapabilities() {
+	capabilities := n.client.Capabilities
+
+	if capabilities.Firewall != value.FirewallToolNone {
+		fields := log.Fields{
+			"host":     n.blueprint.Host,
+			"firewall": capabilities.Firewall,
+			"ports":    value.PeerPorts(n.blueprint),
+		}
+
+		log.WithFields(fields).Warn("Firewall is active, the Couchbase Server ports must be reachable from the other nodes")
+	}
+}
+
+// relaxSecurity sets SELinux to permissive mode and puts any AppArmor profiles mentioning Couchbase Server into
+// complain mode for the run, the original modes are reverted by 'restore-host'.
+func (n *Node) relaxSecurity() error {
+	fields := log.Fields{
+		"host":     n.blueprint.Host,
+		"selinux":  n.client.Capabilities.SELinux,
+		"apparmor": n.client.Capabilities.AppArmor,
+	}
+
+	log.WithFields(fields).Info("Setting SELinux/AppArmor to permissive mode")
+
+	_, err := n.client.ExecuteCommand(n.client.Capabilities.CommandPermissive())
+	if err != nil {
+		return err
+	}
+
+	n.permissive = true
+
+	return nil
+}
+
+// labelPath ensures that Couchbase Server will be allowed to access the given custom path when SELinux is enforcing,
+// by applying the context of the default data directory to it.
+func (n *Node) labelPath(path string) error {
+	if n.permissive {
+		return nil
+	}
+
+	fields := log.Fields{"host": n.blueprint.Host, "path": path}
+
+	if n.client.Capabilities.AppArmor {
+		log.WithFields(fields).Warn("AppArmor is enabled, Couchbase Server may be denied access to the path if it's " +
+			"confined by a profile (see 'permissive_security')")
+	}
+
+	if n.client.Capabilities.SELinux != value.SELinuxEnforcing {
+		return nil
+	}
+
+	log.WithFields(fields).Info("Applying SELinux context to path")
+
+	_, err := n.client.ExecuteCommand(value.CommandLabelPath(path,
+		value.RemoteJoin(n.pkg.InstallDirectory(), "var", "lib", "couchbase", "data")))
+
+	return err
+}
+
 // installDeps installs any required platform specific dependencies which are missing on the remote machine.
-func (n *Node) installDeps() error {
+func (n *Node) installDeps(ctx context.Context) error {
 	log.WithField("host", n.blueprint.Host).Info("Installing dependencies")
 
-	return n.client.InstallPackages(n.client.Platform.Dependencies()...)
+	return n.client.InstallPackages(ctx, n.client.Platform.Dependencies()...)
 }
 
 // uninstallCB will uninstall Couchbase Server from the remote node ensuring a clean slate.
-func (n *Node) uninstallCB() error {
+func (n *Node) uninstallCB(ctx context.Context) error {
 	log.WithField("host", n.blueprint.Host).Info("Uninstalling 'couchbase-server'")
 
-	err := n.client.UninstallPackages("couchbase-server")
+	var err error
+	if n.pkg.Type == value.PackageTypeTar {
+		_, err = n.client.ExecuteCommandContext(ctx, n.pkg.CommandStopTarball())
+	} else {
+		err = n.client.UninstallPackages(ctx, "couchbase-server")
+	}
+
 	if err != nil {
 		return errors.Wrap(err, "failed to uninstall 'couchbase-server'")
 	}
 
 	log.WithField("host", n.blueprint.Host).Info("Purging install directory")
 
-	err = n.client.RemoveDirectory(value.CBInstallDirectory)
+	err = n.client.RemoveDirectory(n.pkg.InstallDirectory())
 	if err != nil {
-		return errors.Wrapf(err, "failed to cleanup install directory at '%s'", value.CBInstallDirectory)
+		return errors.Wrapf(err, "failed to cleanup install directory at '%s'", n.pkg.InstallDirectory())
 	}
 
 	return nil
@@ -99,13 +541,26 @@ func (n *Node) uninstallCB() error {
 
 // installCB uploads the Couchbase Server install package to the remote machine and installs it.
 //
-// NOTE: The package archive will be removed upon completion.
-func (n *Node) installCB(localPath string) error {
-	remotePath := filepath.Join("/home/ec2-user", filepath.Base(localPath))
+// NOTE: The package archive will be removed upon completion, the upload is aborted if the context is cancelled.
+func (n *Node) installCB(ctx context.Context) error {
+	if n.pkg.Type != value.PackageTypeTar && string(n.pkg.Type) != n.client.Capabilities.PackageExtension() {
+		return fmt.Errorf("package type '%s' is not supported by package manager '%s'", n.pkg.Type,
+			n.client.Capabilities.PackageManager)
+	}
+
+	var (
+		directory  = n.tempDirectory()
+		remotePath = value.RemoteJoin(directory, value.LocalBase(n.pkg.Path))
+	)
+
+	err := n.client.CreateDirectory(directory)
+	if err != nil {
+		return errors.Wrap(err, "failed to create upload directory")
+	}
 
 	log.WithField("host", n.blueprint.Host).Info("Uploading package archive")
 
-	err := n.client.SecureUpload(localPath, remotePath)
+	err = n.client.SecureUpload(ctx, n.pkg.Path, remotePath)
 	switch {
 	case err != nil:
 		return errors.Wrap(err, "failed to upload package archive")
@@ -116,7 +571,12 @@ func (n *Node) installCB(localPath string) error {
 
 	log.WithField("host", n.blueprint.Host).Info("Installing 'couchbase-server'")
 
-	err = n.client.InstallPackageAt(remotePath)
+	if n.pkg.Type == value.PackageTypeTar {
+		_, err = n.client.ExecuteCommandContext(ctx, n.pkg.CommandInstallTarball(remotePath))
+	} else {
+		err = n.client.InstallPackageAt(ctx, remotePath)
+	}
+
 	if err != nil {
 		return errors.Wrap(err, "failed to install 'couchbase-server'")
 	}
@@ -131,6 +591,196 @@ func (n *Node) installCB(localPath string) error {
 	return nil
 }
 
+// upgradeCB upgrades Couchbase Server in-place to the given package, unlike 'provision' the data/config on the node is
+// retained.
+func (n *Node) upgradeCB(ctx context.Context, pkg *value.Package) error {
+	log.WithFields(log.Fields{"host": n.blueprint.Host, "package": pkg.Path}).Info("Upgrading 'couchbase-server'")
+
+	n.pkg = pkg
+
+	return n.installCB(ctx)
+}
+
+// extractPackage uploads the given package to the remote machine and extracts it into the given directory without
+// installing it, returning the directory containing the extracted binaries.
+//
+// NOTE: The package archive will be removed upon completion, the upload is aborted if the context is cancelled.
+func (n *Node) extractPackage(ctx context.Context, pkg *value.Package, directory string) (string, error) {
+	remotePath := value.RemoteJoin(n.tempDirectory(), value.LocalBase(pkg.Path))
+
+	err := n.client.CreateDirectory(n.tempDirectory())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create upload directory")
+	}
+
+	log.WithFields(log.Fields{"host": n.blueprint.Host, "package": pkg.Path}).Info("Extracting package archive")
+
+	err = n.client.SecureUpload(ctx, pkg.Path, remotePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to upload package archive")
+	}
+
+	_, err = n.client.ExecuteCommandContext(ctx, pkg.CommandExtract(remotePath, directory))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to extract package archive")
+	}
+
+	err = n.client.RemoveFile(remotePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to remove package archive")
+	}
+
+	return value.RemoteJoin(directory, "opt", "couchbase", "bin"), nil
+}
+
+// configurePorts will configure Couchbase Server to use the non-default ports from the blueprint (if any), this must be
+// done prior to node initialization.
+func (n *Node) configurePorts() error {
+	if !n.blueprint.CustomPorts() {
+		return nil
+	}
+
+	fields := log.Fields{
+		"host":      n.blueprint.Host,
+		"rest_port": n.blueprint.RESTPortOrDefault(),
+		"kv_port":   n.blueprint.KVPortOrDefault(),
+	}
+
+	log.WithFields(fields).Info("Configuring non-default ports")
+
+	err := n.stopCB()
+	if err != nil {
+		return errors.Wrap(err, "failed to stop Couchbase Server")
+	}
+
+	// The ports are read from the static config, however, the node will have already been started once so the generated
+	// config is also removed otherwise the ports will be ignored.
+	_, err = n.client.ExecuteCommand(n.pkg.CommandConfigurePorts(n.blueprint.RESTPortOrDefault(),
+		n.blueprint.KVPortOrDefault()))
+	if err != nil {
+		return errors.Wrap(err, "failed to update static config")
+	}
+
+	err = n.startCB()
+	if err != nil {
+		return errors.Wrap(err, "failed to start Couchbase Server")
+	}
+
+	return nil
+}
+
+// startCB will (re)start Couchbase Server on the remote node.
+func (n *Node) startCB() error {
+	command := n.client.Capabilities.CommandRestartService("couchbase-server")
+	if n.pkg.Type == value.PackageTypeTar {
+		command = n.pkg.CommandStartTarball()
+	}
+
+	_, err := n.client.ExecuteCommand(command)
+
+	return err
+}
+
+// stopCB will stop Couchbase Server on the remote node.
+func (n *Node) stopCB() error {
+	command := n.client.Capabilities.CommandStopService("couchbase-server")
+	if n.pkg.Type == value.PackageTypeTar {
+		command = n.pkg.CommandStopTarball()
+	}
+
+	_, err := n.client.ExecuteCommand(command)
+
+	return err
+}
+
+// address returns the address that other machines should use to reach this node, this is either the host (when it's an
+// IP address) or the first address assigned to the machine.
+func (n *Node) address() (string, error) {
+	if net.ParseIP(n.blueprint.Host) != nil {
+		return n.blueprint.Host, nil
+	}
+
+	output, err := n.client.ExecuteCommand(value.NewCommand("hostname -I | awk '{ print $1 }'"))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to determine address")
+	}
+
+	address := strings.TrimSpace(string(output))
+	if address == "" {
+		return "", fmt.Errorf("no address assigned to '%s'", n.blueprint.Host)
+	}
+
+	return address, nil
+}
+
+// reachable returns a boolean indicating whether the given port on the remote host is reachable from this node.
+func (n *Node) reachable(host string, port uint16) bool {
+	_, err := n.client.ExecuteCommand(value.NewCommand(`timeout 5 bash -c '</dev/tcp/%s/%d' 2>/dev/null`, host, port))
+	return err == nil
+}
+
+// waitUntilReady polls the REST API and data service port with an exponential backoff until Couchbase Server is ready
+// to be initialized, the configured timeout is reached or the context is cancelled.
+func (n *Node) waitUntilReady(ctx context.Context) error {
+	readiness := n.blueprint.Readiness
+
+	err := readiness.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid readiness config")
+	}
+
+	var (
+		start    = time.Now()
+		deadline = start.Add(readiness.TimeoutOrDefault())
+		backoff  = readiness.BackoffOrDefault()
+		command  = value.CommandReady(n.blueprint.RESTPortOrDefault(), n.blueprint.KVPortOrDefault())
+	)
+
+	for attempt := 1; ; attempt++ {
+		_, err = n.client.ExecuteCommand(command)
+		if err == nil {
+			break
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for Couchbase Server to become ready", readiness.TimeoutOrDefault())
+		}
+
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "attempt": attempt, "backoff": backoff.String()}).
+			Debug("Couchbase Server not ready yet, retrying")
+
+		if !sleepContext(ctx, backoff) {
+			return ctx.Err()
+		}
+
+		backoff = readiness.Next(backoff)
+	}
+
+	fields := log.Fields{"host": n.blueprint.Host, "elapsed": time.Since(start).Round(time.Millisecond).String()}
+	log.WithFields(fields).Info("Couchbase Server is ready")
+
+	return nil
+}
+
+// updateHosts replaces any entries previously added to '/etc/hosts' by 'cbtools-autobench' with the given entries.
+func (n *Node) updateHosts(entries value.HostsEntries) error {
+	log.WithField("host", n.blueprint.Host).Info("Updating '/etc/hosts'")
+
+	_, err := n.client.ExecuteCommand(entries.CommandUpdate())
+
+	return err
+}
+
+// localREST returns the address of the REST API when connecting from the node itself.
+func (n *Node) localREST() string {
+	return fmt.Sprintf("localhost:%d", n.blueprint.RESTPortOrDefault())
+}
+
+// localKV returns the address of the data service when connecting from the node itself.
+func (n *Node) localKV() string {
+	return fmt.Sprintf("localhost:%d", n.blueprint.KVPortOrDefault())
+}
+
 // createDataPath ensures that the users chosen data path exists on the remote machine.
 func (n *Node) createDataPath() error {
 	if n.blueprint.DataPath == "" {
@@ -149,6 +799,11 @@ func (n *Node) createDataPath() error {
 		return errors.Wrap(err, "failed to chown remote data directory")
 	}
 
+	err = n.labelPath(n.blueprint.DataPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to label remote data directory")
+	}
+
 	return nil
 }
 
@@ -169,20 +824,27 @@ func (n *Node) createIndexPath() error {
 		return errors.Wrap(err, "failed to chown remote index directory")
 	}
 
+	err = n.labelPath(n.blueprint.IndexPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to label remote index directory")
+	}
+
 	return nil
 }
 
-// initializeCB will perform node level initialization of Couchbase Server.
-func (n *Node) initializeCB() error {
+// initializeCB will perform node level initialization of Couchbase Server, optionally configuring the node to use IPv6.
+func (n *Node) initializeCB(ipv6 bool) error {
 	fields := log.Fields{
 		"host":       n.blueprint.Host,
+		"hostname":   n.blueprint.Hostname,
+		"ipv6":       ipv6,
 		"data_path":  n.blueprint.DataPath,
 		"index_path": n.blueprint.IndexPath,
 	}
 
 	log.WithFields(fields).Info("Initializing node")
 
-	init := "couchbase-cli node-init -c localhost:8091 -u Administrator -p asdasd"
+	init := fmt.Sprintf("couchbase-cli node-init -c %s %s", n.localREST(), n.credentials.Args())
 	if n.blueprint.DataPath != "" {
 		init += fmt.Sprintf(" --node-init-data-path %s", n.blueprint.DataPath)
 	}
@@ -191,56 +853,48 @@ func (n *Node) initializeCB() error {
 		init += fmt.Sprintf(" --node-init-index-path %s", n.blueprint.IndexPath)
 	}
 
-	_, err := n.client.ExecuteCommand(value.NewCommand(init))
-
-	return err
-}
+	if n.blueprint.Hostname != "" {
+		init += fmt.Sprintf(" --node-init-hostname %s", n.blueprint.Hostname)
+	}
 
-// disableCB will disable Couchbase Server on the remote node, this will done on the backup client to free up resources
-// for 'cbbackupmgr'.
-func (n *Node) disableCB() error {
-	log.WithField("host", n.blueprint.Host).Info("Disabling 'couchbase-server'")
+	if ipv6 {
+		init += " --ipv6"
+	}
 
-	_, err := n.client.ExecuteCommand(n.client.Platform.CommandDisableCouchbase())
+	_, err := n.client.ExecuteCommand(value.NewCommand(init))
 
 	return err
 }
 
-// loginAsRoot will attempt to login as the root user on the remote machine.
-func (n *Node) loginAsRoot() error {
-	// Become root
-	_, err := n.client.ExecuteCommand("sudo -s")
-	if err != nil {
-		log.Fatalf("failed to become root: %v", err)
+// enableCB will enable/start Couchbase Server on the remote node, this is required for machines launched from a baked
+// image since it's disabled before the image is created.
+func (n *Node) enableCB() error {
+	log.WithField("host", n.blueprint.Host).Info("Enabling 'couchbase-server'")
+
+	command := n.client.Capabilities.CommandEnableService("couchbase-server")
+	if n.pkg.Type == value.PackageTypeTar {
+		command = n.pkg.CommandStartTarball()
 	}
 
-	// Read the authorized_keys file content
-	output, err := n.client.ExecuteCommand("sudo cat /root/.ssh/authorized_keys")
+	_, err := n.client.ExecuteCommand(command)
 	if err != nil {
-		log.Fatalf("failed to read authorized_keys: %v", err)
+		return errors.Wrap(err, "failed to enable Couchbase Server")
 	}
 
-	// Find the position of the first occurrence of 'ssh-rsa'
-	index := strings.Index(string(output), "ssh-rsa")
-	if index == -1 {
-		log.Fatalf("ssh-rsa not found in authorized_keys")
-	}
+	return nil
+}
 
-	// Trim the content to keep only the portion starting from 'ssh-rsa'
-	newContent := output[index:]
+// disableCB will disable Couchbase Server on the remote node, this will done on the backup client to free up resources
+// for 'cbbackupmgr'.
+func (n *Node) disableCB() error {
+	log.WithField("host", n.blueprint.Host).Info("Disabling 'couchbase-server'")
 
-	// Write the new content back to authorized_keys
-	msg := fmt.Sprintf("echo '%s' | sudo tee /root/.ssh/authorized_keys", newContent)
-	_, err = n.client.ExecuteCommand(value.Command(msg))
-	if err != nil {
-		log.Fatalf("failed to write to authorized_keys: %v", err)
+	command := n.client.Capabilities.CommandDisableService("couchbase-server")
+	if n.pkg.Type == value.PackageTypeTar {
+		command = n.pkg.CommandStopTarball()
 	}
 
-	// Restart the SSH service
-	_, err = n.client.ExecuteCommand("sudo systemctl restart sshd.service || sudo systemctl restart ssh.service")
-	if err != nil {
-		log.Fatalf("failed to restart SSH service: %v", err)
-	}
+	_, err := n.client.ExecuteCommand(command)
 
 	return err
 }
@@ -314,7 +968,7 @@ func (n *Node) checkAndPartitionEBS() error {
 // permissions to access the data path.
 func (n *Node) giveCBPermissions() error {
 	// Run cmhod +X on EBS volume /mnt
-	changePermissions := "sudo chown -R couchbase:couchbase /mnt"
+	changePermissions := "chown -R couchbase:couchbase /mnt"
 	_, err := n.client.ExecuteCommand(value.NewCommand(changePermissions))
 	if err != nil {
 		return fmt.Errorf("failed to change permissions on /mnt: %w", err)
@@ -341,7 +995,46 @@ func ExtractLastVolumeName(lsblkOutput string) (string, error) {
 	return lastVolumeName, nil
 }
 
-// Close releases any resources in use by the connection.
+// hardware returns a description of the hardware of the remote machine, the disk type is determined using the data
+// path (when provided).
+func (n *Node) hardware() (*value.Hardware, error) {
+	path := n.blueprint.DataPath
+	if path == "" {
+		path = n.pkg.InstallDirectory()
+	}
+
+	output, err := n.client.ExecuteCommand(value.CommandHardware(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get hardware info")
+	}
+
+	return value.ParseHardware(n.blueprint.Host, output)
+}
+
+// garbageCollect removes any temporary directories left behind by previous runs which are older than the given
+// duration.
+func (n *Node) garbageCollect(olderThan time.Duration) error {
+	log.WithField("host", n.blueprint.Host).Info("Removing leftover temporary directories")
+
+	_, err := n.client.ExecuteCommand(value.CommandGarbageCollect(n.blueprint.RemoteTmpDirOrDefault(), olderThan))
+
+	return err
+}
+
+// restoreState reverts the changes made to the remote machine, using the state snapshotted prior to modifying it.
+func (n *Node) restoreState() error {
+	log.WithField("host", n.blueprint.Host).Info("Restoring original machine state")
+
+	return n.client.RestoreState()
+}
+
+// Close removes the temporary directory for this run and releases any resources in use by the connection.
 func (n *Node) Close() error {
+	// This is best effort, failing to cleanup shouldn't cause the run to fail and any leftovers may be removed using 'gc'
+	err := n.client.RemoveDirectory(n.tempDirectory())
+	if err != nil {
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "error": err}).Warn("Failed to remove temporary directory")
+	}
+
 	return n.client.Close()
 }
@@ -0,0 +1,87 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// persistBarrier waits for the disk write queue on each node to be drained then optionally compacts the bucket, this
+// is done before the backup timer starts so that the backup measures steady-state reads rather than racing with
+// persistence.
+func (c *Cluster) persistBarrier(ctx context.Context, compact bool) error {
+	log.WithField("compact", compact).Info("Waiting for mutations to be persisted")
+
+	start := time.Now()
+
+	// Check straight away, the write queue has usually been drained by the time the benchmark is started
+	ok, err := c.persisted()
+	if err != nil {
+		return errors.Wrap(err, "failed to check whether mutations have been persisted")
+	}
+
+	if !ok {
+		timeout, err := poll(ctx, c.persisted, value.SettleTimeout)
+		if err != nil {
+			return errors.Wrap(err, "failed to poll until mutations were persisted")
+		}
+
+		if timeout {
+			return fmt.Errorf("disk write queue wasn't drained within %s", value.SettleTimeout)
+		}
+	}
+
+	log.WithField("wait", time.Since(start)).Info("All mutations have been persisted")
+
+	if !compact {
+		return nil
+	}
+
+	err = c.compactBucket(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to compact bucket")
+	}
+
+	return nil
+}
+
+// persisted returns a boolean indicating whether the disk write queue on every node in the cluster has been drained.
+func (c *Cluster) persisted() (bool, error) {
+	stats, err := c.queueStats()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get queue stats")
+	}
+
+	for idx, node := range c.nodes {
+		for _, name := range value.PersistStats {
+			if stats[idx][name] == 0 {
+				continue
+			}
+
+			log.WithFields(log.Fields{"host": node.blueprint.Host, name: stats[idx][name]}).Info("Mutations not persisted")
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	fsutil "github.com/couchbase/tools-common/fs/util"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// processes are the long-running remote processes started by this run which are still running, they're recorded in
+// the run directory so that they may be cleaned up using 'kill-run' if 'cbtools-autobench' crashes.
+var processes = struct {
+	lock      sync.Mutex
+	processes value.TrackedProcesses
+}{}
+
+// executeTracked runs the given long-running command on the remote machine, its PID is recorded on the remote machine
+// (and the process in the run directory) whilst it's running.
+//
+// NOTE: If the context is cancelled, the process (and its descendants) are killed using the PID file since closing the
+// session doesn't reliably terminate the remote command.
+func (n *Node) executeTracked(ctx context.Context, command value.Command) ([]byte, error) {
+	pidFile := n.run.PIDFile(n.blueprint.RemoteTmpDirOrDefault(), command)
+
+	n.track(command.Name(), pidFile)
+	defer n.untrack(pidFile)
+
+	output, err := n.client.ExecuteCommandContext(ctx, value.WithTracking(command, pidFile))
+	if ctx.Err() == nil {
+		return output, err
+	}
+
+	_, kerr := n.client.ExecuteCommand(value.CommandKillProcess(pidFile))
+	if kerr != nil {
+		log.WithError(kerr).WithFields(log.Fields{"host": n.blueprint.Host, "name": command.Name()}).
+			Warn("Failed to kill cancelled process")
+	}
+
+	return output, err
+}
+
+// track records that a process with the given PID file has been started on the remote machine.
+func (n *Node) track(name, pidFile string) {
+	processes.lock.Lock()
+	defer processes.lock.Unlock()
+
+	processes.processes = append(processes.processes, &value.TrackedProcess{
+		Host:    n.blueprint.Host,
+		Name:    name,
+		PIDFile: pidFile,
+		Started: time.Now(),
+	})
+
+	writeProcesses(n.run)
+}
+
+// untrack records that the process with the given PID file is no longer running on the remote machine.
+func (n *Node) untrack(pidFile string) {
+	processes.lock.Lock()
+	defer processes.lock.Unlock()
+
+	running := processes.processes[:0]
+
+	for _, process := range processes.processes {
+		if process.Host != n.blueprint.Host || process.PIDFile != pidFile {
+			running = append(running, process)
+		}
+	}
+
+	processes.processes = running
+
+	writeProcesses(n.run)
+}
+
+// writeProcesses writes the running processes into the run directory, the caller must hold the lock.
+//
+// NOTE: Failing to write the processes isn't fatal, 'kill-run' connects to every host in the config regardless.
+func writeProcesses(run value.RunID) {
+	err := writeJSON(run.ProcessesPath(), processes.processes)
+	if err != nil {
+		log.WithError(err).Warn("Failed to record running processes")
+	}
+}
+
+// writeJSON writes the given value as JSON to the given path, creating any missing directories.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal")
+	}
+
+	err = fsutil.Mkdir(filepath.Dir(path), 0, true, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	return errors.Wrap(os.WriteFile(path, data, 0o644), "failed to write file")
+}
+
+// ReadProcesses returns the remote processes which were running when the given run last recorded them, an empty list is
+// returned if the run never started any.
+func ReadProcesses(run value.RunID) (value.TrackedProcesses, error) {
+	data, err := os.ReadFile(run.ProcessesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read processes")
+	}
+
+	var running value.TrackedProcesses
+
+	err = json.Unmarshal(data, &running)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode processes")
+	}
+
+	return running, nil
+}
+
+// KillRun kills the long-running processes started by the given run on each of the nodes in the cluster.
+func (c *Cluster) KillRun(target value.RunID) error {
+	return c.forEachNode(func(node *Node) error { return node.killRun(target) })
+}
+
+// KillRun kills the long-running processes started by the given run on the backup client, then releases the backup
+// client lock if it's held by the run.
+func (b *BackupClient) KillRun(target value.RunID) error {
+	err := b.node.killRun(target)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.node.client.ExecuteCommand(value.CommandReleaseClientLock(target))
+	if err != nil {
+		return errors.Wrap(err, "failed to release backup client lock")
+	}
+
+	return nil
+}
+
+// killRun kills each process (and its descendants) with a PID file in the process directory of the given run.
+func (n *Node) killRun(target value.RunID) error {
+	directory := target.ProcessDirectory(n.blueprint.RemoteTmpDirOrDefault())
+
+	output, err := n.client.ExecuteCommand(value.CommandKillProcesses(directory))
+	if err != nil {
+		return errors.Wrap(err, "failed to kill processes")
+	}
+
+	log.WithFields(log.Fields{"host": n.blueprint.Host, "run": target, "killed": string(bytes.TrimSpace(output))}).
+		Info("Killed remote processes")
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// runQueries runs each of the given N1QL queries, returning the number of results returned by each query by name.
+func (c *Cluster) runQueries(queries []*value.QueryBlueprint) (map[string]uint64, error) {
+	node := c.serviceNode(value.ServiceQuery)
+	if node == nil {
+		return nil, errors.New("queries require a node running the 'query' service")
+	}
+
+	counts := make(map[string]uint64, len(queries))
+
+	for _, query := range queries {
+		err := query.Validate()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid query")
+		}
+
+		output, err := node.client.ExecuteCommand(value.CommandQuery(fmt.Sprintf("localhost:%d", value.QueryPort),
+			c.credentials, query.Statement))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to run query '%s'", query.Name)
+		}
+
+		counts[query.Name], err = value.ParseQueryResultCount(output)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse results of query '%s'", query.Name)
+		}
+	}
+
+	return counts, nil
+}
+
+// validateQueries runs each of the given N1QL queries comparing the number of results against the expected counts
+// captured before the backup was created.
+//
+// NOTE: Mismatches don't fail the benchmark, they're logged and included in the report.
+func (c *Cluster) validateQueries(queries []*value.QueryBlueprint, expected map[string]uint64,
+) (value.QueryChecks, error) {
+	log.WithField("queries", len(queries)).Info("Validating restored data using queries")
+
+	actual, err := c.runQueries(queries)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make(value.QueryChecks, 0, len(queries))
+
+	for _, query := range queries {
+		check := &value.QueryCheck{Name: query.Name, Expected: expected[query.Name], Actual: actual[query.Name]}
+
+		if !check.Passed() {
+			fields := log.Fields{"name": check.Name, "expected": check.Expected, "actual": check.Actual}
+			log.WithFields(fields).Warn("Query returned an unexpected number of results after restore")
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
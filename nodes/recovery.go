@@ -0,0 +1,175 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// recoverableServices returns the FTS indexes/eventing functions which currently exist on the cluster, an empty set is
+// returned if the cluster isn't running the search/eventing services.
+func (c *Cluster) recoverableServices() (*value.RecoverableServices, error) {
+	services := &value.RecoverableServices{}
+
+	if node := c.serviceNode(value.ServiceSearch); node != nil {
+		output, err := node.client.ExecuteCommand(value.NewCommand(
+			`curl -s -g -u %s http://localhost:%d/api/index`, c.credentials.UserInfo(), value.SearchPort))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to execute curl command")
+		}
+
+		services.SearchIndexes, err = value.ParseSearchIndexes(output)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse FTS indexes")
+		}
+	}
+
+	if node := c.serviceNode(value.ServiceEventing); node != nil {
+		statuses, err := c.eventingStatuses(node)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get eventing function statuses")
+		}
+
+		services.EventingFunctions = value.DeployedEventingFunctions(statuses)
+	}
+
+	if len(services.SearchIndexes) != 0 || len(services.EventingFunctions) != 0 {
+		fields := log.Fields{"fts_indexes": services.SearchIndexes, "eventing_functions": services.EventingFunctions}
+		log.WithFields(fields).Info("Found services which will be timed after each restore")
+	}
+
+	return services, nil
+}
+
+// waitForSearch waits for the given FTS indexes to exist and have indexed all the restored mutations, returning how
+// long it took since the provided time.
+func (c *Cluster) waitForSearch(ctx context.Context, indexes []string, since time.Time) (time.Duration, error) {
+	node := c.serviceNode(value.ServiceSearch)
+
+	return waitUntilOperational(ctx, "FTS indexes", since, func() ([]string, error) {
+		output, err := node.client.ExecuteCommand(value.NewCommand(
+			`curl -s -g -u %s http://localhost:%d/api/nsstats`, c.credentials.UserInfo(), value.SearchPort))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to execute curl command")
+		}
+
+		return value.UnreadySearchIndexes(indexes, output)
+	})
+}
+
+// waitForEventing waits for the given eventing functions to be deployed, returning how long it took since the provided
+// time.
+func (c *Cluster) waitForEventing(ctx context.Context, functions []string, since time.Time) (time.Duration, error) {
+	node := c.serviceNode(value.ServiceEventing)
+
+	return waitUntilOperational(ctx, "eventing functions", since, func() ([]string, error) {
+		statuses, err := c.eventingStatuses(node)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get eventing function statuses")
+		}
+
+		return value.UndeployedEventingFunctions(functions, statuses), nil
+	})
+}
+
+// eventingStatuses returns the composite status of each eventing function by name.
+func (c *Cluster) eventingStatuses(node *Node) (map[string]string, error) {
+	output, err := node.client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://localhost:%d/api/v1/status`, c.credentials.UserInfo(), value.EventingPort))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute curl command")
+	}
+
+	return value.ParseEventingStatus(output)
+}
+
+// waitUntilOperational polls the given function until it returns that nothing is pending, returning how long it took
+// since the provided time.
+func waitUntilOperational(ctx context.Context, what string, since time.Time, fn func() ([]string, error),
+) (time.Duration, error) {
+	log.Infof("Waiting for %s to become operational", what)
+
+	var pending []string
+
+	ready := func() (bool, error) {
+		var err error
+
+		pending, err = fn()
+
+		return len(pending) == 0, err
+	}
+
+	ok, err := ready()
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		timeout, err := pollEvery(ctx, ready, time.Second, value.IndexTimeout)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to poll until %s were operational", what)
+		}
+
+		if timeout {
+			return 0, fmt.Errorf("%s weren't operational within %s: %s", what, value.IndexTimeout,
+				strings.Join(pending, ", "))
+		}
+	}
+
+	took := time.Since(since)
+
+	log.WithField("took", took).Infof("The %s are operational", what)
+
+	return took, nil
+}
+
+// timeRecovery waits for the GSI indexes, FTS indexes and eventing functions to become operational after a restore
+// which completed at the provided time, recording how long each took in the given result.
+func (c *Cluster) timeRecovery(ctx context.Context, result *value.BenchmarkResult, services *value.RecoverableServices,
+	since time.Time,
+) error {
+	var err error
+
+	if len(c.blueprint.Bucket.Data.Indexes) != 0 {
+		result.IndexBuild, err = c.waitForIndexes(ctx, since)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for GSI indexes to be built")
+		}
+	}
+
+	if len(services.SearchIndexes) != 0 {
+		result.SearchBuild, err = c.waitForSearch(ctx, services.SearchIndexes, since)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for FTS indexes to be built")
+		}
+	}
+
+	if len(services.EventingFunctions) != 0 {
+		result.EventingDeploy, err = c.waitForEventing(ctx, services.EventingFunctions, since)
+		if err != nil {
+			return errors.Wrap(err, "failed to wait for eventing functions to be deployed")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// sampler periodically measures the progress of a backup/restore in the background.
+type sampler struct {
+	throughput *value.Throughput
+	measure    func() (uint64, error)
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// startSampler begins sampling using the provided function at the configured interval, nil is returned if sampling
+// isn't enabled; it's valid to call 'stop' on a nil sampler.
+func startSampler(config *value.SamplingConfig, unit value.SampleUnit, measure func() (uint64, error)) *sampler {
+	if config == nil {
+		return nil
+	}
+
+	s := &sampler{
+		throughput: &value.Throughput{Unit: unit},
+		measure:    measure,
+		done:       make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+
+	go s.run(config.IntervalOrDefault())
+
+	return s
+}
+
+// run takes a sample every interval until the sampler is stopped.
+func (s *sampler) run(interval time.Duration) {
+	defer s.wg.Done()
+
+	var (
+		start  = time.Now()
+		ticker = time.NewTicker(interval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		measured, err := s.measure()
+		if err != nil {
+			// Sampling is best effort, a missed sample shouldn't cause the benchmark to fail
+			log.WithError(err).Warn("Failed to take throughput sample")
+			continue
+		}
+
+		s.throughput.Samples = append(s.throughput.Samples, value.Sample{Elapsed: time.Since(start), Value: measured})
+	}
+}
+
+// stop stops sampling and returns the samples taken, nil is returned if sampling wasn't enabled.
+func (s *sampler) stop() *value.Throughput {
+	if s == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.wg.Wait()
+
+	return s.throughput
+}
+
+// archiveSize returns the size in bytes of the archive on the backup client.
+func (b *BackupClient) archiveSize(config *value.CBMConfig) (uint64, error) {
+	output, err := b.node.client.ExecuteCommand(config.CommandArchiveSize())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get archive size")
+	}
+
+	size, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse archive size")
+	}
+
+	return size, nil
+}
+
+// archiveSizeOrZero returns the size of the archive on the backup client, zero is returned when backing up to blackhole
+// or if the size couldn't be determined since it's only informational.
+func (b *BackupClient) archiveSizeOrZero(config *value.CBMConfig) uint64 {
+	if config.Blackhole {
+		return 0
+	}
+
+	size, err := b.archiveSize(config)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get archive size")
+	}
+
+	return size
+}
+
+// itemCount returns the number of items in the benchmarking bucket (and any additional buckets) on the cluster.
+func (c *Cluster) itemCount() (uint64, error) {
+	var items uint64
+
+	for _, name := range c.blueprint.BucketNames() {
+		stats, err := c.bucketStats(name)
+		if err != nil {
+			return 0, err
+		}
+
+		items += stats.ItemCount
+	}
+
+	return items, nil
+}
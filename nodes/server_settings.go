@@ -0,0 +1,149 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// ApplyServerSettings applies the server-side settings from the blueprint, returning the value of each setting before
+// and after it was applied. Nil is returned when there are no settings to apply.
+func (c *Cluster) ApplyServerSettings() (value.ServerSettings, error) {
+	blueprint := c.blueprint.ServerSettings
+	if blueprint == nil {
+		return nil, nil
+	}
+
+	err := blueprint.Validate()
+	if err != nil {
+		return nil, value.Categorize(value.ExitCodeConfig, errors.Wrap(err, "invalid server settings"))
+	}
+
+	log.WithField("host", c.nodes[0].blueprint.Host).Info("Applying server settings")
+
+	before, err := c.serverSettings(blueprint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to capture server settings before applying them")
+	}
+
+	err = c.applyServerSettings(blueprint)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := c.serverSettings(blueprint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to capture server settings after applying them")
+	}
+
+	for idx, setting := range before {
+		setting.After = after[idx].Before
+	}
+
+	return before, nil
+}
+
+// applyServerSettings applies each of the settings in the given blueprint.
+func (c *Cluster) applyServerSettings(blueprint *value.ServerSettingsBlueprint) error {
+	var (
+		node = c.nodes[0]
+		host = node.localREST()
+	)
+
+	if blueprint.CompressionMode != "" {
+		_, err := node.client.ExecuteCommand(value.NewCommand(`couchbase-cli bucket-edit -c %s %s --bucket default \
+			--compression-mode %s`, host, c.credentials.Args(), blueprint.CompressionMode))
+		if err != nil {
+			return errors.Wrap(err, "failed to set compression mode")
+		}
+	}
+
+	if len(blueprint.Memcached) != 0 {
+		_, err := node.client.ExecuteCommand(blueprint.CommandSetMemcachedSettings(host, c.credentials))
+		if err != nil {
+			return errors.Wrap(err, "failed to apply memcached settings")
+		}
+	}
+
+	for _, component := range blueprint.LogComponents() {
+		_, err := node.client.ExecuteCommand(value.CommandSetLogLevel(host, c.credentials, component,
+			blueprint.LogLevels[component]))
+		if err != nil {
+			return errors.Wrapf(err, "failed to set log level for '%s'", component)
+		}
+	}
+
+	return nil
+}
+
+// serverSettings returns the current value of each of the settings in the given blueprint, the values are stored as
+// the 'Before' value of each setting.
+func (c *Cluster) serverSettings(blueprint *value.ServerSettingsBlueprint) (value.ServerSettings, error) {
+	var (
+		node     = c.nodes[0]
+		host     = node.localREST()
+		settings = make(value.ServerSettings, 0)
+	)
+
+	if blueprint.CompressionMode != "" {
+		output, err := node.client.ExecuteCommand(value.NewCommand(
+			`curl -s -g -u %s http://%s/pools/default/buckets/default`, c.credentials.UserInfo(), host))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to execute curl command")
+		}
+
+		mode, err := value.ParseCompressionMode(output)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse compression mode")
+		}
+
+		settings = append(settings, &value.ServerSetting{Name: "compression_mode", Before: mode})
+	}
+
+	if len(blueprint.Memcached) != 0 {
+		output, err := node.client.ExecuteCommand(value.CommandGetMemcachedSettings(host, c.credentials))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to execute curl command")
+		}
+
+		current, err := value.ParseMemcachedSettings(output)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse memcached settings")
+		}
+
+		for _, name := range blueprint.MemcachedNames() {
+			settings = append(settings, &value.ServerSetting{Name: "memcached." + name, Before: current[name]})
+		}
+	}
+
+	for _, component := range blueprint.LogComponents() {
+		output, err := node.client.ExecuteCommand(value.CommandGetLogLevel(host, c.credentials, component))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get log level for '%s'", component)
+		}
+
+		settings = append(settings, &value.ServerSetting{
+			Name:   "log_level." + component,
+			Before: strings.TrimSpace(string(output)),
+		})
+	}
+
+	return settings, nil
+}
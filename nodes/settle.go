@@ -0,0 +1,149 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// waitUntilSettled waits for the cluster to settle before a benchmark is timed i.e. for any rebalance to complete, the
+// vBuckets to be evenly distributed between the nodes and the disk/replication queues to be drained. The time spent
+// waiting is recorded, so that it may be reported separately from the results.
+func (c *Cluster) waitUntilSettled(ctx context.Context) error {
+	log.WithField("hosts", c.hosts()).Info("Waiting for cluster to settle")
+
+	var (
+		start        = time.Now()
+		reason       string
+		distribution value.VBucketDistribution
+	)
+
+	settled := func() (bool, error) {
+		var err error
+
+		reason, distribution, err = c.settleState()
+
+		return reason == "", err
+	}
+
+	// Check straight away, the cluster has usually settled long before the benchmark is started
+	ok, err := settled()
+	if err != nil {
+		return errors.Wrap(err, "failed to check whether the cluster has settled")
+	}
+
+	if !ok {
+		log.WithField("reason", reason).Info("Cluster hasn't settled yet, polling until it has")
+
+		timeout, err := poll(ctx, settled, value.SettleTimeout)
+		if err != nil {
+			return errors.Wrap(err, "failed to poll until the cluster settled")
+		}
+
+		if timeout {
+			return fmt.Errorf("cluster didn't settle within %s: %s", value.SettleTimeout, reason)
+		}
+	}
+
+	c.settle = &value.SettleResult{Wait: time.Since(start), Distribution: distribution}
+
+	log.WithField("wait", c.settle.Wait).Info("Cluster has settled")
+
+	return nil
+}
+
+// settleState returns the reason the cluster hasn't settled (an empty string when it has) and the vBucket distribution.
+func (c *Cluster) settleState() (string, value.VBucketDistribution, error) {
+	rebalancing, err := c.rebalancing()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get rebalance status")
+	}
+
+	if rebalancing {
+		return "rebalance is running", nil, nil
+	}
+
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/pools/default/buckets/default`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to execute curl command")
+	}
+
+	distribution, err := value.ParseVBucketDistribution(output)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to parse vBucket distribution")
+	}
+
+	if !distribution.Balanced() {
+		return "vBuckets aren't evenly distributed", distribution, nil
+	}
+
+	stats, err := c.queueStats()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get queue stats")
+	}
+
+	for idx, node := range c.nodes {
+		if undrained := value.UndrainedQueues(stats[idx]); len(undrained) != 0 {
+			return fmt.Sprintf("queues on node '%s' aren't drained (%s)", node.blueprint.Host,
+				strings.Join(undrained, ", ")), distribution, nil
+		}
+	}
+
+	return "", distribution, nil
+}
+
+// rebalancing returns a boolean indicating whether a rebalance is running on the cluster.
+func (c *Cluster) rebalancing() (bool, error) {
+	output, err := c.nodes[0].client.ExecuteCommand(value.NewCommand(
+		`curl -s -g -u %s http://%s/pools/default/tasks`, c.credentials.UserInfo(), c.nodes[0].localREST()))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute curl command")
+	}
+
+	type overlay struct {
+		Type   string `json:"type"`
+		Status string `json:"status"`
+	}
+
+	var decoded []overlay
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	for _, task := range decoded {
+		if task.Type == "rebalance" && task.Status == "running" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Settle returns how long we waited for the cluster to settle before the benchmark, nil is returned if we didn't wait.
+func (c *Cluster) Settle() *value.SettleResult {
+	return c.settle
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// emptyBucket empties the bucket prior to the given restore iteration. When snapshots are enabled, the bucket is only
+// flushed before the first iteration, it's then snapshotted and later iterations roll back to the empty bucket.
+func (c *Cluster) emptyBucket(ctx context.Context, iteration int) error {
+	if c.blueprint.Snapshot != nil && iteration != 0 {
+		return c.rollbackSnapshot(ctx)
+	}
+
+	err := c.flushBucket()
+	if err != nil {
+		return errors.Wrap(err, "failed to flush bucket")
+	}
+
+	if c.blueprint.Snapshot == nil {
+		return nil
+	}
+
+	return c.createSnapshot(ctx)
+}
+
+// resetData returns the dataset to the state it was in before the first iteration, this is a no-op when snapshots
+// aren't enabled.
+func (c *Cluster) resetData(ctx context.Context, iteration int) error {
+	if c.blueprint.Snapshot == nil {
+		return nil
+	}
+
+	if iteration == 0 {
+		return c.createSnapshot(ctx)
+	}
+
+	return c.rollbackSnapshot(ctx)
+}
+
+// createSnapshot snapshots the data path of every node in the cluster, Couchbase Server is stopped whilst the snapshot
+// is taken so that the data files are consistent.
+func (c *Cluster) createSnapshot(ctx context.Context) error {
+	log.WithFields(log.Fields{"hosts": c.hosts(), "filesystem": c.blueprint.Snapshot.Filesystem}).
+		Info("Snapshotting data path")
+
+	err := c.whilstStopped(func(node *Node) value.Command {
+		return c.blueprint.Snapshot.CommandSnapshot(node.blueprint.DataPath)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to snapshot nodes")
+	}
+
+	c.snapshotted = true
+
+	return c.restarted(ctx)
+}
+
+// rollbackSnapshot rolls back the data path of every node in the cluster to the snapshot taken by 'createSnapshot'.
+func (c *Cluster) rollbackSnapshot(ctx context.Context) error {
+	log.WithFields(log.Fields{"hosts": c.hosts(), "filesystem": c.blueprint.Snapshot.Filesystem}).
+		Info("Rolling back data path")
+
+	err := c.whilstStopped(func(node *Node) value.Command {
+		return c.blueprint.Snapshot.CommandRollback(node.blueprint.DataPath)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to rollback nodes")
+	}
+
+	return c.restarted(ctx)
+}
+
+// destroySnapshot removes the snapshots taken by 'createSnapshot', this is best effort since a leftover snapshot
+// doesn't impact the benchmark results and will be replaced by the next run.
+func (c *Cluster) destroySnapshot() {
+	if !c.snapshotted {
+		return
+	}
+
+	log.WithField("hosts", c.hosts()).Info("Removing data path snapshots")
+
+	err := c.forEachNode(func(node *Node) error {
+		_, err := node.client.ExecuteCommand(c.blueprint.Snapshot.CommandDestroy(node.blueprint.DataPath))
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to remove data path snapshots")
+	}
+
+	c.snapshotted = false
+}
+
+// restarted waits for the cluster to become healthy after the nodes have been restarted to snapshot/rollback the data
+// path.
+func (c *Cluster) restarted(ctx context.Context) error {
+	err := c.waitUntilHealthy(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to wait for the cluster to become healthy")
+	}
+
+	// Restarting the nodes would otherwise be detected as degraded health
+	return c.startHealthMonitor()
+}
+
+// whilstStopped stops Couchbase Server on every node, runs the snapshot command returned for each node then starts
+// Couchbase Server on every node again.
+//
+// NOTE: Each step completes on every node before the next begins, otherwise a node which has been rolled back could
+// rejoin whilst its peers are still running with newer vBucket state.
+func (c *Cluster) whilstStopped(command func(node *Node) value.Command) error {
+	err := c.forEachNode(func(node *Node) error { return node.stopCB() })
+	if err != nil {
+		return errors.Wrap(err, "failed to stop Couchbase Server")
+	}
+
+	err = c.forEachNode(func(node *Node) error {
+		_, err := node.client.ExecuteCommand(command(node))
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to run snapshot command")
+	}
+
+	err = c.forEachNode(func(node *Node) error { return node.startCB() })
+	if err != nil {
+		return errors.Wrap(err, "failed to start Couchbase Server")
+	}
+
+	return nil
+}
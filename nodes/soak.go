@@ -0,0 +1,182 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkSoak repeatedly backs up the cluster into the same repository until the configured duration has elapsed,
+// measuring the transfer rate, archive size and peak memory usage of 'cbbackupmgr' for each backup. The first backup
+// is a full backup, the remainder are incremental backups (containing any churn applied between them).
+//
+// NOTE: The current backup is always completed; if the context is cancelled, the soak stops early.
+func (b *BackupClient) BenchmarkSoak(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.SoakResult, error) {
+	err := config.Soak.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid soak config")
+	}
+
+	// There's nothing for the incremental backups to build on, or for the archive to grow by when using blackhole
+	if config.CBMConfig.Blackhole {
+		return nil, errors.New("the soak benchmark is not supported when backing up to blackhole")
+	}
+
+	duration := time.Duration(config.Soak.Duration) * time.Second
+
+	log.WithField("duration", duration.String()).Info("Beginning 'cbbackupmgr' soak benchmark")
+
+	defer b.enableCoreDumps()()
+
+	err = cluster.startHealthMonitor()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cluster health monitor")
+	}
+
+	err = cluster.waitUntilSettled(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for cluster to settle")
+	}
+
+	err = b.purgeArchive(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to purge archive")
+	}
+
+	err = b.createRepository(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create repository")
+	}
+
+	var (
+		result = &value.SoakResult{}
+		start  = time.Now()
+	)
+
+	for cycle := 0; ; cycle++ {
+		// Churn is only applied between backups, the full backup contains the dataset as loaded
+		if cycle != 0 && config.Churn != nil {
+			_, err = cluster.churn(ctx, config.Churn)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to mutate dataset")
+			}
+		}
+
+		soakCycle, err := b.soakCycle(ctx, config, cluster, start)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to run cycle %d", cycle+1)
+		}
+
+		soakCycle.Incremental = cycle != 0
+
+		result.Cycles = append(result.Cycles, soakCycle)
+
+		fields := log.Fields{
+			"cycle":         cycle + 1,
+			"elapsed":       time.Since(start).String(),
+			"transfer_rate": soakCycle.TransferRate(),
+			"archive_size":  soakCycle.ArchiveSize,
+			"peak_memory":   soakCycle.PeakMemory,
+			"peak_fds":      soakCycle.PeakFDs,
+		}
+
+		log.WithFields(fields).Info("Completed soak cycle")
+
+		// Abort if the cluster health degraded during the backup, the remaining cycles would be misleading
+		err = cluster.checkHealth()
+		if err != nil {
+			return nil, errors.Wrap(err, "cluster health check failed")
+		}
+
+		if time.Since(start) >= duration || !sleepContext(ctx, time.Duration(config.Soak.Interval)*time.Second) {
+			break
+		}
+	}
+
+	result.Elapsed = time.Since(start)
+
+	err = b.purgeBackups(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to purge created backups")
+	}
+
+	return result, nil
+}
+
+// soakCycle creates a single timed backup for the soak benchmark, sampling the memory usage and open file
+// descriptors/threads of 'cbbackupmgr' whilst the backup is running.
+func (b *BackupClient) soakCycle(ctx context.Context, config *value.BenchmarkConfig, cluster *Cluster,
+	start time.Time,
+) (*value.SoakCycle, error) {
+	err := cluster.persistBarrier(ctx, config.CompactBeforeBackup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for mutations to be persisted")
+	}
+
+	tool := startToolMonitor(config.Sampling, b)
+
+	cycleStart := time.Now()
+
+	backupInfo, err := b.createBackup(ctx, config, cluster, false)
+
+	cycle := &value.SoakCycle{Elapsed: cycleStart.Sub(start), Duration: time.Since(cycleStart)}
+
+	memory, resources := tool.stop()
+	if memory != nil {
+		cycle.PeakMemory = memory.PeakRSS
+	}
+
+	if resources != nil {
+		cycle.PeakFDs, cycle.PeakThreads = resources.PeakFDs, resources.PeakThreads
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create backup")
+	}
+
+	cycle.ADS, cycle.AIN = backupInfo.BackupSize, backupInfo.ItemsNum
+
+	cycle.ArchiveSize, err = b.archiveSize(config.CBMConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return cycle, nil
+}
+
+// sleepContext sleeps for the given duration, returning false if the context was cancelled first.
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
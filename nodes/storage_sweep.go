@@ -0,0 +1,76 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkStorage runs the backup then restore benchmarks using each of the archive storage backends from the storage
+// sweep config in turn, so that the backends may be compared against the same cluster/backup client.
+func (b *BackupClient) BenchmarkStorage(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (value.StorageSweepResults, error) {
+	err := config.StorageSweep.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid storage sweep config")
+	}
+
+	log.WithField("backends", config.StorageSweep.Backends).Info("Beginning 'cbbackupmgr' storage benchmark")
+
+	results := make(value.StorageSweepResults, 0, len(config.StorageSweep.Backends))
+
+	for _, backend := range config.StorageSweep.Backends {
+		var (
+			cbm   = *config.CBMConfig
+			sweep = *config
+		)
+
+		cbm.Storage, sweep.CBMConfig = backend, &cbm
+
+		if backend == value.StorageBackendDefault {
+			cbm.Storage = ""
+		}
+
+		backup, err := b.BenchmarkBackup(ctx, &sweep, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to benchmark backup using storage backend '%s'", backend)
+		}
+
+		// If the context has been cancelled, don't benchmark any more; the user wants to gracefully terminate
+		if ctx.Err() != nil {
+			results = append(results, value.NewStorageResult(&cbm, backup, nil))
+			break
+		}
+
+		restore, err := b.BenchmarkRestore(ctx, &sweep, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to benchmark restore using storage backend '%s'", backend)
+		}
+
+		results = append(results, value.NewStorageResult(&cbm, backup, restore))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// Teardown uninstalls Couchbase Server from each of the nodes in the cluster, removing the contents of the data/index
+// paths, any devices prepared during provisioning and the files uploaded/created by 'cbtools-autobench'.
+func (c *Cluster) Teardown() error {
+	log.WithField("hosts", c.hosts()).Info("Tearing down cluster")
+
+	if c.blueprint.LDAP != nil {
+		c.removeLDAP()
+	}
+
+	return c.forEachNode(func(node *Node) error { return node.teardown() })
+}
+
+// InstanceIDs returns the ids of the EC2 instances used for each of the nodes in the cluster.
+func (c *Cluster) InstanceIDs() ([]string, error) {
+	ids := make([]string, 0, len(c.nodes))
+
+	for _, node := range c.nodes {
+		id, err := node.instanceID()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine instance id for '%s'", node.blueprint.Host)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Teardown uninstalls Couchbase Server from the backup client, removing any devices prepared during provisioning and
+// the files uploaded/created by 'cbtools-autobench'.
+//
+// NOTE: The archive is not removed, the repositories created by previous runs may be removed using 'gc --archives'.
+func (b *BackupClient) Teardown() error {
+	log.WithField("host", b.blueprint.Host).Info("Tearing down backup client")
+
+	return b.node.teardown()
+}
+
+// InstanceID returns the id of the EC2 instance used for the backup client.
+func (b *BackupClient) InstanceID() (string, error) {
+	return b.node.instanceID()
+}
+
+// teardown reverts provisioning on the remote machine; the data/index paths are emptied before the instance
+// store/tiered devices are unmounted, since they're usually mounted within them.
+func (n *Node) teardown() error {
+	err := n.uninstallCB(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "failed to uninstall Couchbase Server")
+	}
+
+	// The generated password (if any) is useless once Couchbase Server has been uninstalled
+	_, err = n.client.ExecuteCommand(value.NewCommand("rm -f %s", value.CredentialsPath))
+	if err != nil {
+		return errors.Wrap(err, "failed to remove generated password")
+	}
+
+	for _, path := range []string{n.blueprint.DataPath, n.blueprint.IndexPath} {
+		if path == "" {
+			continue
+		}
+
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "path": path}).Info("Cleaning path")
+
+		_, err = n.client.ExecuteCommand(value.CommandCleanPath(path))
+		if err != nil {
+			return errors.Wrapf(err, "failed to clean '%s'", path)
+		}
+	}
+
+	if tiered := n.blueprint.TieredStorage; tiered != nil {
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "mount_point": tiered.MountPointOrDefault()}).
+			Info("Removing tiered storage")
+
+		_, err = n.client.ExecuteCommand(tiered.CommandTeardown())
+		if err != nil {
+			return errors.Wrap(err, "failed to remove tiered storage")
+		}
+	}
+
+	if store := n.blueprint.InstanceStore; store != nil {
+		log.WithFields(log.Fields{"host": n.blueprint.Host, "mount_point": store.MountPointOrDefault()}).
+			Info("Unmounting instance store")
+
+		_, err = n.client.ExecuteCommand(store.CommandTeardown())
+		if err != nil {
+			return errors.Wrap(err, "failed to unmount instance store")
+		}
+	}
+
+	log.WithField("host", n.blueprint.Host).Info("Removing temporary directories")
+
+	_, err = n.client.ExecuteCommand(value.CommandRemoveTempDirectories(n.blueprint.RemoteTmpDirOrDefault()))
+	if err != nil {
+		return errors.Wrap(err, "failed to remove temporary directories")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkThreads runs the backup benchmark using each of the values of '--threads' from the threads sweep config in
+// turn, recommending the value at the knee of the transfer rate curve.
+func (b *BackupClient) BenchmarkThreads(ctx context.Context, config *value.BenchmarkConfig,
+	cluster *Cluster,
+) (*value.ThreadsSweepResults, error) {
+	err := config.ThreadsSweep.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid threads sweep config")
+	}
+
+	log.WithField("threads", config.ThreadsSweep.Threads).Info("Beginning 'cbbackupmgr' threads benchmark")
+
+	results := make([]*value.ThreadsResult, 0, len(config.ThreadsSweep.Threads))
+
+	for _, threads := range config.ThreadsSweep.Threads {
+		var (
+			cbm   = *config.CBMConfig
+			sweep = *config
+		)
+
+		cbm.Threads, sweep.CBMConfig = threads, &cbm
+
+		benchmarkResults, err := b.BenchmarkBackup(ctx, &sweep, cluster)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to benchmark %d threads", threads)
+		}
+
+		results = append(results, value.NewThreadsResult(threads, benchmarkResults))
+
+		// If the context has been cancelled, don't benchmark any more values; the user wants to gracefully terminate
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return value.NewThreadsSweepResults(results), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodes
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+// tombstone creates then deletes the configured number of documents in the benchmarking bucket leaving a tombstone for
+// each, once the elapsed time has passed the bucket is (optionally) compacted purging any tombstones which are older
+// than the metadata purge interval.
+func (c *Cluster) tombstone(ctx context.Context, config *value.TombstoneConfig) (*value.TombstoneResult, error) {
+	var (
+		interval = c.blueprint.Compaction.MetadataPurgeIntervalOrDefault()
+		elapsed  = time.Duration(config.Elapsed) * time.Second
+	)
+
+	fields := log.Fields{
+		"items":          config.Items,
+		"elapsed":        config.Elapsed,
+		"purge":          config.Purge,
+		"purge_interval": interval,
+	}
+
+	log.WithFields(fields).Info("Leaving tombstones")
+
+	for _, statement := range []string{
+		config.StatementCreateTombstoneDocuments(),
+		config.StatementDeleteTombstoneDocuments(),
+	} {
+		output, err := c.runStatement(statement)
+		if err == nil {
+			_, err = value.ParseQueryResultCount(output)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create tombstones")
+		}
+	}
+
+	err := c.persistBarrier(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wait for tombstones to be persisted")
+	}
+
+	if !sleepContext(ctx, elapsed) {
+		return nil, errors.Wrap(ctx.Err(), "interrupted whilst aging tombstones")
+	}
+
+	// Tombstones are only purged by compaction once they're older than the purge interval, so we only report them as
+	// purged when the elapsed time (which doesn't include the time taken to create them) is long enough
+	purged := config.Purge && elapsed >= time.Duration(interval*float64(24*time.Hour))
+
+	if config.Purge && !purged {
+		log.WithFields(fields).Warn("Tombstones are younger than the metadata purge interval, they won't be purged")
+	}
+
+	if config.Purge {
+		err = c.compactBucket(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compact bucket")
+		}
+	}
+
+	return &value.TombstoneResult{
+		Items:         config.Items,
+		Elapsed:       config.Elapsed,
+		PurgeInterval: interval,
+		Purged:        purged,
+	}, nil
+}
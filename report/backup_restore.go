@@ -0,0 +1,112 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// BackupRestore is a component which compares the backup created by a restore benchmark against the average of the
+// restores of that backup.
+type BackupRestore struct {
+	Backup  *backupRestoreResult `json:"backup"`
+	Restore *backupRestoreResult `json:"restore"`
+}
+
+// backupRestoreResult encapsulates the performance of a backup, or the average performance of the restores.
+type backupRestoreResult struct {
+	Duration     string `json:"duration"`
+	AIN          string `json:"ain"`
+	ADS          string `json:"ads"`
+	TransferRate string `json:"transfer_rate_ads"`
+	ItemRate     string `json:"item_rate"`
+}
+
+// NewBackupRestore creates a new 'BackupRestore' component with the provided options, nil is returned if this wasn't a
+// restore benchmark.
+func NewBackupRestore(options Options) *BackupRestore {
+	if options.Benchmark != "restore" || len(options.Results) == 0 || options.Results[0].Source == nil {
+		return nil
+	}
+
+	var (
+		duration     time.Duration
+		transferRate uint64
+		itemRate     uint64
+	)
+
+	for _, result := range options.Results {
+		duration += result.Duration
+		transferRate += result.AvgTransferRateADS()
+		itemRate += result.AvgItemRate()
+	}
+
+	var (
+		source = options.Results[0].Source
+		count  = uint64(len(options.Results))
+	)
+
+	return &BackupRestore{
+		Backup: newBackupRestoreResult(source.Duration, source.AIN, source.ADS, source.AvgTransferRateADS(),
+			source.AvgItemRate()),
+		Restore: newBackupRestoreResult(duration/time.Duration(count), source.AIN, source.ADS, transferRate/count,
+			itemRate/count),
+	}
+}
+
+// newBackupRestoreResult returns a formatted result using the provided values.
+func newBackupRestoreResult(duration time.Duration, ain, ads, transferRate, itemRate uint64) *backupRestoreResult {
+	return &backupRestoreResult{
+		Duration:     format.Duration(duration),
+		AIN:          fmt.Sprint(ain),
+		ADS:          format.Bytes(ads),
+		TransferRate: format.Bytes(transferRate),
+		ItemRate:     fmt.Sprint(itemRate),
+	}
+}
+
+// String returns a string representation of the 'BackupRestore' component which will be output in the report.
+func (b *BackupRestore) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Backup vs Restore\n| -----------------")
+	fmt.Fprintf(writer, "| Operation\t Duration\t Items (AIN)\t Size (ADS)\t Transfer Rate (ADS)\t Item Rate\t\n")
+
+	for _, row := range []struct {
+		name   string
+		result *backupRestoreResult
+	}{{name: "backup", result: b.Backup}, {name: "restore (avg)", result: b.Restore}} {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s/s\t %s/s\t\n",
+			row.name,
+			row.result.Duration,
+			row.result.AIN,
+			row.result.ADS,
+			row.result.TransferRate,
+			row.result.ItemRate)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
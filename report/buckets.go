@@ -0,0 +1,121 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// bucketSummary is the average size/number of items of a single bucket across all the benchmark iterations.
+type bucketSummary struct {
+	Name               string  `json:"name"`
+	AvgADS             uint64  `json:"avg_ads"`
+	AvgAIN             uint64  `json:"avg_ain"`
+	Share              float64 `json:"share_of_ads"`
+	AvgTransferRateADS uint64  `json:"avg_transfer_rate_ads"`
+}
+
+// Buckets is a component which contains the average size/number of items of each bucket which was backed up/restored
+// along with its contribution to the overall transfer rate, it's only populated when additional buckets are created.
+type Buckets struct {
+	Buckets []*bucketSummary `json:"buckets"`
+	Total   *bucketSummary   `json:"total"`
+}
+
+// NewBuckets creates a new 'Buckets' component with the provided options, nil is returned if none of the results
+// contain per-bucket information.
+func NewBuckets(options Options) *Buckets {
+	var (
+		buckets = &Buckets{Total: &bucketSummary{Name: "Total"}}
+		lookup  = make(map[string]*bucketSummary)
+		counts  = make(map[string]uint64)
+	)
+
+	for _, result := range options.Results {
+		seconds := uint64(1)
+		if result.Duration >= time.Second {
+			seconds = uint64(result.Duration.Seconds())
+		}
+
+		for _, bucket := range result.Buckets {
+			summary, ok := lookup[bucket.Name]
+			if !ok {
+				summary = &bucketSummary{Name: bucket.Name}
+				lookup[bucket.Name] = summary
+				buckets.Buckets = append(buckets.Buckets, summary)
+			}
+
+			// Accumulate the totals here, they're converted to averages once every iteration has been visited
+			summary.AvgADS += bucket.ADS
+			summary.AvgAIN += bucket.AIN
+			summary.AvgTransferRateADS += bucket.ADS / seconds
+			counts[bucket.Name]++
+		}
+	}
+
+	if len(buckets.Buckets) == 0 {
+		return nil
+	}
+
+	for _, summary := range buckets.Buckets {
+		summary.AvgADS /= counts[summary.Name]
+		summary.AvgAIN /= counts[summary.Name]
+		summary.AvgTransferRateADS /= counts[summary.Name]
+
+		buckets.Total.AvgADS += summary.AvgADS
+		buckets.Total.AvgAIN += summary.AvgAIN
+		buckets.Total.AvgTransferRateADS += summary.AvgTransferRateADS
+	}
+
+	for _, summary := range append(buckets.Buckets, buckets.Total) {
+		if buckets.Total.AvgADS != 0 {
+			summary.Share = float64(summary.AvgADS) / float64(buckets.Total.AvgADS) * 100
+		}
+	}
+
+	return buckets
+}
+
+// String returns a string representation of the 'Buckets' component which will be output in the report.
+func (b *Buckets) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	row := func(summary *bucketSummary) {
+		fmt.Fprintf(writer, "| %s\t %s\t %d\t %.2f%%\t %s\t\n", summary.Name, format.Bytes(summary.AvgADS),
+			summary.AvgAIN, summary.Share, format.Bytes(summary.AvgTransferRateADS))
+	}
+
+	fmt.Fprintln(buffer, "| Buckets\n| -------")
+	fmt.Fprintf(writer, "| Bucket\t Avg ADS\t Avg Items\t Share of ADS\t Avg Transfer Rate (ADS)\t\n")
+
+	for _, summary := range b.Buckets {
+		row(summary)
+	}
+
+	row(b.Total)
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,44 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// CloudWatch is a component which contains the EC2/EBS metrics fetched from CloudWatch for the benchmark window,
+// averaged over each iteration so they may be compared with the sampled throughput.
+type CloudWatch struct {
+	Metrics value.CloudWatchMetrics `json:"metrics"`
+
+	results value.BenchmarkResults
+}
+
+// NewCloudWatch creates a new 'CloudWatch' component with the provided options, nil is returned if no metrics were
+// fetched.
+func NewCloudWatch(options Options) *CloudWatch {
+	if len(options.CloudWatch) == 0 {
+		return nil
+	}
+
+	return &CloudWatch{Metrics: options.CloudWatch, results: options.Results}
+}
+
+// String returns a string representation of the 'CloudWatch' component which will be output in the report.
+func (c *CloudWatch) String() string {
+	return fmt.Sprintf("| CloudWatch\n| ----------\n%s", c.Metrics.Table(c.results))
+}
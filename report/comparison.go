@@ -0,0 +1,167 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// EnvironmentReport is the outcome of benchmarking a single environment, the report may be nil if the benchmark failed
+// before a report could be produced.
+type EnvironmentReport struct {
+	Name   string
+	Report *Report
+	Err    error
+}
+
+// BenchmarkReport returns the machine readable form of the environment's report, when the benchmark failed without
+// producing a report, a report flagging the environment as failed is returned instead.
+func (e *EnvironmentReport) BenchmarkReport(run value.RunID, benchmark string) *value.BenchmarkReport {
+	machine := &value.BenchmarkReport{
+		Version:     value.BenchmarkReportVersion,
+		RunID:       run,
+		Environment: e.Name,
+		Benchmark:   benchmark,
+		Status:      value.RunStatusFailed,
+		Results:     []*value.BenchmarkReportResult{},
+	}
+
+	if e.Report != nil {
+		machine = e.Report.BenchmarkReport()
+	}
+
+	if e.Err != nil {
+		machine.Error = e.Err.Error()
+	}
+
+	return machine
+}
+
+// Comparison is the report produced when benchmarking multiple environments concurrently, it contains a summary
+// comparing the environments followed by the full report for each environment.
+type Comparison struct {
+	RunID   value.RunID        `json:"run_id,omitempty"`
+	Summary []*comparisonEntry `json:"summary"`
+	Reports []*Report          `json:"environments"`
+}
+
+// comparisonEntry is a single row in the comparison summary.
+type comparisonEntry struct {
+	Environment        string          `json:"environment"`
+	Status             value.RunStatus `json:"status"`
+	Nodes              int             `json:"nodes,omitempty"`
+	AvgDuration        string          `json:"avg_duration,omitempty"`
+	AvgTransferRateADS string          `json:"avg_transfer_rate_ads,omitempty"`
+	PeakRSS            string          `json:"peak_rss,omitempty"`
+	Relative           string          `json:"relative,omitempty"`
+	Error              string          `json:"error,omitempty"`
+}
+
+// NewComparison creates a new comparison from the reports for each of the environments, the relative column compares
+// the average duration of each environment to the fastest environment.
+func NewComparison(run value.RunID, environments []*EnvironmentReport) *Comparison {
+	comparison := &Comparison{RunID: run}
+
+	var fastest time.Duration
+
+	for _, environment := range environments {
+		if environment.Report == nil || environment.Report.Overview == nil {
+			continue
+		}
+
+		if duration := environment.Report.Overview.avgDuration; fastest == 0 || duration < fastest {
+			fastest = duration
+		}
+	}
+
+	for _, environment := range environments {
+		entry := &comparisonEntry{Environment: environment.Name, Status: value.RunStatusFailed}
+
+		if environment.Err != nil {
+			entry.Error = environment.Err.Error()
+		}
+
+		if environment.Report != nil {
+			entry.Status = environment.Report.Status
+
+			if environment.Report.Cluster != nil {
+				entry.Nodes = len(environment.Report.Cluster.Nodes)
+			}
+
+			comparison.Reports = append(comparison.Reports, environment.Report)
+		}
+
+		if environment.Report != nil && environment.Report.Memory != nil {
+			entry.PeakRSS = format.Bytes(environment.Report.Memory.Overall.PeakRSS)
+		}
+
+		if environment.Report != nil && environment.Report.Overview != nil {
+			overview := environment.Report.Overview
+
+			entry.AvgDuration = overview.AvgDuration
+			entry.AvgTransferRateADS = overview.AvgTransferRateADS + "/s"
+
+			if fastest != 0 {
+				entry.Relative = fmt.Sprintf("%.2fx", float64(overview.avgDuration)/float64(fastest))
+			}
+		}
+
+		comparison.Summary = append(comparison.Summary, entry)
+	}
+
+	return comparison
+}
+
+// String returns a string representation of the comparison, the summary is followed by the report for each
+// environment.
+func (c *Comparison) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	if c.RunID != "" {
+		fmt.Fprintf(buffer, "| Run\n| ---\n| %s\n\n", c.RunID)
+	}
+
+	fmt.Fprintln(buffer, "| Comparison\n| ----------")
+	fmt.Fprintf(writer, "| Environment\t Status\t Nodes\t Avg Duration\t Avg Transfer Rate (ADS)\t Peak RSS\t Relative\t "+
+		"Error\t\n")
+
+	for _, entry := range c.Summary {
+		fmt.Fprintf(writer, "| %s\t %s\t %d\t %s\t %s\t %s\t %s\t %s\t\n", entry.Environment, entry.Status,
+			entry.Nodes, entry.AvgDuration, entry.AvgTransferRateADS, entry.PeakRSS, entry.Relative, entry.Error)
+	}
+
+	_ = writer.Flush()
+
+	for _, report := range c.Reports {
+		fmt.Fprintf(buffer, "\n\n%s", report)
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// Print displays a string representation of the comparison, this is either a human readable form or standard JSON.
+func (c *Comparison) Print(jsonOut bool) error {
+	return display(c, jsonOut)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// Credits is a component which contains the credit balances of the burstable instances/volumes used during the
+// benchmark, along with the iterations whose results were affected by credit exhaustion.
+type Credits struct {
+	Balances value.CreditBalances `json:"balances"`
+	Affected []int                `json:"affected_iterations,omitempty"`
+}
+
+// NewCredits creates a new 'Credits' component with the provided options, nil is returned if no burstable resources
+// were monitored.
+func NewCredits(options Options) *Credits {
+	if len(options.Credits) == 0 {
+		return nil
+	}
+
+	return &Credits{Balances: options.Credits, Affected: options.Credits.Affected(options.Results)}
+}
+
+// String returns a string representation of the 'Credits' component which will be output in the report.
+func (c *Credits) String() string {
+	buffer := &bytes.Buffer{}
+
+	fmt.Fprintf(buffer, "| Burst Credits\n| -------------\n%s\n", c.Balances)
+
+	if len(c.Affected) != 0 {
+		iterations := make([]string, 0, len(c.Affected))
+		for _, iteration := range c.Affected {
+			iterations = append(iterations, strconv.Itoa(iteration))
+		}
+
+		fmt.Fprintf(buffer, "\nNOTE: credits were exhausted during iteration(s) %s, these results are not "+
+			"representative of sustained performance", strings.Join(iterations, ", "))
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Impact is a component which contains the backup impact score for each benchmark iteration, the score combines the
+// front-end latency degradation and the increase in server CPU usage versus the baseline (lower is better).
+type Impact struct {
+	Scores  []float64 `json:"scores"`
+	Average float64   `json:"average"`
+}
+
+// NewImpact creates a new 'Impact' component with the provided options, nil is returned if the live workload wasn't
+// enabled.
+func NewImpact(options Options) *Impact {
+	impact := &Impact{}
+
+	for _, result := range options.Results {
+		if result.Workload == nil {
+			continue
+		}
+
+		score, ok := result.Workload.ImpactScore()
+		if !ok {
+			continue
+		}
+
+		impact.Scores = append(impact.Scores, score)
+		impact.Average += score
+	}
+
+	if len(impact.Scores) == 0 {
+		return nil
+	}
+
+	impact.Average /= float64(len(impact.Scores))
+
+	return impact
+}
+
+// String returns a string representation of the 'Impact' component which will be output in the report.
+func (i *Impact) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Impact\n| ------")
+	fmt.Fprintf(writer, "| Iteration\t Score\t\n")
+
+	for index, score := range i.Scores {
+		fmt.Fprintf(writer, "| %d\t %.2f\t\n", index+1, score)
+	}
+
+	fmt.Fprintf(writer, "| Average\t %.2f\t\n", i.Average)
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// chartWidth is the maximum width of bars in the latency charts.
+const chartWidth = 40
+
+// latencyPhase encapsulates the latencies observed during a single phase of a benchmark iteration.
+type latencyPhase struct {
+	Phase      string `json:"phase"`
+	Operations uint64 `json:"operations"`
+	P50        string `json:"p50"`
+	P95        string `json:"p95"`
+	P99        string `json:"p99"`
+	P999       string `json:"p999"`
+	CPU        string `json:"cpu"`
+
+	summary *value.LatencySummary
+}
+
+// latencyIteration encapsulates the latencies observed before, during and after a single benchmark iteration.
+type latencyIteration struct {
+	Iteration int             `json:"iteration"`
+	Phases    []*latencyPhase `json:"phases"`
+}
+
+// Latency is a component which contains the front-end latencies/CPU usage captured from the live workload.
+type Latency []*latencyIteration
+
+// NewLatency creates a new 'Latency' component with the provided options, nil is returned if the live workload wasn't
+// enabled.
+func NewLatency(options Options) Latency {
+	latency := make(Latency, 0)
+
+	for index, result := range options.Results {
+		if result.Workload == nil {
+			continue
+		}
+
+		iteration := &latencyIteration{Iteration: index + 1}
+
+		for _, phase := range []struct {
+			name    string
+			summary *value.PhaseSummary
+		}{
+			{name: "before", summary: result.Workload.Before},
+			{name: "during", summary: result.Workload.During},
+			{name: "after", summary: result.Workload.After},
+		} {
+			if phase.summary == nil || phase.summary.Latency == nil {
+				continue
+			}
+
+			iteration.Phases = append(iteration.Phases, &latencyPhase{
+				Phase:      phase.name,
+				Operations: phase.summary.Latency.Operations,
+				P50:        phase.summary.Latency.P50.String(),
+				P95:        phase.summary.Latency.P95.String(),
+				P99:        phase.summary.Latency.P99.String(),
+				P999:       phase.summary.Latency.P999.String(),
+				CPU:        fmt.Sprintf("%.2f%%", phase.summary.CPU),
+				summary:    phase.summary.Latency,
+			})
+		}
+
+		latency = append(latency, iteration)
+	}
+
+	if len(latency) == 0 {
+		return nil
+	}
+
+	return latency
+}
+
+// String returns a string representation of the 'Latency' component which will be output in the report.
+func (l Latency) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Latency\n| -------")
+	fmt.Fprintf(writer, "| Iteration\t Phase\t Operations\t p50\t p95\t p99\t p99.9\t CPU\t\n")
+
+	for _, iteration := range l {
+		for _, phase := range iteration.Phases {
+			fmt.Fprintf(writer, "| %d\t %s\t %d\t %s\t %s\t %s\t %s\t %s\t\n", iteration.Iteration, phase.Phase,
+				phase.Operations, phase.P50, phase.P95, phase.P99, phase.P999, phase.CPU)
+		}
+	}
+
+	_ = writer.Flush()
+
+	for _, iteration := range l {
+		fmt.Fprintf(buffer, "\n%s", iteration.chart())
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// chart returns a bar chart comparing the latency percentiles for each phase of the iteration.
+func (l *latencyIteration) chart() string {
+	percentiles := []struct {
+		name    string
+		extract func(s *value.LatencySummary) time.Duration
+	}{
+		{name: "p50", extract: func(s *value.LatencySummary) time.Duration { return s.P50 }},
+		{name: "p95", extract: func(s *value.LatencySummary) time.Duration { return s.P95 }},
+		{name: "p99", extract: func(s *value.LatencySummary) time.Duration { return s.P99 }},
+		{name: "p99.9", extract: func(s *value.LatencySummary) time.Duration { return s.P999 }},
+	}
+
+	var longest time.Duration
+
+	for _, phase := range l.Phases {
+		for _, percentile := range percentiles {
+			if latency := percentile.extract(phase.summary); latency > longest {
+				longest = latency
+			}
+		}
+	}
+
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintf(buffer, "| Iteration %d\n| -----------\n", l.Iteration)
+
+	for _, percentile := range percentiles {
+		for _, phase := range l.Phases {
+			latency := percentile.extract(phase.summary)
+
+			width := 0
+			if longest != 0 {
+				width = int(int64(latency) * chartWidth / int64(longest))
+			}
+
+			fmt.Fprintf(writer, "| %s\t %s\t %-*s\t %s\t\n", percentile.name, phase.Phase, chartWidth,
+				strings.Repeat("#", width), latency)
+		}
+	}
+
+	_ = writer.Flush()
+
+	return buffer.String()
+}
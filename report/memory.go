@@ -0,0 +1,84 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// memoryIteration is the memory usage of 'cbbackupmgr' during a single benchmark iteration.
+type memoryIteration struct {
+	Iteration int                `json:"iteration"`
+	Usage     *value.MemoryUsage `json:"usage"`
+}
+
+// Memory is a component which contains the peak/average memory usage of 'cbbackupmgr' for each benchmark iteration,
+// and across all the iterations.
+type Memory struct {
+	Iterations []*memoryIteration `json:"iterations"`
+	Overall    *value.MemoryUsage `json:"overall"`
+}
+
+// NewMemory creates a new 'Memory' component with the provided options, nil is returned if memory usage wasn't
+// captured for any of the iterations.
+func NewMemory(options Options) *Memory {
+	overall := options.Results.Memory()
+	if overall == nil {
+		return nil
+	}
+
+	memory := &Memory{Overall: overall}
+
+	for index, result := range options.Results {
+		if result.Memory != nil {
+			memory.Iterations = append(memory.Iterations, &memoryIteration{Iteration: index + 1, Usage: result.Memory})
+		}
+	}
+
+	return memory
+}
+
+// String returns a string representation of the 'Memory' component which will be output in the report.
+func (m *Memory) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	row := func(name string, usage *value.MemoryUsage) {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t\n", name, format.Bytes(usage.PeakRSS),
+			format.Bytes(usage.AvgRSS), format.Bytes(usage.PeakVSZ), format.Bytes(usage.AvgVSZ))
+	}
+
+	fmt.Fprintln(buffer, "| Memory\n| ------")
+	fmt.Fprintf(writer, "| Iteration\t Peak RSS\t Avg RSS\t Peak VSZ\t Avg VSZ\t\n")
+
+	for _, iteration := range m.Iterations {
+		row(strconv.Itoa(iteration.Iteration), iteration.Usage)
+	}
+
+	row("Overall", m.Overall)
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
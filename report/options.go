@@ -21,10 +21,38 @@ import (
 // Options encapsulates the options which may be passed into the 'NewReport' function and avoids having ungainly
 // function signatures.
 type Options struct {
-	Blueprint   *value.Blueprint
-	Stats       *value.Stats
-	CBMConfig   *value.CBMConfig
-	Results     value.BenchmarkResults
-	ClusterLogs []string
-	BackupLogs  string
+	RunID          value.RunID
+	Benchmark      string
+	Status         value.RunStatus
+	CI             *value.CIMetadata
+	Blueprint      *value.Blueprint
+	Stats          *value.Stats
+	Hardware       value.HardwareSummary
+	HostLoads      value.HostLoads
+	ClientHardware *value.Hardware
+	CBMConfig      *value.CBMConfig
+	Workload       *value.LiveWorkloadConfig
+	Settle         *value.SettleResult
+	ServerSettings value.ServerSettings
+	Results        value.BenchmarkResults
+	Extrapolation  *value.ExtrapolationConfig
+	Upgrade        *value.UpgradeResult
+	Compatibility  value.CompatibilityMatrix
+	MultiRestore   value.MultiRestoreResults
+	Sweep          *value.SweepResults
+	Threads        *value.ThreadsSweepResults
+	Matrix         value.MatrixResults
+	Storage        value.StorageSweepResults
+	Bisect         *value.BisectResult
+	MTLS           *value.MTLSResults
+	Soak           *value.SoakResult
+	Cloud          *value.CloudResult
+	ClusterLogs    []string
+	BackupLogs     string
+	CoreDumps      value.CoreDumps
+	HealthEvents   value.HealthEvents
+	Credits        value.CreditBalances
+	CloudWatch     value.CloudWatchMetrics
+	Profiles       value.Profiles
+	Notes          value.Notes
 }
@@ -32,32 +32,46 @@ type Overview struct {
 	AvgGDS             string `json:"avg_gds,omitempty"`
 	AvgTransferRateADS string `json:"avg_transfer_rate_ads,omitempty"`
 	AvgTransferRateGDS string `json:"avg_transfer_rate_gds,omitempty"`
+	AvgItemRate        string `json:"avg_item_rate,omitempty"`
+
+	// avgDuration is the raw average duration, used when comparing environments.
+	avgDuration time.Duration
 }
 
-// NewOverview creates a new overview component with the provided options.
+// NewOverview creates a new overview component with the provided options, nil is returned if there are no results.
 func NewOverview(options Options) *Overview {
+	if len(options.Results) == 0 {
+		return nil
+	}
+
 	var (
 		duration        time.Duration
 		ads             uint64
 		gds             uint64
 		transferRateADS uint64
 		transferRateGDS uint64
+		itemRate        uint64
 	)
 
 	for _, result := range options.Results {
 		duration += result.Duration
 		ads += result.ADS
-		gds += uint64(options.Blueprint.Cluster.Bucket.Data.Items * options.Blueprint.Cluster.Bucket.Data.Size)
+		gds += options.Blueprint.Cluster.GDS()
 		transferRateADS += result.AvgTransferRateADS()
-		transferRateGDS += result.AvgTransferRateGDS(options.Blueprint.Cluster.Bucket.Data)
+		transferRateGDS += result.AvgTransferRateGDS(options.Blueprint.Cluster.GDS())
+		itemRate += result.AvgItemRate()
 	}
 
+	avgDuration := time.Duration(int64(duration) / int64(len(options.Results)))
+
 	return &Overview{
-		AvgDuration:        format.Duration(time.Duration(int64(duration) / int64(len(options.Results)))),
+		avgDuration:        avgDuration,
+		AvgDuration:        format.Duration(avgDuration),
 		AvgADS:             format.Bytes(ads / uint64(len(options.Results))),
 		AvgGDS:             format.Bytes(gds / uint64(len(options.Results))),
 		AvgTransferRateADS: format.Bytes(transferRateADS / uint64(len(options.Results))),
 		AvgTransferRateGDS: format.Bytes(transferRateGDS / uint64(len(options.Results))),
+		AvgItemRate:        fmt.Sprint(itemRate / uint64(len(options.Results))),
 	}
 }
 
@@ -70,13 +84,15 @@ func (o *Overview) String() string {
 
 	fmt.Fprintln(buffer, "| Overview\n| --------")
 	fmt.Fprintf(writer,
-		"| Avg Duration\t Avg Size (ADS)\t Avg Size (GDS)\t Avg Transfer Rate (ADS)\t Avg Transfer Rate (GDS)\t\n")
-	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s/s\t %s/s\t\n",
+		"| Avg Duration\t Avg Size (ADS)\t Avg Size (GDS)\t Avg Transfer Rate (ADS)\t Avg Transfer Rate (GDS)\t "+
+			"Avg Item Rate\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s/s\t %s/s\t %s/s\t\n",
 		o.AvgDuration,
 		o.AvgADS,
 		o.AvgGDS,
 		o.AvgTransferRateADS,
-		o.AvgTransferRateGDS)
+		o.AvgTransferRateGDS,
+		o.AvgItemRate)
 
 	_ = writer.Flush()
 
@@ -0,0 +1,166 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// Recovery is a component which translates the benchmark results into recovery objectives; the estimated time to
+// restore the dataset (RTO) when benchmarking restores, or how frequently backups may be taken (RPO) when benchmarking
+// backups.
+type Recovery struct {
+	RTO *RecoveryTime  `json:"rto,omitempty"`
+	RPO *RecoveryPoint `json:"rpo,omitempty"`
+}
+
+// RecoveryTime is the estimated time to restore the full dataset, based on the measured restores.
+type RecoveryTime struct {
+	Dataset string `json:"dataset"`
+	Average string `json:"average"`
+	Worst   string `json:"worst"`
+}
+
+// RecoveryPoint is the guidance for how frequently backups may be taken, based on the slowest measured (incremental)
+// backup. Backups are taken back-to-back at the minimum interval, meaning mutations made just after a backup starts
+// aren't protected until the next backup completes; this is the worst-case RPO.
+type RecoveryPoint struct {
+	Type          string `json:"type"`
+	Worst         string `json:"worst"`
+	Interval      string `json:"minimum_interval"`
+	BackupsPerDay int    `json:"backups_per_day"`
+	WorstCase     string `json:"worst_case_rpo"`
+}
+
+// NewRecovery creates a new 'Recovery' component with the provided options, nil is returned if the benchmark doesn't
+// produce recovery objectives (or restored to blackhole, which doesn't reflect the time to restore the data).
+func NewRecovery(options Options) *Recovery {
+	if len(options.Results) == 0 {
+		return nil
+	}
+
+	switch options.Benchmark {
+	case "backup":
+		return &Recovery{RPO: newRecoveryPoint(options.Results)}
+	case "restore":
+		if options.CBMConfig != nil && options.CBMConfig.Blackhole {
+			return nil
+		}
+
+		return &Recovery{RTO: newRecoveryTime(options.Results)}
+	}
+
+	return nil
+}
+
+// newRecoveryTime returns the estimated time to restore the full dataset using the given restore results.
+func newRecoveryTime(results value.BenchmarkResults) *RecoveryTime {
+	var (
+		duration time.Duration
+		worst    time.Duration
+		ads      uint64
+	)
+
+	for _, result := range results {
+		duration += result.Duration
+		ads += result.ADS
+
+		if result.Duration > worst {
+			worst = result.Duration
+		}
+	}
+
+	return &RecoveryTime{
+		Dataset: format.Bytes(ads / uint64(len(results))),
+		Average: format.Duration(duration / time.Duration(len(results))),
+		Worst:   format.Duration(worst),
+	}
+}
+
+// newRecoveryPoint returns the backup frequency guidance for the given backup results, the incremental backups are
+// used when they were measured since they're what would be taken at the chosen frequency.
+func newRecoveryPoint(results value.BenchmarkResults) *RecoveryPoint {
+	var (
+		worst time.Duration
+		kind  = "full"
+	)
+
+	for _, result := range results {
+		measured := result
+		if result.Incremental != nil {
+			measured, kind = result.Incremental, "incremental"
+		}
+
+		if measured.Duration > worst {
+			worst = measured.Duration
+		}
+	}
+
+	// Schedules are rarely more granular than a minute, and a backup can't start before the previous one has finished
+	interval := worst.Truncate(time.Minute)
+	if interval < worst || interval == 0 {
+		interval += time.Minute
+	}
+
+	return &RecoveryPoint{
+		Type:          kind,
+		Worst:         format.Duration(worst),
+		Interval:      format.Duration(interval),
+		BackupsPerDay: int((24 * time.Hour) / interval),
+		WorstCase:     format.Duration(interval + worst),
+	}
+}
+
+// String returns a string representation of the 'Recovery' component which will be output in the report.
+func (r *Recovery) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Recovery Objectives\n| -------------------")
+
+	if r.RTO != nil {
+		fmt.Fprintf(writer, "| Dataset (ADS)\t Avg Full Restore (RTO)\t Worst Full Restore (RTO)\t\n")
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", r.RTO.Dataset, r.RTO.Average, r.RTO.Worst)
+	}
+
+	if r.RPO != nil {
+		fmt.Fprintf(writer, "| Backup Type\t Worst Duration\t Minimum Interval\t Backups Per Day\t Worst-Case RPO\t\n")
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %d\t %s\t\n",
+			r.RPO.Type,
+			r.RPO.Worst,
+			r.RPO.Interval,
+			r.RPO.BackupsPerDay,
+			r.RPO.WorstCase)
+	}
+
+	_ = writer.Flush()
+
+	if r.RPO != nil && r.RPO.Type != "incremental" {
+		fmt.Fprintln(buffer, "\nNOTE: No incremental backups were measured, the guidance is based on full backups and is "+
+			"therefore pessimistic (enable 'incremental' to measure them)")
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
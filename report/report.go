@@ -27,33 +27,172 @@ import (
 
 // Report is the benchmark report which will be printed to stdout upon completion of the benchmarks.
 type Report struct {
-	Cluster      *value.ClusterBlueprint      `json:"cluster,omitempty"`
-	BackupClient *value.BackupClientBlueprint `json:"backup_client,omitempty"`
-	CBM          *value.CBMConfig             `json:"cbbackupmgr,omitempty"`
-	Stats        *value.Stats                 `json:"bucket_stats,omitempty"`
-	Overview     *Overview                    `json:"overview,omitempty"`
-	Rundown      Rundown                      `json:"rundown,omitempty"`
-	Logs         *Logs                        `json:"logs,omitempty"`
+	RunID          value.RunID                  `json:"run_id,omitempty"`
+	Environment    string                       `json:"environment,omitempty"`
+	Status         value.RunStatus              `json:"status,omitempty"`
+	CI             *value.CIMetadata            `json:"ci,omitempty"`
+	Cluster        *value.ClusterBlueprint      `json:"cluster,omitempty"`
+	BackupClient   *value.BackupClientBlueprint `json:"backup_client,omitempty"`
+	ClientHardware *value.Hardware              `json:"backup_client_hardware,omitempty"`
+	CBM            *value.CBMConfig             `json:"cbbackupmgr,omitempty"`
+	Workload       *value.LiveWorkloadConfig    `json:"live_workload,omitempty"`
+	Stats          *value.Stats                 `json:"bucket_stats,omitempty"`
+	Hardware       value.HardwareSummary        `json:"hardware,omitempty"`
+	Warnings       []string                     `json:"hardware_warnings,omitempty"`
+	HostLoads      value.HostLoads              `json:"host_loads,omitempty"`
+	Settle         *value.SettleResult          `json:"settle,omitempty"`
+	ServerSettings value.ServerSettings         `json:"server_settings,omitempty"`
+	Overview       *Overview                    `json:"overview,omitempty"`
+	Rundown        Rundown                      `json:"rundown,omitempty"`
+	Buckets        *Buckets                     `json:"buckets,omitempty"`
+	BackupRestore  *BackupRestore               `json:"backup_vs_restore,omitempty"`
+	Recovery       *Recovery                    `json:"recovery_objectives,omitempty"`
+	Recovered      value.ServiceRecovery        `json:"-"`
+	Queries        value.QueryValidations       `json:"query_validation,omitempty"`
+	Expiry         value.ExpiryValidations      `json:"expiry_validation,omitempty"`
+	Faults         value.FaultInjections        `json:"fault_injection,omitempty"`
+	Extrapolation  value.Extrapolations         `json:"extrapolation,omitempty"`
+	Upgrade        *value.UpgradeResult         `json:"upgrade,omitempty"`
+	Compatibility  value.CompatibilityMatrix    `json:"compatibility,omitempty"`
+	MultiRestore   value.MultiRestoreResults    `json:"multi_restore,omitempty"`
+	Sweep          *value.SweepResults          `json:"client_sweep,omitempty"`
+	Threads        *value.ThreadsSweepResults   `json:"threads_sweep,omitempty"`
+	Matrix         value.MatrixResults          `json:"matrix,omitempty"`
+	Storage        value.StorageSweepResults    `json:"storage_sweep,omitempty"`
+	Bisect         *value.BisectResult          `json:"bisect,omitempty"`
+	MTLS           *value.MTLSResults           `json:"mtls,omitempty"`
+	Soak           *value.SoakResult            `json:"soak,omitempty"`
+	Cloud          *value.CloudResult           `json:"cloud,omitempty"`
+	Latency        Latency                      `json:"latency,omitempty"`
+	Impact         *Impact                      `json:"impact,omitempty"`
+	Throughput     Throughput                   `json:"throughput,omitempty"`
+	DiskIO         value.DiskUsages             `json:"disk_io,omitempty"`
+	Memory         *Memory                      `json:"memory,omitempty"`
+	Resources      *Resources                   `json:"resources,omitempty"`
+	Churn          value.Churns                 `json:"churn,omitempty"`
+	Tombstones     value.Tombstones             `json:"tombstones,omitempty"`
+	CloudWatch     *CloudWatch                  `json:"cloudwatch,omitempty"`
+	Credits        *Credits                     `json:"burst_credits,omitempty"`
+	Logs           *Logs                        `json:"logs,omitempty"`
+	CoreDumps      value.CoreDumps              `json:"core_dumps,omitempty"`
+	HealthEvents   value.HealthEvents           `json:"health_events,omitempty"`
+	Profiles       value.Profiles               `json:"profiles,omitempty"`
+	Notes          value.Notes                  `json:"notes,omitempty"`
+
+	// benchmark/results are the kind of benchmark run and its raw results, used to build the machine readable report.
+	benchmark string
+	results   value.BenchmarkResults
 }
 
 // NewReport creates a new report with the provided options.
 func NewReport(options Options) *Report {
 	return &Report{
-		Cluster:      options.Blueprint.Cluster,
-		Stats:        options.Stats,
-		BackupClient: options.Blueprint.BackupClient,
-		CBM:          options.CBMConfig,
-		Overview:     NewOverview(options),
-		Rundown:      NewRundown(options),
-		Logs:         NewLogs(options),
+		RunID:          options.RunID,
+		Environment:    options.Blueprint.Name,
+		Status:         options.Status,
+		CI:             options.CI,
+		Cluster:        options.Blueprint.Cluster,
+		Stats:          options.Stats,
+		Hardware:       options.Hardware,
+		Warnings:       options.Hardware.Warnings(),
+		HostLoads:      options.HostLoads,
+		BackupClient:   options.Blueprint.BackupClient,
+		ClientHardware: options.ClientHardware,
+		CBM:            options.CBMConfig,
+		Workload:       options.Workload,
+		Settle:         options.Settle,
+		ServerSettings: options.ServerSettings,
+		Overview:       NewOverview(options),
+		Rundown:        NewRundown(options),
+		Buckets:        NewBuckets(options),
+		BackupRestore:  NewBackupRestore(options),
+		Recovery:       NewRecovery(options),
+		Recovered:      options.Results.ServiceRecovery(),
+		Queries:        options.Results.QueryValidations(),
+		Expiry:         options.Results.ExpiryValidations(),
+		Faults:         options.Results.FaultInjections(),
+		Extrapolation:  value.Extrapolate(options.Extrapolation, options.Results),
+		Upgrade:        options.Upgrade,
+		Compatibility:  options.Compatibility,
+		MultiRestore:   options.MultiRestore,
+		Sweep:          options.Sweep,
+		Threads:        options.Threads,
+		Matrix:         options.Matrix,
+		Storage:        options.Storage,
+		Bisect:         options.Bisect,
+		MTLS:           options.MTLS,
+		Soak:           options.Soak,
+		Cloud:          options.Cloud,
+		Latency:        NewLatency(options),
+		Impact:         NewImpact(options),
+		Throughput:     NewThroughput(options),
+		DiskIO:         options.Results.DiskUsages(),
+		Memory:         NewMemory(options),
+		Resources:      NewResources(options),
+		Churn:          options.Results.Churns(),
+		Tombstones:     options.Results.Tombstones(),
+		CloudWatch:     NewCloudWatch(options),
+		Credits:        NewCredits(options),
+		Logs:           NewLogs(options),
+		CoreDumps:      options.CoreDumps,
+		HealthEvents:   options.HealthEvents,
+		Profiles:       options.Profiles,
+		Notes:          options.Notes,
+		benchmark:      options.Benchmark,
+		results:        options.Results,
 	}
 }
 
+// BenchmarkReport returns the machine readable form of the report, see 'value.BenchmarkReport'.
+func (r *Report) BenchmarkReport() *value.BenchmarkReport {
+	machine := &value.BenchmarkReport{
+		Version:     value.BenchmarkReportVersion,
+		RunID:       r.RunID,
+		Environment: r.Environment,
+		Benchmark:   r.benchmark,
+		Status:      r.Status,
+		CI:          r.CI,
+		Dataset:     value.NewBenchmarkDataset(r.Stats),
+		Results:     r.results.Report(),
+	}
+
+	if r.CBM != nil {
+		machine.Storage = r.CBM.StorageOrDefault()
+	}
+
+	if len(r.Storage) != 0 {
+		machine.Backends = r.Storage.Report()
+	}
+
+	if r.Cluster != nil && r.BackupClient != nil {
+		machine.Versions = value.NewBenchmarkVersions(r.Cluster, r.BackupClient)
+		machine.Topology = value.NewBenchmarkTopology(r.Cluster, r.BackupClient)
+	}
+
+	return machine
+}
+
 // String returns a string representation of the report. Components which are empty/unused will be omitted in a similar
 // fashion to that of the 'omitempty' tag.
 func (r *Report) String() string {
 	buffer := &bytes.Buffer{}
 
+	if r.RunID != "" {
+		fmt.Fprintf(buffer, "| Run\n| ---\n| %s\n\n", r.RunID)
+	}
+
+	if r.Environment != "" {
+		fmt.Fprintf(buffer, "| Environment\n| -----------\n| %s\n\n", r.Environment)
+	}
+
+	if r.Status != "" {
+		fmt.Fprintf(buffer, "| Status\n| ------\n| %s\n\n", r.Status)
+	}
+
+	if r.CI != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.CI)
+	}
+
 	if r.Cluster != nil {
 		fmt.Fprintf(buffer, "%s\n\n", r.Cluster)
 	}
@@ -62,14 +201,38 @@ func (r *Report) String() string {
 		fmt.Fprintf(buffer, "%s\n\n", r.Stats)
 	}
 
+	if len(r.Hardware) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Hardware)
+	}
+
+	if len(r.HostLoads) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.HostLoads)
+	}
+
 	if r.BackupClient != nil {
 		fmt.Fprintf(buffer, "%s\n\n", r.BackupClient)
 	}
 
+	if r.ClientHardware != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.ClientHardware.ArchiveString())
+	}
+
 	if r.CBM != nil {
 		fmt.Fprintf(buffer, "%s\n\n", r.CBM)
 	}
 
+	if r.Workload != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Workload)
+	}
+
+	if r.Settle != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Settle)
+	}
+
+	if len(r.ServerSettings) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.ServerSettings)
+	}
+
 	if r.Overview != nil {
 		fmt.Fprintf(buffer, "%s\n\n", r.Overview)
 	}
@@ -78,8 +241,141 @@ func (r *Report) String() string {
 		fmt.Fprintf(buffer, "%s\n\n", r.Rundown)
 	}
 
+	if r.Buckets != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Buckets)
+	}
+
+	if r.BackupRestore != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.BackupRestore)
+	}
+
+	if r.Recovery != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Recovery)
+	}
+
+	if len(r.Recovered) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Recovered)
+	}
+
+	if len(r.Queries) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Queries)
+	}
+
+	if len(r.Expiry) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Expiry)
+	}
+
+	if len(r.Faults) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Faults)
+	}
+
+	if len(r.Extrapolation) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Extrapolation)
+	}
+
+	if r.Upgrade != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Upgrade)
+	}
+
+	if len(r.Compatibility) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Compatibility)
+	}
+
+	if len(r.MultiRestore) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.MultiRestore)
+	}
+
+	if r.Sweep != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Sweep)
+	}
+
+	if r.Threads != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Threads)
+	}
+
+	if len(r.Matrix) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Matrix)
+	}
+
+	if len(r.Storage) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Storage)
+	}
+
+	if r.Bisect != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Bisect)
+	}
+
+	if r.MTLS != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.MTLS)
+	}
+
+	if r.Soak != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Soak)
+	}
+
+	if r.Cloud != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Cloud)
+	}
+
+	if r.Latency != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Latency)
+	}
+
+	if r.Impact != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Impact)
+	}
+
+	if r.Throughput != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Throughput)
+	}
+
+	if len(r.DiskIO) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.DiskIO)
+	}
+
+	if r.Memory != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Memory)
+	}
+
+	if r.Resources != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Resources)
+	}
+
+	if len(r.Churn) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Churn)
+	}
+
+	if len(r.Tombstones) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Tombstones)
+	}
+
+	if r.CloudWatch != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.CloudWatch)
+	}
+
+	if r.Credits != nil {
+		fmt.Fprintf(buffer, "%s\n\n", r.Credits)
+	}
+
 	if r.Logs != nil {
-		fmt.Fprintf(buffer, "%s\n", r.Logs)
+		fmt.Fprintf(buffer, "%s\n\n", r.Logs)
+	}
+
+	if len(r.CoreDumps) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.CoreDumps)
+	}
+
+	if len(r.HealthEvents) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.HealthEvents)
+	}
+
+	if len(r.Profiles) != 0 {
+		fmt.Fprintf(buffer, "%s\n\n", r.Profiles)
+	}
+
+	// NOTE: The notes must always be the last section, since 'note add' replaces them in recorded reports
+	if len(r.Notes) != 0 {
+		fmt.Fprintf(buffer, "%s\n", r.Notes)
 	}
 
 	return strings.TrimSpace(buffer.String())
@@ -87,6 +383,11 @@ func (r *Report) String() string {
 
 // Print displays a string representation of the report, this is either a human readable form or standard JSON.
 func (r *Report) Print(jsonOut bool) error {
+	return display(r, jsonOut)
+}
+
+// display displays the given report either in a human readable form or as standard JSON.
+func display(r fmt.Stringer, jsonOut bool) error {
 	if !jsonOut {
 		fmt.Printf("%s\n", r)
 		return nil
@@ -0,0 +1,84 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// resourceIteration is the resource usage of 'cbbackupmgr' during a single benchmark iteration.
+type resourceIteration struct {
+	Iteration int                  `json:"iteration"`
+	Usage     *value.ResourceUsage `json:"usage"`
+}
+
+// Resources is a component which contains the peak/average number of open file descriptors/threads of 'cbbackupmgr'
+// for each benchmark iteration, and across all the iterations.
+type Resources struct {
+	Iterations []*resourceIteration `json:"iterations"`
+	Overall    *value.ResourceUsage `json:"overall"`
+}
+
+// NewResources creates a new 'Resources' component with the provided options, nil is returned if resource usage
+// wasn't captured for any of the iterations.
+func NewResources(options Options) *Resources {
+	overall := options.Results.Resources()
+	if overall == nil {
+		return nil
+	}
+
+	resources := &Resources{Overall: overall}
+
+	for index, result := range options.Results {
+		if result.Resources != nil {
+			resources.Iterations = append(resources.Iterations,
+				&resourceIteration{Iteration: index + 1, Usage: result.Resources})
+		}
+	}
+
+	return resources
+}
+
+// String returns a string representation of the 'Resources' component which will be output in the report.
+func (r *Resources) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	row := func(name string, usage *value.ResourceUsage) {
+		fmt.Fprintf(writer, "| %s\t %d\t %d\t %d\t %d\t\n", name, usage.PeakFDs, usage.AvgFDs, usage.PeakThreads,
+			usage.AvgThreads)
+	}
+
+	fmt.Fprintln(buffer, "| Resources\n| ---------")
+	fmt.Fprintf(writer, "| Iteration\t Peak FDs\t Avg FDs\t Peak Threads\t Avg Threads\t\n")
+
+	for _, iteration := range r.Iterations {
+		row(strconv.Itoa(iteration.Iteration), iteration.Usage)
+	}
+
+	row("Overall", r.Overall)
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/couchbase/tools-common/strings/format"
 )
@@ -31,29 +32,49 @@ type rundownResult struct {
 	GDS                string `json:"gds,omitempty"`
 	AvgTransferRateADS string `json:"avg_transfer_rate_ads,omitempty"`
 	AvgTransferRateGDS string `json:"avg_transfer_rate_gds,omitempty"`
+	AvgItemRate        string `json:"avg_item_rate,omitempty"`
+	IndexBuild         string `json:"index_build,omitempty"`
+	SearchBuild        string `json:"search_build,omitempty"`
+	EventingDeploy     string `json:"eventing_deploy,omitempty"`
 }
 
 // Rundown is a component which contains the detailed rundown for each benchmark that was executed.
 type Rundown []*rundownResult
 
-// NewRundown creates a new 'Rundown' component with the provided options.
+// NewRundown creates a new 'Rundown' component with the provided options, nil is returned if there are no results.
 func NewRundown(options Options) Rundown {
+	if len(options.Results) == 0 {
+		return nil
+	}
+
 	results := make([]*rundownResult, 0, len(options.Results))
 	for _, result := range options.Results {
 		results = append(results, &rundownResult{
-			Duration: format.Duration(result.Duration),
-			AIN:      fmt.Sprint(result.AIN),
-			ADS:      format.Bytes(result.ADS),
-			GDS: format.Bytes(uint64(options.Blueprint.Cluster.Bucket.Data.Items *
-				options.Blueprint.Cluster.Bucket.Data.Size)),
+			Duration:           format.Duration(result.Duration),
+			AIN:                fmt.Sprint(result.AIN),
+			ADS:                format.Bytes(result.ADS),
+			GDS:                format.Bytes(options.Blueprint.Cluster.GDS()),
 			AvgTransferRateADS: format.Bytes(result.AvgTransferRateADS()),
-			AvgTransferRateGDS: format.Bytes(result.AvgTransferRateGDS(options.Blueprint.Cluster.Bucket.Data)),
+			AvgTransferRateGDS: format.Bytes(result.AvgTransferRateGDS(options.Blueprint.Cluster.GDS())),
+			AvgItemRate:        fmt.Sprint(result.AvgItemRate()),
+			IndexBuild:         optionalDuration(result.IndexBuild),
+			SearchBuild:        optionalDuration(result.SearchBuild),
+			EventingDeploy:     optionalDuration(result.EventingDeploy),
 		})
 	}
 
 	return results
 }
 
+// optionalDuration returns the given duration formatted, or an empty string (which will be omitted) when it's zero.
+func optionalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return format.Duration(d)
+}
+
 // String returns a string representation of the 'Rundown' component which will be output in the report.
 func (r Rundown) String() string {
 	var (
@@ -63,17 +84,18 @@ func (r Rundown) String() string {
 
 	fmt.Fprintln(buffer, "| Rundown\n| -------")
 	fmt.Fprintf(writer, "| Iteration\t Duration\t Items (AIN)\t Size (ADS)\t Size (GDS)\t Transfer Rate (ADS)\t "+
-		"Transfer Rate (GDS)\t\n")
+		"Transfer Rate (GDS)\t Item Rate\t\n")
 
 	for index, result := range r {
-		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %s/s\t %s/s\t\n",
+		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %s/s\t %s/s\t %s/s\t\n",
 			index+1,
 			result.Duration,
 			result.AIN,
 			result.ADS,
 			result.GDS,
 			result.AvgTransferRateADS,
-			result.AvgTransferRateGDS)
+			result.AvgTransferRateGDS,
+			result.AvgItemRate)
 	}
 
 	_ = writer.Flush()
@@ -0,0 +1,126 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/value"
+)
+
+// throughputRows is the maximum number of samples displayed for each iteration, longer runs are downsampled so that
+// the report remains readable (all the samples are still included in the JSON report).
+const throughputRows = 20
+
+// Throughput is a component which contains the progress of each benchmark iteration sampled over time.
+type Throughput []*throughputIteration
+
+// throughputIteration is the sampled progress for a single benchmark iteration.
+type throughputIteration struct {
+	Iteration  int               `json:"iteration"`
+	Throughput *value.Throughput `json:"throughput"`
+}
+
+// NewThroughput creates a new 'Throughput' component with the provided options, nil is returned if sampling wasn't
+// enabled.
+func NewThroughput(options Options) Throughput {
+	var throughput Throughput
+
+	for index, result := range options.Results {
+		if result.Throughput == nil || len(result.Throughput.Samples) == 0 {
+			continue
+		}
+
+		throughput = append(throughput, &throughputIteration{Iteration: index + 1, Throughput: result.Throughput})
+	}
+
+	return throughput
+}
+
+// String returns a string representation of the 'Throughput' component which will be output in the report.
+func (t Throughput) String() string {
+	buffer := &bytes.Buffer{}
+
+	fmt.Fprintln(buffer, "| Throughput\n| ----------")
+
+	for _, iteration := range t {
+		fmt.Fprintf(buffer, "\n%s", iteration.chart())
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// chart returns a bar chart of the throughput between each (downsampled) sample for the iteration.
+func (t *throughputIteration) chart() string {
+	var (
+		samples = downsample(t.Throughput)
+		fastest uint64
+	)
+
+	for index := range samples.Samples {
+		if rate := samples.Rate(index); rate > fastest {
+			fastest = rate
+		}
+	}
+
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintf(buffer, "| Iteration %d\n| -----------\n", t.Iteration)
+	fmt.Fprintf(writer, "| Elapsed\t Total\t %-*s\t Rate\t\n", chartWidth, "")
+
+	for index, sample := range samples.Samples {
+		width := 0
+		if fastest != 0 {
+			width = int(samples.Rate(index) * chartWidth / fastest)
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %-*s\t %s\t\n", sample.Elapsed.Round(time.Second),
+			samples.Unit.Format(sample.Value), chartWidth, strings.Repeat("#", width), samples.FormatRate(index))
+	}
+
+	_ = writer.Flush()
+
+	return buffer.String()
+}
+
+// downsample returns the given throughput reduced to at most 'throughputRows' samples, the final sample is always kept
+// so that the total is accurate.
+func downsample(throughput *value.Throughput) *value.Throughput {
+	if len(throughput.Samples) <= throughputRows {
+		return throughput
+	}
+
+	var (
+		reduced = &value.Throughput{Unit: throughput.Unit}
+		step    = (len(throughput.Samples) + throughputRows - 1) / throughputRows
+	)
+
+	for index := step - 1; index < len(throughput.Samples); index += step {
+		reduced.Samples = append(reduced.Samples, throughput.Samples[index])
+	}
+
+	if last := throughput.Samples[len(throughput.Samples)-1]; reduced.Samples[len(reduced.Samples)-1] != last {
+		reduced.Samples = append(reduced.Samples, last)
+	}
+
+	return reduced
+}
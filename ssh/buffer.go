@@ -0,0 +1,71 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// limitedBuffer is a buffer which keeps at most 'limit' bytes, any further writes are discarded (but counted) so that a
+// truncation marker may be added to the output.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buffer    bytes.Buffer
+	limit     int
+	discarded int
+}
+
+// Write implements the 'io.Writer' interface, writes never fail; data beyond the limit is silently discarded.
+func (l *limitedBuffer) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	remaining := l.limit - l.buffer.Len()
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if len(data) <= remaining {
+		l.buffer.Write(data)
+		return len(data), nil
+	}
+
+	l.buffer.Write(data[:remaining])
+	l.discarded += len(data) - remaining
+
+	return len(data), nil
+}
+
+// Truncated returns a boolean indicating whether any output was discarded.
+func (l *limitedBuffer) Truncated() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.discarded != 0
+}
+
+// Bytes returns the buffered output, with a truncation marker appended if any output was discarded.
+func (l *limitedBuffer) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.discarded == 0 {
+		return l.buffer.Bytes()
+	}
+
+	return append(l.buffer.Bytes(), fmt.Sprintf("\n[output truncated, discarded %d bytes]\n", l.discarded)...)
+}
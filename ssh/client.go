@@ -15,184 +15,198 @@
 package ssh
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"net"
-	"strings"
+	"io"
+	"os"
+	"time"
 
-	fsutil "github.com/couchbase/tools-common/fs/util"
+	"github.com/jamesl33/cbtools-autobench/transport"
 	"github.com/jamesl33/cbtools-autobench/value"
 
 	"github.com/apex/log"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/ssh"
 )
 
 // TODO (jamesl33) We really shouldn't be using 'os.TempDir' when running commands on remote machines since the
 // temporary directory from the local machine might not be valid on the remote machine. For the time being we only
 // support Linux so this shouldn't be a major issue.
 
-// Client is thin wrapper around an ssh client which exposes some useful functionality required when setting
-// up/performing benchmarks.
+// Client is thin wrapper around a transport (usually ssh) which exposes some useful functionality required when
+// setting up/performing benchmarks.
 type Client struct {
-	client   *ssh.Client
-	Platform value.Platform
+	transport    transport.Transport
+	host         string
+	profile      *value.HostProfile
+	binDirectory string
+	maxOutput    int
+	Platform     value.Platform
+
+	// Capabilities is the package manager, init system etc. detected on the remote machine, this should be consulted
+	// rather than making assumptions based on the platform.
+	Capabilities *value.Capabilities
+
+	// sudo indicates that we're not running commands as the root user, all commands will be run using 'sudo'.
+	sudo bool
 }
 
-// NewClient creates a new client which is connected to the provided host.
-func NewClient(host string, config *value.SSHConfig) (*Client, error) {
-	log.WithField("host", host).Info("Establishing ssh connection")
+// NewClient creates a new client which is connected to the provided host using the transport described by the given
+// blueprint (ssh by default). The platform of the remote machine is detected unless one is provided.
+func NewClient(host string, config *value.SSHConfig, blueprint *value.TransportBlueprint, platform value.Platform,
+) (*Client, error) {
+	var (
+		t   transport.Transport
+		err error
+	)
+
+	switch blueprint.TypeOrDefault() {
+	case value.TransportTypeSSH:
+		t, err = NewTransport(host, config)
+	case value.TransportTypeLocal:
+		t = transport.NewLocal()
+	case value.TransportTypeDocker:
+		t, err = transport.NewDocker(blueprint.Container)
+	case value.TransportTypeDryRun:
+		t = transport.NewDryRun()
+	default:
+		err = fmt.Errorf("unsupported transport '%s'", blueprint.Type)
+	}
 
-	signer, err := parsePrivateKey(config.PrivateKey, config.PrivateKeyPassphrase)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse private key")
+		return nil, errors.Wrap(err, "failed to create transport")
 	}
 
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", host, 22), &ssh.ClientConfig{
-		User:            config.Username,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
-		HostKeyCallback: func(_ string, _ net.Addr, _ ssh.PublicKey) error { return nil },
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create ssh client")
+	return NewClientWithTransport(host, t, config, platform)
+}
+
+// NewClientWithTransport creates a new client which runs commands using the given transport, the platform of the
+// remote machine is detected unless one is provided.
+func NewClientWithTransport(host string, t transport.Transport, config *value.SSHConfig, platform value.Platform,
+) (*Client, error) {
+	client := &Client{
+		transport:    t,
+		host:         host,
+		profile:      value.NewHostProfile(host),
+		binDirectory: value.CBBinDirectory,
+		maxOutput:    config.MaxOutputSizeOrDefault(),
+		sudo:         !t.Privileged(),
 	}
 
-	platform, err := determinePlatform(client)
+	err := platform.Validate()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to determine platform")
+		return nil, errors.Wrap(err, "invalid platform")
 	}
 
-	fields := log.Fields{"platform": platform, "host": host}
-	log.WithFields(fields).Info("Successfully established ssh connection")
-
-	if config.Username == "root" {
-		return &Client{
-			Platform: platform,
-			client:   client,
-		}, nil
+	if platform == "" {
+		platform, err = determinePlatform(t, host, client.maxOutput)
 	}
 
-	ourClient := &Client{
-		Platform: platform,
-		client:   client,
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to determine platform")
 	}
 
-	err = ourClient.loginAsRoot()
+	capabilities, err := determineCapabilities(t, host, client.maxOutput)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to login as root")
+		return nil, errors.Wrap(err, "failed to determine capabilities")
 	}
 
-	newClient, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", host, 22), &ssh.ClientConfig{
-		User:            "root",
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
-		HostKeyCallback: func(_ string, _ net.Addr, _ ssh.PublicKey) error { return nil },
-	})
+	client.Platform, client.Capabilities = platform, capabilities
+
+	fields := log.Fields{"platform": platform, "host": host, "capabilities": capabilities}
+	log.WithFields(fields).Info("Successfully established connection")
+
+	// Snapshot the machine state before we modify anything, so that it may be restored using 'restore-host'
+	_, err = client.ExecuteCommand(capabilities.CommandSnapshotState())
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create ssh client")
+		return nil, errors.Wrap(err, "failed to snapshot machine state")
 	}
 
-	return &Client{
-		Platform: platform,
-		client:   newClient,
-	}, nil
+	return client, nil
 }
 
-// SecureUpload emulates the 'scp' command by uploading the file at the provided path to the remote server.
-func (c *Client) SecureUpload(source, sink string) error {
-	fields := log.Fields{
-		"local":  trimPort(c.client.LocalAddr().String()),
-		"remote": trimPort(c.client.RemoteAddr().String()),
-		"source": source,
-		"sink":   sink,
-	}
+// SetBinDirectory sets the directory containing the Couchbase Server binaries, this directory is added to the 'PATH'
+// for all executed commands.
+func (c *Client) SetBinDirectory(dir string) {
+	c.binDirectory = dir
+}
+
+// Profile returns the timings for all the remote operations run using this client.
+func (c *Client) Profile() *value.HostProfile {
+	return c.profile
+}
+
+// SecureUpload emulates the 'scp' command by uploading the file at the provided path to the remote server, the upload
+// is aborted if the context is cancelled.
+func (c *Client) SecureUpload(ctx context.Context, source, sink string) error {
+	fields := log.Fields{"host": c.host, "source": source, "sink": sink}
 
 	if c.FileExists(sink) {
 		log.WithFields(fields).Debug("File already exists")
-		fmt.Println("File already exists")
 		return nil
 	}
 
 	log.WithFields(fields).Debug("Uploading file")
 
-	log.Infof("Uploading file %s to %s", source, sink)
-	session, err := c.client.NewSession()
-	if err != nil {
-		return errors.Wrap(err, "failed to create session")
-	}
-	defer session.Close()
+	defer c.record("upload", time.Now())
 
-	pipe, err := session.StdinPipe()
-	if err != nil {
-		return errors.Wrap(err, "failed to get stdin pipe")
-	}
+	log.Infof("Uploading file %s to %s", source, sink)
 
-	err = session.Start(fmt.Sprintf("cat > %s", sink))
+	file, err := os.Open(source)
 	if err != nil {
-		return errors.Wrap(err, "failed to start session")
+		return errors.Wrap(err, "failed to open source file")
 	}
+	defer file.Close()
 
-	err = fsutil.CopyFileTo(source, pipe)
-	if err != nil {
-		return errors.Wrap(err, "failed to copy source data to pipe")
-	}
+	stderr := &limitedBuffer{limit: c.maxOutput}
 
-	err = pipe.Close()
+	err = c.transport.Run(ctx, c.wrap(fmt.Sprintf("cat > %s", sink)), file, io.Discard, stderr)
 	if err != nil {
-		return errors.Wrap(err, "failed to close pipe")
+		return errors.Wrapf(err, "failed to copy source data: %s", bytes.TrimSpace(stderr.Bytes()))
 	}
 
-	return session.Wait()
+	return nil
 }
 
-// SecureDownload emulates the 'scp' command by downloaded the file at the provided path to the local machine.
-func (c *Client) SecureDownload(source, sink string) error {
-	fields := log.Fields{
-		"local":  trimPort(c.client.LocalAddr().String()),
-		"remote": trimPort(c.client.RemoteAddr().String()),
-		"source": source,
-		"sink":   sink,
-	}
-
+// SecureDownload emulates the 'scp' command by downloaded the file at the provided path to the local machine, the
+// download is aborted if the context is cancelled.
+func (c *Client) SecureDownload(ctx context.Context, source, sink string) error {
+	fields := log.Fields{"host": c.host, "source": source, "sink": sink}
 	log.WithFields(fields).Debug("Downloading file")
 
-	session, err := c.client.NewSession()
-	if err != nil {
-		return errors.Wrap(err, "failed to create session")
-	}
-	defer session.Close()
+	defer c.record("download", time.Now())
 
-	pipe, err := session.StdoutPipe()
+	file, err := os.Create(sink)
 	if err != nil {
-		return errors.Wrap(err, "failed to get stdout pipe")
+		return errors.Wrap(err, "failed to create sink file")
 	}
+	defer file.Close()
 
-	err = session.Start(fmt.Sprintf("cat %s", source))
-	if err != nil {
-		return errors.Wrap(err, "failed to start session")
-	}
+	stderr := &limitedBuffer{limit: c.maxOutput}
 
-	err = fsutil.WriteToFile(sink, pipe, 0)
+	err = c.transport.Run(ctx, c.wrap(fmt.Sprintf("cat %s", source)), nil, file, stderr)
 	if err != nil {
-		return errors.Wrap(err, "failed to copy to file")
+		return errors.Wrapf(err, "failed to copy to file: %s", bytes.TrimSpace(stderr.Bytes()))
 	}
 
-	return session.Wait()
+	return nil
 }
 
 // InstallPackageAt installs the package at the provided path on the remote machine.
-func (c *Client) InstallPackageAt(path string) error {
-	_, err := c.ExecuteCommand(c.Platform.CommandInstallPackageAt(path))
+func (c *Client) InstallPackageAt(ctx context.Context, path string) error {
+	_, err := c.ExecuteCommandContext(ctx, c.Capabilities.CommandInstallPackageAt(path))
 	return err
 }
 
 // InstallPackages uses the platform specific package manager to install the given package.
-func (c *Client) InstallPackages(packages ...string) error {
-	_, err := c.ExecuteCommand(c.Platform.CommandInstallPackages(packages...))
+func (c *Client) InstallPackages(ctx context.Context, packages ...string) error {
+	_, err := c.ExecuteCommandContext(ctx, c.Capabilities.CommandInstallPackages(packages...))
 	return err
 }
 
 // UninstallPackages uses the platform specific package manager to uninstall the given package.
-func (c *Client) UninstallPackages(packages ...string) error {
-	_, err := c.ExecuteCommand(c.Platform.CommandUninstallPackages(packages...))
+func (c *Client) UninstallPackages(ctx context.Context, packages ...string) error {
+	_, err := c.ExecuteCommandContext(ctx, c.Capabilities.CommandUninstallPackages(packages...))
 	return err
 }
 
@@ -208,12 +222,24 @@ func (c *Client) RemoveFile(path string) error {
 	return err
 }
 
+// CreateDirectory creates the directory (and any missing parents) at the given path on the remote machine.
+func (c *Client) CreateDirectory(path string) error {
+	_, err := c.ExecuteCommand(value.NewCommand("mkdir -p %s", path))
+	return err
+}
+
 // RemoveDirectory removes the directory at the given path on the remote machine.
 func (c *Client) RemoveDirectory(path string) error {
 	_, err := c.ExecuteCommand(value.NewCommand("rm -rf %s", path))
 	return err
 }
 
+// RestoreState restores the machine state snapshotted when the first connection was made to the remote machine.
+func (c *Client) RestoreState() error {
+	_, err := c.ExecuteCommand(c.Capabilities.CommandRestoreState())
+	return err
+}
+
 // Sync runs 'sync' on the remote machine ensuring all dirty package are written to disk.
 func (c *Client) Sync() error {
 	_, err := c.ExecuteCommand(value.NewCommand("sync"))
@@ -222,57 +248,43 @@ func (c *Client) Sync() error {
 
 // FlushCaches sync then flushes the caches on the remote machine; this allows for more consistent benchmark results.
 func (c *Client) FlushCaches() error {
-	_, err := c.ExecuteCommand(value.NewCommand("sync; echo 3 > /proc/sys/vm/drop_caches"))
+	_, err := c.ExecuteCommand(value.CommandFlushCaches())
 	return err
 }
 
 // ExecuteCommand is a wrapper with executes the given command on the remote machine.
+//
+// NOTE: The command isn't cancelled on interrupt, it's used for short lived commands and cleanup (which should always
+// complete); long running commands should use 'ExecuteCommandContext'.
 func (c *Client) ExecuteCommand(command value.Command) ([]byte, error) {
-	return executeCommand(c.client, command.ToString(map[string]string{
-		"PATH": fmt.Sprintf("%s:$PATH", value.CBBinDirectory),
-	}))
+	return c.ExecuteCommandContext(context.Background(), command)
 }
 
-// loginAsRoot will attempt to login as the root user on the remote machine.
-func (c *Client) loginAsRoot() error {
-	// Become root
-	_, err := c.ExecuteCommand("sudo -s")
-	if err != nil {
-		log.Fatalf("failed to become root: %v", err)
-	}
-
-	// Read the authorized_keys file content
-	output, err := c.ExecuteCommand("sudo cat /root/.ssh/authorized_keys")
-	if err != nil {
-		log.Fatalf("failed to read authorized_keys: %v", err)
-	}
-
-	// Find the position of the first occurrence of 'ssh-rsa'
-	index := strings.Index(string(output), "ssh-rsa")
-	if index == -1 {
-		log.Fatalf("ssh-rsa not found in authorized_keys")
-	}
+// ExecuteCommandContext executes the given command on the remote machine, the command is killed if the context is
+// cancelled before it completes.
+func (c *Client) ExecuteCommandContext(ctx context.Context, command value.Command) ([]byte, error) {
+	defer c.record(command.Name(), time.Now())
 
-	// Trim the content to keep only the portion starting from 'ssh-rsa'
-	newContent := output[index:]
+	return executeCommand(ctx, c.transport, c.host, c.wrap(command.ToString(map[string]string{
+		"PATH": fmt.Sprintf("%s:$PATH", c.binDirectory),
+	})), c.maxOutput)
+}
 
-	// Write the new content back to authorized_keys
-	msg := fmt.Sprintf("echo '%s' | sudo tee /root/.ssh/authorized_keys", newContent)
-	_, err = c.ExecuteCommand(value.Command(msg))
-	if err != nil {
-		log.Fatalf("failed to write to authorized_keys: %v", err)
+// wrap runs the given command using non-interactive 'sudo' when we're not running commands as the root user.
+func (c *Client) wrap(command string) string {
+	if !c.sudo {
+		return command
 	}
 
-	// Restart the SSH service
-	_, err = c.ExecuteCommand("sudo systemctl restart sshd.service || sudo systemctl restart ssh.service")
-	if err != nil {
-		log.Fatalf("failed to restart SSH service: %v", err)
-	}
+	return "sudo -n sh -c " + value.ShellQuote(command)
+}
 
-	return err
+// record adds the time since the provided start time to the profile for this host.
+func (c *Client) record(name string, start time.Time) {
+	c.profile.Record(name, time.Since(start))
 }
 
 // Close releases an resources in use by this client.
 func (c *Client) Close() error {
-	return c.client.Close()
+	return c.transport.Close()
 }
@@ -0,0 +1,287 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesl33/cbtools-autobench/transport"
+	"github.com/jamesl33/cbtools-autobench/value"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Transport is a transport which runs commands on a remote machine using ssh, keepalives are sent periodically so that
+// dead connections are detected (and re-established) rather than hanging indefinitely.
+type Transport struct {
+	// mu guards 'client', 'dead' and 'done' which are replaced when reconnecting after the connection is lost.
+	mu     sync.Mutex
+	client *ssh.Client
+	dead   bool
+	done   chan struct{}
+
+	host       string
+	clientConf *ssh.ClientConfig
+	config     *value.SSHConfig
+}
+
+var _ transport.Transport = (*Transport)(nil)
+
+// NewTransport creates a new ssh transport which is connected to the provided host.
+func NewTransport(host string, config *value.SSHConfig) (*Transport, error) {
+	log.WithField("host", host).Info("Establishing ssh connection")
+
+	err := config.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ssh config")
+	}
+
+	signer, err := parsePrivateKey(config.PrivateKey, config.PrivateKeyPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	t := &Transport{
+		host: host,
+		clientConf: &ssh.ClientConfig{
+			User:            config.Username,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: func(_ string, _ net.Addr, _ ssh.PublicKey) error { return nil },
+		},
+		config: config,
+	}
+
+	err = t.dial()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ssh client")
+	}
+
+	return t, nil
+}
+
+// Run implements the 'Transport' interface and runs the given command on the remote machine. When the context is
+// cancelled the remote command is sent 'SIGTERM' and the session is closed, the context's error is returned.
+func (t *Transport) Run(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	client, session, err := t.session()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	fields := log.Fields{"remote": trimPort(client.RemoteAddr().String()), "command": command}
+	log.WithFields(fields).Debug("Executing remote command")
+
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	err = session.Start(command)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+
+	go func() { errs <- session.Wait() }()
+
+	select {
+	case err = <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.WithFields(fields).Warn("Cancelling remote command")
+
+	// NOTE: Not all servers support signals, closing the session will still unblock us even if the command continues
+	_ = session.Signal(ssh.SIGTERM)
+	_ = session.Close()
+
+	<-errs
+
+	return ctx.Err()
+}
+
+// Privileged implements the 'Transport' interface and returns whether we're connected as the root user.
+func (t *Transport) Privileged() bool {
+	return t.config.Username == "root"
+}
+
+// Close implements the 'Transport' interface and closes the connection to the remote machine.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	close(t.done)
+
+	return t.client.Close()
+}
+
+// session opens a new session on the current connection, if the connection was dropped without being detected by the
+// keepalives (e.g. the remote host was rebooted) it's marked as dead and a single attempt is made to reconnect.
+func (t *Transport) session() (*ssh.Client, *ssh.Session, error) {
+	client, err := t.conn()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get connection")
+	}
+
+	session, err := client.NewSession()
+	if err == nil {
+		return client, session, nil
+	}
+
+	log.WithError(err).WithField("host", t.host).Warn("Failed to create session, connection may have been dropped")
+
+	t.markDead(client)
+
+	client, err = t.conn()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get connection")
+	}
+
+	session, err = client.NewSession()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create session")
+	}
+
+	return client, session, nil
+}
+
+// dial establishes a new connection to the remote host and starts sending keepalives. Failed attempts are retried with
+// an exponential backoff until the connect timeout is reached, since freshly booted hosts often aren't accepting
+// connections yet.
+func (t *Transport) dial() error {
+	var (
+		deadline = time.Now().Add(t.config.ConnectTimeoutOrDefault())
+		backoff  = t.config.ConnectBackoffOrDefault()
+		client   *ssh.Client
+		err      error
+	)
+
+	for attempt := 1; ; attempt++ {
+		t.clientConf.Timeout = time.Until(deadline)
+
+		client, err = ssh.Dial("tcp", net.JoinHostPort(t.host, "22"), t.clientConf)
+		if err == nil {
+			break
+		}
+
+		// NOTE: Authentication failures won't resolve themselves, so there's no point in retrying them
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return err
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("timed out after %s connecting to host: %w", t.config.ConnectTimeoutOrDefault(), err)
+		}
+
+		log.WithError(err).WithFields(log.Fields{"host": t.host, "attempt": attempt, "backoff": backoff.String()}).
+			Debug("Failed to connect, retrying")
+
+		time.Sleep(backoff)
+
+		backoff = t.config.NextConnectBackoff(backoff)
+	}
+
+	// Stop sending keepalives on the previous connection, if there was one
+	if t.done != nil {
+		close(t.done)
+	}
+
+	t.client = client
+	t.dead = false
+	t.done = make(chan struct{})
+
+	go t.sendKeepalives(client, t.done)
+
+	return nil
+}
+
+// conn returns the current connection to the remote host, if the connection has been detected as dead we will attempt
+// to reconnect. Note that we don't retry commands which were running when the connection died, since they may not be
+// safe to run again; however, subsequent commands (e.g. monitoring) will resume using the new connection.
+func (t *Transport) conn() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.dead {
+		return t.client, nil
+	}
+
+	log.WithField("host", t.host).Warn("Re-establishing dead ssh connection")
+
+	err := t.dial()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reconnect")
+	}
+
+	return t.client, nil
+}
+
+// sendKeepalives periodically sends keepalives using the given client, if too many consecutive keepalives go
+// unanswered the connection is closed (causing any hanging commands to fail) and marked as dead.
+func (t *Transport) sendKeepalives(client *ssh.Client, done <-chan struct{}) {
+	var (
+		interval = t.config.KeepaliveIntervalOrDefault()
+		ticker   = time.NewTicker(interval)
+		missed   int
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if sendKeepalive(client, interval) == nil {
+			missed = 0
+			continue
+		}
+
+		missed++
+
+		if missed < t.config.KeepaliveMaxMissedOrDefault() {
+			continue
+		}
+
+		log.WithFields(log.Fields{"host": t.host, "missed": missed}).Warn("Detected dead ssh connection")
+
+		t.markDead(client)
+
+		return
+	}
+}
+
+// markDead marks the given connection as dead (if it's still the current connection) and closes it, causing any
+// hanging commands to fail; the connection will be re-established before running the next command.
+func (t *Transport) markDead(client *ssh.Client) {
+	t.mu.Lock()
+	if t.client == client {
+		t.dead = true
+	}
+	t.mu.Unlock()
+
+	client.Close()
+}
@@ -16,9 +16,14 @@ package ssh
 
 import (
 	"bytes"
+	"context"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/jamesl33/cbtools-autobench/transport"
 	"github.com/jamesl33/cbtools-autobench/value"
 
 	"github.com/apex/log"
@@ -29,8 +34,8 @@ import (
 // trimPort returns the given host with the port trimmed. If the provided host does not contain a port, the string will
 // be returned unchanged.
 func trimPort(s string) string {
-	if index := strings.Index(s, ":"); index != 0 {
-		return s[:index]
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return host
 	}
 
 	return s
@@ -39,6 +44,11 @@ func trimPort(s string) string {
 // parsePrivateKey returns a signer which can be used to authenticate ssh connections. If a passphrase is provided, the
 // private key will be decrypted.
 func parsePrivateKey(path, passphrase string) (ssh.Signer, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand private key path")
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read file at '%s'", path)
@@ -51,18 +61,55 @@ func parsePrivateKey(path, passphrase string) (ssh.Signer, error) {
 	return ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
 }
 
-// executeCommand will execute the given command using the provided client and returns the combined output.
-func executeCommand(client *ssh.Client, command string) ([]byte, error) {
-	session, err := client.NewSession()
+// expandHome expands a leading '~' in the given local path to the users home directory, this is done using
+// 'os.UserHomeDir' so that it works when running on Windows/macOS as well as Linux.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return filepath.FromSlash(path), nil
+	}
+
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create session")
+		return "", errors.Wrap(err, "failed to determine home directory")
 	}
-	defer session.Close()
 
-	fields := log.Fields{"remote": trimPort(client.RemoteAddr().String()), "command": command}
-	log.WithFields(fields).Debug("Executing remote command")
+	return filepath.Join(home, filepath.FromSlash(path[1:])), nil
+}
+
+// sendKeepalive sends a single keepalive using the given client, returning an error if it's not answered within the
+// given timeout.
+//
+// NOTE: The remote server will usually reject the request, however, any response indicates the connection is alive.
+func sendKeepalive(client *ssh.Client, timeout time.Duration) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for keepalive response")
+	}
+}
+
+// executeCommand will execute the given command using the provided transport and returns the combined output, at
+// most 'limit' bytes of output are kept.
+func executeCommand(ctx context.Context, t transport.Transport, host, command string, limit int) ([]byte, error) {
+	fields := log.Fields{"host": host, "command": command}
+
+	buffer := &limitedBuffer{limit: limit}
+
+	err := t.Run(ctx, command, nil, buffer, buffer)
 
-	output, err := session.CombinedOutput(command)
+	if buffer.Truncated() {
+		log.WithFields(fields).Warn("Remote command output exceeded the maximum output size and was truncated")
+	}
+
+	output := buffer.Bytes()
 	if err == nil {
 		return output, nil
 	}
@@ -74,18 +121,18 @@ func executeCommand(client *ssh.Client, command string) ([]byte, error) {
 	return nil, err
 }
 
-// determinePlatform uses the provided ssh client to determine which platform it's connected too.
-func determinePlatform(client *ssh.Client) (value.Platform, error) {
+// determinePlatform uses the provided transport to determine which platform it's connected too.
+func determinePlatform(t transport.Transport, host string, limit int) (value.Platform, error) {
 	command := value.NewCommand("cat /etc/os-release | grep '^ID=' | cut -c4-")
 
-	distro, err := executeCommand(client, command.ToString(nil))
+	distro, err := executeCommand(context.Background(), t, host, command.ToString(nil), limit)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to determine distribution")
 	}
 
 	command = value.NewCommand("cat /etc/os-release | grep '^VERSION_ID=' | cut -c13- | rev | cut -c2- | rev")
 
-	release, err := executeCommand(client, command.ToString(nil))
+	release, err := executeCommand(context.Background(), t, host, command.ToString(nil), limit)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to determine version")
 	}
@@ -98,6 +145,8 @@ func determinePlatform(client *ssh.Client) (value.Platform, error) {
 	switch string(distro) {
 	case "ubuntu":
 		return determineUbuntuPlatform(strings.TrimSpace(string(release)))
+	case "debian":
+		return determineDebianPlatform(strings.TrimSpace(string(release)))
 	case "amzn":
 		return determineAmazonLinuxPlatform(strings.TrimSpace(string(release)))
 	}
@@ -105,16 +154,40 @@ func determinePlatform(client *ssh.Client) (value.Platform, error) {
 	return "", errors.Errorf("unsupported distro '%s'", strings.TrimSpace(string(distro)))
 }
 
+// determineCapabilities uses the provided transport to detect the capabilities of the machine it's connected too.
+func determineCapabilities(t transport.Transport, host string, limit int) (*value.Capabilities, error) {
+	output, err := executeCommand(context.Background(), t, host, value.CommandDetectCapabilities().ToString(nil), limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect capabilities")
+	}
+
+	return value.ParseCapabilities(output)
+}
+
 // determineUbuntuPlatform returns the specific platform for the given Ubuntu release.
 func determineUbuntuPlatform(release string) (value.Platform, error) {
 	switch release {
 	case "20.04":
 		return value.PlatformUbuntu20_04, nil
+	case "22.04":
+		return value.PlatformUbuntu22_04, nil
 	}
 
 	return "", errors.Errorf("unsupported ubuntu release '%s'", release)
 }
 
+// determineDebianPlatform returns the specific platform for the given Debian release.
+func determineDebianPlatform(release string) (value.Platform, error) {
+	switch release {
+	case "11":
+		return value.PlatformDebian11, nil
+	case "12":
+		return value.PlatformDebian12, nil
+	}
+
+	return "", errors.Errorf("unsupported debian release '%s'", release)
+}
+
 // determineAmazonLinuxPlatform returns the specific platform for the given Amazon Linux release.
 func determineAmazonLinuxPlatform(release string) (value.Platform, error) {
 	switch release {
@@ -0,0 +1,77 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Docker is a transport which runs commands inside a running container using 'docker exec'.
+type Docker struct {
+	container  string
+	privileged bool
+}
+
+var _ Transport = (*Docker)(nil)
+
+// NewDocker creates a new transport which runs commands in the given container, the container must already be running.
+func NewDocker(container string) (*Docker, error) {
+	docker := &Docker{container: container}
+
+	stdout := &bytes.Buffer{}
+
+	err := docker.Run(context.Background(), "id -u", nil, stdout, io.Discard)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run command in container '%s'", container)
+	}
+
+	docker.privileged = strings.TrimSpace(stdout.String()) == "0"
+
+	return docker, nil
+}
+
+// Run implements the 'Transport' interface and runs the given command in the container.
+func (d *Docker) Run(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	args := []string{"exec"}
+
+	// We should only attach stdin when we have something to pipe, otherwise 'docker' will wait for us to close it
+	if stdin != nil {
+		args = append(args, "-i")
+	}
+
+	cmd := exec.CommandContext(ctx, "docker", append(args, d.container, "sh", "-c", command)...)
+
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd.Run()
+}
+
+// Privileged implements the 'Transport' interface and returns whether the container runs commands as the root user.
+func (d *Docker) Privileged() bool {
+	return d.privileged
+}
+
+// Close implements the 'Transport' interface, there's nothing to release for a docker transport.
+func (d *Docker) Close() error {
+	return nil
+}
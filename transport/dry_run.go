@@ -0,0 +1,52 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+// dryRunArchive is the path returned for any commands which list log archives during a dry run.
+const dryRunArchive = "/tmp/autobench-dry-run.zip"
+
+// NewDryRun creates a fake transport which is scripted with plausible responses for all the commands run by
+// 'cbtools-autobench' that have their output parsed; this allows running full provision/benchmark flows without any
+// machines e.g. to validate configs in CI.
+func NewDryRun() *Fake {
+	return NewFake().
+		On(`grep '\^ID='`, "ubuntu\n").
+		On(`grep '\^VERSION_ID='`, "20.04\n").
+		On(`echo package_manager=apt`, "package_manager=apt\ninit_system=systemd\nselinux=\n").
+		On(`hostname -I`, "127.0.0.1\n").
+		On(`/proc/meminfo`, "1\n1048576\n0\n").
+		On(`/proc/stat`, "cpu  0 0 0 0 0 0 0 0 0 0\n").
+		On(`mkdir /var/lib/cbtools-autobench/lock`, "acquired\n").
+		On(`/proc/loadavg`, "0.00 0.00 0.00 1/100 1\n1\n").
+		On(`du -sb .* | cut -f1`, "0\n").
+		On(`-v mutations=`, "0\n").
+		On(`cbbackupmgr info`, `{"backups":[{"size":0,"buckets":[{"name":"default","size":0,"total_mutations":0}]}]}`).
+		On(`http_code.* http://.*/clusterInit`, "400").
+		On(`/localRandomKey`, `{"ok":true,"key":"dry-run"}`).
+		On(`META\(d\)\.expiration`, `{"status":"success","results":[{"id":"dry-run","expiration":0}]}`).
+		On(`META\(\)\.expiration =`, `{"status":"success","results":[]}`).
+		On(`tombstone::`, `{"status":"success","results":[]}`).
+		On(`/pools/default/buckets/default`, `{"basicStats":{}}`).
+		On(`/pools/default/buckets/[^/ ']+'?$`, `{"basicStats":{}}`).
+		On(`/settings/memcached/global'?$`, `{}`).
+		On(`cbstats .* all`, "ep_queue_size: 0\nep_flusher_todo: 0\nep_dcp_replica_items_remaining: 0\n").
+		On(`/pools/default/tasks`, `[{"type":"rebalance","status":"notRunning"}]`).
+		On(`/pools/default'?$`, `{"nodes":[{"hostname":"127.0.0.1:8091","status":"healthy","clusterMembership":"active"}]}`).
+		On(`/logs'?$`, `{"list":[]}`).
+		On(`https://.*/whoami$`, `{"id":"Administrator","domain":"admin"}`).
+		On(`http://.*/whoami$`, `{"id":"autobench","domain":"external"}`).
+		On(`collect-logs-status .*path :`, dryRunArchive+"\n").
+		On(`ls -t .*\.zip`, dryRunArchive+"\n")
+}
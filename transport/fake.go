@@ -0,0 +1,121 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// FakeResponse is the scripted response returned by a fake transport when a command matches a rule.
+type FakeResponse struct {
+	// Output is written to stdout when the command is run.
+	Output string
+
+	// Err is returned from 'Run', for example to emulate a command exiting with non-zero exit code.
+	Err error
+}
+
+// fakeRule maps commands matching a pattern to a scripted response.
+type fakeRule struct {
+	pattern  *regexp.Regexp
+	response FakeResponse
+}
+
+// Fake is a script-able transport which doesn't run any commands, instead returning the response of the first rule
+// which matches the command (or empty output if none match). This allows the orchestration to be exercised without any
+// machines, for example in unit tests or to validate configs in CI.
+type Fake struct {
+	mu       sync.Mutex
+	rules    []fakeRule
+	commands []string
+}
+
+var _ Transport = (*Fake)(nil)
+
+// NewFake creates a new fake transport with no rules, all commands will succeed and produce no output.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// On adds a rule which returns the given output for any commands matching the given regular expression, rules are
+// checked in the order they're added.
+func (f *Fake) On(pattern, output string) *Fake {
+	return f.OnResponse(pattern, FakeResponse{Output: output})
+}
+
+// OnResponse adds a rule which returns the given response for any commands matching the given regular expression.
+func (f *Fake) OnResponse(pattern string, response FakeResponse) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rules = append(f.rules, fakeRule{pattern: regexp.MustCompile(pattern), response: response})
+
+	return f
+}
+
+// Commands returns all the commands which have been run using this transport, in the order they were run.
+func (f *Fake) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.commands...)
+}
+
+// Run implements the 'Transport' interface, recording the command and returning the scripted response.
+func (f *Fake) Run(ctx context.Context, command string, stdin io.Reader, stdout, _ io.Writer) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if stdin != nil {
+		_, err := io.Copy(io.Discard, stdin)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.commands = append(f.commands, command)
+
+	for _, rule := range f.rules {
+		if !rule.pattern.MatchString(command) {
+			continue
+		}
+
+		_, err := io.WriteString(stdout, rule.response.Output)
+		if err != nil {
+			return err
+		}
+
+		return rule.response.Err
+	}
+
+	return nil
+}
+
+// Privileged implements the 'Transport' interface, the fake transport always acts as the root user.
+func (f *Fake) Privileged() bool {
+	return true
+}
+
+// Close implements the 'Transport' interface, there's nothing to release for a fake transport.
+func (f *Fake) Close() error {
+	return nil
+}
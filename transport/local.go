@@ -0,0 +1,53 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Local is a transport which runs commands on the local machine.
+type Local struct{}
+
+var _ Transport = (*Local)(nil)
+
+// NewLocal creates a new transport which runs commands on the local machine.
+func NewLocal() *Local {
+	return &Local{}
+}
+
+// Run implements the 'Transport' interface and runs the given command using the local shell.
+func (l *Local) Run(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd.Run()
+}
+
+// Privileged implements the 'Transport' interface and returns whether we're running as the root user.
+func (l *Local) Privileged() bool {
+	return os.Geteuid() == 0
+}
+
+// Close implements the 'Transport' interface, there's nothing to release for a local transport.
+func (l *Local) Close() error {
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport abstracts how commands are executed on the machines managed by 'cbtools-autobench', this allows
+// the same node code to manage remote machines (via ssh), the local machine or containers.
+package transport
+
+import (
+	"context"
+	"io"
+)
+
+// Transport is used to execute shell commands on a (possibly remote) machine.
+type Transport interface {
+	// Run runs the given shell command reading stdin from the given reader (which may be nil) and writing the output
+	// to the given writers. A non-nil error is returned if the command exits with a non-zero exit code, the command is
+	// killed (and the context's error returned) if the context is cancelled before it completes.
+	Run(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error
+
+	// Privileged returns a boolean indicating whether commands are run as the root user, when they're not, commands
+	// must be run using 'sudo'.
+	Privileged() bool
+
+	// Close releases any resources in use by the transport.
+	Close() error
+}
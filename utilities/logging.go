@@ -35,22 +35,59 @@ var levels = map[int]string{
 	int(log.FatalLevel): "FATA",
 }
 
+// LoggingFormat represents the format in which log entries are written.
+type LoggingFormat string
+
+const (
+	// LoggingFormatCLI is the human readable format, for example '<timestamp> INFO <message> | <fields>'.
+	LoggingFormatCLI LoggingFormat = "cli"
+
+	// LoggingFormatJSON writes each log entry as a single JSON object on its own line.
+	LoggingFormatJSON LoggingFormat = "json"
+)
+
 // LoggingHandler which implements the apex logging handler interface.
 type LoggingHandler struct {
 	mu     sync.Mutex
 	writer io.Writer
+	format LoggingFormat
+	fields log.Fields
 }
 
-// NewLoggingHandler creates a new LoggingHandler which will log to stdout.
-func NewLoggingHandler() *LoggingHandler {
+// NewLoggingHandler creates a new LoggingHandler which will log to stdout, the provided fields are included in every
+// log entry (e.g. the run id).
+func NewLoggingHandler(fields log.Fields) *LoggingHandler {
+	return NewLoggingHandlerWithOptions(os.Stdout, LoggingFormatCLI, fields)
+}
+
+// NewLoggingHandlerWithOptions creates a new LoggingHandler which will log to the given writer in the given format.
+func NewLoggingHandlerWithOptions(writer io.Writer, format LoggingFormat, fields log.Fields) *LoggingHandler {
 	return &LoggingHandler{
-		writer: os.Stdout,
+		writer: writer,
+		format: format,
+		fields: fields,
 	}
 }
 
 // HandleLog implements the handler interface for the apex logging module.
 func (h *LoggingHandler) HandleLog(e *log.Entry) error {
-	fields, err := json.Marshal(e.Fields)
+	merged := make(log.Fields, len(h.fields)+len(e.Fields))
+
+	for key, value := range h.fields {
+		merged[key] = value
+	}
+
+	for key, value := range e.Fields {
+		merged[key] = value
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+
+	if h.format == LoggingFormatJSON {
+		return h.handleJSON(timestamp, e, merged)
+	}
+
+	fields, err := json.Marshal(merged)
 	if err != nil {
 		return errors.Wrap(err, "failed to marshal fields")
 	}
@@ -58,8 +95,6 @@ func (h *LoggingHandler) HandleLog(e *log.Entry) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	timestamp := time.Now().UTC().Format(time.RFC3339)
-
 	if len(fields) == 0 || string(fields) == "{}" {
 		fmt.Fprintf(h.writer, "%s %s %s\n", timestamp, levels[int(e.Level)], e.Message)
 	} else {
@@ -68,3 +103,28 @@ func (h *LoggingHandler) HandleLog(e *log.Entry) error {
 
 	return nil
 }
+
+// handleJSON writes the given log entry as a single line JSON object.
+func (h *LoggingHandler) handleJSON(timestamp string, e *log.Entry, fields log.Fields) error {
+	entry, err := json.Marshal(struct {
+		Timestamp string     `json:"timestamp"`
+		Level     string     `json:"level"`
+		Message   string     `json:"message"`
+		Fields    log.Fields `json:"fields,omitempty"`
+	}{
+		Timestamp: timestamp,
+		Level:     e.Level.String(),
+		Message:   e.Message,
+		Fields:    fields,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal entry")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(h.writer, "%s\n", entry)
+
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+
+	"github.com/pkg/errors"
+)
+
+// ArchiveRepository is a benchmark repository created by 'cbtools-autobench' in the archive on the backup client.
+type ArchiveRepository struct {
+	Name    string    `json:"name"`
+	RunID   RunID     `json:"run_id"`
+	Size    uint64    `json:"size"`
+	Backups int       `json:"backups"`
+	Latest  time.Time `json:"latest,omitempty"`
+}
+
+// ArchiveRepositories is a wrapper around a slice of repositories which provides a human readable representation.
+type ArchiveRepositories []*ArchiveRepository
+
+// String returns a human readable string representation of the repositories.
+func (a ArchiveRepositories) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Repositories\n| ------------")
+	fmt.Fprintf(writer, "| Repository\t Run\t Backups\t Size\t Latest Backup\t\n")
+
+	for _, repo := range a {
+		latest := "-"
+		if !repo.Latest.IsZero() {
+			latest = repo.Latest.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %d\t %s\t %s\t\n", repo.Name, repo.RunID, repo.Backups, format.Bytes(repo.Size),
+			latest)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// CommandArchiveInfo returns a command which can be run on the remote backup client which will return information about
+// all the repositories in the archive in JSON format.
+func (c *CBMConfig) CommandArchiveInfo() Command {
+	command := fmt.Sprintf("cbbackupmgr info -a %s -j", c.Archive)
+
+	command = c.prefixEnvironment(command)
+	command = c.addCloudArgs(command)
+
+	return NewCommand(command)
+}
+
+// CommandRemoveRepository returns a command which can be run on the remote backup client to remove the given repository
+// from the archive.
+func (c *CBMConfig) CommandRemoveRepository(repository string) Command {
+	command := fmt.Sprintf("cbbackupmgr remove -a %s -r %s", c.Archive, repository)
+
+	command = c.prefixEnvironment(command)
+	command = c.addCloudArgs(command)
+
+	return NewCommand(command)
+}
+
+// ParseArchiveRepositories parses the output of 'CommandArchiveInfo', returning the repositories in the archive which
+// were created by 'cbtools-autobench' using the given base repository name.
+func ParseArchiveRepositories(base string, output []byte) (ArchiveRepositories, error) {
+	type overlay struct {
+		Repos []struct {
+			Name    string `json:"name"`
+			Size    uint64 `json:"size"`
+			Backups []struct {
+				Date time.Time `json:"date"`
+			} `json:"backups"`
+		} `json:"repos"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal archive info")
+	}
+
+	repositories := make(ArchiveRepositories, 0, len(decoded.Repos))
+
+	for _, repo := range decoded.Repos {
+		id, ok := RepositoryRunID(base, repo.Name)
+		if !ok {
+			continue
+		}
+
+		repository := &ArchiveRepository{Name: repo.Name, RunID: id, Size: repo.Size, Backups: len(repo.Backups)}
+
+		for _, backup := range repo.Backups {
+			if backup.Date.After(repository.Latest) {
+				repository.Latest = backup.Date
+			}
+		}
+
+		repositories = append(repositories, repository)
+	}
+
+	return repositories, nil
+}
+
+// RepositoryRunID returns the id of the run which created the given repository (see 'RunID.Namespace'), a boolean
+// indicates whether the repository was created by a run using the given base repository name.
+//
+// NOTE: When benchmarking multiple environments, the repository is also namespaced using the environment name e.g.
+// '<base>-<environment>-<run id>'.
+func RepositoryRunID(base, repository string) (RunID, bool) {
+	rest := strings.TrimPrefix(repository, base+"-")
+
+	// Run ids are always formatted UUIDs
+	if rest == repository || len(rest) < 36 {
+		return "", false
+	}
+
+	environment, id := rest[:len(rest)-36], rest[len(rest)-36:]
+
+	if strings.Count(id, "-") != 4 || (environment != "" && !strings.HasSuffix(environment, "-")) {
+		return "", false
+	}
+
+	return RunID(id), true
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AWSAddressType is the address of an EC2 instance which will be used as the host.
+type AWSAddressType string
+
+const (
+	// AWSAddressPrivateIP uses the private IP address of the instance, this is the default.
+	AWSAddressPrivateIP AWSAddressType = "private-ip"
+
+	// AWSAddressPublicIP uses the public IP address of the instance.
+	AWSAddressPublicIP AWSAddressType = "public-ip"
+
+	// AWSAddressPrivateDNS uses the private DNS name of the instance.
+	AWSAddressPrivateDNS AWSAddressType = "private-dns"
+
+	// AWSAddressPublicDNS uses the public DNS name of the instance.
+	AWSAddressPublicDNS AWSAddressType = "public-dns"
+)
+
+// AWSConfig describes how the 'aws' CLI (which must be installed/configured locally) is run, it's embedded in the
+// config of each feature which uses it.
+type AWSConfig struct {
+	// Region/Profile are passed to the 'aws' CLI, when empty the CLI defaults are used.
+	Region  string `json:"-" yaml:"region,omitempty"`
+	Profile string `json:"-" yaml:"profile,omitempty"`
+}
+
+// AWSInventory describes a query for running EC2 instances which is expanded into a node for each instance; this keeps
+// configs stable whilst the instances themselves churn.
+type AWSInventory struct {
+	// Tags are the tags (and their values) an instance must have to be included e.g. 'Project: autobench'.
+	Tags map[string]string `json:"-" yaml:"tags,omitempty"`
+
+	AWSConfig `yaml:",inline"`
+
+	// Address is the address of the instance which will be used as the host, defaults to the private IP address.
+	Address AWSAddressType `json:"-" yaml:"address,omitempty"`
+}
+
+// AddressOrDefault returns the address type that should be used as the host.
+func (a *AWSInventory) AddressOrDefault() AWSAddressType {
+	if a.Address == "" {
+		return AWSAddressPrivateIP
+	}
+
+	return a.Address
+}
+
+// Args returns the arguments which should be passed to the 'aws' CLI to find the instances.
+func (a *AWSInventory) Args() []string {
+	args := []string{
+		"ec2", "describe-instances", "--output", "json", "--query", "Reservations[].Instances[]",
+		"--filters", "Name=instance-state-name,Values=running",
+	}
+
+	// Sort the tags so that the command is stable, this makes it easier to debug
+	keys := make([]string, 0, len(a.Tags))
+	for key := range a.Tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("Name=tag:%s,Values=%s", key, a.Tags[key]))
+	}
+
+	return append(args, awsArgs(a.AWSConfig)...)
+}
+
+// AWSLaunchConfig describes how EC2 instances are launched when 'cbtools-autobench' is responsible for creating them
+// e.g. when sweeping backup client instance types.
+type AWSLaunchConfig struct {
+	// ImageID is the AMI the instances are launched from, it must be a platform supported by 'cbtools-autobench'.
+	ImageID string `json:"-" yaml:"image_id,omitempty"`
+
+	// KeyName is the name of the EC2 key pair which will be authorized, it must match the private key in the ssh config.
+	KeyName string `json:"-" yaml:"key_name,omitempty"`
+
+	// SubnetID/SecurityGroupIDs determine the network the instances are launched into, the instances must be able to
+	// reach the cluster (when empty the defaults for the account are used).
+	SubnetID         string   `json:"-" yaml:"subnet_id,omitempty"`
+	SecurityGroupIDs []string `json:"-" yaml:"security_group_ids,omitempty"`
+
+	AWSConfig `yaml:",inline"`
+
+	// Address is the address of the instance which will be used as the host, defaults to the private IP address.
+	Address AWSAddressType `json:"-" yaml:"address,omitempty"`
+
+	// Volumes are additional EBS volumes created (and deleted on termination) for each instance e.g. to hold the archive.
+	Volumes EBSVolumes `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+}
+
+// AddressOrDefault returns the address type that should be used as the host.
+func (a *AWSLaunchConfig) AddressOrDefault() AWSAddressType {
+	if a.Address == "" {
+		return AWSAddressPrivateIP
+	}
+
+	return a.Address
+}
+
+// ArgsRun returns the arguments which should be passed to the 'aws' CLI to launch an instance of the given type, the
+// instance is tagged with the run id so that it may be attributed to a specific run.
+func (a *AWSLaunchConfig) ArgsRun(instanceType string, run RunID) []string {
+	args := []string{
+		"ec2", "run-instances", "--output", "text", "--query", "Instances[0].InstanceId",
+		"--image-id", a.ImageID, "--instance-type", instanceType, "--count", "1",
+		"--tag-specifications", fmt.Sprintf("ResourceType=instance,Tags=[{Key=autobench-run,Value=%s}]", run),
+	}
+
+	if a.KeyName != "" {
+		args = append(args, "--key-name", a.KeyName)
+	}
+
+	if a.SubnetID != "" {
+		args = append(args, "--subnet-id", a.SubnetID)
+	}
+
+	if len(a.SecurityGroupIDs) != 0 {
+		args = append(append(args, "--security-group-ids"), a.SecurityGroupIDs...)
+	}
+
+	if len(a.Volumes) != 0 {
+		args = append(args, "--block-device-mappings", a.Volumes.BlockDeviceMappings())
+	}
+
+	return append(args, awsArgs(a.AWSConfig)...)
+}
+
+// ArgsWait returns the arguments which should be passed to the 'aws' CLI to wait until the given instance is running
+// and has passed its status checks.
+func (a *AWSLaunchConfig) ArgsWait(id string) []string {
+	return append([]string{"ec2", "wait", "instance-status-ok", "--instance-ids", id}, awsArgs(a.AWSConfig)...)
+}
+
+// ArgsDescribe returns the arguments which should be passed to the 'aws' CLI to describe the given instance.
+func (a *AWSLaunchConfig) ArgsDescribe(id string) []string {
+	return append([]string{
+		"ec2", "describe-instances", "--output", "json", "--query", "Reservations[].Instances[]", "--instance-ids", id,
+	}, awsArgs(a.AWSConfig)...)
+}
+
+// ArgsTerminate returns the arguments which should be passed to the 'aws' CLI to terminate the given instance.
+func (a *AWSLaunchConfig) ArgsTerminate(id string) []string {
+	return append([]string{"ec2", "terminate-instances", "--instance-ids", id}, awsArgs(a.AWSConfig)...)
+}
+
+// awsArgs returns the global arguments which should be passed to the 'aws' CLI for the given config.
+func awsArgs(config AWSConfig) []string {
+	var args []string
+
+	if config.Region != "" {
+		args = append(args, "--region", config.Region)
+	}
+
+	if config.Profile != "" {
+		args = append(args, "--profile", config.Profile)
+	}
+
+	return args
+}
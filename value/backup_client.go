@@ -28,24 +28,73 @@ type BackupClientBlueprint struct {
 	// Host is the hostname/address of the node
 	Host string `yaml:"host,omitempty"`
 
+	// AWS is a query for the EC2 instance used as the backup client, it must match exactly one instance; used instead
+	// of 'Host'.
+	AWS *AWSInventory `yaml:"aws,omitempty"`
+
+	// Transport describes how commands are executed on the node, by default ssh is used.
+	Transport *TransportBlueprint `yaml:"transport,omitempty"`
+
+	// Platform overrides the platform detected using '/etc/os-release', accepts the same values as the cluster nodes.
+	Platform Platform `yaml:"platform,omitempty"`
+
+	// InstanceStore formats/mounts an NVMe instance store device during provisioning, for example so that it may be used
+	// as the archive.
+	InstanceStore *InstanceStoreBlueprint `yaml:"instance_store,omitempty"`
+
+	// TieredStorage builds a tiered device (e.g. NVMe cache over HDD/EBS) during provisioning, for example so that it
+	// may be used as the archive.
+	TieredStorage *TieredStorageBlueprint `yaml:"tiered_storage,omitempty"`
+
+	// EBS modifies the type/performance of the EBS volumes attached to the backup client during provisioning.
+	EBS *EBSBlueprint `yaml:"ebs,omitempty"`
+
+	// Readiness controls how long we wait for Couchbase Server to become ready after installation.
+	Readiness *ReadinessConfig `yaml:"readiness,omitempty"`
+
+	// RemoteTmpDir is the directory on the backup client which contains the per-run temporary directory, accepts the
+	// same values as the cluster nodes.
+	RemoteTmpDir string `yaml:"remote_tmp_dir,omitempty"`
+
 	// PackagePath is the path to a local package. This package will be secure copied to the backup client and installed
 	// instead of downloading the build from latest builds.
 	//
 	// NOTE: No validation takes place to ensure the package is valid for the current distribution; that's on you...
 	PackagePath string `yaml:"package_path,omitempty"`
 
+	// PackageType overrides the package type determined using the extension of 'PackagePath' i.e. deb/rpm/tar.
+	PackageType PackageType `yaml:"package_type,omitempty"`
+
+	// InstallDirectory is the directory that a 'tar' package will be extracted into.
+	InstallDirectory string `yaml:"install_directory,omitempty"`
+
 	// CBMPath
 	CBMPath string `yaml:"cbm_path,omitempty"`
+
+	// Image is the id of the image (created using 'bake') the backup client was launched from, provisioning skips
+	// installing the dependencies/package when the image contains the same package.
+	Image string `yaml:"image,omitempty"`
+}
+
+// Package returns the package that will be installed on the backup client.
+func (b *BackupClientBlueprint) Package() *Package {
+	return NewPackage(b.PackagePath, b.PackageType, b.InstallDirectory)
 }
 
 // MarshalJSON returns a JSON representation of the backup blueprint which will be displayed in the report.
 func (b *BackupClientBlueprint) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Host    string `json:"host,omitempty"`
-		Version string `json:"version,omitempty"`
+		Host    string        `json:"host,omitempty"`
+		Version string        `json:"version,omitempty"`
+		Edition Edition       `json:"edition,omitempty"`
+		EBS     *EBSBlueprint `json:"ebs,omitempty"`
+		Image   string        `json:"image,omitempty"`
 	}{
 		Host:    b.Host,
 		Version: extractBuild(b.PackagePath),
+		Edition: extractEdition(b.PackagePath),
+		EBS:     b.EBS,
+		Image:   b.Image,
 	})
 }
 
@@ -57,10 +106,14 @@ func (b *BackupClientBlueprint) String() string {
 	)
 
 	fmt.Fprintln(buffer, "| Backup Client\n| -------------")
-	fmt.Fprintf(writer, "| Version\t Host\t\n")
-	fmt.Fprintf(writer, "| %s\t %s\t\n", extractBuild(b.PackagePath), b.Host)
+	fmt.Fprintf(writer, "| Version\t Edition\t Host\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", extractBuild(b.PackagePath), extractEdition(b.PackagePath), b.Host)
 
 	_ = writer.Flush()
 
+	if b.EBS != nil && len(b.EBS.Volumes) != 0 {
+		fmt.Fprintf(buffer, "| Volumes:\n%s\n", b.EBS.Volumes)
+	}
+
 	return strings.TrimSpace(buffer.String())
 }
@@ -0,0 +1,76 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+// BakedMarker is the file written to machines prepared by the 'bake' sub-command, it contains the name of the package
+// which was installed so that provisioning machines launched from the image can skip reinstalling it.
+const BakedMarker = "/etc/cbtools-autobench-baked"
+
+// BakeConfig describes the image created from a machine by the 'bake' sub-command.
+type BakeConfig struct {
+	// Name is the name of the image, it must be unique within the account/region.
+	Name string
+
+	AWSConfig
+
+	// NoReboot skips rebooting the instance before creating the image, the file system may be inconsistent.
+	NoReboot bool
+}
+
+// ArgsCreateImage returns the arguments which should be passed to the 'aws' CLI to create an image from the given
+// instance.
+func (b *BakeConfig) ArgsCreateImage(instanceID string) []string {
+	args := []string{
+		"ec2", "create-image", "--output", "text", "--query", "ImageId", "--instance-id", instanceID, "--name", b.Name,
+		"--description", "Created by 'cbtools-autobench bake'",
+	}
+
+	if b.NoReboot {
+		args = append(args, "--no-reboot")
+	}
+
+	return append(args, awsArgs(b.AWSConfig)...)
+}
+
+// ArgsWait returns the arguments which should be passed to the 'aws' CLI to wait until the given image is available.
+func (b *BakeConfig) ArgsWait(imageID string) []string {
+	return append([]string{"ec2", "wait", "image-available", "--image-ids", imageID}, awsArgs(b.AWSConfig)...)
+}
+
+// CommandBakedPackage returns a command which outputs the name of the package installed when the machine was baked, the
+// output is empty if the machine wasn't baked.
+func CommandBakedPackage() Command {
+	return NewCommand("cat %s 2>/dev/null || true", BakedMarker)
+}
+
+// Name returns the name of the package i.e. the base of its path.
+func (p *Package) Name() string {
+	return LocalBase(p.Path)
+}
+
+// CommandMarkBaked returns a command which records that this package was installed on the machine by 'bake'.
+func (p *Package) CommandMarkBaked() Command {
+	return NewCommand("echo '%s' > %s", p.Name(), BakedMarker)
+}
+
+// CommandResetState returns a command which removes the state generated when Couchbase Server was first started, this
+// ensures that each machine launched from a baked image is initialized as a new node (e.g. with a unique uuid).
+//
+// NOTE: Couchbase Server must be stopped before running this command.
+func (p *Package) CommandResetState() Command {
+	directory := RemoteJoin(p.InstallDirectory(), "var", "lib", "couchbase")
+
+	return NewCommand(`rm -rf %[1]s/config/config.dat %[1]s/ip %[1]s/ip_start %[1]s/data/* %[1]s/logs/*`, directory)
+}
@@ -25,6 +25,84 @@ type BenchmarkConfig struct {
 
 	// CBMConfig is the configuration which will be passed to 'cbbackupmgr' when run on the remote machine.
 	CBMConfig *CBMConfig `json:"cbbackupmgr_config,omitempty" yaml:"cbbackupmgr_config,omitempty"`
+
+	// Incremental enables creating/timing an incremental backup after each backup benchmark iteration, it's used to
+	// determine how frequently backups may be taken.
+	Incremental bool `json:"incremental,omitempty" yaml:"incremental,omitempty"`
+
+	// Churn enables mutating the dataset (using a uniform or hot-spot distribution) between each benchmarked backup and
+	// its incremental backup, it's used to measure how well repeated mutations are deduplicated; requires 'Incremental'.
+	Churn *ChurnConfig `json:"churn,omitempty" yaml:"churn,omitempty"`
+
+	// Tombstones enables leaving tombstones (which are optionally aged/purged) between each benchmarked backup and its
+	// incremental backup, it's used to benchmark how tombstones are handled by incremental backups; requires
+	// 'Incremental'.
+	Tombstones *TombstoneConfig `json:"tombstones,omitempty" yaml:"tombstones,omitempty"`
+
+	// CompactBeforeBackup forces a manual compaction of the benchmarking bucket before each backup benchmark, once the
+	// disk write queue has been drained; the backup will then read from fully compacted data files.
+	CompactBeforeBackup bool `json:"compact_before_backup,omitempty" yaml:"compact_before_backup,omitempty"`
+
+	// Queries are N1QL queries run before the backup is created and after each restore benchmark, the number of results
+	// are compared to validate that the restored data is queryable.
+	Queries []*QueryBlueprint `json:"queries,omitempty" yaml:"queries,omitempty"`
+
+	// ExpiryValidation enables comparing the expiry (TTL) of a sample of documents after each restore benchmark against
+	// their expiry before the backup was created.
+	ExpiryValidation *ExpiryValidationConfig `json:"expiry_validation,omitempty" yaml:"expiry_validation,omitempty"`
+
+	// LiveWorkload is an optional workload which will be run against the cluster before, during and after each backup
+	// benchmark to capture the impact on front-end latency.
+	LiveWorkload *LiveWorkloadConfig `json:"live_workload,omitempty" yaml:"live_workload,omitempty"`
+
+	// Chaos enables randomly injecting faults into the cluster during each benchmarked backup/restore.
+	Chaos *ChaosConfig `json:"chaos,omitempty" yaml:"chaos,omitempty"`
+
+	// Upgrade describes the versions the cluster/backup client will be upgraded to by the 'upgrade' benchmark.
+	Upgrade *UpgradeConfig `json:"upgrade,omitempty" yaml:"upgrade,omitempty"`
+
+	// Compatibility describes the versions of 'cbbackupmgr' used by the 'compatibility' benchmark.
+	Compatibility *CompatibilityConfig `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+
+	// MultiRestore describes the number of buckets restored concurrently by the 'multi-restore' benchmark.
+	MultiRestore *MultiRestoreConfig `json:"multi_restore,omitempty" yaml:"multi_restore,omitempty"`
+
+	// ClientSweep describes the backup client instance types benchmarked by the 'sweep' benchmark.
+	ClientSweep *ClientSweepConfig `json:"client_sweep,omitempty" yaml:"client_sweep,omitempty"`
+
+	// ThreadsSweep describes the values of '--threads' benchmarked by the 'threads' benchmark.
+	ThreadsSweep *ThreadsSweepConfig `json:"threads_sweep,omitempty" yaml:"threads_sweep,omitempty"`
+
+	// Matrix describes the combinations of threads/document sizes/document counts benchmarked by the 'matrix'
+	// benchmark.
+	Matrix *MatrixConfig `json:"matrix,omitempty" yaml:"matrix,omitempty"`
+
+	// StorageSweep describes the archive storage backends benchmarked by the 'storage' benchmark.
+	StorageSweep *StorageSweepConfig `json:"storage_sweep,omitempty" yaml:"storage_sweep,omitempty"`
+
+	// Bisect describes the builds searched by the 'bisect' benchmark.
+	Bisect *BisectConfig `json:"bisect,omitempty" yaml:"bisect,omitempty"`
+
+	// Soak describes how long the 'soak' benchmark repeats backups for.
+	Soak *SoakConfig `json:"soak,omitempty" yaml:"soak,omitempty"`
+
+	// Extrapolation describes the dataset sizes which the backup/restore durations are extrapolated to in the report.
+	Extrapolation *ExtrapolationConfig `json:"extrapolation,omitempty" yaml:"extrapolation,omitempty"`
+
+	// Sampling enables sampling the throughput of each backup/restore over time.
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+
+	// DiskIO enables attributing the IO of each backup/restore to the devices backing the data paths/archive.
+	DiskIO *DiskIOConfig `json:"disk_io,omitempty" yaml:"disk_io,omitempty"`
+
+	// Credits enables flagging results affected by the exhaustion of CPU/EBS credits on burstable resources.
+	Credits *CreditsConfig `json:"-" yaml:"credits,omitempty"`
+
+	// CloudWatch enables fetching EBS/network metrics for the benchmark window, they're reported per iteration.
+	CloudWatch *CloudWatchConfig `json:"-" yaml:"cloudwatch,omitempty"`
+
+	// Thresholds are the limits the average backup/restore performance must stay within (if configured).
+	Thresholds *ThresholdConfig `json:"thresholds,omitempty" yaml:"thresholds,omitempty"`
 }
 
 // BenchmarkResults is a wrapper around a slice of benchmark results which provides some utility functions.
@@ -32,6 +110,9 @@ type BenchmarkResults []*BenchmarkResult
 
 // BenchmarkResult encapsulates a single benchmark results.
 type BenchmarkResult struct {
+	// Start is when the benchmark was started.
+	Start time.Time
+
 	// Duration is the how long the benchmark took to complete (this does not include setup/cleanup).
 	Duration time.Duration
 
@@ -43,15 +124,81 @@ type BenchmarkResult struct {
 	// ADS is the actual size of the data that was backed up. This will be used to calculate how much data is
 	// transferred for backup/restore benchmarks.
 	ADS uint64
+
+	// ArchiveSize is the size of the archive once the backup completed (if it's stored locally).
+	ArchiveSize uint64
+
+	// Workload is the front-end latency/resource usage captured whilst running the live workload (if enabled).
+	Workload *WorkloadResult
+
+	// Throughput is the progress of the backup/restore sampled over time (if enabled).
+	Throughput *Throughput
+
+	// Incremental is the result of the incremental backup created after the benchmarked backup (if enabled).
+	Incremental *BenchmarkResult
+
+	// Churn is the mutations made before the incremental backup was created (if enabled).
+	Churn *ChurnResult
+
+	// Tombstones is the tombstones left before the incremental backup was created (if enabled).
+	Tombstones *TombstoneResult
+
+	// Source is the backup which was created, and then restored, when benchmarking restores.
+	Source *BenchmarkResult
+
+	// IndexBuild is how long it took for the GSI indexes in the dataset to be built after the restore completed.
+	IndexBuild time.Duration
+
+	// SearchBuild/EventingDeploy are how long it took for the FTS indexes to be built and the eventing functions to be
+	// deployed after the restore completed.
+	SearchBuild    time.Duration
+	EventingDeploy time.Duration
+
+	// Queries are the results of the N1QL queries run after the restore completed (if any were configured).
+	Queries QueryChecks
+
+	// Buckets is the size/number of items of each bucket in the backup, it's only populated for clusters with multiple
+	// buckets.
+	Buckets BucketResults
+
+	// Expiry is the result of validating the expiry of the sampled documents after the restore completed (if enabled).
+	Expiry *ExpiryCheck
+
+	// Chaos is the faults injected during the backup/restore (if enabled).
+	Chaos *ChaosResult
+
+	// DiskIO is the IO attributed to each block device during the backup/restore (if enabled).
+	DiskIO []*DiskIO
+
+	// Memory is the memory usage of 'cbbackupmgr' sampled during the backup/restore.
+	Memory *MemoryUsage
+
+	// Resources is the number of open file descriptors/threads of 'cbbackupmgr' sampled during the backup/restore.
+	Resources *ResourceUsage
+}
+
+// Recovery returns how long it took for every service to become operational after the restore completed, the services
+// recover concurrently so this is the slowest of them.
+func (b *BenchmarkResult) Recovery() time.Duration {
+	recovery := b.IndexBuild
+
+	for _, d := range []time.Duration{b.SearchBuild, b.EventingDeploy} {
+		if d > recovery {
+			recovery = d
+		}
+	}
+
+	return recovery
 }
 
-// AvgTransferRateGDS returns the average transfer rate of all the benchmarks calculated using the generated data size.
-func (b *BenchmarkResult) AvgTransferRateGDS(blueprint *DataBlueprint) uint64 {
+// AvgTransferRateGDS returns the average transfer rate of all the benchmarks calculated using the given generated data
+// size.
+func (b *BenchmarkResult) AvgTransferRateGDS(gds uint64) uint64 {
 	if b.Duration < time.Second {
-		return uint64(blueprint.Size * blueprint.Items)
+		return gds
 	}
 
-	return uint64(blueprint.Size*blueprint.Items) / uint64(b.Duration.Seconds())
+	return gds / uint64(b.Duration.Seconds())
 }
 
 // AvgTransferRateADS returns the average transfer rate of all the benchmarks calculated using the actual data size.
@@ -62,3 +209,12 @@ func (b *BenchmarkResult) AvgTransferRateADS() uint64 {
 
 	return b.ADS / uint64(b.Duration.Seconds())
 }
+
+// AvgItemRate returns the average number of items transferred per second.
+func (b *BenchmarkResult) AvgItemRate() uint64 {
+	if b.Duration < time.Second {
+		return b.AIN
+	}
+
+	return b.AIN / uint64(b.Duration.Seconds())
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "time"
+
+// BenchmarkReportVersion is the version of the 'BenchmarkReport' schema, it's incremented whenever a field is renamed
+// or removed so that pipelines parsing the report may detect breaking changes.
+const BenchmarkReportVersion = 1
+
+// BenchmarkReport is a machine readable report of a benchmark run, unlike the human readable report the field names
+// are stable and all the values are raw (i.e. bytes/seconds) so that CI pipelines may parse and archive them.
+type BenchmarkReport struct {
+	Version     int                      `json:"version"`
+	RunID       RunID                    `json:"run_id"`
+	Environment string                   `json:"environment,omitempty"`
+	Benchmark   string                   `json:"benchmark"`
+	Status      RunStatus                `json:"status"`
+	Storage     string                   `json:"storage,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+	CI          *CIMetadata              `json:"ci,omitempty"`
+	Versions    *BenchmarkVersions       `json:"versions,omitempty"`
+	Topology    *BenchmarkTopology       `json:"topology,omitempty"`
+	Dataset     *BenchmarkDataset        `json:"dataset,omitempty"`
+	Results     []*BenchmarkReportResult `json:"results"`
+
+	// Backends are the results for each of the archive storage backends benchmarked by the 'storage' benchmark.
+	Backends []*BenchmarkBackend `json:"storage_sweep,omitempty"`
+}
+
+// BenchmarkVersions are the versions of Couchbase Server/'cbbackupmgr' which were benchmarked.
+type BenchmarkVersions struct {
+	Server      string  `json:"server"`
+	Cbbackupmgr string  `json:"cbbackupmgr"`
+	Edition     Edition `json:"edition,omitempty"`
+}
+
+// NewBenchmarkVersions returns the versions installed using the given blueprints.
+func NewBenchmarkVersions(cluster *ClusterBlueprint, client *BackupClientBlueprint) *BenchmarkVersions {
+	return &BenchmarkVersions{
+		Server:      extractBuild(cluster.PackagePath),
+		Cbbackupmgr: extractBuild(client.PackagePath),
+		Edition:     extractEdition(cluster.PackagePath),
+	}
+}
+
+// BenchmarkNode is a single node in the benchmarked cluster.
+type BenchmarkNode struct {
+	Host        string   `json:"host"`
+	Services    Services `json:"services,omitempty"`
+	ServerGroup string   `json:"server_group,omitempty"`
+}
+
+// BenchmarkTopology is the topology of the benchmarked cluster/backup client.
+type BenchmarkTopology struct {
+	Nodes        []*BenchmarkNode `json:"nodes"`
+	BackupClient string           `json:"backup_client"`
+}
+
+// NewBenchmarkTopology returns the topology described by the given blueprints.
+func NewBenchmarkTopology(cluster *ClusterBlueprint, client *BackupClientBlueprint) *BenchmarkTopology {
+	topology := &BenchmarkTopology{Nodes: make([]*BenchmarkNode, 0, len(cluster.Nodes)), BackupClient: client.Host}
+
+	for i, node := range cluster.Nodes {
+		topology.Nodes = append(topology.Nodes, &BenchmarkNode{
+			Host:        node.Name(),
+			Services:    node.ServicesOrDefault(i == 0),
+			ServerGroup: node.ServerGroup,
+		})
+	}
+
+	return topology
+}
+
+// BenchmarkDataset is the dataset in the benchmarking bucket once the benchmarks completed.
+type BenchmarkDataset struct {
+	Items    uint64 `json:"items"`
+	DiskUsed uint64 `json:"disk_used"`
+}
+
+// NewBenchmarkDataset returns the dataset described by the given stats, nil is returned if there aren't any.
+func NewBenchmarkDataset(stats *Stats) *BenchmarkDataset {
+	if stats == nil {
+		return nil
+	}
+
+	return &BenchmarkDataset{Items: stats.ItemCount, DiskUsed: stats.DiskUsed}
+}
+
+// BenchmarkBackend is the machine readable form of the results for a single archive storage backend.
+type BenchmarkBackend struct {
+	Backend string                   `json:"backend"`
+	Backup  []*BenchmarkReportResult `json:"backup"`
+	Restore []*BenchmarkReportResult `json:"restore,omitempty"`
+}
+
+// BenchmarkReportResult is the machine readable form of a single benchmark result, durations are in seconds and sizes
+// are in bytes.
+type BenchmarkReportResult struct {
+	Iteration    int                    `json:"iteration,omitempty"`
+	Start        time.Time              `json:"start"`
+	Duration     float64                `json:"duration_seconds"`
+	Items        uint64                 `json:"items"`
+	Size         uint64                 `json:"size"`
+	TransferRate uint64                 `json:"transfer_rate"`
+	ArchiveSize  uint64                 `json:"archive_size,omitempty"`
+	Recovery     float64                `json:"recovery_seconds,omitempty"`
+	Memory       *MemoryUsage           `json:"memory,omitempty"`
+	Resources    *ResourceUsage         `json:"resources,omitempty"`
+	Incremental  *BenchmarkReportResult `json:"incremental,omitempty"`
+	Source       *BenchmarkReportResult `json:"source,omitempty"`
+}
+
+// NewBenchmarkReportResult returns the machine readable form of the given result, nil is returned if there isn't one.
+func NewBenchmarkReportResult(iteration int, result *BenchmarkResult) *BenchmarkReportResult {
+	if result == nil {
+		return nil
+	}
+
+	return &BenchmarkReportResult{
+		Iteration:    iteration,
+		Start:        result.Start,
+		Duration:     result.Duration.Seconds(),
+		Items:        result.AIN,
+		Size:         result.ADS,
+		TransferRate: result.AvgTransferRateADS(),
+		ArchiveSize:  result.ArchiveSize,
+		Recovery:     result.Recovery().Seconds(),
+		Memory:       result.Memory,
+		Resources:    result.Resources,
+		Incremental:  NewBenchmarkReportResult(0, result.Incremental),
+		Source:       NewBenchmarkReportResult(0, result.Source),
+	}
+}
+
+// Report returns the machine readable form of each of the results, iterations are numbered from one.
+func (b BenchmarkResults) Report() []*BenchmarkReportResult {
+	results := make([]*BenchmarkReportResult, 0, len(b))
+
+	for index, result := range b {
+		results = append(results, NewBenchmarkReportResult(index+1, result))
+	}
+
+	return results
+}
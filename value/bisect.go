@@ -0,0 +1,257 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// DefaultBisectIterations is the default number of iterations benchmarked for each build when bisecting, a single
+// iteration keeps each step fast.
+const DefaultBisectIterations = 1
+
+// BisectVerdict is the outcome of benchmarking a single build whilst bisecting.
+type BisectVerdict string
+
+const (
+	// BisectVerdictGood indicates the build performed like the good build.
+	BisectVerdictGood BisectVerdict = "good"
+
+	// BisectVerdictBad indicates the build performed like the bad build i.e. it contains the regression.
+	BisectVerdictBad BisectVerdict = "bad"
+
+	// BisectVerdictSkipped indicates the build couldn't be benchmarked e.g. it's missing from the build archive.
+	BisectVerdictSkipped BisectVerdict = "skipped"
+)
+
+// BisectConfig describes the builds searched by the 'bisect' benchmark, which finds the build of 'cbbackupmgr' that
+// introduced a performance regression by benchmarking intermediate builds between a good and bad build.
+type BisectConfig struct {
+	// Version is the version of the builds e.g. '7.2.0', it's substituted for '{version}' in the archive.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// Good/Bad are the build numbers of the builds without/with the regression.
+	Good int `json:"good" yaml:"good"`
+	Bad  int `json:"bad" yaml:"bad"`
+
+	// Archive is the URL of each build, where '{build}' and '{version}' are substituted; it's downloaded using 'curl'
+	// on the backup client so 'file://' URLs may be used for builds which are already on the backup client.
+	Archive string `json:"archive,omitempty" yaml:"archive,omitempty"`
+
+	// Benchmark is the benchmark run for each build, either 'backup' (default) or 'restore'.
+	Benchmark string `json:"benchmark,omitempty" yaml:"benchmark,omitempty"`
+
+	// Iterations is the number of iterations benchmarked for each build, overriding the benchmark iterations.
+	Iterations int `json:"iterations,omitempty" yaml:"iterations,omitempty"`
+
+	// Threshold is the percentage slower than the good build at which a build is considered bad, by default a build is
+	// bad when it's closer to the bad build than the good build.
+	Threshold int `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+}
+
+// Validate returns an error if the bisect config is incomplete.
+func (b *BisectConfig) Validate() error {
+	if b == nil || b.Archive == "" {
+		return errors.New("a build archive must be provided")
+	}
+
+	if !strings.Contains(b.Archive, "{build}") {
+		return errors.New("the build archive must contain '{build}'")
+	}
+
+	if b.Good <= 0 || b.Bad <= 0 || b.Good >= b.Bad {
+		return errors.New("the good build must be before the bad build")
+	}
+
+	pkg := b.Package(b.Good)
+	if pkg.Type == "" {
+		return fmt.Errorf("unable to determine package type for '%s'", pkg.Path)
+	}
+
+	switch b.BenchmarkOrDefault() {
+	case "backup", "restore":
+	default:
+		return fmt.Errorf("unsupported bisect benchmark '%s'", b.Benchmark)
+	}
+
+	return nil
+}
+
+// BenchmarkOrDefault returns the benchmark run for each build.
+func (b *BisectConfig) BenchmarkOrDefault() string {
+	if b.Benchmark == "" {
+		return "backup"
+	}
+
+	return b.Benchmark
+}
+
+// IterationsOrDefault returns the number of iterations benchmarked for each build.
+func (b *BisectConfig) IterationsOrDefault() int {
+	if b.Iterations == 0 {
+		return DefaultBisectIterations
+	}
+
+	return b.Iterations
+}
+
+// URL returns the URL of the given build in the build archive.
+func (b *BisectConfig) URL(build int) string {
+	return strings.NewReplacer("{build}", strconv.Itoa(build), "{version}", b.Version).Replace(b.Archive)
+}
+
+// Package returns the package for the given build, the path of the package is its URL.
+func (b *BisectConfig) Package(build int) *Package {
+	return NewPackage(b.URL(build), "", "")
+}
+
+// CommandDownload returns a command which downloads the given build from the build archive to the given path.
+func (b *BisectConfig) CommandDownload(build int, remotePath string) Command {
+	return NewCommand("curl -fsSL -o %s %s", remotePath, ShellQuote(b.URL(build)))
+}
+
+// Regressed returns the duration beyond which a build is considered bad, given the durations of the good/bad builds.
+func (b *BisectConfig) Regressed(good, bad time.Duration) time.Duration {
+	if b.Threshold == 0 {
+		return (good + bad) / 2
+	}
+
+	return good + good*time.Duration(b.Threshold)/100
+}
+
+// BisectStep is the result of benchmarking a single build whilst bisecting.
+type BisectStep struct {
+	Build       int           `json:"build"`
+	Verdict     BisectVerdict `json:"verdict"`
+	AvgDuration time.Duration `json:"avg_duration,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// BisectResult is the result of the 'bisect' benchmark.
+type BisectResult struct {
+	Config *BisectConfig `json:"config"`
+
+	// Steps are the builds benchmarked in the order they were benchmarked, starting with the good/bad builds.
+	Steps []*BisectStep `json:"steps"`
+
+	// Threshold is the duration beyond which a build was considered bad.
+	Threshold time.Duration `json:"threshold,omitempty"`
+
+	// LastGood/FirstBad are the builds either side of the regression, when they're adjacent (ignoring skipped builds)
+	// the first bad build introduced the regression.
+	LastGood int `json:"last_good"`
+	FirstBad int `json:"first_bad"`
+
+	// Complete indicates that there are no more builds to benchmark between the last good/first bad builds, it's false
+	// when the bisect was interrupted.
+	Complete bool `json:"complete"`
+}
+
+// NewBisectResult returns a result where the regression is somewhere between the good/bad builds from the given config.
+func NewBisectResult(config *BisectConfig) *BisectResult {
+	return &BisectResult{Config: config, LastGood: config.Good, FirstBad: config.Bad}
+}
+
+// Record adds the given step, narrowing the range of builds which may have introduced the regression.
+func (b *BisectResult) Record(step *BisectStep) {
+	b.Steps = append(b.Steps, step)
+
+	switch {
+	case step.Verdict == BisectVerdictGood && step.Build > b.LastGood && step.Build < b.FirstBad:
+		b.LastGood = step.Build
+	case step.Verdict == BisectVerdictBad && step.Build > b.LastGood && step.Build < b.FirstBad:
+		b.FirstBad = step.Build
+	}
+}
+
+// Next returns the next build to benchmark i.e. the untested build closest to the middle of the range which may have
+// introduced the regression, false is returned once there are no builds left to benchmark.
+func (b *BisectResult) Next() (int, bool) {
+	tested := make(map[int]struct{}, len(b.Steps))
+	for _, step := range b.Steps {
+		tested[step.Build] = struct{}{}
+	}
+
+	middle := b.LastGood + (b.FirstBad-b.LastGood)/2
+
+	// Search outwards from the middle, skipped builds are avoided in the same way as 'git bisect skip'
+	for offset := 0; middle-offset > b.LastGood || middle+offset < b.FirstBad; offset++ {
+		for _, build := range []int{middle - offset, middle + offset} {
+			if _, ok := tested[build]; !ok && build > b.LastGood && build < b.FirstBad {
+				return build, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// skipped returns the builds between the last good/first bad builds which couldn't be benchmarked.
+func (b *BisectResult) skipped() []string {
+	var skipped []string
+
+	for _, step := range b.Steps {
+		if step.Verdict == BisectVerdictSkipped && step.Build > b.LastGood && step.Build < b.FirstBad {
+			skipped = append(skipped, strconv.Itoa(step.Build))
+		}
+	}
+
+	return skipped
+}
+
+// String returns a string representation of the bisect result which will be output in the report.
+func (b *BisectResult) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Bisect\n| ------")
+	fmt.Fprintf(buffer, "| Benchmark: %s, Threshold: %s\n", b.Config.BenchmarkOrDefault(), format.Duration(b.Threshold))
+	fmt.Fprintf(writer, "| Step\t Build\t Avg Duration\t Verdict\t Error\t\n")
+
+	for idx, step := range b.Steps {
+		duration := "-"
+		if step.AvgDuration != 0 {
+			duration = format.Duration(step.AvgDuration)
+		}
+
+		fmt.Fprintf(writer, "| %d\t %d\t %s\t %s\t %s\t\n", idx+1, step.Build, duration, step.Verdict, step.Error)
+	}
+
+	_ = writer.Flush()
+
+	switch skipped := b.skipped(); {
+	case !b.Complete:
+		fmt.Fprintf(buffer, "\nIncomplete: the regression was introduced after build %d and by build %d", b.LastGood,
+			b.FirstBad)
+	case len(skipped) != 0:
+		fmt.Fprintf(buffer, "\nThe regression was introduced after build %d and by build %d, builds %s couldn't be "+
+			"benchmarked", b.LastGood, b.FirstBad, strings.Join(skipped, ", "))
+	default:
+		fmt.Fprintf(buffer, "\nThe regression was introduced by build %d (the last good build is %d)", b.FirstBad,
+			b.LastGood)
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -14,9 +14,47 @@
 
 package value
 
+import "github.com/pkg/errors"
+
 // Blueprint is an abstraction representing a cluster/backup client setup which will be configured by the 'provision'
 // sub-command.
 type Blueprint struct {
+	// Name identifies the environment in the comparative report, only used when defining multiple environments.
+	Name string `yaml:"name,omitempty"`
+
 	Cluster      *ClusterBlueprint      `yaml:"cluster,omitempty"`
 	BackupClient *BackupClientBlueprint `yaml:"backup_client,omitempty"`
+
+	// Environments is a list of independent cluster/backup client pairs (used instead of 'Cluster'/'BackupClient'), the
+	// same benchmark is run against each environment concurrently and the results are merged into a comparative report.
+	Environments []*Blueprint `yaml:"environments,omitempty"`
+}
+
+// Expand expands any host patterns/files and topology presets in the blueprint into node blueprints.
+func (b *Blueprint) Expand() error {
+	if b.Cluster == nil {
+		return nil
+	}
+
+	err := b.Cluster.ExpandHosts()
+	if err != nil {
+		return errors.Wrap(err, "failed to expand hosts")
+	}
+
+	return b.Cluster.ExpandTopology()
+}
+
+// DryRun replaces the transport for every node in the blueprint with the dry run transport.
+func (b *Blueprint) DryRun() {
+	dryRun := &TransportBlueprint{Type: TransportTypeDryRun}
+
+	if b.Cluster != nil {
+		for _, node := range b.Cluster.Nodes {
+			node.Transport = dryRun
+		}
+	}
+
+	if b.BackupClient != nil {
+		b.BackupClient.Transport = dryRun
+	}
 }
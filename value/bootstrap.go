@@ -0,0 +1,87 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BootstrapFlow is how an uninitialized cluster is initialized, newer versions of Couchbase Server set the
+// administrator credentials along with the node settings in a single call on first boot.
+type BootstrapFlow string
+
+const (
+	// BootstrapFlowAuto detects the flow supported by the installed version of Couchbase Server, this is the default.
+	BootstrapFlowAuto BootstrapFlow = "auto"
+
+	// BootstrapFlowLegacy initializes each node using 'node-init' (which is unauthenticated before the cluster is
+	// initialized) then sets the administrator credentials using 'cluster-init'.
+	BootstrapFlowLegacy BootstrapFlow = "legacy"
+
+	// BootstrapFlowClusterInit initializes the first node using the '/clusterInit' REST endpoint, which sets the
+	// administrator credentials at the same time as the node settings so the node is never left half initialized; the
+	// remaining nodes are initialized using 'node-init' before they're added.
+	BootstrapFlowClusterInit BootstrapFlow = "cluster-init"
+)
+
+// OrDefault returns the bootstrap flow, or the default if one wasn't provided.
+func (b BootstrapFlow) OrDefault() BootstrapFlow {
+	if b == "" {
+		return BootstrapFlowAuto
+	}
+
+	return b
+}
+
+// Validate returns an error if the bootstrap flow is unknown.
+func (b BootstrapFlow) Validate() error {
+	switch b.OrDefault() {
+	case BootstrapFlowAuto, BootstrapFlowLegacy, BootstrapFlowClusterInit:
+		return nil
+	}
+
+	return fmt.Errorf("unknown bootstrap flow '%s'", b)
+}
+
+// CommandProbeClusterInit returns a command which outputs the HTTP status code returned by the '/clusterInit' REST
+// endpoint of the (uninitialized) node at the given address when no parameters are supplied, see 'ParseBootstrapFlow'.
+func CommandProbeClusterInit(host string) Command {
+	return NewCommand(`curl -s -g -o /dev/null -w '%%{http_code}' -X POST http://%s/clusterInit`, host)
+}
+
+// ParseBootstrapFlow parses the output of 'CommandProbeClusterInit', returning the flow supported by the node.
+// Versions without the endpoint return a 404, whilst those with it reject the request since it's missing the required
+// parameters.
+func ParseBootstrapFlow(output []byte) (BootstrapFlow, error) {
+	switch code := strings.TrimSpace(string(output)); code {
+	case "404":
+		return BootstrapFlowLegacy, nil
+	case "200", "400":
+		return BootstrapFlowClusterInit, nil
+	default:
+		return "", fmt.Errorf("unexpected status code '%s' from '/clusterInit'", code)
+	}
+}
+
+// IndexerStorageMode returns the value accepted by the '/clusterInit' REST endpoint for the given
+// '--index-storage-setting' value (see 'IndexStorageSetting').
+func IndexerStorageMode(setting string) string {
+	if setting == "memopt" {
+		return "memory_optimized"
+	}
+
+	return "plasma"
+}
@@ -19,10 +19,22 @@ import (
 	"fmt"
 	"strconv"
 	"text/tabwriter"
+
+	"github.com/pkg/errors"
 )
 
+// DefaultBucketName is the name of the benchmarking bucket.
+const DefaultBucketName = "default"
+
 // BucketBlueprint represents the configration for a bucket that will be created by the 'provision' sub-command.
+//
+// NOTE: The name, quota and replicas are only required for additional buckets, the benchmarking bucket is always named
+// 'default' and uses the memory which isn't used by the additional buckets by default.
 type BucketBlueprint struct {
+	Name              string         `json:"name,omitempty" yaml:"name,omitempty"`
+	Quota             int            `json:"quota,omitempty" yaml:"quota,omitempty"`
+	Replicas          int            `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	StorageBackend    string         `json:"storage_backend,omitempty" yaml:"storage_backend,omitempty"`
 	VBuckets          uint16         `json:"vbuckets,omitempty" yaml:"vbuckets,omitempty"`
 	Type              string         `json:"type,omitempty" yaml:"type,omitempty"`
 	EvictionPolicy    string         `json:"eviction_policy,omitempty" yaml:"eviction_policy,omitempty"`
@@ -33,6 +45,54 @@ type BucketBlueprint struct {
 	Data              *DataBlueprint `json:"data,omitempty" yaml:"data,omitempty"`
 }
 
+// NameOrDefault returns the name of the bucket.
+func (b *BucketBlueprint) NameOrDefault() string {
+	if b.Name == "" {
+		return DefaultBucketName
+	}
+
+	return b.Name
+}
+
+// Validate returns an error if the bucket blueprint is invalid, additional buckets must be named and have a quota.
+func (b *BucketBlueprint) Validate(additional bool) error {
+	if b.Quota < 0 {
+		return errors.New("bucket quota must not be negative")
+	}
+
+	if b.Replicas < 0 || b.Replicas > 3 {
+		return errors.New("bucket replicas must be between 0 and 3")
+	}
+
+	switch b.StorageBackend {
+	case "", "couchstore", "magma":
+	default:
+		return fmt.Errorf("unsupported storage backend '%s'", b.StorageBackend)
+	}
+
+	if !additional {
+		if b.NameOrDefault() != DefaultBucketName {
+			return fmt.Errorf("the benchmarking bucket must be named '%s'", DefaultBucketName)
+		}
+
+		return nil
+	}
+
+	if b.Name == "" || b.Name == DefaultBucketName {
+		return fmt.Errorf("additional buckets must have a name other than '%s'", DefaultBucketName)
+	}
+
+	if b.Quota == 0 {
+		return fmt.Errorf("additional bucket '%s' must have a quota", b.Name)
+	}
+
+	if b.Data != nil && b.Data.DataLoader != "" && b.Data.DataLoader != CBM {
+		return fmt.Errorf("additional bucket '%s' may only be loaded using '%s'", b.Name, CBM)
+	}
+
+	return nil
+}
+
 // String returns a string representation of the blueprint which will be output in the report.
 func (b *BucketBlueprint) String() string {
 	var (
@@ -55,17 +115,30 @@ func (b *BucketBlueprint) String() string {
 		evictionPolicy = b.EvictionPolicy
 	}
 
+	quota := "default"
+	if b.Quota != 0 {
+		quota = fmt.Sprintf("%d MiB", b.Quota)
+	}
+
+	storageBackend := "default"
+	if b.StorageBackend != "" {
+		storageBackend = b.StorageBackend
+	}
+
 	pitrGranularity, pitrMaxHistoryAge := b.stringifyPiTRSettings()
 
 	fmt.Fprintln(buffer, "| Bucket\n| ------")
-	fmt.Fprintf(writer, "| vBuckets\t Type\t Eviction Policy\t PiTR Enabled\t PiTR Granularity\t PiTR Max History "+
-		"Age\t Compact\t\n")
-	fmt.Fprintf(writer, "| %s\t %s\t %s\t %t\t %s\t %s\t %t\t\n", vbuckets, bucketType, evictionPolicy, b.PiTREnabled,
-		pitrGranularity, pitrMaxHistoryAge, b.Compact)
+	fmt.Fprintf(writer, "| Name\t vBuckets\t Type\t Eviction Policy\t Quota\t Replicas\t Storage Backend\t "+
+		"PiTR Enabled\t PiTR Granularity\t PiTR Max History Age\t Compact\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t %d\t %s\t %t\t %s\t %s\t %t\t\n", b.NameOrDefault(), vbuckets,
+		bucketType, evictionPolicy, quota, b.Replicas, storageBackend, b.PiTREnabled, pitrGranularity,
+		pitrMaxHistoryAge, b.Compact)
 
 	_ = writer.Flush()
 
-	fmt.Fprintf(buffer, "\n%s", b.Data)
+	if b.Data != nil {
+		fmt.Fprintf(buffer, "\n%s", b.Data)
+	}
 
 	return buffer.String()
 }
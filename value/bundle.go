@@ -0,0 +1,84 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BundleConfigFile is the (redacted) resolved config in a run bundle, which is used by the reproduce script.
+const BundleConfigFile = "config.yaml"
+
+// BundleVersions are the versions of Couchbase Server/'cbbackupmgr' used by an environment.
+type BundleVersions struct {
+	Environment string `json:"environment,omitempty"`
+	*BenchmarkVersions
+}
+
+// BundleSeed is the seed used to inject faults during a single benchmark iteration, setting 'chaos.seed' to the seed
+// replays the same faults.
+type BundleSeed struct {
+	Environment string `json:"environment,omitempty"`
+	Iteration   int    `json:"iteration"`
+	Seed        int64  `json:"seed"`
+}
+
+// ReproduceCommand returns the given command line (as recorded in the run manifest) using the given config, the
+// operator side flags which only applied to the original run (e.g. '--yes') are removed.
+func ReproduceCommand(command, config string) string {
+	var (
+		args      = strings.Fields(command)
+		reproduce = make([]string, 0, len(args))
+	)
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-c" || arg == "--config":
+			i++
+		case strings.HasPrefix(arg, "-c=") || strings.HasPrefix(arg, "--config="):
+		case arg == "-y" || arg == "--yes":
+		default:
+			reproduce = append(reproduce, arg)
+		}
+	}
+
+	return strings.Join(append(reproduce, "-c", config), " ")
+}
+
+// ReproduceScript returns a shell script which reproduces the run with the given id using the bundled config, the
+// cluster is provisioned (and the dataset loaded) before the recorded command is run.
+//
+// NOTE: The secrets in the bundled config are redacted, they must be provided before running the script.
+func ReproduceScript(id RunID, command string) string {
+	script := fmt.Sprintf(`#!/bin/sh
+# Reproduces run '%s' using the bundled config.
+#
+# NOTE: Any redacted secrets (and hosts which aren't available) must be replaced in '%s' first.
+set -e
+cd "$(dirname "$0")"
+`, id, BundleConfigFile)
+
+	if command == "" {
+		return script + "\n# NOTE: The command run by the original run wasn't recorded\n" +
+			fmt.Sprintf("cbtools-autobench provision -c %s\n", BundleConfigFile)
+	}
+
+	if fields := strings.Fields(command); len(fields) == 0 || fields[0] != "provision" {
+		script += fmt.Sprintf("cbtools-autobench provision -c %s\n", BundleConfigFile)
+	}
+
+	return script + fmt.Sprintf("cbtools-autobench %s\n", ReproduceCommand(command, BundleConfigFile))
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageManager is the package manager used to install/uninstall packages on a remote machine.
+type PackageManager string
+
+const (
+	// PackageManagerAPT is the package manager used by Debian based distributions e.g. Ubuntu.
+	PackageManagerAPT PackageManager = "apt"
+
+	// PackageManagerYUM is the package manager used by Red Hat based distributions e.g. Amazon Linux.
+	PackageManagerYUM PackageManager = "yum"
+)
+
+// InitSystem is the init system used to manage services on a remote machine.
+type InitSystem string
+
+const (
+	// InitSystemSystemd indicates that services are managed using 'systemctl'.
+	InitSystemSystemd InitSystem = "systemd"
+
+	// InitSystemSysV indicates that services are managed using 'service', this is used for anything other than systemd
+	// e.g. containers where the first process isn't an init system.
+	InitSystemSysV InitSystem = "sysvinit"
+)
+
+// FirewallTool is the tool managing an active firewall on a remote machine.
+type FirewallTool string
+
+const (
+	// FirewallToolNone indicates that there's no active firewall.
+	FirewallToolNone FirewallTool = ""
+
+	// FirewallToolFirewalld indicates that the firewall is managed by 'firewalld'.
+	FirewallToolFirewalld FirewallTool = "firewalld"
+
+	// FirewallToolUFW indicates that the firewall is managed by 'ufw'.
+	FirewallToolUFW FirewallTool = "ufw"
+)
+
+// SELinuxState is the state of SELinux on a remote machine, it's empty when SELinux isn't installed.
+type SELinuxState string
+
+// SELinuxEnforcing indicates that SELinux is enforcing its policy, which may deny access to non-default paths.
+const SELinuxEnforcing SELinuxState = "enforcing"
+
+// Capabilities is the set of capabilities detected on a remote machine, these are consulted when provisioning rather
+// than making assumptions based on the distribution.
+type Capabilities struct {
+	PackageManager PackageManager `json:"package_manager"`
+	InitSystem     InitSystem     `json:"init_system"`
+	Firewall       FirewallTool   `json:"firewall,omitempty"`
+	SELinux        SELinuxState   `json:"selinux,omitempty"`
+	AppArmor       bool           `json:"apparmor,omitempty"`
+}
+
+// CommandDetectCapabilities returns a command which outputs the capabilities of the remote machine as 'key=value'
+// pairs, one per line.
+func CommandDetectCapabilities() Command {
+	return NewCommand(`(command -v apt-get > /dev/null && echo package_manager=apt); \
+		(command -v yum > /dev/null && echo package_manager=yum); \
+		echo init_system=$(cat /proc/1/comm); \
+		(systemctl is-active --quiet firewalld 2> /dev/null && echo firewall=firewalld); \
+		(ufw status 2> /dev/null | grep -q 'Status: active' && echo firewall=ufw); \
+		echo selinux=$(getenforce 2> /dev/null | tr '[:upper:]' '[:lower:]'); \
+		echo apparmor=$(cat /sys/module/apparmor/parameters/enabled 2> /dev/null)`)
+}
+
+// ParseCapabilities parses the output of the command returned by 'CommandDetectCapabilities', when multiple values are
+// detected for the same capability the first is used.
+func ParseCapabilities(output []byte) (*Capabilities, error) {
+	detected := make(map[string]string)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if _, seen := detected[key]; !ok || seen {
+			continue
+		}
+
+		detected[key] = value
+	}
+
+	capabilities := &Capabilities{
+		PackageManager: PackageManager(detected["package_manager"]),
+		InitSystem:     InitSystemSysV,
+		Firewall:       FirewallTool(detected["firewall"]),
+		SELinux:        SELinuxState(detected["selinux"]),
+		AppArmor:       detected["apparmor"] == "Y",
+	}
+
+	switch capabilities.PackageManager {
+	case PackageManagerAPT, PackageManagerYUM:
+	default:
+		return nil, fmt.Errorf("unsupported package manager '%s'", capabilities.PackageManager)
+	}
+
+	if detected["init_system"] == string(InitSystemSystemd) {
+		capabilities.InitSystem = InitSystemSystemd
+	}
+
+	return capabilities, nil
+}
+
+// PackageExtension returns the extension of the packages installed by the package manager.
+func (c *Capabilities) PackageExtension() string {
+	switch c.PackageManager {
+	case PackageManagerAPT:
+		return "deb"
+	case PackageManagerYUM:
+		return "rpm"
+	}
+
+	panic(fmt.Sprintf("unsupported package manager '%s'", c.PackageManager))
+}
+
+// CommandInstallPackageAt returns a command which can be used to install the package at the provided path.
+func (c *Capabilities) CommandInstallPackageAt(path string) Command {
+	switch c.PackageManager {
+	case PackageManagerAPT:
+		return NewCommand("dpkg -i %s", path)
+	case PackageManagerYUM:
+		return NewCommand("yum install -y %s", path)
+	}
+
+	panic(fmt.Sprintf("unsupported package manager '%s'", c.PackageManager))
+}
+
+// CommandInstallPackages returns a command which can be used to installed the provided list of packages by name.
+func (c *Capabilities) CommandInstallPackages(packages ...string) Command {
+	switch c.PackageManager {
+	case PackageManagerAPT:
+		return NewCommand("apt update && apt install -y %s", strings.Join(packages, " "))
+	case PackageManagerYUM:
+		return NewCommand("yum update -y && yum install -y %s", strings.Join(packages, " "))
+	}
+
+	panic(fmt.Sprintf("unsupported package manager '%s'", c.PackageManager))
+}
+
+// CommandUninstallPackages returns a command which can be used to uninstall the provided list of package by name.
+func (c *Capabilities) CommandUninstallPackages(packages ...string) Command {
+	switch c.PackageManager {
+	case PackageManagerAPT:
+		return NewCommand("dpkg --purge %s", strings.Join(packages, " "))
+	case PackageManagerYUM:
+		return NewCommand("yum autoremove -y %s", strings.Join(packages, " "))
+	}
+
+	panic(fmt.Sprintf("unsupported package manager '%s'", c.PackageManager))
+}
+
+// CommandListPackages returns a command which lists the names of all the installed packages, one per line.
+func (c *Capabilities) CommandListPackages() Command {
+	switch c.PackageManager {
+	case PackageManagerAPT:
+		return NewCommand(`dpkg-query -W -f="\${Package}\n"`)
+	case PackageManagerYUM:
+		return NewCommand(`rpm -qa --qf "%%{NAME}\n"`)
+	}
+
+	panic(fmt.Sprintf("unsupported package manager '%s'", c.PackageManager))
+}
+
+// CommandEnableService returns a command which enables/starts the given service.
+//
+// NOTE: When not using systemd, the service is only started since enabling services isn't portable.
+func (c *Capabilities) CommandEnableService(name string) Command {
+	if c.InitSystem == InitSystemSystemd {
+		return NewCommand("systemctl enable --now %s", name)
+	}
+
+	return NewCommand("service %s start", name)
+}
+
+// CommandDisableService returns a command which disables/stops the given service.
+//
+// NOTE: When not using systemd, the service is only stopped since disabling services isn't portable.
+func (c *Capabilities) CommandDisableService(name string) Command {
+	if c.InitSystem == InitSystemSystemd {
+		return NewCommand("systemctl disable --now %s", name)
+	}
+
+	return NewCommand("service %s stop", name)
+}
+
+// CommandRestartService returns a command which restarts the given service.
+func (c *Capabilities) CommandRestartService(name string) Command {
+	if c.InitSystem == InitSystemSystemd {
+		return NewCommand("systemctl restart %s", name)
+	}
+
+	return NewCommand("service %s restart", name)
+}
+
+// CommandStopService returns a command which stops the given service.
+func (c *Capabilities) CommandStopService(name string) Command {
+	if c.InitSystem == InitSystemSystemd {
+		return NewCommand("systemctl stop %s", name)
+	}
+
+	return NewCommand("service %s stop", name)
+}
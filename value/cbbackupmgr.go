@@ -79,9 +79,28 @@ type CBMConfig struct {
 	// PiTR indicates whether the backup repository should be configured for Point-In-Time backups.
 	PiTR bool `json:"pitr,omitempty" yaml:"pitr,omitempty"`
 
+	// Placement controls which CPUs/NUMA nodes the benchmarked backup/restore will be run on.
+	Placement *PlacementConfig `json:"placement,omitempty" yaml:"placement,omitempty"`
+
 	// Blackhole indicates whether the benchmarks should actually backup any data or just pull it from the cluster and
 	// then discard it immediately.
 	Blackhole bool `json:"blackhole,omitempty" yaml:"blackhole,omitempty"`
+
+	// LogLevel is the value passed to '--log-level', by default the flag isn't supplied.
+	LogLevel string `json:"log_level,omitempty" yaml:"log_level,omitempty"`
+
+	// AutoCreateBuckets indicates whether the restore benchmark should delete the bucket before each iteration and
+	// restore using '--auto-create-buckets', rather than restoring into the flushed (pre-created) bucket. This includes
+	// the bucket creation/warmup time in the results, as it would be when recovering from a disaster.
+	AutoCreateBuckets bool `json:"auto_create_buckets,omitempty" yaml:"auto_create_buckets,omitempty"`
+
+	// ClientCert is the client certificate which will be used to authenticate with the cluster (over TLS) rather than
+	// the cluster credentials.
+	ClientCert *ClientCertConfig `json:"client_cert,omitempty" yaml:"client_cert,omitempty"`
+
+	// version is the version of 'cbbackupmgr' the commands are built for, see 'ForVersion'. When unknown, the commands
+	// are built for the latest version.
+	version *Version
 }
 
 // String returns a human readable string representation of the config which will be displayed in the report.
@@ -96,30 +115,38 @@ func (c *CBMConfig) String() string {
 		staging = c.ObjStagingDirectory
 	}
 
-	storage := "default"
-	if c.Storage != "" {
-		storage = c.Storage
-	}
+	storage := c.StorageOrDefault()
 
 	threads := "auto"
 	if c.Threads != 0 {
 		threads = strconv.Itoa(c.Threads)
 	}
 
+	logLevel := "default"
+	if c.LogLevel != "" {
+		logLevel = c.LogLevel
+	}
+
 	fmt.Fprintln(buffer, "| CBM\n| ----")
 	fmt.Fprintf(writer, "| Archive\t Repository\t Staging Directory\t Storage\t Threads\t PiTR\t "+
-		"Blackhole\t\n")
-	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t %t\t %t\t\n",
+		"Blackhole\t Log Level\t Auto-Create Buckets\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t %t\t %t\t %s\t %t\t\n",
 		c.Archive,
 		c.Repository,
 		staging,
 		storage,
 		threads,
 		c.PiTR,
-		c.Blackhole)
+		c.Blackhole,
+		logLevel,
+		c.AutoCreateBuckets)
 
 	_ = writer.Flush()
 
+	if c.Placement.Enabled() {
+		fmt.Fprintf(buffer, "\n%s\n", c.Placement)
+	}
+
 	if len(c.EnvVars) != 0 {
 		fmt.Fprintf(buffer, "\n%s", c.EnvVars)
 	}
@@ -136,25 +163,27 @@ func (c *CBMConfig) CommandConfig() Command {
 	command = c.addCloudArgs(command)
 	command = c.addEncryptionArgs(command, true)
 	command = c.addPointInTimeFlag(command)
+	command = c.addLogLevel(command)
 
-	fmt.Printf("command: %s\n", command)
 	return NewCommand(command)
 }
 
 // CommandBackup returns a command which may be run on the remote backup client to perform a backup.
-func (c *CBMConfig) CommandBackup(host string, ignoreBlackhole bool) Command {
+func (c *CBMConfig) CommandBackup(host string, credentials *Credentials, ignoreBlackhole bool) Command {
 	command := fmt.Sprintf(
-		`cbbackupmgr backup -a %s -r %s -c %s -u Administrator -p asdasd --no-progress-bar`,
+		`cbbackupmgr backup -a %s -r %s %s --no-progress-bar`,
 		c.Archive,
 		c.Repository,
-		host,
+		c.clusterArgs(host, credentials),
 	)
 
+	command = c.Placement.prefix(command)
 	command = c.prefixEnvironment(command)
 	command = c.addCloudArgs(command)
 	command = c.addEncryptionArgs(command, false)
 	command = c.addStorage(command)
 	command = c.addThreads(command)
+	command = c.addLogLevel(command)
 
 	// When we're performing restore benchmarks we actually need to create a backup so we should ignore the blackhole
 	// configuration.
@@ -162,26 +191,45 @@ func (c *CBMConfig) CommandBackup(host string, ignoreBlackhole bool) Command {
 		command = c.addBlackhole(command)
 	}
 
-	fmt.Println("command: ", command)
 	return NewCommand(command)
 }
 
 // CommandRestore returns a command which can be run on the remote backup client to perform a restore.
-func (c *CBMConfig) CommandRestore(host string) Command {
+func (c *CBMConfig) CommandRestore(host string, credentials *Credentials) Command {
+	return NewCommand(c.addAutoCreateBuckets(c.commandRestore(host, credentials)))
+}
+
+// CommandRestoreInto returns a command which can be run on the remote backup client to restore the benchmarking bucket
+// into the given bucket.
+func (c *CBMConfig) CommandRestoreInto(host string, credentials *Credentials, bucket string) Command {
+	command := c.commandRestore(host, credentials)
+
+	// There's no bucket to map to when restoring to blackhole
+	if !c.Blackhole {
+		command += fmt.Sprintf(" --map-data default=%s", bucket)
+	}
+
+	return NewCommand(command)
+}
+
+// commandRestore returns the restore command shared by 'CommandRestore' and 'CommandRestoreInto'.
+func (c *CBMConfig) commandRestore(host string, credentials *Credentials) string {
 	command := fmt.Sprintf(
-		`cbbackupmgr restore -a %s -r %s -c %s -u Administrator -p asdasd --no-progress-bar`,
+		`cbbackupmgr restore -a %s -r %s %s --no-progress-bar`,
 		c.Archive,
 		c.Repository,
-		host,
+		c.clusterArgs(host, credentials),
 	)
 
+	command = c.Placement.prefix(command)
 	command = c.prefixEnvironment(command)
 	command = c.addCloudArgs(command)
 	command = c.addEncryptionArgs(command, false)
 	command = c.addThreads(command)
 	command = c.addBlackhole(command)
+	command = c.addLogLevel(command)
 
-	return NewCommand(command)
+	return command
 }
 
 // CommandCollectLogs returns a command which can be run on the remote backup client to collect the 'cbbackupmgr' logs.
@@ -194,6 +242,23 @@ func (c *CBMConfig) CommandCollectLogs() Command {
 	return NewCommand(command)
 }
 
+// LogsDirectory returns the path to the directory on the remote backup client which 'cbbackupmgr' writes its logs to.
+func (c *CBMConfig) LogsDirectory() string {
+	return RemoteJoin(c.LocalArchive(), "logs")
+}
+
+// CommandArchiveLogs returns a command which can be run on the remote backup client to archive the 'cbbackupmgr' logs
+// directory into a gzipped tarball at the given path.
+func (c *CBMConfig) CommandArchiveLogs(sink string) Command {
+	return NewCommand("tar -czf %s -C %s logs", sink, c.LocalArchive())
+}
+
+// CommandArchiveSize returns a command which outputs the size in bytes of the archive on the backup client, when using
+// cloud storage this is the size of the staging directory.
+func (c *CBMConfig) CommandArchiveSize() Command {
+	return NewCommand("du -sb %s | cut -f1", c.LocalArchive())
+}
+
 // CommandRemove returns a command which can be run on the remote backup client to remove all the backups from start to
 // end.
 func (c *CBMConfig) CommandRemove(start, end string) Command {
@@ -222,6 +287,16 @@ func (c *CBMConfig) CommandInfo() Command {
 	return NewCommand(command)
 }
 
+// LocalArchive returns the local directory used by 'cbbackupmgr' on the backup client, when using cloud storage this is
+// the staging directory.
+func (c *CBMConfig) LocalArchive() string {
+	if c.ObjStagingDirectory != "" {
+		return c.ObjStagingDirectory
+	}
+
+	return c.Archive
+}
+
 // prefixEnvironment with prefix the given command with the current 'cbbackupmgr' environment variables.
 func (c *CBMConfig) prefixEnvironment(command string) string {
 	if len(c.EnvVars) == 0 {
@@ -236,6 +311,26 @@ func (c *CBMConfig) prefixEnvironment(command string) string {
 	return env + command
 }
 
+// clusterArgs returns the arguments which tell 'cbbackupmgr' which cluster to connect to and how to authenticate, using
+// the client certificate (and therefore TLS) when one is configured.
+func (c *CBMConfig) clusterArgs(host string, credentials *Credentials) string {
+	if c.ClientCert == nil {
+		return fmt.Sprintf("-c %s %s", host, credentials.Args())
+	}
+
+	return fmt.Sprintf("-c %s %s", TLSConnectionString(host), c.ClientCert.Args())
+}
+
+// StorageOrDefault returns the archive storage backend used by 'cbbackupmgr', this is 'default' when the '--storage'
+// flag isn't supplied.
+func (c *CBMConfig) StorageOrDefault() string {
+	if c.Storage == "" {
+		return StorageBackendDefault
+	}
+
+	return c.Storage
+}
+
 // addStorage will add the storage flag to the given command if required.
 func (c *CBMConfig) addStorage(command string) string {
 	if c.Storage == "" {
@@ -246,14 +341,29 @@ func (c *CBMConfig) addStorage(command string) string {
 }
 
 // addThreads will add the --threads/--auto-select-threads flag to the given command.
+//
+// NOTE: Versions which don't support automatic thread selection use their default number of threads.
 func (c *CBMConfig) addThreads(command string) string {
 	if c.Threads != 0 {
 		return command + fmt.Sprintf(" --threads %d", c.Threads)
 	}
 
+	if !c.version.Supports(FeatureAutoSelectThreads) {
+		return command
+	}
+
 	return command + " --auto-select-threads"
 }
 
+// addLogLevel will conditionally add the --log-level flag to the given command.
+func (c *CBMConfig) addLogLevel(command string) string {
+	if c.LogLevel == "" {
+		return command
+	}
+
+	return command + fmt.Sprintf(" --log-level %s", c.LogLevel)
+}
+
 // addBlackhole will conditionally add the --blackhole flag to the given command.
 func (c *CBMConfig) addBlackhole(command string) string {
 	if !c.Blackhole {
@@ -263,6 +373,15 @@ func (c *CBMConfig) addBlackhole(command string) string {
 	return command + " --sink blackhole"
 }
 
+// addAutoCreateBuckets will conditionally add the --auto-create-buckets flag to the given command.
+func (c *CBMConfig) addAutoCreateBuckets(command string) string {
+	if !c.AutoCreateBuckets || c.Blackhole {
+		return command
+	}
+
+	return command + " --auto-create-buckets"
+}
+
 // addPointInTimeArg will conditionally add the --point-in-time flag to the given command.
 func (c *CBMConfig) addPointInTimeFlag(command string) string {
 	if !c.PiTR {
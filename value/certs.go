@@ -0,0 +1,155 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CertValidity is how long the generated certificates are valid for, they're only intended to last for a run.
+	CertValidity = 7 * 24 * time.Hour
+
+	// certKeySize is the size of the generated RSA keys.
+	certKeySize = 2048
+)
+
+// CertBundle is a self-signed CA used to sign the node certificates and the client certificate for the cluster
+// administrator, allowing 'cbbackupmgr' to authenticate using mTLS rather than a password.
+type CertBundle struct {
+	// CA is the PEM encoded CA certificate which must be trusted by the cluster/clients.
+	CA []byte
+
+	// ClientCert/ClientKey are the PEM encoded client certificate/key, the common name is the cluster administrator.
+	ClientCert []byte
+	ClientKey  []byte
+
+	ca    *x509.Certificate
+	caKey *rsa.PrivateKey
+}
+
+// NewCertBundle generates a CA and a client certificate for the given user.
+func NewCertBundle(username string) (*CertBundle, error) {
+	caKey, err := rsa.GenerateKey(rand.Reader, certKeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate CA key")
+	}
+
+	template, err := certTemplate("cbtools-autobench CA")
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create CA certificate")
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse CA certificate")
+	}
+
+	bundle := &CertBundle{CA: encodeCert(der), ca: ca, caKey: caKey}
+
+	template, err = certTemplate(username)
+	if err != nil {
+		return nil, err
+	}
+
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	bundle.ClientCert, bundle.ClientKey, err = bundle.sign(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client certificate")
+	}
+
+	return bundle, nil
+}
+
+// NodeCert returns a PEM encoded certificate/key signed by the CA which is valid for the given names/addresses.
+func (c *CertBundle) NodeCert(names ...string) ([]byte, []byte, error) {
+	template, err := certTemplate(names[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	return c.sign(template)
+}
+
+// sign generates a key then returns the certificate for the given template signed by the CA, along with the key.
+func (c *CertBundle) sign(template *x509.Certificate) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, certKeySize)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate key")
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+
+	der, err := x509.CreateCertificate(rand.Reader, template, c.ca, &key.PublicKey, c.caKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create certificate")
+	}
+
+	// NOTE: Older versions of Couchbase Server only accept PKCS#1 encoded node keys
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return encodeCert(der), encoded, nil
+}
+
+// certTemplate returns a certificate template with the given common name and a random serial number.
+func certTemplate(name string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate serial number")
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(CertValidity),
+	}, nil
+}
+
+// encodeCert returns the given DER encoded certificate PEM encoded.
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
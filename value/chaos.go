@@ -0,0 +1,272 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultChaosInterval is the default number of seconds between each roll of the dice for injecting faults.
+	DefaultChaosInterval = 30
+
+	// DefaultFaultDuration is the default number of seconds that network partitions/disk latency last for.
+	DefaultFaultDuration = 30
+
+	// chaosComment is used to tag the firewall rules added to partition nodes, so that they may be removed.
+	chaosComment = "cbtools-autobench-chaos"
+)
+
+// FaultType is a type of fault which may be injected into the cluster during a benchmark.
+type FaultType string
+
+const (
+	// FaultTypeNodeRestart restarts Couchbase Server on a node.
+	FaultTypeNodeRestart FaultType = "node-restart"
+
+	// FaultTypeNetworkPartition drops all traffic between a node and the other nodes in the cluster.
+	FaultTypeNetworkPartition FaultType = "network-partition"
+
+	// FaultTypeDiskLatency adds latency to the device-mapper device backing the data path of a node.
+	FaultTypeDiskLatency FaultType = "disk-latency"
+)
+
+// ChaosConfig enables randomly injecting faults into the cluster during each benchmarked backup/restore, producing
+// resilience-under-fault benchmark data.
+type ChaosConfig struct {
+	// Interval is the number of seconds between each roll of the dice, each fault is injected with its probability.
+	Interval int `json:"interval,omitempty" yaml:"interval,omitempty"`
+
+	// Seed is the seed for the random number generator, the same faults are injected at the same times for a given
+	// seed; by default a random seed is used (it's included in the report so that the run may be reproduced).
+	Seed int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+
+	NodeRestart      *FaultConfig       `json:"node_restart,omitempty" yaml:"node_restart,omitempty"`
+	NetworkPartition *FaultConfig       `json:"network_partition,omitempty" yaml:"network_partition,omitempty"`
+	DiskLatency      *DiskLatencyConfig `json:"disk_latency,omitempty" yaml:"disk_latency,omitempty"`
+}
+
+// IntervalOrDefault returns the duration between each roll of the dice.
+func (c *ChaosConfig) IntervalOrDefault() time.Duration {
+	if c.Interval == 0 {
+		return DefaultChaosInterval * time.Second
+	}
+
+	return time.Duration(c.Interval) * time.Second
+}
+
+// Validate returns an error if the chaos config is invalid.
+func (c *ChaosConfig) Validate() error {
+	for _, fault := range c.Faults() {
+		if config := c.Fault(fault); config.Probability < 0 || config.Probability > 1 {
+			return fmt.Errorf("the probability of a '%s' must be between 0 and 1", fault)
+		}
+	}
+
+	if c.DiskLatency != nil && (c.DiskLatency.Device == "" || c.DiskLatency.BackingDevice == "" ||
+		c.DiskLatency.DelayMS <= 0) {
+		return errors.New("disk latency requires a 'device', 'backing_device' and 'delay_ms'")
+	}
+
+	return nil
+}
+
+// Faults returns the types of the faults which are enabled, in a stable order so that the faults injected are
+// reproducible using the seed.
+func (c *ChaosConfig) Faults() []FaultType {
+	faults := make([]FaultType, 0, 3)
+
+	for _, fault := range []FaultType{FaultTypeNodeRestart, FaultTypeNetworkPartition, FaultTypeDiskLatency} {
+		if c.Fault(fault) != nil {
+			faults = append(faults, fault)
+		}
+	}
+
+	return faults
+}
+
+// Fault returns the config for the given type of fault, nil is returned if it's not enabled.
+func (c *ChaosConfig) Fault(fault FaultType) *FaultConfig {
+	switch {
+	case fault == FaultTypeNodeRestart:
+		return c.NodeRestart
+	case fault == FaultTypeNetworkPartition:
+		return c.NetworkPartition
+	case fault == FaultTypeDiskLatency && c.DiskLatency != nil:
+		return &c.DiskLatency.FaultConfig
+	}
+
+	return nil
+}
+
+// FaultConfig describes how frequently a fault is injected, and how long it lasts.
+type FaultConfig struct {
+	// Probability is the chance (between 0 and 1) of the fault being injected each interval.
+	Probability float64 `json:"probability" yaml:"probability"`
+
+	// Duration is the number of seconds that the fault lasts, it's ignored for node restarts.
+	Duration int `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// DurationOrDefault returns how long the fault lasts.
+func (f *FaultConfig) DurationOrDefault() time.Duration {
+	if f.Duration == 0 {
+		return DefaultFaultDuration * time.Second
+	}
+
+	return time.Duration(f.Duration) * time.Second
+}
+
+// DiskLatencyConfig describes the device-mapper device which latency is added to using 'dm-delay'.
+//
+// NOTE: The data path must already be on the device-mapper device e.g. one created using 'dmsetup create <device>
+// --table "0 $(blockdev --getsz <backing device>) linear <backing device> 0"', it's not created by 'cbtools-autobench'.
+type DiskLatencyConfig struct {
+	FaultConfig `json:",inline" yaml:",inline"`
+
+	// Device is the name of the device-mapper device e.g. 'autobench-data'.
+	Device string `json:"device" yaml:"device"`
+
+	// BackingDevice is the block device which the device-mapper device maps onto e.g. '/dev/nvme1n1'.
+	BackingDevice string `json:"backing_device" yaml:"backing_device"`
+
+	// DelayMS is the number of milliseconds of latency added to each read/write.
+	DelayMS int `json:"delay_ms" yaml:"delay_ms"`
+}
+
+// CommandPartition returns a command which drops all traffic to/from the given peers, the partition is healed in the
+// background after the given duration.
+func CommandPartition(peers []string, duration time.Duration) Command {
+	rules := make([]string, 0, len(peers))
+
+	for _, peer := range peers {
+		rules = append(rules, fmt.Sprintf(`iptables -I INPUT -s %[1]s -m comment --comment %[2]s -j DROP && \
+			iptables -I OUTPUT -d %[1]s -m comment --comment %[2]s -j DROP`, peer, chaosComment))
+	}
+
+	return NewCommand("%s && %s", strings.Join(rules, " && "), healInBackground(commandHealPartition(), duration))
+}
+
+// CommandHealPartition returns a command which removes any firewall rules added by 'CommandPartition'.
+func CommandHealPartition() Command {
+	return NewCommand(commandHealPartition())
+}
+
+// commandHealPartition returns the shell command used to remove the firewall rules added by 'CommandPartition'.
+func commandHealPartition() string {
+	return fmt.Sprintf("iptables-save | grep -v %s | iptables-restore", chaosComment)
+}
+
+// CommandDelayDisk returns a command which adds latency to the given device-mapper device, the latency is removed in
+// the background after the given duration.
+func (d *DiskLatencyConfig) CommandDelayDisk(duration time.Duration) Command {
+	return NewCommand("%s && %s", d.reload(fmt.Sprintf("delay %s 0 %d", d.BackingDevice, d.DelayMS)),
+		healInBackground(d.reload(fmt.Sprintf("linear %s 0", d.BackingDevice)), duration))
+}
+
+// CommandHealDisk returns a command which removes any latency added by 'CommandDelayDisk'.
+func (d *DiskLatencyConfig) CommandHealDisk() Command {
+	return NewCommand(d.reload(fmt.Sprintf("linear %s 0", d.BackingDevice)))
+}
+
+// reload returns a shell command which replaces the table of the device-mapper device with the given target, the
+// device is suspended whilst the table is replaced.
+func (d *DiskLatencyConfig) reload(target string) string {
+	return fmt.Sprintf(`dmsetup suspend %[1]s && dmsetup reload %[1]s --table "0 $(blockdev --getsz %[2]s) %[3]s" && \
+		dmsetup resume %[1]s`, d.Device, d.BackingDevice, target)
+}
+
+// healInBackground returns a shell command which runs the given command after the given duration, in the background
+// so that the fault is healed even if we're unable to reach the machine.
+func healInBackground(command string, duration time.Duration) string {
+	return fmt.Sprintf("(nohup sh -c %s > /dev/null 2>&1 &)",
+		ShellQuote(fmt.Sprintf("sleep %d; %s", int(duration.Seconds()), command)))
+}
+
+// FaultEvent is a single fault which was injected during a benchmark.
+type FaultEvent struct {
+	Type     FaultType     `json:"type"`
+	Node     string        `json:"node"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Err      string        `json:"error,omitempty"`
+}
+
+// ChaosResult is the faults injected during a single backup/restore.
+type ChaosResult struct {
+	Seed   int64         `json:"seed"`
+	Events []*FaultEvent `json:"events"`
+}
+
+// FaultInjection is the faults injected during a single benchmark iteration.
+type FaultInjection struct {
+	Iteration int `json:"iteration"`
+	*ChaosResult
+}
+
+// FaultInjections is a wrapper around the faults injected during each benchmark iteration.
+type FaultInjections []*FaultInjection
+
+// FaultInjections returns the faults injected during each of the results, nil is returned if chaos wasn't enabled.
+func (b BenchmarkResults) FaultInjections() FaultInjections {
+	var injections FaultInjections
+
+	for idx, result := range b {
+		if result.Chaos != nil {
+			injections = append(injections, &FaultInjection{Iteration: idx + 1, ChaosResult: result.Chaos})
+		}
+	}
+
+	return injections
+}
+
+// String returns a human readable string representation of the injected faults which will be displayed in the report.
+func (f FaultInjections) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Fault Injection\n| ---------------")
+	fmt.Fprintf(writer, "| Iteration\t Seed\t Elapsed\t Fault\t Node\t Duration\t Error\t\n")
+
+	for _, injection := range f {
+		if len(injection.Events) == 0 {
+			fmt.Fprintf(writer, "| %d\t %d\t -\t none\t -\t -\t\t\n", injection.Iteration, injection.Seed)
+		}
+
+		for _, event := range injection.Events {
+			duration := "-"
+			if event.Duration != 0 {
+				duration = format.Duration(event.Duration)
+			}
+
+			fmt.Fprintf(writer, "| %d\t %d\t %s\t %s\t %s\t %s\t %s\t\n", injection.Iteration, injection.Seed,
+				format.Duration(event.Elapsed), event.Type, event.Node, duration, event.Err)
+		}
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
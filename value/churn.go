@@ -0,0 +1,194 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// DefaultChurnSkew is the default exponent of the Zipfian distribution, this is the same skew used by YCSB.
+const DefaultChurnSkew = 0.99
+
+// churnPrefix is the prefix of the keys mutated by the churn generator, they're kept separate from the dataset since
+// the keys of the dataset may be randomly generated.
+const churnPrefix = "churn::"
+
+// ChurnDistribution is how the mutations made by the churn generator are distributed across its keys.
+type ChurnDistribution string
+
+const (
+	// ChurnDistributionUniform mutates each key with equal probability, this is the default.
+	ChurnDistributionUniform ChurnDistribution = "uniform"
+
+	// ChurnDistributionZipfian repeatedly mutates a small fraction of hot keys, the probability of mutating the key with
+	// rank 'k' is proportional to '1/k^skew'.
+	ChurnDistributionZipfian ChurnDistribution = "zipfian"
+)
+
+// ChurnConfig configures mutations made between each benchmarked backup and its incremental backup, the distribution
+// controls how many of the mutations are to the same keys and may therefore be deduplicated.
+type ChurnConfig struct {
+	// Mutations is the total number of mutations which will be made.
+	Mutations int `json:"mutations,omitempty" yaml:"mutations,omitempty"`
+
+	// Keys is the number of distinct keys which may be mutated.
+	Keys int `json:"keys,omitempty" yaml:"keys,omitempty"`
+
+	// Distribution is how the mutations are distributed across the keys i.e. 'uniform' or 'zipfian'.
+	Distribution ChurnDistribution `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+
+	// Skew is the exponent of the Zipfian distribution, larger values concentrate the mutations on fewer keys.
+	Skew float64 `json:"skew,omitempty" yaml:"skew,omitempty"`
+}
+
+// DistributionOrDefault returns how the mutations are distributed across the keys.
+func (c *ChurnConfig) DistributionOrDefault() ChurnDistribution {
+	if c.Distribution == "" {
+		return ChurnDistributionUniform
+	}
+
+	return c.Distribution
+}
+
+// SkewOrDefault returns the exponent of the Zipfian distribution.
+func (c *ChurnConfig) SkewOrDefault() float64 {
+	if c.Skew == 0 {
+		return DefaultChurnSkew
+	}
+
+	return c.Skew
+}
+
+// Validate returns an error if the churn config is invalid.
+func (c *ChurnConfig) Validate() error {
+	if c.Mutations < 1 || c.Keys < 1 {
+		return fmt.Errorf("churn must make at least one mutation to at least one key")
+	}
+
+	switch c.DistributionOrDefault() {
+	case ChurnDistributionUniform, ChurnDistributionZipfian:
+	default:
+		return fmt.Errorf("unknown churn distribution '%s'", c.Distribution)
+	}
+
+	if c.Skew < 0 {
+		return fmt.Errorf("churn skew must not be negative")
+	}
+
+	return nil
+}
+
+// CommandGenerate returns a command which generates the mutations (a JSON document per line) into the file at the given
+// path on the remote machine, ready to be imported using 'CommandImport'. The number of distinct keys mutated is
+// output, since this is how many items a perfectly deduplicated incremental backup would contain.
+//
+// NOTE: Zipfian ranks are sampled by binary searching the cumulative distribution, which is built in memory.
+func (c *ChurnConfig) CommandGenerate(path string) Command {
+	var zipfian int
+	if c.DistributionOrDefault() == ChurnDistributionZipfian {
+		zipfian = 1
+	}
+
+	return NewCommand(`awk -v mutations=%[1]d -v keys=%[2]d -v zipfian=%[3]d -v skew=%[4]f -v prefix='%[5]s' \
+		-v out=%[6]s 'BEGIN {
+			srand();
+			if (zipfian) { for (k = 1; k <= keys; k++) { total += 1 / (k ^ skew); cdf[k] = total } };
+			for (m = 0; m < mutations; m++) {
+				if (zipfian) {
+					r = rand() * total; lo = 1; hi = keys;
+					while (lo < hi) { mid = int((lo + hi) / 2); if (cdf[mid] < r) { lo = mid + 1 } else { hi = mid } };
+					k = lo;
+				} else { k = int(rand() * keys) + 1 };
+				if (!(k in seen)) { seen[k] = 1; unique++ };
+				printf "{\"key\":\"%%s%%d\",\"mutation\":%%d}\n", prefix, k, m > out;
+			};
+			close(out); print unique + 0;
+		}'`,
+		c.Mutations, c.Keys, zipfian, c.SkewOrDefault(), churnPrefix, path)
+}
+
+// CommandImport returns a command which imports the mutations generated by 'CommandGenerate' at the given path into the
+// benchmarking bucket using the cluster at the given address.
+func (c *ChurnConfig) CommandImport(host string, credentials *Credentials, path string, threads int) Command {
+	dataset := &ImportBlueprint{Source: ImportSourceJSON, Format: ImportFormatLines, Key: "%key%"}
+
+	return dataset.CommandImport(host, credentials, path, threads)
+}
+
+// ChurnResult is the mutations made by the churn generator before an incremental backup, and how many items that
+// backup contained.
+type ChurnResult struct {
+	Distribution ChurnDistribution `json:"distribution"`
+	Mutations    int               `json:"mutations"`
+	Unique       int               `json:"unique_keys"`
+	Items        uint64            `json:"items_backed_up"`
+	Size         uint64            `json:"incremental_size"`
+}
+
+// Deduplicated returns the percentage of the mutations which weren't backed up by the incremental backup, since they
+// were superseded by a later mutation to the same key.
+func (c *ChurnResult) Deduplicated() float64 {
+	if c.Mutations == 0 {
+		return 0
+	}
+
+	return math.Max(0, 100*(1-float64(c.Items)/float64(c.Mutations)))
+}
+
+// Churns is a wrapper around the churn of each benchmark iteration.
+type Churns []*ChurnResult
+
+// Churns returns the churn of each of the results, nil is returned if the churn generator wasn't enabled.
+func (b BenchmarkResults) Churns() Churns {
+	var churns Churns
+
+	for _, result := range b {
+		if result.Churn != nil {
+			churns = append(churns, result.Churn)
+		}
+	}
+
+	return churns
+}
+
+// String returns a human readable string representation of the churn which will be displayed in the report.
+func (c Churns) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Mutation Churn\n| --------------")
+	fmt.Fprintf(writer, "| Iteration\t Distribution\t Mutations\t Unique Keys\t Items Backed Up\t Deduplicated\t "+
+		"Incremental Size\t\n")
+
+	for idx, churn := range c {
+		fmt.Fprintf(writer, "| %d\t %s\t %d\t %d\t %d\t %.1f%%\t %s\t\n", idx+1, churn.Distribution, churn.Mutations,
+			churn.Unique, churn.Items, churn.Deduplicated(), format.Bytes(churn.Size))
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprint(buffer, "\nNOTE: The items backed up include any other mutations made since the backup started e.g. by "+
+		"the live workload")
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// CIMetadata is information about the CI job which ran the benchmarks, it's included in the results so that a
+// regression may be traced straight back to the build.
+type CIMetadata struct {
+	Provider string `json:"provider,omitempty"`
+	JobURL   string `json:"job_url,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+// ciProvider describes the environment variables used by a CI provider.
+type ciProvider struct {
+	name, detect, commit, user string
+
+	// jobURL returns the URL for the current job using the given function to lookup environment variables.
+	jobURL func(getenv func(string) string) string
+}
+
+// ciProviders are the supported CI providers, they're checked in order.
+var ciProviders = []ciProvider{
+	{
+		name: "github-actions", detect: "GITHUB_ACTIONS", commit: "GITHUB_SHA", user: "GITHUB_ACTOR",
+		jobURL: func(getenv func(string) string) string {
+			return fmt.Sprintf("%s/%s/actions/runs/%s", getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"),
+				getenv("GITHUB_RUN_ID"))
+		},
+	},
+	{
+		name: "gitlab", detect: "GITLAB_CI", commit: "CI_COMMIT_SHA", user: "GITLAB_USER_LOGIN",
+		jobURL: func(getenv func(string) string) string { return getenv("CI_JOB_URL") },
+	},
+	{
+		name: "buildkite", detect: "BUILDKITE", commit: "BUILDKITE_COMMIT", user: "BUILDKITE_BUILD_CREATOR",
+		jobURL: func(getenv func(string) string) string { return getenv("BUILDKITE_BUILD_URL") },
+	},
+	{
+		name: "jenkins", detect: "JENKINS_URL", commit: "GIT_COMMIT", user: "BUILD_USER_ID",
+		jobURL: func(getenv func(string) string) string { return getenv("BUILD_URL") },
+	},
+}
+
+// DetectCIMetadata returns the metadata for the CI job which is running 'cbtools-autobench' using the well-known
+// environment variables for each provider, nil is returned when not running in CI.
+//
+// NOTE: The 'CBM_AUTOBENCH_CI_JOB_URL', 'CBM_AUTOBENCH_TOOLS_SHA' and 'CBM_AUTOBENCH_CI_USER' environment variables
+// take precedence, for example, when the tools build under test isn't the commit which triggered the job.
+func DetectCIMetadata() *CIMetadata {
+	metadata := &CIMetadata{}
+
+	for _, provider := range ciProviders {
+		if os.Getenv(provider.detect) == "" {
+			continue
+		}
+
+		metadata = &CIMetadata{
+			Provider: provider.name,
+			JobURL:   provider.jobURL(os.Getenv),
+			Commit:   os.Getenv(provider.commit),
+			User:     os.Getenv(provider.user),
+		}
+
+		break
+	}
+
+	override := func(dst *string, key string) {
+		if value := os.Getenv(key); value != "" {
+			*dst = value
+		}
+	}
+
+	override(&metadata.JobURL, "CBM_AUTOBENCH_CI_JOB_URL")
+	override(&metadata.Commit, "CBM_AUTOBENCH_TOOLS_SHA")
+	override(&metadata.User, "CBM_AUTOBENCH_CI_USER")
+
+	if *metadata == (CIMetadata{}) {
+		return nil
+	}
+
+	return metadata
+}
+
+// String returns a human readable string representation of the CI metadata which will be displayed in the report.
+func (c *CIMetadata) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	orNA := func(s string) string {
+		if s == "" {
+			return "N/A"
+		}
+
+		return s
+	}
+
+	fmt.Fprintln(buffer, "| CI\n| --")
+	fmt.Fprintf(writer, "| Provider\t Job URL\t Commit\t User\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t\n", orNA(c.Provider), orNA(c.JobURL), orNA(c.Commit), orNA(c.User))
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
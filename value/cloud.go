@@ -0,0 +1,165 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/pkg/errors"
+)
+
+// cloudScheme is the scheme of archives stored in S3 (or an S3 compatible object store).
+const cloudScheme = "s3://"
+
+// Cloud returns a boolean indicating whether the archive is stored in an object store.
+func (c *CBMConfig) Cloud() bool {
+	return strings.HasPrefix(c.Archive, cloudScheme)
+}
+
+// ObjBucket returns the name of the bucket in the object store which contains the archive.
+func (c *CBMConfig) ObjBucket() string {
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(c.Archive, cloudScheme), "/")
+	return bucket
+}
+
+// ValidateCloud returns an error if the archive is stored in an object store, but the config is missing the arguments
+// required to do so.
+func (c *CBMConfig) ValidateCloud() error {
+	if !c.Cloud() {
+		return nil
+	}
+
+	if c.ObjBucket() == "" {
+		return fmt.Errorf("the archive '%s' doesn't contain a bucket", c.Archive)
+	}
+
+	if c.ObjStagingDirectory == "" {
+		return errors.New("a staging directory is required when the archive is stored in an object store")
+	}
+
+	if (c.ObjAccessKeyID == "") != (c.ObjSecretAccessKey == "") {
+		return errors.New("both the access key id and secret access key must be provided, or neither")
+	}
+
+	if c.ObjAuthByInstanceMetadata && c.ObjAccessKeyID != "" {
+		return errors.New("an access key must not be provided when authenticating using the instance metadata")
+	}
+
+	return nil
+}
+
+// CommandCheckObjBucket returns a command which may be run on the backup client to check that the bucket containing
+// the archive exists and is accessible, using the same credentials/region/endpoint as 'cbbackupmgr'.
+func (c *CBMConfig) CommandCheckObjBucket() Command {
+	return NewCommand(c.awsCommand(fmt.Sprintf("aws s3api head-bucket --bucket %s", c.ObjBucket())))
+}
+
+// CommandPurgeObjArchive returns a command which may be run on the backup client to remove the archive from the object
+// store.
+func (c *CBMConfig) CommandPurgeObjArchive() Command {
+	return NewCommand(c.awsCommand(fmt.Sprintf("aws s3 rm %s --recursive", c.Archive)))
+}
+
+// awsCommand returns the given 'aws' CLI command with the credentials/region exported and the endpoint appended.
+func (c *CBMConfig) awsCommand(command string) string {
+	var env string
+
+	if c.ObjAccessKeyID != "" {
+		env += fmt.Sprintf("export AWS_ACCESS_KEY_ID=%s; ", c.ObjAccessKeyID)
+	}
+
+	if c.ObjSecretAccessKey != "" {
+		env += fmt.Sprintf("export AWS_SECRET_ACCESS_KEY=%s; ", c.ObjSecretAccessKey)
+	}
+
+	if c.ObjRegion != "" {
+		env += fmt.Sprintf("export AWS_REGION=%s; ", c.ObjRegion)
+	}
+
+	if c.ObjEndpoint != "" {
+		command += fmt.Sprintf(" --endpoint-url %s", c.ObjEndpoint)
+	}
+
+	if c.ObjNoSSLVerify {
+		command += " --no-verify-ssl"
+	}
+
+	return env + command
+}
+
+// CloudResult is the result of the 'cloud' benchmark, which backs up to and restores from an object store.
+type CloudResult struct {
+	Archive string `json:"archive"`
+	Region  string `json:"region,omitempty"`
+
+	Backup  *ThreadsResult `json:"backup"`
+	Restore *ThreadsResult `json:"restore,omitempty"`
+}
+
+// NewCloudResult calculates the averages of the given backup/restore results.
+func NewCloudResult(config *CBMConfig, backup, restore BenchmarkResults) *CloudResult {
+	result := &CloudResult{
+		Archive: config.Archive,
+		Region:  config.ObjRegion,
+		Backup:  NewThreadsResult(config.Threads, backup),
+	}
+
+	if restore != nil {
+		result.Restore = NewThreadsResult(config.Threads, restore)
+	}
+
+	return result
+}
+
+// String returns a string representation of the cloud result which will be output in the report.
+func (c *CloudResult) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	region := "default"
+	if c.Region != "" {
+		region = c.Region
+	}
+
+	fmt.Fprintln(buffer, "| Cloud\n| -----")
+	fmt.Fprintf(writer, "| Operation\t Archive\t Region\t Iterations\t Avg Duration\t Avg Transfer Rate (ADS)\t\n")
+
+	for _, operation := range []struct {
+		name   string
+		result *ThreadsResult
+	}{{"backup", c.Backup}, {"restore", c.Restore}} {
+		if operation.result == nil {
+			continue
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %d\t %s\t %s/s\t\n",
+			operation.name,
+			c.Archive,
+			region,
+			len(operation.result.Results),
+			format.Duration(operation.result.AvgDuration),
+			format.Bytes(operation.result.AvgTransferRateADS))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// CloudWatchConfig enables fetching EBS/network metrics (from CloudWatch) for the benchmark window using the 'aws' CLI,
+// which must be installed/configured locally.
+type CloudWatchConfig struct {
+	AWSConfig `yaml:",inline"`
+}
+
+// ArgsVolumes returns the arguments which should be passed to the 'aws' CLI to output the volumes attached to the given
+// instance.
+func (c *CloudWatchConfig) ArgsVolumes(instanceID string) []string {
+	return argsVolumes(instanceID, awsArgs(c.AWSConfig))
+}
+
+// ArgsStatistics returns the arguments which should be passed to the 'aws' CLI to output the statistic for the given
+// metric/resource for each period between the start/end.
+func (c *CloudWatchConfig) ArgsStatistics(metric *CloudWatchMetric, id string, start, end time.Time) []string {
+	return argsStatistics(metric, id, start, end, awsArgs(c.AWSConfig))
+}
+
+// CloudWatchMetric describes a CloudWatch metric which is fetched for each instance/volume.
+type CloudWatchMetric struct {
+	// Name is the name the metric is displayed with in the report.
+	Name string
+
+	Namespace string
+	Metric    string
+	Dimension string
+	Statistic string
+	Period    time.Duration
+
+	// Rate indicates the statistic is a sum which should be converted into a per-second rate.
+	Rate bool
+
+	// Bytes indicates the metric is a number of bytes.
+	Bytes bool
+}
+
+// value returns the value of the given datapoint for this metric.
+func (c *CloudWatchMetric) value(datapoint float64) float64 {
+	if c.Rate {
+		return datapoint / c.Period.Seconds()
+	}
+
+	return datapoint
+}
+
+// format returns the given value as a human readable string.
+func (c *CloudWatchMetric) format(value float64) string {
+	switch {
+	case c.Bytes && c.Rate:
+		return format.Bytes(uint64(value)) + "/s"
+	case c.Bytes:
+		return format.Bytes(uint64(value))
+	}
+
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+var (
+	// EBSMetrics are the metrics fetched for each EBS volume; volumes on Nitro instances report at one minute
+	// granularity.
+	EBSMetrics = []*CloudWatchMetric{
+		ebsMetric("read_iops", "VolumeReadOps", "Sum", true, false),
+		ebsMetric("write_iops", "VolumeWriteOps", "Sum", true, false),
+		ebsMetric("read_throughput", "VolumeReadBytes", "Sum", true, true),
+		ebsMetric("write_throughput", "VolumeWriteBytes", "Sum", true, true),
+		ebsMetric("queue_depth", "VolumeQueueLength", "Average", false, false),
+	}
+
+	// EC2Metrics are the metrics fetched for each instance; basic monitoring reports at five minute granularity.
+	EC2Metrics = []*CloudWatchMetric{
+		ec2Metric("network_in", "NetworkIn"),
+		ec2Metric("network_out", "NetworkOut"),
+	}
+)
+
+// ebsMetric returns a metric for an EBS volume.
+func ebsMetric(name, metric, statistic string, rate, bytes bool) *CloudWatchMetric {
+	return &CloudWatchMetric{
+		Name:      name,
+		Namespace: "AWS/EBS",
+		Metric:    metric,
+		Dimension: "VolumeId",
+		Statistic: statistic,
+		Period:    time.Minute,
+		Rate:      rate,
+		Bytes:     bytes,
+	}
+}
+
+// ec2Metric returns a (byte rate) metric for an EC2 instance.
+func ec2Metric(name, metric string) *CloudWatchMetric {
+	return &CloudWatchMetric{
+		Name:      name,
+		Namespace: "AWS/EC2",
+		Metric:    metric,
+		Dimension: "InstanceId",
+		Statistic: "Sum",
+		Period:    5 * time.Minute,
+		Rate:      true,
+		Bytes:     true,
+	}
+}
+
+// CloudWatchSample is the value of a metric during a single period.
+type CloudWatchSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// CloudWatchSeries is the value of a metric for a single instance/volume sampled over the course of a benchmark.
+type CloudWatchSeries struct {
+	Host     string             `json:"host"`
+	Resource string             `json:"resource"`
+	Metric   *CloudWatchMetric  `json:"-"`
+	Name     string             `json:"metric"`
+	Samples  []CloudWatchSample `json:"samples,omitempty"`
+}
+
+// NewCloudWatchSeries creates a new series for the given metric/resource from the raw datapoints.
+func NewCloudWatchSeries(host, resource string, metric *CloudWatchMetric, samples []CloudWatchSample,
+) *CloudWatchSeries {
+	for index := range samples {
+		samples[index].Value = metric.value(samples[index].Value)
+	}
+
+	return &CloudWatchSeries{Host: host, Resource: resource, Metric: metric, Name: metric.Name, Samples: samples}
+}
+
+// Average returns the average value of the samples in periods overlapping the given time range, false is returned if
+// there are no such samples.
+func (c *CloudWatchSeries) Average(start time.Time, duration time.Duration) (float64, bool) {
+	var (
+		end   = start.Add(duration)
+		total float64
+		count int
+	)
+
+	for _, sample := range c.Samples {
+		if sample.Timestamp.Before(end) && sample.Timestamp.Add(c.Metric.Period).After(start) {
+			total, count = total+sample.Value, count+1
+		}
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	return total / float64(count), true
+}
+
+// Peak returns the highest value of all the samples.
+func (c *CloudWatchSeries) Peak() float64 {
+	var peak float64
+
+	for _, sample := range c.Samples {
+		if sample.Value > peak {
+			peak = sample.Value
+		}
+	}
+
+	return peak
+}
+
+// CloudWatchMetrics is a wrapper around a slice of metric series which provides some utility functions.
+type CloudWatchMetrics []*CloudWatchSeries
+
+// Table returns a table containing the average value of each metric for each benchmark iteration alongside the peak
+// value seen during the benchmark.
+func (c CloudWatchMetrics) Table(results BenchmarkResults) string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprint(writer, "| Host\t Resource\t Metric\t")
+
+	for index := range results {
+		fmt.Fprintf(writer, " Iteration %d\t", index+1)
+	}
+
+	fmt.Fprint(writer, " Peak\t\n")
+
+	for _, series := range c {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t", series.Host, series.Resource, series.Name)
+
+		for _, result := range results {
+			average, ok := series.Average(result.Start, result.Duration)
+			if !ok {
+				fmt.Fprint(writer, " -\t")
+				continue
+			}
+
+			fmt.Fprintf(writer, " %s\t", series.Metric.format(average))
+		}
+
+		fmt.Fprintf(writer, " %s\t\n", series.Metric.format(series.Peak()))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// argsVolumes returns the arguments which should be passed to the 'aws' CLI to output the volumes attached to the given
+// instance.
+func argsVolumes(instanceID string, global []string) []string {
+	return append([]string{
+		"ec2", "describe-volumes", "--output", "json", "--query", "Volumes[]",
+		"--filters", "Name=attachment.instance-id,Values=" + instanceID,
+	}, global...)
+}
+
+// argsStatistics returns the arguments which should be passed to the 'aws' CLI to output the statistic for the given
+// metric/resource for each period between the start/end.
+func argsStatistics(metric *CloudWatchMetric, id string, start, end time.Time, global []string) []string {
+	return append([]string{
+		"cloudwatch", "get-metric-statistics", "--output", "json",
+		"--namespace", metric.Namespace, "--metric-name", metric.Metric,
+		"--dimensions", fmt.Sprintf("Name=%s,Value=%s", metric.Dimension, id),
+		"--start-time", start.UTC().Format(time.RFC3339), "--end-time", end.UTC().Format(time.RFC3339),
+		"--period", strconv.Itoa(int(metric.Period.Seconds())), "--statistics", metric.Statistic,
+	}, global...)
+}
@@ -19,8 +19,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/pkg/errors"
 )
 
 // ClusterBlueprint encapsulates the configuration for the Couchbase Cluster which will be provisioned by the
@@ -32,29 +35,187 @@ type ClusterBlueprint struct {
 	// NOTE: No validation takes place to ensure the package is valid for the current distribution; that's on you...
 	PackagePath string `yaml:"package_path,omitempty"`
 
+	// PackageType overrides the package type determined using the extension of 'PackagePath' i.e. deb/rpm/tar.
+	PackageType PackageType `yaml:"package_type,omitempty"`
+
+	// InstallDirectory is the directory that a 'tar' package will be extracted into.
+	InstallDirectory string `yaml:"install_directory,omitempty"`
+
+	// Readiness controls how long we wait for Couchbase Server to become ready on each node after installation.
+	Readiness *ReadinessConfig `yaml:"readiness,omitempty"`
+
+	// Credentials are the credentials of the cluster administrator, they're excluded from the report.
+	Credentials *CredentialsBlueprint `json:"-" yaml:"credentials,omitempty"`
+
 	// Nodes is the list of node blueprints which will be used to create the cluster.
 	Nodes []*NodeBlueprint `yaml:"nodes,omitempty"`
 
+	// Topology is a preset which is expanded into additional nodes, useful for large clusters.
+	Topology *TopologyBlueprint `yaml:"topology,omitempty"`
+
 	// Bucket is the blueprint for the bucket that will be created once the cluster is provisioned.
 	Bucket *BucketBlueprint `yaml:"bucket,omitempty"`
 
+	// Buckets are additional buckets created (and loaded) alongside the benchmarking bucket, they're backed up and
+	// restored with it so that the performance of clusters with multiple buckets may be benchmarked.
+	Buckets []*BucketBlueprint `yaml:"buckets,omitempty"`
+
 	// DeveloperPreview is a boolean which indicates whether or not developer preview should be enabled on the
 	// cluster.
 	DeveloperPreview bool `yaml:"developer_preview,omitempty"`
+
+	// IPv6 indicates that the cluster should use IPv6 for intra-cluster communication. This is implied when any of the
+	// nodes are addressed using an IPv6 address.
+	IPv6 bool `yaml:"ipv6,omitempty"`
+
+	// ManageHosts indicates that entries for each node with a 'hostname' should be added to '/etc/hosts' on all the
+	// nodes (and the backup client), this is required when the names aren't resolvable from the other machines.
+	ManageHosts bool `yaml:"manage_hosts,omitempty"`
+
+	// PermissiveSecurity sets SELinux to permissive mode and puts any AppArmor profiles mentioning Couchbase Server into
+	// complain mode on each node, rather than labelling the custom data/index paths; the original modes are reverted by
+	// the 'restore-host' sub-command.
+	PermissiveSecurity bool `yaml:"permissive_security,omitempty"`
+
+	// IndexStorageMode is the storage mode used by the index service i.e. plasma/memory_optimized.
+	IndexStorageMode string `yaml:"index_storage_mode,omitempty"`
+
+	// Bootstrap is how the cluster is initialized i.e. auto/legacy/cluster-init, by default the flow supported by the
+	// installed version of Couchbase Server is detected.
+	Bootstrap BootstrapFlow `yaml:"bootstrap,omitempty"`
+
+	// ReuseInstall skips reinstalling Couchbase Server on nodes which already have the version being provisioned
+	// installed, the generated state is wiped instead so that they're still initialized as new nodes.
+	ReuseInstall bool `yaml:"reuse_install,omitempty"`
+
+	// AutoFailover/Compaction are the cluster wide settings which will be applied after the cluster is initialized, the
+	// server defaults will be used when they're not provided.
+	AutoFailover *AutoFailoverBlueprint `yaml:"auto_failover,omitempty"`
+	Compaction   *CompactionBlueprint   `yaml:"compaction,omitempty"`
+
+	// ServerSettings are the server-side settings applied before each benchmark (if any).
+	ServerSettings *ServerSettingsBlueprint `yaml:"server_settings,omitempty"`
+
+	// LDAP stands up an LDAP server on the first node during provisioning and configures the cluster to authenticate
+	// external users against it.
+	LDAP *LDAPBlueprint `yaml:"ldap,omitempty"`
+
+	// Snapshot enables resetting the bucket between iterations using ZFS/BTRFS snapshots of the data path of each node.
+	Snapshot *SnapshotBlueprint `yaml:"snapshot,omitempty"`
+
+	// Image is the id of the image (created using 'bake') the nodes were launched from, provisioning skips installing
+	// the dependencies/package when the image contains the same package.
+	Image string `yaml:"image,omitempty"`
+}
+
+// UseIPv6 returns a boolean indicating whether the nodes should be initialized using IPv6.
+func (c *ClusterBlueprint) UseIPv6() bool {
+	if c.IPv6 {
+		return true
+	}
+
+	for _, node := range c.Nodes {
+		if IsIPv6(node.Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateBuckets returns an error if the benchmarking bucket or any of the additional buckets are invalid.
+func (c *ClusterBlueprint) ValidateBuckets() error {
+	err := c.Bucket.Validate(false)
+	if err != nil {
+		return errors.Wrap(err, "invalid benchmarking bucket")
+	}
+
+	names := map[string]struct{}{DefaultBucketName: {}}
+
+	for _, bucket := range c.Buckets {
+		err = bucket.Validate(true)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := names[bucket.Name]; ok {
+			return fmt.Errorf("bucket '%s' is defined more than once", bucket.Name)
+		}
+
+		names[bucket.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// BucketNames returns the names of the benchmarking bucket and any additional buckets.
+func (c *ClusterBlueprint) BucketNames() []string {
+	names := []string{DefaultBucketName}
+
+	for _, bucket := range c.Buckets {
+		names = append(names, bucket.Name)
+	}
+
+	return names
+}
+
+// QuotaExpression returns a shell arithmetic expression (which may reference the '$QUOTA' variable i.e. 80% of the
+// memory on the node) for the memory quota of the benchmarking bucket in MiB, by default it uses the memory which isn't
+// used by the additional buckets.
+func (c *ClusterBlueprint) QuotaExpression() string {
+	if c.Bucket.Quota != 0 {
+		return strconv.Itoa(c.Bucket.Quota)
+	}
+
+	var used int
+
+	for _, bucket := range c.Buckets {
+		used += bucket.Quota
+	}
+
+	if used == 0 {
+		return "QUOTA"
+	}
+
+	return fmt.Sprintf("QUOTA - %d", used)
+}
+
+// GDS returns the generated data size of the benchmarking bucket and any additional buckets which are loaded.
+func (c *ClusterBlueprint) GDS() uint64 {
+	var gds uint64
+
+	for _, bucket := range append([]*BucketBlueprint{c.Bucket}, c.Buckets...) {
+		if bucket.Data != nil {
+			gds += uint64(bucket.Data.Items * bucket.Data.Size)
+		}
+	}
+
+	return gds
+}
+
+// Package returns the package that will be installed on each of the cluster nodes.
+func (c *ClusterBlueprint) Package() *Package {
+	return NewPackage(c.PackagePath, c.PackageType, c.InstallDirectory)
 }
 
 // MarshalJSON returns a JSON representation of the cluster blueprint which will be displayed in the report.
 func (c *ClusterBlueprint) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Version          string           `json:"version,omitempty"`
-		Nodes            []*NodeBlueprint `json:"nodes,omitempty"`
-		Bucket           *BucketBlueprint `json:"bucket,omitempty"`
-		DeveloperPreview bool             `json:"developer_preview,omitempty"`
+		Version          string             `json:"version,omitempty"`
+		Edition          Edition            `json:"edition,omitempty"`
+		Nodes            []*NodeBlueprint   `json:"nodes,omitempty"`
+		Bucket           *BucketBlueprint   `json:"bucket,omitempty"`
+		Buckets          []*BucketBlueprint `json:"buckets,omitempty"`
+		DeveloperPreview bool               `json:"developer_preview,omitempty"`
+		Image            string             `json:"image,omitempty"`
 	}{
 		Version:          extractBuild(c.PackagePath),
+		Edition:          extractEdition(c.PackagePath),
 		Nodes:            c.Nodes,
 		Bucket:           c.Bucket,
+		Buckets:          c.Buckets,
 		DeveloperPreview: c.DeveloperPreview,
+		Image:            c.Image,
 	})
 }
 
@@ -66,17 +227,32 @@ func (c *ClusterBlueprint) String() string {
 	)
 
 	fmt.Fprintln(buffer, "| Cluster\n| -------")
-	fmt.Fprintf(writer, "| Node\t Version\t Host\t Developer Preview\t\n")
+	fmt.Fprintf(writer, "| Node\t Version\t Edition\t Host\t Server Group\t Developer Preview\t\n")
 
 	for index, node := range c.Nodes {
-		fmt.Fprintf(writer, "| %d\t %s\t %s\t %t\t\n", index+1, extractBuild(c.PackagePath), node.Host,
-			c.DeveloperPreview)
+		group := node.ServerGroup
+		if group == "" {
+			group = DefaultServerGroup
+		}
+
+		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %t\t\n", index+1, extractBuild(c.PackagePath),
+			extractEdition(c.PackagePath), node.Host, group, c.DeveloperPreview)
 	}
 
 	_ = writer.Flush()
 
+	for index, node := range c.Nodes {
+		if node.EBS != nil && len(node.EBS.Volumes) != 0 {
+			fmt.Fprintf(buffer, "| Node %d Volumes:\n%s\n", index+1, node.EBS.Volumes)
+		}
+	}
+
 	fmt.Fprintf(buffer, "\n%s", c.Bucket)
 
+	for _, bucket := range c.Buckets {
+		fmt.Fprintf(buffer, "\n%s", bucket)
+	}
+
 	return strings.TrimSpace(buffer.String())
 }
 
@@ -0,0 +1,84 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "fmt"
+
+const (
+	// DefaultMetadataPurgeInterval is the number of days after which tombstones may be purged, the server default.
+	DefaultMetadataPurgeInterval = 3.0
+
+	// MinMetadataPurgeInterval/MaxMetadataPurgeInterval are the bounds (in days) of the metadata purge interval which are
+	// accepted by the server.
+	MinMetadataPurgeInterval = 0.04
+	MaxMetadataPurgeInterval = 60.0
+)
+
+// AutoFailoverBlueprint represents the auto-failover settings which will be applied to the cluster.
+type AutoFailoverBlueprint struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Timeout is the number of seconds a node must be unresponsive before it's failed over, a zero value will use the
+	// server default.
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// CompactionBlueprint represents the auto-compaction settings which will be applied to the cluster.
+type CompactionBlueprint struct {
+	// DatabasePercentage/ViewPercentage are the fragmentation percentages at which compaction is triggered, a zero value
+	// will use the server default.
+	DatabasePercentage int `json:"database_percentage,omitempty" yaml:"database_percentage,omitempty"`
+	ViewPercentage     int `json:"view_percentage,omitempty" yaml:"view_percentage,omitempty"`
+
+	// Parallel indicates whether database and view compaction should be run in parallel.
+	Parallel bool `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+
+	// MetadataPurgeInterval is the number of days after which tombstones may be purged by compaction, a zero value will
+	// use the server default.
+	MetadataPurgeInterval float64 `json:"metadata_purge_interval,omitempty" yaml:"metadata_purge_interval,omitempty"`
+}
+
+// MetadataPurgeIntervalOrDefault returns the number of days after which tombstones may be purged by compaction.
+func (c *CompactionBlueprint) MetadataPurgeIntervalOrDefault() float64 {
+	if c == nil || c.MetadataPurgeInterval == 0 {
+		return DefaultMetadataPurgeInterval
+	}
+
+	return c.MetadataPurgeInterval
+}
+
+// Validate returns an error if the auto-compaction settings are invalid.
+func (c *CompactionBlueprint) Validate() error {
+	if c.MetadataPurgeInterval != 0 && (c.MetadataPurgeInterval < MinMetadataPurgeInterval ||
+		c.MetadataPurgeInterval > MaxMetadataPurgeInterval) {
+		return fmt.Errorf("metadata purge interval must be between %g and %g days", MinMetadataPurgeInterval,
+			MaxMetadataPurgeInterval)
+	}
+
+	return nil
+}
+
+// IndexStorageSetting returns the value which should be passed to '--index-storage-setting' for the given index storage
+// mode, accepting both the names used by the CLI and the names used in the WebUI.
+func IndexStorageSetting(mode string) (string, error) {
+	switch mode {
+	case "", "default", "plasma":
+		return "default", nil
+	case "memopt", "memory_optimized":
+		return "memopt", nil
+	}
+
+	return "", fmt.Errorf("unsupported index storage mode '%s'", mode)
+}
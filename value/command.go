@@ -16,9 +16,13 @@ package value
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// subcommand matches arguments which look like a sub-command e.g. 'install' in 'yum install -y'.
+var subcommand = regexp.MustCompile(`^[a-z][a-z-]*$`)
+
 // Command represents a command to be executed on a system (generally via ssh).
 type Command string
 
@@ -35,6 +39,11 @@ func NewCommand(format string, args ...interface{}) Command {
 	return Command(command)
 }
 
+// ShellQuote returns the given string single quoted so that it may be passed as a single argument to a shell.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // ToString converts the provided command into a string which can be directly run on the remote system.
 func (c Command) ToString(environment map[string]string) string {
 	if len(environment) == 0 {
@@ -48,3 +57,44 @@ func (c Command) ToString(environment map[string]string) string {
 
 	return env + string(c)
 }
+
+// Name returns a short name for the command which can be used to group similar commands together e.g.
+// 'cbbackupmgr backup' or 'yum install'. Environment variable exports/assignments, 'ulimit' and 'trap' calls are
+// ignored.
+func (c Command) Name() string {
+	for _, segment := range strings.Split(string(c), "; ") {
+		fields := strings.Fields(segment)
+		if len(fields) == 0 || fields[0] == "export" || fields[0] == "ulimit" || fields[0] == "trap" ||
+			strings.Contains(fields[0], "=") {
+			continue
+		}
+
+		fields = trimWrappers(fields)
+
+		if len(fields) > 1 && subcommand.MatchString(fields[1]) {
+			return fields[0] + " " + fields[1]
+		}
+
+		return strings.TrimSuffix(fields[0], ";")
+	}
+
+	return strings.TrimSpace(string(c))
+}
+
+// trimWrappers removes any leading wrapper commands (and their arguments) e.g. 'sudo' or 'taskset -c 0-3'.
+func trimWrappers(fields []string) []string {
+	for len(fields) > 1 {
+		switch fields[0] {
+		case "sudo", "taskset", "numactl":
+		default:
+			return fields
+		}
+
+		fields = fields[1:]
+		for len(fields) > 1 && !subcommand.MatchString(fields[0]) {
+			fields = fields[1:]
+		}
+	}
+
+	return fields
+}
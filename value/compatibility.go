@@ -0,0 +1,124 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// CompatibilityConfig describes the 'cbbackupmgr' versions used by the 'compatibility' benchmark, which restores the
+// backups created by each version using every other version.
+type CompatibilityConfig struct {
+	// PackagePaths are the paths to the packages containing each version of 'cbbackupmgr', the packages are extracted
+	// into the run temporary directory on the backup client rather than being installed.
+	PackagePaths []string `json:"package_paths,omitempty" yaml:"package_paths,omitempty"`
+}
+
+// Validate returns an error if the compatibility config is missing, or doesn't contain enough packages.
+func (c *CompatibilityConfig) Validate() error {
+	if c == nil || len(c.PackagePaths) < 2 {
+		return fmt.Errorf("at least two packages must be provided to benchmark compatibility")
+	}
+
+	for _, path := range c.PackagePaths {
+		switch NewPackage(path, "", "").Type {
+		case PackageTypeDEB, PackageTypeRPM, PackageTypeTar:
+		default:
+			return fmt.Errorf("unable to determine package type for '%s'", path)
+		}
+	}
+
+	return nil
+}
+
+// CommandExtract returns a command which will extract the uploaded package at the given path into the given directory,
+// without installing it. The binaries will be in the 'opt/couchbase/bin' directory beneath the given directory.
+func (p *Package) CommandExtract(remotePath, directory string) Command {
+	switch p.Type {
+	case PackageTypeDEB:
+		return NewCommand("mkdir -p %[1]s && dpkg-deb -x %[2]s %[1]s", directory, remotePath)
+	case PackageTypeRPM:
+		return NewCommand("mkdir -p %[1]s && cd %[1]s && rpm2cpio %[2]s | cpio -idm", directory, remotePath)
+	}
+
+	return NewCommand("mkdir -p %[1]s && tar -xf %[2]s -C %[1]s", directory, remotePath)
+}
+
+// Version returns the build contained in the package, falling back to the package name when it can't be determined.
+func (p *Package) Version() string {
+	if build := extractBuild(p.Path); build != "unknown" {
+		return build
+	}
+
+	return LocalBase(p.Path)
+}
+
+// CompatibilityResult is the result of restoring a backup created by one version of 'cbbackupmgr' using another.
+type CompatibilityResult struct {
+	// Backup/Restore are the versions of 'cbbackupmgr' which created/restored the backup.
+	Backup  string `json:"backup"`
+	Restore string `json:"restore"`
+
+	// Result is the timing/size of the restore, only populated when the restore succeeded.
+	Result *BenchmarkResult `json:"result,omitempty"`
+
+	// Error is the reason the restore failed, which indicates that the versions aren't compatible.
+	Error string `json:"error,omitempty"`
+}
+
+// Compatible returns a boolean indicating whether the restore completed successfully.
+func (c *CompatibilityResult) Compatible() bool {
+	return c.Error == ""
+}
+
+// CompatibilityMatrix is a wrapper around a slice of compatibility results which provides a human readable
+// representation.
+type CompatibilityMatrix []*CompatibilityResult
+
+// String returns a human readable string representation of the compatibility matrix which will be displayed in the
+// report.
+func (c CompatibilityMatrix) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Compatibility\n| -------------")
+	fmt.Fprintf(writer, "| Backup Version\t Restore Version\t Duration\t Size (ADS)\t Transfer Rate (ADS)\t "+
+		"Compatible\t\n")
+
+	for _, result := range c {
+		if !result.Compatible() {
+			fmt.Fprintf(writer, "| %s\t %s\t -\t -\t -\t false (%s)\t\n", result.Backup, result.Restore, result.Error)
+			continue
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s/s\t true\t\n",
+			result.Backup,
+			result.Restore,
+			format.Duration(result.Result.Duration),
+			format.Bytes(result.Result.ADS),
+			format.Bytes(result.Result.AvgTransferRateADS()))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
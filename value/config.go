@@ -20,4 +20,16 @@ type AutobenchConfig struct {
 	SSHConfig       *SSHConfig       `yaml:"ssh,omitempty"`
 	Blueprint       *Blueprint       `yaml:"blueprint,omitempty"`
 	BenchmarkConfig *BenchmarkConfig `yaml:"benchmark,omitempty"`
+	Logging         *LoggingConfig   `yaml:"logging,omitempty"`
+	Retention       *RetentionConfig `yaml:"retention,omitempty"`
+	Webhook         *WebhookConfig   `yaml:"webhook,omitempty"`
+}
+
+// WithBlueprint returns a shallow copy of the config which uses the given blueprint, this is used to provision and
+// benchmark each environment independently.
+func (a *AutobenchConfig) WithBlueprint(blueprint *Blueprint) *AutobenchConfig {
+	copied := *a
+	copied.Blueprint = blueprint
+
+	return &copied
 }
@@ -20,4 +20,13 @@ const (
 
 	// CBBinDirectory is the default bin directory used by Couchbase Server.
 	CBBinDirectory = "/opt/couchbase/bin"
+
+	// DefaultRESTPort is the default port used by the Couchbase Server REST API.
+	DefaultRESTPort = 8091
+
+	// DefaultTLSRESTPort is the default port used by the Couchbase Server REST API when using TLS.
+	DefaultTLSRESTPort = 18091
+
+	// DefaultKVPort is the default port used by the Couchbase Server data service.
+	DefaultKVPort = 11210
 )
@@ -0,0 +1,95 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// CorePattern is the file which controls where the kernel writes core dumps.
+const CorePattern = "/proc/sys/kernel/core_pattern"
+
+// CoreDump describes a core dump, and the binary which produced it, downloaded from a remote machine.
+type CoreDump struct {
+	Host   string `json:"host"`
+	Core   string `json:"core"`
+	Binary string `json:"binary"`
+}
+
+// CoreDumps is a wrapper around a slice of core dumps which provides a human readable representation.
+type CoreDumps []*CoreDump
+
+// String returns a human readable string representation of the core dumps which will be displayed in the report.
+func (c CoreDumps) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Core Dumps\n| ----------")
+	fmt.Fprintf(writer, "| Host\t Core\t Binary\t\n")
+
+	for _, dump := range c {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", dump.Host, dump.Core, dump.Binary)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// CoreDirectory returns the directory on the remote machine (within the given parent) which core dumps will be written
+// to during this run.
+func (r RunID) CoreDirectory(parent string) string {
+	return RemoteJoin(r.TempDirectory(parent), "cores")
+}
+
+// CommandEnableCoreDumps returns a command which will configure the kernel to write core dumps into the given
+// directory, the file names include the path to the crashing binary (with '/' replaced by '!') and its PID.
+func CommandEnableCoreDumps(directory string) Command {
+	return NewCommand("mkdir -p %[1]s && chmod 1777 %[1]s && %[2]s",
+		directory, CommandSetCorePattern(RemoteJoin(directory, "core.%E.%p")))
+}
+
+// CommandSetCorePattern returns a command which will set the kernel core pattern to the given value.
+func CommandSetCorePattern(pattern string) Command {
+	return NewCommand("echo %s > %s", ShellQuote(pattern), CorePattern)
+}
+
+// CommandFindCoreDumps returns a command which lists the core dumps in the given directory.
+func CommandFindCoreDumps(directory string) Command {
+	return NewCommand("find %s -type f -name 'core.*' 2>/dev/null || true", directory)
+}
+
+// WithCoreDumps returns the given command prefixed so that it will produce a core dump if it crashes; 'GOTRACEBACK' is
+// required because the Go runtime doesn't abort (and therefore dump core) on a panic by default.
+func WithCoreDumps(command Command) Command {
+	return NewCommand("ulimit -c unlimited; export GOTRACEBACK=crash; %s", command)
+}
+
+// CoreDumpBinary returns the path to the binary which produced the core dump at the given path, this relies on the
+// core pattern set by 'CommandEnableCoreDumps'.
+func CoreDumpBinary(core string) string {
+	name := strings.TrimPrefix(RemoteBase(core), "core.")
+
+	if idx := strings.LastIndex(name, "."); idx != -1 {
+		name = name[:idx]
+	}
+
+	return strings.ReplaceAll(name, "!", "/")
+}
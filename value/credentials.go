@@ -0,0 +1,144 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultUsername/DefaultPassword are the credentials of the cluster administrator when none are provided.
+	DefaultUsername = "Administrator"
+	DefaultPassword = "asdasd"
+)
+
+// CredentialsPath is the file on each cluster node containing a generated password, it's read each time we connect to
+// the cluster so that the password doesn't need to be recorded locally.
+const CredentialsPath = "/var/lib/cbtools-autobench/credentials"
+
+// CredentialsBlueprint describes the credentials of the cluster administrator, they're used when initializing the
+// cluster and by every command which interacts with it (including 'cbbackupmgr').
+type CredentialsBlueprint struct {
+	// Username is the username of the cluster administrator, by default 'Administrator'.
+	Username string `yaml:"username,omitempty"`
+
+	// Password is the password of the cluster administrator, by default 'asdasd'.
+	Password string `yaml:"password,omitempty"`
+
+	// GeneratePassword indicates that a random password should be generated when the cluster is first connected to,
+	// it's stored in 'CredentialsPath' on each cluster node.
+	GeneratePassword bool `yaml:"generate_password,omitempty"`
+}
+
+// Validate returns an error if the credentials blueprint is invalid.
+func (c *CredentialsBlueprint) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.GeneratePassword && c.Password != "" {
+		return errors.New("a password must not be provided when generating a password")
+	}
+
+	// NOTE: Some commands are formatted twice, which would mangle any formatting verbs in the credentials
+	if strings.Contains(c.Username, "%") || strings.Contains(c.Password, "%") {
+		return errors.New("the username/password must not contain '%'")
+	}
+
+	return nil
+}
+
+// Generated returns a boolean indicating whether the password should be generated.
+func (c *CredentialsBlueprint) Generated() bool {
+	return c != nil && c.GeneratePassword
+}
+
+// UsernameOrDefault returns the username or the default if one wasn't provided.
+func (c *CredentialsBlueprint) UsernameOrDefault() string {
+	if c == nil || c.Username == "" {
+		return DefaultUsername
+	}
+
+	return c.Username
+}
+
+// PasswordOrDefault returns the password or the default if one wasn't provided.
+func (c *CredentialsBlueprint) PasswordOrDefault() string {
+	if c == nil || c.Password == "" {
+		return DefaultPassword
+	}
+
+	return c.Password
+}
+
+// Credentials are the resolved credentials of the cluster administrator.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// GenerateCredentials returns credentials for the given username with a random password.
+func GenerateCredentials(username string) (*Credentials, error) {
+	buffer := make([]byte, 16)
+
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate password")
+	}
+
+	return &Credentials{Username: username, Password: hex.EncodeToString(buffer)}, nil
+}
+
+// Args returns the username/password quoted as the '-u'/'-p' arguments accepted by most of the Couchbase tools.
+func (c *Credentials) Args() string {
+	return fmt.Sprintf("-u %s -p %s", ShellQuote(c.Username), ShellQuote(c.Password))
+}
+
+// UserInfo returns the quoted 'username:password' pair accepted by 'curl -u' and 'cbindex -auth'.
+func (c *Credentials) UserInfo() string {
+	return ShellQuote(c.Username + ":" + c.Password)
+}
+
+// QuotedUsername returns the quoted username, for tools which use non-standard flags.
+func (c *Credentials) QuotedUsername() string {
+	return ShellQuote(c.Username)
+}
+
+// QuotedPassword returns the quoted password, for tools which use non-standard flags.
+func (c *Credentials) QuotedPassword() string {
+	return ShellQuote(c.Password)
+}
+
+// CommandReadPassword returns a command which outputs the generated password, nothing is output if there isn't one.
+func CommandReadPassword() Command {
+	return NewCommand("cat %s 2> /dev/null || true", CredentialsPath)
+}
+
+// CommandWritePassword returns a command which stores the given generated password, it's only readable by root.
+func CommandWritePassword(password string) Command {
+	return NewCommand("mkdir -p %s && (umask 077 && printf '%%s' %s > %s)", path.Dir(CredentialsPath),
+		ShellQuote(password), CredentialsPath)
+}
+
+// ParsePassword parses the output of the command returned by 'CommandReadPassword'.
+func ParsePassword(output []byte) string {
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// CreditsPeriod is the granularity at which credit balances are fetched from CloudWatch, this matches the granularity
+// of the 'CPUCreditBalance' metric with basic monitoring.
+const CreditsPeriod = 5 * time.Minute
+
+// creditsExhausted is the balance below which credits are considered exhausted; this is a number of credits for
+// burstable instances and a percentage for burstable volumes.
+const creditsExhausted = 1
+
+// burstableInstance matches the burstable (T-class) EC2 instance types e.g. 't3.large'.
+var burstableInstance = regexp.MustCompile(`^t\d+[a-z]*\.`)
+
+// CreditsConfig enables monitoring the credit balances of burstable instances/volumes (via CloudWatch) during each
+// benchmark using the 'aws' CLI, which must be installed/configured locally.
+type CreditsConfig struct {
+	AWSConfig `yaml:",inline"`
+}
+
+// ArgsGlobal returns the global arguments which should be passed to the 'aws' CLI.
+func (c *CreditsConfig) ArgsGlobal() []string {
+	return awsArgs(c.AWSConfig)
+}
+
+// ArgsInstanceType returns the arguments which should be passed to the 'aws' CLI to output the type of the given
+// instance.
+func (c *CreditsConfig) ArgsInstanceType(instanceID string) []string {
+	return append([]string{
+		"ec2", "describe-instances", "--instance-ids", instanceID, "--output", "text",
+		"--query", "Reservations[0].Instances[0].InstanceType",
+	}, c.ArgsGlobal()...)
+}
+
+// ArgsVolumes returns the arguments which should be passed to the 'aws' CLI to output the volumes attached to the given
+// instance.
+func (c *CreditsConfig) ArgsVolumes(instanceID string) []string {
+	return argsVolumes(instanceID, c.ArgsGlobal())
+}
+
+// ArgsStatistics returns the arguments which should be passed to the 'aws' CLI to output the minimum credit balance of
+// the given resource for each period between the start/end.
+func (c *CreditsConfig) ArgsStatistics(resource *CreditResource, start, end time.Time) []string {
+	return argsStatistics(resource.Type.metric(), resource.ID, start, end, c.ArgsGlobal())
+}
+
+// CreditResourceType is the type of burstable resource whose credits are monitored.
+type CreditResourceType string
+
+const (
+	// CreditResourceCPU is the CPU credit balance of a burstable (T-class) instance.
+	CreditResourceCPU CreditResourceType = "cpu"
+
+	// CreditResourceEBS is the burst balance of a burstable (gp2/st1/sc1) EBS volume.
+	CreditResourceEBS CreditResourceType = "ebs"
+)
+
+// metric returns the CloudWatch metric containing the credit balance for this resource type.
+func (c CreditResourceType) metric() *CloudWatchMetric {
+	metric := &CloudWatchMetric{
+		Name:      string(c),
+		Namespace: "AWS/EBS",
+		Metric:    "BurstBalance",
+		Dimension: "VolumeId",
+		Statistic: "Minimum",
+		Period:    CreditsPeriod,
+	}
+
+	if c == CreditResourceCPU {
+		metric.Namespace, metric.Metric, metric.Dimension = "AWS/EC2", "CPUCreditBalance", "InstanceId"
+	}
+
+	return metric
+}
+
+// unit returns the unit of the credit balance for this resource type.
+func (c CreditResourceType) unit() string {
+	if c == CreditResourceCPU {
+		return " credits"
+	}
+
+	return "%"
+}
+
+// IsBurstableInstance returns a boolean indicating whether the given instance type earns/spends CPU credits.
+func IsBurstableInstance(instanceType string) bool {
+	return burstableInstance.MatchString(instanceType)
+}
+
+// IsBurstableVolume returns a boolean indicating whether the given volume type earns/spends I/O credits.
+func IsBurstableVolume(volumeType string) bool {
+	return volumeType == "gp2" || volumeType == "st1" || volumeType == "sc1"
+}
+
+// CreditResource is a burstable instance/volume whose credit balance is monitored.
+type CreditResource struct {
+	Host string             `json:"host"`
+	Type CreditResourceType `json:"type"`
+	ID   string             `json:"id"`
+
+	// Class is the instance/volume type e.g. 't3.large' or 'gp2'.
+	Class string `json:"class"`
+}
+
+// CreditSample is the minimum credit balance of a resource during a single period.
+type CreditSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Minimum   float64   `json:"minimum"`
+}
+
+// exhausted returns a boolean indicating whether credits were exhausted during this period.
+func (c CreditSample) exhausted() bool {
+	return c.Minimum < creditsExhausted
+}
+
+// CreditBalance is the credit balance of a resource sampled over the course of a benchmark.
+type CreditBalance struct {
+	Resource *CreditResource `json:"resource"`
+	Samples  []CreditSample  `json:"samples,omitempty"`
+}
+
+// Minimum returns the minimum balance seen for the resource, or -1 if there are no samples.
+func (c *CreditBalance) Minimum() float64 {
+	if len(c.Samples) == 0 {
+		return -1
+	}
+
+	minimum := c.Samples[0].Minimum
+
+	for _, sample := range c.Samples[1:] {
+		if sample.Minimum < minimum {
+			minimum = sample.Minimum
+		}
+	}
+
+	return minimum
+}
+
+// ExhaustedDuring returns a boolean indicating whether the credits of the resource were exhausted during a period
+// overlapping the given time range.
+//
+// NOTE: Samples are relatively coarse, so a result may be flagged when credits were exhausted just before/after.
+func (c *CreditBalance) ExhaustedDuring(start time.Time, duration time.Duration) bool {
+	end := start.Add(duration)
+
+	for _, sample := range c.Samples {
+		if sample.exhausted() && sample.Timestamp.Before(end) && sample.Timestamp.Add(CreditsPeriod).After(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreditBalances is a wrapper around a slice of credit balances which provides some utility functions.
+type CreditBalances []*CreditBalance
+
+// Affected returns the (one indexed) iterations whose results were affected by credit exhaustion.
+func (c CreditBalances) Affected(results BenchmarkResults) []int {
+	affected := make([]int, 0)
+
+	for index, result := range results {
+		for _, balance := range c {
+			if balance.ExhaustedDuring(result.Start, result.Duration) {
+				affected = append(affected, index+1)
+				break
+			}
+		}
+	}
+
+	return affected
+}
+
+// String returns a string representation of the credit balances which will be output in the report.
+func (c CreditBalances) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintf(writer, "| Host\t Resource\t Type\t ID\t Minimum Balance\t\n")
+
+	for _, balance := range c {
+		minimum := "unknown"
+		if value := balance.Minimum(); value >= 0 {
+			minimum = strconv.FormatFloat(value, 'f', 2, 64) + balance.Resource.Type.unit()
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t\n", balance.Resource.Host, balance.Resource.Type,
+			balance.Resource.Class, balance.Resource.ID, minimum)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -31,6 +31,7 @@ type DataLoaderType string
 const (
 	CBM         DataLoaderType = "cbbackupmgr"
 	Pillowfight DataLoaderType = "pillowfight"
+	CBImport    DataLoaderType = "cbimport"
 )
 
 // DataBlueprint encapsulates all the options available when populating a bucket with benchmarking data.
@@ -41,6 +42,21 @@ type DataBlueprint struct {
 	Size         int            `json:"size,omitempty" yaml:"size,omitempty"`
 	Compressible bool           `json:"compressible,omitempty" yaml:"compressible,omitempty"`
 	LoadThreads  int            `json:"load_threads,omitempty" yaml:"load_threads,omitempty"`
+
+	// KeyPattern/KeyGroups describe how the keys are generated, see 'KeyPattern'. Key patterns are only supported when
+	// loading data using 'cbbackupmgr'.
+	KeyPattern KeyPattern `json:"key_pattern,omitempty" yaml:"key_pattern,omitempty"`
+	KeyGroups  int        `json:"key_groups,omitempty" yaml:"key_groups,omitempty"`
+
+	// Similarity is the percentage of each document which is shared with the other documents, a zero value uses the
+	// 'cbbackupmgr' generator (random data, unless compressible); see 'CommandGenerateSimilar'.
+	Similarity int `json:"similarity,omitempty" yaml:"similarity,omitempty"`
+
+	// Indexes are the GSI indexes created once the dataset is loaded, restores are timed until they've been rebuilt.
+	Indexes []*IndexBlueprint `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+
+	// Import is the dataset imported when loading data using 'cbimport', the items/size are ignored.
+	Import *ImportBlueprint `json:"import,omitempty" yaml:"import,omitempty"`
 }
 
 // String returns a string representation of the blueprint which will be output in the report.
@@ -60,17 +76,78 @@ func (d *DataBlueprint) String() string {
 		activeItems = message.NewPrinter(language.English).Sprintf("%d", d.ActiveItems)
 	}
 
+	keyPattern := "N/A"
+	if d.DataLoader != Pillowfight {
+		keyPattern = string(d.KeyPatternOrDefault())
+	}
+
+	if d.KeyPatternOrDefault() == KeyPatternGrouped {
+		keyPattern += fmt.Sprintf(" (%d groups)", d.KeyGroups)
+	}
+
+	similarity := "N/A"
+	if d.Similarity != 0 {
+		similarity = fmt.Sprintf("%d%%", d.Similarity)
+	}
+
+	loader := string(d.DataLoader)
+	if d.DataLoader == CBImport && d.Import != nil {
+		loader += fmt.Sprintf(" (%s)", d.Import)
+	}
+
 	fmt.Fprintln(buffer, "| Data\n| ----")
-	fmt.Fprintf(writer, "| Data Loader\t Items\t Active Items\t Size\t Compressible\t Load Threads\t\n")
-	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %t\t %s\t\n",
-		d.DataLoader,
+	fmt.Fprintf(writer,
+		"| Data Loader\t Items\t Active Items\t Size\t Compressible\t Similarity\t Load Threads\t Key Pattern\t "+
+			"GSI Indexes\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %t\t %s\t %s\t %s\t %d\t\n",
+		loader,
 		message.NewPrinter(language.English).Sprintf("%d", d.Items),
 		activeItems,
 		format.Bytes(uint64(d.Size)),
 		d.Compressible,
-		threads)
+		similarity,
+		threads,
+		keyPattern,
+		len(d.Indexes))
 
 	_ = writer.Flush()
 
 	return buffer.String()
 }
+
+// LoadMode describes how the dataset is loaded into a bucket which may already contain data, by default the bucket is
+// flushed and the dataset loaded from scratch.
+type LoadMode struct {
+	// Resume only loads the items which are missing from the bucket (e.g. after an interrupted load) rather than
+	// flushing the bucket and starting again.
+	Resume bool
+
+	// TopUp is the number of additional items which are loaded into the existing dataset.
+	TopUp int
+}
+
+// Flush returns a boolean indicating whether the bucket is flushed before loading the dataset.
+func (l LoadMode) Flush() bool {
+	return !l.Resume && l.TopUp == 0
+}
+
+// Validate returns an error if the load mode is invalid, or isn't supported by the data loader.
+func (l LoadMode) Validate(data *DataBlueprint) error {
+	if l.Resume && l.TopUp != 0 {
+		return fmt.Errorf("resuming and topping up a load are mutually exclusive")
+	}
+
+	if l.TopUp < 0 {
+		return fmt.Errorf("the number of items to top up must be positive")
+	}
+
+	if !l.Flush() && data.DataLoader == Pillowfight {
+		return fmt.Errorf("resuming/topping up a load is not supported when loading data with 'cbc-pillowfight'")
+	}
+
+	if !l.Flush() && data.DataLoader == CBImport {
+		return fmt.Errorf("resuming/topping up a load is not supported when importing data with 'cbimport'")
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+const (
+	// DefaultDiskIOInterval is the default number of seconds between disk IO samples.
+	DefaultDiskIOInterval = 5
+
+	// DefaultSaturation is the default utilization percentage at which a device is considered saturated.
+	DefaultSaturation = 90
+
+	// sectorSize is the size of the sectors counted in '/proc/diskstats', this is always 512 regardless of the device.
+	sectorSize = 512
+)
+
+// DiskIOConfig enables attributing the IO during each backup/restore to the block devices backing the data/index
+// paths of the cluster nodes, the archive of the backup client and the root filesystem of each host.
+type DiskIOConfig struct {
+	// Interval is the number of seconds between each sample of the IO counters.
+	Interval int `json:"interval,omitempty" yaml:"interval,omitempty"`
+
+	// Saturation is the utilization percentage (time the device was busy) at which a device is considered saturated.
+	Saturation int `json:"saturation,omitempty" yaml:"saturation,omitempty"`
+}
+
+// IntervalOrDefault returns the duration between each sample.
+func (d *DiskIOConfig) IntervalOrDefault() time.Duration {
+	if d.Interval == 0 {
+		return DefaultDiskIOInterval * time.Second
+	}
+
+	return time.Duration(d.Interval) * time.Second
+}
+
+// SaturationOrDefault returns the utilization percentage at which a device is considered saturated.
+func (d *DiskIOConfig) SaturationOrDefault() float64 {
+	if d.Saturation == 0 {
+		return DefaultSaturation
+	}
+
+	return float64(d.Saturation)
+}
+
+// DiskRole is the purpose of a path whose IO is attributed to the device backing it.
+type DiskRole string
+
+const (
+	// DiskRoleData is the data path of a cluster node.
+	DiskRoleData DiskRole = "data"
+
+	// DiskRoleIndex is the index path of a cluster node.
+	DiskRoleIndex DiskRole = "index"
+
+	// DiskRoleArchive is the archive (or staging directory when using cloud storage) of the backup client.
+	DiskRoleArchive DiskRole = "archive"
+
+	// DiskRoleRoot is the root filesystem of a host.
+	DiskRoleRoot DiskRole = "root"
+)
+
+// DiskRoles is every role in the order they're displayed, the archive is displayed next to the data paths so that the
+// two sides of the backup/restore may be easily compared.
+var DiskRoles = []DiskRole{DiskRoleData, DiskRoleIndex, DiskRoleArchive, DiskRoleRoot}
+
+// order returns the position of the role in the report.
+func (d DiskRole) order() int {
+	for idx, role := range DiskRoles {
+		if role == d {
+			return idx
+		}
+	}
+
+	return len(DiskRoles)
+}
+
+// CommandBlockDevices returns a command which outputs the kernel name (e.g. 'nvme1n1', 'dm-0') of the block device
+// backing each of the given paths, one per line; a '-' is output for paths which aren't backed by a block device.
+//
+// NOTE: Paths which don't exist yet (e.g. the archive before the first backup) are resolved using their closest
+// existing parent.
+func CommandBlockDevices(paths ...string) Command {
+	var commands []string
+
+	for _, path := range paths {
+		commands = append(commands, fmt.Sprintf(`p=%s; while [ ! -e "$p" ]; do p=$(dirname "$p"); done;
+			lsblk -ndo KNAME $(df --output=source "$p" | tail -1) 2>/dev/null | head -1 | grep . || echo -`, path))
+	}
+
+	return NewCommand("%s", strings.Join(commands, "; "))
+}
+
+// ParseBlockDevices parses the output of the command returned by 'CommandBlockDevices' for the given number of paths,
+// an empty string is returned for paths which aren't backed by a block device.
+func ParseBlockDevices(output []byte, paths int) ([]string, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != paths {
+		return nil, fmt.Errorf("unexpected output '%s'", bytes.TrimSpace(output))
+	}
+
+	devices := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "-" {
+			line = ""
+		}
+
+		devices = append(devices, line)
+	}
+
+	return devices, nil
+}
+
+// CommandDiskStats returns a command which outputs the IO counters of every block device on the host.
+func CommandDiskStats() Command {
+	return NewCommand("cat /proc/diskstats")
+}
+
+// DiskCounters are the cumulative IO counters of a block device.
+type DiskCounters struct {
+	ReadBytes  uint64
+	WriteBytes uint64
+	Busy       time.Duration
+}
+
+// Sub returns the IO which took place between the given (earlier) counters and these counters.
+func (d DiskCounters) Sub(earlier DiskCounters) DiskCounters {
+	// The counters are cumulative, but wrap on 32-bit kernels; treat a wrapped counter as no IO
+	if d.ReadBytes < earlier.ReadBytes || d.WriteBytes < earlier.WriteBytes || d.Busy < earlier.Busy {
+		return DiskCounters{}
+	}
+
+	return DiskCounters{
+		ReadBytes:  d.ReadBytes - earlier.ReadBytes,
+		WriteBytes: d.WriteBytes - earlier.WriteBytes,
+		Busy:       d.Busy - earlier.Busy,
+	}
+}
+
+// ParseDiskStats parses the output of the command returned by 'CommandDiskStats' returning the counters for each
+// device; see https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats.
+func ParseDiskStats(output []byte) (map[string]DiskCounters, error) {
+	counters := make(map[string]DiskCounters)
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			return nil, fmt.Errorf("unexpected line '%s'", line)
+		}
+
+		var values [3]uint64
+
+		for idx, field := range []int{5, 9, 12} {
+			parsed, err := strconv.ParseUint(fields[field], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse counter for device '%s': %w", fields[2], err)
+			}
+
+			values[idx] = parsed
+		}
+
+		counters[fields[2]] = DiskCounters{
+			ReadBytes:  values[0] * sectorSize,
+			WriteBytes: values[1] * sectorSize,
+			Busy:       time.Duration(values[2]) * time.Millisecond,
+		}
+	}
+
+	return counters, nil
+}
+
+// DiskIO is the IO attributed to a single block device during a backup/restore.
+type DiskIO struct {
+	Host   string     `json:"host"`
+	Device string     `json:"device"`
+	Roles  []DiskRole `json:"roles"`
+
+	// Duration is the time between the first and last sample of the counters.
+	Duration time.Duration `json:"duration"`
+
+	ReadBytes  uint64        `json:"read_bytes"`
+	WriteBytes uint64        `json:"write_bytes"`
+	Busy       time.Duration `json:"busy"`
+
+	// Peak is the highest utilization percentage between two samples.
+	Peak float64 `json:"peak_utilization"`
+
+	// Saturated is how long after the start of the backup/restore the device first became saturated, a zero value
+	// indicates the device never became saturated.
+	Saturated time.Duration `json:"saturated_after,omitempty"`
+}
+
+// Record adds the IO which took place during the interval ending at the given elapsed time, the device is marked as
+// saturated the first time its utilization reaches the given percentage.
+func (d *DiskIO) Record(delta DiskCounters, elapsed, interval time.Duration, saturation float64) {
+	if interval <= 0 {
+		return
+	}
+
+	d.Duration = elapsed
+	d.ReadBytes += delta.ReadBytes
+	d.WriteBytes += delta.WriteBytes
+	d.Busy += delta.Busy
+
+	utilization := percentage(delta.Busy, interval)
+	if utilization > d.Peak {
+		d.Peak = utilization
+	}
+
+	if d.Saturated == 0 && utilization >= saturation {
+		d.Saturated = elapsed
+	}
+}
+
+// Utilization returns the percentage of the backup/restore the device was busy.
+func (d *DiskIO) Utilization() float64 {
+	if d.Duration <= 0 {
+		return 0
+	}
+
+	return percentage(d.Busy, d.Duration)
+}
+
+// percentage returns the given busy time as a percentage of the given duration, capped at 100 since the busy time is
+// only updated when IO completes so may briefly exceed the time between samples.
+func percentage(busy, duration time.Duration) float64 {
+	return math.Min(100, float64(busy)/float64(duration)*100)
+}
+
+// RoleString returns the roles of the paths backed by the device e.g. 'data/root'.
+func (d *DiskIO) RoleString() string {
+	roles := make([]string, 0, len(d.Roles))
+	for _, role := range d.Roles {
+		roles = append(roles, string(role))
+	}
+
+	return strings.Join(roles, "/")
+}
+
+// rate returns a human readable throughput for the given number of bytes transferred during the backup/restore.
+func (d *DiskIO) rate(transferred uint64) string {
+	if d.Duration <= 0 {
+		return "-"
+	}
+
+	return format.Bytes(uint64(float64(transferred)/d.Duration.Seconds())) + "/s"
+}
+
+// DiskUsage is the IO attributed to each block device during a single benchmark iteration.
+type DiskUsage struct {
+	Iteration int       `json:"iteration"`
+	Devices   []*DiskIO `json:"devices"`
+}
+
+// SortDiskIO sorts the given devices by role then host, so that the data paths/archive are displayed first.
+func SortDiskIO(devices []*DiskIO) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		ri, rj := devices[i].Roles[0].order(), devices[j].Roles[0].order()
+		if ri != rj {
+			return ri < rj
+		}
+
+		return devices[i].Host < devices[j].Host
+	})
+}
+
+// FirstSaturated returns the device which became saturated first, nil is returned if no device became saturated.
+func (d *DiskUsage) FirstSaturated() *DiskIO {
+	var first *DiskIO
+
+	for _, device := range d.Devices {
+		if device.Saturated != 0 && (first == nil || device.Saturated < first.Saturated) {
+			first = device
+		}
+	}
+
+	return first
+}
+
+// DiskUsages is a wrapper around the disk IO of each benchmark iteration.
+type DiskUsages []*DiskUsage
+
+// DiskUsages returns the disk IO of each of the results, nil is returned if disk IO monitoring wasn't enabled.
+func (b BenchmarkResults) DiskUsages() DiskUsages {
+	var usages DiskUsages
+
+	for idx, result := range b {
+		if len(result.DiskIO) != 0 {
+			usages = append(usages, &DiskUsage{Iteration: idx + 1, Devices: result.DiskIO})
+		}
+	}
+
+	return usages
+}
+
+// String returns a human readable string representation of the disk IO which will be displayed in the report.
+func (d DiskUsages) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+		notes  []string
+	)
+
+	fmt.Fprintln(buffer, "| Disk IO\n| -------")
+	fmt.Fprintf(writer, "| Iteration\t Role\t Host\t Device\t Read\t Write\t Utilization\t Peak\t Saturated After\t\n")
+
+	for _, usage := range d {
+		for _, device := range usage.Devices {
+			saturated := "-"
+			if device.Saturated != 0 {
+				saturated = format.Duration(device.Saturated)
+			}
+
+			fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %s\t %.0f%%\t %.0f%%\t %s\t\n", usage.Iteration,
+				device.RoleString(), device.Host, device.Device, device.rate(device.ReadBytes),
+				device.rate(device.WriteBytes), device.Utilization(), device.Peak, saturated)
+		}
+
+		if first := usage.FirstSaturated(); first != nil {
+			notes = append(notes, fmt.Sprintf("NOTE: during iteration %d the %s device on %s saturated first, after %s",
+				usage.Iteration, first.RoleString(), first.Host, format.Duration(first.Saturated)))
+		}
+	}
+
+	_ = writer.Flush()
+
+	if len(notes) != 0 {
+		fmt.Fprintf(buffer, "\n%s", strings.Join(notes, "\n"))
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
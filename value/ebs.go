@@ -0,0 +1,228 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultEBSVolumeType is the volume type used when one isn't provided.
+const DefaultEBSVolumeType = "gp3"
+
+// EBSVolumeBlueprint describes the type/performance of an EBS volume; either for volumes created when launching an
+// instance, or when modifying the volumes attached to an existing instance.
+type EBSVolumeBlueprint struct {
+	// Device is the device name the volume is attached as e.g. '/dev/sdf'.
+	Device string `json:"device" yaml:"device,omitempty"`
+
+	// Type is the volume type e.g. 'gp3' (default) or 'io2'.
+	Type string `json:"type" yaml:"type,omitempty"`
+
+	// Size is the size of the volume in GiB, required when creating a volume.
+	Size int `json:"size,omitempty" yaml:"size,omitempty"`
+
+	// IOPS is the provisioned IOPS, required for 'io1'/'io2' and optional for 'gp3' (the AWS default is used when
+	// omitted).
+	IOPS int `json:"iops,omitempty" yaml:"iops,omitempty"`
+
+	// Throughput is the provisioned throughput in MiB/s, only supported by 'gp3'.
+	Throughput int `json:"throughput,omitempty" yaml:"throughput,omitempty"`
+}
+
+// TypeOrDefault returns the volume type.
+func (e *EBSVolumeBlueprint) TypeOrDefault() string {
+	if e.Type == "" {
+		return DefaultEBSVolumeType
+	}
+
+	return e.Type
+}
+
+// Validate returns an error if the volume settings aren't supported by the volume type.
+func (e *EBSVolumeBlueprint) Validate() error {
+	if e.Device == "" {
+		return errors.New("a device must be provided")
+	}
+
+	switch volumeType := e.TypeOrDefault(); volumeType {
+	case "gp3":
+	case "io1", "io2":
+		if e.IOPS == 0 {
+			return fmt.Errorf("iops must be provided for '%s' volumes", volumeType)
+		}
+	case "gp2", "st1", "sc1":
+		if e.IOPS != 0 {
+			return fmt.Errorf("iops can't be provisioned for '%s' volumes", volumeType)
+		}
+	default:
+		return fmt.Errorf("unsupported volume type '%s'", volumeType)
+	}
+
+	if e.Throughput != 0 && e.TypeOrDefault() != "gp3" {
+		return errors.New("throughput can only be provisioned for 'gp3' volumes")
+	}
+
+	return nil
+}
+
+// ArgsModify returns the arguments which should be passed to the 'aws' CLI (after 'aws ec2 modify-volume') to modify
+// the volume with the given id to match this blueprint.
+func (e *EBSVolumeBlueprint) ArgsModify(id string) []string {
+	args := []string{"--volume-id", id, "--volume-type", e.TypeOrDefault()}
+
+	if e.Size != 0 {
+		args = append(args, "--size", strconv.Itoa(e.Size))
+	}
+
+	if e.IOPS != 0 {
+		args = append(args, "--iops", strconv.Itoa(e.IOPS))
+	}
+
+	if e.Throughput != 0 {
+		args = append(args, "--throughput", strconv.Itoa(e.Throughput))
+	}
+
+	return args
+}
+
+// Matches returns a boolean indicating whether a volume with the given settings already matches this blueprint, EBS
+// volumes may only be modified once every six hours so unnecessary modifications must be avoided.
+func (e *EBSVolumeBlueprint) Matches(volumeType string, size, iops, throughput int) bool {
+	return volumeType == e.TypeOrDefault() &&
+		(e.Size == 0 || e.Size == size) &&
+		(e.IOPS == 0 || e.IOPS == iops) &&
+		(e.Throughput == 0 || e.Throughput == throughput)
+}
+
+// EBSVolumes is a wrapper around a slice of volume blueprints which provides some utility functions.
+type EBSVolumes []*EBSVolumeBlueprint
+
+// Validate returns an error if any of the volumes are invalid.
+func (e EBSVolumes) Validate() error {
+	for _, volume := range e {
+		err := volume.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid volume '%s': %w", volume.Device, err)
+		}
+	}
+
+	return nil
+}
+
+// BlockDeviceMappings returns the JSON block device mappings which should be passed to 'aws ec2 run-instances' to
+// create the volumes, they're deleted when the instance is terminated.
+func (e EBSVolumes) BlockDeviceMappings() string {
+	type ebs struct {
+		VolumeSize          int    `json:"VolumeSize,omitempty"`
+		VolumeType          string `json:"VolumeType"`
+		Iops                int    `json:"Iops,omitempty"`
+		Throughput          int    `json:"Throughput,omitempty"`
+		DeleteOnTermination bool   `json:"DeleteOnTermination"`
+	}
+
+	type mapping struct {
+		DeviceName string `json:"DeviceName"`
+		Ebs        ebs    `json:"Ebs"`
+	}
+
+	mappings := make([]mapping, 0, len(e))
+
+	for _, volume := range e {
+		mappings = append(mappings, mapping{
+			DeviceName: volume.Device,
+			Ebs: ebs{
+				VolumeSize:          volume.Size,
+				VolumeType:          volume.TypeOrDefault(),
+				Iops:                volume.IOPS,
+				Throughput:          volume.Throughput,
+				DeleteOnTermination: true,
+			},
+		})
+	}
+
+	// Marshalling a slice of plain structs can't fail
+	data, _ := json.Marshal(mappings)
+
+	return string(data)
+}
+
+// String returns a string representation of the volumes which will be output in the report.
+func (e EBSVolumes) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintf(writer, "| Device\t Type\t Size\t IOPS\t Throughput\t\n")
+
+	for _, volume := range e {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t\n", volume.Device, volume.TypeOrDefault(),
+			defaultable(volume.Size, "GiB"), defaultable(volume.IOPS, ""), defaultable(volume.Throughput, "MiB/s"))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// EBSBlueprint describes the type/performance the EBS volumes attached to an existing instance should have, they're
+// modified using the 'aws' CLI (which must be installed/configured locally) when the node is provisioned.
+type EBSBlueprint struct {
+	AWSConfig `yaml:",inline"`
+
+	Volumes EBSVolumes `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+}
+
+// ArgsDescribe returns the arguments which should be passed to the 'aws' CLI to describe the volume attached to the
+// given instance as the given device.
+func (e *EBSBlueprint) ArgsDescribe(instanceID, device string) []string {
+	return append([]string{
+		"ec2", "describe-volumes", "--output", "json", "--query", "Volumes[]",
+		"--filters", "Name=attachment.instance-id,Values=" + instanceID, "Name=attachment.device,Values=" + device,
+	}, e.ArgsGlobal()...)
+}
+
+// ArgsModify returns the arguments which should be passed to the 'aws' CLI to modify the volume with the given id.
+func (e *EBSBlueprint) ArgsModify(volume *EBSVolumeBlueprint, id string) []string {
+	return append(append([]string{"ec2", "modify-volume"}, volume.ArgsModify(id)...), e.ArgsGlobal()...)
+}
+
+// ArgsGlobal returns the global arguments which should be passed to the 'aws' CLI.
+func (e *EBSBlueprint) ArgsGlobal() []string {
+	return awsArgs(e.AWSConfig)
+}
+
+// CommandInstanceID returns a command which outputs the id of the EC2 instance it's run on using the instance metadata
+// service (IMDSv2).
+func CommandInstanceID() Command {
+	return NewCommand(`token=$(curl -s -X PUT http://169.254.169.254/latest/api/token \
+		-H 'X-aws-ec2-metadata-token-ttl-seconds: 60');
+		curl -s -H "X-aws-ec2-metadata-token: $token" http://169.254.169.254/latest/meta-data/instance-id`)
+}
+
+// defaultable returns the given value with its unit, or 'default' when it's not been provided.
+func defaultable(value int, unit string) string {
+	if value == 0 {
+		return "default"
+	}
+
+	return fmt.Sprintf("%d%s", value, unit)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edition represents the edition of Couchbase Server contained in a package.
+type Edition string
+
+const (
+	// EditionEnterprise is Couchbase Server Enterprise Edition.
+	EditionEnterprise Edition = "enterprise"
+
+	// EditionCommunity is Couchbase Server Community Edition, some features (e.g. cloud backups) are unavailable.
+	EditionCommunity Edition = "community"
+
+	// EditionUnknown is returned when the edition could not be determined from the package name.
+	EditionUnknown Edition = "unknown"
+)
+
+// extractEdition will extract the edition from the provided package path e.g.
+// 'couchbase-server-enterprise_7.0.0-4259-ubuntu20.04_amd64.deb'.
+func extractEdition(p string) Edition {
+	name := strings.ToLower(LocalBase(p))
+
+	switch {
+	case strings.Contains(name, string(EditionEnterprise)):
+		return EditionEnterprise
+	case strings.Contains(name, string(EditionCommunity)):
+		return EditionCommunity
+	}
+
+	return EditionUnknown
+}
+
+// Edition returns the edition of Couchbase Server contained in the package, determined using the package name.
+func (p *Package) Edition() Edition {
+	return extractEdition(p.Path)
+}
+
+// enterpriseFeatures returns the names of the Enterprise Edition only features that are used by the config.
+func (c *CBMConfig) enterpriseFeatures() []string {
+	features := make([]string, 0)
+
+	if c.ObjStagingDirectory != "" {
+		features = append(features, "cloud backups")
+	}
+
+	if c.Encrypted {
+		features = append(features, "encrypted backups")
+	}
+
+	if c.PiTR {
+		features = append(features, "point-in-time backups")
+	}
+
+	return features
+}
+
+// ValidateEdition returns an error if the config uses features which aren't available in the given edition of
+// 'cbbackupmgr'.
+//
+// NOTE: When the edition is unknown we assume the user knows what they're doing, 'cbbackupmgr' will fail otherwise.
+func (c *CBMConfig) ValidateEdition(edition Edition) error {
+	features := c.enterpriseFeatures()
+	if edition != EditionCommunity || len(features) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s require Couchbase Server Enterprise Edition, but the backup client package is Community "+
+		"Edition", strings.Join(features, ", "))
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Split returns a blueprint for each of the environments which should be provisioned/benchmarked, when no environments
+// are defined the blueprint itself is the only environment.
+func (b *Blueprint) Split() []*Blueprint {
+	if len(b.Environments) == 0 {
+		return []*Blueprint{b}
+	}
+
+	return b.Environments
+}
+
+// MultipleEnvironments returns a boolean indicating whether the blueprint defines multiple named environments.
+func (b *Blueprint) MultipleEnvironments() bool {
+	return len(b.Environments) != 0
+}
+
+// ValidateEnvironments returns an error if the named environments are ambiguous e.g. they're missing names or are
+// mixed with a top-level cluster/backup client.
+func (b *Blueprint) ValidateEnvironments() error {
+	if len(b.Environments) == 0 {
+		return nil
+	}
+
+	if b.Cluster != nil || b.BackupClient != nil {
+		return errors.New("a cluster/backup client must not be provided alongside environments")
+	}
+
+	names := make(map[string]struct{}, len(b.Environments))
+
+	for _, environment := range b.Environments {
+		if environment.Name == "" {
+			return errors.New("every environment must have a name")
+		}
+
+		if _, ok := names[environment.Name]; ok {
+			return fmt.Errorf("duplicate environment '%s'", environment.Name)
+		}
+
+		if len(environment.Environments) != 0 {
+			return fmt.Errorf("environment '%s' must not contain nested environments", environment.Name)
+		}
+
+		if environment.Cluster == nil || environment.BackupClient == nil {
+			return fmt.Errorf("environment '%s' must have a cluster and a backup client", environment.Name)
+		}
+
+		names[environment.Name] = struct{}{}
+	}
+
+	return nil
+}
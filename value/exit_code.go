@@ -0,0 +1,94 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ExitCode is the code which cbtools-autobench exits with, each category of error has a distinct exit code so that any
+// wrapping automation may react appropriately without parsing the error.
+type ExitCode int
+
+const (
+	// ExitCodeSuccess indicates that the sub-command completed successfully.
+	ExitCodeSuccess ExitCode = 0
+
+	// ExitCodeFailure indicates that the sub-command failed for a reason which doesn't fall into any other category.
+	ExitCodeFailure ExitCode = 1
+
+	// ExitCodeConfig indicates that the config/flags are invalid, retrying without changing them won't help.
+	ExitCodeConfig ExitCode = 2
+
+	// ExitCodeProvision indicates that a remote machine couldn't be reached (e.g. via ssh) or provisioned.
+	ExitCodeProvision ExitCode = 3
+
+	// ExitCodeBenchmark indicates that a benchmark failed e.g. a benchmarked tool crashed or the cluster health
+	// degraded.
+	ExitCodeBenchmark ExitCode = 4
+
+	// ExitCodeRegression indicates that the benchmarks completed, but the results breached the configured thresholds.
+	ExitCodeRegression ExitCode = 5
+
+	// ExitCodeAborted indicates that the run was aborted by the user e.g. the destructive actions weren't confirmed or
+	// the benchmarks were interrupted.
+	ExitCodeAborted ExitCode = 6
+)
+
+// CategorizedError is an error which has been placed in a category, determining the code cbtools-autobench exits with.
+type CategorizedError struct {
+	Code ExitCode
+	Err  error
+}
+
+// Categorize returns the given error placed in the category for the provided exit code, nil is returned when the error
+// is nil.
+func Categorize(code ExitCode, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &CategorizedError{Code: code, Err: err}
+}
+
+// Error implements the 'error' interface.
+func (c *CategorizedError) Error() string {
+	return c.Err.Error()
+}
+
+// Unwrap returns the categorized error, allowing it to be inspected using 'errors.Is/As'.
+func (c *CategorizedError) Unwrap() error {
+	return c.Err
+}
+
+// Cause returns the categorized error, allowing 'errors.Cause' to find the root cause.
+func (c *CategorizedError) Cause() error {
+	return c.Err
+}
+
+// ExitCodeOf returns the exit code for the given error; this is the code of the outermost category, or a generic
+// failure when the error hasn't been categorized.
+func ExitCodeOf(err error) ExitCode {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Code
+	}
+
+	return ExitCodeFailure
+}
@@ -0,0 +1,275 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultExpirySample is the default number of documents whose expiry is validated after each restore.
+const DefaultExpirySample = 100
+
+// ExpiryValidationConfig configures validating that restored documents retained their expiry (TTL), a sample of the
+// documents in the benchmarking bucket is taken before the backup is created and compared after each restore.
+type ExpiryValidationConfig struct {
+	// Sample is the number of randomly chosen documents whose expiry is validated, duplicates are only checked once.
+	Sample int `json:"sample,omitempty" yaml:"sample,omitempty"`
+
+	// TTL is an expiry (in seconds) set on the sampled documents before the backup is created, when omitted the
+	// expiries from the dataset are compared.
+	TTL int `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// SampleOrDefault returns the number of documents whose expiry is validated.
+func (e *ExpiryValidationConfig) SampleOrDefault() int {
+	if e.Sample == 0 {
+		return DefaultExpirySample
+	}
+
+	return e.Sample
+}
+
+// Validate returns an error if the expiry validation config is invalid.
+func (e *ExpiryValidationConfig) Validate() error {
+	if e.Sample < 0 {
+		return errors.New("expiry validation sample must not be negative")
+	}
+
+	if e.TTL < 0 {
+		return errors.New("expiry validation TTL must not be negative")
+	}
+
+	return nil
+}
+
+// CommandRandomKeys returns a command which outputs the given number of randomly chosen keys from the benchmarking
+// bucket using the REST API at the given address, one response per line.
+//
+// NOTE: The URL is globbed by 'curl' so that the keys are fetched using a single command/connection.
+func CommandRandomKeys(host string, credentials *Credentials, n int) Command {
+	return NewCommand(`curl -s -w '\n' -u %s 'http://%s/pools/default/buckets/default/localRandomKey?sample=[1-%d]'`,
+		credentials.UserInfo(), host, n)
+}
+
+// ParseRandomKeys parses the output of 'CommandRandomKeys', returning the distinct keys in sorted order.
+func ParseRandomKeys(output []byte) ([]string, error) {
+	type overlay struct {
+		OK  bool   `json:"ok"`
+		Key string `json:"key"`
+	}
+
+	unique := make(map[string]struct{})
+
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var decoded overlay
+
+		err := json.Unmarshal([]byte(line), &decoded)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal response")
+		}
+
+		if !decoded.OK {
+			return nil, fmt.Errorf("failed to get random key: %s", line)
+		}
+
+		unique[decoded.Key] = struct{}{}
+	}
+
+	keys := make([]string, 0, len(unique))
+
+	for key := range unique {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// StatementExpiries returns a N1QL statement which selects the expiry of each of the given keys, no index is required.
+func StatementExpiries(keys []string) string {
+	return fmt.Sprintf("SELECT META(d).id AS id, META(d).expiration AS expiration FROM default AS d USE KEYS %s",
+		useKeys(keys))
+}
+
+// StatementSetExpiry returns a N1QL statement which sets the expiry of each of the given keys to the given TTL.
+func StatementSetExpiry(keys []string, ttl int) string {
+	return fmt.Sprintf("UPDATE default USE KEYS %s SET META().expiration = %d", useKeys(keys), ttl)
+}
+
+// useKeys returns the given keys formatted as a N1QL array.
+func useKeys(keys []string) string {
+	data, _ := json.Marshal(keys)
+	return string(data)
+}
+
+// ParseExpiries parses the response from the query service for 'StatementExpiries', returning the expiry of each
+// document which was found by key.
+func ParseExpiries(output []byte) (map[string]uint64, error) {
+	_, err := ParseQueryResultCount(output)
+	if err != nil {
+		return nil, err
+	}
+
+	type overlay struct {
+		Results []struct {
+			ID         string `json:"id"`
+			Expiration uint64 `json:"expiration"`
+		} `json:"results"`
+	}
+
+	var decoded overlay
+
+	err = json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	expiries := make(map[string]uint64, len(decoded.Results))
+
+	for _, result := range decoded.Results {
+		expiries[result.ID] = result.Expiration
+	}
+
+	return expiries, nil
+}
+
+// ExpiryMismatch is a sampled document whose expiry after a restore didn't match its expiry before the backup.
+type ExpiryMismatch struct {
+	Key      string `json:"key"`
+	Expected uint64 `json:"expected"`
+	Actual   uint64 `json:"actual"`
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+// ExpiryCheck is the result of comparing the expiry of the sampled documents after a restore.
+type ExpiryCheck struct {
+	Sampled    int               `json:"sampled"`
+	Mismatches []*ExpiryMismatch `json:"mismatches,omitempty"`
+}
+
+// NewExpiryCheck compares the expiries of the sampled documents after a restore against those before the backup.
+//
+// NOTE: A document which expired before it was restored is reported as missing.
+func NewExpiryCheck(expected, actual map[string]uint64) *ExpiryCheck {
+	check := &ExpiryCheck{Sampled: len(expected)}
+
+	keys := make([]string, 0, len(expected))
+
+	for key := range expected {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		expiry, ok := actual[key]
+		if ok && expiry == expected[key] {
+			continue
+		}
+
+		check.Mismatches = append(check.Mismatches,
+			&ExpiryMismatch{Key: key, Expected: expected[key], Actual: expiry, Missing: !ok})
+	}
+
+	return check
+}
+
+// Passed returns a boolean indicating whether every sampled document retained its expiry.
+func (e *ExpiryCheck) Passed() bool {
+	return len(e.Mismatches) == 0
+}
+
+// ExpiryValidation is the expiry check run after a single restore iteration.
+type ExpiryValidation struct {
+	Iteration int          `json:"iteration"`
+	Check     *ExpiryCheck `json:"check"`
+}
+
+// ExpiryValidations is a wrapper around the expiry checks run after each restore benchmark.
+type ExpiryValidations []*ExpiryValidation
+
+// ExpiryValidations returns the expiry checks run after each of the results, nil is returned if none were run.
+func (b BenchmarkResults) ExpiryValidations() ExpiryValidations {
+	var validations ExpiryValidations
+
+	for idx, result := range b {
+		if result.Expiry != nil {
+			validations = append(validations, &ExpiryValidation{Iteration: idx + 1, Check: result.Expiry})
+		}
+	}
+
+	return validations
+}
+
+// String returns a human readable string representation of the expiry validations which will be displayed in the
+// report, each mismatch is listed below the summary.
+func (e ExpiryValidations) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Expiry Validation\n| -----------------")
+	fmt.Fprintf(writer, "| Iteration\t Sampled\t Mismatches\t Passed\t\n")
+
+	for _, validation := range e {
+		fmt.Fprintf(writer, "| %d\t %d\t %d\t %t\t\n", validation.Iteration, validation.Check.Sampled,
+			len(validation.Check.Mismatches), validation.Check.Passed())
+	}
+
+	_ = writer.Flush()
+
+	var mismatches int
+
+	for _, validation := range e {
+		mismatches += len(validation.Check.Mismatches)
+	}
+
+	if mismatches == 0 {
+		return strings.TrimSpace(buffer.String())
+	}
+
+	fmt.Fprintf(buffer, "|\n")
+	fmt.Fprintf(writer, "| Iteration\t Key\t Expected\t Actual\t\n")
+
+	for _, validation := range e {
+		for _, mismatch := range validation.Check.Mismatches {
+			actual := fmt.Sprint(mismatch.Actual)
+			if mismatch.Missing {
+				actual = "missing"
+			}
+
+			fmt.Fprintf(writer, "| %d\t %s\t %d\t %s\t\n", validation.Iteration, mismatch.Key, mismatch.Expected,
+				actual)
+		}
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
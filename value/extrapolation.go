@@ -0,0 +1,167 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// ExtrapolationConfidence indicates how much an extrapolated duration should be trusted.
+type ExtrapolationConfidence string
+
+const (
+	// ExtrapolationConfidenceHigh indicates that the target size is close to the benchmarked size and that the results
+	// were consistent.
+	ExtrapolationConfidenceHigh ExtrapolationConfidence = "high"
+
+	// ExtrapolationConfidenceMedium indicates that the target size is an order of magnitude of the benchmarked size and
+	// that the results were reasonably consistent.
+	ExtrapolationConfidenceMedium ExtrapolationConfidence = "medium"
+
+	// ExtrapolationConfidenceLow indicates that the target size is far beyond the benchmarked size, or that the results
+	// were inconsistent.
+	ExtrapolationConfidenceLow ExtrapolationConfidence = "low"
+)
+
+// ExtrapolationConfig describes the dataset sizes which the benchmark results are extrapolated to, the estimated
+// durations are included in the report.
+type ExtrapolationConfig struct {
+	// TargetSizes are the sizes (in GiB) for which the durations are estimated e.g. 2048 for 2TiB.
+	TargetSizes []int `json:"target_sizes,omitempty" yaml:"target_sizes,omitempty"`
+}
+
+// Extrapolation is the estimated duration of a backup/restore of the target size, based on the measured transfer
+// rates.
+type Extrapolation struct {
+	// TargetSize is the size (in GiB) of the dataset.
+	TargetSize int `json:"target_size"`
+
+	// Expected is the estimated duration using the average transfer rate, whilst Best/Worst use the fastest/slowest
+	// transfer rate of any iteration.
+	Expected time.Duration `json:"expected"`
+	Best     time.Duration `json:"best"`
+	Worst    time.Duration `json:"worst"`
+
+	// Factor is how many times larger the target size is than the benchmarked size.
+	Factor float64 `json:"factor"`
+
+	// Confidence indicates how much the estimate should be trusted.
+	Confidence ExtrapolationConfidence `json:"confidence"`
+}
+
+// Extrapolations is a wrapper around a slice of extrapolations which provides a human readable representation.
+type Extrapolations []*Extrapolation
+
+// Extrapolate estimates the durations for each of the target sizes using the transfer rates of the given results, nil
+// is returned if there are no target sizes or results to extrapolate from.
+//
+// NOTE: The durations are extrapolated linearly, the confidence is lowered the further the results are extrapolated
+// and the more the transfer rates varied between iterations.
+func Extrapolate(config *ExtrapolationConfig, results BenchmarkResults) Extrapolations {
+	if config == nil || len(config.TargetSizes) == 0 || len(results) == 0 {
+		return nil
+	}
+
+	var ads, total, fastest, slowest uint64
+
+	for idx, result := range results {
+		rate := result.AvgTransferRateADS()
+
+		if idx == 0 || rate < slowest {
+			slowest = rate
+		}
+
+		if rate > fastest {
+			fastest = rate
+		}
+
+		ads += result.ADS
+		total += rate
+	}
+
+	average := total / uint64(len(results))
+	ads /= uint64(len(results))
+
+	if ads == 0 || slowest == 0 {
+		return nil
+	}
+
+	variation := float64(fastest-slowest) / float64(average)
+
+	extrapolations := make(Extrapolations, 0, len(config.TargetSizes))
+
+	for _, size := range config.TargetSizes {
+		target := uint64(size) * 1024 * 1024 * 1024
+		factor := float64(target) / float64(ads)
+
+		extrapolations = append(extrapolations, &Extrapolation{
+			TargetSize: size,
+			Expected:   time.Duration(target/average) * time.Second,
+			Best:       time.Duration(target/fastest) * time.Second,
+			Worst:      time.Duration(target/slowest) * time.Second,
+			Factor:     factor,
+			Confidence: extrapolationConfidence(factor, variation),
+		})
+	}
+
+	return extrapolations
+}
+
+// extrapolationConfidence returns the confidence for an estimate which has been extrapolated by the given factor, from
+// results whose transfer rates varied by the given fraction of the average.
+func extrapolationConfidence(factor, variation float64) ExtrapolationConfidence {
+	switch {
+	case factor <= 2 && variation <= 0.1:
+		return ExtrapolationConfidenceHigh
+	case factor <= 10 && variation <= 0.25:
+		return ExtrapolationConfidenceMedium
+	}
+
+	return ExtrapolationConfidenceLow
+}
+
+// String returns a human readable string representation of the extrapolations which will be displayed in the report.
+func (e Extrapolations) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Extrapolation\n| -------------")
+	fmt.Fprintf(writer, "| Target Size\t Expected Duration\t Range (Best - Worst)\t Extrapolated By\t Confidence\t\n")
+
+	for _, extrapolation := range e {
+		fmt.Fprintf(writer, "| %dGiB\t %s\t %s - %s\t %.1fx\t %s\t\n",
+			extrapolation.TargetSize,
+			format.Duration(extrapolation.Expected),
+			format.Duration(extrapolation.Best),
+			format.Duration(extrapolation.Worst),
+			extrapolation.Factor,
+			extrapolation.Confidence)
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprintln(buffer, "\nNOTE: Durations are extrapolated linearly from the measured transfer rates, larger datasets "+
+		"may behave differently e.g. due to a lower resident ratio, disk/network limits or burst credit exhaustion")
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommandFlushCaches returns a command which syncs then drops the page cache, dentries and inodes on the remote
+// machine.
+func CommandFlushCaches() Command {
+	return NewCommand("sync; echo 3 > /proc/sys/vm/drop_caches")
+}
+
+// HostOutput is the output of a command run on a single host, along with any error which occurred.
+type HostOutput struct {
+	Host   string
+	Output []byte
+	Err    error
+}
+
+// HostOutputs is the output of a command which was run on multiple hosts, in the order of the hosts.
+type HostOutputs []*HostOutput
+
+// Failed returns the outputs for the hosts where the command failed.
+func (h HostOutputs) Failed() HostOutputs {
+	failed := make(HostOutputs, 0)
+
+	for _, output := range h {
+		if output.Err != nil {
+			failed = append(failed, output)
+		}
+	}
+
+	return failed
+}
+
+// Err returns a single error describing the failure on each host, nil is returned if the command succeeded on all the
+// hosts.
+func (h HostOutputs) Err() error {
+	failed := h.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	errs := make([]string, 0, len(failed))
+	for _, output := range failed {
+		errs = append(errs, fmt.Sprintf("'%s': %s", output.Host, output.Err))
+	}
+
+	return fmt.Errorf("command failed on %d/%d host(s): %s", len(failed), len(h), strings.Join(errs, "; "))
+}
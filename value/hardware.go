@@ -0,0 +1,202 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/pkg/errors"
+)
+
+// MemoryTolerance is the percentage by which the total memory of two nodes may differ before they're considered to
+// have materially different hardware; the reported total memory varies slightly even between identical machines.
+const MemoryTolerance = 10
+
+// Hardware describes the hardware of a single remote machine.
+type Hardware struct {
+	Host     string      `json:"host"`
+	CPUs     uint64      `json:"cpus"`
+	Memory   uint64      `json:"memory"`
+	DiskType string      `json:"disk_type"`
+	Storage  StorageType `json:"storage"`
+}
+
+// CommandHardware returns a command which outputs the number of CPUs, total memory (in KiB), whether the disk backing
+// the given path is rotational and the model of that disk, each on a separate line.
+func CommandHardware(path string) Command {
+	return NewCommand(`nproc; awk '/^MemTotal:/ { print $2 }' /proc/meminfo;
+		device=$(df --output=source %s | tail -1);
+		lsblk -ndo ROTA $device; lsblk -sno MODEL $device | tail -1`, path)
+}
+
+// ParseHardware parses the output of the command returned by 'CommandHardware'.
+func ParseHardware(host string, output []byte) (*Hardware, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	// The disk model is omitted for some devices e.g. those backed by a ramdisk
+	if len(lines) == 3 {
+		lines = append(lines, "")
+	}
+
+	if len(lines) != 4 {
+		return nil, fmt.Errorf("unexpected output '%s'", bytes.TrimSpace(output))
+	}
+
+	for idx := range lines {
+		lines[idx] = strings.TrimSpace(lines[idx])
+	}
+
+	cpus, err := strconv.ParseUint(lines[0], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse cpu count")
+	}
+
+	memory, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse total memory")
+	}
+
+	diskType := "ssd"
+	if lines[2] == "1" {
+		diskType = "hdd"
+	}
+
+	return &Hardware{
+		Host:     host,
+		CPUs:     cpus,
+		Memory:   memory * 1024,
+		DiskType: diskType,
+		Storage:  ParseStorageType(lines[3]),
+	}, nil
+}
+
+// HardwareSummary is a wrapper around the hardware of each of the cluster nodes which provides some utility functions.
+type HardwareSummary []*Hardware
+
+// Warnings returns a list of human readable warnings, one for each way in which the nodes have materially different
+// hardware; mixed hardware silently skews benchmark results.
+func (h HardwareSummary) Warnings() []string {
+	if len(h) < 2 {
+		return nil
+	}
+
+	var (
+		warnings    = make([]string, 0)
+		minMemory   = h[0].Memory
+		maxMemory   = h[0].Memory
+		cpus        = make(map[uint64]struct{})
+		diskTypes   = make(map[string]struct{})
+		storage     = make(map[StorageType]struct{})
+		cpuHosts    = make([]string, 0, len(h))
+		diskHosts   = make([]string, 0, len(h))
+		storeHosts  = make([]string, 0, len(h))
+		memoryHosts = make([]string, 0, len(h))
+	)
+
+	for _, hw := range h {
+		cpus[hw.CPUs] = struct{}{}
+		diskTypes[hw.DiskType] = struct{}{}
+		storage[hw.Storage] = struct{}{}
+
+		cpuHosts = append(cpuHosts, fmt.Sprintf("%s=%d", hw.Host, hw.CPUs))
+		diskHosts = append(diskHosts, fmt.Sprintf("%s=%s", hw.Host, hw.DiskType))
+		storeHosts = append(storeHosts, fmt.Sprintf("%s=%s", hw.Host, hw.Storage))
+		memoryHosts = append(memoryHosts, fmt.Sprintf("%s=%s", hw.Host, format.Bytes(hw.Memory)))
+
+		if hw.Memory < minMemory {
+			minMemory = hw.Memory
+		}
+
+		if hw.Memory > maxMemory {
+			maxMemory = hw.Memory
+		}
+	}
+
+	if len(cpus) > 1 {
+		warnings = append(warnings, "nodes have different CPU counts: "+strings.Join(cpuHosts, ", "))
+	}
+
+	if maxMemory != 0 && (maxMemory-minMemory)*100/maxMemory > MemoryTolerance {
+		warnings = append(warnings, "nodes have different amounts of memory: "+strings.Join(memoryHosts, ", "))
+	}
+
+	if len(diskTypes) > 1 {
+		warnings = append(warnings, "nodes have different disk types: "+strings.Join(diskHosts, ", "))
+	}
+
+	if len(storage) > 1 {
+		warnings = append(warnings, "nodes have different storage types: "+strings.Join(storeHosts, ", "))
+	}
+
+	return warnings
+}
+
+// String returns a human readable string representation of the hardware which will be displayed in the report.
+func (h HardwareSummary) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Hardware\n| --------")
+	fmt.Fprintf(writer, "| Host\t CPUs\t Memory\t Disk Type\t Storage\t\n")
+
+	for _, hw := range h {
+		fmt.Fprintf(writer, "| %s\t %d\t %s\t %s\t %s\t\n", hw.Host, hw.CPUs, format.Bytes(hw.Memory), hw.DiskType,
+			hw.Storage)
+	}
+
+	_ = writer.Flush()
+
+	for _, warning := range h.Warnings() {
+		fmt.Fprintf(buffer, "\nWARNING: %s", warning)
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// ArchiveString returns a human readable string representation of the backup client hardware which will be displayed
+// in the report, the disk/storage type is that of the archive.
+func (h *Hardware) ArchiveString() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Backup Client Hardware\n| ----------------------")
+	fmt.Fprintf(writer, "| Host\t CPUs\t Memory\t Archive Disk Type\t Archive Storage\t\n")
+	fmt.Fprintf(writer, "| %s\t %d\t %s\t %s\t %s\t\n", h.Host, h.CPUs, format.Bytes(h.Memory), h.DiskType, h.Storage)
+
+	_ = writer.Flush()
+
+	// Instance store is usually much faster than EBS, make sure these results aren't mistaken for EBS results
+	if h.Storage == StorageTypeInstanceStore {
+		fmt.Fprint(buffer, "\nNOTE: the archive is on an ephemeral instance store device, results are not comparable "+
+			"with an archive on EBS")
+	}
+
+	// The tiered device performance depends on how much of the data fits in the cache, so label these results too
+	if h.Storage == StorageTypeTiered {
+		fmt.Fprint(buffer, "\nNOTE: the archive is on a tiered (cached) device, results are not comparable with an "+
+			"archive on a single device")
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
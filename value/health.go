@@ -0,0 +1,136 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// healthEvent matches the ns_server log messages which indicate that the health of the cluster has degraded e.g. a node
+// was (auto) failed over or a service such as memcached crashed and was restarted.
+var healthEvent = regexp.MustCompile(
+	`(?i)failed over|exited with status|crashed|connection to memcached .* disconnected`,
+)
+
+// HealthEvent describes an event which indicates that the health of the cluster degraded during a run.
+type HealthEvent struct {
+	Node string `json:"node"`
+	Text string `json:"text"`
+}
+
+// HealthEvents is a wrapper around a slice of health events which provides a human readable representation.
+type HealthEvents []*HealthEvent
+
+// String returns a human readable string representation of the health events which will be displayed in the report.
+func (h HealthEvents) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Health Events\n| -------------")
+	fmt.Fprintf(writer, "| Node\t Event\t\n")
+
+	for _, event := range h {
+		fmt.Fprintf(writer, "| %s\t %s\t\n", event.Node, event.Text)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// ParseHealthEvents parses the output of the ns_server '/logs' endpoint returning the events which indicate degraded
+// health that were logged after the given timestamp (in milliseconds), along with the timestamp of the latest entry.
+//
+// NOTE: Timestamps are those reported by the cluster, this avoids any issues caused by clock skew.
+func ParseHealthEvents(data []byte, since int64) (HealthEvents, int64, error) {
+	type overlay struct {
+		List []struct {
+			Node      string `json:"node"`
+			Timestamp int64  `json:"tstamp"`
+			Text      string `json:"text"`
+		} `json:"list"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to unmarshal logs")
+	}
+
+	var (
+		events = make(HealthEvents, 0)
+		latest = since
+	)
+
+	for _, entry := range decoded.List {
+		if entry.Timestamp <= since {
+			continue
+		}
+
+		if entry.Timestamp > latest {
+			latest = entry.Timestamp
+		}
+
+		if healthEvent.MatchString(entry.Text) {
+			events = append(events, &HealthEvent{Node: entry.Node, Text: strings.TrimSpace(entry.Text)})
+		}
+	}
+
+	return events, latest, nil
+}
+
+// ParseNodeHealth parses the output of the ns_server '/pools/default' endpoint returning an event for each node which
+// is not healthy or is no longer an active member of the cluster.
+func ParseNodeHealth(data []byte) (HealthEvents, error) {
+	type overlay struct {
+		Nodes []struct {
+			Hostname   string `json:"hostname"`
+			Status     string `json:"status"`
+			Membership string `json:"clusterMembership"`
+		} `json:"nodes"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal cluster info")
+	}
+
+	events := make(HealthEvents, 0)
+
+	for _, node := range decoded.Nodes {
+		if node.Status == "healthy" && node.Membership == "active" {
+			continue
+		}
+
+		events = append(events, &HealthEvent{
+			Node: node.Hostname,
+			Text: fmt.Sprintf("node is '%s' with membership '%s'", node.Status, node.Membership),
+		})
+	}
+
+	return events, nil
+}
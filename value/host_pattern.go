@@ -0,0 +1,170 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExpandHostPattern expands the given host pattern into a list of hosts, patterns may contain one or more bracketed
+// lists of numbers/ranges e.g. 'node[01-03,10].perf.lab' expands to 'node01.perf.lab', 'node02.perf.lab',
+// 'node03.perf.lab' and 'node10.perf.lab'. Zero padding is preserved using the width of the start of each range.
+//
+// NOTE: Hosts without a pattern are returned as is, this means IPv6 addresses must not be bracketed.
+func ExpandHostPattern(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	if start == -1 {
+		return []string{pattern}, nil
+	}
+
+	end := strings.Index(pattern[start:], "]")
+	if end == -1 {
+		return nil, fmt.Errorf("host pattern '%s' contains an unterminated range", pattern)
+	}
+
+	end += start
+
+	values, err := expandHostRange(pattern[start+1 : end])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid host pattern '%s'", pattern)
+	}
+
+	// Expand any remaining ranges in the rest of the pattern
+	suffixes, err := ExpandHostPattern(pattern[end+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0, len(values)*len(suffixes))
+
+	for _, value := range values {
+		for _, suffix := range suffixes {
+			hosts = append(hosts, pattern[:start]+value+suffix)
+		}
+	}
+
+	return hosts, nil
+}
+
+// expandHostRange expands the contents of a bracketed range e.g. '01-03,10' into its values.
+func expandHostRange(r string) ([]string, error) {
+	values := make([]string, 0)
+
+	for _, part := range strings.Split(r, ",") {
+		lower, upper, isRange := strings.Cut(part, "-")
+		if !isRange {
+			upper = lower
+		}
+
+		first, err := strconv.Atoi(lower)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start '%s'", lower)
+		}
+
+		last, err := strconv.Atoi(upper)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end '%s'", upper)
+		}
+
+		if last < first {
+			return nil, fmt.Errorf("range '%s' ends before it starts", part)
+		}
+
+		for i := first; i <= last; i++ {
+			values = append(values, fmt.Sprintf("%0*d", len(lower), i))
+		}
+	}
+
+	return values, nil
+}
+
+// ReadHostsFile reads the hosts from the file at the given path, there should be one host (or host pattern) per line.
+// Empty lines and those starting with '#' are ignored.
+func ReadHostsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open hosts file")
+	}
+	defer file.Close()
+
+	var (
+		hosts   = make([]string, 0)
+		scanner = bufio.NewScanner(file)
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		expanded, err := ExpandHostPattern(line)
+		if err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, expanded...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read hosts file")
+	}
+
+	return hosts, nil
+}
+
+// ExpandHosts replaces any nodes which use a host pattern/file with a node for each host, all the other values from the
+// original node are retained.
+func (c *ClusterBlueprint) ExpandHosts() error {
+	nodes := make([]*NodeBlueprint, 0, len(c.Nodes))
+
+	for _, node := range c.Nodes {
+		var (
+			hosts []string
+			err   error
+		)
+
+		if node.HostsFile != "" {
+			hosts, err = ReadHostsFile(node.HostsFile)
+		} else {
+			hosts, err = ExpandHostPattern(node.Host)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(hosts) > 1 && node.Hostname != "" {
+			return fmt.Errorf("a hostname can't be used when the host '%s' expands to multiple hosts", node.Host)
+		}
+
+		for _, host := range hosts {
+			copied := *node
+			copied.Host, copied.HostsFile = host, ""
+
+			nodes = append(nodes, &copied)
+		}
+	}
+
+	c.Nodes = nodes
+
+	return nil
+}
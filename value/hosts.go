@@ -0,0 +1,51 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// hostsBegin/hostsEnd are the markers used to delimit the entries managed by 'cbtools-autobench' in '/etc/hosts',
+	// this allows them to be replaced without touching any other entries.
+	hostsBegin = "# BEGIN cbtools-autobench"
+	hostsEnd   = "# END cbtools-autobench"
+)
+
+// HostsEntry is a single entry which will be added to '/etc/hosts'.
+type HostsEntry struct {
+	Address string
+	Name    string
+}
+
+// HostsEntries is a wrapper around a slice of '/etc/hosts' entries which provides some utility functions.
+type HostsEntries []HostsEntry
+
+// CommandUpdate returns a command which will replace any previously managed entries in '/etc/hosts' with these entries.
+func (h HostsEntries) CommandUpdate() Command {
+	lines := make([]string, 0, len(h)+2)
+
+	lines = append(lines, hostsBegin)
+	for _, entry := range h {
+		lines = append(lines, fmt.Sprintf("%s %s", entry.Address, entry.Name))
+	}
+
+	lines = append(lines, hostsEnd)
+
+	return NewCommand(`sed -i '/^%s$/,/^%s$/d' /etc/hosts && printf '%%s\n' %s >> /etc/hosts`,
+		hostsBegin, hostsEnd, "'"+strings.Join(lines, "' '")+"'")
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ImpactLatencyWeight/ImpactCPUWeight are the weights given to the front-end latency degradation and the increase
+	// in server CPU usage when calculating the impact score.
+	ImpactLatencyWeight = 0.5
+	ImpactCPUWeight     = 0.5
+)
+
+// CommandCPUTimes returns a command which outputs the aggregate CPU times for the remote machine.
+func CommandCPUTimes() Command {
+	return NewCommand("head -1 /proc/stat")
+}
+
+// CPUTimes is a snapshot of the aggregate CPU times (in jiffies) for a machine.
+type CPUTimes struct {
+	Idle  uint64
+	Total uint64
+}
+
+// ParseCPUTimes parses the output of the command returned by 'CommandCPUTimes'.
+func ParseCPUTimes(output []byte) (CPUTimes, error) {
+	fields := strings.Fields(string(output))
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return CPUTimes{}, fmt.Errorf("unexpected output '%s'", strings.TrimSpace(string(output)))
+	}
+
+	var times CPUTimes
+
+	// We only care about 'user', 'nice', 'system', 'idle', 'iowait', 'irq', 'softirq' and 'steal' since 'guest' time
+	// is already included in 'user'.
+	for idx, field := range fields[1:9] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return CPUTimes{}, errors.Wrap(err, "failed to parse cpu time")
+		}
+
+		// Time spent waiting for I/O is considered idle
+		if idx == 3 || idx == 4 {
+			times.Idle += value
+		}
+
+		times.Total += value
+	}
+
+	return times, nil
+}
+
+// CPUUsage returns the percentage of time the CPU(s) were busy between the given snapshots.
+func CPUUsage(start, end CPUTimes) float64 {
+	if end.Total <= start.Total {
+		return 0
+	}
+
+	return 100 * (1 - float64(end.Idle-start.Idle)/float64(end.Total-start.Total))
+}
+
+// PhaseSummary encapsulates the front-end latencies and server resource usage observed during a single phase of a
+// benchmark (e.g. whilst the backup was running).
+type PhaseSummary struct {
+	Latency *LatencySummary `json:"latency"`
+
+	// CPU is the average CPU usage (as a percentage) across the cluster nodes.
+	CPU float64 `json:"cpu"`
+}
+
+// WorkloadResult contains the phase summaries captured before, during and after a single benchmark.
+type WorkloadResult struct {
+	Before *PhaseSummary `json:"before,omitempty"`
+	During *PhaseSummary `json:"during,omitempty"`
+	After  *PhaseSummary `json:"after,omitempty"`
+}
+
+// ImpactScore returns a single number which represents the impact that the benchmark had on the cluster, combining the
+// degradation in p99 front-end latency and the increase in CPU usage versus the baseline (the average of the before
+// and after phases). A score of zero indicates no impact, a score of 100 would indicate that the p99 latency doubled
+// and that the benchmark consumed all the CPU on the cluster nodes.
+//
+// A boolean is returned indicating whether the score could be calculated i.e. whether we have a baseline.
+func (w *WorkloadResult) ImpactScore() (float64, bool) {
+	baselines := make([]*PhaseSummary, 0, 2)
+
+	for _, phase := range []*PhaseSummary{w.Before, w.After} {
+		if phase != nil && phase.Latency != nil {
+			baselines = append(baselines, phase)
+		}
+	}
+
+	if w.During == nil || w.During.Latency == nil || len(baselines) == 0 {
+		return 0, false
+	}
+
+	var p99, cpu float64
+
+	for _, baseline := range baselines {
+		p99 += float64(baseline.Latency.P99) / float64(len(baselines))
+		cpu += baseline.CPU / float64(len(baselines))
+	}
+
+	var latencyDegradation float64
+	if p99 != 0 {
+		latencyDegradation = float64(w.During.Latency.P99)/p99 - 1
+	}
+
+	cpuIncrease := (w.During.CPU - cpu) / 100
+
+	return 100 * (ImpactLatencyWeight*maxFloat(0, latencyDegradation) + ImpactCPUWeight*maxFloat(0, cpuIncrease)), true
+}
+
+// maxFloat returns the larger of the two given values.
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ImportSource is the kind of dataset imported using 'cbimport'.
+type ImportSource string
+
+const (
+	// ImportSourceSample imports one of the sample buckets shipped with Couchbase Server e.g. 'travel-sample'.
+	ImportSourceSample ImportSource = "sample"
+
+	// ImportSourceJSON imports a file of JSON documents, or a zip of a directory containing a JSON document per file.
+	ImportSourceJSON ImportSource = "json"
+
+	// ImportSourceCSV imports a CSV file, for example a table exported from a SQL database.
+	ImportSourceCSV ImportSource = "csv"
+)
+
+// ImportFormat is the format of the JSON documents imported using 'cbimport', see 'cbimport json --format'.
+type ImportFormat string
+
+const (
+	// ImportFormatLines is a file containing a JSON document per line.
+	ImportFormatLines ImportFormat = "lines"
+
+	// ImportFormatList is a file containing a JSON list of documents.
+	ImportFormatList ImportFormat = "list"
+
+	// ImportFormatSample is a zip of a directory containing a JSON document per file, the file name is used as the key.
+	ImportFormatSample ImportFormat = "sample"
+)
+
+// ImportBlueprint describes a dataset which is imported into the benchmarking bucket using 'cbimport' when the data
+// loader is 'cbimport', rather than generating synthetic data; this allows benchmarks to reflect the shape of real
+// data.
+type ImportBlueprint struct {
+	// Source is the kind of dataset which is imported.
+	Source ImportSource `json:"source" yaml:"source"`
+
+	// Sample is the name of the sample bucket which is imported e.g. 'travel-sample' or 'beer-sample'.
+	Sample string `json:"sample,omitempty" yaml:"sample,omitempty"`
+
+	// Path is the local path to the JSON/CSV file (or zip) which is uploaded to the first cluster node and imported.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Format is the format of the JSON documents, either 'lines' (default), 'list' or 'sample'.
+	Format ImportFormat `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Key is the 'cbimport' key generator expression e.g. 'airline::%id%', by default a UUID is used.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// Validate returns an error if the import blueprint is invalid.
+func (i *ImportBlueprint) Validate() error {
+	if i == nil {
+		return errors.New("an 'import' dataset must be provided when loading data using 'cbimport'")
+	}
+
+	switch i.Source {
+	case ImportSourceSample:
+		if i.Sample == "" {
+			return errors.New("the name of the sample bucket must be provided")
+		}
+	case ImportSourceJSON, ImportSourceCSV:
+		if i.Path == "" {
+			return fmt.Errorf("a path must be provided when importing '%s'", i.Source)
+		}
+	default:
+		return fmt.Errorf("unsupported import source '%s'", i.Source)
+	}
+
+	switch i.FormatOrDefault() {
+	case ImportFormatLines, ImportFormatList, ImportFormatSample:
+	default:
+		return fmt.Errorf("unsupported import format '%s'", i.Format)
+	}
+
+	return nil
+}
+
+// FormatOrDefault returns the format of the JSON documents.
+func (i *ImportBlueprint) FormatOrDefault() ImportFormat {
+	if i.Format == "" {
+		return ImportFormatLines
+	}
+
+	return i.Format
+}
+
+// KeyOrDefault returns the 'cbimport' key generator expression.
+func (i *ImportBlueprint) KeyOrDefault() string {
+	if i.Key == "" {
+		return "#UUID#"
+	}
+
+	return i.Key
+}
+
+// SamplePath returns the path to the sample bucket archive within the given Couchbase Server install directory.
+func (i *ImportBlueprint) SamplePath(installDirectory string) string {
+	return RemoteJoin(installDirectory, "samples", i.Sample+".zip")
+}
+
+// CommandImport returns a command which imports the dataset at the given path on the remote machine into the
+// benchmarking bucket using the cluster at the given address, a zero value for threads uses every CPU.
+func (i *ImportBlueprint) CommandImport(host string, credentials *Credentials, path string, threads int) Command {
+	var command string
+
+	switch {
+	case i.Source == ImportSourceCSV:
+		command = fmt.Sprintf("cbimport csv --infer-types -g %s", ShellQuote(i.KeyOrDefault()))
+	case i.Source == ImportSourceSample || i.FormatOrDefault() == ImportFormatSample:
+		command = "cbimport json --format sample"
+	default:
+		command = fmt.Sprintf("cbimport json --format %s -g %s", i.FormatOrDefault(), ShellQuote(i.KeyOrDefault()))
+	}
+
+	command += fmt.Sprintf(" -c %s %s -b default -d file://%s", host, credentials.Args(), path)
+
+	if threads != 0 {
+		command += fmt.Sprintf(" -t %d", threads)
+	} else {
+		command += " -t $(nproc)"
+	}
+
+	return NewCommand("%s", command)
+}
+
+// String returns a short human readable description of the dataset which will be displayed in the report.
+func (i *ImportBlueprint) String() string {
+	switch i.Source {
+	case ImportSourceSample:
+		return i.Sample
+	case ImportSourceCSV:
+		return fmt.Sprintf("csv %s", LocalBase(i.Path))
+	}
+
+	return fmt.Sprintf("json (%s) %s", i.FormatOrDefault(), LocalBase(i.Path))
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IndexTimeout is how long we'll wait for the GSI indexes (or any other service) to be built, before giving up.
+const IndexTimeout = 24 * time.Hour
+
+// IndexStatusReady is the status of a GSI index which has been built and is able to service queries.
+const IndexStatusReady = "Ready"
+
+// IndexBlueprint describes a GSI index on the benchmarking bucket which is part of the dataset.
+type IndexBlueprint struct {
+	Name   string   `json:"name" yaml:"name"`
+	Fields []string `json:"fields" yaml:"fields"`
+}
+
+// Validate returns an error if the index blueprint is invalid.
+func (i *IndexBlueprint) Validate() error {
+	if i.Name == "" {
+		return errors.New("indexes must have a name")
+	}
+
+	if len(i.Fields) == 0 {
+		return fmt.Errorf("index '%s' must have at least one field", i.Name)
+	}
+
+	return nil
+}
+
+// CommandCreateIndex returns a command which creates the given index on the benchmarking bucket using the cluster at
+// the given address.
+func CommandCreateIndex(host string, credentials *Credentials, index *IndexBlueprint) Command {
+	return NewCommand(`cbindex -auth %s -server %s -type create -bucket default -index %s -fields=%s`,
+		credentials.UserInfo(), host, index.Name, strings.Join(index.Fields, ","))
+}
+
+// CommandDropIndex returns a command which drops the given index from the benchmarking bucket using the cluster at the
+// given address.
+func CommandDropIndex(host string, credentials *Credentials, index *IndexBlueprint) Command {
+	return NewCommand(`cbindex -auth %s -server %s -type drop -bucket default -index %s`, credentials.UserInfo(),
+		host, index.Name)
+}
+
+// ParseIndexStatus parses the response from the '/indexStatus' REST endpoint, returning the status of each index on the
+// benchmarking bucket by name.
+func ParseIndexStatus(output []byte) (map[string]string, error) {
+	type overlay struct {
+		Indexes []struct {
+			Bucket string `json:"bucket"`
+			Index  string `json:"index"`
+			Status string `json:"status"`
+		} `json:"indexes"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	statuses := make(map[string]string, len(decoded.Indexes))
+
+	for _, index := range decoded.Indexes {
+		if index.Bucket == "default" {
+			statuses[index.Index] = index.Status
+		}
+	}
+
+	return statuses, nil
+}
+
+// UnreadyIndexes returns the names of the given indexes which aren't ready (or don't exist) according to the given
+// statuses.
+func UnreadyIndexes(indexes []*IndexBlueprint, statuses map[string]string) []string {
+	unready := make([]string, 0)
+
+	for _, index := range indexes {
+		if statuses[index.Name] != IndexStatusReady {
+			unready = append(unready, index.Name)
+		}
+	}
+
+	return unready
+}
@@ -18,4 +18,17 @@ package value
 type BackupInfo struct {
 	BackupSize uint64
 	ItemsNum   uint64
+
+	// Buckets is the size/number of items of each bucket in the backup.
+	Buckets BucketResults
+}
+
+// BucketResult is the size/number of items of a single bucket in a backup.
+type BucketResult struct {
+	Name string `json:"name"`
+	ADS  uint64 `json:"size"`
+	AIN  uint64 `json:"items"`
 }
+
+// BucketResults is a wrapper around the results for each bucket in a backup.
+type BucketResults []*BucketResult
@@ -0,0 +1,122 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+)
+
+// KeyPattern describes how the keys of the benchmarking dataset are generated, the key distribution affects the
+// order data is iterated in during a backup and how well it compresses.
+type KeyPattern string
+
+const (
+	// KeyPatternRandom generates the keys on each node beneath a randomly chosen prefix, this is the default.
+	KeyPatternRandom KeyPattern = "random"
+
+	// KeyPatternSequential generates sequentially numbered keys beneath a fixed prefix for each node e.g. 'node-0::1'.
+	KeyPatternSequential KeyPattern = "sequential"
+
+	// KeyPatternGrouped splits the keys between a number of groups which share a common prefix e.g. 'group-0::1'.
+	KeyPatternGrouped KeyPattern = "grouped"
+)
+
+// randomPrefix is a shell expression which generates a random key prefix on the remote machine.
+const randomPrefix = "$(cat /dev/urandom | tr -dc 'a-z0-9' | fold -w 5 | head -n 1)"
+
+// KeyBatch is a number of keys which are generated beneath the same prefix.
+type KeyBatch struct {
+	// Prefix is prepended to each key, it may be a shell expression which is evaluated on the remote machine.
+	Prefix string
+
+	// Items is the number of keys which are generated.
+	Items int
+}
+
+// KeyPatternOrDefault returns the configured key pattern, or the default if none was provided.
+func (d *DataBlueprint) KeyPatternOrDefault() KeyPattern {
+	if d.KeyPattern == "" {
+		return KeyPatternRandom
+	}
+
+	return d.KeyPattern
+}
+
+// ValidateKeys returns an error if the key pattern is unknown, or isn't supported by the data loader.
+func (d *DataBlueprint) ValidateKeys() error {
+	switch d.KeyPatternOrDefault() {
+	case KeyPatternRandom, KeyPatternSequential:
+	case KeyPatternGrouped:
+		if d.KeyGroups < 1 {
+			return fmt.Errorf("at least one key group must be provided when using the '%s' key pattern",
+				KeyPatternGrouped)
+		}
+	default:
+		return fmt.Errorf("unknown/unsupported key pattern '%s'", d.KeyPattern)
+	}
+
+	if d.KeyPattern != "" && d.DataLoader == Pillowfight {
+		return fmt.Errorf("key patterns are not supported when loading data with 'cbc-pillowfight'")
+	}
+
+	return nil
+}
+
+// KeyBatches returns the batches of keys which should be generated by each of the given number of nodes to load the
+// given number of items, which are split as evenly as possible. The offset is the number of items already in the
+// bucket, it's included in the fixed prefixes so that resumed/topped up loads don't overwrite existing keys.
+func (d *DataBlueprint) KeyBatches(items, nodes, offset int) [][]KeyBatch {
+	batches := make([][]KeyBatch, nodes)
+
+	suffix := "::"
+	if offset != 0 {
+		suffix = fmt.Sprintf("-%d::", offset)
+	}
+
+	if d.KeyPatternOrDefault() == KeyPatternGrouped {
+		for group, items := range split(items, d.KeyGroups) {
+			batches[group%nodes] = append(batches[group%nodes], KeyBatch{
+				Prefix: fmt.Sprintf("group-%d%s", group, suffix),
+				Items:  items,
+			})
+		}
+
+		return batches
+	}
+
+	for node, items := range split(items, nodes) {
+		prefix := randomPrefix + "::"
+		if d.KeyPatternOrDefault() == KeyPatternSequential {
+			prefix = fmt.Sprintf("node-%d%s", node, suffix)
+		}
+
+		batches[node] = []KeyBatch{{Prefix: prefix, Items: items}}
+	}
+
+	return batches
+}
+
+// split divides the given number of items into the given number of parts, the remainder is added to the last part.
+func split(items, parts int) []int {
+	split := make([]int, parts)
+
+	for idx := range split {
+		split[idx] = items / parts
+	}
+
+	split[parts-1] += items % parts
+
+	return split
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// histogramLine matches a single line of the latency histogram output by the 'libcouchbase' tools when run with
+// '--timings' for example '[ 20 -  29]us |######    - 1234'.
+var histogramLine = regexp.MustCompile(`^\[\s*([\d.]+)\s*-\s*([\d.]+)\s*\]\s*(ns|us|ms|s)\s*\|[^-]*-\s*(\d+)\s*$`)
+
+// LatencyBucket is a single bucket in a latency histogram.
+type LatencyBucket struct {
+	// Upper is the upper bound of the bucket, we use this when calculating percentiles to avoid under-reporting.
+	Upper time.Duration
+
+	// Count is the number of operations which fell into this bucket.
+	Count uint64
+}
+
+// LatencyHistogram is a histogram of operation latencies.
+type LatencyHistogram []LatencyBucket
+
+// ParseLatencyHistogram parses the latency histogram from the given 'cbc-pillowfight' output, any lines which aren't
+// part of the histogram are ignored.
+func ParseLatencyHistogram(output []byte) LatencyHistogram {
+	units := map[string]time.Duration{
+		"ns": time.Nanosecond,
+		"us": time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+	}
+
+	histogram := make(LatencyHistogram, 0)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		matches := histogramLine.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+
+		upper, err1 := strconv.ParseFloat(matches[2], 64)
+		count, err2 := strconv.ParseUint(matches[4], 10, 64)
+
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		histogram = append(histogram, LatencyBucket{
+			Upper: time.Duration(upper * float64(units[matches[3]])),
+			Count: count,
+		})
+	}
+
+	sort.Slice(histogram, func(i, j int) bool { return histogram[i].Upper < histogram[j].Upper })
+
+	return histogram
+}
+
+// Total returns the total number of operations in the histogram.
+func (h LatencyHistogram) Total() uint64 {
+	var total uint64
+	for _, bucket := range h {
+		total += bucket.Count
+	}
+
+	return total
+}
+
+// Percentile returns the latency at the given percentile (0-100), a zero value is returned for an empty histogram.
+func (h LatencyHistogram) Percentile(percentile float64) time.Duration {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+
+	var (
+		target = uint64(float64(total) * percentile / 100)
+		seen   uint64
+	)
+
+	for _, bucket := range h {
+		seen += bucket.Count
+		if seen > target {
+			return bucket.Upper
+		}
+	}
+
+	return h[len(h)-1].Upper
+}
+
+// Summary returns the interesting percentiles from the histogram.
+func (h LatencyHistogram) Summary() *LatencySummary {
+	return &LatencySummary{
+		Operations: h.Total(),
+		P50:        h.Percentile(50),
+		P95:        h.Percentile(95),
+		P99:        h.Percentile(99),
+		P999:       h.Percentile(99.9),
+	}
+}
+
+// LatencySummary encapsulates the interesting percentiles from a latency histogram.
+type LatencySummary struct {
+	Operations uint64        `json:"operations"`
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+	P99        time.Duration `json:"p99"`
+	P999       time.Duration `json:"p999"`
+}
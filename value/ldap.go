@@ -0,0 +1,165 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LDAPContainer is the name of the docker container running the LDAP server on the first cluster node.
+	LDAPContainer = "cbtools-autobench-ldap"
+
+	// LDAPImage is the docker image used to run the LDAP server.
+	LDAPImage = "osixia/openldap:1.5.0"
+
+	// LDAPBaseDN is the base DN of the LDAP directory, users are created directly beneath it.
+	LDAPBaseDN = "dc=autobench,dc=local"
+
+	// LDAPPort is the port the LDAP server listens on, it must be reachable from every node in the cluster.
+	LDAPPort = 389
+
+	// DefaultLDAPUsername/DefaultLDAPRoles are the username/roles of the external user when none are provided.
+	DefaultLDAPUsername = "autobench"
+	DefaultLDAPRoles    = "admin"
+)
+
+// LDAPBlueprint describes the LDAP server which is stood up on the first cluster node during provisioning, and the
+// external user which is authenticated against it.
+type LDAPBlueprint struct {
+	// Username is the username of the external user, by default 'autobench'.
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+
+	// Password is the password of the external user and of the LDAP administrator, by default 'asdasd'.
+	Password string `json:"-" yaml:"password,omitempty"`
+
+	// Roles are the (comma separated) roles granted to the external user, by default 'admin'.
+	Roles string `json:"roles,omitempty" yaml:"roles,omitempty"`
+
+	// AuthenticateTools indicates that the benchmarked tools should authenticate as the external user, rather than as
+	// the cluster administrator.
+	AuthenticateTools bool `json:"authenticate_tools,omitempty" yaml:"authenticate_tools,omitempty"`
+}
+
+// Validate returns an error if the LDAP blueprint is invalid.
+func (l *LDAPBlueprint) Validate() error {
+	if l == nil {
+		return nil
+	}
+
+	// NOTE: Some commands are formatted twice, which would mangle any formatting verbs in the credentials
+	if strings.Contains(l.Username, "%") || strings.Contains(l.Password, "%") {
+		return errors.New("the username/password must not contain '%'")
+	}
+
+	return nil
+}
+
+// Credentials returns the credentials of the external user.
+func (l *LDAPBlueprint) Credentials() *Credentials {
+	credentials := &Credentials{Username: l.Username, Password: l.Password}
+
+	if credentials.Username == "" {
+		credentials.Username = DefaultLDAPUsername
+	}
+
+	if credentials.Password == "" {
+		credentials.Password = DefaultPassword
+	}
+
+	return credentials
+}
+
+// RolesOrDefault returns the roles granted to the external user or the default if none were provided.
+func (l *LDAPBlueprint) RolesOrDefault() string {
+	if l.Roles == "" {
+		return DefaultLDAPRoles
+	}
+
+	return l.Roles
+}
+
+// bindDN returns the DN of the LDAP administrator, which the cluster binds as when searching the directory.
+func (l *LDAPBlueprint) bindDN() string {
+	return "cn=admin," + LDAPBaseDN
+}
+
+// DockerPackage returns the name of the package which provides docker using the given package manager.
+func DockerPackage(manager PackageManager) string {
+	if manager == PackageManagerAPT {
+		return "docker.io"
+	}
+
+	return "docker"
+}
+
+// CommandStartServer returns a command which (re)starts the LDAP server, waiting for it to accept connections.
+func (l *LDAPBlueprint) CommandStartServer() Command {
+	password := l.Credentials().QuotedPassword()
+
+	return NewCommand(`docker rm -f %[1]s >/dev/null 2>&1; \
+		docker run -d --restart unless-stopped --name %[1]s -p %[2]d:389 \
+			-e LDAP_DOMAIN=autobench.local -e LDAP_ADMIN_PASSWORD=%[3]s %[4]s && \
+		for i in $(seq 1 60); do \
+			docker exec %[1]s ldapwhoami -x -H ldap://localhost -D %[5]s -w %[3]s >/dev/null 2>&1 && break; \
+			sleep 1; \
+		done; docker exec %[1]s ldapwhoami -x -H ldap://localhost -D %[5]s -w %[3]s`,
+		LDAPContainer, LDAPPort, password, LDAPImage, l.bindDN())
+}
+
+// CommandAddUser returns a command which adds the external user to the LDAP directory.
+func (l *LDAPBlueprint) CommandAddUser() Command {
+	credentials := l.Credentials()
+
+	entry := []string{
+		fmt.Sprintf("dn: uid=%s,%s", credentials.Username, LDAPBaseDN),
+		"objectClass: inetOrgPerson",
+		"uid: " + credentials.Username,
+		"cn: " + credentials.Username,
+		"sn: " + credentials.Username,
+		"userPassword: " + credentials.Password,
+	}
+
+	for idx, line := range entry {
+		entry[idx] = ShellQuote(line)
+	}
+
+	return NewCommand(`printf '%%s\n' %s | docker exec -i %s ldapadd -x -D %s -w %s`, strings.Join(entry, " "),
+		LDAPContainer, l.bindDN(), credentials.QuotedPassword())
+}
+
+// CommandConfigureCluster returns a command which configures the cluster at the given address to authenticate external
+// users against the LDAP server running on the given host.
+func (l *LDAPBlueprint) CommandConfigureCluster(host string, credentials *Credentials, server string) Command {
+	return NewCommand(`couchbase-cli setting-ldap -c %s %s --hosts %s --port %d --encryption none \
+		--authentication-enabled 1 --user-dn-template 'uid=%%u,%s' --bind-dn %s --bind-password %s`,
+		host, credentials.Args(), server, LDAPPort, LDAPBaseDN, l.bindDN(), l.Credentials().QuotedPassword())
+}
+
+// CommandAddExternalUser returns a command which grants the external user its roles on the cluster at the given
+// address.
+func (l *LDAPBlueprint) CommandAddExternalUser(host string, credentials *Credentials) Command {
+	return NewCommand(`couchbase-cli user-manage -c %s %s --set --rbac-username %s --roles %s --auth-domain external`,
+		host, credentials.Args(), l.Credentials().QuotedUsername(), l.RolesOrDefault())
+}
+
+// CommandWhoAmI returns a command which outputs the user which the external user authenticates as against the node at
+// the given address.
+func (l *LDAPBlueprint) CommandWhoAmI(host string) Command {
+	return NewCommand(`curl -s -f -g -u %s http://%s/whoami`, l.Credentials().UserInfo(), host)
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"path"
+	"strings"
+)
+
+// ClientLockDirectory is the directory created on the backup client whilst a benchmark is running, it prevents other
+// runs from starting a concurrent benchmark which would invalidate the results of both.
+const ClientLockDirectory = "/var/lib/cbtools-autobench/lock"
+
+// ClientLockAcquired is output by the command returned by 'CommandAcquireClientLock' when the lock was acquired.
+const ClientLockAcquired = "acquired"
+
+// CommandAcquireClientLock returns a command which attempts to acquire the backup client lock for the given run. The
+// command outputs 'ClientLockAcquired' when the lock is acquired, otherwise it outputs the current owner which is
+// either the run id holding the lock or 'cbbackupmgr' when an unmanaged instance of 'cbbackupmgr' is running.
+func CommandAcquireClientLock(run RunID) Command {
+	return NewCommand(`if pgrep -x cbbackupmgr > /dev/null; then echo cbbackupmgr; \
+		elif mkdir -p %[1]s && mkdir %[2]s 2> /dev/null; then echo %[3]s > %[4]s && echo %[5]s; \
+		else cat %[4]s 2> /dev/null || echo unknown; fi`,
+		path.Dir(ClientLockDirectory), ClientLockDirectory, run, clientLockOwner, ClientLockAcquired)
+}
+
+// CommandReleaseClientLock returns a command which releases the backup client lock, if it's held by the given run.
+func CommandReleaseClientLock(run RunID) Command {
+	return NewCommand(`test "$(cat %s 2> /dev/null)" != %s || rm -rf %s`, clientLockOwner, run, ClientLockDirectory)
+}
+
+// ParseClientLockOwner parses the output of the command returned by 'CommandAcquireClientLock', returning an empty
+// string when the lock was acquired.
+func ParseClientLockOwner(output []byte) string {
+	owner := strings.TrimSpace(string(output))
+	if owner == ClientLockAcquired {
+		return ""
+	}
+
+	return owner
+}
+
+// clientLockOwner is the file containing the id of the run holding the backup client lock.
+var clientLockOwner = RemoteJoin(ClientLockDirectory, "run")
@@ -0,0 +1,46 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "path/filepath"
+
+// LogFileDisabled may be provided as the log file to disable writing logs to disk.
+const LogFileDisabled = "none"
+
+// LoggingConfig encapsulates the options which can be used to configure logging.
+type LoggingConfig struct {
+	// Handler is the format in which logs are written, either 'cli' (default) or 'json'.
+	Handler string `yaml:"handler,omitempty"`
+
+	// Level is the minimum level of the logs which will be written e.g. 'debug' or 'info' (default).
+	Level string `yaml:"level,omitempty"`
+
+	// File is the path to a file which logs will be tee'd to in addition to stdout, by default logs are written to
+	// 'autobench.log' in the run directory.
+	File string `yaml:"file,omitempty"`
+}
+
+// LogFile returns the path to the file which logs should be written to for the given run, an empty string is returned
+// if writing logs to disk has been disabled.
+func (l *LoggingConfig) LogFile(run RunID) string {
+	switch {
+	case l == nil || l.File == "":
+		return filepath.Join(run.LocalDirectory(), "autobench.log")
+	case l.File == LogFileDisabled:
+		return ""
+	}
+
+	return l.File
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+const (
+	// ManifestFile is the file in the run directory which describes the run.
+	ManifestFile = "run.json"
+
+	// ConfigFile is the file in the run directory which contains a copy of the config used by the run.
+	ConfigFile = "config.yaml"
+
+	// ResolvedConfigFile is the file in the run directory which contains the config once any inventories, host
+	// patterns/files and topology presets have been resolved into the nodes used by the run.
+	ResolvedConfigFile = "resolved.yaml"
+
+	// ReportFile/ReportJSONFile are the files in the (environment) run directory containing the human readable/JSON
+	// benchmark report.
+	ReportFile     = "report.txt"
+	ReportJSONFile = "report.json"
+)
+
+// secretPattern matches the config keys containing secrets which are redacted when describing a run.
+var secretPattern = regexp.MustCompile(`(?im)^(\s*-?\s*[a-z_]*(secret|passphrase|password|token)[a-z_]*:).*$`)
+
+// ManifestPath returns the path to the manifest for the given run.
+func (r RunID) ManifestPath() string {
+	return filepath.Join(r.LocalDirectory(), ManifestFile)
+}
+
+// RunManifest describes a run and how long each of its phases took, it's written to the run directory so that past
+// runs may be described using 'describe'.
+type RunManifest struct {
+	RunID   RunID        `json:"run_id"`
+	Command string       `json:"command"`
+	Started time.Time    `json:"started"`
+	Phases  PhaseTimings `json:"phases,omitempty"`
+}
+
+// PhaseTiming records how long a phase took for an environment, and whether it failed.
+type PhaseTiming struct {
+	Environment string        `json:"environment,omitempty"`
+	Phase       Phase         `json:"phase"`
+	Started     time.Time     `json:"started"`
+	Duration    time.Duration `json:"duration"`
+	Err         string        `json:"error,omitempty"`
+}
+
+// PhaseTimings is a wrapper around a slice of phase timings which provides a human readable representation.
+type PhaseTimings []*PhaseTiming
+
+// String returns a human readable string representation of the manifest.
+func (r *RunManifest) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	started := "-"
+	if !r.Started.IsZero() {
+		started = r.Started.Format("2006-01-02 15:04:05")
+	}
+
+	fmt.Fprintln(buffer, "| Manifest\n| --------")
+	fmt.Fprintf(writer, "| ID\t Command\t Started\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", r.RunID, r.Command, started)
+
+	_ = writer.Flush()
+
+	if len(r.Phases) != 0 {
+		fmt.Fprintf(buffer, "\n%s", r.Phases)
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// String returns a human readable string representation of the phase timings.
+func (p PhaseTimings) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Phases\n| ------")
+	fmt.Fprintf(writer, "| Environment\t Phase\t Started\t Duration\t Error\t\n")
+
+	for _, timing := range p {
+		environment := timing.Environment
+		if environment == "" {
+			environment = "-"
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t\n", environment, timing.Phase,
+			timing.Started.Format("15:04:05"), format.Duration(timing.Duration), timing.Err)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// RedactConfig returns the given config with the values of any keys which look like they contain secrets (e.g.
+// 'obj_secret_access_key' or 'passphrase') redacted.
+func RedactConfig(config []byte) []byte {
+	return secretPattern.ReplaceAll(config, []byte("$1 <redacted>"))
+}
@@ -0,0 +1,205 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// MatrixConfig describes the combinations benchmarked by the 'matrix' benchmark, the backup benchmark is run for the
+// cartesian product of the values; a dimension without any values uses the value from the blueprint/config.
+type MatrixConfig struct {
+	// Threads are the values of '--threads' which will be benchmarked e.g. [4, 8, 16].
+	Threads []int `json:"threads,omitempty" yaml:"threads,omitempty"`
+
+	// Sizes are the sizes (in bytes) of the documents which will be benchmarked e.g. [256, 4096].
+	Sizes []int `json:"sizes,omitempty" yaml:"sizes,omitempty"`
+
+	// Items are the numbers of documents which will be benchmarked e.g. [1000000, 10000000].
+	Items []int `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// Validate returns an error if the matrix config is incomplete, the dataset must be generated (rather than imported)
+// for the document sizes/counts to be varied.
+func (m *MatrixConfig) Validate(data *DataBlueprint) error {
+	if m == nil || len(m.Threads)+len(m.Sizes)+len(m.Items) == 0 {
+		return errors.New("at least one value of threads, sizes or items must be provided")
+	}
+
+	for name, values := range map[string][]int{"threads": m.Threads, "sizes": m.Sizes, "items": m.Items} {
+		seen := make(map[int]struct{}, len(values))
+
+		for _, v := range values {
+			if v <= 0 {
+				return fmt.Errorf("invalid %s value %d, it must be positive", name, v)
+			}
+
+			if _, ok := seen[v]; ok {
+				return fmt.Errorf("%s value %d is duplicated", name, v)
+			}
+
+			seen[v] = struct{}{}
+		}
+	}
+
+	if !m.ReloadsDataset() {
+		return nil
+	}
+
+	if data == nil || data.DataLoader == CBImport {
+		return errors.New("varying the document sizes/counts requires a generated dataset")
+	}
+
+	return nil
+}
+
+// ReloadsDataset returns a boolean indicating whether the matrix varies the document sizes/counts, in which case the
+// bucket is flushed and the dataset reloaded for each combination.
+func (m *MatrixConfig) ReloadsDataset() bool {
+	return m != nil && len(m.Sizes)+len(m.Items) != 0
+}
+
+// Datasets returns the datasets (the cartesian product of the sizes/items) which will be benchmarked, in the order
+// they'll be loaded; the dataset from the blueprint is benchmarked first (if it's included) so it needn't be reloaded.
+func (m *MatrixConfig) Datasets(data *DataBlueprint) []*DataBlueprint {
+	var (
+		sizes = m.Sizes
+		items = m.Items
+	)
+
+	if len(sizes) == 0 {
+		sizes = []int{data.Size}
+	}
+
+	if len(items) == 0 {
+		items = []int{data.Items}
+	}
+
+	datasets := make([]*DataBlueprint, 0, len(sizes)*len(items))
+
+	for _, size := range sizes {
+		for _, count := range items {
+			dataset := *data
+			dataset.Size, dataset.Items = size, count
+
+			// PiTR datasets mutate the active items repeatedly, there's no sensible way to scale them with the items
+			if dataset.ActiveItems > dataset.Items {
+				dataset.ActiveItems = dataset.Items
+			}
+
+			if size == data.Size && count == data.Items {
+				datasets = append([]*DataBlueprint{&dataset}, datasets...)
+			} else {
+				datasets = append(datasets, &dataset)
+			}
+		}
+	}
+
+	return datasets
+}
+
+// ThreadsOrDefault returns the values of '--threads' which will be benchmarked, using the given value when none were
+// provided.
+func (m *MatrixConfig) ThreadsOrDefault(threads int) []int {
+	if len(m.Threads) == 0 {
+		return []int{threads}
+	}
+
+	return m.Threads
+}
+
+// MatrixResult is the result of benchmarking a single combination in the matrix.
+type MatrixResult struct {
+	Items  int            `json:"items"`
+	Size   int            `json:"size"`
+	Backup *ThreadsResult `json:"backup"`
+}
+
+// GDS returns the generated data size of the dataset benchmarked by this combination.
+func (m *MatrixResult) GDS() uint64 {
+	return uint64(m.Items * m.Size)
+}
+
+// MatrixResults are the results for each of the combinations in a matrix.
+type MatrixResults []*MatrixResult
+
+// best returns the index of the combination with the highest average transfer rate, -1 is returned if there weren't
+// any results.
+func (m MatrixResults) best() int {
+	best := -1
+
+	for idx, result := range m {
+		if len(result.Backup.Results) == 0 {
+			continue
+		}
+
+		if best == -1 || result.Backup.AvgTransferRateADS > m[best].Backup.AvgTransferRateADS {
+			best = idx
+		}
+	}
+
+	return best
+}
+
+// String returns a string representation of the matrix results which will be output in the report.
+func (m MatrixResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+		best   = m.best()
+	)
+
+	fmt.Fprintln(buffer, "| Matrix\n| ------")
+	fmt.Fprintf(writer, "| Items\t Size\t GDS\t Threads\t Avg Duration\t Avg Transfer Rate (ADS)\t Relative\t Best\t\n")
+
+	for idx, result := range m {
+		var duration, rate, relative, marker string
+
+		threads := "auto"
+		if result.Backup.Threads != 0 {
+			threads = strconv.Itoa(result.Backup.Threads)
+		}
+
+		if len(result.Backup.Results) != 0 {
+			duration = format.Duration(result.Backup.AvgDuration)
+			rate = format.Bytes(result.Backup.AvgTransferRateADS) + "/s"
+		}
+
+		if best != -1 && m[best].Backup.AvgTransferRateADS != 0 && len(result.Backup.Results) != 0 {
+			relative = fmt.Sprintf("%.1f%%", 100*float64(result.Backup.AvgTransferRateADS)/
+				float64(m[best].Backup.AvgTransferRateADS))
+		}
+
+		if idx == best {
+			marker = "*"
+		}
+
+		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %s\t %s\t %s\t\n", result.Items,
+			format.Bytes(uint64(result.Size)), format.Bytes(result.GDS()), threads, duration, rate, relative, marker)
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprint(buffer, "\nNOTE: The relative transfer rate is compared against the best combination")
+
+	return strings.TrimSpace(buffer.String())
+}
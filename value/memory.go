@@ -0,0 +1,139 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemorySample is the memory usage (in bytes) of the benchmarked tool at a point in time.
+type MemorySample struct {
+	RSS uint64
+	VSZ uint64
+}
+
+// CommandToolMemory returns a command which outputs the total resident set size and virtual memory size in bytes of
+// all the running 'cbbackupmgr' processes, zeros are output if there aren't any.
+func CommandToolMemory() Command {
+	return NewCommand(`for pid in $(pgrep -x cbbackupmgr); do cat /proc/$pid/status; done 2>/dev/null | \
+		awk '/^VmRSS:/ { rss += $2 } /^VmSize:/ { vsz += $2 } END { printf "%%.0f %%.0f\n", rss * 1024, vsz * 1024 }'`)
+}
+
+// ParseToolMemory parses the output of 'CommandToolMemory', no output is treated as the tool not running.
+func ParseToolMemory(output []byte) (MemorySample, error) {
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return MemorySample{}, nil
+	}
+
+	if len(fields) != 2 {
+		return MemorySample{}, fmt.Errorf("expected two values but got %d", len(fields))
+	}
+
+	rss, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return MemorySample{}, fmt.Errorf("invalid resident set size '%s'", fields[0])
+	}
+
+	vsz, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return MemorySample{}, fmt.Errorf("invalid virtual memory size '%s'", fields[1])
+	}
+
+	return MemorySample{RSS: rss, VSZ: vsz}, nil
+}
+
+// MemoryUsage is the peak/average memory usage of the benchmarked tool during a backup/restore.
+type MemoryUsage struct {
+	PeakRSS uint64 `json:"peak_rss"`
+	AvgRSS  uint64 `json:"avg_rss"`
+	PeakVSZ uint64 `json:"peak_vsz"`
+	AvgVSZ  uint64 `json:"avg_vsz"`
+
+	// Samples is the number of samples taken whilst the tool was running.
+	Samples int `json:"samples"`
+}
+
+// NewMemoryUsage calculates the peak/average memory usage from the given samples, samples taken whilst the tool
+// wasn't running are ignored. Nil is returned if the tool wasn't running when any of the samples were taken.
+func NewMemoryUsage(samples []MemorySample) *MemoryUsage {
+	var (
+		usage    = &MemoryUsage{}
+		rss, vsz uint64
+	)
+
+	for _, sample := range samples {
+		if sample.RSS == 0 && sample.VSZ == 0 {
+			continue
+		}
+
+		usage.Samples++
+		rss += sample.RSS
+		vsz += sample.VSZ
+
+		if sample.RSS > usage.PeakRSS {
+			usage.PeakRSS = sample.RSS
+		}
+
+		if sample.VSZ > usage.PeakVSZ {
+			usage.PeakVSZ = sample.VSZ
+		}
+	}
+
+	if usage.Samples == 0 {
+		return nil
+	}
+
+	usage.AvgRSS, usage.AvgVSZ = rss/uint64(usage.Samples), vsz/uint64(usage.Samples)
+
+	return usage
+}
+
+// Memory returns the peak/average memory usage across all the results, the averages are weighted by the number of
+// samples taken during each result. Nil is returned if memory usage wasn't captured for any of the results.
+func (b BenchmarkResults) Memory() *MemoryUsage {
+	var (
+		usage    = &MemoryUsage{}
+		rss, vsz uint64
+	)
+
+	for _, result := range b {
+		if result.Memory == nil {
+			continue
+		}
+
+		usage.Samples += result.Memory.Samples
+		rss += result.Memory.AvgRSS * uint64(result.Memory.Samples)
+		vsz += result.Memory.AvgVSZ * uint64(result.Memory.Samples)
+
+		if result.Memory.PeakRSS > usage.PeakRSS {
+			usage.PeakRSS = result.Memory.PeakRSS
+		}
+
+		if result.Memory.PeakVSZ > usage.PeakVSZ {
+			usage.PeakVSZ = result.Memory.PeakVSZ
+		}
+	}
+
+	if usage.Samples == 0 {
+		return nil
+	}
+
+	usage.AvgRSS, usage.AvgVSZ = rss/uint64(usage.Samples), vsz/uint64(usage.Samples)
+
+	return usage
+}
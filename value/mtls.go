@@ -0,0 +1,195 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AuthPassword/AuthCertificate are the methods 'cbbackupmgr' may use to authenticate with the cluster.
+	AuthPassword    = "password"
+	AuthCertificate = "certificate"
+)
+
+// ClientCertConfig describes the client certificate 'cbbackupmgr' authenticates with rather than a password, using TLS
+// to connect to the cluster. The paths are on the backup client.
+type ClientCertConfig struct {
+	Cert   string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Key    string `json:"key,omitempty" yaml:"key,omitempty"`
+	CACert string `json:"cacert,omitempty" yaml:"cacert,omitempty"`
+}
+
+// Validate returns an error if the client certificate config is invalid.
+func (c *ClientCertConfig) Validate() error {
+	if c.Cert == "" || c.Key == "" || c.CACert == "" {
+		return errors.New("the client certificate, key and CA certificate must all be provided")
+	}
+
+	return nil
+}
+
+// Args returns the arguments which authenticate 'cbbackupmgr' using the client certificate.
+func (c *ClientCertConfig) Args() string {
+	return fmt.Sprintf("--client-cert %s --client-key %s --cacert %s", c.Cert, c.Key, c.CACert)
+}
+
+// TLSConnectionString returns the given connection string using the TLS variant of its scheme.
+//
+// NOTE: Connection strings for clusters using a non-default REST port use 'http://' and are returned unmodified, the
+// client certificate may only be used when the cluster is using the default ports.
+func TLSConnectionString(host string) string {
+	if !strings.HasPrefix(host, "couchbase://") {
+		return host
+	}
+
+	return "couchbases://" + strings.TrimPrefix(host, "couchbase://")
+}
+
+// CommandInstallNodeCert returns a command which moves the uploaded node certificate/key and CA certificate into the
+// inbox of the Couchbase Server install in the given directory, ready to be loaded.
+func CommandInstallNodeCert(installDirectory, chain, key, ca string) Command {
+	inbox := RemoteJoin(installDirectory, "var", "lib", "couchbase", "inbox")
+
+	return NewCommand(`mkdir -p %[1]s/CA && cp %[2]s %[1]s/chain.pem && cp %[3]s %[1]s/pkey.key && \
+		cp %[4]s %[1]s/CA/ca.pem && chown -R --reference=%[5]s %[1]s && chmod -R go-rwx %[1]s`,
+		inbox, chain, key, ca, path.Dir(inbox))
+}
+
+// CommandLoadNodeCert returns a command which loads the CA certificate then the node certificate from the inbox of the
+// node at the given address.
+func CommandLoadNodeCert(host string, credentials *Credentials) Command {
+	return NewCommand(`curl -s -f -g -X POST -u %[1]s http://%[2]s/node/controller/loadTrustedCAs && \
+		curl -s -f -g -X POST -u %[1]s http://%[2]s/node/controller/reloadCertificate`, credentials.UserInfo(), host)
+}
+
+// CommandEnableClientCertAuth returns a command which enables client certificate authentication on the cluster at the
+// given address, mapping the common name of the certificate to the user.
+func CommandEnableClientCertAuth(host string, credentials *Credentials) Command {
+	return NewCommand(`curl -s -f -g -X POST -u %s http://%s/settings/clientCertAuth -H 'Content-Type: application/json' \
+		-d '{"state":"enable","prefixes":[{"path":"subject.cn","prefix":"","delimiter":""}]}'`,
+		credentials.UserInfo(), host)
+}
+
+// CommandWhoAmI returns a command which outputs the user the given client certificate authenticates as, using TLS to
+// connect to the node at the given (TLS) address.
+func (c *ClientCertConfig) CommandWhoAmI(host string) Command {
+	return NewCommand(`curl -s -f -g --cacert %s --cert %s --key %s https://%s/whoami`, c.CACert, c.Cert, c.Key, host)
+}
+
+// WhoAmI is the user returned by the '/whoami' REST endpoint.
+type WhoAmI struct {
+	ID     string `json:"id"`
+	Domain string `json:"domain"`
+}
+
+// ParseWhoAmI parses the response from the '/whoami' REST endpoint.
+func ParseWhoAmI(output []byte) (*WhoAmI, error) {
+	var decoded *WhoAmI
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	if decoded == nil {
+		return nil, errors.New("response is empty")
+	}
+
+	return decoded, nil
+}
+
+// MTLSResult is the result of benchmarking a backup/restore using a single method of authentication.
+type MTLSResult struct {
+	Operation string           `json:"operation"`
+	Auth      string           `json:"auth"`
+	Results   BenchmarkResults `json:"-"`
+
+	AvgDuration        time.Duration `json:"avg_duration,omitempty"`
+	AvgTransferRateADS uint64        `json:"avg_transfer_rate_ads,omitempty"`
+}
+
+// NewMTLSResult calculates the averages of the given results for an operation/method of authentication.
+func NewMTLSResult(operation, auth string, results BenchmarkResults) *MTLSResult {
+	threads := NewThreadsResult(0, results)
+
+	return &MTLSResult{
+		Operation:          operation,
+		Auth:               auth,
+		Results:            results,
+		AvgDuration:        threads.AvgDuration,
+		AvgTransferRateADS: threads.AvgTransferRateADS,
+	}
+}
+
+// MTLSResults are the results of the 'mtls' benchmark, comparing password and client certificate authentication.
+type MTLSResults struct {
+	// Identity is the user the client certificate authenticated as, validating the mTLS path end-to-end.
+	Identity string `json:"identity"`
+
+	Results []*MTLSResult `json:"results"`
+}
+
+// overhead returns the percentage by which the given result was slower than its password authenticated equivalent,
+// an empty string is returned for password authenticated results.
+func (m *MTLSResults) overhead(result *MTLSResult) string {
+	if result.Auth == AuthPassword {
+		return ""
+	}
+
+	for _, baseline := range m.Results {
+		if baseline.Auth == AuthPassword && baseline.Operation == result.Operation && baseline.AvgDuration != 0 {
+			return fmt.Sprintf("%+.1f%%", float64(result.AvgDuration-baseline.AvgDuration)/
+				float64(baseline.AvgDuration)*100)
+		}
+	}
+
+	return ""
+}
+
+// String returns a string representation of the mTLS results which will be output in the report.
+func (m *MTLSResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| mTLS\n| ----")
+	fmt.Fprintf(writer, "| Operation\t Authentication\t Avg Duration\t Avg Transfer Rate (ADS)\t Overhead\t\n")
+
+	for _, result := range m.Results {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s/s\t %s\t\n",
+			result.Operation,
+			result.Auth,
+			format.Duration(result.AvgDuration),
+			format.Bytes(result.AvgTransferRateADS),
+			m.overhead(result))
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprintf(buffer, "\nThe client certificate authenticated as '%s'", m.Identity)
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,194 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// MultiRestoreBucketPrefix is the prefix for the names of the buckets created by the 'multi-restore' benchmark, the
+// benchmarking bucket is restored into each of them.
+const MultiRestoreBucketPrefix = "restore-"
+
+// MultiRestoreConfig describes the 'multi-restore' benchmark, which restores the same backup into multiple buckets
+// concurrently (using a 'cbbackupmgr' process per bucket) to measure the contention between them.
+type MultiRestoreConfig struct {
+	// Buckets is the number of buckets which are restored concurrently.
+	Buckets int `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+}
+
+// Validate returns an error if the multi-restore config is missing, or doesn't describe multiple buckets.
+func (m *MultiRestoreConfig) Validate() error {
+	if m == nil || m.Buckets < 2 {
+		return fmt.Errorf("at least two buckets must be provided to benchmark concurrent restores")
+	}
+
+	return nil
+}
+
+// BucketNames returns the names of the buckets which will be restored into.
+func (m *MultiRestoreConfig) BucketNames() []string {
+	names := make([]string, 0, m.Buckets)
+
+	for idx := 1; idx <= m.Buckets; idx++ {
+		names = append(names, fmt.Sprintf("%s%d", MultiRestoreBucketPrefix, idx))
+	}
+
+	return names
+}
+
+// MultiRestoreResult is the result of a single iteration of the 'multi-restore' benchmark.
+type MultiRestoreResult struct {
+	// Baseline is the restore of a single bucket whilst no other restores are running, it's used to determine the
+	// impact of the contention between the concurrent restores.
+	Baseline *BenchmarkResult `json:"baseline"`
+
+	// BaselineCPU is the CPU usage (as a percentage) of the backup client during the baseline restore.
+	BaselineCPU float64 `json:"baseline_cpu"`
+
+	// Buckets contains the result for each of the concurrently restored buckets, in the same order as the names
+	// returned by 'BucketNames'.
+	Buckets []*BenchmarkResult `json:"buckets"`
+
+	// Duration is how long it took for all the concurrent restores to complete.
+	Duration time.Duration `json:"duration"`
+
+	// CPU is the CPU usage (as a percentage) of the backup client whilst running the concurrent restores.
+	CPU float64 `json:"cpu"`
+}
+
+// AggregateTransferRate returns the combined transfer rate of the concurrent restores, calculated using the actual
+// data size.
+func (m *MultiRestoreResult) AggregateTransferRate() uint64 {
+	var ads uint64
+	for _, bucket := range m.Buckets {
+		ads += bucket.ADS
+	}
+
+	if m.Duration < time.Second {
+		return ads
+	}
+
+	return ads / uint64(m.Duration.Seconds())
+}
+
+// BucketTransferRates returns the minimum/average/maximum transfer rate of the concurrently restored buckets.
+func (m *MultiRestoreResult) BucketTransferRates() (uint64, uint64, uint64) {
+	if len(m.Buckets) == 0 {
+		return 0, 0, 0
+	}
+
+	var lowest, total, highest uint64
+
+	for idx, bucket := range m.Buckets {
+		rate := bucket.AvgTransferRateADS()
+
+		if idx == 0 || rate < lowest {
+			lowest = rate
+		}
+
+		if rate > highest {
+			highest = rate
+		}
+
+		total += rate
+	}
+
+	return lowest, total / uint64(len(m.Buckets)), highest
+}
+
+// Scaling returns the aggregate transfer rate as a multiple of the baseline transfer rate, perfect scaling would
+// return the number of buckets.
+func (m *MultiRestoreResult) Scaling() float64 {
+	baseline := m.Baseline.AvgTransferRateADS()
+	if baseline == 0 {
+		return 0
+	}
+
+	return float64(m.AggregateTransferRate()) / float64(baseline)
+}
+
+// Contention returns the percentage by which the average per-bucket transfer rate dropped versus the baseline.
+func (m *MultiRestoreResult) Contention() float64 {
+	baseline := m.Baseline.AvgTransferRateADS()
+	if baseline == 0 {
+		return 0
+	}
+
+	_, avg, _ := m.BucketTransferRates()
+
+	return maxFloat(0, 100*(1-float64(avg)/float64(baseline)))
+}
+
+// MultiRestoreResults is a wrapper around a slice of multi-restore results which provides a human readable
+// representation.
+type MultiRestoreResults []*MultiRestoreResult
+
+// String returns a human readable string representation of the multi-restore results which will be displayed in the
+// report.
+func (m MultiRestoreResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Concurrent Restore\n| ------------------")
+	fmt.Fprintf(writer, "| Iteration\t Buckets\t Baseline Rate (ADS)\t Aggregate Rate (ADS)\t Scaling\t "+
+		"Per-Bucket Rate (Min/Avg/Max)\t Contention\t Client CPU (Baseline/Concurrent)\t\n")
+
+	for idx, result := range m {
+		lowest, avg, highest := result.BucketTransferRates()
+
+		fmt.Fprintf(writer, "| %d\t %d\t %s/s\t %s/s\t %.2fx\t %s/s / %s/s / %s/s\t %.2f%%\t %.2f%% / %.2f%%\t\n",
+			idx+1,
+			len(result.Buckets),
+			format.Bytes(result.Baseline.AvgTransferRateADS()),
+			format.Bytes(result.AggregateTransferRate()),
+			result.Scaling(),
+			format.Bytes(lowest),
+			format.Bytes(avg),
+			format.Bytes(highest),
+			result.Contention(),
+			result.BaselineCPU,
+			result.CPU)
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprintln(buffer, "\n| Concurrent Restore Buckets\n| --------------------------")
+	fmt.Fprintf(writer, "| Iteration\t Bucket\t Duration\t Size (ADS)\t Transfer Rate (ADS)\t\n")
+
+	for idx, result := range m {
+		for bucket, bucketResult := range result.Buckets {
+			fmt.Fprintf(writer, "| %d\t %s%d\t %s\t %s\t %s/s\t\n",
+				idx+1,
+				MultiRestoreBucketPrefix,
+				bucket+1,
+				format.Duration(bucketResult.Duration),
+				format.Bytes(bucketResult.ADS),
+				format.Bytes(bucketResult.AvgTransferRateADS()))
+		}
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/util"
+
+	"github.com/pkg/errors"
+)
+
+// NoisyCPUThreshold is the CPU usage (as a percentage of a single core) above which an unexpected process is considered
+// to be a noisy neighbor.
+const NoisyCPUThreshold = 10.0
+
+// BenchmarkProcesses are processes which indicate that another benchmark is running on the host, regardless of their
+// CPU usage.
+//
+// NOTE: Processes are matched using their command name, which is truncated to 15 characters by the kernel.
+var BenchmarkProcesses = []string{"cbbackupmgr", "cbc-pillowfight", "cbtools-autoben", "cbworkloadgen"}
+
+// CouchbaseProcesses are processes which indicate that an instance of Couchbase Server is running on the host.
+var CouchbaseProcesses = []string{"beam.smp", "memcached"}
+
+// CommandHostLoad returns a command which outputs the load averages, the number of CPUs and the processes running on
+// the remote machine.
+func CommandHostLoad() Command {
+	return NewCommand("cat /proc/loadavg; nproc; ps -eo pid=,pcpu=,comm=")
+}
+
+// Neighbor is a process which may skew the results of a benchmark run on the same host.
+type Neighbor struct {
+	PID     int     `json:"pid"`
+	Command string  `json:"command"`
+	CPU     float64 `json:"cpu_percent"`
+	Reason  string  `json:"reason"`
+}
+
+// String returns a short human readable description of the neighbor.
+func (n *Neighbor) String() string {
+	return fmt.Sprintf("%s (pid %d, %s)", n.Command, n.PID, n.Reason)
+}
+
+// HostLoad is the load on a host at the start of a benchmark, along with any noisy neighbors.
+type HostLoad struct {
+	Host      string      `json:"host"`
+	Load      [3]float64  `json:"load_average"`
+	CPUs      int         `json:"cpus"`
+	Neighbors []*Neighbor `json:"neighbors,omitempty"`
+}
+
+// ParseHostLoad parses the output of the command returned by 'CommandHostLoad' for the given host, the provided
+// processes are expected to be running on the host and are never considered to be noisy neighbors.
+func ParseHostLoad(host string, output []byte, expected []string) (*HostLoad, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("expected at least 2 lines of output but got %d", len(lines))
+	}
+
+	load := &HostLoad{Host: host, Neighbors: make([]*Neighbor, 0)}
+
+	fields := strings.Fields(lines[0])
+	if len(fields) < len(load.Load) {
+		return nil, fmt.Errorf("unexpected load averages '%s'", lines[0])
+	}
+
+	var err error
+
+	for idx := range load.Load {
+		load.Load[idx], err = strconv.ParseFloat(fields[idx], 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse load average")
+		}
+	}
+
+	load.CPUs, err = strconv.Atoi(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse number of cpus")
+	}
+
+	for _, line := range lines[2:] {
+		neighbor, err := parseNeighbor(line, expected)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse process '%s'", line)
+		}
+
+		if neighbor != nil {
+			load.Neighbors = append(load.Neighbors, neighbor)
+		}
+	}
+
+	return load, nil
+}
+
+// parseNeighbor parses a single process from the output of 'ps', returning nil if it's not a noisy neighbor.
+func parseNeighbor(line string, expected []string) (*Neighbor, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, nil
+	}
+
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse pid")
+	}
+
+	cpu, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse cpu usage")
+	}
+
+	neighbor := &Neighbor{PID: pid, Command: strings.Join(fields[2:], " "), CPU: cpu}
+
+	switch {
+	// The 'ps' process itself has only just started, so its CPU usage is always high
+	case neighbor.Command == "ps" || util.Contains(neighbor.Command, expected...):
+		return nil, nil
+	case util.Contains(neighbor.Command, BenchmarkProcesses...):
+		neighbor.Reason = "benchmark"
+	case util.Contains(neighbor.Command, CouchbaseProcesses...):
+		neighbor.Reason = "couchbase"
+	case cpu >= NoisyCPUThreshold:
+		neighbor.Reason = fmt.Sprintf("%.1f%% cpu", cpu)
+	default:
+		return nil, nil
+	}
+
+	return neighbor, nil
+}
+
+// Busy returns a boolean indicating whether the host was already busy i.e. the one minute load average is at least
+// half the number of CPUs.
+func (h *HostLoad) Busy() bool {
+	return h.CPUs != 0 && h.Load[0] >= float64(h.CPUs)/2
+}
+
+// HostLoads is a wrapper around a slice of host loads which provides some utility functions.
+type HostLoads []*HostLoad
+
+// Contaminated returns the hosts which were busy or had noisy neighbors at the start of the benchmark, meaning the
+// results may not be representative.
+func (h HostLoads) Contaminated() HostLoads {
+	contaminated := make(HostLoads, 0)
+
+	for _, load := range h {
+		if load.Busy() || len(load.Neighbors) != 0 {
+			contaminated = append(contaminated, load)
+		}
+	}
+
+	return contaminated
+}
+
+// String returns a human readable string representation of the host loads which will be displayed in the report.
+func (h HostLoads) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Host Load\n| ---------")
+	fmt.Fprintf(writer, "| Host\t Load Average (1m/5m/15m)\t CPUs\t Noisy Neighbors\t\n")
+
+	for _, load := range h {
+		neighbors := make([]string, 0, len(load.Neighbors))
+		for _, neighbor := range load.Neighbors {
+			neighbors = append(neighbors, neighbor.String())
+		}
+
+		if len(neighbors) == 0 {
+			neighbors = append(neighbors, "-")
+		}
+
+		fmt.Fprintf(writer, "| %s\t %.2f/%.2f/%.2f\t %d\t %s\t\n", load.Host, load.Load[0], load.Load[1], load.Load[2],
+			load.CPUs, strings.Join(neighbors, ", "))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -14,9 +14,170 @@
 
 package value
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
 // NodeBlueprint represents the configuration for a Couchbase Cluster node.
 type NodeBlueprint struct {
-	Host      string `json:"host,omitempty" yaml:"host,omitempty"`
+	// Host is the hostname/address of the node, it may be a pattern e.g. 'node[01-12].perf.lab' which is expanded into
+	// a node for each host.
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+
+	// HostsFile is the path to a file containing a host (or host pattern) per line, which is expanded into a node for
+	// each host; used instead of 'Host'.
+	HostsFile string `json:"-" yaml:"hosts_file,omitempty"`
+
+	// AWS is a query for EC2 instances which is expanded into a node for each instance; used instead of 'Host'.
+	AWS *AWSInventory `json:"-" yaml:"aws,omitempty"`
+
+	// Hostname is the name (e.g. an FQDN) the node will be known by inside the cluster, set using '--node-init-hostname'.
+	// When empty, the node will be added to the cluster using 'Host'.
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+
 	DataPath  string `json:"-" yaml:"data_path,omitempty"`
 	IndexPath string `json:"-" yaml:"index_path,omitempty"`
+
+	// Services are the services run by the node e.g. 'data,index'. By default, the first node and nodes with a data
+	// path run the data service otherwise nodes with an index path run the search service.
+	//
+	// NOTE: The first node must run the data service, since it's used to create/monitor the buckets.
+	Services Services `json:"services,omitempty" yaml:"services,omitempty"`
+
+	// InstanceStore formats/mounts an NVMe instance store device during provisioning, for example so that it may be used
+	// as the data path.
+	InstanceStore *InstanceStoreBlueprint `json:"instance_store,omitempty" yaml:"instance_store,omitempty"`
+
+	// TieredStorage builds a tiered device (e.g. NVMe cache over HDD/EBS) during provisioning, for example so that it
+	// may be used as the data path.
+	TieredStorage *TieredStorageBlueprint `json:"tiered_storage,omitempty" yaml:"tiered_storage,omitempty"`
+
+	// EBS modifies the type/performance of the EBS volumes attached to the node during provisioning.
+	EBS *EBSBlueprint `json:"ebs,omitempty" yaml:"ebs,omitempty"`
+
+	// Readiness controls how long we wait for Couchbase Server to become ready after installation, by default the
+	// readiness config from the cluster blueprint is used.
+	Readiness *ReadinessConfig `json:"-" yaml:"readiness,omitempty"`
+
+	// AlternateAddress is the address external clients should use to connect to this node e.g. a public IP address.
+	AlternateAddress string `json:"alternate_address,omitempty" yaml:"alternate_address,omitempty"`
+
+	// RESTPort/KVPort allow running Couchbase Server using non-default ports, for example, where 8091 is already taken.
+	// A zero value indicates that the default port should be used.
+	RESTPort uint16 `json:"rest_port,omitempty" yaml:"rest_port,omitempty"`
+	KVPort   uint16 `json:"kv_port,omitempty" yaml:"kv_port,omitempty"`
+
+	// Transport describes how commands are executed on the node, by default ssh is used.
+	Transport *TransportBlueprint `json:"transport,omitempty" yaml:"transport,omitempty"`
+
+	// Platform overrides the platform detected using '/etc/os-release' e.g. 'debian12', for example where the image is
+	// a derivative of a supported distribution.
+	Platform Platform `json:"platform,omitempty" yaml:"platform,omitempty"`
+
+	// ServerGroup is the server group (i.e. rack/zone) the node will be placed in, when empty the node will be placed in
+	// the default server group.
+	ServerGroup string `json:"server_group,omitempty" yaml:"server_group,omitempty"`
+
+	// RemoteTmpDir is the directory on the node which contains the per-run temporary directory, which all the
+	// uploads/scratch files are written to. By default '/tmp' is used.
+	RemoteTmpDir string `json:"-" yaml:"remote_tmp_dir,omitempty"`
+}
+
+// RemoteTmpDirOrDefault returns the directory on the node which contains the per-run temporary directories.
+func (n *NodeBlueprint) RemoteTmpDirOrDefault() string {
+	if n.RemoteTmpDir == "" {
+		return TempDirectoryParent
+	}
+
+	return strings.TrimRight(n.RemoteTmpDir, "/")
+}
+
+// ValidateRemoteTmpDir returns an error if the remote temporary directory isn't an absolute path, the per-run
+// directories are removed using 'find' so a relative path (or the root directory) would be dangerous.
+func (n *NodeBlueprint) ValidateRemoteTmpDir() error {
+	if n.RemoteTmpDir == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(n.RemoteTmpDir, "/") || n.RemoteTmpDirOrDefault() == "" {
+		return fmt.Errorf("remote temporary directory '%s' must be an absolute path other than '/'", n.RemoteTmpDir)
+	}
+
+	return nil
+}
+
+// ServicesOrDefault returns the services run by the node, nil is returned if the services can't be determined because
+// the node doesn't have a data or index path.
+func (n *NodeBlueprint) ServicesOrDefault(first bool) Services {
+	switch {
+	case len(n.Services) != 0:
+		return n.Services
+	case first || n.DataPath != "":
+		return Services{ServiceData}
+	case n.IndexPath != "":
+		return Services{ServiceSearch}
+	}
+
+	return nil
+}
+
+// RESTPortOrDefault returns the port used by the REST API on this node.
+func (n *NodeBlueprint) RESTPortOrDefault() uint16 {
+	if n.RESTPort == 0 {
+		return DefaultRESTPort
+	}
+
+	return n.RESTPort
+}
+
+// KVPortOrDefault returns the port used by the data service on this node.
+func (n *NodeBlueprint) KVPortOrDefault() uint16 {
+	if n.KVPort == 0 {
+		return DefaultKVPort
+	}
+
+	return n.KVPort
+}
+
+// CustomPorts returns a boolean indicating whether this node uses any non-default ports.
+func (n *NodeBlueprint) CustomPorts() bool {
+	return n.RESTPortOrDefault() != DefaultRESTPort || n.KVPortOrDefault() != DefaultKVPort
+}
+
+// Name returns the name the node is known by inside the cluster.
+func (n *NodeBlueprint) Name() string {
+	if n.Hostname != "" {
+		return n.Hostname
+	}
+
+	return n.Host
+}
+
+// RESTAddress returns the 'host:port' address of the REST API on this node, IPv6 addresses will be bracketed.
+func (n *NodeBlueprint) RESTAddress() string {
+	return net.JoinHostPort(n.Host, strconv.Itoa(int(n.RESTPortOrDefault())))
+}
+
+// ClusterAddress returns the 'name:port' address used to refer to this node from other nodes in the cluster.
+func (n *NodeBlueprint) ClusterAddress() string {
+	return net.JoinHostPort(n.Name(), strconv.Itoa(int(n.RESTPortOrDefault())))
+}
+
+// IsIPv6 returns a boolean indicating whether the given host is an IPv6 address.
+func IsIPv6(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+// FormatHost returns the given host in a form which may be used in a URL/connection string, i.e. IPv6 addresses will be
+// bracketed.
+func FormatHost(host string) string {
+	if IsIPv6(host) {
+		return "[" + host + "]"
+	}
+
+	return host
 }
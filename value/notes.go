@@ -0,0 +1,67 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NotesFile is the file in the run directory which contains the notes attached to the run using 'note add'.
+const NotesFile = "notes.json"
+
+// NotesPath returns the path to the notes attached to the given run.
+func (r RunID) NotesPath() string {
+	return filepath.Join(r.LocalDirectory(), NotesFile)
+}
+
+// Note is a manual observation attached to a run by an operator e.g. "switch firmware updated mid-run", which provides
+// context to later readers of the results.
+type Note struct {
+	Text   string    `json:"text"`
+	Author string    `json:"author,omitempty"`
+	Added  time.Time `json:"added"`
+}
+
+// Notes is a wrapper around a slice of notes which provides a human readable representation.
+type Notes []*Note
+
+// String returns a human readable string representation of the notes which will be displayed in the report.
+func (n Notes) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Notes\n| -----")
+	fmt.Fprintf(writer, "| Added\t Author\t Note\t\n")
+
+	for _, note := range n {
+		author := note.Author
+		if author == "" {
+			author = "-"
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", note.Added.Format("2006-01-02 15:04:05"), author, note.Text)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
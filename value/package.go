@@ -0,0 +1,157 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PackageType represents the type of Couchbase Server package which will be installed on a remote machine.
+type PackageType string
+
+const (
+	// PackageTypeDEB is a Debian package installed using 'dpkg'.
+	PackageTypeDEB PackageType = "deb"
+
+	// PackageTypeRPM is an RPM package installed using 'yum'.
+	PackageTypeRPM PackageType = "rpm"
+
+	// PackageTypeTar is the non-root tarball install of Couchbase Server which may be extracted into any directory; this
+	// is useful in restricted environments where we aren't able to install packages as root.
+	PackageTypeTar PackageType = "tar"
+)
+
+// packageTypeFromPath returns the package type based on the extension of the given path, an empty string is returned if
+// the type could not be determined.
+func packageTypeFromPath(p string) PackageType {
+	switch {
+	case strings.HasSuffix(p, ".deb"):
+		return PackageTypeDEB
+	case strings.HasSuffix(p, ".rpm"):
+		return PackageTypeRPM
+	case strings.HasSuffix(p, ".tar"), strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		return PackageTypeTar
+	}
+
+	return ""
+}
+
+// Package describes a Couchbase Server package and where it will be installed on the remote machine.
+type Package struct {
+	// Path is the local path to the package.
+	Path string
+
+	// Type is the type of the package, determines how it will be installed.
+	Type PackageType
+
+	// Directory is the directory that a 'tar' package will be extracted into, ignored for other package types.
+	Directory string
+}
+
+// NewPackage returns a package using the given type, falling back to the type determined from the package extension
+// when no type is provided.
+func NewPackage(p string, packageType PackageType, directory string) *Package {
+	if packageType == "" {
+		packageType = packageTypeFromPath(p)
+	}
+
+	return &Package{Path: p, Type: packageType, Directory: directory}
+}
+
+// Validate returns an error if the package won't be able to be installed.
+func (p *Package) Validate() error {
+	switch p.Type {
+	case PackageTypeDEB, PackageTypeRPM:
+		return nil
+	case PackageTypeTar:
+		if p.Directory == "" {
+			return fmt.Errorf("an install directory must be provided for 'tar' packages")
+		}
+
+		return nil
+	case "":
+		return fmt.Errorf("unable to determine package type for '%s'", p.Path)
+	}
+
+	return fmt.Errorf("unsupported package type '%s'", p.Type)
+}
+
+// InstallDirectory returns the directory Couchbase Server will be installed into.
+func (p *Package) InstallDirectory() string {
+	if p.Type != PackageTypeTar {
+		return CBInstallDirectory
+	}
+
+	return RemoteJoin(p.Directory, "opt", "couchbase")
+}
+
+// BinDirectory returns the directory containing the Couchbase Server binaries.
+func (p *Package) BinDirectory() string {
+	return RemoteJoin(p.InstallDirectory(), "bin")
+}
+
+// CommandInstallTarball returns a command which will extract/relocate the uploaded tarball at the given path.
+func (p *Package) CommandInstallTarball(remotePath string) Command {
+	return NewCommand(`mkdir -p %[1]s && tar -xf %[2]s -C %[1]s && cd %[3]s && ./bin/install/reloc.sh %[3]s && \
+		./bin/couchbase-server -- -noinput -detached`, p.Directory, remotePath, p.InstallDirectory())
+}
+
+// CommandStartTarball returns a command which will start a tarball install of Couchbase Server.
+func (p *Package) CommandStartTarball() Command {
+	return NewCommand("%s/couchbase-server -- -noinput -detached", p.BinDirectory())
+}
+
+// CommandInstalledVersion returns a command which outputs the build of Couchbase Server installed in the install
+// directory e.g. '7.0.0-5302', the output is empty if it's not installed.
+func (p *Package) CommandInstalledVersion() Command {
+	return NewCommand("cat %s 2>/dev/null || true", RemoteJoin(p.InstallDirectory(), "VERSION.txt"))
+}
+
+// CommandWipeState returns a command which removes all the state generated by Couchbase Server (unlike
+// 'CommandResetState', this includes the cluster membership and buckets) so that an existing install is initialized as
+// a new node. Any ports added to the static config are also removed, they're re-added when provisioning with custom
+// ports.
+//
+// NOTE: Couchbase Server must be stopped before running this command.
+func (p *Package) CommandWipeState() Command {
+	return NewCommand("rm -rf %s/* && %s", RemoteJoin(p.InstallDirectory(), "var", "lib", "couchbase"),
+		p.commandRemovePorts())
+}
+
+// CommandConfigurePorts returns a command which sets the given REST/KV ports in the static config, replacing those from
+// any previous provision. The generated config is also removed, since otherwise the ports would be ignored.
+//
+// NOTE: Couchbase Server must be stopped before running this command.
+func (p *Package) CommandConfigurePorts(restPort, kvPort uint16) Command {
+	return NewCommand(`%s && printf '{rest_port, %d}.\n{memcached_port, %d}.\n' >> %s && rm -f %s`,
+		p.commandRemovePorts(), restPort, kvPort, p.staticConfig(),
+		RemoteJoin(p.InstallDirectory(), "var", "lib", "couchbase", "config", "config.dat"))
+}
+
+// commandRemovePorts returns a command which removes any ports added to the static config by 'CommandConfigurePorts'.
+func (p *Package) commandRemovePorts() Command {
+	return NewCommand(`sed -i '/^{rest_port,/d;/^{memcached_port,/d' %s`, p.staticConfig())
+}
+
+// staticConfig returns the path to the static config of the install, which is read when Couchbase Server starts.
+func (p *Package) staticConfig() string {
+	return RemoteJoin(p.InstallDirectory(), "etc", "couchbase", "static_config")
+}
+
+// CommandStopTarball returns a command which will stop a tarball install of Couchbase Server (if it's running).
+func (p *Package) CommandStopTarball() Command {
+	return NewCommand("test ! -e %[1]s/couchbase-server || %[1]s/couchbase-server -k", p.BinDirectory())
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStateFile is the local file shared by the phase sub-commands, used to record the phases which have completed.
+var DefaultStateFile = filepath.Join(RunsDirectory, "state.json")
+
+// Phase is a single phase of the benchmarking pipeline, which may be run individually using its sub-command.
+type Phase string
+
+const (
+	// PhaseProvision installs/configures the cluster and backup client.
+	PhaseProvision Phase = "provision"
+
+	// PhaseLoad loads the test dataset into the cluster.
+	PhaseLoad Phase = "load"
+
+	// PhaseBackup runs the backup benchmark.
+	PhaseBackup Phase = "backup"
+
+	// PhaseRestore runs the restore benchmark.
+	PhaseRestore Phase = "restore"
+)
+
+// BenchmarkPhases are the phases which produce a report.
+var BenchmarkPhases = []Phase{PhaseBackup, PhaseRestore}
+
+// PhaseRecord records the completion of a phase.
+type PhaseRecord struct {
+	RunID     RunID     `json:"run_id"`
+	Completed time.Time `json:"completed"`
+	Status    RunStatus `json:"status,omitempty"`
+
+	// Report/ReportJSON are the human readable/JSON reports produced by benchmark phases.
+	Report     string          `json:"report,omitempty"`
+	ReportJSON json.RawMessage `json:"report_json,omitempty"`
+}
+
+// PhaseState is the state shared by the phase sub-commands, it contains the latest record of each phase for every
+// environment (keyed by the environment name, which is empty when there's only a single environment).
+type PhaseState struct {
+	Environments map[string]map[Phase]*PhaseRecord `json:"environments"`
+}
+
+// NewPhaseState returns a new empty state.
+func NewPhaseState() *PhaseState {
+	return &PhaseState{Environments: make(map[string]map[Phase]*PhaseRecord)}
+}
+
+// Record records the completion of the given phase for the given environment, replacing any previous record.
+func (p *PhaseState) Record(environment string, phase Phase, record *PhaseRecord) {
+	if p.Environments == nil {
+		p.Environments = make(map[string]map[Phase]*PhaseRecord)
+	}
+
+	if p.Environments[environment] == nil {
+		p.Environments[environment] = make(map[Phase]*PhaseRecord)
+	}
+
+	p.Environments[environment][phase] = record
+}
+
+// Get returns the latest record of the given phase for the given environment, nil is returned if the phase hasn't
+// completed.
+func (p *PhaseState) Get(environment string, phase Phase) *PhaseRecord {
+	return p.Environments[environment][phase]
+}
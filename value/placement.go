@@ -0,0 +1,97 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// PlacementConfig describes how the benchmarked process should be placed on the CPUs/NUMA nodes of the backup client.
+// This is useful when running experiments on large NUMA machines where the scheduler may otherwise migrate threads
+// between nodes.
+type PlacementConfig struct {
+	// CPUSet is the list of CPUs the process may run on, in the format accepted by 'taskset' e.g. '0-15,32-47'.
+	CPUSet string `json:"cpu_set,omitempty" yaml:"cpu_set,omitempty"`
+
+	// NUMANodes is the list of NUMA nodes the process (and its memory) will be bound to e.g. '0' or '0,1'. When provided
+	// 'numactl' will be used instead of 'taskset'.
+	NUMANodes string `json:"numa_nodes,omitempty" yaml:"numa_nodes,omitempty"`
+}
+
+// String returns a human readable string representation of the placement which will be displayed in the report.
+func (p *PlacementConfig) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	cpus := "any"
+	if p.CPUSet != "" {
+		cpus = p.CPUSet
+	}
+
+	nodes := "any"
+	if p.NUMANodes != "" {
+		nodes = p.NUMANodes
+	}
+
+	fmt.Fprintln(buffer, "| CBM Placement\n| -------------")
+	fmt.Fprintf(writer, "| Tool\t CPU Set\t NUMA Nodes\t\n")
+	fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", p.tool(), cpus, nodes)
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// Enabled returns a boolean indicating whether any placement has been requested.
+func (p *PlacementConfig) Enabled() bool {
+	return p != nil && (p.CPUSet != "" || p.NUMANodes != "")
+}
+
+// tool returns the name of the tool that will be used to apply the placement.
+func (p *PlacementConfig) tool() string {
+	switch {
+	case p.NUMANodes != "":
+		return "numactl"
+	case p.CPUSet != "":
+		return "taskset"
+	}
+
+	return "none"
+}
+
+// prefix will prefix the given command with 'numactl'/'taskset' so that it's run using the configured placement.
+//
+// NOTE: The command must not already be prefixed with any environment variables.
+func (p *PlacementConfig) prefix(command string) string {
+	if !p.Enabled() {
+		return command
+	}
+
+	if p.NUMANodes == "" {
+		return fmt.Sprintf("taskset -c %s %s", p.CPUSet, command)
+	}
+
+	args := fmt.Sprintf("--cpunodebind=%s --membind=%s", p.NUMANodes, p.NUMANodes)
+	if p.CPUSet != "" {
+		args = fmt.Sprintf("--physcpubind=%s --membind=%s", p.CPUSet, p.NUMANodes)
+	}
+
+	return fmt.Sprintf("numactl %s %s", args, command)
+}
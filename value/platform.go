@@ -16,91 +16,51 @@ package value
 
 import (
 	"fmt"
-	"strings"
 )
 
 // Platform represents the platform that 'cbtools-autobench' is currently being run against (note this is referring to
 // the remote machine).
 //
-// NOTE: At the moment, only Linux is supported, however, package managers and package names may differ; this means
-// additional work may be required to handle different distributions.
+// NOTE: At the moment, only Linux is supported. The package manager/init system are detected separately (see
+// 'Capabilities'), the platform only describes what differs between distributions e.g. package names, meaning
+// supporting a new distribution requires a new platform and detecting it in 'determinePlatform'.
 type Platform string
 
 const (
 	// PlatformUbuntu20_04 represents the 20.04 release of Ubuntu.
 	PlatformUbuntu20_04 Platform = "ubuntu20.04"
 
+	// PlatformUbuntu22_04 represents the 22.04 release of Ubuntu.
+	PlatformUbuntu22_04 Platform = "ubuntu22.04"
+
+	// PlatformDebian11/PlatformDebian12 represent the 11 (bullseye) and 12 (bookworm) releases of Debian.
+	PlatformDebian11 Platform = "debian11"
+	PlatformDebian12 Platform = "debian12"
+
 	// PlatformAmazonLinux2 represents the second version of Amazon Linux, note that the first version is now hidden
 	// from users and in theory should no longer be used.
 	PlatformAmazonLinux2 Platform = "amzn2"
 )
 
-// PackageExtension returns the extension used by this platforms package manager.
-func (p Platform) PackageExtension() string {
-	switch p {
-	case PlatformUbuntu20_04:
-		return "deb"
-	case PlatformAmazonLinux2:
-		return "rpm"
-	}
-
-	panic(fmt.Sprintf("unsupported platform '%s'", p))
-}
-
 // Dependencies returns a list of package names which will be installed if they are missing.
 func (p Platform) Dependencies() []string {
 	switch p {
-	case PlatformUbuntu20_04:
-		return []string{"awscli", "libtinfo5"}
+	case PlatformUbuntu20_04, PlatformUbuntu22_04, PlatformDebian11, PlatformDebian12:
+		return []string{"awscli", "libtinfo5", "numactl"}
 	case PlatformAmazonLinux2:
-		return []string{"awscli", "ncurses-compat-libs"}
+		return []string{"awscli", "ncurses-compat-libs", "numactl"}
 	}
 
 	panic(fmt.Sprintf("unsupported platform '%s'", p))
 }
 
-// CommandInstallPackageAt returns a command which can be used to install the package at the provided path.
-func (p Platform) CommandInstallPackageAt(path string) Command {
+// Validate returns an error if the platform isn't supported, an empty platform is valid since it indicates that the
+// platform should be detected.
+func (p Platform) Validate() error {
 	switch p {
-	case PlatformUbuntu20_04:
-		return NewCommand("dpkg -i %s", path)
-	case PlatformAmazonLinux2:
-		return NewCommand("yum install -y %s", path)
+	case "", PlatformUbuntu20_04, PlatformUbuntu22_04, PlatformDebian11, PlatformDebian12, PlatformAmazonLinux2:
+		return nil
 	}
 
-	panic(fmt.Sprintf("unsupported platform '%s'", p))
-}
-
-// CommandInstallPackages returns a command which can be used to installed the provided list of packages by name.
-func (p Platform) CommandInstallPackages(packages ...string) Command {
-	switch p {
-	case PlatformUbuntu20_04:
-		return NewCommand("apt update && apt install -y %s", strings.Join(packages, " "))
-	case PlatformAmazonLinux2:
-		return NewCommand("yum update -y && yum install -y %s", strings.Join(packages, " "))
-	}
-
-	panic(fmt.Sprintf("unsupported platform '%s'", p))
-}
-
-// CommandUninstallPackages returns a command which can be used to uninstall the provided list of package by name.
-func (p Platform) CommandUninstallPackages(packages ...string) Command {
-	switch p {
-	case PlatformUbuntu20_04:
-		return NewCommand("dpkg --purge %s", strings.Join(packages, " "))
-	case PlatformAmazonLinux2:
-		return NewCommand("yum autoremove -y %s", strings.Join(packages, " "))
-	}
-
-	panic(fmt.Sprintf("unsupported platform '%s'", p))
-}
-
-// CommandDisableCouchbase returns a command which when executed on the remote machine will disable Couchbase Server.
-func (p Platform) CommandDisableCouchbase() Command {
-	switch p {
-	case PlatformUbuntu20_04, PlatformAmazonLinux2:
-		return NewCommand("systemctl disable --now couchbase-server")
-	}
-
-	panic(fmt.Sprintf("unsupported platform '%s'", p))
+	return fmt.Errorf("unsupported platform '%s'", p)
 }
@@ -0,0 +1,73 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// DistributionPort is the port used for node-to-node communication by the cluster manager.
+const DistributionPort = 21100
+
+// PeerPorts returns the ports which must be reachable on the given node from all the other nodes in the cluster.
+func PeerPorts(node *NodeBlueprint) []uint16 {
+	return []uint16{node.RESTPortOrDefault(), node.KVPortOrDefault(), DistributionPort}
+}
+
+// PortCheck is the result of checking whether a port on one node is reachable from another.
+type PortCheck struct {
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Port      uint16 `json:"port"`
+	Reachable bool   `json:"reachable"`
+}
+
+// PortMatrix is a wrapper around a slice of port checks which provides some utility functions.
+type PortMatrix []PortCheck
+
+// Unreachable returns the checks which failed.
+func (p PortMatrix) Unreachable() PortMatrix {
+	unreachable := make(PortMatrix, 0)
+
+	for _, check := range p {
+		if !check.Reachable {
+			unreachable = append(unreachable, check)
+		}
+	}
+
+	return unreachable
+}
+
+// String returns a human readable string representation of the matrix.
+func (p PortMatrix) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Port Reachability\n| -----------------")
+	fmt.Fprintf(writer, "| Source\t Target\t Port\t Reachable\t\n")
+
+	for _, check := range p {
+		fmt.Fprintf(writer, "| %s\t %s\t %d\t %t\t\n", check.Source, check.Target, check.Port, check.Reachable)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
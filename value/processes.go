@@ -0,0 +1,130 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ProcessesFile is the file in the run directory which lists the long-running remote processes which are running.
+const ProcessesFile = "processes.json"
+
+// unsafeName matches the characters which are replaced when a command name is used as a PID file name.
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ProcessesPath returns the path to the list of running remote processes for the given run.
+func (r RunID) ProcessesPath() string {
+	return filepath.Join(r.LocalDirectory(), ProcessesFile)
+}
+
+// ProcessDirectory returns the directory on the remote machines (within the given parent) which contains a PID file for
+// each of the long-running processes started by this run, they're killed using 'kill-run' if 'cbtools-autobench'
+// crashes.
+func (r RunID) ProcessDirectory(parent string) string {
+	return RemoteJoin(r.TempDirectory(parent), "pids")
+}
+
+// PIDFile returns a unique path to the PID file (in the process directory within the given parent) of a process started
+// using the given command.
+func (r RunID) PIDFile(parent string, command Command) string {
+	name := strings.Trim(unsafeName.ReplaceAllString(command.Name(), "-"), "-")
+
+	return RemoteJoin(r.ProcessDirectory(parent), fmt.Sprintf("%s-%d.pid", name, time.Now().UnixNano()))
+}
+
+// WithTracking returns the given command prefixed so that the PID of the shell running it is written to the given PID
+// file for as long as it's running.
+func WithTracking(command Command, pidFile string) Command {
+	return NewCommand("trap 'rm -f %[1]s' EXIT && mkdir -p %[2]s && echo $$ > %[1]s; %[3]s",
+		pidFile, RemoteDirectory(pidFile), command)
+}
+
+// CommandKillProcesses returns a command which kills each process (and all of its descendants) with a PID file in the
+// given directory, then removes the directory. The number of processes which were killed is output.
+func CommandKillProcesses(directory string) Command {
+	return NewCommand(`test -d %[1]s || { echo 0; exit 0; };
+		tree() { for child in $(pgrep -P $1); do tree $child; done; echo $1; };
+		killed=0; for file in %[1]s/*.pid; do \
+			[ -e "$file" ] || continue; pid=$(cat "$file"); \
+			kill -0 $pid 2>/dev/null && { kill -TERM $(tree $pid) 2>/dev/null; killed=$((killed + 1)); }; \
+		done;
+		sleep 1; for file in %[1]s/*.pid; do [ -e "$file" ] && kill -KILL $(tree $(cat "$file")) 2>/dev/null; done;
+		rm -rf %[1]s; echo $killed`, directory)
+}
+
+// CommandKillProcess returns a command which kills the process (and all of its descendants) with the given PID file,
+// it's used to clean up a tracked process whose command was cancelled.
+func CommandKillProcess(pidFile string) Command {
+	return NewCommand(`test -e %[1]s || exit 0;
+		tree() { for child in $(pgrep -P $1); do tree $child; done; echo $1; };
+		pid=$(cat %[1]s); kill -TERM $(tree $pid) 2>/dev/null;
+		sleep 1; kill -0 $pid 2>/dev/null && kill -KILL $(tree $pid) 2>/dev/null; rm -f %[1]s`, pidFile)
+}
+
+// TrackedProcess is a long-running process started on a remote machine, it's recorded in the run directory whilst it's
+// running so that an operator can see what was running when 'cbtools-autobench' crashed.
+type TrackedProcess struct {
+	Host    string    `json:"host"`
+	Name    string    `json:"name"`
+	PIDFile string    `json:"pid_file"`
+	Started time.Time `json:"started"`
+}
+
+// TrackedProcesses is a wrapper around a slice of tracked processes which provides a human readable representation.
+type TrackedProcesses []*TrackedProcess
+
+// Hosts returns the distinct hosts which the processes were running on.
+func (t TrackedProcesses) Hosts() []string {
+	var (
+		hosts = make([]string, 0, len(t))
+		seen  = make(map[string]struct{})
+	)
+
+	for _, process := range t {
+		if _, ok := seen[process.Host]; ok {
+			continue
+		}
+
+		seen[process.Host] = struct{}{}
+		hosts = append(hosts, process.Host)
+	}
+
+	return hosts
+}
+
+// String returns a human readable string representation of the tracked processes.
+func (t TrackedProcesses) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Running Processes\n| -----------------")
+	fmt.Fprintf(writer, "| Host\t Name\t Started\t\n")
+
+	for _, process := range t {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", process.Host, process.Name, process.Started.Format("2006-01-02 15:04:05"))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// ProfileStep is the aggregated timing information for a single type of remote operation e.g. 'yum install'.
+type ProfileStep struct {
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+}
+
+// HostProfile records how long each of the remote operations run against a single host took, this allows slow
+// infrastructure steps to be identified.
+type HostProfile struct {
+	Host string
+
+	mu    sync.Mutex
+	steps []*ProfileStep
+	index map[string]*ProfileStep
+}
+
+// NewHostProfile creates a new empty profile for the given host.
+func NewHostProfile(host string) *HostProfile {
+	return &HostProfile{Host: host, index: make(map[string]*ProfileStep)}
+}
+
+// Record adds the given duration to the step with the provided name, steps are reported in the order they were first
+// seen.
+func (h *HostProfile) Record(name string, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	step, ok := h.index[name]
+	if !ok {
+		step = &ProfileStep{Name: name}
+		h.index[name] = step
+		h.steps = append(h.steps, step)
+	}
+
+	step.Count++
+	step.Duration += duration
+}
+
+// Steps returns a copy of the steps recorded so far.
+func (h *HostProfile) Steps() []ProfileStep {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	steps := make([]ProfileStep, 0, len(h.steps))
+	for _, step := range h.steps {
+		steps = append(steps, *step)
+	}
+
+	return steps
+}
+
+// MarshalJSON returns a JSON representation of the host profile which will be displayed in the report.
+func (h *HostProfile) MarshalJSON() ([]byte, error) {
+	type step struct {
+		Name     string `json:"name"`
+		Count    int    `json:"count"`
+		Duration string `json:"duration"`
+	}
+
+	steps := make([]step, 0)
+	for _, s := range h.Steps() {
+		steps = append(steps, step{Name: s.Name, Count: s.Count, Duration: format.Duration(s.Duration)})
+	}
+
+	return json.Marshal(struct {
+		Host  string `json:"host"`
+		Steps []step `json:"steps"`
+	}{
+		Host:  h.Host,
+		Steps: steps,
+	})
+}
+
+// Profiles is a wrapper around a slice of host profiles which provides some utility functions.
+type Profiles []*HostProfile
+
+// String returns a human readable string representation of the profiles which will be displayed in the report.
+func (p Profiles) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Profile\n| -------")
+	fmt.Fprintf(writer, "| Host\t Step\t Count\t Duration\t\n")
+
+	for _, host := range p {
+		for _, step := range host.Steps() {
+			fmt.Fprintf(writer, "| %s\t %s\t %d\t %s\t\n", host.Host, step.Name, step.Count,
+				format.Duration(step.Duration))
+		}
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// QueryPort is the port used by the query service.
+const QueryPort = 8093
+
+// QueryBlueprint describes a N1QL query which is run before the backup is created and after each restore, the number
+// of results must match for the restore to be considered valid.
+type QueryBlueprint struct {
+	Name      string `json:"name" yaml:"name"`
+	Statement string `json:"statement" yaml:"statement"`
+}
+
+// Validate returns an error if the query blueprint is invalid.
+func (q *QueryBlueprint) Validate() error {
+	if q.Name == "" {
+		return errors.New("queries must have a name")
+	}
+
+	if q.Statement == "" {
+		return fmt.Errorf("query '%s' must have a statement", q.Name)
+	}
+
+	return nil
+}
+
+// CommandQuery returns a command which runs the given N1QL statement using the query service at the given address.
+func CommandQuery(host string, credentials *Credentials, statement string) Command {
+	return NewCommand(`curl -s -u %s http://%s/query/service --data-urlencode statement=%s`, credentials.UserInfo(),
+		host, ShellQuote(statement))
+}
+
+// ParseQueryResultCount parses the response from the query service, returning the number of results.
+func ParseQueryResultCount(output []byte) (uint64, error) {
+	type overlay struct {
+		Status  string `json:"status"`
+		Metrics struct {
+			ResultCount uint64 `json:"resultCount"`
+		} `json:"metrics"`
+		Errors []struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		} `json:"errors"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	if decoded.Status == "success" {
+		return decoded.Metrics.ResultCount, nil
+	}
+
+	msgs := make([]string, 0, len(decoded.Errors))
+
+	for _, e := range decoded.Errors {
+		msgs = append(msgs, fmt.Sprintf("%d: %s", e.Code, e.Msg))
+	}
+
+	return 0, fmt.Errorf("query finished with status '%s': %s", decoded.Status, strings.Join(msgs, "; "))
+}
+
+// QueryCheck is the result of comparing the number of results returned by a query after a restore, against the number
+// returned before the backup was created.
+type QueryCheck struct {
+	Name     string `json:"name"`
+	Expected uint64 `json:"expected"`
+	Actual   uint64 `json:"actual"`
+}
+
+// Passed returns a boolean indicating whether the query returned the expected number of results.
+func (q *QueryCheck) Passed() bool {
+	return q.Expected == q.Actual
+}
+
+// QueryChecks is a wrapper around a slice of query checks which provides some utility functions.
+type QueryChecks []*QueryCheck
+
+// Failed returns the checks which didn't return the expected number of results.
+func (q QueryChecks) Failed() QueryChecks {
+	failed := make(QueryChecks, 0)
+
+	for _, check := range q {
+		if !check.Passed() {
+			failed = append(failed, check)
+		}
+	}
+
+	return failed
+}
+
+// QueryValidation is the query checks run after a single restore iteration.
+type QueryValidation struct {
+	Iteration int         `json:"iteration"`
+	Checks    QueryChecks `json:"checks"`
+}
+
+// QueryValidations is a wrapper around the query checks run after each restore benchmark.
+type QueryValidations []*QueryValidation
+
+// QueryValidations returns the query checks run after each of the results, nil is returned if none were run.
+func (b BenchmarkResults) QueryValidations() QueryValidations {
+	var validations QueryValidations
+
+	for idx, result := range b {
+		if len(result.Queries) != 0 {
+			validations = append(validations, &QueryValidation{Iteration: idx + 1, Checks: result.Queries})
+		}
+	}
+
+	return validations
+}
+
+// String returns a human readable string representation of the query validations which will be displayed in the
+// report.
+func (q QueryValidations) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Query Validation\n| ----------------")
+	fmt.Fprintf(writer, "| Iteration\t Query\t Expected\t Actual\t Passed\t\n")
+
+	for _, validation := range q {
+		for _, check := range validation.Checks {
+			fmt.Fprintf(writer, "| %d\t %s\t %d\t %d\t %t\t\n", validation.Iteration, check.Name, check.Expected,
+				check.Actual, check.Passed())
+		}
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultReadinessTimeout is the default number of seconds to wait for Couchbase Server to become ready.
+	DefaultReadinessTimeout = 300
+
+	// DefaultReadinessBackoff is the default number of seconds to wait before the first retry.
+	DefaultReadinessBackoff = 1
+
+	// DefaultReadinessMaxBackoff is the default maximum number of seconds to wait between retries.
+	DefaultReadinessMaxBackoff = 15
+)
+
+// ReadinessConfig controls how we wait for Couchbase Server to become ready after it has been installed/started; the
+// REST API and the data service port are polled with an exponential backoff until they respond.
+type ReadinessConfig struct {
+	// Timeout is the number of seconds to wait before giving up.
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// Backoff is the number of seconds to wait before the first retry, this is doubled after each failed attempt.
+	Backoff int `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+
+	// MaxBackoff is the maximum number of seconds to wait between retries.
+	MaxBackoff int `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+}
+
+// TimeoutOrDefault returns how long to wait for Couchbase Server to become ready.
+func (r *ReadinessConfig) TimeoutOrDefault() time.Duration {
+	if r == nil || r.Timeout == 0 {
+		return DefaultReadinessTimeout * time.Second
+	}
+
+	return time.Duration(r.Timeout) * time.Second
+}
+
+// BackoffOrDefault returns how long to wait before the first retry.
+func (r *ReadinessConfig) BackoffOrDefault() time.Duration {
+	if r == nil || r.Backoff == 0 {
+		return DefaultReadinessBackoff * time.Second
+	}
+
+	return time.Duration(r.Backoff) * time.Second
+}
+
+// MaxBackoffOrDefault returns the maximum time to wait between retries.
+func (r *ReadinessConfig) MaxBackoffOrDefault() time.Duration {
+	if r == nil || r.MaxBackoff == 0 {
+		return DefaultReadinessMaxBackoff * time.Second
+	}
+
+	return time.Duration(r.MaxBackoff) * time.Second
+}
+
+// Validate returns an error if the readiness config is invalid.
+func (r *ReadinessConfig) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Timeout < 0 || r.Backoff < 0 || r.MaxBackoff < 0 {
+		return fmt.Errorf("readiness timeout/backoff must not be negative")
+	}
+
+	if r.BackoffOrDefault() > r.MaxBackoffOrDefault() {
+		return fmt.Errorf("readiness backoff must not exceed the max backoff")
+	}
+
+	return nil
+}
+
+// Next returns the duration to wait after the given backoff, doubling it up to the max backoff.
+func (r *ReadinessConfig) Next(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > r.MaxBackoffOrDefault() {
+		return r.MaxBackoffOrDefault()
+	}
+
+	return backoff
+}
+
+// CommandReady returns a command which succeeds once the REST API responds on the given port and the data service is
+// accepting connections on the given port.
+func CommandReady(restPort, kvPort uint16) Command {
+	return NewCommand(
+		`curl -sf -o /dev/null -m 5 http://localhost:%d/pools && timeout 5 bash -c '</dev/tcp/localhost/%d' 2>/dev/null`,
+		restPort, kvPort,
+	)
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SearchPort is the port used by the search (FTS) service.
+	SearchPort = 8094
+
+	// EventingPort is the port used by the eventing service.
+	EventingPort = 8096
+
+	// EventingStatusDeployed is the composite status of an eventing function which is deployed and processing
+	// mutations.
+	EventingStatusDeployed = "deployed"
+)
+
+// RecoverableServices are the FTS indexes/eventing functions which existed before the backup was created, the restore
+// benchmark waits for them to become operational after each restore.
+type RecoverableServices struct {
+	SearchIndexes     []string
+	EventingFunctions []string
+}
+
+// ParseSearchIndexes parses the response from the '/api/index' search REST endpoint, returning the names of the FTS
+// indexes on the benchmarking bucket.
+func ParseSearchIndexes(output []byte) ([]string, error) {
+	type overlay struct {
+		IndexDefs *struct {
+			IndexDefs map[string]struct {
+				SourceName string `json:"sourceName"`
+			} `json:"indexDefs"`
+		} `json:"indexDefs"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	names := make([]string, 0)
+
+	if decoded.IndexDefs == nil {
+		return names, nil
+	}
+
+	for name, def := range decoded.IndexDefs.IndexDefs {
+		if def.SourceName == "default" {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// UnreadySearchIndexes parses the response from the '/api/nsstats' search REST endpoint, returning the names of the
+// given FTS indexes which don't exist yet or still have mutations to index.
+func UnreadySearchIndexes(indexes []string, output []byte) ([]string, error) {
+	var stats map[string]any
+
+	err := json.Unmarshal(output, &stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	unready := make([]string, 0)
+
+	for _, index := range indexes {
+		pending, ok := stats[fmt.Sprintf("default:%s:num_mutations_to_index", index)].(float64)
+		if !ok || pending != 0 {
+			unready = append(unready, index)
+		}
+	}
+
+	return unready, nil
+}
+
+// ParseEventingStatus parses the response from the '/api/v1/status' eventing REST endpoint, returning the composite
+// status of each eventing function by name.
+func ParseEventingStatus(output []byte) (map[string]string, error) {
+	type overlay struct {
+		Apps []struct {
+			Name            string `json:"name"`
+			CompositeStatus string `json:"composite_status"`
+		} `json:"apps"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	statuses := make(map[string]string, len(decoded.Apps))
+
+	for _, app := range decoded.Apps {
+		statuses[app.Name] = app.CompositeStatus
+	}
+
+	return statuses, nil
+}
+
+// DeployedEventingFunctions returns the names of the eventing functions which are deployed according to the given
+// statuses.
+func DeployedEventingFunctions(statuses map[string]string) []string {
+	deployed := make([]string, 0)
+
+	for name, status := range statuses {
+		if status == EventingStatusDeployed {
+			deployed = append(deployed, name)
+		}
+	}
+
+	return deployed
+}
+
+// UndeployedEventingFunctions returns the names of the given eventing functions which aren't deployed (or don't exist)
+// according to the given statuses.
+func UndeployedEventingFunctions(functions []string, statuses map[string]string) []string {
+	undeployed := make([]string, 0)
+
+	for _, function := range functions {
+		if statuses[function] != EventingStatusDeployed {
+			undeployed = append(undeployed, function)
+		}
+	}
+
+	return undeployed
+}
+
+// ServiceRecovery is a wrapper around the results of restore benchmarks which waited for the GSI indexes, FTS indexes
+// and/or eventing functions to become operational.
+type ServiceRecovery BenchmarkResults
+
+// ServiceRecovery returns the results which waited for services to become operational, nil is returned if none did.
+func (b BenchmarkResults) ServiceRecovery() ServiceRecovery {
+	for _, result := range b {
+		if result.IndexBuild != 0 || result.SearchBuild != 0 || result.EventingDeploy != 0 {
+			return ServiceRecovery(b)
+		}
+	}
+
+	return nil
+}
+
+// String returns a human readable string representation of the service recovery which will be displayed in the
+// report.
+func (s ServiceRecovery) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	duration := func(d time.Duration) string {
+		if d == 0 {
+			return "-"
+		}
+
+		return format.Duration(d)
+	}
+
+	fmt.Fprintln(buffer, "| Service Recovery\n| ----------------")
+	fmt.Fprintf(writer, "| Iteration\t Restore\t GSI Indexes\t FTS Indexes\t Eventing\t Time To Service\t\n")
+
+	for idx, result := range s {
+		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t %s\t %s\t\n", idx+1, format.Duration(result.Duration),
+			duration(result.IndexBuild), duration(result.SearchBuild), duration(result.EventingDeploy),
+			format.Duration(result.Duration+result.Recovery()))
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprintln(buffer, "\nNOTE: The index definitions/eventing functions are restored by 'cbbackupmgr' so are "+
+		"included in the restore, each service is timed from when the restore completed until it was operational")
+
+	return strings.TrimSpace(buffer.String())
+}
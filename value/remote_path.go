@@ -0,0 +1,44 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"path"
+	"strings"
+)
+
+// NOTE: The remote machines are always Linux, so paths on them must always be built using forward slashes regardless
+// of the platform the operator is running 'cbtools-autobench' on; this is why we use 'path' and not 'path/filepath'.
+
+// RemoteJoin joins the provided elements into a single path which is valid on the remote machine.
+func RemoteJoin(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// RemoteBase returns the last element of the given path on the remote machine.
+func RemoteBase(p string) string {
+	return path.Base(p)
+}
+
+// RemoteDirectory returns all but the last element of the given path on the remote machine.
+func RemoteDirectory(p string) string {
+	return path.Dir(p)
+}
+
+// LocalBase returns the last element of the given local path, unlike 'filepath.Base' both forward and back slashes are
+// treated as separators so that paths written in the config file are handled the same way on all platforms.
+func LocalBase(p string) string {
+	return path.Base(strings.ReplaceAll(p, `\`, "/"))
+}
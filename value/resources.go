@@ -0,0 +1,141 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResourceSample is the number of open file descriptors/threads of the benchmarked tool at a point in time.
+type ResourceSample struct {
+	FDs     uint64
+	Threads uint64
+}
+
+// CommandToolResources returns a command which outputs the total number of open file descriptors and threads of all
+// the running 'cbbackupmgr' processes, zeros are output if there aren't any.
+func CommandToolResources() Command {
+	return NewCommand(`for pid in $(pgrep -x cbbackupmgr); do echo "FDs: $(ls /proc/$pid/fd | wc -l)"; \
+		cat /proc/$pid/status; done 2>/dev/null | \
+		awk '/^FDs:/ { fds += $2 } /^Threads:/ { threads += $2 } END { printf "%%.0f %%.0f\n", fds, threads }'`)
+}
+
+// ParseToolResources parses the output of 'CommandToolResources', no output is treated as the tool not running.
+func ParseToolResources(output []byte) (ResourceSample, error) {
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return ResourceSample{}, nil
+	}
+
+	if len(fields) != 2 {
+		return ResourceSample{}, fmt.Errorf("expected two values but got %d", len(fields))
+	}
+
+	fds, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return ResourceSample{}, fmt.Errorf("invalid file descriptor count '%s'", fields[0])
+	}
+
+	threads, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return ResourceSample{}, fmt.Errorf("invalid thread count '%s'", fields[1])
+	}
+
+	return ResourceSample{FDs: fds, Threads: threads}, nil
+}
+
+// ResourceUsage is the peak/average number of open file descriptors/threads of the benchmarked tool during a
+// backup/restore.
+type ResourceUsage struct {
+	PeakFDs     uint64 `json:"peak_fds"`
+	AvgFDs      uint64 `json:"avg_fds"`
+	PeakThreads uint64 `json:"peak_threads"`
+	AvgThreads  uint64 `json:"avg_threads"`
+
+	// Samples is the number of samples taken whilst the tool was running.
+	Samples int `json:"samples"`
+}
+
+// NewResourceUsage calculates the peak/average resource usage from the given samples, samples taken whilst the tool
+// wasn't running are ignored. Nil is returned if the tool wasn't running when any of the samples were taken.
+func NewResourceUsage(samples []ResourceSample) *ResourceUsage {
+	var (
+		usage        = &ResourceUsage{}
+		fds, threads uint64
+	)
+
+	for _, sample := range samples {
+		if sample.FDs == 0 && sample.Threads == 0 {
+			continue
+		}
+
+		usage.Samples++
+		fds += sample.FDs
+		threads += sample.Threads
+
+		if sample.FDs > usage.PeakFDs {
+			usage.PeakFDs = sample.FDs
+		}
+
+		if sample.Threads > usage.PeakThreads {
+			usage.PeakThreads = sample.Threads
+		}
+	}
+
+	if usage.Samples == 0 {
+		return nil
+	}
+
+	usage.AvgFDs, usage.AvgThreads = fds/uint64(usage.Samples), threads/uint64(usage.Samples)
+
+	return usage
+}
+
+// Resources returns the peak/average resource usage across all the results, the averages are weighted by the number
+// of samples taken during each result. Nil is returned if resource usage wasn't captured for any of the results.
+func (b BenchmarkResults) Resources() *ResourceUsage {
+	var (
+		usage        = &ResourceUsage{}
+		fds, threads uint64
+	)
+
+	for _, result := range b {
+		if result.Resources == nil {
+			continue
+		}
+
+		usage.Samples += result.Resources.Samples
+		fds += result.Resources.AvgFDs * uint64(result.Resources.Samples)
+		threads += result.Resources.AvgThreads * uint64(result.Resources.Samples)
+
+		if result.Resources.PeakFDs > usage.PeakFDs {
+			usage.PeakFDs = result.Resources.PeakFDs
+		}
+
+		if result.Resources.PeakThreads > usage.PeakThreads {
+			usage.PeakThreads = result.Resources.PeakThreads
+		}
+	}
+
+	if usage.Samples == 0 {
+		return nil
+	}
+
+	usage.AvgFDs, usage.AvgThreads = fds/uint64(usage.Samples), threads/uint64(usage.Samples)
+
+	return usage
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionConfig is the retention policy applied by 'gc' to the local run directories (and optionally the benchmark
+// repositories in the archive), preventing disks from filling up over time.
+type RetentionConfig struct {
+	// KeepRuns is the number of most recent run directories which are kept, by default all of them are kept.
+	KeepRuns int `yaml:"keep_runs,omitempty"`
+
+	// MaxSizeGiB is the maximum total size of the run directories which are kept, the oldest runs are removed first.
+	MaxSizeGiB int `yaml:"max_size_gib,omitempty"`
+
+	// Archives enables removing the benchmark repositories created by the pruned runs from the archive.
+	Archives bool `yaml:"archives,omitempty"`
+}
+
+// Enabled returns a boolean indicating whether any run directories may be pruned by the policy.
+func (r *RetentionConfig) Enabled() bool {
+	return r != nil && (r.KeepRuns > 0 || r.MaxSizeGiB > 0)
+}
+
+// RunDirectory describes a local run directory.
+type RunDirectory struct {
+	RunID    RunID
+	Modified time.Time
+	Size     uint64
+}
+
+// Prune returns the given run directories which should be removed to satisfy the policy, the most recently modified
+// directories are kept.
+func (r *RetentionConfig) Prune(runs []*RunDirectory) []*RunDirectory {
+	if !r.Enabled() {
+		return nil
+	}
+
+	sorted := append([]*RunDirectory{}, runs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Modified.After(sorted[j].Modified) })
+
+	var (
+		pruned = make([]*RunDirectory, 0)
+		limit  = uint64(r.MaxSizeGiB) * 1024 * 1024 * 1024
+		total  uint64
+	)
+
+	for idx, run := range sorted {
+		total += run.Size
+
+		if (r.KeepRuns > 0 && idx >= r.KeepRuns) || (r.MaxSizeGiB > 0 && total > limit) {
+			pruned = append(pruned, run)
+		}
+	}
+
+	return pruned
+}
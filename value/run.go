@@ -0,0 +1,87 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RunsDirectory is the local directory which will contain a directory for each run, used to store artifacts
+	// such as logs.
+	RunsDirectory = "autobench-runs"
+
+	// TempDirectoryParent is the directory on the remote machines which will contain the per-run temporary
+	// directories, unless overridden using 'remote_tmp_dir'.
+	TempDirectoryParent = "/tmp"
+
+	// TempDirectoryPrefix is the prefix for each of the per-run temporary directories.
+	TempDirectoryPrefix = "autobench-"
+)
+
+// RunID is a unique identifier generated for each invocation of 'cbtools-autobench', it's included in logs, remote
+// paths, repository names and reports so that concurrent runs against shared infrastructure never collide and may be
+// attributed to a specific run.
+type RunID string
+
+// NewRunID generates a new random (version 4) UUID which may be used to identify a run.
+func NewRunID() (RunID, error) {
+	var uuid [16]byte
+
+	_, err := rand.Read(uuid[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return RunID(fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])), nil
+}
+
+// Namespace returns the given name suffixed with the run id, for example the repository 'repo' would become
+// 'repo-<run id>'.
+func (r RunID) Namespace(name string) string {
+	if r == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s-%s", name, r)
+}
+
+// LocalDirectory returns the local directory in which artifacts for this run should be stored.
+func (r RunID) LocalDirectory() string {
+	return filepath.Join(RunsDirectory, string(r))
+}
+
+// TempDirectory returns the directory (within the given parent) on the remote machines which should contain all the
+// uploads/temporary files created during this run, it's removed once the run is complete.
+func (r RunID) TempDirectory(parent string) string {
+	return RemoteJoin(parent, TempDirectoryPrefix+string(r))
+}
+
+// CommandGarbageCollect returns a command which will remove any per-run temporary directories (and the backup client
+// lock) in the given parent which haven't been modified within the given duration, for example those left behind by
+// runs which crashed.
+func CommandGarbageCollect(parent string, olderThan time.Duration) Command {
+	return NewCommand(`find %s -mindepth 1 -maxdepth 1 -type d -name '%s*' -mmin +%[3]d -exec rm -rf {} + && \
+		(test ! -d %[4]s || find %[4]s -maxdepth 0 -mmin +%[3]d -exec rm -rf {} +)`,
+		parent, TempDirectoryPrefix, int(olderThan.Minutes()), ClientLockDirectory)
+}
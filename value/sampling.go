@@ -0,0 +1,101 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// DefaultSamplingInterval is the default number of seconds between throughput samples.
+const DefaultSamplingInterval = 5
+
+// SamplingConfig enables sampling the progress of each backup/restore at a fixed interval, this allows plotting
+// throughput over time without relying on the progress output of 'cbbackupmgr'.
+type SamplingConfig struct {
+	// Interval is the number of seconds between each sample.
+	Interval int `json:"interval,omitempty" yaml:"interval,omitempty"`
+}
+
+// IntervalOrDefault returns the duration between each sample.
+func (s *SamplingConfig) IntervalOrDefault() time.Duration {
+	if s == nil || s.Interval == 0 {
+		return DefaultSamplingInterval * time.Second
+	}
+
+	return time.Duration(s.Interval) * time.Second
+}
+
+// SampleUnit is the unit of the values that have been sampled.
+type SampleUnit string
+
+const (
+	// SampleUnitBytes indicates the samples are the size of the archive; used when benchmarking backups.
+	SampleUnitBytes SampleUnit = "bytes"
+
+	// SampleUnitItems indicates the samples are the number of items in the bucket; used when benchmarking restores.
+	SampleUnitItems SampleUnit = "items"
+)
+
+// Format returns a human readable representation of the given value in this unit.
+func (s SampleUnit) Format(value uint64) string {
+	if s == SampleUnitBytes {
+		return format.Bytes(value)
+	}
+
+	return message.NewPrinter(language.English).Sprintf("%d items", value)
+}
+
+// Sample is a single measurement of the progress of a backup/restore.
+type Sample struct {
+	// Elapsed is how long after the start of the backup/restore the sample was taken.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Value is the total amount of progress made at the time of the sample.
+	Value uint64 `json:"value"`
+}
+
+// Throughput is the progress of a backup/restore sampled at a fixed interval.
+type Throughput struct {
+	Unit    SampleUnit `json:"unit"`
+	Samples []Sample   `json:"samples"`
+}
+
+// Rate returns the throughput (per second) between the sample at the given index and the previous sample.
+func (t *Throughput) Rate(index int) uint64 {
+	var previous Sample
+	if index > 0 {
+		previous = t.Samples[index-1]
+	}
+
+	current := t.Samples[index]
+
+	// The size of the archive may shrink e.g. when temporary files are removed, treat this as no progress
+	if current.Value < previous.Value || current.Elapsed <= previous.Elapsed {
+		return 0
+	}
+
+	return uint64(float64(current.Value-previous.Value) / (current.Elapsed - previous.Elapsed).Seconds())
+}
+
+// FormatRate returns a human readable representation of the throughput at the given index.
+func (t *Throughput) FormatRate(index int) string {
+	return fmt.Sprintf("%s/s", t.Unit.Format(t.Rate(index)))
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"strings"
+)
+
+var (
+	// selinuxState is the file containing the SELinux mode from before it was set to permissive.
+	selinuxState = RemoteJoin(StateDirectory, "selinux")
+
+	// appArmorState is the file containing the AppArmor profiles which were put into complain mode.
+	appArmorState = RemoteJoin(StateDirectory, "apparmor")
+)
+
+// CommandLabelPath returns a command which recursively applies the SELinux context of the reference path to the given
+// path, allowing Couchbase Server to access a custom data/index path on hosts where SELinux is enforcing.
+//
+// NOTE: The context isn't persisted in the policy, it'll be reset if the file system is relabelled.
+func CommandLabelPath(path, reference string) Command {
+	return NewCommand("chcon -R --reference=%s %s", reference, path)
+}
+
+// CommandPermissive returns a command which sets SELinux to permissive mode and puts any AppArmor profiles mentioning
+// Couchbase Server into complain mode, as detected in the given capabilities. The original modes are recorded in the
+// state directory, so that they're reverted by 'CommandRestoreState'.
+func (c *Capabilities) CommandPermissive() Command {
+	commands := make([]string, 0, 2)
+
+	if c.SELinux == SELinuxEnforcing {
+		commands = append(commands, "(test -e "+selinuxState+" || getenforce > "+selinuxState+")", "setenforce 0")
+	}
+
+	if c.AppArmor {
+		commands = append(commands, "for profile in $(grep -ls couchbase /etc/apparmor.d/* 2> /dev/null); do "+
+			"aa-complain $profile && echo $profile >> "+appArmorState+"; done")
+	}
+
+	if len(commands) == 0 {
+		return NewCommand("true")
+	}
+
+	return NewCommand("sh -c '%s'", strings.Join(commands, "; "))
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// logComponent matches the names of the ns_server logging components e.g. 'ns_server' or 'xdcr', they're interpolated
+// into the Erlang run using '/diag/eval'.
+var logComponent = regexp.MustCompile(`^[a-z_]+$`)
+
+// ServerSettingsBlueprint describes server-side settings which are applied before each benchmark, allowing controlled
+// experiments on the server knobs relevant to backup/restore without re-provisioning the cluster.
+//
+// NOTE: The settings aren't reverted after the benchmark, the values captured before the settings were applied are
+// included in the report.
+type ServerSettingsBlueprint struct {
+	// CompressionMode is the compression mode of the benchmarking bucket i.e. off/passive/active.
+	CompressionMode string `json:"compression_mode,omitempty" yaml:"compression_mode,omitempty"`
+
+	// Memcached are the global memcached settings, posted as is to '/pools/default/settings/memcached/global' e.g. the
+	// number of reader/writer threads or the connection limits.
+	Memcached map[string]string `json:"memcached,omitempty" yaml:"memcached,omitempty"`
+
+	// LogLevels are the log levels of the ns_server logging components e.g. 'ns_server: debug'.
+	LogLevels map[string]string `json:"log_levels,omitempty" yaml:"log_levels,omitempty"`
+}
+
+// Validate returns an error if the server settings blueprint is invalid.
+func (s *ServerSettingsBlueprint) Validate() error {
+	switch s.CompressionMode {
+	case "", "off", "passive", "active":
+	default:
+		return fmt.Errorf("unsupported compression mode '%s'", s.CompressionMode)
+	}
+
+	for name := range s.Memcached {
+		if name == "" {
+			return errors.New("memcached settings must have a name")
+		}
+	}
+
+	for component, level := range s.LogLevels {
+		if !logComponent.MatchString(component) {
+			return fmt.Errorf("invalid log component '%s'", component)
+		}
+
+		switch level {
+		case "debug", "info", "warn", "error", "critical":
+		default:
+			return fmt.Errorf("unsupported log level '%s' for component '%s'", level, component)
+		}
+	}
+
+	return nil
+}
+
+// MemcachedNames returns the names of the memcached settings in a stable order.
+func (s *ServerSettingsBlueprint) MemcachedNames() []string {
+	return sortedKeys(s.Memcached)
+}
+
+// LogComponents returns the names of the logging components in a stable order.
+func (s *ServerSettingsBlueprint) LogComponents() []string {
+	return sortedKeys(s.LogLevels)
+}
+
+// CommandGetMemcachedSettings returns a command which outputs the global memcached settings of the cluster at the given
+// address.
+func CommandGetMemcachedSettings(host string, credentials *Credentials) Command {
+	return NewCommand(`curl -s -g -u %s http://%s/pools/default/settings/memcached/global`, credentials.UserInfo(),
+		host)
+}
+
+// CommandSetMemcachedSettings returns a command which applies the memcached settings to the cluster at the given
+// address.
+func (s *ServerSettingsBlueprint) CommandSetMemcachedSettings(host string, credentials *Credentials) Command {
+	args := make([]string, 0, len(s.Memcached))
+
+	for _, name := range s.MemcachedNames() {
+		args = append(args, "-d "+ShellQuote(name+"="+s.Memcached[name]))
+	}
+
+	return NewCommand(`curl -s -f -g -X POST -u %s http://%s/pools/default/settings/memcached/global %s`,
+		credentials.UserInfo(), host, strings.Join(args, " "))
+}
+
+// CommandGetLogLevel returns a command which outputs the log level of the given ns_server logging component.
+func CommandGetLogLevel(host string, credentials *Credentials, component string) Command {
+	return NewCommand(`curl -s -g -X POST -u %s http://%s/diag/eval -d 'ale:get_loglevel(%s).'`, credentials.UserInfo(),
+		host, component)
+}
+
+// CommandSetLogLevel returns a command which sets the log level of the given ns_server logging component.
+func CommandSetLogLevel(host string, credentials *Credentials, component, level string) Command {
+	return NewCommand(`curl -s -f -g -X POST -u %s http://%s/diag/eval -d 'ale:set_loglevel(%s, %s).'`,
+		credentials.UserInfo(), host, component, level)
+}
+
+// ParseMemcachedSettings parses the response from the '/pools/default/settings/memcached/global' REST endpoint,
+// returning each setting formatted as a string.
+func ParseMemcachedSettings(output []byte) (map[string]string, error) {
+	var decoded map[string]json.RawMessage
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	settings := make(map[string]string, len(decoded))
+
+	for name, raw := range decoded {
+		settings[name] = strings.Trim(string(raw), `"`)
+	}
+
+	return settings, nil
+}
+
+// ParseCompressionMode parses the response from the '/pools/default/buckets/default' REST endpoint, returning the
+// compression mode of the bucket.
+func ParseCompressionMode(output []byte) (string, error) {
+	var decoded struct {
+		CompressionMode string `json:"compressionMode"`
+	}
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	return decoded.CompressionMode, nil
+}
+
+// ServerSetting is a server-side setting applied before the benchmark, alongside its value before/after it was applied.
+type ServerSetting struct {
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ServerSettings is a wrapper around a slice of server settings which provides a human readable representation.
+type ServerSettings []*ServerSetting
+
+// String returns a human readable string representation of the server settings which will be displayed in the report.
+func (s ServerSettings) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	stringify := func(v string) string {
+		if v == "" {
+			return "unknown"
+		}
+
+		return v
+	}
+
+	fmt.Fprintln(buffer, "| Server Settings\n| ---------------")
+	fmt.Fprintf(writer, "| Setting\t Before\t After\t\n")
+
+	for _, setting := range s {
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t\n", setting.Name, stringify(setting.Before), stringify(setting.After))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// sortedKeys returns the keys of the given map sorted alphabetically.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
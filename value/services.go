@@ -0,0 +1,163 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Service is a service which may be run by a node in the cluster.
+type Service string
+
+// The services which may be run by a node, the names match those accepted by 'couchbase-cli'.
+const (
+	ServiceData      Service = "data"
+	ServiceIndex     Service = "index"
+	ServiceQuery     Service = "query"
+	ServiceSearch    Service = "fts"
+	ServiceEventing  Service = "eventing"
+	ServiceAnalytics Service = "analytics"
+	ServiceBackup    Service = "backup"
+)
+
+// serviceNames are the names used for each service by the REST API, for example, by the '/clusterInit' endpoint.
+var serviceNames = map[Service]string{
+	ServiceData:      "kv",
+	ServiceIndex:     "index",
+	ServiceQuery:     "n1ql",
+	ServiceSearch:    "fts",
+	ServiceEventing:  "eventing",
+	ServiceAnalytics: "cbas",
+	ServiceBackup:    "backup",
+}
+
+// serviceAliases are the alternative names which may be used for services in the config.
+var serviceAliases = map[string]Service{
+	"kv":     ServiceData,
+	"n1ql":   ServiceQuery,
+	"search": ServiceSearch,
+	"cbas":   ServiceAnalytics,
+}
+
+// serviceQuotas are the default memory quotas (in MiB) of the services which have one, the memory is reserved on each
+// node which runs them alongside the data service.
+var serviceQuotas = map[Service]int{
+	ServiceIndex:     512,
+	ServiceSearch:    512,
+	ServiceEventing:  256,
+	ServiceAnalytics: 1024,
+}
+
+// Services are the services run by a node, they may be provided as a comma separated list e.g. 'data,index' or as a
+// YAML list.
+type Services []Service
+
+// UnmarshalYAML decodes the services from either a comma separated list or a YAML list, aliases e.g. 'kv' and 'search'
+// are replaced with the services they refer to.
+func (s *Services) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var names []string
+	if err := unmarshal(&names); err != nil {
+		var joined string
+		if err := unmarshal(&joined); err != nil {
+			return err
+		}
+
+		names = strings.Split(joined, ",")
+	}
+
+	*s = nil
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		service := Service(name)
+		if alias, ok := serviceAliases[name]; ok {
+			service = alias
+		}
+
+		*s = append(*s, service)
+	}
+
+	return nil
+}
+
+// Validate returns an error if any of the services are unknown or duplicated.
+func (s Services) Validate() error {
+	seen := make(map[Service]struct{}, len(s))
+
+	for _, service := range s {
+		if _, ok := serviceNames[service]; !ok {
+			return fmt.Errorf("unknown service '%s'", service)
+		}
+
+		if _, ok := seen[service]; ok {
+			return fmt.Errorf("service '%s' is duplicated", service)
+		}
+
+		seen[service] = struct{}{}
+	}
+
+	return nil
+}
+
+// Has returns a boolean indicating whether the given service is one of the services.
+func (s Services) Has(service Service) bool {
+	for _, other := range s {
+		if other == service {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReservedMemory returns the memory (in MiB) which must be left for the services which are run alongside the data
+// service, zero is returned if the data service isn't one of the services.
+func (s Services) ReservedMemory() int {
+	if !s.Has(ServiceData) {
+		return 0
+	}
+
+	var reserved int
+	for _, service := range s {
+		reserved += serviceQuotas[service]
+	}
+
+	return reserved
+}
+
+// String returns the services as accepted by 'couchbase-cli' e.g. 'data,index'.
+func (s Services) String() string {
+	names := make([]string, 0, len(s))
+	for _, service := range s {
+		names = append(names, string(service))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// REST returns the services as accepted by the REST API e.g. 'kv,index'.
+func (s Services) REST() string {
+	names := make([]string, 0, len(s))
+	for _, service := range s {
+		names = append(names, serviceNames[service])
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/couchbase/tools-common/utils/maths"
+
+	"github.com/pkg/errors"
+)
+
+// SettleTimeout is how long we'll wait for the cluster to settle before a benchmark, before giving up.
+const SettleTimeout = 30 * time.Minute
+
+// queueStats are the 'cbstats' which must be zero for the disk/replication queues to be considered drained.
+var queueStats = []string{"ep_queue_size", "ep_flusher_todo", "ep_dcp_replica_items_remaining"}
+
+// PersistStats are the 'cbstats' which must be zero for the disk write queue to be considered drained i.e. for all the
+// mutations to have been persisted.
+var PersistStats = []string{"ep_queue_size", "ep_flusher_todo"}
+
+// VBucketCount is the number of active/replica vBuckets on a single node.
+type VBucketCount struct {
+	Host    string `json:"host"`
+	Active  int    `json:"active"`
+	Replica int    `json:"replica"`
+}
+
+// VBucketDistribution is the number of active/replica vBuckets on each node in the cluster.
+type VBucketDistribution []*VBucketCount
+
+// ParseVBucketDistribution parses the vBucket map from the bucket details returned by the REST API.
+func ParseVBucketDistribution(output []byte) (VBucketDistribution, error) {
+	type overlay struct {
+		VBucketServerMap struct {
+			ServerList []string `json:"serverList"`
+			VBucketMap [][]int  `json:"vBucketMap"`
+		} `json:"vBucketServerMap"`
+	}
+
+	var decoded overlay
+
+	err := json.Unmarshal(output, &decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal bucket details")
+	}
+
+	distribution := make(VBucketDistribution, 0, len(decoded.VBucketServerMap.ServerList))
+
+	for _, server := range decoded.VBucketServerMap.ServerList {
+		distribution = append(distribution, &VBucketCount{Host: server})
+	}
+
+	for _, chain := range decoded.VBucketServerMap.VBucketMap {
+		for position, server := range chain {
+			if server < 0 || server >= len(distribution) {
+				continue
+			}
+
+			if position == 0 {
+				distribution[server].Active++
+			} else {
+				distribution[server].Replica++
+			}
+		}
+	}
+
+	return distribution, nil
+}
+
+// Balanced returns a boolean indicating whether the active/replica vBuckets are evenly distributed between the nodes
+// i.e. the counts on each node differ by at most one.
+func (v VBucketDistribution) Balanced() bool {
+	if len(v) == 0 {
+		return true
+	}
+
+	minActive, maxActive := v[0].Active, v[0].Active
+	minReplica, maxReplica := v[0].Replica, v[0].Replica
+
+	for _, count := range v[1:] {
+		minActive, maxActive = maths.Min(minActive, count.Active), maths.Max(maxActive, count.Active)
+		minReplica, maxReplica = maths.Min(minReplica, count.Replica), maths.Max(maxReplica, count.Replica)
+	}
+
+	return maxActive-minActive <= 1 && maxReplica-minReplica <= 1
+}
+
+// ParseQueueStats parses the output of 'cbstats all', returning the stats which must be zero for the disk/replication
+// queues to be considered drained.
+func ParseQueueStats(output []byte) (map[string]uint64, error) {
+	stats := make(map[string]uint64, len(queueStats))
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		stat := strings.TrimSuffix(fields[0], ":")
+
+		for _, name := range queueStats {
+			if stat != name {
+				continue
+			}
+
+			parsed, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse '%s'", name)
+			}
+
+			stats[name] = parsed
+		}
+	}
+
+	for _, name := range queueStats {
+		if _, ok := stats[name]; !ok {
+			return nil, fmt.Errorf("missing stat '%s'", name)
+		}
+	}
+
+	return stats, nil
+}
+
+// UndrainedQueues returns the names of the given queue stats which are non-zero, sorted by name.
+func UndrainedQueues(stats map[string]uint64) []string {
+	undrained := make([]string, 0)
+
+	for name, value := range stats {
+		if value != 0 {
+			undrained = append(undrained, fmt.Sprintf("%s=%d", name, value))
+		}
+	}
+
+	sort.Strings(undrained)
+
+	return undrained
+}
+
+// SettleResult describes how long we waited for the cluster to settle before the benchmark was timed, and the vBucket
+// distribution once it had.
+type SettleResult struct {
+	Wait         time.Duration       `json:"wait"`
+	Distribution VBucketDistribution `json:"vbucket_distribution,omitempty"`
+}
+
+// String returns a human readable string representation of the settle result which will be displayed in the report.
+func (s *SettleResult) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Settling\n| --------")
+	fmt.Fprintf(buffer, "| Waited %s for the cluster to settle (not included in the results)\n", format.Duration(s.Wait))
+
+	if len(s.Distribution) != 0 {
+		fmt.Fprintln(buffer, "|")
+		fmt.Fprintf(writer, "| Node\t Active vBuckets\t Replica vBuckets\t\n")
+
+		for _, count := range s.Distribution {
+			fmt.Fprintf(writer, "| %s\t %d\t %d\t\n", count.Host, count.Active, count.Replica)
+		}
+
+		_ = writer.Flush()
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+)
+
+const (
+	// similarityBlockSize is the size of the blocks which the generated document bodies are built from.
+	similarityBlockSize = 64
+
+	// similarityPoolSize is the number of shared blocks which similar documents are built from.
+	similarityPoolSize = 16
+)
+
+// ValidateSimilarity returns an error if the cross-document similarity is invalid, or isn't supported by the data
+// loader.
+func (d *DataBlueprint) ValidateSimilarity() error {
+	if d.Similarity < 0 || d.Similarity > 100 {
+		return fmt.Errorf("similarity must be a percentage between 0 and 100")
+	}
+
+	if d.Similarity != 0 && d.DataLoader != "" && d.DataLoader != CBM {
+		return fmt.Errorf("similarity is only supported when generating data with 'cbbackupmgr'")
+	}
+
+	return nil
+}
+
+// CommandGenerateSimilar returns a command which generates the given batch of JSON documents (a document per line)
+// into the file at the given path on the remote machine, ready to be imported using 'CommandImportSimilar'.
+//
+// Each document body is built from blocks, each block is either one of a small pool of blocks shared by every document
+// (with a probability of the similarity percentage) or random; so the similarity controls how well the documents
+// deduplicate/compress across the dataset.
+func (d *DataBlueprint) CommandGenerateSimilar(batch KeyBatch, path string) Command {
+	return NewCommand(`prefix=%[1]s; awk -v items=%[2]d -v size=%[3]d -v similarity=%[4]d -v prefix="$prefix" \
+		-v block=%[5]d -v pool=%[6]d 'BEGIN {
+			srand(); random = "tr -dc a-z0-9 </dev/urandom | fold -w " block;
+			for (i = 0; i < pool; i++) random | getline shared[i];
+			for (item = 0; item < items; item++) {
+				body = "";
+				while (length(body) < size) {
+					if (rand() * 100 < similarity) { body = body shared[int(rand() * pool)] }
+					else { random | getline unique; body = body unique }
+				}
+				printf "{\"key\":\"%%s%%d\",\"body\":\"%%s\"}\n", prefix, item, substr(body, 1, size);
+			}
+			close(random);
+		}' > %[7]s`,
+		batch.Prefix, batch.Items, d.Size, d.Similarity, similarityBlockSize, similarityPoolSize, path)
+}
+
+// CommandImportSimilar returns a command which imports the documents generated by 'CommandGenerateSimilar' at the
+// given path into the benchmarking bucket using the cluster at the given address.
+func (d *DataBlueprint) CommandImportSimilar(host string, credentials *Credentials, path string) Command {
+	dataset := &ImportBlueprint{Source: ImportSourceJSON, Format: ImportFormatLines, Key: "%key%"}
+
+	return dataset.CommandImport(host, credentials, path, d.LoadThreads)
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SnapshotFilesystem is the copy-on-write filesystem used to snapshot the data path.
+type SnapshotFilesystem string
+
+const (
+	// SnapshotFilesystemZFS snapshots the ZFS dataset containing the data path.
+	SnapshotFilesystemZFS SnapshotFilesystem = "zfs"
+
+	// SnapshotFilesystemBTRFS snapshots the data path, which must be a BTRFS subvolume.
+	SnapshotFilesystemBTRFS SnapshotFilesystem = "btrfs"
+)
+
+// snapshotName is the name given to the snapshots created by 'cbtools-autobench'.
+const snapshotName = "autobench"
+
+// SnapshotBlueprint enables resetting the bucket between iterations by rolling back a filesystem snapshot of the data
+// path, this is almost instant regardless of the size of the dataset unlike flushing/reloading the bucket.
+type SnapshotBlueprint struct {
+	// Filesystem is the filesystem the data path resides on, either 'zfs' or 'btrfs'.
+	Filesystem SnapshotFilesystem `yaml:"filesystem,omitempty"`
+
+	// Dataset is the ZFS dataset mounted at the data path e.g. 'tank/couchbase', it's unused for BTRFS.
+	Dataset string `yaml:"dataset,omitempty"`
+}
+
+// Validate returns an error if the data/index paths of a node can't be snapshotted using this blueprint.
+//
+// NOTE: Only the data path is snapshotted, so the index path must be the data path (or within it); otherwise rolling
+// back would leave the indexes out of step with the data.
+func (s *SnapshotBlueprint) Validate(dataPath, indexPath string) error {
+	if dataPath == "" {
+		return errors.New("a data path must be provided to use snapshots")
+	}
+
+	if indexPath != "" && !isWithin(indexPath, dataPath) {
+		return fmt.Errorf("the index path '%s' must be within the data path '%s' to use snapshots", indexPath, dataPath)
+	}
+
+	switch s.Filesystem {
+	case SnapshotFilesystemZFS:
+		if s.Dataset == "" {
+			return errors.New("a dataset must be provided to use ZFS snapshots")
+		}
+	case SnapshotFilesystemBTRFS:
+	default:
+		return fmt.Errorf("unsupported snapshot filesystem '%s'", s.Filesystem)
+	}
+
+	return nil
+}
+
+// ValidateSnapshot returns an error if snapshots are enabled but the data path of a node can't be snapshotted.
+func (c *ClusterBlueprint) ValidateSnapshot() error {
+	if c.Snapshot == nil {
+		return nil
+	}
+
+	for _, node := range c.Nodes {
+		err := c.Snapshot.Validate(node.DataPath, node.IndexPath)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot config for node '%s': %w", node.Host, err)
+		}
+	}
+
+	return nil
+}
+
+// CommandSnapshot returns a command which snapshots the given data path, replacing any existing snapshot.
+func (s *SnapshotBlueprint) CommandSnapshot(dataPath string) Command {
+	if s.Filesystem == SnapshotFilesystemZFS {
+		return NewCommand("zfs destroy -r %[1]s@%[2]s 2>/dev/null; zfs snapshot -r %[1]s@%[2]s", s.Dataset, snapshotName)
+	}
+
+	return NewCommand("btrfs subvolume delete %[1]s.%[2]s 2>/dev/null; btrfs subvolume snapshot %[1]s %[1]s.%[2]s",
+		dataPath, snapshotName)
+}
+
+// CommandRollback returns a command which returns the given data path to the state it was in when it was snapshotted.
+//
+// NOTE: Couchbase Server must not be running on the node.
+func (s *SnapshotBlueprint) CommandRollback(dataPath string) Command {
+	if s.Filesystem == SnapshotFilesystemZFS {
+		return NewCommand("zfs rollback -r %s@%s", s.Dataset, snapshotName)
+	}
+
+	return NewCommand("btrfs subvolume delete %[1]s && btrfs subvolume snapshot %[1]s.%[2]s %[1]s",
+		dataPath, snapshotName)
+}
+
+// CommandDestroy returns a command which removes the snapshot of the given data path.
+func (s *SnapshotBlueprint) CommandDestroy(dataPath string) Command {
+	if s.Filesystem == SnapshotFilesystemZFS {
+		return NewCommand("zfs destroy -r %s@%s", s.Dataset, snapshotName)
+	}
+
+	return NewCommand("btrfs subvolume delete %s.%s", dataPath, snapshotName)
+}
+
+// isWithin returns a boolean indicating whether the given (remote) path is the parent path or within it.
+func isWithin(path, parent string) bool {
+	path, parent = strings.TrimSuffix(path, "/"), strings.TrimSuffix(parent, "/")
+
+	return path == parent || strings.HasPrefix(path, parent+"/")
+}
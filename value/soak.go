@@ -0,0 +1,193 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+	"github.com/pkg/errors"
+)
+
+// SoakConfig describes the 'soak' benchmark, which repeats backups into the same repository (a full backup followed
+// by incremental backups) for a long period of time to catch throughput drift and leaks in 'cbbackupmgr'.
+type SoakConfig struct {
+	// Duration is the number of seconds to keep creating backups for e.g. 172800 for 48 hours, the cycle in progress
+	// when the duration elapses is completed.
+	Duration int `json:"duration,omitempty" yaml:"duration,omitempty"`
+
+	// Interval is the number of seconds to wait between each cycle, by default the next cycle starts immediately.
+	Interval int `json:"interval,omitempty" yaml:"interval,omitempty"`
+}
+
+// Validate returns an error if the soak config is invalid.
+func (s *SoakConfig) Validate() error {
+	if s == nil {
+		return errors.New("the soak benchmark requires a 'soak' config")
+	}
+
+	if s.Duration <= 0 {
+		return errors.New("the duration must be greater than zero")
+	}
+
+	if s.Interval < 0 {
+		return errors.New("the interval must not be negative")
+	}
+
+	return nil
+}
+
+// SoakCycle is the result of a single backup created by the 'soak' benchmark.
+type SoakCycle struct {
+	// Elapsed is how long after the start of the soak the cycle started.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Incremental indicates whether the backup was an incremental backup, only the first backup is a full backup.
+	Incremental bool `json:"incremental"`
+
+	Duration time.Duration `json:"duration"`
+	ADS      uint64        `json:"ads"`
+	AIN      uint64        `json:"ain"`
+
+	// ArchiveSize is the size of the archive once the backup completed.
+	ArchiveSize uint64 `json:"archive_size"`
+
+	// PeakMemory is the peak resident set size of 'cbbackupmgr' sampled during the backup.
+	PeakMemory uint64 `json:"peak_memory"`
+
+	// PeakFDs is the peak number of open file descriptors of 'cbbackupmgr' sampled during the backup.
+	PeakFDs uint64 `json:"peak_fds"`
+
+	// PeakThreads is the peak number of threads of 'cbbackupmgr' sampled during the backup.
+	PeakThreads uint64 `json:"peak_threads"`
+}
+
+// TransferRate returns the average transfer rate (ADS/second) of the backup.
+func (s *SoakCycle) TransferRate() uint64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+
+	return uint64(float64(s.ADS) / s.Duration.Seconds())
+}
+
+// SoakResult is the result of the 'soak' benchmark.
+type SoakResult struct {
+	Elapsed time.Duration `json:"elapsed"`
+	Cycles  []*SoakCycle  `json:"cycles"`
+}
+
+// soakWindow is the average of a metric across the first/last cycles of a soak, and the drift between them.
+type soakWindow struct {
+	First uint64
+	Last  uint64
+}
+
+// Drift returns the percentage change between the first and last window.
+func (s soakWindow) Drift() string {
+	if s.First == 0 {
+		return "N/A"
+	}
+
+	return fmt.Sprintf("%+.1f%%", (float64(s.Last)-float64(s.First))/float64(s.First)*100)
+}
+
+// window returns the average of the given metric across the first/last quarter of the incremental backups, the full
+// backup is excluded since it's not comparable.
+func (s *SoakResult) window(metric func(cycle *SoakCycle) uint64) soakWindow {
+	incrementals := make([]*SoakCycle, 0, len(s.Cycles))
+
+	for _, cycle := range s.Cycles {
+		if cycle.Incremental {
+			incrementals = append(incrementals, cycle)
+		}
+	}
+
+	if len(incrementals) == 0 {
+		return soakWindow{}
+	}
+
+	average := func(cycles []*SoakCycle) uint64 {
+		var total uint64
+		for _, cycle := range cycles {
+			total += metric(cycle)
+		}
+
+		return total / uint64(len(cycles))
+	}
+
+	size := len(incrementals) / 4
+	if size == 0 {
+		size = 1
+	}
+
+	return soakWindow{First: average(incrementals[:size]), Last: average(incrementals[len(incrementals)-size:])}
+}
+
+// ArchiveGrowthRate returns the average number of bytes the archive grew by per hour, after the full backup.
+func (s *SoakResult) ArchiveGrowthRate() uint64 {
+	if len(s.Cycles) < 2 {
+		return 0
+	}
+
+	var (
+		first = s.Cycles[0]
+		last  = s.Cycles[len(s.Cycles)-1]
+		hours = (last.Elapsed + last.Duration - first.Elapsed - first.Duration).Hours()
+	)
+
+	if hours <= 0 || last.ArchiveSize < first.ArchiveSize {
+		return 0
+	}
+
+	return uint64(float64(last.ArchiveSize-first.ArchiveSize) / hours)
+}
+
+// String returns a string representation of the soak result which will be output in the report.
+func (s *SoakResult) String() string {
+	var (
+		buffer  = &bytes.Buffer{}
+		writer  = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+		rate    = s.window(func(cycle *SoakCycle) uint64 { return cycle.TransferRate() })
+		memory  = s.window(func(cycle *SoakCycle) uint64 { return cycle.PeakMemory })
+		fds     = s.window(func(cycle *SoakCycle) uint64 { return cycle.PeakFDs })
+		threads = s.window(func(cycle *SoakCycle) uint64 { return cycle.PeakThreads })
+	)
+
+	fmt.Fprintln(buffer, "| Soak\n| ----")
+	fmt.Fprintf(writer, "| Metric\t First Incrementals\t Last Incrementals\t Drift\t\n")
+	fmt.Fprintf(writer, "| Transfer Rate (ADS)\t %s/s\t %s/s\t %s\t\n", format.Bytes(rate.First),
+		format.Bytes(rate.Last), rate.Drift())
+	fmt.Fprintf(writer, "| Peak Memory (RSS)\t %s\t %s\t %s\t\n", format.Bytes(memory.First), format.Bytes(memory.Last),
+		memory.Drift())
+	fmt.Fprintf(writer, "| Peak Open FDs\t %d\t %d\t %s\t\n", fds.First, fds.Last, fds.Drift())
+	fmt.Fprintf(writer, "| Peak Threads\t %d\t %d\t %s\t\n", threads.First, threads.Last, threads.Drift())
+
+	_ = writer.Flush()
+
+	var archive uint64
+	if len(s.Cycles) != 0 {
+		archive = s.Cycles[len(s.Cycles)-1].ArchiveSize
+	}
+
+	fmt.Fprintf(buffer, "\nCreated %d backup(s) in %s, the archive grew to %s (%s/h after the full backup)\n",
+		len(s.Cycles), format.Duration(s.Elapsed), format.Bytes(archive), format.Bytes(s.ArchiveGrowthRate()))
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -14,10 +14,141 @@
 
 package value
 
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultMaxOutputSize is the default maximum number of bytes of output which will be kept for each remote command.
+	DefaultMaxOutputSize = 16 * 1024 * 1024
+
+	// DefaultKeepaliveInterval is the default number of seconds between each keepalive sent to the remote host.
+	DefaultKeepaliveInterval = 30
+
+	// DefaultKeepaliveMaxMissed is the default number of consecutive keepalives which may go unanswered before the
+	// connection is considered dead.
+	DefaultKeepaliveMaxMissed = 3
+
+	// DefaultConnectTimeout is the default number of seconds to keep retrying when establishing an ssh connection.
+	DefaultConnectTimeout = 300
+
+	// DefaultConnectBackoff is the default number of seconds to wait before the first connection retry.
+	DefaultConnectBackoff = 1
+
+	// DefaultConnectMaxBackoff is the default maximum number of seconds to wait between connection retries.
+	DefaultConnectMaxBackoff = 30
+)
+
 // SSHConfig encapsulates the SSH config accepted by 'cbtools-autobench'. This will be used when connecting to remote
 // hosts. The same config will be used to connect to each server.
 type SSHConfig struct {
 	Username             string `yaml:"username,omitempty"`
 	PrivateKey           string `yaml:"private_key,omitempty"`
 	PrivateKeyPassphrase string `yaml:"private_key_passphrase,omitempty"`
+
+	// MaxOutputSize is the maximum number of bytes of output kept for each remote command, any further output is
+	// discarded and replaced with a truncation marker. This avoids running out of memory when a command produces a
+	// huge amount of output.
+	MaxOutputSize int `yaml:"max_output_size,omitempty"`
+
+	// KeepaliveInterval is the number of seconds between each keepalive, this stops a NAT/firewall silently dropping
+	// idle connections during long running benchmarks.
+	KeepaliveInterval int `yaml:"keepalive_interval,omitempty"`
+
+	// KeepaliveMaxMissed is the number of consecutive keepalives which may go unanswered before the connection is
+	// considered dead; dead connections are closed and re-established before running the next command.
+	KeepaliveMaxMissed int `yaml:"keepalive_max_missed,omitempty"`
+
+	// ConnectTimeout is the total number of seconds to keep retrying when (re)establishing a connection, this allows
+	// connecting to hosts which have only just booted and aren't accepting connections yet.
+	ConnectTimeout int `yaml:"connect_timeout,omitempty"`
+
+	// ConnectBackoff is the number of seconds to wait before the first connection retry, this is doubled after each
+	// failed attempt.
+	ConnectBackoff int `yaml:"connect_backoff,omitempty"`
+
+	// ConnectMaxBackoff is the maximum number of seconds to wait between connection retries.
+	ConnectMaxBackoff int `yaml:"connect_max_backoff,omitempty"`
+}
+
+// Validate returns an error if the ssh config is invalid.
+func (s *SSHConfig) Validate() error {
+	if s.KeepaliveInterval < 0 || s.KeepaliveMaxMissed < 0 {
+		return fmt.Errorf("ssh keepalive interval/max missed must not be negative")
+	}
+
+	if s.ConnectTimeout < 0 || s.ConnectBackoff < 0 || s.ConnectMaxBackoff < 0 {
+		return fmt.Errorf("ssh connect timeout/backoff must not be negative")
+	}
+
+	if s.ConnectBackoffOrDefault() > s.ConnectMaxBackoffOrDefault() {
+		return fmt.Errorf("ssh connect backoff must not exceed the max backoff")
+	}
+
+	return nil
+}
+
+// ConnectTimeoutOrDefault returns how long to keep retrying when establishing a connection.
+func (s *SSHConfig) ConnectTimeoutOrDefault() time.Duration {
+	if s.ConnectTimeout == 0 {
+		return DefaultConnectTimeout * time.Second
+	}
+
+	return time.Duration(s.ConnectTimeout) * time.Second
+}
+
+// ConnectBackoffOrDefault returns how long to wait before the first connection retry.
+func (s *SSHConfig) ConnectBackoffOrDefault() time.Duration {
+	if s.ConnectBackoff == 0 {
+		return DefaultConnectBackoff * time.Second
+	}
+
+	return time.Duration(s.ConnectBackoff) * time.Second
+}
+
+// ConnectMaxBackoffOrDefault returns the maximum time to wait between connection retries.
+func (s *SSHConfig) ConnectMaxBackoffOrDefault() time.Duration {
+	if s.ConnectMaxBackoff == 0 {
+		return DefaultConnectMaxBackoff * time.Second
+	}
+
+	return time.Duration(s.ConnectMaxBackoff) * time.Second
+}
+
+// NextConnectBackoff returns the duration to wait after the given backoff, doubling it up to the max backoff.
+func (s *SSHConfig) NextConnectBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > s.ConnectMaxBackoffOrDefault() {
+		return s.ConnectMaxBackoffOrDefault()
+	}
+
+	return backoff
+}
+
+// KeepaliveIntervalOrDefault returns the keepalive interval, falling back to the default if one wasn't provided.
+func (s *SSHConfig) KeepaliveIntervalOrDefault() time.Duration {
+	if s.KeepaliveInterval == 0 {
+		return DefaultKeepaliveInterval * time.Second
+	}
+
+	return time.Duration(s.KeepaliveInterval) * time.Second
+}
+
+// KeepaliveMaxMissedOrDefault returns the number of keepalives which may be missed, falling back to the default if one
+// wasn't provided.
+func (s *SSHConfig) KeepaliveMaxMissedOrDefault() int {
+	if s.KeepaliveMaxMissed == 0 {
+		return DefaultKeepaliveMaxMissed
+	}
+
+	return s.KeepaliveMaxMissed
+}
+
+// MaxOutputSizeOrDefault returns the maximum output size, falling back to the default if one wasn't provided.
+func (s *SSHConfig) MaxOutputSizeOrDefault() int {
+	if s.MaxOutputSize == 0 {
+		return DefaultMaxOutputSize
+	}
+
+	return s.MaxOutputSize
 }
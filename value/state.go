@@ -0,0 +1,93 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"strings"
+)
+
+// StateDirectory is the directory on each remote machine which contains a snapshot of the machine state from before
+// 'cbtools-autobench' modified it.
+const StateDirectory = "/var/lib/cbtools-autobench/original"
+
+// StateFiles are the files which will be snapshotted and restored by the 'restore-host' sub-command.
+var StateFiles = []string{"/etc/fstab", "/etc/hosts", "/root/.ssh/authorized_keys"}
+
+// CommandSnapshotState returns a command which will snapshot the current machine state, the snapshot is only taken once
+// so that the original state is retained across multiple runs.
+func (c *Capabilities) CommandSnapshotState() Command {
+	commands := []string{
+		"test -e " + StateDirectory + " && exit 0",
+		"mkdir -p " + StateDirectory,
+		string(c.CommandListPackages()) + " | sort > " + RemoteJoin(StateDirectory, "packages"),
+		"sysctl -a > " + RemoteJoin(StateDirectory, "sysctl") + " 2>/dev/null",
+	}
+
+	for _, file := range StateFiles {
+		commands = append(commands, "(test ! -e "+file+" || cp -p "+file+" "+stateFile(file)+")")
+	}
+
+	return NewCommand("sh -c '%s'", strings.Join(commands, "; "))
+}
+
+// CommandRestoreState returns a command which will restore the snapshotted state, removing any packages which have
+// been installed since the snapshot was taken and re-applying any kernel parameters which have since been changed.
+func (c *Capabilities) CommandRestoreState() Command {
+	commands := []string{
+		"test -e " + StateDirectory + " || exit 0",
+	}
+
+	for _, file := range StateFiles {
+		commands = append(commands, "(test ! -e "+stateFile(file)+" || cp -p "+stateFile(file)+" "+file+")")
+	}
+
+	var (
+		current = RemoteJoin(StateDirectory, "current")
+		added   = RemoteJoin(StateDirectory, "added")
+	)
+
+	commands = append(commands,
+		"(test ! -e "+selinuxState+" || ! grep -qx Enforcing "+selinuxState+" || setenforce 1)",
+		"(test ! -s "+appArmorState+" || aa-enforce $(cat "+appArmorState+"))",
+		string(c.CommandListPackages())+" | sort > "+current,
+		"comm -13 "+RemoteJoin(StateDirectory, "packages")+" "+current+" > "+added,
+		"(test ! -s "+added+" || "+string(c.CommandUninstallPackages("$(cat "+added+")"))+")",
+		commandRestoreSysctl(),
+		"rm -rf "+StateDirectory,
+	)
+
+	return NewCommand("sh -c '%s'", strings.Join(commands, "; "))
+}
+
+// commandRestoreSysctl returns a command which re-applies the snapshotted value of each kernel parameter which differs
+// from its current value.
+//
+// NOTE: 'sysctl -a' includes read-only parameters (and counters which change constantly), failing to write them is
+// ignored.
+func commandRestoreSysctl() string {
+	var (
+		original = RemoteJoin(StateDirectory, "sysctl")
+		current  = RemoteJoin(StateDirectory, "sysctl.current")
+	)
+
+	return "(test ! -s " + original + " || { sysctl -a 2>/dev/null | LC_ALL=C sort > " + current + "; " +
+		"LC_ALL=C sort " + original + " | LC_ALL=C comm -23 - " + current + " | while IFS= read -r line; do " +
+		`sysctl -q -w "${line%% = *}=${line#* = }" >/dev/null 2>&1 || true; done; })`
+}
+
+// stateFile returns the path to the snapshot of the given file.
+func stateFile(file string) string {
+	return RemoteJoin(StateDirectory, strings.ReplaceAll(strings.TrimPrefix(file, "/"), "/", "_"))
+}
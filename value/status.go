@@ -0,0 +1,35 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+// RunStatus indicates the outcome of a benchmark run, it's included in the report so that failed runs are never
+// mistaken for slow ones.
+type RunStatus string
+
+const (
+	// RunStatusSuccess indicates that all the benchmarks completed successfully.
+	RunStatusSuccess RunStatus = "success"
+
+	// RunStatusCrashed indicates that a benchmarked tool crashed, any core dumps will have been downloaded into the
+	// run directory.
+	RunStatusCrashed RunStatus = "failed-with-crash"
+
+	// RunStatusEnvironmentFailure indicates that the health of the cluster degraded during the run e.g. a node was failed
+	// over, the benchmarks were aborted since the results would be misleading.
+	RunStatusEnvironmentFailure RunStatus = "environment-failure"
+
+	// RunStatusFailed indicates that the run failed for any other reason e.g. a machine couldn't be reached.
+	RunStatusFailed RunStatus = "failed"
+)
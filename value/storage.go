@@ -0,0 +1,90 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "strings"
+
+// StorageType describes the kind of block device backing a path.
+type StorageType string
+
+const (
+	// StorageTypeEBS indicates the path is backed by an EBS volume, which persists across instance stop/start.
+	StorageTypeEBS StorageType = "ebs"
+
+	// StorageTypeInstanceStore indicates the path is backed by an (NVMe) instance store device, which is ephemeral and
+	// usually much faster than EBS; results using instance store are not comparable with those using EBS.
+	StorageTypeInstanceStore StorageType = "instance-store"
+
+	// StorageTypeTiered indicates the path is on a tiered device (built during provisioning) where a fast device caches
+	// a slow one, the performance depends on the size of the cache and how much of the data fits in it.
+	StorageTypeTiered StorageType = "tiered"
+
+	// StorageTypeOther indicates the path is backed by a device which isn't provided by EC2 e.g. a local disk.
+	StorageTypeOther StorageType = "other"
+)
+
+const (
+	// ebsModel is the model reported by NVMe EBS volumes on Nitro instances.
+	ebsModel = "Amazon Elastic Block Store"
+
+	// instanceStoreModel is the model reported by NVMe instance store devices.
+	instanceStoreModel = "Amazon EC2 NVMe Instance Storage"
+)
+
+// DefaultInstanceStoreMountPoint is the default directory instance store devices are mounted at.
+const DefaultInstanceStoreMountPoint = "/mnt/instance-store"
+
+// ParseStorageType returns the storage type for a block device with the given model.
+func ParseStorageType(model string) StorageType {
+	model = strings.TrimSpace(model)
+
+	switch {
+	case strings.HasPrefix(model, instanceStoreModel):
+		return StorageTypeInstanceStore
+	case strings.HasPrefix(model, ebsModel):
+		return StorageTypeEBS
+	}
+
+	return StorageTypeOther
+}
+
+// InstanceStoreBlueprint describes how an NVMe instance store device is prepared for use e.g. as the data path or
+// archive.
+//
+// NOTE: Instance store devices are wiped when the instance is stopped, so the device is formatted (when it's not
+// already mounted) each time the node is provisioned and it's intentionally not added to '/etc/fstab'.
+type InstanceStoreBlueprint struct {
+	// MountPoint is the directory the instance store device will be mounted at.
+	MountPoint string `json:"mount_point,omitempty" yaml:"mount_point,omitempty"`
+}
+
+// MountPointOrDefault returns the directory the instance store device will be mounted at.
+func (i *InstanceStoreBlueprint) MountPointOrDefault() string {
+	if i.MountPoint == "" {
+		return DefaultInstanceStoreMountPoint
+	}
+
+	return i.MountPoint
+}
+
+// CommandPrepare returns a command which formats/mounts the first instance store device unless it's already mounted,
+// an error is returned if the machine doesn't have an instance store device.
+func (i *InstanceStoreBlueprint) CommandPrepare() Command {
+	return NewCommand(`mountpoint -q %[1]s && exit 0;
+		device=$(lsblk -dnpo NAME,MODEL | awk '/%[2]s/ { print $1; exit }');
+		[ -n "$device" ] || { echo 'no instance store device found' >&2; exit 1; };
+		mkfs.xfs -f $device && mkdir -p %[1]s && mount -o noatime $device %[1]s && chmod 777 %[1]s`,
+		i.MountPointOrDefault(), instanceStoreModel)
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// StorageBackendDefault is the archive storage backend used by 'cbbackupmgr' when the '--storage' flag isn't supplied.
+const StorageBackendDefault = "default"
+
+// StorageSweepConfig describes the archive storage backends benchmarked by the 'storage' benchmark, the backup then
+// restore benchmarks are run using each backend in turn against the same cluster/backup client.
+type StorageSweepConfig struct {
+	// Backends are the values of '--storage' which will be benchmarked, 'default' benchmarks the archive storage
+	// backend used when the flag isn't supplied e.g. ['default', 'sqlite'].
+	Backends []string `json:"backends,omitempty" yaml:"backends,omitempty"`
+}
+
+// Validate returns an error if the storage sweep config is incomplete.
+func (s *StorageSweepConfig) Validate() error {
+	if s == nil || len(s.Backends) == 0 {
+		return errors.New("at least one storage backend must be provided")
+	}
+
+	seen := make(map[string]struct{}, len(s.Backends))
+
+	for _, backend := range s.Backends {
+		if backend == "" || strings.ContainsAny(backend, " \t'\"") {
+			return fmt.Errorf("invalid storage backend '%s'", backend)
+		}
+
+		if _, ok := seen[backend]; ok {
+			return fmt.Errorf("storage backend '%s' is duplicated", backend)
+		}
+
+		seen[backend] = struct{}{}
+	}
+
+	return nil
+}
+
+// StorageResult is the result of benchmarking a single archive storage backend.
+type StorageResult struct {
+	Backend string         `json:"backend"`
+	Backup  *ThreadsResult `json:"backup"`
+	Restore *ThreadsResult `json:"restore,omitempty"`
+
+	// AvgArchiveSize is the average size of the archive after each backup, the layout of the backend determines how
+	// much space the same data takes up.
+	AvgArchiveSize uint64 `json:"avg_archive_size,omitempty"`
+}
+
+// NewStorageResult calculates the averages of the given backup/restore results for an archive storage backend.
+func NewStorageResult(config *CBMConfig, backup, restore BenchmarkResults) *StorageResult {
+	result := &StorageResult{
+		Backend: config.StorageOrDefault(),
+		Backup:  NewThreadsResult(config.Threads, backup),
+	}
+
+	if restore != nil {
+		result.Restore = NewThreadsResult(config.Threads, restore)
+	}
+
+	if len(backup) == 0 {
+		return result
+	}
+
+	for _, r := range backup {
+		result.AvgArchiveSize += r.ArchiveSize
+	}
+
+	result.AvgArchiveSize /= uint64(len(backup))
+
+	return result
+}
+
+// StorageSweepResults are the results for each of the archive storage backends in a storage sweep.
+type StorageSweepResults []*StorageResult
+
+// Report returns the machine readable form of the results for each of the archive storage backends.
+func (s StorageSweepResults) Report() []*BenchmarkBackend {
+	backends := make([]*BenchmarkBackend, 0, len(s))
+
+	for _, result := range s {
+		backend := &BenchmarkBackend{Backend: result.Backend, Backup: result.Backup.Results.Report()}
+
+		if result.Restore != nil {
+			backend.Restore = result.Restore.Results.Report()
+		}
+
+		backends = append(backends, backend)
+	}
+
+	return backends
+}
+
+// String returns a string representation of the storage sweep results which will be output in the report.
+func (s StorageSweepResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	averages := func(result *ThreadsResult) (string, string) {
+		if result == nil || len(result.Results) == 0 {
+			return "N/A", "N/A"
+		}
+
+		return format.Duration(result.AvgDuration), format.Bytes(result.AvgTransferRateADS) + "/s"
+	}
+
+	fmt.Fprintln(buffer, "| Storage Sweep\n| -------------")
+	fmt.Fprintf(writer, "| Backend\t Avg Backup Duration\t Avg Backup Rate (ADS)\t Avg Restore Duration\t "+
+		"Avg Restore Rate (ADS)\t Avg Archive Size\t\n")
+
+	for _, result := range s {
+		var (
+			backupDuration, backupRate   = averages(result.Backup)
+			restoreDuration, restoreRate = averages(result.Restore)
+			archiveSize                  = "N/A"
+		)
+
+		if result.AvgArchiveSize != 0 {
+			archiveSize = format.Bytes(result.AvgArchiveSize)
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t %s\t\n", result.Backend, backupDuration, backupRate,
+			restoreDuration, restoreRate, archiveSize)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// ClientSweepConfig describes the backup client instance types benchmarked by the 'sweep' benchmark, an instance of
+// each type is launched in turn and used to back up the same cluster.
+type ClientSweepConfig struct {
+	// InstanceTypes are the EC2 instance types which will be benchmarked e.g. 'c5.2xlarge'.
+	InstanceTypes []string `json:"instance_types,omitempty" yaml:"instance_types,omitempty"`
+
+	// Launch describes how the backup client instances are launched.
+	Launch *AWSLaunchConfig `json:"launch,omitempty" yaml:"launch,omitempty"`
+
+	// TargetSize is the size (in GiB) of the dataset which must be backed up within the target duration, used to
+	// project whether each instance type is sufficient.
+	TargetSize int `json:"target_size,omitempty" yaml:"target_size,omitempty"`
+
+	// TargetDuration is the number of seconds in which the target size must be backed up.
+	TargetDuration int `json:"target_duration,omitempty" yaml:"target_duration,omitempty"`
+}
+
+// Validate returns an error if the sweep config is incomplete.
+func (c *ClientSweepConfig) Validate() error {
+	if c == nil || len(c.InstanceTypes) == 0 {
+		return errors.New("at least one instance type must be provided")
+	}
+
+	if c.Launch == nil || c.Launch.ImageID == "" {
+		return errors.New("an image id must be provided to launch instances")
+	}
+
+	err := c.Launch.Volumes.Validate()
+	if err != nil {
+		return err
+	}
+
+	if (c.TargetSize == 0) != (c.TargetDuration == 0) {
+		return errors.New("a target size and duration must be provided together")
+	}
+
+	return nil
+}
+
+// targetBytes returns the target size in bytes.
+func (c *ClientSweepConfig) targetBytes() uint64 {
+	return uint64(c.TargetSize) * 1024 * 1024 * 1024
+}
+
+// SweepResult is the result of benchmarking a single backup client instance type.
+type SweepResult struct {
+	InstanceType string           `json:"instance_type"`
+	Results      BenchmarkResults `json:"-"`
+	Error        string           `json:"error,omitempty"`
+
+	AvgDuration        time.Duration `json:"avg_duration,omitempty"`
+	AvgTransferRateADS uint64        `json:"avg_transfer_rate_ads,omitempty"`
+
+	// ProjectedDuration is how long backing up the target size is projected to take using the average transfer rate.
+	ProjectedDuration time.Duration `json:"projected_duration,omitempty"`
+	MeetsTarget       bool          `json:"meets_target"`
+}
+
+// NewSweepResult calculates the averages of the given results for an instance type, projecting how long the target size
+// would take to back up.
+func NewSweepResult(config *ClientSweepConfig, instanceType string, results BenchmarkResults) *SweepResult {
+	result := &SweepResult{InstanceType: instanceType, Results: results}
+
+	if len(results) == 0 {
+		return result
+	}
+
+	var duration time.Duration
+
+	for _, r := range results {
+		duration += r.Duration
+		result.AvgTransferRateADS += r.AvgTransferRateADS()
+	}
+
+	result.AvgDuration = duration / time.Duration(len(results))
+	result.AvgTransferRateADS /= uint64(len(results))
+
+	if config.TargetSize == 0 || result.AvgTransferRateADS == 0 {
+		return result
+	}
+
+	result.ProjectedDuration = time.Duration(config.targetBytes()/result.AvgTransferRateADS) * time.Second
+	result.MeetsTarget = result.ProjectedDuration <= time.Duration(config.TargetDuration)*time.Second
+
+	return result
+}
+
+// SweepResults are the results for each of the instance types in a backup client sweep.
+type SweepResults struct {
+	Config  *ClientSweepConfig `json:"config"`
+	Results []*SweepResult     `json:"results"`
+}
+
+// String returns a string representation of the sweep results which will be output in the report.
+func (s *SweepResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Client Sweep\n| ------------")
+
+	if s.Config.TargetSize != 0 {
+		fmt.Fprintf(buffer, "| Target: %dGiB in %s\n", s.Config.TargetSize,
+			format.Duration(time.Duration(s.Config.TargetDuration)*time.Second))
+	}
+
+	if len(s.Config.Launch.Volumes) != 0 {
+		fmt.Fprintf(buffer, "| Volumes:\n%s\n", s.Config.Launch.Volumes)
+	}
+
+	fmt.Fprintf(writer,
+		"| Instance Type\t Avg Duration\t Avg Transfer Rate (ADS)\t Projected Duration\t Meets Target\t Error\t\n")
+
+	for _, result := range s.Results {
+		var duration, rate, projected, meets string
+
+		if len(result.Results) != 0 {
+			duration = format.Duration(result.AvgDuration)
+			rate = format.Bytes(result.AvgTransferRateADS) + "/s"
+		}
+
+		if result.ProjectedDuration != 0 {
+			projected, meets = format.Duration(result.ProjectedDuration), fmt.Sprintf("%t", result.MeetsTarget)
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s\t %s\t %s\t\n", result.InstanceType, duration, rate, projected, meets,
+			result.Error)
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+)
+
+// TeardownConfig describes what 'teardown' removes in addition to Couchbase Server and the files created by
+// 'cbtools-autobench'.
+type TeardownConfig struct {
+	// Terminate indicates that the EC2 instances used for the cluster nodes/backup client should be terminated once
+	// they've been cleaned up.
+	Terminate bool
+
+	// AWSConfig is used when terminating instances.
+	AWSConfig
+}
+
+// ArgsTerminate returns the arguments which should be passed to the 'aws' CLI to terminate the given instances.
+func (t *TeardownConfig) ArgsTerminate(ids []string) []string {
+	args := append([]string{"ec2", "terminate-instances", "--instance-ids"}, ids...)
+	return append(args, awsArgs(t.AWSConfig)...)
+}
+
+// CommandCleanPath returns a command which removes everything within the given directory (if it exists), the directory
+// itself is kept since it may be a mount point.
+func CommandCleanPath(path string) Command {
+	return NewCommand(`test ! -d %[1]s || find %[1]s -mindepth 1 -delete`, path)
+}
+
+// CommandRemoveTempDirectories returns a command which removes the per-run temporary directories in the given parent
+// (and the backup client lock) for every run, unlike 'CommandGarbageCollect' this includes the directories of runs
+// which may still be active.
+func CommandRemoveTempDirectories(parent string) Command {
+	return NewCommand(`find %s -mindepth 1 -maxdepth 1 -type d -name '%s*' -exec rm -rf {} + && rm -rf %s`,
+		parent, TempDirectoryPrefix, ClientLockDirectory)
+}
+
+// CommandTeardown returns a command which unmounts the instance store device (if it's mounted).
+func (i *InstanceStoreBlueprint) CommandTeardown() Command {
+	return NewCommand(`! mountpoint -q %[1]s || umount %[1]s`, i.MountPointOrDefault())
+}
+
+// CommandTeardown returns a command which unmounts then dismantles the tiered device, leaving the cache/backing
+// devices unused.
+func (t *TieredStorageBlueprint) CommandTeardown() Command {
+	var dismantle string
+
+	switch t.TypeOrDefault() {
+	case TieredStorageTypeBCache:
+		dismantle = `for b in /sys/block/bcache*/bcache; do [ -e $b ] && echo 1 > $b/stop; done;
+			for c in /sys/fs/bcache/*-*; do [ -e $c ] && echo 1 > $c/unregister; done; true`
+	default:
+		dismantle = fmt.Sprintf(`vgremove -ff -y %s 2>/dev/null; true`, tieredVolumeGroup)
+	}
+
+	return NewCommand(`(! mountpoint -q %[1]s || umount %[1]s) && %[2]s`, t.MountPointOrDefault(), dismantle)
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// ThreadsSweepConfig describes the values of '--threads' benchmarked by the 'threads' benchmark, the backup benchmark
+// is run using each value in turn against the same cluster/backup client.
+type ThreadsSweepConfig struct {
+	// Threads are the values of '--threads' which will be benchmarked e.g. [1, 2, 4, 8, 16].
+	Threads []int `json:"threads,omitempty" yaml:"threads,omitempty"`
+}
+
+// Validate returns an error if the threads sweep config is incomplete.
+func (t *ThreadsSweepConfig) Validate() error {
+	if t == nil || len(t.Threads) == 0 {
+		return errors.New("at least one value of threads must be provided")
+	}
+
+	for idx, threads := range t.Threads {
+		if threads <= 0 {
+			return fmt.Errorf("invalid threads value %d, it must be positive", threads)
+		}
+
+		if idx > 0 && threads <= t.Threads[idx-1] {
+			return errors.New("the threads values must be in ascending order")
+		}
+	}
+
+	return nil
+}
+
+// ThreadsResult is the result of benchmarking a single value of '--threads'.
+type ThreadsResult struct {
+	Threads int              `json:"threads"`
+	Results BenchmarkResults `json:"-"`
+
+	AvgDuration        time.Duration `json:"avg_duration,omitempty"`
+	AvgTransferRateADS uint64        `json:"avg_transfer_rate_ads,omitempty"`
+}
+
+// NewThreadsResult calculates the averages of the given results for a value of '--threads'.
+func NewThreadsResult(threads int, results BenchmarkResults) *ThreadsResult {
+	result := &ThreadsResult{Threads: threads, Results: results}
+
+	if len(results) == 0 {
+		return result
+	}
+
+	var duration time.Duration
+
+	for _, r := range results {
+		duration += r.Duration
+		result.AvgTransferRateADS += r.AvgTransferRateADS()
+	}
+
+	result.AvgDuration = duration / time.Duration(len(results))
+	result.AvgTransferRateADS /= uint64(len(results))
+
+	return result
+}
+
+// ThreadsSweepResults are the results for each of the values of '--threads' in a threads sweep.
+type ThreadsSweepResults struct {
+	Results []*ThreadsResult `json:"results"`
+
+	// Recommended is the recommended value of '--threads' for the benchmarked environment, a zero value indicates there
+	// weren't any successful results.
+	Recommended int `json:"recommended,omitempty"`
+}
+
+// NewThreadsSweepResults returns the sweep results for the given results, recommending the value of '--threads' at
+// the knee of the transfer rate curve.
+func NewThreadsSweepResults(results []*ThreadsResult) *ThreadsSweepResults {
+	return &ThreadsSweepResults{Results: results, Recommended: recommendThreads(results)}
+}
+
+// recommendThreads returns the value of '--threads' at the knee of the curve i.e. beyond which additional threads
+// yield diminishing returns, using the "Kneedle" approach; the knee is the point furthest above the straight line
+// between the first result and the result with the highest transfer rate.
+//
+// NOTE: Threads beyond the highest transfer rate are never recommended, and the highest transfer rate is recommended
+// when the curve has no knee (i.e. the transfer rate scales linearly).
+func recommendThreads(results []*ThreadsResult) int {
+	var points []*ThreadsResult
+
+	for _, result := range results {
+		if result.AvgTransferRateADS != 0 {
+			points = append(points, result)
+		}
+	}
+
+	if len(points) == 0 {
+		return 0
+	}
+
+	peak := 0
+
+	for idx, point := range points {
+		if point.AvgTransferRateADS > points[peak].AvgTransferRateADS {
+			peak = idx
+		}
+	}
+
+	var (
+		first = points[0]
+		last  = points[peak]
+		knee  = last
+		best  float64
+	)
+
+	if peak == 0 {
+		return first.Threads
+	}
+
+	// Normalize both axes into [0, 1] so that the threads/transfer rate are comparable
+	normalize := func(point *ThreadsResult) (float64, float64) {
+		x := float64(point.Threads-first.Threads) / float64(last.Threads-first.Threads)
+
+		y := 1.0
+		if last.AvgTransferRateADS != first.AvgTransferRateADS {
+			y = (float64(point.AvgTransferRateADS) - float64(first.AvgTransferRateADS)) /
+				(float64(last.AvgTransferRateADS) - float64(first.AvgTransferRateADS))
+		}
+
+		return x, y
+	}
+
+	for _, point := range points[1:peak] {
+		x, y := normalize(point)
+
+		// The line between the first/peak results is 'y = x' once normalized, so the distance above it is 'y - x'
+		if distance := y - x; distance > best {
+			best, knee = distance, point
+		}
+	}
+
+	return knee.Threads
+}
+
+// String returns a string representation of the threads sweep results which will be output in the report.
+func (t *ThreadsSweepResults) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Threads Sweep\n| -------------")
+	fmt.Fprintf(writer, "| Threads\t Avg Duration\t Avg Transfer Rate (ADS)\t Recommended\t\n")
+
+	for _, result := range t.Results {
+		var duration, rate, recommended string
+
+		if len(result.Results) != 0 {
+			duration = format.Duration(result.AvgDuration)
+			rate = format.Bytes(result.AvgTransferRateADS) + "/s"
+		}
+
+		if result.Threads == t.Recommended {
+			recommended = "*"
+		}
+
+		fmt.Fprintf(writer, "| %d\t %s\t %s\t %s\t\n", result.Threads, duration, rate, recommended)
+	}
+
+	_ = writer.Flush()
+
+	if t.Recommended != 0 {
+		fmt.Fprintf(buffer, "\nRecommended: --threads %d, additional threads yield diminishing returns in this "+
+			"environment", t.Recommended)
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
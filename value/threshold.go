@@ -0,0 +1,78 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// ThresholdConfig describes the limits which the average backup/restore performance must stay within, a breach results
+// in a distinct exit code allowing automation to detect regressions.
+type ThresholdConfig struct {
+	// MaxDuration is the maximum average duration of the backups/restores in seconds.
+	MaxDuration int `json:"max_duration,omitempty" yaml:"max_duration,omitempty"`
+
+	// MinTransferRate is the minimum average transfer rate (using the actual data size) in MiB/s.
+	MinTransferRate int `json:"min_transfer_rate,omitempty" yaml:"min_transfer_rate,omitempty"`
+}
+
+// Validate returns an error if the threshold config is invalid.
+func (t *ThresholdConfig) Validate() error {
+	if t.MaxDuration < 0 {
+		return fmt.Errorf("max duration must not be negative")
+	}
+
+	if t.MinTransferRate < 0 {
+		return fmt.Errorf("min transfer rate must not be negative")
+	}
+
+	return nil
+}
+
+// Check returns an error categorized as a regression if the average performance of the given results breaches any of
+// the thresholds, nil is returned if there are no thresholds or results.
+func (t *ThresholdConfig) Check(results BenchmarkResults) error {
+	if t == nil || len(results) == 0 {
+		return nil
+	}
+
+	var (
+		duration     time.Duration
+		transferRate uint64
+	)
+
+	for _, result := range results {
+		duration += result.Duration
+		transferRate += result.AvgTransferRateADS()
+	}
+
+	duration /= time.Duration(len(results))
+	transferRate /= uint64(len(results))
+
+	if limit := time.Duration(t.MaxDuration) * time.Second; t.MaxDuration != 0 && duration > limit {
+		return Categorize(ExitCodeRegression, fmt.Errorf("average duration %s exceeded the threshold of %s",
+			format.Duration(duration), format.Duration(limit)))
+	}
+
+	if limit := uint64(t.MinTransferRate) * 1024 * 1024; t.MinTransferRate != 0 && transferRate < limit {
+		return Categorize(ExitCodeRegression, fmt.Errorf("average transfer rate %s/s is below the threshold of %s/s",
+			format.Bytes(transferRate), format.Bytes(limit)))
+	}
+
+	return nil
+}
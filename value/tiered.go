@@ -0,0 +1,176 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTieredStorageMountPoint is the default directory tiered devices are mounted at.
+const DefaultTieredStorageMountPoint = "/mnt/tiered"
+
+// tieredVolumeGroup is the LVM volume group created for 'dm-cache' tiered devices.
+const tieredVolumeGroup = "autobench-tiered"
+
+// TieredStorageType is the technology used to build a tiered device, caching a slow device using a fast one.
+type TieredStorageType string
+
+const (
+	// TieredStorageTypeDMCache builds the tiered device using 'dm-cache' via LVM.
+	TieredStorageTypeDMCache TieredStorageType = "dm-cache"
+
+	// TieredStorageTypeBCache builds the tiered device using 'bcache'.
+	TieredStorageTypeBCache TieredStorageType = "bcache"
+)
+
+// CacheMode is the write policy of the cache in a tiered device.
+type CacheMode string
+
+const (
+	// CacheModeWritethrough writes to both the cache and backing device before completing, the default.
+	CacheModeWritethrough CacheMode = "writethrough"
+
+	// CacheModeWriteback completes writes once they're in the cache, they're written to the backing device later.
+	CacheModeWriteback CacheMode = "writeback"
+)
+
+// TieredStorageBlueprint describes a tiered device (e.g. NVMe cache over HDD/EBS) which is built during provisioning,
+// for example so that the archive IO may be benchmarked on tiered storage.
+//
+// NOTE: Both devices are wiped and the tiered device is rebuilt (when it's not already mounted) each time the machine
+// is provisioned, since instance store cache devices are wiped when the machine is stopped.
+type TieredStorageBlueprint struct {
+	// Type is the technology used to build the tiered device, either 'dm-cache' (default) or 'bcache'.
+	Type TieredStorageType `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// CacheDevice is the fast block device used as the cache e.g. '/dev/nvme1n1'.
+	CacheDevice string `json:"cache_device" yaml:"cache_device"`
+
+	// BackingDevice is the slow block device which is cached e.g. '/dev/xvdf'.
+	BackingDevice string `json:"backing_device" yaml:"backing_device"`
+
+	// CacheMode is the write policy of the cache, either 'writethrough' (default) or 'writeback'.
+	CacheMode CacheMode `json:"cache_mode,omitempty" yaml:"cache_mode,omitempty"`
+
+	// MountPoint is the directory the tiered device will be mounted at.
+	MountPoint string `json:"mount_point,omitempty" yaml:"mount_point,omitempty"`
+}
+
+// TypeOrDefault returns the technology used to build the tiered device.
+func (t *TieredStorageBlueprint) TypeOrDefault() TieredStorageType {
+	if t.Type == "" {
+		return TieredStorageTypeDMCache
+	}
+
+	return t.Type
+}
+
+// CacheModeOrDefault returns the write policy of the cache.
+func (t *TieredStorageBlueprint) CacheModeOrDefault() CacheMode {
+	if t.CacheMode == "" {
+		return CacheModeWritethrough
+	}
+
+	return t.CacheMode
+}
+
+// MountPointOrDefault returns the directory the tiered device will be mounted at.
+func (t *TieredStorageBlueprint) MountPointOrDefault() string {
+	if t.MountPoint == "" {
+		return DefaultTieredStorageMountPoint
+	}
+
+	return t.MountPoint
+}
+
+// Contains returns a boolean indicating whether the given path is on the tiered device.
+func (t *TieredStorageBlueprint) Contains(path string) bool {
+	rel, err := filepath.Rel(t.MountPointOrDefault(), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Validate returns an error if the tiered storage blueprint is invalid.
+func (t *TieredStorageBlueprint) Validate() error {
+	if t.CacheDevice == "" || t.BackingDevice == "" {
+		return errors.New("tiered storage requires a 'cache_device' and 'backing_device'")
+	}
+
+	if t.CacheDevice == t.BackingDevice {
+		return errors.New("the tiered storage cache and backing devices must differ")
+	}
+
+	switch t.TypeOrDefault() {
+	case TieredStorageTypeDMCache, TieredStorageTypeBCache:
+	default:
+		return fmt.Errorf("unsupported tiered storage type '%s'", t.Type)
+	}
+
+	switch t.CacheModeOrDefault() {
+	case CacheModeWritethrough, CacheModeWriteback:
+	default:
+		return fmt.Errorf("unsupported cache mode '%s'", t.CacheMode)
+	}
+
+	return nil
+}
+
+// Packages returns the packages required to build the tiered device.
+func (t *TieredStorageBlueprint) Packages() []string {
+	if t.TypeOrDefault() == TieredStorageTypeBCache {
+		return []string{"xfsprogs", "bcache-tools"}
+	}
+
+	return []string{"xfsprogs", "lvm2"}
+}
+
+// CommandPrepare returns a command which builds/formats/mounts the tiered device unless it's already mounted, any
+// previous tiered device using the devices is torn down first.
+func (t *TieredStorageBlueprint) CommandPrepare() Command {
+	var build, device string
+
+	switch t.TypeOrDefault() {
+	case TieredStorageTypeBCache:
+		device = "/dev/bcache0"
+		build = fmt.Sprintf(`for b in /sys/block/bcache*/bcache; do [ -e $b ] && echo 1 > $b/stop; done;
+			for c in /sys/fs/bcache/*-*; do [ -e $c ] && echo 1 > $c/unregister; done; sleep 1;
+			wipefs -a %[1]s %[2]s && make-bcache --wipe-bcache -B %[2]s -C %[1]s &&
+			(echo %[2]s > /sys/fs/bcache/register; echo %[1]s > /sys/fs/bcache/register) 2>/dev/null;
+			for i in $(seq 30); do [ -e %[3]s ] && break; sleep 1; done;
+			echo %[4]s > /sys/block/bcache0/bcache/cache_mode`,
+			t.CacheDevice, t.BackingDevice, device, t.CacheModeOrDefault())
+	default:
+		device = fmt.Sprintf("/dev/%s/archive", tieredVolumeGroup)
+		build = fmt.Sprintf(`vgremove -ff -y %[1]s 2>/dev/null;
+			wipefs -a %[2]s %[3]s && pvcreate -ff -y %[3]s %[2]s && vgcreate %[1]s %[3]s %[2]s &&
+			lvcreate -y -n archive -l 100%%PVS %[1]s %[3]s &&
+			lvcreate -y --type cache-pool -n cache -l 90%%PVS %[1]s %[2]s &&
+			lvconvert -y --type cache --cachepool %[1]s/cache --cachemode %[4]s %[1]s/archive`,
+			tieredVolumeGroup, t.CacheDevice, t.BackingDevice, t.CacheModeOrDefault())
+	}
+
+	return NewCommand(`mountpoint -q %[1]s && exit 0; %[2]s &&
+		mkfs.xfs -f %[3]s && mkdir -p %[1]s && mount -o noatime %[3]s %[1]s && chmod 777 %[1]s`,
+		t.MountPointOrDefault(), build, device)
+}
+
+// String returns a short human readable description of the tiered device which will be displayed in the report.
+func (t *TieredStorageBlueprint) String() string {
+	return fmt.Sprintf("%s (%s) caching %s using %s", t.TypeOrDefault(), t.CacheModeOrDefault(), t.BackingDevice,
+		t.CacheDevice)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// tombstonePrefix is the prefix of the keys of the documents which are created then deleted to leave tombstones,
+// they're kept separate from the dataset so that the dataset is left intact.
+const tombstonePrefix = "tombstone::"
+
+// TombstoneConfig configures tombstones (deleted documents) left between each benchmarked backup and its incremental
+// backup, along with how long they're aged for and whether they're purged, so that how tombstones are handled by
+// incremental backups may be benchmarked deterministically.
+type TombstoneConfig struct {
+	// Items is the number of documents which are created then deleted, leaving a tombstone for each.
+	Items int `json:"items,omitempty" yaml:"items,omitempty"`
+
+	// Elapsed is the number of seconds to wait once the documents have been deleted, simulating the time between
+	// backups; tombstones may only be purged once they're older than the metadata purge interval.
+	Elapsed int `json:"elapsed,omitempty" yaml:"elapsed,omitempty"`
+
+	// Purge compacts the benchmarking bucket once the elapsed time has passed, so that any tombstones older than the
+	// metadata purge interval are purged before the incremental backup (rather than whenever auto-compaction runs).
+	Purge bool `json:"purge,omitempty" yaml:"purge,omitempty"`
+}
+
+// Validate returns an error if the tombstone config is invalid.
+func (t *TombstoneConfig) Validate() error {
+	if t.Items < 1 {
+		return fmt.Errorf("tombstones must delete at least one document")
+	}
+
+	if t.Elapsed < 0 {
+		return fmt.Errorf("tombstone elapsed time must not be negative")
+	}
+
+	return nil
+}
+
+// StatementCreateTombstoneDocuments returns a N1QL statement which creates the documents in the benchmarking bucket
+// which will be deleted to leave tombstones.
+func (t *TombstoneConfig) StatementCreateTombstoneDocuments() string {
+	return fmt.Sprintf("UPSERT INTO `default` (KEY k, VALUE v) SELECT '%s' || TO_STRING(i) AS k, {'tombstone': i} AS v "+
+		"FROM ARRAY_RANGE(0, %d) AS i", tombstonePrefix, t.Items)
+}
+
+// StatementDeleteTombstoneDocuments returns a N1QL statement which deletes the documents created by
+// 'StatementCreateTombstoneDocuments', leaving a tombstone for each.
+func (t *TombstoneConfig) StatementDeleteTombstoneDocuments() string {
+	return fmt.Sprintf("DELETE FROM `default` USE KEYS ARRAY '%s' || TO_STRING(i) FOR i IN ARRAY_RANGE(0, %d) END",
+		tombstonePrefix, t.Items)
+}
+
+// TombstoneResult is the tombstones left before an incremental backup, and how many items that backup contained.
+type TombstoneResult struct {
+	Items   int `json:"tombstones"`
+	Elapsed int `json:"elapsed"`
+
+	// PurgeInterval is the metadata purge interval (in days) of the cluster.
+	PurgeInterval float64 `json:"metadata_purge_interval"`
+
+	// Purged indicates that the bucket was compacted once the tombstones were older than the metadata purge interval, so
+	// they should have been purged before the incremental backup.
+	Purged bool `json:"purged"`
+
+	IncrementalItems uint64 `json:"items_backed_up"`
+	IncrementalSize  uint64 `json:"incremental_size"`
+}
+
+// Tombstones is a wrapper around the tombstones of each benchmark iteration.
+type Tombstones []*TombstoneResult
+
+// Tombstones returns the tombstones of each of the results, nil is returned if tombstones weren't enabled.
+func (b BenchmarkResults) Tombstones() Tombstones {
+	var tombstones Tombstones
+
+	for _, result := range b {
+		if result.Tombstones != nil {
+			tombstones = append(tombstones, result.Tombstones)
+		}
+	}
+
+	return tombstones
+}
+
+// String returns a human readable string representation of the tombstones which will be displayed in the report.
+func (t Tombstones) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Tombstones\n| ----------")
+	fmt.Fprintf(writer, "| Iteration\t Tombstones\t Elapsed\t Purge Interval\t Purged\t Items Backed Up\t "+
+		"Incremental Size\t\n")
+
+	for idx, tombstones := range t {
+		fmt.Fprintf(writer, "| %d\t %d\t %s\t %g days\t %t\t %d\t %s\t\n", idx+1, tombstones.Items,
+			format.Duration(time.Duration(tombstones.Elapsed)*time.Second), tombstones.PurgeInterval, tombstones.Purged,
+			tombstones.IncrementalItems, format.Bytes(tombstones.IncrementalSize))
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprint(buffer, "\nNOTE: Purging tombstones which haven't been backed up may cause the incremental backup to "+
+		"roll back, backing up the entire bucket")
+
+	return strings.TrimSpace(buffer.String())
+}
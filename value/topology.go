@@ -0,0 +1,143 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import "fmt"
+
+// DefaultServerGroup is the server group which nodes are placed in by default when the cluster is initialized.
+const DefaultServerGroup = "Group 1"
+
+// TopologyPreset is a predefined large scale cluster topology, nodes are spread evenly across the server groups so that
+// replicas are placed in different zones/racks.
+type TopologyPreset string
+
+const (
+	// TopologyPreset9 is a nine node cluster spread across three server groups.
+	TopologyPreset9 TopologyPreset = "9-node"
+
+	// TopologyPreset15 is a fifteen node cluster spread across three server groups.
+	TopologyPreset15 TopologyPreset = "15-node"
+
+	// TopologyPreset30 is a thirty node cluster spread across three server groups.
+	TopologyPreset30 TopologyPreset = "30-node"
+)
+
+// topologyPresets maps each preset to the number of nodes/server groups it contains.
+var topologyPresets = map[TopologyPreset]struct{ nodes, groups int }{
+	TopologyPreset9:  {nodes: 9, groups: 3},
+	TopologyPreset15: {nodes: 15, groups: 3},
+	TopologyPreset30: {nodes: 30, groups: 3},
+}
+
+// TopologyBlueprint describes a cluster topology which is expanded into node blueprints, avoiding the need to write an
+// entry for every node in large clusters.
+type TopologyBlueprint struct {
+	// Preset is the topology to use, which determines the number of nodes and server groups.
+	Preset TopologyPreset `yaml:"preset,omitempty"`
+
+	// Hosts are the hosts (or host patterns) for each of the nodes, the number of hosts must match the number of nodes
+	// in the preset.
+	Hosts []string `yaml:"hosts,omitempty"`
+
+	// ServerGroups overrides the number of server groups from the preset.
+	ServerGroups int `yaml:"server_groups,omitempty"`
+
+	// Node is a template which is applied to every node e.g. to set the data path, the host is set from 'Hosts' and the
+	// server group is determined by the topology.
+	Node *NodeBlueprint `yaml:"node,omitempty"`
+}
+
+// Expand returns a node blueprint for each of the hosts, nodes are assigned to the server groups ('zone-1', 'zone-2'
+// etc.) in a round-robin fashion so that each group contains the same number of nodes.
+func (t *TopologyBlueprint) Expand() ([]*NodeBlueprint, error) {
+	preset, ok := topologyPresets[t.Preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown topology preset '%s'", t.Preset)
+	}
+
+	hosts := make([]string, 0, len(t.Hosts))
+
+	for _, pattern := range t.Hosts {
+		expanded, err := ExpandHostPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, expanded...)
+	}
+
+	if len(hosts) != preset.nodes {
+		return nil, fmt.Errorf("topology preset '%s' requires %d hosts but %d were provided", t.Preset, preset.nodes,
+			len(hosts))
+	}
+
+	groups := preset.groups
+	if t.ServerGroups != 0 {
+		groups = t.ServerGroups
+	}
+
+	nodes := make([]*NodeBlueprint, 0, len(hosts))
+
+	for idx, host := range hosts {
+		node := &NodeBlueprint{}
+		if t.Node != nil {
+			copied := *t.Node
+			node = &copied
+		}
+
+		node.Host = host
+		node.ServerGroup = fmt.Sprintf("zone-%d", idx%groups+1)
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// ExpandTopology appends the nodes from the topology (if any) to the cluster nodes.
+func (c *ClusterBlueprint) ExpandTopology() error {
+	if c.Topology == nil {
+		return nil
+	}
+
+	nodes, err := c.Topology.Expand()
+	if err != nil {
+		return err
+	}
+
+	c.Nodes = append(c.Nodes, nodes...)
+	c.Topology = nil
+
+	return nil
+}
+
+// ServerGroups returns the distinct server groups used by the nodes in the cluster, in the order they first appear.
+func (c *ClusterBlueprint) ServerGroups() []string {
+	var (
+		groups = make([]string, 0)
+		seen   = make(map[string]struct{})
+	)
+
+	for _, node := range c.Nodes {
+		if _, ok := seen[node.ServerGroup]; ok || node.ServerGroup == "" {
+			continue
+		}
+
+		seen[node.ServerGroup] = struct{}{}
+		groups = append(groups, node.ServerGroup)
+	}
+
+	return groups
+}
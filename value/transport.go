@@ -0,0 +1,49 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+// TransportType represents how commands are executed on a machine.
+type TransportType string
+
+const (
+	// TransportTypeSSH runs commands on a remote machine using ssh, this is the default.
+	TransportTypeSSH TransportType = "ssh"
+
+	// TransportTypeLocal runs commands on the local machine.
+	TransportTypeLocal TransportType = "local"
+
+	// TransportTypeDocker runs commands in a running container using 'docker exec'.
+	TransportTypeDocker TransportType = "docker"
+
+	// TransportTypeDryRun doesn't run any commands, instead returning scripted responses; this is used by '--dry-run'.
+	TransportTypeDryRun TransportType = "dry-run"
+)
+
+// TransportBlueprint describes how commands should be executed on a node.
+type TransportBlueprint struct {
+	Type TransportType `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Container is the name/id of the container to run commands in when using the docker transport.
+	Container string `json:"container,omitempty" yaml:"container,omitempty"`
+}
+
+// TypeOrDefault returns the transport type, falling back to ssh if one wasn't provided.
+func (t *TransportBlueprint) TypeOrDefault() TransportType {
+	if t == nil || t.Type == "" {
+		return TransportTypeSSH
+	}
+
+	return t.Type
+}
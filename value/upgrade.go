@@ -0,0 +1,139 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/couchbase/tools-common/strings/format"
+)
+
+// UpgradeConfig describes the packages which the cluster/backup client will be upgraded to by the 'upgrade'
+// benchmark, the packages from the blueprint are the versions being upgraded from.
+type UpgradeConfig struct {
+	// ClusterPackagePath is the path to the package the cluster nodes will be upgraded to, when empty the cluster will
+	// not be upgraded.
+	ClusterPackagePath string `json:"cluster_package_path,omitempty" yaml:"cluster_package_path,omitempty"`
+
+	// BackupClientPackagePath is the path to the package the backup client will be upgraded to, when empty the backup
+	// client will not be upgraded.
+	BackupClientPackagePath string `json:"backup_client_package_path,omitempty" yaml:"backup_client_package_path,omitempty"` //nolint:lll
+}
+
+// Validate returns an error if the upgrade config is missing, or the packages can't be used to upgrade in-place.
+func (u *UpgradeConfig) Validate() error {
+	if u == nil || (u.ClusterPackagePath == "" && u.BackupClientPackagePath == "") {
+		return fmt.Errorf("a cluster and/or backup client package to upgrade to must be provided")
+	}
+
+	for _, path := range []string{u.ClusterPackagePath, u.BackupClientPackagePath} {
+		if path == "" {
+			continue
+		}
+
+		pkg := NewPackage(path, "", "")
+		if pkg.Type != PackageTypeDEB && pkg.Type != PackageTypeRPM {
+			return fmt.Errorf("package '%s' can't be used to upgrade, only 'deb'/'rpm' packages are supported", path)
+		}
+	}
+
+	return nil
+}
+
+// UpgradeStep is the result of a single step in the upgrade benchmark e.g. the incremental backup after the upgrade.
+type UpgradeStep struct {
+	// Name describes the step e.g. 'restore (after upgrade)'.
+	Name string `json:"name"`
+
+	// Result is the timing/size of the step, only populated when the step succeeded.
+	Result *BenchmarkResult `json:"result,omitempty"`
+
+	// Error is the reason the step failed, which indicates that the step isn't compatible across the upgrade.
+	Error string `json:"error,omitempty"`
+}
+
+// Compatible returns a boolean indicating whether the step completed successfully.
+func (u *UpgradeStep) Compatible() bool {
+	return u.Error == ""
+}
+
+// UpgradeResult encapsulates the versions that were upgraded from/to and the result of each step of the upgrade
+// benchmark.
+type UpgradeResult struct {
+	ClusterFrom string         `json:"cluster_from"`
+	ClusterTo   string         `json:"cluster_to"`
+	ClientFrom  string         `json:"backup_client_from"`
+	ClientTo    string         `json:"backup_client_to"`
+	Steps       []*UpgradeStep `json:"steps"`
+}
+
+// NewUpgradeResult returns an upgrade result populated with the versions being upgraded from/to.
+func NewUpgradeResult(cluster *ClusterBlueprint, client *BackupClientBlueprint, config *UpgradeConfig,
+) *UpgradeResult {
+	result := &UpgradeResult{
+		ClusterFrom: extractBuild(cluster.PackagePath),
+		ClusterTo:   extractBuild(cluster.PackagePath),
+		ClientFrom:  extractBuild(client.PackagePath),
+		ClientTo:    extractBuild(client.PackagePath),
+	}
+
+	if config.ClusterPackagePath != "" {
+		result.ClusterTo = extractBuild(config.ClusterPackagePath)
+	}
+
+	if config.BackupClientPackagePath != "" {
+		result.ClientTo = extractBuild(config.BackupClientPackagePath)
+	}
+
+	return result
+}
+
+// String returns a human readable string representation of the upgrade result which will be displayed in the report.
+func (u *UpgradeResult) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	fmt.Fprintln(buffer, "| Upgrade\n| -------")
+	fmt.Fprintf(writer, "| Component\t From\t To\t\n")
+	fmt.Fprintf(writer, "| Cluster\t %s\t %s\t\n", u.ClusterFrom, u.ClusterTo)
+	fmt.Fprintf(writer, "| Backup Client\t %s\t %s\t\n", u.ClientFrom, u.ClientTo)
+
+	_ = writer.Flush()
+
+	fmt.Fprintln(buffer)
+	fmt.Fprintf(writer, "| Step\t Duration\t Size (ADS)\t Transfer Rate (ADS)\t Compatible\t\n")
+
+	for _, step := range u.Steps {
+		if !step.Compatible() {
+			fmt.Fprintf(writer, "| %s\t -\t -\t -\t false (%s)\t\n", step.Name, step.Error)
+			continue
+		}
+
+		fmt.Fprintf(writer, "| %s\t %s\t %s\t %s/s\t true\t\n",
+			step.Name,
+			format.Duration(step.Result.Duration),
+			format.Bytes(step.Result.ADS),
+			format.Bytes(step.Result.AvgTransferRateADS()))
+	}
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Version is the release version of Couchbase Server (and therefore the tools shipped with it) e.g. 7.0.0.
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// ParseVersion extracts the release version from the given build identifier/package name e.g.
+// 'couchbase-server-enterprise_7.0.0-4259-ubuntu20.04_amd64.deb', nil is returned when it can't be determined.
+func ParseVersion(s string) *Version {
+	match := regexp.MustCompile(RegexBuildID).FindStringSubmatch(s)
+	if match == nil {
+		return nil
+	}
+
+	var version Version
+
+	_, err := fmt.Sscanf(match[1], "%d.%d.%d", &version.Major, &version.Minor, &version.Patch)
+	if err != nil {
+		return nil
+	}
+
+	return &version
+}
+
+// ReleaseVersion returns the release version contained in the package, determined using the package name; nil is
+// returned when it can't be determined.
+func (p *Package) ReleaseVersion() *Version {
+	return ParseVersion(LocalBase(p.Path))
+}
+
+// Less returns a boolean indicating whether this version is older than the given version.
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+
+	return v.Patch < other.Patch
+}
+
+// String returns the version in the form 'major.minor.patch'.
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Supports returns a boolean indicating whether the given feature is available in this version.
+//
+// NOTE: When the version is unknown (nil) we assume the user knows what they're doing, the tool will fail otherwise.
+func (v *Version) Supports(feature Feature) bool {
+	return v == nil || !v.Less(feature.Since)
+}
+
+// Feature is a feature of Couchbase Server/'cbbackupmgr' which is only available from a given version.
+type Feature struct {
+	Name  string
+	Since Version
+}
+
+// The features which are used conditionally, depending on the config, and the version they're first available in.
+var (
+	FeatureCloudBackups      = Feature{Name: "cloud backups", Since: Version{Major: 6, Minor: 6}}
+	FeatureEncryptedBackups  = Feature{Name: "encrypted backups", Since: Version{Major: 7}}
+	FeaturePointInTime       = Feature{Name: "point-in-time backups", Since: Version{Major: 7}}
+	FeatureClientCertAuth    = Feature{Name: "client certificate authentication", Since: Version{Major: 7}}
+	FeatureAutoSelectThreads = Feature{Name: "automatic thread selection", Since: Version{Major: 7}}
+	FeatureMagma             = Feature{Name: "the magma storage backend", Since: Version{Major: 7, Minor: 1}}
+	FeatureBucketPiTR        = Feature{Name: "point-in-time buckets", Since: Version{Major: 7}}
+	FeatureBackupService     = Feature{Name: "the backup service", Since: Version{Major: 7}}
+)
+
+// validateFeatures returns an error listing the given features which aren't available in the given version of the
+// tool, the package is used to describe where the version came from.
+func validateFeatures(version *Version, tool, pkg string, features []Feature) error {
+	unsupported := make([]string, 0)
+
+	for _, feature := range features {
+		if !version.Supports(feature) {
+			unsupported = append(unsupported, fmt.Sprintf("%s (since %s)", feature.Name, feature.Since))
+		}
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the %s package is version %s of %s, which doesn't support %s", pkg, version, tool,
+		strings.Join(unsupported, ", "))
+}
+
+// versionedFeatures returns the features used by the config which are only available in some versions of
+// 'cbbackupmgr'.
+func (c *CBMConfig) versionedFeatures() []Feature {
+	features := make([]Feature, 0)
+
+	if c.ObjStagingDirectory != "" {
+		features = append(features, FeatureCloudBackups)
+	}
+
+	if c.Encrypted {
+		features = append(features, FeatureEncryptedBackups)
+	}
+
+	if c.PiTR {
+		features = append(features, FeaturePointInTime)
+	}
+
+	if c.ClientCert != nil {
+		features = append(features, FeatureClientCertAuth)
+	}
+
+	return features
+}
+
+// ValidateVersion returns an error if the config uses features which aren't available in the given version of
+// 'cbbackupmgr', the package describes where the version came from e.g. 'backup client'.
+func (c *CBMConfig) ValidateVersion(version *Version, pkg string) error {
+	return validateFeatures(version, "'cbbackupmgr'", pkg, c.versionedFeatures())
+}
+
+// ForVersion returns a copy of the config whose commands are built for the given version of 'cbbackupmgr', flags which
+// aren't supported by the version are omitted where 'cbbackupmgr' has an equivalent default.
+func (c *CBMConfig) ForVersion(version *Version) *CBMConfig {
+	copied := *c
+	copied.version = version
+
+	return &copied
+}
+
+// versionedFeatures returns the features used by the blueprint which are only available in some versions of Couchbase
+// Server.
+func (c *ClusterBlueprint) versionedFeatures() []Feature {
+	features := make([]Feature, 0)
+
+	for _, bucket := range append([]*BucketBlueprint{c.Bucket}, c.Buckets...) {
+		if bucket.StorageBackend == "magma" {
+			features = append(features, FeatureMagma)
+			break
+		}
+	}
+
+	if c.Bucket.PiTREnabled {
+		features = append(features, FeatureBucketPiTR)
+	}
+
+	for i, node := range c.Nodes {
+		if node.ServicesOrDefault(i == 0).Has(ServiceBackup) {
+			features = append(features, FeatureBackupService)
+			break
+		}
+	}
+
+	return features
+}
+
+// ValidateVersion returns an error if the blueprint uses features which aren't available in the given version of
+// Couchbase Server.
+func (c *ClusterBlueprint) ValidateVersion(version *Version) error {
+	return validateFeatures(version, "Couchbase Server", "cluster", c.versionedFeatures())
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultWebhookTimeout is the default number of seconds to wait for the webhook to respond to each event.
+const DefaultWebhookTimeout = 10
+
+// WebhookEvent is a lifecycle event of a run which is posted to the webhook.
+type WebhookEvent string
+
+const (
+	// WebhookEventRunStarted is posted once the config has been read, before connecting to any machines.
+	WebhookEventRunStarted WebhookEvent = "run.started"
+
+	// WebhookEventPhaseCompleted is posted each time a phase completes (or fails) for an environment.
+	WebhookEventPhaseCompleted WebhookEvent = "phase.completed"
+
+	// WebhookEventRunFinished is posted once the sub-command completes, along with a summary of the run.
+	WebhookEventRunFinished WebhookEvent = "run.finished"
+)
+
+// WebhookEvents are the supported lifecycle events, by default all of them are posted.
+var WebhookEvents = []WebhookEvent{WebhookEventRunStarted, WebhookEventPhaseCompleted, WebhookEventRunFinished}
+
+// WebhookConfig enables posting lifecycle events to a URL, allowing external orchestration (e.g. Jenkins) to chain
+// downstream jobs without polling the run directory.
+type WebhookConfig struct {
+	// URL is the endpoint which each event is posted to (as JSON).
+	URL string `yaml:"url,omitempty"`
+
+	// Token is sent as a bearer token in the 'Authorization' header (optional).
+	Token string `yaml:"token,omitempty"`
+
+	// Events are the lifecycle events which are posted, by default all of them are posted.
+	Events []WebhookEvent `yaml:"events,omitempty"`
+
+	// Timeout is the number of seconds to wait for the webhook to respond to each event.
+	Timeout int `yaml:"timeout,omitempty"`
+}
+
+// Validate returns an error if the webhook config is invalid.
+func (w *WebhookConfig) Validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook url '%s' must be an absolute http(s) url", w.URL)
+	}
+
+	for _, event := range w.Events {
+		if !containsEvent(WebhookEvents, event) {
+			return fmt.Errorf("unsupported webhook event '%s', expected one of %v", event, WebhookEvents)
+		}
+	}
+
+	if w.Timeout < 0 {
+		return fmt.Errorf("webhook timeout must not be negative")
+	}
+
+	return nil
+}
+
+// Enabled returns a boolean indicating whether the given event should be posted to the webhook.
+func (w *WebhookConfig) Enabled(event WebhookEvent) bool {
+	return len(w.Events) == 0 || containsEvent(w.Events, event)
+}
+
+// TimeoutOrDefault returns the timeout for each event, or the default if none was provided.
+func (w *WebhookConfig) TimeoutOrDefault() time.Duration {
+	if w.Timeout == 0 {
+		return DefaultWebhookTimeout * time.Second
+	}
+
+	return time.Duration(w.Timeout) * time.Second
+}
+
+// containsEvent returns a boolean indicating whether the given event is in the list of events.
+func containsEvent(events []WebhookEvent, event WebhookEvent) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookPayload is the JSON body posted to the webhook for each event, like the machine readable report the field
+// names are stable so that external orchestration may parse them.
+type WebhookPayload struct {
+	Event   WebhookEvent `json:"event"`
+	RunID   RunID        `json:"run_id"`
+	Command string       `json:"command"`
+	Time    time.Time    `json:"time"`
+	DryRun  bool         `json:"dry_run,omitempty"`
+	CI      *CIMetadata  `json:"ci,omitempty"`
+
+	// Phase is the phase which completed, only set for 'phase.completed' events.
+	Phase *PhaseTiming `json:"phase,omitempty"`
+
+	// Summary is the outcome of the run, only set for 'run.finished' events.
+	Summary *RunSummary `json:"summary,omitempty"`
+}
+
+// RunSummary is the outcome of a run, posted to the webhook once the sub-command completes.
+type RunSummary struct {
+	Status   RunStatus     `json:"status"`
+	ExitCode ExitCode      `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Phases   PhaseTimings  `json:"phases,omitempty"`
+
+	// Reports are the machine readable reports produced by any benchmarks, see 'BenchmarkReport'.
+	Reports []*BenchmarkReport `json:"reports,omitempty"`
+}
+
+// NewRunSummary returns the summary of a run which completed with the given error (or nil), the status of a failed run
+// is taken from the first report which didn't succeed e.g. so that crashes are distinguished from other failures.
+func NewRunSummary(err error, duration time.Duration, phases PhaseTimings, reports []*BenchmarkReport) *RunSummary {
+	summary := &RunSummary{
+		Status:   RunStatusSuccess,
+		ExitCode: ExitCodeOf(err),
+		Duration: duration,
+		Phases:   phases,
+		Reports:  reports,
+	}
+
+	if err == nil {
+		return summary
+	}
+
+	summary.Status, summary.Error = RunStatusFailed, errors.Cause(err).Error()
+
+	for _, report := range reports {
+		if report.Status != "" && report.Status != RunStatusSuccess {
+			summary.Status = report.Status
+			break
+		}
+	}
+
+	return summary
+}
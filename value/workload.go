@@ -0,0 +1,115 @@
+// Copyright 2021 Couchbase Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultLiveWorkloadPhase is the default number of seconds the live workload will be run before/after each benchmark
+// to capture the baseline latencies.
+const DefaultLiveWorkloadPhase = 30
+
+// LiveWorkloadConfig configures a 'cbc-pillowfight' workload which is run against the cluster whilst benchmarking
+// backups, this allows measuring the impact that backups have on front-end latency.
+type LiveWorkloadConfig struct {
+	// Items is the number of documents the workload will operate on, a zero value uses the 'cbc-pillowfight' default.
+	Items int `json:"items,omitempty" yaml:"items,omitempty"`
+
+	// SetPercentage is the percentage of operations which are mutations, the remainder are reads.
+	SetPercentage int `json:"set_percentage,omitempty" yaml:"set_percentage,omitempty"`
+
+	// Rate is the maximum number of operations per second (per thread), a zero value means there's no limit.
+	Rate int `json:"rate,omitempty" yaml:"rate,omitempty"`
+
+	// Threads is the number of 'cbc-pillowfight' threads, a zero value uses the 'cbc-pillowfight' default.
+	Threads int `json:"threads,omitempty" yaml:"threads,omitempty"`
+
+	// Phase is the number of seconds the workload is run before/after the backup to capture the baseline latencies.
+	Phase int `json:"phase,omitempty" yaml:"phase,omitempty"`
+}
+
+// PhaseDuration returns how long the workload should be run before/after each backup.
+func (l *LiveWorkloadConfig) PhaseDuration() time.Duration {
+	if l.Phase == 0 {
+		return DefaultLiveWorkloadPhase * time.Second
+	}
+
+	return time.Duration(l.Phase) * time.Second
+}
+
+// String returns a string representation of the live workload config which will be output in the report.
+func (l *LiveWorkloadConfig) String() string {
+	var (
+		buffer = &bytes.Buffer{}
+		writer = tabwriter.NewWriter(buffer, 4, 0, 1, ' ', tabwriter.Debug)
+	)
+
+	stringify := func(v int, def string) string {
+		if v == 0 {
+			return def
+		}
+
+		return strconv.Itoa(v)
+	}
+
+	fmt.Fprintln(buffer, "| Live Workload\n| -------------")
+	fmt.Fprintf(writer, "| Items\t Set Percentage\t Rate\t Threads\t Phase\t\n")
+	fmt.Fprintf(writer, "| %s\t %d%%\t %s\t %s\t %s\t\n",
+		stringify(l.Items, "default"),
+		l.SetPercentage,
+		stringify(l.Rate, "unlimited"),
+		stringify(l.Threads, "default"),
+		l.PhaseDuration())
+
+	_ = writer.Flush()
+
+	return strings.TrimSpace(buffer.String())
+}
+
+// CommandStart returns a command which will start the workload in the background against the given data service
+// address, the output (including the latency histogram) is written to the given output file.
+func (l *LiveWorkloadConfig) CommandStart(kv string, credentials *Credentials, output, pidFile string) Command {
+	command := fmt.Sprintf(`cbc-pillowfight -U couchbase://%s=mcd -u %s -P %s --timings -r %d`,
+		kv, credentials.QuotedUsername(), credentials.QuotedPassword(), l.SetPercentage)
+
+	if l.Items != 0 {
+		command += fmt.Sprintf(" -I %d", l.Items)
+	}
+
+	if l.Rate != 0 {
+		command += fmt.Sprintf(" --rate-limit %d", l.Rate)
+	}
+
+	if l.Threads != 0 {
+		command += fmt.Sprintf(" --num-threads %d", l.Threads)
+	}
+
+	return NewCommand(`nohup %s < /dev/null > %s 2>&1 & echo $! > %s`, command, output, pidFile)
+}
+
+// CommandStopWorkload returns a command which will stop the workload started using the given pid file, waiting until
+// it has exited.
+//
+// NOTE: 'cbc-pillowfight' dumps the latency histogram when interrupted.
+func CommandStopWorkload(pidFile string) Command {
+	return NewCommand(`PID=$(cat %s); kill -INT $PID; while kill -0 $PID 2>/dev/null; do sleep 1; done; rm %s`,
+		pidFile, pidFile)
+}